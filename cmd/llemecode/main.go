@@ -2,31 +2,59 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/LaPingvino/llemecode/internal/acp"
 	"github.com/LaPingvino/llemecode/internal/benchmark"
 	"github.com/LaPingvino/llemecode/internal/cli"
 	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/database"
+	"github.com/LaPingvino/llemecode/internal/i18n"
 	"github.com/LaPingvino/llemecode/internal/logger"
 	"github.com/LaPingvino/llemecode/internal/mcp"
+	"github.com/LaPingvino/llemecode/internal/notes"
 	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/sshtunnel"
 	"github.com/LaPingvino/llemecode/internal/tools"
+	"github.com/LaPingvino/llemecode/internal/version"
 	"github.com/spf13/pflag"
+	"golang.org/x/term"
 )
 
 var (
 	modelFlag      = pflag.StringP("model", "m", "", "Override the default model")
-	benchmarkFlag  = pflag.BoolP("benchmark", "b", false, "Run benchmarks and update configuration")
+	urlFlag        = pflag.StringP("url", "u", "", "Ollama server URL (e.g. http://gpu-box:11434)")
+	saveFlag       = pflag.Bool("save", false, "Save --model and --url to the config file instead of using them for this run only")
+	benchmarkFlag  = pflag.BoolP("benchmark", "b", false, "Benchmark models added or changed since the last run and update configuration")
+	allFlag        = pflag.Bool("all", false, "With --benchmark, re-benchmark every model")
+	modelsFlag     = pflag.StringSlice("models", nil, "With --benchmark, re-benchmark only these models: names or patterns like qwen*, comma-separated")
 	listModelsFlag = pflag.BoolP("list", "l", false, "List available models and their capabilities")
 	setupFlag      = pflag.BoolP("setup", "s", false, "Force re-run first-time setup")
 	evaluatorModel = pflag.String("evaluator", "", "Model to use for evaluating benchmark results")
 	acpFlag        = pflag.Bool("acp", false, "Run in ACP (Anthropic Computer Protocol) server mode")
+	serveFlag      = pflag.String("serve", "", "Serve the agent over WebSocket on this address for browser front-ends (e.g. 127.0.0.1:7878)")
+	plainFlag      = pflag.Bool("plain", false, "Plain line-by-line chat without the full-screen interface (screen readers, dumb terminals, CI logs)")
+	headlessFlag   = pflag.Bool("headless", false, "Read messages from stdin and print replies and progress as line-delimited JSON, for scripts")
 	helpFlag       = pflag.BoolP("help", "h", false, "Show help message")
+	versionFlag    = pflag.Bool("version", false, "Show version and build information")
 	logToFile      = pflag.String("log-to-file", "", "Log debug output and conversation to file")
+	htmlFlag       = pflag.Bool("html", false, "With bench report, print the report as an HTML page")
+	jsonFlag       = pflag.Bool("json", false, "With --list or bench report, print machine-readable JSON")
+	profileFlag    = pflag.StringP("profile", "p", "", "Use a named profile with its own config, permissions and sessions (default $LLEMECODE_PROFILE)")
+	rootFlag       = pflag.StringArray("root", nil, "Add a named project root, e.g. --root api=../backend (repeatable)")
+	writeFlag      = pflag.Bool("write", false, "With integrate, write the configuration into the editor's config directory")
+	linesFlag      = pflag.Int("lines", 200, "With report-bug, how many lines from the end of the --log-to-file log to include")
+	replayTools    = pflag.String("tools", "recorded", "With replay, answer tool calls with the recorded results (recorded) or run only read-only tools (dry-run)")
+	sortFlag       = pflag.String("sort", "score", "With bench report, the column to sort by (rank, model, score, latency, format, strengths)")
 )
 
 func main() {
@@ -36,11 +64,245 @@ func main() {
 		printHelp()
 		os.Exit(0)
 	}
+	if *versionFlag {
+		fmt.Println(version.Get())
+		os.Exit(0)
+	}
 
-	if err := run(); err != nil {
+	profile := *profileFlag
+	if profile == "" {
+		profile = os.Getenv("LLEMECODE_PROFILE")
+	}
+	if err := config.SetProfile(profile); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+
+	if args := pflag.Args(); len(args) > 0 {
+		if err := runSubcommand(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := run(""); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runSubcommand handles commands that don't start a chat
+func runSubcommand(args []string) error {
+	if args[0] == "doctor" {
+		return cli.RunDoctor(context.Background(), os.Stdout, *urlFlag, newOllamaClient)
+	}
+	if args[0] == "db" {
+		return runDatabaseCommand(args[1:])
+	}
+	if args[0] == "integrate" {
+		return runIntegrateCommand(args[1:])
+	}
+	if args[0] == "profiles" {
+		return listProfiles()
+	}
+	if args[0] == "report-bug" {
+		return runBugReportCommand(args[1:])
+	}
+	if args[0] == "replay" {
+		return runReplayCommand(args[1:])
+	}
+	if args[0] == "new" {
+		return runNewCommand(args[1:])
+	}
+	if len(args) < 2 || args[0] != "bench" || args[1] != "report" {
+		return fmt.Errorf("unknown command %q (try llemecode bench report, llemecode db, llemecode doctor, llemecode integrate, llemecode new, llemecode profiles, llemecode replay or llemecode report-bug)", strings.Join(args, " "))
+	}
+
+	scores, _, err := benchmark.LoadLatestResults()
+	if err != nil {
+		return err
+	}
+	if err := benchmark.SortScores(scores, *sortFlag); err != nil {
+		return err
+	}
+
+	if *htmlFlag && *jsonFlag {
+		return fmt.Errorf("--html and --json can't be combined")
+	}
+	if *jsonFlag {
+		return printJSON(scores)
+	}
+	if *htmlFlag {
+		page, err := benchmark.HTMLReport(scores)
+		if err != nil {
+			return err
+		}
+		fmt.Print(page)
+		return nil
+	}
+	fmt.Print(benchmark.TextReport(scores))
+	return nil
+}
+
+// runDatabaseCommand stores or removes the password of a database profile
+// in the system keyring
+func runDatabaseCommand(args []string) error {
+	if len(args) != 2 || (args[0] != "password" && args[0] != "forget") {
+		return fmt.Errorf("usage: llemecode db password <profile> | llemecode db forget <profile>")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	name := args[1]
+	if _, ok := cfg.Databases[name]; !ok {
+		return fmt.Errorf("no database profile %q in the config", name)
+	}
+	if args[0] == "forget" {
+		if err := database.DeletePassword(name); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Removed the password for %s\n", name)
+		return nil
+	}
+
+	fmt.Printf("Password for %s: ", name)
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return fmt.Errorf("read password: %w", err)
+	}
+	if err := database.SetPassword(name, string(password)); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Stored the password for %s in the system keyring\n", name)
+	return nil
+}
+
+// runBugReportCommand bundles what a bug report needs into a local tarball
+func runBugReportCommand(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: llemecode report-bug [session-id] [--log-to-file <log>] [--lines N]")
+	}
+	report := cli.BugReport{LogFile: *logToFile, LogLines: *linesFlag, Dir: "."}
+	if len(args) == 1 {
+		report.SessionID = args[0]
+	}
+	if report.LogLines < 1 {
+		return fmt.Errorf("--lines must be at least 1")
+	}
+	return cli.RunBugReport(context.Background(), os.Stdout, report, *urlFlag, newOllamaClient)
+}
+
+// runReplayCommand replays a stored session against the --model model
+func runReplayCommand(args []string) error {
+	if len(args) != 1 || *modelFlag == "" {
+		return fmt.Errorf("usage: llemecode replay <session-id> --model <model> [--tools recorded|dry-run]")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger.SetQuiet(true)
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if *urlFlag != "" {
+		cfg.OverrideOllamaURL(*urlFlag)
+	} else if cfg.SSHTunnel != nil {
+		tunnel, err := sshtunnel.Open(ctx, *cfg.SSHTunnel)
+		if err != nil {
+			return err
+		}
+		defer tunnel.Close()
+		cfg.OverrideOllamaURL(tunnel.URL())
+	}
+	client := newOllamaClient(cfg)
+	policy, err := config.LoadPolicy()
+	if err != nil {
+		return err
+	}
+	toolRegistry, _, _, mcpRegistry := setupTools(ctx, client, cfg, policy, true)
+	defer mcpRegistry.Close()
+
+	return cli.RunReplay(ctx, os.Stdout, os.Stderr, client, cfg, toolRegistry, args[0], *modelFlag, *replayTools)
+}
+
+// runNewCommand creates a project from a template and opens a chat in it
+// with the task of customizing it in the input
+func runNewCommand(args []string) error {
+	if len(args) > 2 {
+		return fmt.Errorf("usage: llemecode new [<template> [dir]]")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if len(args) == 0 {
+		cli.ListTemplates(os.Stdout, cfg)
+		return nil
+	}
+	dir := ""
+	if len(args) == 2 {
+		dir = args[1]
+	}
+	dir, task, err := cli.NewProject(context.Background(), os.Stdout, cfg, args[0], dir)
+	if err != nil {
+		return err
+	}
+	// The chat, its workspace jail and project memories belong to the new project
+	if err := os.Chdir(dir); err != nil {
+		return fmt.Errorf("enter %s: %w", dir, err)
+	}
+	return run(task)
+}
+
+// listProfiles prints the profiles in use, marking the current one
+func listProfiles() error {
+	names, err := config.ListProfiles()
+	if err != nil {
+		return err
+	}
+	current := config.Profile()
+	for _, name := range append([]string{"default"}, names...) {
+		mark := " "
+		if name == current || (current == "" && name == "default") {
+			mark = "*"
+		}
+		fmt.Printf("%s %s\n", mark, name)
+	}
+	return nil
+}
+
+// runIntegrateCommand sets up an editor to start this binary as its ACP
+// agent, passing on --url, --model, the profile and the interface language
+func runIntegrateCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: llemecode integrate zed|nvim [--write]")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("find the llemecode binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	in := cli.Integration{Command: exe, Args: []string{"--acp"}, Env: map[string]string{}, Write: *writeFlag}
+	if *urlFlag != "" {
+		in.Args = append(in.Args, "--url", *urlFlag)
+	}
+	if *modelFlag != "" {
+		in.Args = append(in.Args, "--model", *modelFlag)
+	}
+	if profile := config.Profile(); profile != "" {
+		in.Args = append(in.Args, "--profile", profile)
+	}
+	if lang := os.Getenv("LLEMECODE_LANG"); lang != "" {
+		in.Env["LLEMECODE_LANG"] = lang
+	}
+	return cli.RunIntegrate(context.Background(), os.Stdout, args[0], in)
 }
 
 func printHelp() {
@@ -55,26 +317,62 @@ func printHelp() {
 	fmt.Println("Examples:")
 	fmt.Println("  llemecode                          # Start chat with default model")
 	fmt.Println("  llemecode -m llama3.2              # Use specific model")
-	fmt.Println("  llemecode -b                       # Re-run benchmarks")
+	fmt.Println("  llemecode -u http://gpu-box:11434  # Use a remote Ollama server")
+	fmt.Println("  llemecode -m qwen3 --save          # Make qwen3 the saved default")
+	fmt.Println("  llemecode -b                       # Benchmark new or changed models")
+	fmt.Println("  llemecode -b --all                 # Re-benchmark every model")
+	fmt.Println("  llemecode -b --models 'qwen*'      # Re-benchmark only matching models")
 	fmt.Println("  llemecode -s                       # Re-run first-time setup")
 	fmt.Println("  llemecode -l                       # List available models")
+	fmt.Println("  llemecode -l --json                # Models and capabilities as JSON")
+	fmt.Println("  llemecode --plain                  # Line-by-line chat for screen readers")
+	fmt.Println("  llemecode --headless < tasks.txt   # Scripted use, JSON events on stdout")
+	fmt.Println("  llemecode --profile work           # Separate config, permissions and sessions")
+	fmt.Println("  llemecode report-bug --log-to-file debug.log  # Bundle details for an issue")
+	fmt.Println("  llemecode replay <session> -m qwen3  # Compare another model on a past session")
 	fmt.Println("  llemecode -b --evaluator gpt-oss   # Benchmark with AI evaluation")
+	fmt.Println("  llemecode bench report             # Show the last benchmark results")
+	fmt.Println("  llemecode bench report --html > report.html  # Shareable HTML report")
+	fmt.Println("  llemecode bench report --json     # Benchmark results as JSON")
+	fmt.Println("  llemecode doctor                   # Diagnose Ollama, config and MCP problems")
+	fmt.Println("  llemecode db password prod         # Store a database profile's password in the keyring")
+	fmt.Println("  llemecode integrate zed --write    # Add llemecode as an agent in Zed (or nvim)")
+	fmt.Println("  llemecode new go-cli myapp         # Start a project from a template and customize it in a chat")
 }
 
-func run() error {
+// run starts the chat, or another front-end chosen by the flags. A non-empty
+// draft is the first message, ready in the chat's input.
+func run(draft string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// MCP servers get their own context so they are stopped by the shutdown
+	// sequence after the agent, rather than killed the moment we're interrupted
+	mcpCtx, cancelMCP := context.WithCancel(context.Background())
+
+	shutdown := &shutdownSequence{cancelAgent: cancel, cancelMCP: cancelMCP}
+	defer shutdown.Run(shutdownTimeout)
+
+	// Dumb terminals can't draw the full-screen interface; headless mode
+	// has no terminal at all and keeps stdout for its JSON events
+	plain := *plainFlag || *headlessFlag || os.Getenv("TERM") == "dumb"
+	if plain || *jsonFlag {
+		logger.SetQuiet(true)
+	}
+	var status io.Writer = os.Stdout
+	if *headlessFlag {
+		status = os.Stderr
+	}
+
 	// Initialize logger if requested
 	if *logToFile != "" {
 		if err := logger.Init(*logToFile); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: Failed to initialize logging: %v\n", err)
 		} else {
-			defer logger.Close()
+			shutdown.closeLogger = true
 			logger.Log("Llemecode starting with logging enabled")
 		}
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
 	// Handle signals
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
@@ -82,6 +380,15 @@ func run() error {
 		<-sigCh
 		logger.Log("Received interrupt signal")
 		cancel()
+
+		// A second signal, or a shutdown that never finishes, forces exit
+		select {
+		case <-sigCh:
+			fmt.Fprintln(os.Stderr, "Received second interrupt, exiting immediately")
+		case <-time.After(2 * shutdownTimeout):
+			fmt.Fprintln(os.Stderr, "Shutdown did not complete, exiting")
+		}
+		os.Exit(1)
 	}()
 
 	// Load or create config
@@ -89,13 +396,42 @@ func run() error {
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
+	i18n.SetLanguage(cfg.Language)
+	if *urlFlag != "" {
+		cfg.OverrideOllamaURL(*urlFlag)
+	} else if cfg.SSHTunnel != nil {
+		// Point this run at the local end of the tunnel; the saved URL is untouched
+		if !*acpFlag && !*jsonFlag && !*headlessFlag {
+			fmt.Printf("🔐 Opening SSH tunnel to %s...\n", cfg.SSHTunnel.Host)
+		}
+		tunnel, err := sshtunnel.Open(ctx, *cfg.SSHTunnel)
+		if err != nil {
+			return err
+		}
+		shutdown.tunnel = tunnel
+		cfg.OverrideOllamaURL(tunnel.URL())
+		logger.Log("SSH tunnel to %s listening at %s", cfg.SSHTunnel.Host, tunnel.URL())
+	}
 
 	// Create Ollama client
-	client := ollama.NewClient(cfg.OllamaURL)
+	client := newOllamaClient(cfg)
+
+	// Only trigger setup if there's NO default model
+	// Model capabilities can be populated later by background benchmarking
+	needsSetup := cfg.DefaultModel == ""
+
+	// Guided setup on first run or --setup, unless the run is non-interactive
+	if (needsSetup || *setupFlag) && !plain && !*acpFlag && !*benchmarkFlag && !*listModelsFlag && *modelFlag == "" {
+		editURL := *urlFlag == "" && cfg.SSHTunnel == nil && len(cfg.Endpoints) == 0
+		if err := cli.RunSetupWizard(ctx, client, cfg, editURL); err != nil {
+			return fmt.Errorf("setup failed: %w", err)
+		}
+		client = newOllamaClient(cfg)
+	}
 
 	// Check if Ollama is available
-	if !client.IsAvailable(ctx) {
-		return fmt.Errorf("Ollama is not available at %s. Please ensure Ollama is running", cfg.OllamaURL)
+	if err := checkEndpoints(ctx, client, *acpFlag); err != nil {
+		return err
 	}
 
 	// Handle list models flag
@@ -104,10 +440,6 @@ func run() error {
 	}
 
 	// Handle setup/benchmark flags
-	// Only trigger setup if there's NO default model
-	// Model capabilities can be populated later by background benchmarking
-	needsSetup := cfg.DefaultModel == ""
-
 	if *setupFlag || *benchmarkFlag {
 		// Explicit setup/benchmark request - use traditional flow
 		if needsSetup {
@@ -120,15 +452,7 @@ func run() error {
 		}
 		fmt.Println()
 
-		// If evaluator model specified, use it
-		if *evaluatorModel != "" {
-			cfg.DefaultModel = *evaluatorModel
-			if err := cfg.Save(); err != nil {
-				return fmt.Errorf("save config: %w", err)
-			}
-		}
-
-		if err := cli.RunSetup(ctx, client, cfg); err != nil {
+		if err := cli.RunSetup(ctx, client, cfg, *evaluatorModel, *allFlag || *setupFlag, *modelsFlag); err != nil {
 			return fmt.Errorf("setup failed: %w", err)
 		}
 
@@ -137,6 +461,11 @@ func run() error {
 		if err != nil {
 			return fmt.Errorf("reload config: %w", err)
 		}
+		if *urlFlag != "" {
+			cfg.OverrideOllamaURL(*urlFlag)
+		} else if shutdown.tunnel != nil {
+			cfg.OverrideOllamaURL(shutdown.tunnel.URL())
+		}
 
 		// If this was just a benchmark run, exit
 		if *benchmarkFlag && !needsSetup {
@@ -145,23 +474,32 @@ func run() error {
 			return nil
 		}
 	} else if needsSetup {
-		// First run - use interactive model picker
-		selectedModel, err := cli.RunModelPicker(ctx, client)
-		if err != nil {
-			return fmt.Errorf("model selection failed: %w", err)
+		// First run - the model comes from the wizard or --model, and
+		// otherwise from the interactive picker, which plain mode can't show
+		selectedModel := *modelFlag
+		if selectedModel == "" {
+			selectedModel = cfg.DefaultModel
+		}
+		if selectedModel == "" && !plain {
+			selectedModel, err = cli.RunModelPicker(ctx, client, cfg)
+			if err != nil {
+				return fmt.Errorf("model selection failed: %w", err)
+			}
+		} else if selectedModel == "" {
+			return fmt.Errorf("no default model configured. Specify one with --model in plain mode")
 		}
 
 		cfg.DefaultModel = selectedModel
 
 		// Immediately test the selected model's tool capabilities
-		fmt.Printf("\n✓ Selected %s as your default model\n", selectedModel)
-		fmt.Println("🔍 Testing tool capabilities...")
+		fmt.Fprintf(status, "\n✓ Selected %s as your default model\n", selectedModel)
+		fmt.Fprintln(status, "🔍 Testing tool capabilities...")
 
 		benchmarker := benchmark.New(client, cfg.BenchmarkTasks)
 		if err := benchmarker.DetectToolSupport(ctx, selectedModel, cfg); err != nil {
-			fmt.Printf("⚠️  Warning: Could not detect tool support: %v\n", err)
+			fmt.Fprintf(status, "⚠️  Warning: Could not detect tool support: %v\n", err)
 		} else {
-			fmt.Printf("✓ Tool support detected and configured\n")
+			fmt.Fprintf(status, "✓ Tool support detected and configured\n")
 		}
 
 		// Save config with tool capabilities
@@ -169,14 +507,22 @@ func run() error {
 			return fmt.Errorf("save config: %w", err)
 		}
 
-		fmt.Println("📊 Full benchmarking will run in the background to evaluate all models...")
-		fmt.Println()
+		fmt.Fprintln(status, "📊 Full benchmarking will run in the background to evaluate all models...")
+		fmt.Fprintln(status)
 	}
 
 	// Override model if specified
 	if *modelFlag != "" {
-		cfg.DefaultModel = *modelFlag
-		fmt.Printf("Using model: %s\n", cfg.DefaultModel)
+		cfg.OverrideDefaultModel(*modelFlag)
+		fmt.Fprintf(status, "Using model: %s\n", cfg.DefaultModel)
+	}
+
+	// Flags only apply to this run unless asked to save them
+	if *saveFlag && (*modelFlag != "" || *urlFlag != "") {
+		if err := cfg.PersistOverrides(); err != nil {
+			return fmt.Errorf("save config: %w", err)
+		}
+		fmt.Fprintln(status, "✓ Saved command line overrides to config")
 	}
 
 	// Validate we have a model
@@ -184,8 +530,15 @@ func run() error {
 		return fmt.Errorf("no default model configured. Run with --setup or specify --model")
 	}
 
-	// Create tool registry and register tools
-	toolRegistry, memTracker, messageChannel := setupTools(ctx, client, cfg, *acpFlag)
+	// Create tool registry and register tools (MCP servers live on mcpCtx)
+	// The team policy limits every profile; one that can't be read stops the run
+	policy, err := config.LoadPolicy()
+	if err != nil {
+		return err
+	}
+
+	toolRegistry, memTracker, messageChannel, mcpRegistry := setupTools(mcpCtx, client, cfg, policy, *acpFlag)
+	shutdown.mcpRegistry = mcpRegistry
 	_ = memTracker     // TODO: Use for tracking
 	_ = messageChannel // TODO: Use for model communication
 
@@ -193,13 +546,16 @@ func run() error {
 	var bgBenchmark *cli.BackgroundBenchmark
 	if needsSetup && !*setupFlag && !*benchmarkFlag {
 		benchmarker := benchmark.New(client, cfg.BenchmarkTasks)
-		if *evaluatorModel != "" {
-			benchmarker.SetEvaluator(*evaluatorModel)
-		} else if cfg.DefaultModel != "" {
-			benchmarker.SetEvaluator(cfg.DefaultModel)
-		}
+		benchmarker.Configure(cfg)
+		benchmarker.SetEvaluator(*evaluatorModel)
 		bgBenchmark = cli.NewBackgroundBenchmark(ctx, benchmarker, cfg)
 		bgBenchmark.Start()
+		shutdown.bgBenchmark = bgBenchmark
+	}
+
+	// Only the chat interface can hold a draft; tell the others what to send
+	if draft != "" && (plain || *acpFlag || *serveFlag != "") {
+		fmt.Fprintf(os.Stderr, "Suggested first message: %s\n", draft)
 	}
 
 	// Run in ACP mode or chat mode
@@ -207,12 +563,89 @@ func run() error {
 		return runACPMode(ctx, client, cfg, toolRegistry)
 	}
 
+	if *serveFlag != "" {
+		return cli.RunBridge(ctx, client, cfg, toolRegistry, bgBenchmark, *serveFlag)
+	}
+
+	if *headlessFlag {
+		return cli.RunHeadless(ctx, client, cfg, toolRegistry)
+	}
+
+	if plain {
+		return cli.RunPlainChat(ctx, client, cfg, toolRegistry, bgBenchmark)
+	}
+
 	// Run chat interface
-	return cli.RunChat(ctx, client, cfg, toolRegistry, bgBenchmark)
+	return cli.RunChat(ctx, client, cfg, toolRegistry, bgBenchmark, draft)
+}
+
+// newToolPolicy converts the team policy for the tools, nil when there is none
+func newToolPolicy(policy *config.Policy) *tools.Policy {
+	if policy == nil {
+		return nil
+	}
+	toolPolicy := &tools.Policy{
+		RequireApproval:      make(map[tools.PermissionLevel]bool),
+		BlockedCommands:      policy.BlockedCommands,
+		DisabledTools:        make(map[string]bool),
+		RestrictToWorkingDir: policy.RestrictToWorkingDir,
+	}
+	for _, name := range policy.RequireApproval {
+		// LoadPolicy has checked the names
+		if level, err := tools.ParsePermissionLevel(name); err == nil {
+			toolPolicy.RequireApproval[level] = true
+		}
+	}
+	for _, name := range policy.DisabledTools {
+		toolPolicy.DisabledTools[name] = true
+	}
+	return toolPolicy
+}
+
+// newOllamaClient connects to the configured endpoints, or to the single
+// Ollama URL when none are configured or --url or an SSH tunnel is in use
+func newOllamaClient(cfg *config.Config) *ollama.Client {
+	if len(cfg.Endpoints) == 0 || *urlFlag != "" || cfg.SSHTunnel != nil {
+		return ollama.NewClient(cfg.OllamaURL)
+	}
+
+	endpoints := make([]*ollama.Endpoint, 0, len(cfg.Endpoints))
+	for _, ep := range cfg.Endpoints {
+		endpoints = append(endpoints, ollama.NewEndpoint(ep.Name, ep.URL, ep.Models))
+	}
+	return ollama.NewMultiClient(endpoints)
+}
+
+// checkEndpoints health-checks every endpoint, warning about unreachable ones
+// and failing only when none respond
+func checkEndpoints(ctx context.Context, client *ollama.Client, quiet bool) error {
+	statuses := client.CheckEndpoints(ctx)
+
+	available := 0
+	for _, status := range statuses {
+		if status.Err == nil {
+			available++
+			continue
+		}
+		logger.Log("Endpoint %s (%s) unavailable: %v", status.Name, status.URL, status.Err)
+		if !quiet && len(statuses) > 1 {
+			fmt.Fprintf(os.Stderr, "⚠️ Ollama endpoint %s (%s) is unreachable\n", status.Name, status.URL)
+		}
+	}
+
+	if available == 0 {
+		if len(statuses) == 1 {
+			return fmt.Errorf("Ollama is not available at %s. Please ensure Ollama is running", statuses[0].URL)
+		}
+		return fmt.Errorf("none of the %d configured Ollama endpoints are available", len(statuses))
+	}
+	return nil
 }
 
-func setupTools(ctx context.Context, client *ollama.Client, cfg *config.Config, acpMode bool) (*tools.Registry, *tools.ModelMemoryTracker, *tools.MessageChannel) {
+func setupTools(ctx context.Context, client *ollama.Client, cfg *config.Config, policy *config.Policy, acpMode bool) (*tools.Registry, *tools.ModelMemoryTracker, *tools.MessageChannel, *mcp.MCPToolRegistry) {
 	toolRegistry := tools.NewRegistry()
+	toolPolicy := newToolPolicy(policy)
+	toolRegistry.SetPolicy(toolPolicy)
 
 	// Create shared infrastructure
 	memTracker := tools.NewModelMemoryTracker()
@@ -255,19 +688,145 @@ func setupTools(ctx context.Context, client *ollama.Client, cfg *config.Config,
 		RequireApprovalExecute: cfg.Permissions.RequireApprovalExecute,
 		RequireApprovalNetwork: cfg.Permissions.RequireApprovalNetwork,
 		BlockedCommands:        cfg.Permissions.BlockedCommands,
+		RestrictToWorkingDir:   cfg.Permissions.RestrictToWorkingDir,
+		AllowedRoots:           cfg.Permissions.AllowedRoots,
+		CommandAllowlist:       cfg.Permissions.CommandAllowlist,
+		AllowedCommands:        cfg.Permissions.AllowedCommands,
+	}
+	toolPermConfig.SetPolicy(toolPolicy)
+	if toolPermConfig.AllowedCommands == nil {
+		// Configs from before allowlist mode have no list yet
+		toolPermConfig.AllowedCommands = tools.DefaultAllowedCommands()
+	}
+	for _, pattern := range cfg.Permissions.AlwaysAllowPatterns {
+		toolPermConfig.AlwaysAllowPatterns = append(toolPermConfig.AlwaysAllowPatterns, tools.PermissionPattern{
+			Tool:           pattern.Tool,
+			PathPattern:    pattern.PathPattern,
+			CommandPattern: pattern.CommandPattern,
+			AlwaysAllow:    pattern.AlwaysAllow,
+			Root:           pattern.Root,
+			Enabled:        pattern.Enabled,
+		})
+	}
+	for _, spec := range *rootFlag {
+		name, dir, ok := strings.Cut(spec, "=")
+		if !ok {
+			name, dir = filepath.Base(spec), spec
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			fmt.Fprintf(os.Stderr, "⚠️ Ignoring --root %s: not a directory\n", spec)
+			continue
+		}
+		if _, err := toolPermConfig.AddProjectRoot(name, dir); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️ Ignoring --root %s: %v\n", spec, err)
+		}
+	}
+
+	// File tools enforce allowed/denied roots themselves, whatever is approved
+	deniedRoots := cfg.Permissions.DeniedRoots
+	if policy != nil {
+		deniedRoots = append(append([]string(nil), deniedRoots...), policy.DeniedRoots...)
 	}
+	pathPolicy := tools.NewPathPolicy(cfg.Permissions.FileRoots, deniedRoots)
+	// write_file refuses to overwrite changes made since the model read a file
+	fileVersions := tools.NewFileVersions()
+	readFileTool := tools.NewReadFileTool()
+	readFileTool.SetPathPolicy(pathPolicy)
+	readFileTool.SetFileVersions(fileVersions)
+	writeFileTool := tools.NewWriteFileTool()
+	writeFileTool.SetPathPolicy(pathPolicy)
+	writeFileTool.SetFileVersions(fileVersions)
+	listFilesTool := tools.NewListFilesTool()
+	listFilesTool.SetPathPolicy(pathPolicy)
+	applyChangesTool := tools.NewApplyChangesTool()
+	applyChangesTool.SetPathPolicy(pathPolicy)
+	applyChangesTool.SetFileVersions(fileVersions)
+	moveFileTool := tools.NewMoveFileTool()
+	moveFileTool.SetPathPolicy(pathPolicy)
+	deleteFileTool := tools.NewDeleteFileTool(toolRegistry.Trash())
+	deleteFileTool.SetPathPolicy(pathPolicy)
+	scaffoldTool := tools.NewScaffoldTool()
+	scaffoldTool.SetPathPolicy(pathPolicy)
+	appendTool := tools.NewAppendToFileTool()
+	appendTool.SetPathPolicy(pathPolicy)
+	appendTool.SetFileVersions(fileVersions)
+	insertTool := tools.NewInsertAtLineTool()
+	insertTool.SetPathPolicy(pathPolicy)
+	insertTool.SetFileVersions(fileVersions)
+	downloadTool := tools.NewDownloadFileTool()
+	downloadTool.SetPathPolicy(pathPolicy)
+	listArchiveTool := tools.NewListArchiveTool()
+	listArchiveTool.SetPathPolicy(pathPolicy)
+	extractArchiveTool := tools.NewExtractArchiveTool()
+	extractArchiveTool.SetPathPolicy(pathPolicy)
 
 	// Register built-in tools with permission levels
 	toolRegistry.Register(tools.NewProtectedTool(
-		tools.NewReadFileTool(), tools.PermissionRead, permChecker, toolPermConfig))
+		readFileTool, tools.PermissionRead, permChecker, toolPermConfig))
 	toolRegistry.Register(tools.NewProtectedTool(
-		tools.NewWriteFileTool(), tools.PermissionWrite, permChecker, toolPermConfig))
+		writeFileTool, tools.PermissionWrite, permChecker, toolPermConfig))
 	toolRegistry.Register(tools.NewProtectedTool(
-		tools.NewListFilesTool(), tools.PermissionRead, permChecker, toolPermConfig))
+		listFilesTool, tools.PermissionRead, permChecker, toolPermConfig))
 	toolRegistry.Register(tools.NewProtectedTool(
-		tools.NewReadBenchmarkTool(), tools.PermissionRead, permChecker, toolPermConfig))
+		applyChangesTool, tools.PermissionWrite, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		moveFileTool, tools.PermissionWrite, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		deleteFileTool, tools.PermissionWrite, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		scaffoldTool, tools.PermissionWrite, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		appendTool, tools.PermissionWrite, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		insertTool, tools.PermissionWrite, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		downloadTool, tools.PermissionWrite, permChecker, toolPermConfig).AlsoNeeds(tools.PermissionNetwork))
+	toolRegistry.Register(tools.NewProtectedTool(
+		listArchiveTool, tools.PermissionRead, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		extractArchiveTool, tools.PermissionWrite, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		tools.NewReadBenchmarkTool(cfg), tools.PermissionRead, permChecker, toolPermConfig))
 	toolRegistry.Register(tools.NewProtectedTool(
 		tools.NewWebFetchTool(), tools.PermissionNetwork, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		tools.NewClipboardReadTool(), tools.PermissionRead, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		tools.NewClipboardWriteTool(), tools.PermissionSafe, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		tools.NewScreenshotTool(), tools.PermissionExecute, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		tools.NewEnvironmentTool(), tools.PermissionSafe, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		tools.NewEvaluateTool(), tools.PermissionSafe, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		tools.NewListProcessesTool(), tools.PermissionRead, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		tools.NewProcessInfoTool(), tools.PermissionRead, permChecker, toolPermConfig))
+	for _, tool := range []tools.Tool{tools.NewContainerListTool(), tools.NewContainerLogsTool(), tools.NewContainerExecTool(), tools.NewComposeTool()} {
+		toolRegistry.Register(tools.NewProtectedTool(tool, tools.PermissionExecute, permChecker, toolPermConfig))
+	}
+	if _, err := exec.LookPath("kubectl"); err == nil {
+		kubeContext := cfg.Kubernetes.Context
+		for _, tool := range []tools.Tool{tools.NewKubeGetTool(kubeContext), tools.NewKubeDescribeTool(kubeContext), tools.NewKubeLogsTool(kubeContext)} {
+			toolRegistry.Register(tools.NewProtectedTool(tool, tools.PermissionRead, permChecker, toolPermConfig))
+		}
+		if cfg.Kubernetes.AllowMutating {
+			toolRegistry.Register(tools.NewProtectedTool(
+				tools.NewKubectlTool(kubeContext), tools.PermissionExecute, permChecker, toolPermConfig))
+		}
+	}
+	if len(cfg.Databases) > 0 {
+		// Writes need write approval as soon as any profile allows them
+		level := tools.PermissionRead
+		for _, profile := range cfg.Databases {
+			if profile.ReadWrite {
+				level = tools.PermissionWrite
+			}
+		}
+		toolRegistry.Register(tools.NewProtectedTool(
+			tools.NewQueryDatabaseTool(cfg.Databases), level, permChecker, toolPermConfig))
+	}
 
 	// Create bash tool with interactive executor (only in chat mode, not ACP)
 	bashTool := tools.NewBashTool()
@@ -284,7 +843,7 @@ func setupTools(ctx context.Context, client *ollama.Client, cfg *config.Config,
 	for _, mat := range cfg.ModelAsTools {
 		if mat.Enabled {
 			toolRegistry.Register(tools.NewProtectedTool(
-				tools.NewAskModelTool(client, mat.ModelName, mat.Description),
+				tools.NewModelAsTool(client, mat),
 				tools.PermissionSafe, permChecker, toolPermConfig))
 			if !acpMode {
 				fmt.Printf("✓ Registered model as tool: %s\n", mat.ModelName)
@@ -300,6 +859,37 @@ func setupTools(ctx context.Context, client *ollama.Client, cfg *config.Config,
 	toolRegistry.Register(tools.NewProtectedTool(
 		tools.NewGarbageCollectModelsTool(memTracker), tools.PermissionSafe, permChecker, toolPermConfig))
 
+	// Long tool results are paged through from the registry's output store
+	if cfg.ToolOutput.Budget() > 0 {
+		toolRegistry.Register(tools.NewProtectedTool(
+			tools.NewFetchMoreTool(toolRegistry.Outputs(), cfg.ToolOutput.PageBytes()), tools.PermissionSafe, permChecker, toolPermConfig))
+	}
+
+	// Register Ollama model building tools
+	toolRegistry.Register(tools.NewProtectedTool(
+		tools.NewCreateModelTool(client), tools.PermissionWrite, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		tools.NewShowModelfileTool(client), tools.PermissionSafe, permChecker, toolPermConfig))
+
+	// Register project memory tools
+	projectNotes := notes.OpenWorkingDir()
+	toolRegistry.Register(tools.NewProtectedTool(
+		tools.NewRememberTool(projectNotes), tools.PermissionSafe, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		tools.NewRecallTool(projectNotes), tools.PermissionSafe, permChecker, toolPermConfig))
+
+	// Register task list tools
+	toolRegistry.Register(tools.NewProtectedTool(
+		tools.NewTodoAddTool(toolRegistry.Todos()), tools.PermissionSafe, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		tools.NewTodoUpdateTool(toolRegistry.Todos()), tools.PermissionSafe, permChecker, toolPermConfig))
+	toolRegistry.Register(tools.NewProtectedTool(
+		tools.NewTodoCompleteTool(toolRegistry.Todos()), tools.PermissionSafe, permChecker, toolPermConfig))
+
+	// Each frontend sets who answers; until then the model is told to assume
+	toolRegistry.Register(tools.NewProtectedTool(
+		tools.NewAskUserTool(), tools.PermissionSafe, permChecker, toolPermConfig))
+
 	// Register communication tools
 	toolRegistry.Register(tools.NewProtectedTool(
 		tools.NewReceiveMessagesTool(messageChannel), tools.PermissionSafe, permChecker, toolPermConfig))
@@ -347,7 +937,7 @@ func setupTools(ctx context.Context, client *ollama.Client, cfg *config.Config,
 		}
 	}
 
-	return toolRegistry, memTracker, messageChannel
+	return toolRegistry, memTracker, messageChannel, mcpRegistry
 }
 
 func runACPMode(ctx context.Context, client *ollama.Client, cfg *config.Config, toolRegistry *tools.Registry) error {
@@ -362,15 +952,22 @@ func listModels(ctx context.Context, client *ollama.Client, cfg *config.Config)
 		return fmt.Errorf("list models: %w", err)
 	}
 
+	if *jsonFlag {
+		return printJSON(listedModels(models, cfg))
+	}
+
 	fmt.Println("Available Models:")
 	fmt.Println()
 
 	for _, model := range models {
 		fmt.Printf("📦 %s\n", model.Name)
 
-		if cap, ok := cfg.ModelCapabilities[model.Name]; ok {
+		if cap, ok := cfg.GetCapability(model.Name); ok {
 			fmt.Printf("   Tool Support: %v\n", cap.SupportsTools)
 			fmt.Printf("   Tool Format: %s\n", cap.ToolCallFormat)
+			if cap.ToolConfidence > 0 {
+				fmt.Printf("   Tool Confidence: %.0f%%\n", cap.ToolConfidence*100)
+			}
 			if len(cap.RecommendedFor) > 0 {
 				fmt.Printf("   Best For: %v\n", cap.RecommendedFor)
 			}
@@ -388,6 +985,57 @@ func listModels(ctx context.Context, client *ollama.Client, cfg *config.Config)
 	return nil
 }
 
+// listedModel is one entry of llemecode --list --json
+type listedModel struct {
+	Name        string                  `json:"name"`
+	Size        int64                   `json:"size"`
+	ModifiedAt  time.Time               `json:"modified_at"`
+	Digest      string                  `json:"digest,omitempty"`
+	Default     bool                    `json:"default"`
+	Benchmarked bool                    `json:"benchmarked"`
+	Capability  *config.ModelCapability `json:"capability,omitempty"`
+	Score       float64                 `json:"score,omitempty"`
+	Rank        int                     `json:"rank,omitempty"`
+}
+
+func listedModels(models []ollama.ModelInfo, cfg *config.Config) []listedModel {
+	scores := make(map[string]benchmark.ModelScore)
+	if results, _, err := benchmark.LoadLatestResults(); err == nil {
+		for _, score := range results {
+			scores[score.Model] = score
+		}
+	}
+
+	listed := make([]listedModel, 0, len(models))
+	for _, model := range models {
+		entry := listedModel{
+			Name:       model.Name,
+			Size:       model.Size,
+			ModifiedAt: model.ModifiedAt,
+			Digest:     model.Digest,
+			Default:    model.Name == cfg.DefaultModel,
+		}
+		if cap, ok := cfg.GetCapability(model.Name); ok {
+			entry.Benchmarked = true
+			entry.Capability = &cap
+		}
+		if score, ok := scores[model.Name]; ok {
+			entry.Score, entry.Rank = score.TotalScore, score.Rank
+		}
+		listed = append(listed, entry)
+	}
+	return listed
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("encode json: %w", err)
+	}
+	return nil
+}
+
 func mustGetConfigDir() string {
 	dir, _ := config.GetConfigDir()
 	return dir
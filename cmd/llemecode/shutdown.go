@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/cli"
+	"github.com/LaPingvino/llemecode/internal/logger"
+	"github.com/LaPingvino/llemecode/internal/mcp"
+	"github.com/LaPingvino/llemecode/internal/sshtunnel"
+)
+
+// shutdownTimeout bounds how long the whole shutdown sequence may take
+const shutdownTimeout = 10 * time.Second
+
+// shutdownSequence tears down long-running components in dependency order:
+// the agent is cancelled first so nothing new is started, then background
+// benchmarks flush their partial results, then MCP child processes are
+// stopped, then the SSH tunnel to Ollama is closed, and finally the logger
+// is closed so every step above is logged.
+type shutdownSequence struct {
+	cancelAgent context.CancelFunc
+	bgBenchmark *cli.BackgroundBenchmark
+	mcpRegistry *mcp.MCPToolRegistry
+	cancelMCP   context.CancelFunc
+	tunnel      *sshtunnel.Tunnel
+	closeLogger bool
+}
+
+func (s *shutdownSequence) Run(timeout time.Duration) {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		if s.cancelAgent != nil {
+			logger.Log("Shutdown: cancelling agent")
+			s.cancelAgent()
+		}
+
+		if s.bgBenchmark != nil {
+			logger.Log("Shutdown: stopping background benchmark")
+			s.bgBenchmark.Stop()
+		}
+
+		if s.mcpRegistry != nil {
+			logger.Log("Shutdown: closing MCP servers")
+			if err := s.mcpRegistry.Close(); err != nil {
+				logger.Log("Shutdown: %v", err)
+			}
+		}
+		if s.cancelMCP != nil {
+			s.cancelMCP()
+		}
+
+		if s.tunnel != nil {
+			logger.Log("Shutdown: closing SSH tunnel")
+			s.tunnel.Close()
+		}
+	}()
+
+	select {
+	case <-done:
+		logger.Log("Shutdown: complete")
+	case <-time.After(timeout):
+		logger.Log("Shutdown: timed out after %v", timeout)
+		fmt.Fprintf(os.Stderr, "Warning: shutdown timed out after %v\n", timeout)
+	}
+
+	if s.closeLogger {
+		logger.Close()
+	}
+}
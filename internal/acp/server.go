@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
 
 	"github.com/LaPingvino/llemecode/internal/agent"
 	"github.com/LaPingvino/llemecode/internal/config"
@@ -22,6 +23,12 @@ type ACPServer struct {
 	agent        *agent.Agent
 	reader       *bufio.Reader
 	writer       io.Writer
+
+	mu         sync.Mutex         // Guards writer, cancelChat and the requests below
+	cancelChat context.CancelFunc // Stops the running chat; nil when idle
+
+	nextID  int                   // Numbers the requests sent to the editor
+	replies map[string]chan reply // Requests to the editor awaiting a reply, by ID
 }
 
 // Request represents an ACP JSON-RPC request
@@ -40,6 +47,46 @@ type Response struct {
 	Error   *Error      `json:"error,omitempty"`
 }
 
+// reply is the editor's answer to a request the server sent
+type reply struct {
+	ID     interface{}     `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *Error          `json:"error,omitempty"`
+}
+
+// outgoingRequest is a request the server sends to the editor
+type outgoingRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      string      `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// AskParams is sent with a user/ask request when the model asks the user
+// a question; the editor replies with an AskResult
+type AskParams struct {
+	Question string   `json:"question"`
+	Options  []string `json:"options,omitempty"` // Shown as a list to choose from; free answers are allowed too
+}
+
+// AskResult is the editor's reply to user/ask; an empty answer skips the question
+type AskResult struct {
+	Answer string `json:"answer"`
+}
+
+// Notification is a JSON-RPC message that expects no reply
+type Notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// ProgressParams is sent with a progress notification while a chat runs
+type ProgressParams struct {
+	RequestID interface{} `json:"request_id"` // ID of the chat request
+	agent.Checkpoint
+}
+
 // Error represents a JSON-RPC error
 type Error struct {
 	Code    int         `json:"code"`
@@ -66,6 +113,7 @@ func NewServer(client *ollama.Client, cfg *config.Config, toolRegistry *tools.Re
 		toolRegistry: toolRegistry,
 		reader:       bufio.NewReader(os.Stdin),
 		writer:       os.Stdout,
+		replies:      make(map[string]chan reply),
 	}
 }
 
@@ -79,6 +127,9 @@ func (s *ACPServer) Start(ctx context.Context) error {
 
 	s.agent = agent.New(s.client, s.toolRegistry, s.config, model)
 	s.agent.SetDisabledTools(s.config.DisabledTools)
+	if cwd, err := os.Getwd(); err == nil {
+		s.agent.SetLanguageGuidance(agent.LanguageGuidance(s.config.LanguageGuidance, cwd))
+	}
 
 	if sysPrompt, ok := s.config.SystemPrompts["default"]; ok {
 		s.agent.AddSystemPrompt(sysPrompt)
@@ -86,16 +137,32 @@ func (s *ACPServer) Start(ctx context.Context) error {
 		s.agent.AddSystemPrompt("")
 	}
 
+	// Read stdin in the background so cancellation isn't blocked on a pending read
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			line, err := s.reader.ReadBytes('\n')
+			if err != nil {
+				readErr <- err
+				return
+			}
+			lines <- line
+		}
+	}()
+
 	// Main request loop
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		default:
-			if err := s.handleRequest(ctx); err != nil {
-				if err == io.EOF {
-					return nil
-				}
+		case err := <-readErr:
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("read request: %w", err)
+		case line := <-lines:
+			if err := s.handleRequest(ctx, line); err != nil {
 				// Log error but continue
 				fmt.Fprintf(os.Stderr, "Error handling request: %v\n", err)
 			}
@@ -103,19 +170,19 @@ func (s *ACPServer) Start(ctx context.Context) error {
 	}
 }
 
-func (s *ACPServer) handleRequest(ctx context.Context) error {
-	// Read line from stdin
-	line, err := s.reader.ReadBytes('\n')
-	if err != nil {
-		return err
-	}
-
+func (s *ACPServer) handleRequest(ctx context.Context, line []byte) error {
 	var req Request
 	if err := json.Unmarshal(line, &req); err != nil {
 		s.sendError(req.ID, -32700, "Parse error", err.Error())
 		return nil
 	}
 
+	// A reply to a request the server sent
+	if req.Method == "" && req.ID != nil {
+		s.handleReply(line)
+		return nil
+	}
+
 	// Handle methods
 	switch req.Method {
 	case "initialize":
@@ -125,7 +192,9 @@ func (s *ACPServer) handleRequest(ctx context.Context) error {
 	case "tools/call":
 		s.handleToolCall(ctx, req)
 	case "chat":
-		s.handleChat(ctx, req)
+		s.startChat(ctx, req)
+	case "chat/cancel":
+		s.handleChatCancel(req)
 	case "models/list":
 		s.handleModelsList(ctx, req)
 	case "models/switch":
@@ -138,6 +207,21 @@ func (s *ACPServer) handleRequest(ctx context.Context) error {
 }
 
 func (s *ACPServer) handleInitialize(req Request) {
+	var params struct {
+		Capabilities struct {
+			AskUser bool `json:"ask_user"`
+		} `json:"capabilities"`
+	}
+	if len(req.Params) > 0 {
+		_ = json.Unmarshal(req.Params, &params) // Capabilities are optional
+	}
+	// Only editors that can show a question get the model's questions
+	if params.Capabilities.AskUser {
+		s.toolRegistry.SetUserAsker(s)
+	} else {
+		s.toolRegistry.SetUserAsker(nil)
+	}
+
 	result := map[string]interface{}{
 		"protocolVersion": "0.1.0",
 		"serverInfo": map[string]interface{}{
@@ -145,8 +229,10 @@ func (s *ACPServer) handleInitialize(req Request) {
 			"version": "0.1.0",
 		},
 		"capabilities": map[string]interface{}{
-			"tools": true,
-			"chat":  true,
+			"tools":    true,
+			"chat":     true,
+			"progress": true,
+			"ask_user": true, // Sends user/ask to editors that declare ask_user
 		},
 	}
 	s.sendResponse(req.ID, result)
@@ -192,6 +278,40 @@ func (s *ACPServer) handleToolCall(ctx context.Context, req Request) {
 	})
 }
 
+// startChat runs a chat in the background, so chat/cancel can be read
+// while it works. One chat runs at a time.
+func (s *ACPServer) startChat(ctx context.Context, req Request) {
+	s.mu.Lock()
+	if s.cancelChat != nil {
+		s.mu.Unlock()
+		s.sendError(req.ID, -32000, "Chat failed", "a chat is already running; send chat/cancel to stop it")
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s.cancelChat = cancel
+	s.mu.Unlock()
+
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			s.cancelChat = nil
+			s.mu.Unlock()
+			cancel()
+		}()
+		s.handleChat(ctx, req)
+	}()
+}
+
+func (s *ACPServer) handleChatCancel(req Request) {
+	s.mu.Lock()
+	cancel := s.cancelChat
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	s.sendResponse(req.ID, map[string]interface{}{"cancelled": cancel != nil})
+}
+
 func (s *ACPServer) handleChat(ctx context.Context, req Request) {
 	var params ChatParams
 	if err := json.Unmarshal(req.Params, &params); err != nil {
@@ -200,18 +320,22 @@ func (s *ACPServer) handleChat(ctx context.Context, req Request) {
 	}
 
 	// Switch model if specified
-	if params.Model != "" && params.Model != s.agent.GetMessages()[0].Role {
-		s.agent = agent.New(s.client, s.toolRegistry, s.config, params.Model)
-		s.agent.SetDisabledTools(s.config.DisabledTools)
-		if sysPrompt, ok := s.config.SystemPrompts["default"]; ok {
-			s.agent.AddSystemPrompt(sysPrompt)
-		} else {
-			s.agent.AddSystemPrompt("")
-		}
+	if params.Model != "" && params.Model != s.agent.Model() {
+		s.agent.SetModel(params.Model, s.config.SystemPrompts["default"])
 	}
 
-	resp, err := s.agent.Chat(ctx, params.Message)
+	// Report each model request and tool call as a progress notification
+	progress := agent.NewProgressTracker(s.agent, func(cp agent.Checkpoint) {
+		s.notify("progress", ProgressParams{RequestID: req.ID, Checkpoint: cp})
+	})
+	s.agent.SetToolObserver(progress.Observe(nil))
+	resp, err := s.agent.ChatStream(ctx, params.Message, progress.Stream(nil))
+	progress.Finish(err)
 	if err != nil {
+		if ctx.Err() == context.Canceled {
+			s.sendError(req.ID, -32800, "Chat cancelled", err.Error())
+			return
+		}
 		s.sendError(req.ID, -32000, "Chat failed", err.Error())
 		return
 	}
@@ -252,6 +376,55 @@ func (s *ACPServer) handleChat(ctx context.Context, req Request) {
 	})
 }
 
+// AskUser sends the model's question to the editor as a user/ask request
+// and waits for the answer
+func (s *ACPServer) AskUser(ctx context.Context, question string, options []string) (string, error) {
+	s.mu.Lock()
+	s.nextID++
+	id := fmt.Sprintf("ask-%d", s.nextID)
+	answer := make(chan reply, 1)
+	s.replies[id] = answer
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.replies, id)
+		s.mu.Unlock()
+	}()
+
+	s.write(outgoingRequest{JSONRPC: "2.0", ID: id, Method: "user/ask", Params: AskParams{Question: question, Options: options}})
+	select {
+	case r := <-answer:
+		if r.Error != nil {
+			return "", fmt.Errorf("editor: %s", r.Error.Message)
+		}
+		var result AskResult
+		if err := json.Unmarshal(r.Result, &result); err != nil {
+			return "", fmt.Errorf("parse user/ask reply: %w", err)
+		}
+		return result.Answer, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// handleReply hands a reply to the request waiting for it
+func (s *ACPServer) handleReply(line []byte) {
+	var r reply
+	if err := json.Unmarshal(line, &r); err != nil {
+		return
+	}
+	id := fmt.Sprint(r.ID)
+	s.mu.Lock()
+	answer, ok := s.replies[id]
+	delete(s.replies, id)
+	s.mu.Unlock()
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Reply to unknown request %s\n", id)
+		return
+	}
+	answer <- r
+}
+
 func (s *ACPServer) handleModelsList(ctx context.Context, req Request) {
 	models, err := s.client.ListModels(ctx)
 	if err != nil {
@@ -266,7 +439,7 @@ func (s *ACPServer) handleModelsList(ctx context.Context, req Request) {
 			"size": model.Size,
 		}
 
-		if cap, ok := s.config.ModelCapabilities[model.Name]; ok {
+		if cap, ok := s.config.GetCapability(model.Name); ok {
 			info["supports_tools"] = cap.SupportsTools
 			info["tool_format"] = cap.ToolCallFormat
 			info["recommended_for"] = cap.RecommendedFor
@@ -290,18 +463,22 @@ func (s *ACPServer) handleModelSwitch(req Request) {
 		return
 	}
 
-	// Create new agent with new model
-	s.agent = agent.New(s.client, s.toolRegistry, s.config, params.Model)
-	s.agent.SetDisabledTools(s.config.DisabledTools)
-	if sysPrompt, ok := s.config.SystemPrompts["default"]; ok {
-		s.agent.AddSystemPrompt(sysPrompt)
-	} else {
-		s.agent.AddSystemPrompt("")
+	s.mu.Lock()
+	busy := s.cancelChat != nil
+	s.mu.Unlock()
+	if busy {
+		s.sendError(req.ID, -32000, "Failed to switch model", "a chat is running; wait for it or send chat/cancel")
+		return
 	}
 
+	// Switch the agent over, keeping the conversation
+	s.agent.SetModel(params.Model, s.config.SystemPrompts["default"])
+
 	// Update default in config
-	s.config.DefaultModel = params.Model
-	if err := s.config.Save(); err != nil {
+	err := s.config.Update(func(c *config.Config) {
+		c.DefaultModel = params.Model
+	})
+	if err != nil {
 		s.sendError(req.ID, -32000, "Failed to save config", err.Error())
 		return
 	}
@@ -333,12 +510,23 @@ func (s *ACPServer) sendError(id interface{}, code int, message string, data int
 	s.send(resp)
 }
 
+func (s *ACPServer) notify(method string, params interface{}) {
+	s.write(Notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
 func (s *ACPServer) send(resp Response) {
-	data, err := json.Marshal(resp)
+	s.write(resp)
+}
+
+// write sends one message per line; chats answer from their own goroutine
+func (s *ACPServer) write(msg interface{}) {
+	data, err := json.Marshal(msg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to marshal response: %v\n", err)
 		return
 	}
 	data = append(data, '\n')
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.writer.Write(data)
 }
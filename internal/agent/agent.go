@@ -2,15 +2,19 @@ package agent
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/LaPingvino/llemecode/internal/config"
 	"github.com/LaPingvino/llemecode/internal/logger"
 	"github.com/LaPingvino/llemecode/internal/ollama"
 	"github.com/LaPingvino/llemecode/internal/tools"
+	core "github.com/LaPingvino/llemecode/pkg/agent"
 )
 
 type Agent struct {
@@ -18,21 +22,48 @@ type Agent struct {
 	toolRegistry   *tools.Registry
 	config         *config.Config
 	model          string
+	mu             sync.Mutex // Guards messages and toolCallFormat, which the UI reads while a turn runs
 	messages       []ollama.Message
 	toolCallFormat string
 	disabledTools  []string // Combined list of disabled tools (config + session)
+	budgetPrompt   BudgetPrompt
+	toolObserver   ToolObserver
+	notes          string // Appended to the system prompt
+	guidance       string // Advice for the project's languages, appended to the system prompt
+	pins           []Pin  // Guarded by mu
+	fileWatcher    *FileWatcher
+	embeddings     map[string][]float64 // Cached by text for context pruning; guarded by mu
+	embeddingModel string
+	lastParse      *ParseReport // How the last reply was searched for tool calls; guarded by mu
+
+	exchanges []*ollama.Exchange // Raw model requests of the last turn; guarded by mu
 }
 
 type Response struct {
 	Content   string
 	ToolCalls []ToolExecution
+
+	PromptTokens     int // Summed over every model request in the turn
+	CompletionTokens int
+
+	Meta *ollama.MessageMeta // How the final reply was generated
 }
 
+// StreamFunc receives response content as it is generated. iteration counts
+// model requests within one turn, so callers can tell when a new reply starts.
+type StreamFunc func(iteration int, chunk string)
+
+// ToolObserver is told about each tool call before it runs and again once it
+// has finished, with Result and Error filled in
+type ToolObserver func(execution ToolExecution, finished bool)
+
 type ToolExecution struct {
-	Name   string
-	Args   map[string]interface{}
-	Result string
-	Error  error
+	Name     string
+	Args     map[string]interface{}
+	Result   string
+	Error    error
+	Started  time.Time     // When the tool began running
+	Duration time.Duration // Includes post-processing
 }
 
 func New(client *ollama.Client, toolRegistry *tools.Registry, cfg *config.Config, model string) *Agent {
@@ -73,13 +104,81 @@ func (a *Agent) AddSystemPrompt(customPrompt string) {
 	// Even native models benefit from knowing what tools are available
 	toolDesc := a.generateToolDescriptions()
 	prompt = strings.Replace(prompt, "{{TOOLS}}", toolDesc, -1)
+	if a.toolEnabled("todo_add") {
+		prompt += "\n\n" + todoGuidance
+	}
+	if a.toolEnabled("ask_user") {
+		prompt += "\n\n" + askUserGuidance
+	}
+	if a.guidance != "" {
+		prompt += "\n\n" + a.guidance
+	}
+	if a.notes != "" {
+		prompt += "\n\n" + a.notes
+	}
 
-	a.messages = append(a.messages, ollama.Message{
+	a.appendMessage(ollama.Message{
 		Role:    "system",
 		Content: prompt,
 	})
 }
 
+// SetModel switches the agent to another model while keeping the
+// conversation. The tool call format is re-derived for the new model and the
+// system prompt is replaced to match it.
+func (a *Agent) SetModel(model, customPrompt string) {
+	format := a.config.GetToolCallFormat(model)
+
+	a.mu.Lock()
+	a.model = model
+	a.toolCallFormat = format
+	// Only the system prompt is replaced; context added later stays
+	history := make([]ollama.Message, 0, len(a.messages))
+	for i, msg := range a.messages {
+		if i > 0 || msg.Role != "system" {
+			history = append(history, msg)
+		}
+	}
+	a.messages = make([]ollama.Message, 0, len(history)+1)
+	a.mu.Unlock()
+
+	a.AddSystemPrompt(customPrompt)
+
+	a.mu.Lock()
+	a.messages = append(a.messages, history...)
+	a.mu.Unlock()
+}
+
+// SetNotes sets text added to every system prompt from now on, such as facts
+// remembered about the project. Call it before AddSystemPrompt.
+func (a *Agent) SetNotes(notes string) {
+	a.notes = notes
+}
+
+// SetLanguageGuidance sets advice for the project's languages added to every
+// system prompt from now on (see LanguageGuidance). Call it before
+// AddSystemPrompt.
+func (a *Agent) SetLanguageGuidance(guidance string) {
+	a.guidance = guidance
+}
+
+func (a *Agent) appendMessage(msg ollama.Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.messages = append(a.messages, msg)
+}
+
+// SetFileWatcher makes every turn start by telling the model about files
+// changed since the previous turn ended
+func (a *Agent) SetFileWatcher(w *FileWatcher) {
+	a.fileWatcher = w
+}
+
+// AddContext adds a note the model sees from the next turn on
+func (a *Agent) AddContext(note string) {
+	a.appendMessage(ollama.Message{Role: "system", Content: note})
+}
+
 func (a *Agent) generateToolDescriptions() string {
 	var sb strings.Builder
 	for _, tool := range a.toolRegistry.AllFiltered(a.disabledTools) {
@@ -99,104 +198,177 @@ func (a *Agent) SetDisabledTools(disabledTools []string) {
 	a.disabledTools = disabledTools
 }
 
+// toolEnabled reports whether the model can call the named tool
+func (a *Agent) toolEnabled(name string) bool {
+	if _, ok := a.toolRegistry.Get(name); !ok {
+		return false
+	}
+	for _, disabled := range a.disabledTools {
+		if disabled == name {
+			return false
+		}
+	}
+	return true
+}
+
 func (a *Agent) Chat(ctx context.Context, userMessage string) (*Response, error) {
+	return a.ChatStream(ctx, userMessage, nil)
+}
+
+// ChatStream is like Chat but streams model output to onChunk as it arrives.
+// A nil onChunk makes plain non-streaming requests.
+func (a *Agent) ChatStream(ctx context.Context, userMessage string, onChunk StreamFunc) (*Response, error) {
 	logger.Log("Agent.Chat: Starting chat with message: %q", userMessage)
 	logger.LogConversation("USER", userMessage)
 
-	a.messages = append(a.messages, ollama.Message{
+	// Whatever the model changes itself this turn is not news next turn
+	if a.fileWatcher != nil {
+		if note := a.fileWatcher.Note(); note != "" {
+			a.AddContext(note)
+		}
+		defer a.fileWatcher.Snapshot()
+	}
+
+	a.appendMessage(ollama.Message{
 		Role:    "user",
 		Content: userMessage,
 	})
 
-	maxIterations := 10
+	a.mu.Lock()
+	a.exchanges = nil
+	a.mu.Unlock()
+
 	var response Response
+	var last *ollama.ChatResponse
+	usage := newTurnUsage(a.config.TurnBudget)
+
+	loop := core.Loop{
+		Format: a.ToolCallFormat(),
+		Reply: func(ctx context.Context, i int) (core.Message, error) {
+			logger.Log("Agent.Chat: Iteration %d/%d", i+1, core.DefaultMaxIterations)
+			if err := a.checkTime(ctx, usage); err != nil {
+				return core.Message{}, err
+			}
 
-	for i := 0; i < maxIterations; i++ {
-		logger.Log("Agent.Chat: Iteration %d/%d", i+1, maxIterations)
-		chatResp, err := a.performChat(ctx)
-		if err != nil {
-			logger.Log("Agent.Chat: performChat error: %v", err)
-			return nil, fmt.Errorf("chat request: %w", err)
-		}
+			chatResp, err := a.performChat(ctx, i, onChunk)
+			if err != nil {
+				logger.Log("Agent.Chat: performChat error: %v", err)
+				return core.Message{}, fmt.Errorf("chat request: %w", err)
+			}
 
-		logger.Log("Agent.Chat: Got response from model, content length: %d", len(chatResp.Message.Content))
-		logger.Log("Agent.Chat: Response content: %q", chatResp.Message.Content)
-		logger.LogConversation("ASSISTANT", chatResp.Message.Content)
-
-		a.messages = append(a.messages, chatResp.Message)
-
-		// Parse tool calls based on format
-		toolCalls := a.extractToolCalls(chatResp)
-
-		if len(toolCalls) == 0 {
-			// No tool calls - check if we got an empty response which might indicate wrong tool format
-			if len(strings.TrimSpace(chatResp.Message.Content)) == 0 && i == 0 {
-				logger.Log("Agent.Chat: Empty response on first iteration, might be wrong tool format")
-
-				// Try switching to native format if we're not already using it
-				if a.toolCallFormat != "native" {
-					logger.Log("Agent.Chat: Attempting to reconfigure to native tool format")
-
-					// Update model capability in config
-					if cap, ok := a.config.ModelCapabilities[a.model]; ok {
-						cap.ToolCallFormat = "native"
-						cap.SupportsTools = true
-						a.config.ModelCapabilities[a.model] = cap
-					} else {
-						a.config.ModelCapabilities[a.model] = config.ModelCapability{
-							SupportsTools:  true,
-							ToolCallFormat: "native",
-							RecommendedFor: []string{"coding", "tool_use"},
-						}
-					}
-
-					// Save config
-					if err := a.config.Save(); err != nil {
-						logger.Log("Agent.Chat: Failed to save config: %v", err)
-					} else {
-						logger.Log("Agent.Chat: Saved new tool format configuration for %s", a.model)
-					}
-
-					// Update our format
-					a.toolCallFormat = "native"
-
-					// Restart the conversation with the new format
-					logger.Log("Agent.Chat: Restarting chat with native format")
-					return a.Chat(ctx, userMessage)
-				}
+			response.PromptTokens += chatResp.PromptEvalCount
+			response.CompletionTokens += chatResp.EvalCount
+
+			logger.Log("Agent.Chat: Got response from model, content length: %d", len(chatResp.Message.Content))
+			logger.Log("Agent.Chat: Response content: %q", chatResp.Message.Content)
+			logger.LogConversation("ASSISTANT", chatResp.Message.Content)
+
+			a.appendMessage(chatResp.Message)
+			last = chatResp
+
+			reply := core.Message{Role: chatResp.Message.Role, Content: chatResp.Message.Content}
+			for _, call := range chatResp.Message.ToolCalls {
+				reply.ToolCalls = append(reply.ToolCalls, core.ToolCall{Name: call.Function.Name, Args: call.Function.Arguments})
 			}
+			return reply, nil
+		},
+		Found: func(i int, reply core.Message, calls []core.ToolCall) {
+			a.recordParse(last, calls, i)
+		},
+		RunTool: func(ctx context.Context, i int, call core.ToolCall) error {
+			return a.runTool(ctx, call, &response, usage)
+		},
+		// Calls left when the turn ends early still get an answer, or the
+		// model would see them unanswered next turn
+		Skip: func(call core.ToolCall, err error) {
+			a.appendMessage(ollama.Message{Role: "tool", ToolName: call.Name, Content: fmt.Sprintf("Not run: %v", err)})
+		},
+	}
 
-			// No tool calls - we're done
-			// Collect the final response content (could be just text or text + reasoning about tool results)
-			response.Content = chatResp.Message.Content
-			return &response, nil
-		}
+	final, err := loop.Run(ctx)
+	if errors.Is(err, core.ErrMaxIterations) {
+		logger.Log("Agent.Chat: Max iterations reached!")
+	}
+	if err != nil {
+		return nil, err
+	}
 
-		// Execute tool calls
-		if err := a.executeToolCalls(ctx, toolCalls, &response); err != nil {
-			return nil, err
+	// An empty first reply might indicate the wrong tool format
+	if strings.TrimSpace(final.Content) == "" && len(response.ToolCalls) == 0 && a.ToolCallFormat() != "native" {
+		logger.Log("Agent.Chat: Empty response on first iteration, attempting to reconfigure to native tool format")
+
+		// Update model capability in config and save
+		err := a.config.Update(func(c *config.Config) {
+			if c.ModelCapabilities == nil {
+				c.ModelCapabilities = make(map[string]config.ModelCapability)
+			}
+			if cap, ok := c.ModelCapabilities[a.model]; ok {
+				cap.ToolCallFormat = "native"
+				cap.SupportsTools = true
+				c.ModelCapabilities[a.model] = cap
+			} else {
+				c.ModelCapabilities[a.model] = config.ModelCapability{
+					SupportsTools:  true,
+					ToolCallFormat: "native",
+					RecommendedFor: []string{"coding", "tool_use"},
+				}
+			}
+		})
+		if err != nil {
+			logger.Log("Agent.Chat: Failed to save config: %v", err)
+		} else {
+			logger.Log("Agent.Chat: Saved new tool format configuration for %s", a.model)
 		}
 
-		// After executing tools, continue loop to let LLM respond with the results
-		// The LLM will see the tool results and provide a final answer
+		// Update our format
+		a.mu.Lock()
+		a.toolCallFormat = "native"
+		a.mu.Unlock()
+
+		// Restart the conversation with the new format
+		logger.Log("Agent.Chat: Restarting chat with native format")
+		return a.ChatStream(ctx, userMessage, onChunk)
 	}
 
-	logger.Log("Agent.Chat: Max iterations reached!")
-	return nil, fmt.Errorf("max iterations reached without completion")
+	response.Content = final.Content
+	response.Meta = last.Message.Meta
+	return &response, nil
 }
 
-func (a *Agent) performChat(ctx context.Context) (*ollama.ChatResponse, error) {
+func (a *Agent) performChat(ctx context.Context, iteration int, onChunk StreamFunc) (*ollama.ChatResponse, error) {
 	logger.Log("performChat: Using model %q with tool format %q", a.model, a.toolCallFormat)
-	logger.Log("performChat: Message count: %d", len(a.messages))
+	req := a.buildRequest(ctx, a.GetMessages())
+	logger.Log("performChat: Message count: %d", len(req.Messages))
+
+	start := time.Now()
+	ctx = a.recordExchange(ctx)
+	var resp *ollama.ChatResponse
+	var err error
+	if onChunk != nil {
+		resp, err = a.client.ChatStream(ctx, req, func(content string) {
+			onChunk(iteration, content)
+		})
+	} else {
+		resp, err = a.client.Chat(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp.Message.Meta = requestMeta(req, time.Since(start))
+	return resp, nil
+}
 
+// buildRequest assembles the request for history: pins and the task list
+// are added, the context pruned and, for native, the tools defined
+func (a *Agent) buildRequest(ctx context.Context, history []ollama.Message) ollama.ChatRequest {
 	req := ollama.ChatRequest{
 		Model:    a.model,
-		Messages: a.messages,
+		Messages: a.pruneContext(ctx, a.withTodos(a.withPins(history))),
 		Stream:   false,
 	}
 
 	// Add tools for native format only
-	if a.toolCallFormat == "native" {
+	if a.ToolCallFormat() == "native" {
 		ollamaTools := make([]ollama.Tool, 0)
 		for _, tool := range a.toolRegistry.AllFiltered(a.disabledTools) {
 			ollamaTools = append(ollamaTools, ollama.Tool{
@@ -209,214 +381,169 @@ func (a *Agent) performChat(ctx context.Context) (*ollama.ChatResponse, error) {
 			})
 		}
 		req.Tools = ollamaTools
-	} else {
 	}
+	return req
+}
 
-	resp, err := a.client.Chat(ctx, req)
-	if err != nil {
-		return nil, err
+// requestMeta describes the request that produced a reply
+func requestMeta(req ollama.ChatRequest, took time.Duration) *ollama.MessageMeta {
+	meta := &ollama.MessageMeta{Model: req.Model, Duration: took}
+	if t, ok := req.Options["temperature"].(float64); ok {
+		meta.Temperature = &t
 	}
-
-	return resp, nil
+	return meta
 }
 
-func (a *Agent) extractToolCalls(resp *ollama.ChatResponse) []ollama.ToolCall {
-
-	// Native tool calls (from message.tool_calls)
-	if len(resp.Message.ToolCalls) > 0 {
-		return resp.Message.ToolCalls
+// runTool runs a call the model made and adds its result to the
+// conversation, unless the turn budget stops it first
+func (a *Agent) runTool(ctx context.Context, call core.ToolCall, response *Response, usage *turnUsage) error {
+	if err := a.chargeTool(ctx, usage, call.Name, call.Args); err != nil {
+		return err
 	}
 
-	// Parse fallback formats
-	content := resp.Message.Content
-
-	var calls []ollama.ToolCall
-	switch a.toolCallFormat {
-	case "xml":
-		calls = a.parseXMLToolCalls(content)
-	case "json":
-		calls = a.parseJSONToolCalls(content)
-	case "text":
-		calls = a.parseTextToolCalls(content)
+	execution := ToolExecution{
+		Name: call.Name,
+		Args: call.Args,
 	}
 
-	return calls
-}
-
-func (a *Agent) parseXMLToolCalls(content string) []ollama.ToolCall {
-	var toolCalls []ollama.ToolCall
-
-	re := regexp.MustCompile(`(?s)<tool_call>(.*?)</tool_call>`)
-	matches := re.FindAllStringSubmatch(content, -1)
+	if a.toolObserver != nil {
+		a.toolObserver(execution, false)
+	}
 
-	for _, match := range matches {
-		if len(match) < 2 {
-			continue
-		}
+	toolCtx, attachments := tools.WithAttachments(tools.WithConversation(ctx, a.GetMessages))
+	start := time.Now()
+	execution.Started = start
+	result, err := a.toolRegistry.Execute(toolCtx, call.Name, call.Args)
+	if err == nil {
+		result = a.postProcess(ctx, call.Name, call.Args, result)
+	}
 
-		toolCallContent := match[1]
+	execution.Result = result
+	execution.Error = err
+	execution.Duration = time.Since(start)
+	logger.LogToolCall(execution.Name, execution.Args, result, err, execution.Duration)
+	if a.toolObserver != nil {
+		a.toolObserver(execution, true)
+	}
 
-		// Extract name
-		nameRe := regexp.MustCompile(`<name>(.*?)</name>`)
-		nameMatch := nameRe.FindStringSubmatch(toolCallContent)
-		if len(nameMatch) < 2 {
-			continue
-		}
-		name := strings.TrimSpace(nameMatch[1])
-
-		// Extract arguments
-		argsRe := regexp.MustCompile(`(?s)<arguments>(.*?)</arguments>`)
-		argsMatch := argsRe.FindStringSubmatch(toolCallContent)
-
-		var args map[string]interface{}
-		if len(argsMatch) >= 2 {
-			argsJSON := strings.TrimSpace(argsMatch[1])
-			if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-				// Log parsing error but continue with empty args
-				args = make(map[string]interface{})
-			}
-		}
+	response.ToolCalls = append(response.ToolCalls, execution)
 
-		if args == nil {
-			args = make(map[string]interface{})
-		}
+	toolResultMsg := ollama.Message{
+		Role:     "tool",
+		ToolName: call.Name, // Required by Ollama API
+	}
 
-		toolCalls = append(toolCalls, ollama.ToolCall{
-			Function: ollama.ToolCallFunction{
-				Name:      name,
-				Arguments: args,
-			},
-		})
+	if err != nil {
+		toolResultMsg.Content = fmt.Sprintf("Error executing tool %s: %v", call.Name, err)
+	} else {
+		toolResultMsg.Content = a.truncateToolResult(call.Name, result)
+	}
+	if images := attachments.Images(); len(images) > 0 {
+		a.attachImages(ctx, &toolResultMsg, images)
 	}
 
-	return toolCalls
+	a.appendMessage(toolResultMsg)
+	return nil
 }
 
-func (a *Agent) parseJSONToolCalls(content string) []ollama.ToolCall {
-	var toolCalls []ollama.ToolCall
-
-	// Look for ```json blocks
-	re := regexp.MustCompile("(?s)```json\\s*\\n(.*?)\\n```")
-	matches := re.FindAllStringSubmatch(content, -1)
-
-	for _, match := range matches {
-		if len(match) < 2 {
-			continue
-		}
-
-		var data map[string]interface{}
-		if err := json.Unmarshal([]byte(match[1]), &data); err != nil {
-			continue
-		}
-
-		if toolCallData, ok := data["tool_call"].(map[string]interface{}); ok {
-			name, nameOk := toolCallData["name"].(string)
-			args, argsOk := toolCallData["arguments"].(map[string]interface{})
-
-			if !nameOk {
-				continue // Skip if name is missing
-			}
-
-			if !argsOk || args == nil {
-				args = make(map[string]interface{})
-			}
-
-			toolCalls = append(toolCalls, ollama.ToolCall{
-				Function: ollama.ToolCallFunction{
-					Name:      name,
-					Arguments: args,
-				},
-			})
-		}
+// truncateToolResult shortens a result that is over the tool output budget
+// to a preview, keeping the whole text for fetch_more
+func (a *Agent) truncateToolResult(tool, result string) string {
+	budget := a.config.ToolOutput.Budget()
+	if budget == 0 || tool == "fetch_more" || len(result)/4 <= budget {
+		return result
 	}
-
-	return toolCalls
+	handle := a.toolRegistry.Outputs().Put(result)
+	preview, next := tools.Page(result, 0, a.config.ToolOutput.PageBytes())
+	logger.Log("truncateToolResult: %s returned %d bytes, stored as %s", tool, len(result), handle)
+	return preview + fmt.Sprintf("\n[Output truncated: showing bytes 0-%d of %d (~%d tokens). Call fetch_more with handle %q and offset %d to read more.]",
+		next, len(result), len(result)/4, handle, next)
 }
 
-func (a *Agent) parseTextToolCalls(content string) []ollama.ToolCall {
-	var toolCalls []ollama.ToolCall
-
-	lines := strings.Split(content, "\n")
-	var currentName string
-
-	for i := 0; i < len(lines); i++ {
-		line := strings.TrimSpace(lines[i])
-
-		// Look for USE_TOOL: pattern
-		if strings.HasPrefix(line, "USE_TOOL:") {
-			currentName = strings.TrimSpace(strings.TrimPrefix(line, "USE_TOOL:"))
-
-			// Look for ARGS: on next line
-			if i+1 < len(lines) {
-				nextLine := strings.TrimSpace(lines[i+1])
-				if strings.HasPrefix(nextLine, "ARGS:") {
-					argsJSON := strings.TrimSpace(strings.TrimPrefix(nextLine, "ARGS:"))
-
-					var args map[string]interface{}
-					if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-						// Log parsing error but continue with empty args
-						args = make(map[string]interface{})
-					}
-
-					if args == nil {
-						args = make(map[string]interface{})
-					}
-
-					toolCalls = append(toolCalls, ollama.ToolCall{
-						Function: ollama.ToolCallFunction{
-							Name:      currentName,
-							Arguments: args,
-						},
-					})
-
-					i++ // Skip the ARGS line
-				}
-			}
-		}
+// attachImages adds images from a tool to its result when the model can see
+// them, and otherwise tells the model it can't
+func (a *Agent) attachImages(ctx context.Context, msg *ollama.Message, images [][]byte) {
+	vision := false
+	if cap, ok := a.config.GetCapability(a.Model()); ok {
+		vision = cap.Vision
+	} else if details, err := a.client.ShowModel(ctx, a.Model()); err == nil {
+		vision = details.HasCapability("vision")
+	}
+	if !vision {
+		msg.Content += fmt.Sprintf("\n(%d image(s) not attached: %s cannot see images. Suggest the user switch to a vision-capable model.)", len(images), a.Model())
+		return
+	}
+	for _, img := range images {
+		msg.Images = append(msg.Images, base64.StdEncoding.EncodeToString(img))
 	}
-
-	return toolCalls
 }
 
-func (a *Agent) executeToolCalls(ctx context.Context, toolCalls []ollama.ToolCall, response *Response) error {
-
-	for _, toolCall := range toolCalls {
+// SetToolObserver sets a callback that follows tool calls as they run
+func (a *Agent) SetToolObserver(observer ToolObserver) {
+	a.toolObserver = observer
+}
 
-		execution := ToolExecution{
-			Name: toolCall.Function.Name,
-			Args: toolCall.Function.Arguments,
-		}
+// GetMessages returns a copy of the conversation history
+func (a *Agent) GetMessages() []ollama.Message {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	messages := make([]ollama.Message, len(a.messages))
+	copy(messages, a.messages)
+	return messages
+}
 
-		result, err := a.toolRegistry.Execute(ctx, toolCall.Function.Name, toolCall.Function.Arguments)
+// RestoreMessages replaces the conversation history with previously saved
+// messages. The agent's own system prompt is kept so the restored
+// conversation matches the current model's tool format.
+func (a *Agent) RestoreMessages(saved []ollama.Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 
-		if err != nil {
-		} else {
+	restored := make([]ollama.Message, 0, len(saved)+1)
+	for _, msg := range a.messages {
+		if msg.Role == "system" {
+			restored = append(restored, msg)
 		}
-
-		execution.Result = result
-		execution.Error = err
-
-		response.ToolCalls = append(response.ToolCalls, execution)
-
-		toolResultMsg := ollama.Message{
-			Role:     "tool",
-			ToolName: toolCall.Function.Name, // Required by Ollama API
+	}
+	for _, msg := range saved {
+		if msg.Role != "system" {
+			restored = append(restored, msg)
 		}
+	}
+	a.messages = restored
+}
 
-		if err != nil {
-			toolResultMsg.Content = fmt.Sprintf("Error executing tool %s: %v", toolCall.Function.Name, err)
-		} else {
-			toolResultMsg.Content = result
+// RestoreSummarized is RestoreMessages for a conversation resumed from a
+// summary of its earlier turns: the summary goes before the recent messages.
+func (a *Agent) RestoreSummarized(summary string, recent []ollama.Message) {
+	a.RestoreMessages(recent)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	note := ollama.Message{Role: "system", Content: "Summary of the earlier part of this conversation, which is not repeated here:\n\n" + summary}
+	for i, msg := range a.messages {
+		if msg.Role != "system" {
+			a.messages = append(a.messages[:i], append([]ollama.Message{note}, a.messages[i:]...)...)
+			return
 		}
-
-		a.messages = append(a.messages, toolResultMsg)
 	}
+	a.messages = append(a.messages, note)
+}
 
-	return nil
+// Model returns the name of the model this agent talks to
+func (a *Agent) Model() string {
+	return a.model
 }
 
-func (a *Agent) GetMessages() []ollama.Message {
-	return a.messages
+// ToolCallFormat returns how tool calls are exchanged with the model
+func (a *Agent) ToolCallFormat() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.toolCallFormat
+}
+
+// Provider describes where the model is served from
+func (a *Agent) Provider() string {
+	return "ollama @ " + a.client.HostFor(a.model)
 }
 
 func (a *Agent) GetToolRegistry() *tools.Registry {
@@ -424,6 +551,8 @@ func (a *Agent) GetToolRegistry() *tools.Registry {
 }
 
 func (a *Agent) ClearHistory() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	systemMsgs := make([]ollama.Message, 0)
 	for _, msg := range a.messages {
 		if msg.Role == "system" {
@@ -433,6 +562,19 @@ func (a *Agent) ClearHistory() {
 	a.messages = systemMsgs
 }
 
+// FormatToolDuration shows fast tools in milliseconds and slow ones in
+// seconds, e.g. "3ms", "4.2s" or "1m5s"
+func FormatToolDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < 10*time.Second:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	default:
+		return d.Round(time.Second).String()
+	}
+}
+
 func FormatToolCall(tc ToolExecution) string {
 	argsJSON, err := json.MarshalIndent(tc.Args, "", "  ")
 	argsStr := string(argsJSON)
@@ -441,6 +583,9 @@ func FormatToolCall(tc ToolExecution) string {
 	}
 
 	result := fmt.Sprintf("🔧 Tool: %s\nArguments:\n%s\n", tc.Name, argsStr)
+	if tc.Duration > 0 {
+		result = fmt.Sprintf("🔧 Tool: %s · %s\nArguments:\n%s\n", tc.Name, FormatToolDuration(tc.Duration), argsStr)
+	}
 
 	if tc.Error != nil {
 		result += fmt.Sprintf("❌ Error: %v\n", tc.Error)
@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/tools"
+)
+
+// BudgetPrompt asks the user whether to keep going after a turn budget was
+// exceeded. reason describes the limit that was hit.
+type BudgetPrompt func(ctx context.Context, reason string) bool
+
+// turnUsage tracks what the agent has done since the user's last message
+type turnUsage struct {
+	limits       config.TurnBudgetConfig
+	started      time.Time
+	toolCalls    int
+	filesWritten int
+	commands     int
+}
+
+func newTurnUsage(limits config.TurnBudgetConfig) *turnUsage {
+	return &turnUsage{limits: limits, started: time.Now()}
+}
+
+// SetBudgetPrompt sets how the agent asks to continue when a turn budget is
+// exceeded. Without one the turn simply stops.
+func (a *Agent) SetBudgetPrompt(prompt BudgetPrompt) {
+	a.budgetPrompt = prompt
+}
+
+// checkTime stops or extends the turn once it has run too long
+func (a *Agent) checkTime(ctx context.Context, usage *turnUsage) error {
+	limit := time.Duration(usage.limits.MaxMinutes) * time.Minute
+	if limit <= 0 || time.Since(usage.started) < limit {
+		return nil
+	}
+
+	reason := fmt.Sprintf("this turn has been running for over %d minutes", usage.limits.MaxMinutes)
+	if !a.askToContinue(ctx, reason) {
+		return fmt.Errorf("turn budget exceeded: %s", reason)
+	}
+	usage.started = time.Now()
+	return nil
+}
+
+// chargeTool counts a tool call against the budget before it runs
+func (a *Agent) chargeTool(ctx context.Context, usage *turnUsage, name string, args map[string]interface{}) error {
+	if err := a.checkTime(ctx, usage); err != nil {
+		return err
+	}
+
+	usage.toolCalls++
+	if limit := usage.limits.MaxToolCalls; limit > 0 && usage.toolCalls > limit {
+		reason := fmt.Sprintf("the model made more than %d tool calls", limit)
+		if !a.askToContinue(ctx, reason) {
+			return fmt.Errorf("turn budget exceeded: %s", reason)
+		}
+		usage.toolCalls = 1
+	}
+
+	level, ok := a.toolLevel(name)
+	if !ok {
+		return nil
+	}
+
+	switch level {
+	case tools.PermissionWrite:
+		files := a.filesWritten(name, args)
+		usage.filesWritten += files
+		if limit := usage.limits.MaxFilesWritten; limit > 0 && usage.filesWritten > limit {
+			reason := fmt.Sprintf("the model wrote more than %d files", limit)
+			if !a.askToContinue(ctx, reason) {
+				return fmt.Errorf("turn budget exceeded: %s", reason)
+			}
+			usage.filesWritten = files
+		}
+	case tools.PermissionExecute:
+		usage.commands++
+		if limit := usage.limits.MaxCommands; limit > 0 && usage.commands > limit {
+			reason := fmt.Sprintf("the model ran more than %d commands", limit)
+			if !a.askToContinue(ctx, reason) {
+				return fmt.Errorf("turn budget exceeded: %s", reason)
+			}
+			usage.commands = 1
+		}
+	}
+
+	return nil
+}
+
+func (a *Agent) askToContinue(ctx context.Context, reason string) bool {
+	if a.budgetPrompt == nil {
+		return false
+	}
+	return a.budgetPrompt(ctx, reason)
+}
+
+// filesWritten is how many files a write tool call changes: one, or every
+// path of a tool like apply_changes
+func (a *Agent) filesWritten(name string, args map[string]interface{}) int {
+	tool, ok := a.toolRegistry.Get(name)
+	if !ok {
+		return 1
+	}
+	if pt, ok := tool.(*tools.ProtectedTool); ok {
+		tool = pt.UnwrapTool()
+	}
+	if multi, ok := tool.(tools.MultiPathTool); ok {
+		return max(len(multi.TargetPaths(args)), 1)
+	}
+	return 1
+}
+
+func (a *Agent) toolLevel(name string) (tools.PermissionLevel, bool) {
+	tool, ok := a.toolRegistry.Get(name)
+	if !ok {
+		return 0, false
+	}
+	pt, ok := tool.(*tools.ProtectedTool)
+	if !ok {
+		return 0, false
+	}
+	return pt.Level(), true
+}
@@ -0,0 +1,4 @@
+package agent
+
+// askUserGuidance is added to the system prompt when ask_user is on
+const askUserGuidance = `When a request is ambiguous in a way that changes what you would do, or needs a decision only the user can make, call ask_user with one specific question, likely options and the assumption you would otherwise make, and continue with the answer. Don't ask about things you can find out with your other tools, and don't ask more than you need. When you go ahead without asking, say what you assumed and how sure you are.`
@@ -0,0 +1,306 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/logger"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+)
+
+const (
+	defaultKeepRecent    = 8
+	defaultContextLength = 8192
+	relevanceWeight      = 0.7  // The rest of an exchange's score is recency
+	embedTextLimit       = 2000 // Runes of an exchange that are embedded
+	embedCacheLimit      = 500  // Cached embeddings; past it only those still in use are kept
+)
+
+// exchange is a user message with the replies and tool results that followed
+// it, so tool calls are never separated from their results
+type exchange struct {
+	messages []ollama.Message
+	tokens   int
+	score    float64
+}
+
+// estimateTokens guesses tokens at about four characters each
+func estimateTokens(msg ollama.Message) int {
+	n := len(msg.Content)
+	for _, call := range msg.ToolCalls {
+		args, _ := json.Marshal(call.Function.Arguments)
+		n += len(call.Function.Name) + len(args)
+	}
+	return n/4 + 4
+}
+
+// EstimateTokens guesses how many tokens msgs take in a request
+func EstimateTokens(msgs []ollama.Message) int {
+	total := 0
+	for _, msg := range msgs {
+		total += estimateTokens(msg)
+	}
+	return total
+}
+
+// ContextLength is the model's context window in tokens, 8192 if unknown
+func (a *Agent) ContextLength() int {
+	if cap, ok := a.config.GetCapability(a.Model()); ok && cap.ContextLength > 0 {
+		return cap.ContextLength
+	}
+	return defaultContextLength
+}
+
+// contextBudget is how many tokens a request may use
+func (a *Agent) contextBudget() int {
+	if limit := a.config.ContextPruning.MaxTokens; limit > 0 {
+		return limit
+	}
+	return a.ContextLength() * 3 / 4
+}
+
+// pruneContext leaves the least valuable older exchanges out of a request
+// once the history is over budget. System messages, the current turn and the
+// newest messages are always sent; what was left out is listed in a note.
+func (a *Agent) pruneContext(ctx context.Context, messages []ollama.Message) []ollama.Message {
+	settings := a.config.ContextPruning
+	if !settings.Enabled {
+		return messages
+	}
+	budget := a.contextBudget()
+	total := 0
+	for _, msg := range messages {
+		total += estimateTokens(msg)
+	}
+	if total <= budget {
+		return messages
+	}
+
+	lastUser := -1
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			lastUser = i
+			break
+		}
+	}
+	if lastUser < 0 {
+		return messages
+	}
+	keepRecent := settings.KeepRecent
+	if keepRecent <= 0 {
+		keepRecent = defaultKeepRecent
+	}
+	keepFrom := lastUser
+	if len(messages)-keepRecent < keepFrom {
+		keepFrom = max(len(messages)-keepRecent, 0)
+	}
+	for keepFrom > 0 && messages[keepFrom].Role != "user" {
+		keepFrom--
+	}
+
+	// Split the older messages into system messages, which stay, and exchanges
+	var system []ollama.Message
+	var exchanges []*exchange
+	available := budget
+	for _, msg := range messages[:keepFrom] {
+		if msg.Role == "system" {
+			system = append(system, msg)
+			available -= estimateTokens(msg)
+			continue
+		}
+		if msg.Role == "user" || len(exchanges) == 0 {
+			exchanges = append(exchanges, &exchange{})
+		}
+		ex := exchanges[len(exchanges)-1]
+		ex.messages = append(ex.messages, msg)
+		ex.tokens += estimateTokens(msg)
+	}
+	for _, msg := range messages[keepFrom:] {
+		available -= estimateTokens(msg)
+	}
+	if len(exchanges) == 0 {
+		return messages
+	}
+
+	relevance := a.relevance(ctx, messages[lastUser].Content, exchanges)
+	for i, ex := range exchanges {
+		recency := float64(i+1) / float64(len(exchanges))
+		ex.score = relevanceWeight*relevance[i] + (1-relevanceWeight)*recency
+	}
+	order := make([]int, len(exchanges))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return exchanges[order[i]].score > exchanges[order[j]].score })
+
+	kept := make([]bool, len(exchanges))
+	for _, i := range order {
+		if exchanges[i].tokens <= available {
+			kept[i] = true
+			available -= exchanges[i].tokens
+		}
+	}
+
+	result := append([]ollama.Message{}, system...)
+	var dropped []string
+	for i, ex := range exchanges {
+		if kept[i] {
+			result = append(result, ex.messages...)
+		} else if ex.messages[0].Role == "user" {
+			dropped = append(dropped, truncateRunes(strings.Join(strings.Fields(ex.messages[0].Content), " "), 80))
+		} else {
+			dropped = append(dropped, "(messages before the first question)")
+		}
+	}
+	if len(dropped) == 0 {
+		return messages
+	}
+	logger.Log("pruneContext: left out %d of %d older exchanges (~%d tokens, budget %d)", len(dropped), len(exchanges), total, budget)
+	result = append(result, ollama.Message{Role: "system", Content: droppedNote(dropped)})
+	return append(result, messages[keepFrom:]...)
+}
+
+// droppedNote tells the model which earlier exchanges it is not seeing
+func droppedNote(dropped []string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d earlier exchanges were left out of this request to fit the context. They began with:\n", len(dropped)))
+	for i, first := range dropped {
+		if i == 10 {
+			sb.WriteString(fmt.Sprintf("- ... and %d more\n", len(dropped)-i))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("- %q\n", first))
+	}
+	sb.WriteString("Ask the user if you need details from them.")
+	return sb.String()
+}
+
+// relevance scores each exchange against the query between 0 and 1, with
+// embeddings when an embedding model is configured and shared words otherwise
+func (a *Agent) relevance(ctx context.Context, query string, exchanges []*exchange) []float64 {
+	texts := make([]string, len(exchanges))
+	for i, ex := range exchanges {
+		var sb strings.Builder
+		for _, msg := range ex.messages {
+			sb.WriteString(msg.Content + "\n")
+		}
+		texts[i] = truncateRunes(sb.String(), embedTextLimit)
+	}
+
+	if model := a.config.ContextPruning.EmbeddingModel; model != "" {
+		scores, err := a.embeddingRelevance(ctx, model, truncateRunes(query, embedTextLimit), texts)
+		if err == nil {
+			return scores
+		}
+		logger.Log("pruneContext: embeddings from %s failed, using shared words: %v", model, err)
+	}
+
+	queryWords := words(query)
+	scores := make([]float64, len(texts))
+	if len(queryWords) == 0 {
+		return scores
+	}
+	for i, text := range texts {
+		shared := 0
+		textWords := words(text)
+		for word := range queryWords {
+			if textWords[word] {
+				shared++
+			}
+		}
+		scores[i] = float64(shared) / float64(len(queryWords))
+	}
+	return scores
+}
+
+// embeddingRelevance embeds what isn't cached yet and returns cosine
+// similarities to the query, clamped to 0..1
+func (a *Agent) embeddingRelevance(ctx context.Context, model, query string, texts []string) ([]float64, error) {
+	a.mu.Lock()
+	if a.embeddings == nil || a.embeddingModel != model {
+		a.embeddings = make(map[string][]float64)
+		a.embeddingModel = model
+	}
+	var missing []string
+	for _, text := range append([]string{query}, texts...) {
+		if _, ok := a.embeddings[text]; !ok {
+			missing = append(missing, text)
+		}
+	}
+	a.mu.Unlock()
+
+	if len(missing) > 0 {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		vectors, err := a.client.Embed(ctx, model, missing)
+		if err != nil {
+			return nil, err
+		}
+		a.mu.Lock()
+		if len(a.embeddings)+len(missing) > embedCacheLimit {
+			// Drop what this request doesn't need: earlier queries and
+			// exchanges no longer in the history
+			kept := make(map[string][]float64, len(texts)+1)
+			for _, text := range append([]string{query}, texts...) {
+				if vec, ok := a.embeddings[text]; ok {
+					kept[text] = vec
+				}
+			}
+			a.embeddings = kept
+		}
+		for i, text := range missing {
+			a.embeddings[text] = vectors[i]
+		}
+		a.mu.Unlock()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	queryVec := a.embeddings[query]
+	scores := make([]float64, len(texts))
+	for i, text := range texts {
+		scores[i] = math.Max(0, cosine(queryVec, a.embeddings[text]))
+	}
+	return scores, nil
+}
+
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// words returns the distinct lowercase words of at least three letters
+func words(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !(r == '_' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127)
+	}) {
+		if len([]rune(word)) >= 3 {
+			set[word] = true
+		}
+	}
+	return set
+}
+
+func truncateRunes(s string, max int) string {
+	if r := []rune(s); len(r) > max {
+		return string(r[:max]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,224 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	core "github.com/LaPingvino/llemecode/pkg/agent"
+)
+
+// ParseReport explains how the last model reply was searched for tool calls,
+// so a model that talks instead of using tools can be debugged
+type ParseReport struct {
+	Model     string
+	Format    string // Tool call format the reply was parsed with
+	Iteration int    // Model request within the turn, from 0
+	At        time.Time
+	Content   string
+	Native    int          // Calls the server returned in tool_calls
+	Calls     []string     // Names of the tool calls found, native or parsed
+	Checks    []ParseCheck // What the fallback parser looked for; none for native
+	Hints     []string     // Likely reasons no call was found
+	Tools     []string     // Tools the model was offered, sorted
+}
+
+// ParseCheck is one pattern the parser tried and how far replies got
+type ParseCheck struct {
+	Pattern string
+	Matches int
+	Note    string // What went wrong after matching, if anything
+}
+
+// textToolPattern matches the text format's tool lines, for reports only
+var textToolPattern = regexp.MustCompile(`(?m)^\s*USE_TOOL:`)
+
+// nativeAsText matches a native style call written out in a reply, e.g.
+// {"name": "read_file", "arguments": {...}}
+var nativeAsText = regexp.MustCompile(`\{\s*"name"\s*:\s*"[\w.-]+"\s*,\s*"(?:arguments|parameters)"\s*:`)
+
+// recordParse keeps the report for the reply just parsed
+func (a *Agent) recordParse(resp *ollama.ChatResponse, calls []core.ToolCall, iteration int) {
+	format := a.ToolCallFormat()
+	report := &ParseReport{
+		Model:     a.model,
+		Format:    format,
+		Iteration: iteration,
+		At:        time.Now(),
+		Content:   resp.Message.Content,
+		Native:    len(resp.Message.ToolCalls),
+	}
+	for _, call := range calls {
+		report.Calls = append(report.Calls, call.Name)
+	}
+	for _, tool := range a.toolRegistry.AllFiltered(a.disabledTools) {
+		report.Tools = append(report.Tools, tool.Name())
+	}
+	sort.Strings(report.Tools)
+	if report.Native == 0 {
+		report.Checks = parseChecks(format, resp.Message.Content)
+	}
+	report.Hints = parseHints(report)
+
+	a.mu.Lock()
+	a.lastParse = report
+	a.mu.Unlock()
+}
+
+// LastParse returns how the last reply was searched for tool calls, or nil
+// before the first reply
+func (a *Agent) LastParse() *ParseReport {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.lastParse
+}
+
+// recordExchange returns ctx set to keep the raw request and response of the
+// model request made with it
+func (a *Agent) recordExchange(ctx context.Context) context.Context {
+	ex := &ollama.Exchange{}
+	a.mu.Lock()
+	a.exchanges = append(a.exchanges, ex)
+	a.mu.Unlock()
+	return ollama.WithExchange(ctx, ex)
+}
+
+// LastExchanges returns the raw requests sent to Ollama in the last turn and
+// what came back, oldest first. Requests write their exchange as they run, so
+// call it between turns.
+func (a *Agent) LastExchanges() []ollama.Exchange {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	exchanges := make([]ollama.Exchange, 0, len(a.exchanges))
+	for _, ex := range a.exchanges {
+		exchanges = append(exchanges, *ex)
+	}
+	return exchanges
+}
+
+// parseChecks replays the fallback parser for format on content, counting
+// how many candidates each step kept
+func parseChecks(format, content string) []ParseCheck {
+	switch format {
+	case "xml":
+		blocks := core.XMLToolCallPattern.FindAllStringSubmatch(content, -1)
+		named, badArgs := 0, 0
+		for _, block := range blocks {
+			if core.XMLNamePattern.MatchString(block[1]) {
+				named++
+			}
+			if args := core.XMLArgsPattern.FindStringSubmatch(block[1]); args != nil && !json.Valid([]byte(strings.TrimSpace(args[1]))) {
+				badArgs++
+			}
+		}
+		checks := []ParseCheck{{Pattern: core.XMLToolCallPattern.String(), Matches: len(blocks)}}
+		if len(blocks) > 0 {
+			check := ParseCheck{Pattern: core.XMLNamePattern.String(), Matches: named}
+			if named < len(blocks) {
+				check.Note = fmt.Sprintf("%d <tool_call> blocks have no <name> and were skipped", len(blocks)-named)
+			}
+			checks = append(checks, check)
+		}
+		if badArgs > 0 {
+			checks = append(checks, ParseCheck{Pattern: core.XMLArgsPattern.String(), Matches: badArgs, Note: fmt.Sprintf("%d <arguments> are not valid JSON and were replaced by no arguments", badArgs)})
+		}
+		return checks
+
+	case "json":
+		blocks := core.JSONBlockPattern.FindAllStringSubmatch(content, -1)
+		valid, calls := 0, 0
+		for _, block := range blocks {
+			var data map[string]interface{}
+			if json.Unmarshal([]byte(block[1]), &data) != nil {
+				continue
+			}
+			valid++
+			if call, ok := data["tool_call"].(map[string]interface{}); ok {
+				if _, ok := call["name"].(string); ok {
+					calls++
+				}
+			}
+		}
+		check := ParseCheck{Pattern: core.JSONBlockPattern.String(), Matches: len(blocks)}
+		switch {
+		case valid < len(blocks):
+			check.Note = fmt.Sprintf("%d of %d ```json blocks are not valid JSON objects", len(blocks)-valid, len(blocks))
+		case calls < valid:
+			check.Note = fmt.Sprintf(`%d blocks have no "tool_call" object with a "name"`, valid-calls)
+		}
+		return []ParseCheck{check}
+
+	case "text":
+		lines := strings.Split(content, "\n")
+		found, withArgs := 0, 0
+		for i, line := range lines {
+			if !strings.HasPrefix(strings.TrimSpace(line), "USE_TOOL:") {
+				continue
+			}
+			found++
+			if i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i+1]), "ARGS:") {
+				withArgs++
+			}
+		}
+		check := ParseCheck{Pattern: "USE_TOOL: <name> followed by an ARGS: <json> line", Matches: withArgs}
+		if withArgs < found {
+			check.Note = fmt.Sprintf("%d USE_TOOL: lines have no ARGS: line after them and were skipped", found-withArgs)
+		}
+		return []ParseCheck{check}
+	}
+	return nil
+}
+
+// parseHints guesses why a reply had no tool calls: calls in another format,
+// tools only mentioned by name, or calls to tools that don't exist
+func parseHints(report *ParseReport) []string {
+	var hints []string
+	content := report.Content
+	if len(report.Calls) == 0 {
+		if strings.TrimSpace(content) == "" {
+			hints = append(hints, "The reply was empty. Models that expect native tools sometimes answer nothing when given another format.")
+		}
+		if report.Format != "xml" && core.XMLToolCallPattern.MatchString(content) {
+			hints = append(hints, "The reply contains <tool_call> blocks, which the xml format reads. Set tool_call_format to xml for this model.")
+		}
+		if report.Format != "json" && core.JSONBlockPattern.MatchString(content) && strings.Contains(content, `"tool_call"`) {
+			hints = append(hints, "The reply contains ```json blocks with a tool_call, which the json format reads. Set tool_call_format to json for this model.")
+		}
+		if report.Format != "text" && textToolPattern.MatchString(content) {
+			hints = append(hints, "The reply contains USE_TOOL: lines, which the text format reads. Set tool_call_format to text for this model.")
+		}
+		if nativeAsText.MatchString(content) {
+			hints = append(hints, `The reply writes a call like {"name": ..., "arguments": ...} as text instead of sending it. With native, the model or its template may not support tools; try the json or xml format.`)
+		}
+		if report.Format == "native" && len(hints) == 0 && strings.TrimSpace(content) != "" {
+			hints = append(hints, "With the native format, the Ollama server parses tool calls using the model's template; it found none. Check that `ollama show` lists tools under the model's capabilities.")
+		}
+		var mentioned []string
+		for _, name := range report.Tools {
+			if strings.Contains(content, name) {
+				mentioned = append(mentioned, name)
+			}
+		}
+		if len(mentioned) > 0 {
+			hints = append(hints, fmt.Sprintf("The reply names %s but doesn't call it. The model may be describing the call rather than making it.", strings.Join(mentioned, ", ")))
+		}
+	}
+	offered := make(map[string]bool, len(report.Tools))
+	for _, name := range report.Tools {
+		offered[name] = true
+	}
+	for _, name := range report.Calls {
+		if !offered[name] {
+			hints = append(hints, fmt.Sprintf("The model called %s, which is not an enabled tool.", name))
+		}
+	}
+	if len(report.Tools) == 0 {
+		hints = append(hints, "No tools are enabled, so there was nothing to call.")
+	}
+	return hints
+}
@@ -0,0 +1,257 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/logger"
+)
+
+const (
+	defaultWatchedFiles = 2000
+	maxWatchedFileBytes = 256 * 1024 // Larger files are not tracked
+	maxRereadBytes      = 8 * 1024   // Larger changed files are only listed
+	maxListedChanges    = 20
+)
+
+// skippedWatchDirs are never walked; hidden directories are skipped too
+var skippedWatchDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"target":       true,
+	"dist":         true,
+	"build":        true,
+	"__pycache__":  true,
+}
+
+// FileChange is a file created, edited or deleted since the last snapshot
+type FileChange struct {
+	Path    string // Absolute
+	Added   int    // Lines
+	Removed int
+	Created bool
+	Deleted bool
+}
+
+// String shows the change like "main.go (+12/-3 lines)", with the path
+// relative to the working directory when it is inside it
+func (c FileChange) String() string {
+	path := displayPath(c.Path)
+	switch {
+	case c.Created && c.Added == 1:
+		return fmt.Sprintf("%s (new, 1 line)", path)
+	case c.Created:
+		return fmt.Sprintf("%s (new, %d lines)", path, c.Added)
+	case c.Deleted:
+		return fmt.Sprintf("%s (deleted)", path)
+	}
+	return fmt.Sprintf("%s (+%d/-%d lines)", path, c.Added, c.Removed)
+}
+
+type watchedFile struct {
+	modTime time.Time
+	size    int64
+	lines   []uint64 // Sorted line hashes, so changes can be counted without keeping contents
+}
+
+// FileWatcher notices workspace files that change between turns. It
+// compares snapshots instead of subscribing to file events, so it needs no
+// platform support and costs nothing while the model is idle.
+type FileWatcher struct {
+	dirs     func() []string
+	maxFiles int
+	reread   bool
+	mu       sync.Mutex // Held while a snapshot is being taken
+	files    map[string]watchedFile
+}
+
+// NewFileWatcher starts taking the first snapshot of dirs in the background.
+// dirs is asked again for every snapshot, so roots added later are watched.
+func NewFileWatcher(dirs func() []string, cfg config.FileWatchConfig) *FileWatcher {
+	w := &FileWatcher{dirs: dirs, maxFiles: cfg.MaxFiles, reread: cfg.Reread}
+	if w.maxFiles <= 0 {
+		w.maxFiles = defaultWatchedFiles
+	}
+	w.mu.Lock()
+	go func() {
+		defer w.mu.Unlock()
+		w.files = w.scan(nil)
+	}()
+	return w
+}
+
+// Snapshot records the current state of the files, e.g. after the model
+// changed them itself
+func (w *FileWatcher) Snapshot() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.files = w.scan(w.files)
+}
+
+// Changes takes a new snapshot and returns what changed since the last one
+func (w *FileWatcher) Changes() []FileChange {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	current := w.scan(w.files)
+
+	var changes []FileChange
+	for path, now := range current {
+		before, existed := w.files[path]
+		switch {
+		case !existed:
+			changes = append(changes, FileChange{Path: path, Added: len(now.lines), Created: true})
+		case now.modTime.Equal(before.modTime) && now.size == before.size:
+		default:
+			added, removed := diffLines(before.lines, now.lines)
+			if added > 0 || removed > 0 {
+				changes = append(changes, FileChange{Path: path, Added: added, Removed: removed})
+			}
+		}
+	}
+	for path := range w.files {
+		if _, ok := current[path]; !ok {
+			changes = append(changes, FileChange{Path: path, Deleted: true})
+		}
+	}
+	w.files = current
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// Note describes the changes since the last snapshot for the model, or
+// returns "" when nothing changed
+func (w *FileWatcher) Note() string {
+	changes := w.Changes()
+	if len(changes) == 0 {
+		return ""
+	}
+	logger.Log("FileWatcher: %d files changed externally", len(changes))
+
+	var sb strings.Builder
+	sb.WriteString("Files changed outside this chat since your last turn, e.g. by the user in an editor:\n")
+	for i, change := range changes {
+		if i == maxListedChanges {
+			sb.WriteString(fmt.Sprintf("- ... and %d more\n", len(changes)-maxListedChanges))
+			break
+		}
+		sb.WriteString("- " + change.String() + "\n")
+	}
+	sb.WriteString("What you read of these files before may be out of date; read them again with read_file before relying on it.")
+
+	if w.reread {
+		for i, change := range changes {
+			if i == maxListedChanges || change.Deleted {
+				continue
+			}
+			data, err := os.ReadFile(change.Path)
+			if err != nil || len(data) > maxRereadBytes {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("\n\n### %s now\n```\n%s\n```", displayPath(change.Path), strings.TrimRight(string(data), "\n")))
+		}
+	}
+	return sb.String()
+}
+
+// scan walks the watched directories, reusing previous entries for files
+// whose size and modification time are unchanged
+func (w *FileWatcher) scan(previous map[string]watchedFile) map[string]watchedFile {
+	files := make(map[string]watchedFile)
+	for _, dir := range w.dirs() {
+		root, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if path != root && (strings.HasPrefix(d.Name(), ".") || skippedWatchDirs[d.Name()]) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if len(files) >= w.maxFiles {
+				return filepath.SkipAll
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			if _, seen := files[path]; seen {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil || info.Size() > maxWatchedFileBytes {
+				return nil
+			}
+			if old, ok := previous[path]; ok && old.modTime.Equal(info.ModTime()) && old.size == info.Size() {
+				files[path] = old
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil || bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+				return nil // Unreadable or binary
+			}
+			files[path] = watchedFile{modTime: info.ModTime(), size: info.Size(), lines: hashLines(data)}
+			return nil
+		})
+	}
+	return files
+}
+
+func hashLines(data []byte) []uint64 {
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	hashes := make([]uint64, len(lines))
+	for i, line := range lines {
+		h := fnv.New64a()
+		h.Write([]byte(line))
+		hashes[i] = h.Sum64()
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+	return hashes
+}
+
+// diffLines counts lines only in after (added) and only in before (removed).
+// Moved lines are not counted, which is close enough for a summary.
+func diffLines(before, after []uint64) (added, removed int) {
+	i, j := 0, 0
+	for i < len(before) && j < len(after) {
+		switch {
+		case before[i] == after[j]:
+			i++
+			j++
+		case before[i] < after[j]:
+			removed++
+			i++
+		default:
+			added++
+			j++
+		}
+	}
+	return added + len(after) - j, removed + len(before) - i
+}
+
+// displayPath shows paths under the working directory relative to it
+func displayPath(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return path
+}
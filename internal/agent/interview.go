@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/tools"
+)
+
+// DefaultInterviewRounds is how many times each speaker talks by default
+const DefaultInterviewRounds = 3
+
+// InterviewSpeaker is one side of an interview
+type InterviewSpeaker struct {
+	Model string
+	Role  string // e.g. designer or critic
+}
+
+type InterviewOptions struct {
+	Task     string
+	Speakers [2]InterviewSpeaker
+	Rounds   int // Turns per speaker; DefaultInterviewRounds if 0
+}
+
+type InterviewTurn struct {
+	Round   int
+	Speaker InterviewSpeaker
+	Content string
+}
+
+// InterviewResult is the exchange and the moderator's summary of it
+type InterviewResult struct {
+	Turns   []InterviewTurn
+	Summary string
+}
+
+// Interview lets two models discuss a task in turns, with the agent's own
+// model moderating: it frames every turn and summarizes the consensus at the
+// end. Each turn streams through progress if it is not nil. On error the
+// turns so far are returned with it.
+func (a *Agent) Interview(ctx context.Context, opts InterviewOptions, progress tools.ModelProgress) (*InterviewResult, error) {
+	if opts.Rounds <= 0 {
+		opts.Rounds = DefaultInterviewRounds
+	}
+	result := &InterviewResult{}
+	for round := 1; round <= opts.Rounds; round++ {
+		for i, speaker := range opts.Speakers {
+			other := opts.Speakers[1-i]
+			messages := []ollama.Message{{Role: "system", Content: interviewSystemPrompt(opts.Task, speaker, other)}}
+			for _, turn := range result.Turns {
+				if turn.Speaker == speaker {
+					messages = append(messages, ollama.Message{Role: "assistant", Content: turn.Content})
+				} else {
+					messages = append(messages, ollama.Message{Role: "user", Content: fmt.Sprintf("The %s says:\n\n%s", turn.Speaker.Role, turn.Content)})
+				}
+			}
+			messages = append(messages, ollama.Message{Role: "user", Content: moderatorCue(round, opts.Rounds, result.Turns == nil, other)})
+
+			id := fmt.Sprintf("interview_%d_%d", round, i)
+			label := fmt.Sprintf("%s, round %d/%d", speaker.Role, round, opts.Rounds)
+			content, err := a.streamTurn(ctx, speaker.Model, messages, progress, id, label)
+			if err != nil {
+				return result, fmt.Errorf("%s (%s), round %d: %w", speaker.Role, speaker.Model, round, err)
+			}
+			result.Turns = append(result.Turns, InterviewTurn{Round: round, Speaker: speaker, Content: content})
+		}
+	}
+
+	var transcript strings.Builder
+	for _, turn := range result.Turns {
+		transcript.WriteString(fmt.Sprintf("## %s, round %d\n\n%s\n\n", turn.Speaker.Role, turn.Round, turn.Content))
+	}
+	messages := []ollama.Message{
+		{Role: "system", Content: "You moderated a discussion between two experts. Summarize it for the user: the agreed approach, the points that changed along the way, and any disagreements left open with both positions. Be concise and concrete."},
+		{Role: "user", Content: fmt.Sprintf("Task: %s\n\n%s", opts.Task, transcript.String())},
+	}
+	summary, err := a.streamTurn(ctx, a.Model(), messages, progress, "interview_summary", "summary")
+	if err != nil {
+		return result, fmt.Errorf("summarize the interview: %w", err)
+	}
+	result.Summary = summary
+	return result, nil
+}
+
+// streamTurn sends one request, streaming its answer through progress
+func (a *Agent) streamTurn(ctx context.Context, model string, messages []ollama.Message, progress tools.ModelProgress, id, label string) (string, error) {
+	req := ollama.ChatRequest{Model: model, Messages: messages}
+	var resp *ollama.ChatResponse
+	var err error
+	if progress != nil {
+		progress.Start(id, model, label)
+		resp, err = a.client.ChatStream(ctx, req, func(content string) {
+			progress.Chunk(id, content)
+		})
+		progress.End(id, err)
+	} else {
+		resp, err = a.client.Chat(ctx, req)
+	}
+	if err != nil {
+		return "", err
+	}
+	content := strings.TrimSpace(resp.Message.Content)
+	if content == "" {
+		return "", fmt.Errorf("%s gave an empty answer", model)
+	}
+	return content, nil
+}
+
+func interviewSystemPrompt(task string, speaker, other InterviewSpeaker) string {
+	return fmt.Sprintf("You are the %s in a moderated discussion with a %s. The task under discussion:\n\n%s\n\n"+
+		"Stay in your role. Answer the %s's latest points directly, concede when they are right and push back when they are not. "+
+		"Keep each turn short and concrete; the goal is an approach you both agree on.", speaker.Role, other.Role, task, other.Role)
+}
+
+// moderatorCue is the moderator's prompt for one turn
+func moderatorCue(round, rounds int, first bool, other InterviewSpeaker) string {
+	switch {
+	case first:
+		return "Moderator: please open the discussion with your proposal."
+	case round == rounds:
+		return fmt.Sprintf("Moderator: this is the final round. Respond to the %s and state what you now agree on and what you still don't.", other.Role)
+	default:
+		return fmt.Sprintf("Moderator: round %d of %d. Respond to the %s.", round, rounds, other.Role)
+	}
+}
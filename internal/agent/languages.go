@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// languageMarkers are files whose presence says what a project is written in
+var languageMarkers = map[string]string{
+	"go.mod":           "go",
+	"Cargo.toml":       "rust",
+	"pyproject.toml":   "python",
+	"setup.py":         "python",
+	"requirements.txt": "python",
+	"package.json":     "javascript",
+	"tsconfig.json":    "typescript",
+	"pom.xml":          "java",
+	"build.gradle":     "java",
+	"build.gradle.kts": "java",
+	"Gemfile":          "ruby",
+	"mix.exs":          "elixir",
+}
+
+// languageNames are the display names of detected languages
+var languageNames = map[string]string{
+	"go": "Go", "rust": "Rust", "python": "Python", "javascript": "JavaScript", "typescript": "TypeScript",
+	"java": "Java", "ruby": "Ruby", "elixir": "Elixir",
+}
+
+// defaultLanguageGuidance is the built-in advice per language; the config
+// can replace or turn off each entry
+var defaultLanguageGuidance = map[string]string{
+	"go":         "Format changed files with gofmt, run go vet ./... and go test ./... after changes, wrap errors with fmt.Errorf and %w, and prefer table-driven tests.",
+	"rust":       "Run cargo fmt, cargo clippy and cargo test after changes; return Result and use ? instead of unwrap outside tests.",
+	"python":     "Follow PEP 8 and the formatter the project configures (black or ruff in pyproject.toml), add type hints to new functions, and run the tests with pytest.",
+	"javascript": "Use the package manager the lockfile belongs to (npm, pnpm or yarn), run the lint and test scripts from package.json rather than guessing commands, and keep to the module style already used (ESM or CommonJS).",
+	"typescript": "Use the package manager the lockfile belongs to, type-check with tsc --noEmit after changes, run the scripts from package.json, and avoid any.",
+	"java":       "Build and test with the wrapper the project ships (./mvnw or ./gradlew) when there is one, and follow the existing package layout.",
+	"ruby":       "Run bundle exec rspec or rake test after changes, and follow the project's RuboCop settings.",
+	"elixir":     "Run mix format and mix test after changes.",
+}
+
+// DetectLanguages returns the languages of the projects in dirs, judged by
+// marker files at their top level and one directory down, e.g. a monorepo's
+// frontend and backend
+func DetectLanguages(dirs ...string) []string {
+	found := make(map[string]bool)
+	check := func(dir string) {
+		for marker, lang := range languageMarkers {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				found[lang] = true
+			}
+		}
+	}
+	for _, dir := range dirs {
+		check(dir)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !entry.IsDir() || strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor" {
+				continue
+			}
+			check(filepath.Join(dir, name))
+		}
+	}
+	// A TypeScript project has a package.json too
+	if found["typescript"] {
+		delete(found, "javascript")
+	}
+
+	langs := make([]string, 0, len(found))
+	for lang := range found {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// LanguageGuidance returns system prompt advice for the languages used in
+// dirs. overrides replaces the built-in advice per language; an empty
+// entry turns a language's advice off.
+func LanguageGuidance(overrides map[string]string, dirs ...string) string {
+	var sb strings.Builder
+	for _, lang := range DetectLanguages(dirs...) {
+		advice, ok := overrides[lang]
+		if !ok {
+			advice = defaultLanguageGuidance[lang]
+		}
+		if advice == "" {
+			continue
+		}
+		if sb.Len() == 0 {
+			sb.WriteString("Advice for the languages in this project:")
+		}
+		sb.WriteString("\n- " + languageNames[lang] + ": " + advice)
+	}
+	return sb.String()
+}
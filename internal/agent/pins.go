@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/ollama"
+)
+
+// maxPinnedFileBytes caps how much of a pinned file goes into every request
+const maxPinnedFileBytes = 64 * 1024
+
+// Pin is context sent with every request. Pins are kept apart from the
+// history, so clearing, compressing or switching models never drops them.
+type Pin struct {
+	Label string // How /pins shows it
+	Text  string // Pinned text; empty for a file
+	Path  string // File that is read again for every request
+}
+
+// AddPin pins text or a file
+func (a *Agent) AddPin(pin Pin) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pins = append(a.pins, pin)
+}
+
+// Pins returns the pins in the order they were added
+func (a *Agent) Pins() []Pin {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	pins := make([]Pin, len(a.pins))
+	copy(pins, a.pins)
+	return pins
+}
+
+// RemovePin unpins pin i (0-based)
+func (a *Agent) RemovePin(i int) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if i < 0 || i >= len(a.pins) {
+		return fmt.Errorf("no pin %d", i+1)
+	}
+	a.pins = append(a.pins[:i], a.pins[i+1:]...)
+	return nil
+}
+
+// withPins returns messages with the pinned context right after the system
+// prompt
+func (a *Agent) withPins(messages []ollama.Message) []ollama.Message {
+	pins := a.Pins()
+	if len(pins) == 0 {
+		return messages
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Pinned context the user wants you to always keep in mind:\n")
+	for _, pin := range pins {
+		sb.WriteString("\n### " + pin.Label + "\n")
+		if pin.Path == "" {
+			sb.WriteString(pin.Text + "\n")
+			continue
+		}
+		data, err := os.ReadFile(pin.Path)
+		if err != nil {
+			sb.WriteString(fmt.Sprintf("(could not read %s: %v)\n", pin.Path, err))
+			continue
+		}
+		if len(data) > maxPinnedFileBytes {
+			data = append(data[:maxPinnedFileBytes], []byte("\n... (truncated)")...)
+		}
+		sb.WriteString("```\n" + string(data) + "\n```\n")
+	}
+	pinned := ollama.Message{Role: "system", Content: sb.String()}
+
+	at := 0
+	if len(messages) > 0 && messages[0].Role == "system" {
+		at = 1
+	}
+	result := make([]ollama.Message, 0, len(messages)+1)
+	result = append(result, messages[:at]...)
+	result = append(result, pinned)
+	return append(result, messages[at:]...)
+}
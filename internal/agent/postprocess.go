@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/logger"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// postProcessTimeout is how long a post-processing command may run
+const postProcessTimeout = 30 * time.Second
+
+// templateFuncs are available to post-processing templates
+var templateFuncs = template.FuncMap{
+	"stripANSI": ansi.Strip,
+	"head":      func(n int, s string) string { return keepLines(s, n, true) },
+	"tail":      func(n int, s string) string { return keepLines(s, n, false) },
+	"trim":      strings.TrimSpace,
+	"grep": func(substr, s string) string {
+		var kept []string
+		for _, line := range strings.Split(s, "\n") {
+			if strings.Contains(line, substr) {
+				kept = append(kept, line)
+			}
+		}
+		return strings.Join(kept, "\n")
+	},
+}
+
+// keepLines keeps the first or last n lines of s
+func keepLines(s string, n int, first bool) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= n {
+		return s
+	}
+	if first {
+		return strings.Join(lines[:n], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-n)
+	}
+	return fmt.Sprintf("... (%d earlier lines)\n", len(lines)-n) + strings.Join(lines[len(lines)-n:], "\n")
+}
+
+// ValidatePostProcessor reports a post-processor that can never work
+func ValidatePostProcessor(p config.ToolPostProcessor) error {
+	if p.Tool == "" {
+		return fmt.Errorf("post-processor has no tool")
+	}
+	if p.Command == "" && p.Template == "" {
+		return fmt.Errorf("post-processor for %s has neither command nor template", p.Tool)
+	}
+	if p.Template != "" {
+		if _, err := template.New(p.Tool).Funcs(templateFuncs).Parse(p.Template); err != nil {
+			return fmt.Errorf("post-processor for %s: %w", p.Tool, err)
+		}
+	}
+	return nil
+}
+
+// postProcess runs the configured post-processors for a tool over its
+// result. A processor that fails is skipped, so the model still gets output.
+func (a *Agent) postProcess(ctx context.Context, tool string, args map[string]interface{}, result string) string {
+	for _, p := range a.config.PostProcessors {
+		if p.Tool != tool && p.Tool != "*" {
+			continue
+		}
+		processed, err := runPostProcessor(ctx, p, tool, args, result)
+		if err != nil {
+			logger.Log("postProcess: %s: %v", tool, err)
+			continue
+		}
+		result = processed
+	}
+	return result
+}
+
+func runPostProcessor(ctx context.Context, p config.ToolPostProcessor, tool string, args map[string]interface{}, result string) (string, error) {
+	if p.Command != "" {
+		ctx, cancel := context.WithTimeout(ctx, postProcessTimeout)
+		defer cancel()
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "sh", "-c", p.Command)
+		cmd.Stdin = strings.NewReader(result)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("command %q: %w: %s", p.Command, err, strings.TrimSpace(stderr.String()))
+		}
+		result = stdout.String()
+	}
+
+	if p.Template != "" {
+		tmpl, err := template.New(tool).Funcs(templateFuncs).Parse(p.Template)
+		if err != nil {
+			return "", fmt.Errorf("template: %w", err)
+		}
+		var sb strings.Builder
+		data := map[string]interface{}{"Result": result, "Tool": tool, "Args": args}
+		if err := tmpl.Execute(&sb, data); err != nil {
+			return "", fmt.Errorf("template: %w", err)
+		}
+		result = sb.String()
+	}
+	return result, nil
+}
@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/LaPingvino/llemecode/internal/ollama"
+)
+
+// RequestPreview is the request the next message would send, with estimated
+// token counts so it can be trimmed before sending
+type RequestPreview struct {
+	Request       ollama.ChatRequest
+	MessageTokens []int // Estimate for each message in Request
+	ToolTokens    int   // Estimate for the native tool definitions
+	Tokens        int   // Estimate for the whole request
+	Budget        int   // Tokens the context pruning allows
+	History       int   // Messages in the history before pruning
+}
+
+// Preview assembles the request that sending pending would make, without
+// sending it. An empty pending previews the history as it stands.
+func (a *Agent) Preview(ctx context.Context, pending string) *RequestPreview {
+	history := a.GetMessages()
+	if pending != "" {
+		history = append(history, ollama.Message{Role: "user", Content: pending})
+	}
+	preview := &RequestPreview{
+		Request: a.buildRequest(ctx, history),
+		Budget:  a.contextBudget(),
+		History: len(history),
+	}
+	for _, msg := range preview.Request.Messages {
+		tokens := estimateTokens(msg)
+		preview.MessageTokens = append(preview.MessageTokens, tokens)
+		preview.Tokens += tokens
+	}
+	if len(preview.Request.Tools) > 0 {
+		tools, _ := json.Marshal(preview.Request.Tools)
+		preview.ToolTokens = len(tools) / 4
+		preview.Tokens += preview.ToolTokens
+	}
+	return preview
+}
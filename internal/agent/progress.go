@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"sync"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/tools"
+)
+
+// Checkpoint phases
+const (
+	PhaseThinking  = "thinking"   // The model began a new reply
+	PhaseToolStart = "tool_start" // A tool call is about to run
+	PhaseToolEnd   = "tool_end"   // A tool call finished, Error set if it failed
+	PhaseDone      = "done"       // The turn finished
+	PhaseFailed    = "failed"     // The turn stopped with Error
+)
+
+// Checkpoint is a structured report of how far a turn has got, for editors
+// and scripts that draw progress or decide when to abort
+type Checkpoint struct {
+	Phase         string   `json:"phase"`
+	Step          int      `json:"step"`           // Tool calls started so far
+	ModelRequests int      `json:"model_requests"` // Model replies that have streamed text so far
+	Tool          string   `json:"tool,omitempty"`
+	Target        string   `json:"target,omitempty"` // Path or command of the tool call
+	Error         string   `json:"error,omitempty"`
+	FilesModified []string `json:"files_modified"`
+	CommandsRun   []string `json:"commands_run"`
+	ElapsedMS     int64    `json:"elapsed_ms"`
+}
+
+// ProgressTracker turns the stream and tool observer callbacks of one turn
+// into checkpoints
+type ProgressTracker struct {
+	agent *Agent
+	emit  func(Checkpoint)
+
+	mu            sync.Mutex
+	started       time.Time
+	iteration     int
+	step          int
+	modelRequests int
+	files         []string
+	seenFiles     map[string]bool
+	commands      []string
+}
+
+func NewProgressTracker(ag *Agent, emit func(Checkpoint)) *ProgressTracker {
+	return &ProgressTracker{agent: ag, emit: emit, started: time.Now(), iteration: -1, seenFiles: make(map[string]bool)}
+}
+
+// Stream wraps next, reporting each new model request; next may be nil
+func (p *ProgressTracker) Stream(next StreamFunc) StreamFunc {
+	return func(iteration int, chunk string) {
+		p.mu.Lock()
+		started := iteration != p.iteration
+		if started {
+			p.iteration = iteration
+			p.modelRequests++
+		}
+		p.mu.Unlock()
+		if started {
+			p.send(Checkpoint{Phase: PhaseThinking})
+		}
+		if next != nil {
+			next(iteration, chunk)
+		}
+	}
+}
+
+// Observe wraps next, reporting tool calls and what they changed; next may
+// be nil
+func (p *ProgressTracker) Observe(next ToolObserver) ToolObserver {
+	return func(execution ToolExecution, finished bool) {
+		target := toolTarget(execution)
+		p.mu.Lock()
+		if !finished {
+			p.step++
+		} else {
+			if execution.Name == "run_command" && target != "" {
+				p.commands = append(p.commands, target)
+			}
+			if execution.Error == nil {
+				for _, path := range p.agent.writtenPaths(execution.Name, execution.Args) {
+					if !p.seenFiles[path] {
+						p.seenFiles[path] = true
+						p.files = append(p.files, path)
+					}
+				}
+			}
+		}
+		p.mu.Unlock()
+
+		cp := Checkpoint{Phase: PhaseToolStart, Tool: execution.Name, Target: target}
+		if finished {
+			cp.Phase = PhaseToolEnd
+			if execution.Error != nil {
+				cp.Error = execution.Error.Error()
+			}
+		}
+		p.send(cp)
+		if next != nil {
+			next(execution, finished)
+		}
+	}
+}
+
+// Finish reports the end of the turn
+func (p *ProgressTracker) Finish(err error) {
+	if err != nil {
+		p.send(Checkpoint{Phase: PhaseFailed, Error: err.Error()})
+		return
+	}
+	p.send(Checkpoint{Phase: PhaseDone})
+}
+
+// send fills in the running totals and emits cp
+func (p *ProgressTracker) send(cp Checkpoint) {
+	p.mu.Lock()
+	cp.Step = p.step
+	cp.ModelRequests = p.modelRequests
+	cp.FilesModified = append([]string{}, p.files...)
+	cp.CommandsRun = append([]string{}, p.commands...)
+	cp.ElapsedMS = time.Since(p.started).Milliseconds()
+	p.mu.Unlock()
+	p.emit(cp)
+}
+
+// toolTarget is what a tool call acts on, for a one-line description
+func toolTarget(execution ToolExecution) string {
+	for _, key := range []string{"command", "path", "url", "query"} {
+		if s, ok := execution.Args[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// writtenPaths lists the files a write tool call changed
+func (a *Agent) writtenPaths(name string, args map[string]interface{}) []string {
+	if level, ok := a.toolLevel(name); !ok || level != tools.PermissionWrite {
+		return nil
+	}
+	tool, _ := a.toolRegistry.Get(name)
+	if pt, ok := tool.(*tools.ProtectedTool); ok {
+		tool = pt.UnwrapTool()
+	}
+	if multi, ok := tool.(tools.MultiPathTool); ok {
+		return multi.TargetPaths(args)
+	}
+	var paths []string
+	for _, key := range []string{"path", "destination"} {
+		if s, ok := args[key].(string); ok && s != "" {
+			paths = append(paths, s)
+		}
+	}
+	return paths
+}
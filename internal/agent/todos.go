@@ -0,0 +1,27 @@
+package agent
+
+import (
+	"github.com/LaPingvino/llemecode/internal/ollama"
+)
+
+// todoGuidance is added to the system prompt when the todo tools are on
+const todoGuidance = `For work with more than two or three steps, keep a task list the user can follow: add the steps with todo_add before you start, mark each one in_progress with todo_update when you begin it and done with todo_complete as soon as it is finished. Keep the list current when plans change, so the work can be resumed if it is interrupted.`
+
+// withTodos adds the open task list after the system prompt, so it
+// survives context pruning and resumed sessions
+func (a *Agent) withTodos(messages []ollama.Message) []ollama.Message {
+	list := a.toolRegistry.Todos()
+	if list.Open() == 0 {
+		return messages
+	}
+	current := ollama.Message{Role: "system", Content: "Current task list (keep it up to date with the todo_ tools):\n" + list.Markdown()}
+
+	at := 0
+	if len(messages) > 0 && messages[0].Role == "system" {
+		at = 1
+	}
+	result := make([]ollama.Message, 0, len(messages)+1)
+	result = append(result, messages[:at]...)
+	result = append(result, current)
+	return append(result, messages[at:]...)
+}
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/LaPingvino/llemecode/internal/config"
@@ -17,11 +19,18 @@ type ModelScore struct {
 	Model       string
 	TotalScore  float64
 	Scores      map[string]float64
+	Categories  map[string]float64 // Average score per task category
 	AvgLatency  time.Duration
 	Strengths   []string
 	Description string
 	Capability  config.ModelCapability
 	Rank        int
+	Digest      string    // Model digest when benchmarked, to spot updated models
+	ModifiedAt  time.Time // Model modification time when benchmarked
+
+	Options map[string]interface{} `json:",omitempty"` // Generation options of the task requests, e.g. seed and temperature
+
+	Evaluator string `json:",omitempty"` // Model that graded the answers; empty for heuristics
 }
 
 type Benchmarker struct {
@@ -29,8 +38,23 @@ type Benchmarker struct {
 	detector  *Detector
 	evaluator *AIEvaluator
 	tasks     []config.BenchmarkTask
+	previous  []ModelScore // Earlier results; models unchanged since are skipped
+	models    []string     // Only benchmark these models when set
+	stepHook  StepHook
+	options   map[string]interface{} // Generation options for task and evaluation requests
+
+	// Models that can stand in as evaluator, best first, so no model grades
+	// its own answers; autoEvaluator is set when the first was picked
+	// because none was configured
+	evaluators    []string
+	autoEvaluator bool
 }
 
+// StepHook is called before each step of benchmarking a model: tool
+// detection, then every task. It may block, e.g. while benchmarking is
+// paused; an error stops the model's benchmark.
+type StepHook func(ctx context.Context, model, step string) error
+
 func New(client *ollama.Client, tasks []config.BenchmarkTask) *Benchmarker {
 	if len(tasks) == 0 {
 		tasks = getDefaultTasks()
@@ -40,31 +64,206 @@ func New(client *ollama.Client, tasks []config.BenchmarkTask) *Benchmarker {
 		client:   client,
 		detector: NewDetector(client),
 		tasks:    tasks,
+		options:  config.BenchmarkSamplingConfig{}.Options(),
 	}
 }
 
 func (b *Benchmarker) SetEvaluator(evaluatorModel string) {
 	if evaluatorModel != "" {
-		b.evaluator = NewAIEvaluator(b.client, evaluatorModel)
+		b.evaluator = b.newEvaluator(evaluatorModel)
+		b.autoEvaluator = false
+	}
+}
+
+func (b *Benchmarker) newEvaluator(model string) *AIEvaluator {
+	e := NewAIEvaluator(b.client, model)
+	e.options = b.options
+	return e
+}
+
+// AutoEvaluator reports whether the evaluator was picked automatically
+func (b *Benchmarker) AutoEvaluator() bool {
+	return b.evaluator != nil && b.autoEvaluator
+}
+
+// EvaluatorNote says what grades the answers, for progress output
+func (b *Benchmarker) EvaluatorNote() string {
+	switch {
+	case b.evaluator == nil:
+		return "Grading answers with heuristics"
+	case b.autoEvaluator:
+		return fmt.Sprintf("Using %s to evaluate other models, the best ranked one (pick another with --evaluator or \"evaluator\" in the config)", b.evaluator.evaluatorModel)
+	}
+	return fmt.Sprintf("Using %s to evaluate other models", b.evaluator.evaluatorModel)
+}
+
+// Evaluator returns the model grading answers, or "" for heuristics
+func (b *Benchmarker) Evaluator() string {
+	if b.evaluator == nil {
+		return ""
+	}
+	return b.evaluator.evaluatorModel
+}
+
+// SetModels limits benchmarking to the named models; none means all
+func (b *Benchmarker) SetModels(models []string) {
+	b.models = models
+}
+
+// Configure applies the benchmark settings from cfg: tool detection trials,
+// which models to benchmark, the evaluator and the generation options
+func (b *Benchmarker) Configure(cfg *config.Config) {
+	b.SetToolDetection(cfg.ToolDetection)
+	b.options = cfg.BenchmarkSampling.Options()
+	b.SetModels(cfg.BenchmarkModels)
+	b.evaluators = rankEvaluators(cfg.GetCapabilities(), cfg.DefaultModel)
+	b.evaluator, b.autoEvaluator = nil, false
+	switch cfg.Evaluator {
+	case "none":
+		b.evaluators = nil
+	case "":
+		b.autoEvaluator = true
+		if len(b.evaluators) > 0 {
+			b.evaluator = b.newEvaluator(b.evaluators[0])
+		}
+	default:
+		b.SetEvaluator(cfg.Evaluator)
 	}
 }
 
-func (b *Benchmarker) BenchmarkModel(ctx context.Context, modelName string, progressChan chan<- string) (*ModelScore, error) {
+// rankEvaluators orders the benchmarked models in caps by how well they follow
+// instructions, judged by their reasoning score and then their total score.
+// Embedding models can't grade and are left out. fallback, usually the
+// default model, comes last when it has no scores yet.
+func rankEvaluators(caps map[string]config.ModelCapability, fallback string) []string {
+	var models []string
+	for model, cap := range caps {
+		if cap.TotalScore > 0 && !strings.Contains(strings.ToLower(model), "embed") {
+			models = append(models, model)
+		}
+	}
+	sort.Slice(models, func(i, j int) bool {
+		a, b := caps[models[i]], caps[models[j]]
+		if a.CategoryScores["reasoning"] != b.CategoryScores["reasoning"] {
+			return a.CategoryScores["reasoning"] > b.CategoryScores["reasoning"]
+		}
+		if a.TotalScore != b.TotalScore {
+			return a.TotalScore > b.TotalScore
+		}
+		return models[i] < models[j]
+	})
+	if fallback != "" && caps[fallback].TotalScore == 0 {
+		models = append(models, fallback)
+	}
+	return models
+}
+
+// useInstalledEvaluators drops stand-in evaluators that are no longer
+// installed and adds the unranked ones last, for first runs. An automatic
+// choice that went is moved on.
+func (b *Benchmarker) useInstalledEvaluators(installed []ollama.ModelInfo) {
+	isInstalled := make(map[string]bool, len(installed))
+	for _, model := range installed {
+		isInstalled[model.Name] = true
+	}
+	var kept []string
+	ranked := make(map[string]bool, len(b.evaluators))
+	for _, name := range b.evaluators {
+		ranked[name] = true
+		if isInstalled[name] {
+			kept = append(kept, name)
+		}
+	}
+	for _, model := range installed {
+		if !ranked[model.Name] && !strings.Contains(strings.ToLower(model.Name), "embed") {
+			kept = append(kept, model.Name)
+		}
+	}
+	b.evaluators = kept
+	if b.autoEvaluator && (b.evaluator == nil || !isInstalled[b.evaluator.evaluatorModel]) {
+		b.evaluator = nil
+		if len(kept) > 0 {
+			b.evaluator = b.newEvaluator(kept[0])
+		}
+	}
+}
+
+// evaluatorFor returns the evaluator for model's answers: never model itself,
+// which would favour its own work. Nil means heuristics.
+func (b *Benchmarker) evaluatorFor(model string) *AIEvaluator {
+	if b.evaluator == nil || b.evaluator.evaluatorModel != model {
+		return b.evaluator
+	}
+	for _, name := range b.evaluators {
+		if name != model {
+			return b.newEvaluator(name)
+		}
+	}
+	return nil
+}
+
+// SetToolDetection configures the trials used to detect tool call formats
+func (b *Benchmarker) SetToolDetection(detection config.ToolDetectionConfig) {
+	b.detector.SetTrials(detection.Trials, detection.Threshold)
+}
+
+func (b *Benchmarker) SetStepHook(hook StepHook) {
+	b.stepHook = hook
+}
+
+// StepsPerModel is how many steps benchmarking one model takes
+func (b *Benchmarker) StepsPerModel() int {
+	return len(b.tasks) + 1
+}
+
+// step announces a step of benchmarking model and runs the step hook
+func (b *Benchmarker) step(ctx context.Context, events chan<- Progress, model, step string, index int) error {
+	send(events, Progress{Kind: ProgressStep, Model: model, Step: step, StepIndex: index, StepCount: b.StepsPerModel()})
+	if b.stepHook == nil {
+		return ctx.Err()
+	}
+	return b.stepHook(ctx, model, step)
+}
+
+func (b *Benchmarker) BenchmarkModel(ctx context.Context, modelName string, events chan<- Progress) (*ModelScore, error) {
 	score := &ModelScore{
-		Model:  modelName,
-		Scores: make(map[string]float64),
+		Model:      modelName,
+		Scores:     make(map[string]float64),
+		Categories: make(map[string]float64),
+		Options:    b.options,
 	}
 
 	// Detect capabilities first
-	score.Capability = b.detector.DetectCapabilities(ctx, modelName, progressChan)
+	if err := b.step(ctx, events, modelName, "tool detection", 1); err != nil {
+		return nil, err
+	}
+	score.Capability = b.detector.DetectCapabilities(ctx, modelName, events)
 
 	totalLatency := time.Duration(0)
 	categoryScores := make(map[string][]float64)
+	record := func(task config.BenchmarkTask, taskScore float64) {
+		score.Scores[task.Name] = taskScore
+		send(events, Progress{Kind: ProgressTaskScore, Model: modelName, Task: task.Name, TaskScore: taskScore})
+		categoryScores[task.Category] = append(categoryScores[task.Category], taskScore)
+	}
 
-	for _, task := range b.tasks {
-		if progressChan != nil {
-			progressChan <- fmt.Sprintf("Running '%s' test on %s", task.Name, modelName)
+	// With an evaluator the answers are graded together once all are in
+	evaluator := b.evaluatorFor(modelName)
+	if evaluator != nil {
+		score.Evaluator = evaluator.evaluatorModel
+		if b.evaluator != nil && evaluator != b.evaluator {
+			sendf(events, "Grading %s with %s, so it doesn't grade itself", modelName, evaluator.evaluatorModel)
+		}
+	} else if b.evaluator != nil {
+		sendf(events, "No other model can grade %s; using heuristics", modelName)
+	}
+	var answers []Answer
+	var latencies []time.Duration
+	for i, task := range b.tasks {
+		if err := b.step(ctx, events, modelName, task.Name, i+2); err != nil {
+			return nil, err
 		}
+		sendf(events, "Running '%s' test on %s", task.Name, modelName)
 
 		start := time.Now()
 		resp, err := b.client.Chat(ctx, ollama.ChatRequest{
@@ -72,49 +271,47 @@ func (b *Benchmarker) BenchmarkModel(ctx context.Context, modelName string, prog
 			Messages: []ollama.Message{
 				{Role: "user", Content: task.Prompt},
 			},
-			Stream: false,
+			Stream:  false,
+			Options: b.options,
 		})
 		latency := time.Since(start)
 		totalLatency += latency
 
 		if err != nil {
 			score.Scores[task.Name] = 0
-			if progressChan != nil {
-				progressChan <- fmt.Sprintf("  ✗ Failed: %v", err)
-			}
+			sendf(events, "  ✗ Failed: %v", err)
 			continue
 		}
 
-		var taskScore float64
-		if b.evaluator != nil {
-			// Use AI evaluator
-			aiScore, reasoning, err := b.evaluator.EvaluateResponse(ctx, task, resp.Message.Content)
-			if err != nil {
-				if progressChan != nil {
-					progressChan <- fmt.Sprintf("  ⚠ Evaluation failed, using fallback: %v", err)
-				}
-				taskScore = evaluateResponse(task, resp.Message.Content, latency)
-			} else {
-				taskScore = aiScore
-				if progressChan != nil {
-					progressChan <- fmt.Sprintf("  Score: %.2f - %s", taskScore, reasoning)
-				}
-			}
-		} else {
-			// Use simple heuristic evaluation
-			taskScore = evaluateResponse(task, resp.Message.Content, latency)
-			if progressChan != nil {
-				progressChan <- fmt.Sprintf("  Score: %.2f", taskScore)
-			}
+		if evaluator != nil {
+			answers = append(answers, Answer{Task: task, Response: resp.Message.Content})
+			latencies = append(latencies, latency)
+			continue
 		}
+		// Use simple heuristic evaluation
+		taskScore := evaluateResponse(task, resp.Message.Content, latency)
+		sendf(events, "  Score: %.2f", taskScore)
+		record(task, taskScore)
+	}
 
-		score.Scores[task.Name] = taskScore
-		categoryScores[task.Category] = append(categoryScores[task.Category], taskScore)
+	if len(answers) > 0 {
+		sendf(events, "Evaluating %d answers with %s...", len(answers), evaluator.evaluatorModel)
+		for i, eval := range evaluator.EvaluateAnswers(ctx, answers) {
+			task := answers[i].Task
+			if eval.Err != nil {
+				sendf(events, "  ⚠ Evaluation of '%s' failed, using fallback: %v", task.Name, eval.Err)
+				record(task, evaluateResponse(task, answers[i].Response, latencies[i]))
+				continue
+			}
+			sendf(events, "  %s: %.2f - %s", task.Name, eval.Score, eval.Reasoning)
+			record(task, eval.Score)
+		}
 	}
 
 	// Determine strengths
 	for category, scores := range categoryScores {
 		avg := average(scores)
+		score.Categories[category] = avg
 		if avg > 0.7 {
 			score.Strengths = append(score.Strengths, category)
 		}
@@ -124,17 +321,13 @@ func (b *Benchmarker) BenchmarkModel(ctx context.Context, modelName string, prog
 	score.AvgLatency = totalLatency / time.Duration(len(b.tasks))
 
 	// Generate description using AI if evaluator is available
-	if b.evaluator != nil {
-		if progressChan != nil {
-			progressChan <- fmt.Sprintf("Generating AI description for %s...", modelName)
-		}
-		desc, err := b.evaluator.GenerateModelDescription(ctx, score)
+	if evaluator != nil {
+		sendf(events, "Generating AI description for %s...", modelName)
+		desc, err := evaluator.GenerateModelDescription(ctx, score)
 		if err == nil {
 			score.Description = desc
 		} else {
-			if progressChan != nil {
-				progressChan <- fmt.Sprintf("  ⚠ Description generation failed: %v", err)
-			}
+			sendf(events, "  ⚠ Description generation failed: %v", err)
 			score.Description = generateDescription(score)
 		}
 	} else {
@@ -144,63 +337,248 @@ func (b *Benchmarker) BenchmarkModel(ctx context.Context, modelName string, prog
 	return score, nil
 }
 
+// ListModels returns the models installed on the Ollama server
 func (b *Benchmarker) ListModels(ctx context.Context) ([]ollama.ModelInfo, error) {
 	return b.client.ListModels(ctx)
 }
 
-func (b *Benchmarker) BenchmarkAll(ctx context.Context, progressChan chan<- string) ([]ModelScore, error) {
-	models, err := b.client.ListModels(ctx)
+func (b *Benchmarker) BenchmarkAll(ctx context.Context, events chan<- Progress) ([]ModelScore, error) {
+	pending, installed, err := b.PendingModels(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("list models: %w", err)
+		return nil, err
 	}
 
-	if progressChan != nil {
-		progressChan <- fmt.Sprintf("Found %d models to benchmark", len(models))
+	message := fmt.Sprintf("Found %d models to benchmark", len(pending))
+	if skipped := len(installed) - len(pending); skipped > 0 {
+		message = fmt.Sprintf("Found %d models, %d new or changed (%d unchanged since the last run)", len(installed), len(pending), skipped)
 	}
+	send(events, Progress{Kind: ProgressModels, ModelCount: len(pending), Message: message})
+	send(events, Progress{Message: b.EvaluatorNote()})
+
+	return b.Merge(installed, b.benchmarkEach(ctx, pending, events)), nil
+}
 
+// BenchmarkModels benchmarks the installed models matching names, exact
+// names or patterns like "qwen*", whether or not they changed since the last
+// run. The result includes the previous scores of the other models.
+func (b *Benchmarker) BenchmarkModels(ctx context.Context, names []string, events chan<- Progress) ([]ModelScore, error) {
+	selected, installed, err := b.SelectModels(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	send(events, Progress{Kind: ProgressModels, ModelCount: len(selected), Message: fmt.Sprintf("Found %d matching models to benchmark", len(selected))})
+	send(events, Progress{Message: b.EvaluatorNote()})
+
+	return b.Merge(installed, b.benchmarkEach(ctx, selected, events)), nil
+}
+
+// benchmarkEach benchmarks models in turn, leaving out those that fail
+func (b *Benchmarker) benchmarkEach(ctx context.Context, models []ollama.ModelInfo, events chan<- Progress) []ModelScore {
 	scores := make([]ModelScore, 0, len(models))
-	for _, model := range models {
-		if progressChan != nil {
-			progressChan <- fmt.Sprintf("\n=== Benchmarking %s ===", model.Name)
-		}
+	for i, model := range models {
+		send(events, ModelStarted(model.Name, i+1, len(models)))
 
-		score, err := b.BenchmarkModel(ctx, model.Name, progressChan)
+		score, err := b.BenchmarkModel(ctx, model.Name, events)
 		if err != nil {
-			if progressChan != nil {
-				progressChan <- fmt.Sprintf("Error benchmarking %s: %v", model.Name, err)
-			}
+			send(events, ModelFinished(model.Name, nil, err))
 			continue
 		}
+		score.Digest = model.Digest
+		score.ModifiedAt = model.ModifiedAt
+		send(events, ModelFinished(model.Name, score, nil))
 		scores = append(scores, *score)
 	}
+	return scores
+}
+
+// SelectModels returns the installed models matching names and all installed
+// models. A name that matches nothing is an error, so typos don't go unseen.
+func (b *Benchmarker) SelectModels(ctx context.Context, names []string) (selected, installed []ollama.ModelInfo, err error) {
+	installed, err = b.client.ListModels(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list models: %w", err)
+	}
+	b.useInstalledEvaluators(installed)
+	selected, err = MatchModels(installed, names)
+	if err != nil {
+		return nil, nil, err
+	}
+	return selected, installed, nil
+}
+
+// MatchModels returns the models matching any of patterns, in installed
+// order. Patterns are exact names or globs; a name without a tag also
+// matches its tags, so "qwen3" finds "qwen3:8b".
+func MatchModels(installed []ollama.ModelInfo, patterns []string) ([]ollama.ModelInfo, error) {
+	matched := make([]bool, len(installed))
+	for _, pattern := range patterns {
+		found := false
+		for i, model := range installed {
+			base, _, _ := strings.Cut(model.Name, ":")
+			ok, err := path.Match(pattern, model.Name)
+			if err != nil {
+				return nil, fmt.Errorf("bad model pattern %q: %w", pattern, err)
+			}
+			if !ok && !strings.Contains(pattern, ":") {
+				ok, _ = path.Match(pattern, base)
+			}
+			if ok {
+				matched[i] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no installed model matches %q", pattern)
+		}
+	}
+
+	var models []ollama.ModelInfo
+	for i, model := range installed {
+		if matched[i] {
+			models = append(models, model)
+		}
+	}
+	return models, nil
+}
+
+// LoadPrevious reads earlier results from path so only models added or
+// changed since are benchmarked. A missing file means everything is new.
+func (b *Benchmarker) LoadPrevious(path string) error {
+	previous, err := LoadResults(path)
+	if err != nil {
+		return fmt.Errorf("load previous results: %w", err)
+	}
+	b.previous = previous
+	return nil
+}
+
+// PendingModels returns the models that need benchmarking and all installed
+// models. Without previous results every model is pending.
+func (b *Benchmarker) PendingModels(ctx context.Context) (pending, installed []ollama.ModelInfo, err error) {
+	installed, err = b.client.ListModels(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list models: %w", err)
+	}
+	b.useInstalledEvaluators(installed)
+
+	previous := make(map[string]ModelScore, len(b.previous))
+	for _, score := range b.previous {
+		previous[score.Model] = score
+	}
+
+	selected := make(map[string]bool, len(b.models))
+	for _, name := range b.models {
+		selected[name] = true
+	}
+
+	for _, model := range installed {
+		if len(selected) > 0 && !selected[model.Name] {
+			continue
+		}
+		if score, ok := previous[model.Name]; ok && unchanged(model, score) {
+			continue
+		}
+		pending = append(pending, model)
+	}
+	return pending, installed, nil
+}
+
+// unchanged compares digests, or the modification time for results saved
+// before digests were recorded
+func unchanged(model ollama.ModelInfo, score ModelScore) bool {
+	if score.Digest != "" {
+		return score.Digest == model.Digest
+	}
+	return !score.ModifiedAt.IsZero() && score.ModifiedAt.Equal(model.ModifiedAt)
+}
+
+// Merge combines fresh scores with previous results for installed models
+// that were not re-run, sorted and ranked by total score
+func (b *Benchmarker) Merge(installed []ollama.ModelInfo, fresh []ModelScore) []ModelScore {
+	merged := append([]ModelScore(nil), fresh...)
+	done := make(map[string]bool, len(fresh))
+	for _, score := range fresh {
+		done[score.Model] = true
+	}
+
+	isInstalled := make(map[string]bool, len(installed))
+	for _, model := range installed {
+		isInstalled[model.Name] = true
+	}
+	for _, score := range b.previous {
+		if isInstalled[score.Model] && !done[score.Model] {
+			merged = append(merged, score)
+			done[score.Model] = true
+		}
+	}
 
 	// Sort by total score
-	sort.Slice(scores, func(i, j int) bool {
-		return scores[i].TotalScore > scores[j].TotalScore
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].TotalScore > merged[j].TotalScore
 	})
 
 	// Assign ranks
-	for i := range scores {
-		scores[i].Rank = i + 1
+	for i := range merged {
+		merged[i].Rank = i + 1
 	}
 
-	return scores, nil
+	return merged
 }
 
-func (b *Benchmarker) SelectBestModel(scores []ModelScore) string {
+// SelectBestModel picks a default model by weighted score. weights multiply
+// the category scores; categories without a weight count 1.
+func (b *Benchmarker) SelectBestModel(scores []ModelScore, weights map[string]float64) string {
 	if len(scores) == 0 {
 		return ""
 	}
 
+	ranked := append([]ModelScore(nil), scores...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return WeightedScore(ranked[i], weights) > WeightedScore(ranked[j], weights)
+	})
+
 	// Prefer models with native tool support and good scores
-	for _, score := range scores {
-		if score.Capability.SupportsTools && score.TotalScore > 0.6 {
+	for _, score := range ranked {
+		if score.Capability.SupportsTools && WeightedScore(score, weights) > 0.6 {
 			return score.Model
 		}
 	}
 
 	// Otherwise just pick the highest scoring
-	return scores[0].Model
+	return ranked[0].Model
+}
+
+// WeightedScore is the weighted average of a model's category scores. Scores
+// without category results fall back to the total score.
+func WeightedScore(score ModelScore, weights map[string]float64) float64 {
+	var sum, total float64
+	for category, avg := range score.Categories {
+		w, ok := weights[category]
+		if !ok {
+			w = 1
+		}
+		sum += w * avg
+		total += w
+	}
+	if total == 0 {
+		return score.TotalScore
+	}
+	return sum / total
+}
+
+// BestByCategory returns the highest scoring model in each category
+func BestByCategory(scores []ModelScore) map[string]string {
+	best := make(map[string]string)
+	top := make(map[string]float64)
+	for _, score := range scores {
+		for category, avg := range score.Categories {
+			if _, ok := best[category]; !ok || avg > top[category] {
+				best[category] = score.Model
+				top[category] = avg
+			}
+		}
+	}
+	return best
 }
 
 func (b *Benchmarker) SaveResults(scores []ModelScore, outputPath string) error {
@@ -223,28 +601,41 @@ func (b *Benchmarker) SaveResults(scores []ModelScore, outputPath string) error
 
 // DetectToolSupport detects and saves tool capabilities for a single model
 func (b *Benchmarker) DetectToolSupport(ctx context.Context, modelName string, cfg *config.Config) error {
+	b.SetToolDetection(cfg.ToolDetection)
 	capability := b.detector.DetectCapabilities(ctx, modelName, nil)
-
-	if cfg.ModelCapabilities == nil {
-		cfg.ModelCapabilities = make(map[string]config.ModelCapability)
-	}
-
-	cfg.ModelCapabilities[modelName] = capability
+	cfg.SetCapability(modelName, capability)
 
 	return nil
 }
 
+// UpdateConfig writes benchmark results into cfg. Callers sharing cfg across
+// goroutines should run it inside cfg.Update.
 func (b *Benchmarker) UpdateConfig(cfg *config.Config, scores []ModelScore) {
+	if cfg.ModelCapabilities == nil {
+		cfg.ModelCapabilities = make(map[string]config.ModelCapability)
+	}
+
 	// Update model capabilities
 	for _, score := range scores {
 		capability := score.Capability
 		capability.RecommendedFor = score.Strengths
+		capability.TotalScore = score.TotalScore
+		capability.CategoryScores = score.Categories
+		capability.AvgLatency = score.AvgLatency
 		cfg.ModelCapabilities[score.Model] = capability
 	}
 
+	// Categories this run did not cover keep their previous best model
+	if cfg.BestModels == nil {
+		cfg.BestModels = make(map[string]string)
+	}
+	for category, model := range BestByCategory(scores) {
+		cfg.BestModels[category] = model
+	}
+
 	// Set default model if not already set
 	if cfg.DefaultModel == "" {
-		cfg.DefaultModel = b.SelectBestModel(scores)
+		cfg.DefaultModel = b.SelectBestModel(scores, cfg.CategoryWeights)
 	}
 }
 
@@ -9,79 +9,141 @@ import (
 	"github.com/LaPingvino/llemecode/internal/ollama"
 )
 
+const (
+	defaultTrials    = 3
+	defaultThreshold = 0.66
+)
+
+// toolTrial is one tool call a model is asked to make. Trials vary the tool
+// and argument so a model can't pass by echoing a single example.
+type toolTrial struct {
+	tool   string
+	param  string
+	value  string
+	prompt string
+}
+
+var toolTrials = []toolTrial{
+	{tool: "test_tool", param: "test", value: "hello", prompt: "Use the test_tool with test='hello'"},
+	{tool: "get_weather", param: "city", value: "Paris", prompt: "What is the weather in Paris right now? Use the get_weather tool with city='Paris'."},
+	{tool: "read_file", param: "path", value: "main.go", prompt: "Read the file main.go with the read_file tool, passing path='main.go'."},
+	{tool: "search_code", param: "query", value: "TODO", prompt: "Find every TODO in the project. Call search_code with query='TODO'."},
+}
+
 type Detector struct {
-	client *ollama.Client
+	client    *ollama.Client
+	trials    int
+	threshold float64
 }
 
 func NewDetector(client *ollama.Client) *Detector {
-	return &Detector{client: client}
+	return &Detector{client: client, trials: defaultTrials, threshold: defaultThreshold}
+}
+
+// SetTrials sets how many times each format is tried and the fraction of
+// trials that must pass. Zero values keep the defaults.
+func (d *Detector) SetTrials(trials int, threshold float64) {
+	if trials > 0 {
+		d.trials = trials
+	}
+	if threshold > 0 && threshold <= 1 {
+		d.threshold = threshold
+	}
 }
 
-// DetectCapabilities tests if a model supports native tool calling
-func (d *Detector) DetectCapabilities(ctx context.Context, modelName string, progressChan chan<- string) config.ModelCapability {
+// DetectCapabilities tests which tool call format a model handles reliably.
+// Formats are tried in order; the first to pass the threshold is used, and
+// otherwise the one with the best pass rate.
+func (d *Detector) DetectCapabilities(ctx context.Context, modelName string, events chan<- Progress) config.ModelCapability {
 	capability := config.ModelCapability{
 		SupportsTools:  false,
 		ToolCallFormat: "text", // default fallback
 	}
 
-	if progressChan != nil {
-		progressChan <- fmt.Sprintf("Testing %s for native tool support...", modelName)
+	if details, err := d.client.ShowModel(ctx, modelName); err == nil {
+		capability.Vision = details.HasCapability("vision")
+		capability.ContextLength = details.ContextLength
 	}
 
-	// Test 1: Try native tool calling
-	if d.testNativeTools(ctx, modelName) {
-		capability.SupportsTools = true
-		capability.ToolCallFormat = "native"
-		if progressChan != nil {
-			progressChan <- fmt.Sprintf("✓ %s supports native tools", modelName)
-		}
-		return capability
-	}
+	sendf(events, "Testing %s for native tool support (%d trials)...", modelName, d.trials)
 
-	if progressChan != nil {
-		progressChan <- fmt.Sprintf("✗ %s doesn't support native tools, testing fallbacks...", modelName)
+	formats := []struct {
+		name string
+		test func(context.Context, string, toolTrial) bool
+	}{
+		{"native", d.testNativeTools},
+		{"xml", d.testXMLFormat},
+		{"json", d.testJSONFormat},
 	}
 
-	// Test 2: Try XML format
-	if d.testXMLFormat(ctx, modelName) {
-		capability.ToolCallFormat = "xml"
-		if progressChan != nil {
-			progressChan <- fmt.Sprintf("✓ %s works with XML format", modelName)
+	best, bestConfidence := "", 0.0
+	for _, format := range formats {
+		confidence := d.confidence(ctx, modelName, format.test)
+		sendf(events, "  %s format: %.0f%% of trials passed", format.name, confidence*100)
+		if confidence >= d.threshold {
+			best, bestConfidence = format.name, confidence
+			break
+		}
+		if confidence > bestConfidence {
+			best, bestConfidence = format.name, confidence
 		}
-		return capability
 	}
 
-	// Test 3: Try JSON format
-	if d.testJSONFormat(ctx, modelName) {
-		capability.ToolCallFormat = "json"
-		if progressChan != nil {
-			progressChan <- fmt.Sprintf("✓ %s works with JSON format", modelName)
-		}
+	if best == "" {
+		sendf(events, "→ %s will use simple text format", modelName)
 		return capability
 	}
 
-	// Default to text format
-	if progressChan != nil {
-		progressChan <- fmt.Sprintf("→ %s will use simple text format", modelName)
+	capability.ToolCallFormat = best
+	capability.SupportsTools = best == "native"
+	capability.ToolConfidence = bestConfidence
+	note := ""
+	if bestConfidence < d.threshold {
+		note = fmt.Sprintf(", below the %.0f%% threshold", d.threshold*100)
 	}
-
+	sendf(events, "✓ %s will use %s format (confidence %.0f%%%s)", modelName, best, bestConfidence*100, note)
 	return capability
 }
 
-func (d *Detector) testNativeTools(ctx context.Context, modelName string) bool {
+// confidence runs test for up to d.trials varied trials and returns the
+// fraction that passed. It stops early once the outcome is decided.
+func (d *Detector) confidence(ctx context.Context, modelName string, test func(context.Context, string, toolTrial) bool) float64 {
+	passed, run := 0, 0
+	for i := 0; i < d.trials; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		run++
+		if test(ctx, modelName, toolTrials[i%len(toolTrials)]) {
+			passed++
+		}
+
+		remaining := d.trials - run
+		if float64(passed+remaining)/float64(d.trials) < d.threshold {
+			break // Can no longer reach the threshold
+		}
+	}
+	if run == 0 {
+		return 0
+	}
+	return float64(passed) / float64(run)
+}
+
+func (d *Detector) testNativeTools(ctx context.Context, modelName string, trial toolTrial) bool {
 	testTool := ollama.Tool{
 		Type: "function",
 		Function: ollama.ToolFunction{
-			Name:        "test_tool",
-			Description: "A test tool",
+			Name:        trial.tool,
+			Description: fmt.Sprintf("A test tool taking a %s", trial.param),
 			Parameters: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
-					"test": map[string]interface{}{
+					trial.param: map[string]interface{}{
 						"type":        "string",
 						"description": "A test parameter",
 					},
 				},
+				"required": []string{trial.param},
 			},
 		},
 	}
@@ -89,7 +151,7 @@ func (d *Detector) testNativeTools(ctx context.Context, modelName string) bool {
 	resp, err := d.client.Chat(ctx, ollama.ChatRequest{
 		Model: modelName,
 		Messages: []ollama.Message{
-			{Role: "user", Content: "Use the test_tool with test='hello'"},
+			{Role: "user", Content: trial.prompt},
 		},
 		Tools:  []ollama.Tool{testTool},
 		Stream: false,
@@ -99,17 +161,22 @@ func (d *Detector) testNativeTools(ctx context.Context, modelName string) bool {
 		return false
 	}
 
-	return len(resp.Message.ToolCalls) > 0
+	for _, call := range resp.Message.ToolCalls {
+		if call.Function.Name == trial.tool && fmt.Sprint(call.Function.Arguments[trial.param]) == trial.value {
+			return true
+		}
+	}
+	return false
 }
 
-func (d *Detector) testXMLFormat(ctx context.Context, modelName string) bool {
-	prompt := `You have access to a test_tool. To use it, respond with:
+func (d *Detector) testXMLFormat(ctx context.Context, modelName string, trial toolTrial) bool {
+	prompt := fmt.Sprintf(`You have access to a %[1]s tool. To use it, respond with:
 <tool_call>
-<name>test_tool</name>
-<arguments>{"test": "hello"}</arguments>
+<name>%[1]s</name>
+<arguments>{"%[2]s": "..."}</arguments>
 </tool_call>
 
-Now use the test_tool with test='hello'.`
+%[3]s`, trial.tool, trial.param, trial.prompt)
 
 	resp, err := d.client.Chat(ctx, ollama.ChatRequest{
 		Model: modelName,
@@ -125,21 +192,22 @@ Now use the test_tool with test='hello'.`
 
 	content := resp.Message.Content
 	return strings.Contains(content, "<tool_call>") &&
-		strings.Contains(content, "<name>test_tool</name>")
+		strings.Contains(content, "<name>"+trial.tool+"</name>") &&
+		strings.Contains(content, trial.value)
 }
 
-func (d *Detector) testJSONFormat(ctx context.Context, modelName string) bool {
-	prompt := `You have access to a test_tool. To use it, respond with a JSON block:
+func (d *Detector) testJSONFormat(ctx context.Context, modelName string, trial toolTrial) bool {
+	prompt := fmt.Sprintf(`You have access to a %[1]s tool. To use it, respond with a JSON block:
 '''json
 {
   "tool_call": {
-    "name": "test_tool",
-    "arguments": {"test": "hello"}
+    "name": "%[1]s",
+    "arguments": {"%[2]s": "..."}
   }
 }
 '''
 
-Now use the test_tool with test='hello'.`
+%[3]s`, trial.tool, trial.param, trial.prompt)
 
 	resp, err := d.client.Chat(ctx, ollama.ChatRequest{
 		Model: modelName,
@@ -155,5 +223,6 @@ Now use the test_tool with test='hello'.`
 
 	content := resp.Message.Content
 	return strings.Contains(content, "tool_call") &&
-		strings.Contains(content, "test_tool")
+		strings.Contains(content, trial.tool) &&
+		strings.Contains(content, trial.value)
 }
@@ -2,23 +2,278 @@ package benchmark
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/logger"
 	"github.com/LaPingvino/llemecode/internal/ollama"
 )
 
+// Limits on one batch evaluation request, so it fits small context windows
+const (
+	evaluationBatchSize  = 5
+	evaluationBatchChars = 12000
+)
+
+// maxCachedEvaluations bounds the evaluation cache; the grades used least
+// recently are dropped when it saves
+const maxCachedEvaluations = 5000
+
 type AIEvaluator struct {
 	client         *ollama.Client
 	evaluatorModel string
+	cache          *evaluationCache       // nil when there is no config directory
+	options        map[string]interface{} // Generation options, e.g. a fixed seed
 }
 
 func NewAIEvaluator(client *ollama.Client, model string) *AIEvaluator {
-	return &AIEvaluator{
+	e := &AIEvaluator{
 		client:         client,
 		evaluatorModel: model,
 	}
+	if dir, err := config.GetConfigDir(); err == nil {
+		e.cache = &evaluationCache{path: filepath.Join(dir, "evaluation_cache.json")}
+	}
+	return e
+}
+
+// Answer is a model's response to a benchmark task, to be graded
+type Answer struct {
+	Task     config.BenchmarkTask
+	Response string
+}
+
+// Evaluation is the grade the evaluator gave an answer. Err is set when it
+// could not be graded, so the caller can fall back to heuristics.
+type Evaluation struct {
+	Score     float64 `json:"score"`
+	Reasoning string  `json:"reasoning"`
+	Err       error   `json:"-"`
+}
+
+// EvaluateAnswers grades answers, several per evaluator request. Grades are
+// cached by task and answer, so re-running a benchmark only grades answers
+// that changed. Answers a batch doesn't grade are asked about one by one.
+func (e *AIEvaluator) EvaluateAnswers(ctx context.Context, answers []Answer) []Evaluation {
+	evals := make([]Evaluation, len(answers))
+	var pending []int
+	for i, answer := range answers {
+		if eval, ok := e.cache.get(e.cacheKey(answer)); ok {
+			evals[i] = eval
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	for len(pending) > 0 {
+		batch := pending[:1]
+		size := len(answers[pending[0]].Response)
+		for _, i := range pending[1:] {
+			size += len(answers[i].Response)
+			if len(batch) == evaluationBatchSize || size > evaluationBatchChars {
+				break
+			}
+			batch = append(batch, i)
+		}
+		pending = pending[len(batch):]
+
+		graded := make(map[int]Evaluation)
+		if len(batch) > 1 {
+			var err error
+			if graded, err = e.evaluateBatch(ctx, answers, batch); err != nil {
+				logger.Log("Batch evaluation failed, grading one by one: %v", err)
+			}
+		}
+		for _, i := range batch {
+			eval, ok := graded[i]
+			if !ok {
+				score, reasoning, err := e.EvaluateResponse(ctx, answers[i].Task, answers[i].Response)
+				eval = Evaluation{Score: score, Reasoning: reasoning, Err: err}
+			}
+			evals[i] = eval
+			if eval.Err == nil {
+				e.cache.put(e.cacheKey(answers[i]), eval)
+			}
+		}
+	}
+
+	if err := e.cache.save(); err != nil {
+		logger.Log("Failed to save evaluation cache: %v", err)
+	}
+	return evals
+}
+
+// evaluateBatch grades the answers at indexes in one request with JSON
+// output. Answers missing from the reply are left out of the result.
+func (e *AIEvaluator) evaluateBatch(ctx context.Context, answers []Answer, indexes []int) (map[int]Evaluation, error) {
+	var sb strings.Builder
+	sb.WriteString(`You are evaluating LLM responses to tasks. Rate each response on a scale of 0.0 to 1.0, on its own merits, based on:
+- Correctness and accuracy
+- Completeness
+- Clarity and coherence
+- Appropriateness for the task category
+
+Be strict but fair. Only exceptional responses should score above 0.9.
+`)
+	for n, i := range indexes {
+		task := answers[i].Task
+		fmt.Fprintf(&sb, "\n### Answer %d\n\nTask Category: %s\nTask Description: %s\nTask Prompt: %s\n\nModel's Response:\n%s\n",
+			n+1, task.Category, task.Description, task.Prompt, answers[i].Response)
+	}
+	fmt.Fprintf(&sb, `
+Respond with JSON only, one entry per answer:
+{"evaluations": [{"answer": 1, "score": 0.0, "reasoning": "brief explanation"}]}
+There are %d answers.`, len(indexes))
+
+	resp, err := e.client.Chat(ctx, ollama.ChatRequest{
+		Model: e.evaluatorModel,
+		Messages: []ollama.Message{
+			{Role: "user", Content: sb.String()},
+		},
+		Stream:  false,
+		Format:  "json",
+		Options: e.options,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("chat with evaluator: %w", err)
+	}
+
+	var reply struct {
+		Evaluations []struct {
+			Answer    int     `json:"answer"`
+			Score     float64 `json:"score"`
+			Reasoning string  `json:"reasoning"`
+		} `json:"evaluations"`
+	}
+	if err := json.Unmarshal([]byte(resp.Message.Content), &reply); err != nil {
+		return nil, fmt.Errorf("parse evaluations: %w", err)
+	}
+	graded := make(map[int]Evaluation, len(indexes))
+	for _, ev := range reply.Evaluations {
+		if ev.Answer < 1 || ev.Answer > len(indexes) {
+			continue
+		}
+		graded[indexes[ev.Answer-1]] = Evaluation{Score: min(max(ev.Score, 0), 1), Reasoning: ev.Reasoning}
+	}
+	return graded, nil
+}
+
+// cacheKey identifies a grade: the same answer to the same task from the
+// same evaluator gets the same grade
+func (e *AIEvaluator) cacheKey(answer Answer) string {
+	h := sha256.New()
+	for _, part := range []string{e.evaluatorModel, answer.Task.Category, answer.Task.Prompt, answer.Response} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// evaluationCache keeps evaluator grades on disk between benchmark runs.
+// A nil cache remembers nothing.
+type evaluationCache struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]cachedEvaluation // Loaded on first use
+	dirty   bool
+}
+
+// cachedEvaluation is a grade and when it was last used
+type cachedEvaluation struct {
+	Evaluation
+	Used time.Time `json:"used"`
+}
+
+func (c *evaluationCache) load() {
+	if c.entries != nil {
+		return
+	}
+	c.entries = make(map[string]cachedEvaluation)
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		logger.Log("Ignoring unreadable evaluation cache %s: %v", c.path, err)
+		c.entries = make(map[string]cachedEvaluation)
+	}
+}
+
+func (c *evaluationCache) get(key string) (Evaluation, bool) {
+	if c == nil {
+		return Evaluation{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+	entry, ok := c.entries[key]
+	if ok {
+		entry.Used = time.Now()
+		c.entries[key] = entry
+		c.dirty = true
+	}
+	return entry.Evaluation, ok
+}
+
+func (c *evaluationCache) put(key string, eval Evaluation) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+	c.entries[key] = cachedEvaluation{Evaluation: eval, Used: time.Now()}
+	c.dirty = true
+}
+
+// evict drops the least recently used grades beyond maxCachedEvaluations
+func (c *evaluationCache) evict() {
+	if len(c.entries) <= maxCachedEvaluations {
+		return
+	}
+	keys := make([]string, 0, len(c.entries))
+	for key := range c.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.entries[keys[i]].Used.After(c.entries[keys[j]].Used)
+	})
+	for _, key := range keys[maxCachedEvaluations:] {
+		delete(c.entries, key)
+	}
+}
+
+func (c *evaluationCache) save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	c.evict()
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("marshal evaluation cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	if err := config.WriteFileAtomic(c.path, data, 0644); err != nil {
+		return fmt.Errorf("write evaluation cache: %w", err)
+	}
+	c.dirty = false
+	return nil
 }
 
 // EvaluateResponse uses an LLM to evaluate another model's response
@@ -50,7 +305,8 @@ Be strict but fair. Only exceptional responses should score above 0.9.`,
 		Messages: []ollama.Message{
 			{Role: "user", Content: prompt},
 		},
-		Stream: false,
+		Stream:  false,
+		Options: e.options,
 	})
 	if err != nil {
 		return 0, "", fmt.Errorf("chat with evaluator: %w", err)
@@ -112,7 +368,8 @@ Example format: "Fast general-purpose model, ideal for coding tasks and quick re
 		Messages: []ollama.Message{
 			{Role: "user", Content: prompt},
 		},
-		Stream: false,
+		Stream:  false,
+		Options: e.options,
 	})
 	if err != nil {
 		return "", fmt.Errorf("generate description: %w", err)
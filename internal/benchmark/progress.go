@@ -0,0 +1,148 @@
+package benchmark
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProgressKind says what a Progress event reports
+type ProgressKind int
+
+const (
+	ProgressMessage    ProgressKind = iota // Informational line only
+	ProgressModels                         // ModelCount models are about to be benchmarked
+	ProgressModelStart                     // Model, ModelIndex of ModelCount, started
+	ProgressStep                           // Step, StepIndex of StepCount, of Model started
+	ProgressTaskScore                      // Task of Model scored TaskScore
+	ProgressModelDone                      // Model finished; Score is nil if it failed
+)
+
+// Progress is one benchmark progress event. Message is a human readable
+// line for logs and may be empty.
+type Progress struct {
+	Kind       ProgressKind
+	Message    string
+	Model      string
+	ModelIndex int
+	ModelCount int
+	Step       string
+	StepIndex  int
+	StepCount  int
+	Task       string
+	TaskScore  float64
+	Score      *ModelScore
+}
+
+func send(events chan<- Progress, p Progress) {
+	if events != nil {
+		events <- p
+	}
+}
+
+func sendf(events chan<- Progress, format string, args ...interface{}) {
+	send(events, Progress{Kind: ProgressMessage, Message: fmt.Sprintf(format, args...)})
+}
+
+// ModelStarted is the event for starting model index of count
+func ModelStarted(model string, index, count int) Progress {
+	return Progress{
+		Kind:       ProgressModelStart,
+		Model:      model,
+		ModelIndex: index,
+		ModelCount: count,
+		Message:    fmt.Sprintf("\n=== Benchmarking %s ===", model),
+	}
+}
+
+// ModelFinished is the event for a model that finished or failed with err
+func ModelFinished(model string, score *ModelScore, err error) Progress {
+	p := Progress{Kind: ProgressModelDone, Model: model, Score: score}
+	if err != nil {
+		p.Message = fmt.Sprintf("Error benchmarking %s: %v", model, err)
+	} else if score != nil {
+		p.Message = fmt.Sprintf("✓ %s scored %.2f", model, score.TotalScore)
+	}
+	return p
+}
+
+// ProgressState accumulates Progress events into the overall picture
+type ProgressState struct {
+	ModelCount int
+	ModelsDone int
+	ModelIndex int
+	Model      string
+	Step       string
+	StepIndex  int
+	StepCount  int
+	TaskScores map[string]float64 // Tasks of the current model scored so far
+	Finished   []ModelScore       // Models completed in this run
+	Last       string             // Latest non-empty message
+}
+
+// Apply updates the state with an event
+func (s *ProgressState) Apply(p Progress) {
+	if p.Message != "" {
+		s.Last = strings.TrimSpace(p.Message)
+	}
+
+	switch p.Kind {
+	case ProgressModels:
+		s.ModelCount = p.ModelCount
+	case ProgressModelStart:
+		s.Model, s.ModelIndex = p.Model, p.ModelIndex
+		if p.ModelCount > 0 {
+			s.ModelCount = p.ModelCount
+		}
+		s.Step, s.StepIndex = "", 0
+		s.TaskScores = make(map[string]float64)
+	case ProgressStep:
+		s.Model, s.Step = p.Model, p.Step
+		s.StepIndex, s.StepCount = p.StepIndex, p.StepCount
+	case ProgressTaskScore:
+		if s.TaskScores == nil {
+			s.TaskScores = make(map[string]float64)
+		}
+		s.TaskScores[p.Task] = p.TaskScore
+	case ProgressModelDone:
+		s.ModelsDone++
+		if p.Score != nil {
+			s.Finished = append(s.Finished, *p.Score)
+		}
+	}
+}
+
+// Copy returns a state that shares nothing with s
+func (s ProgressState) Copy() ProgressState {
+	c := s
+	c.TaskScores = make(map[string]float64, len(s.TaskScores))
+	for task, score := range s.TaskScores {
+		c.TaskScores[task] = score
+	}
+	c.Finished = append([]ModelScore(nil), s.Finished...)
+	return c
+}
+
+// Fraction is how much of the run is done, from 0 to 1
+func (s ProgressState) Fraction() float64 {
+	if s.ModelCount == 0 {
+		return 0
+	}
+	done := float64(s.ModelsDone)
+	if s.StepCount > 0 && s.ModelsDone < s.ModelIndex {
+		// Partway through the current model
+		done += float64(s.StepIndex-1) / float64(s.StepCount)
+	}
+	if f := done / float64(s.ModelCount); f < 1 {
+		return f
+	}
+	return 1
+}
+
+// ProgressBar draws fraction as a bar width cells wide
+func ProgressBar(fraction float64, width int) string {
+	filled := int(fraction*float64(width) + 0.5)
+	if filled > width {
+		filled = width
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
@@ -0,0 +1,251 @@
+package benchmark
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+)
+
+// ReportColumns are the columns a report can be sorted by
+var ReportColumns = []string{"rank", "model", "score", "latency", "format", "strengths"}
+
+// LoadResults reads saved benchmark results. A missing file returns no
+// scores and no error.
+func LoadResults(path string) ([]ModelScore, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read results: %w", err)
+	}
+
+	var scores []ModelScore
+	if err := json.Unmarshal(data, &scores); err != nil {
+		return nil, fmt.Errorf("parse results %s: %w", path, err)
+	}
+	return scores, nil
+}
+
+// LoadLatestResults reads the last full results, or the partial results of
+// an interrupted run when there are none. It returns the file it used.
+func LoadLatestResults() ([]ModelScore, string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, "", fmt.Errorf("get config dir: %w", err)
+	}
+
+	for _, name := range []string{"benchmark_results.json", "benchmark_results_partial.json"} {
+		path := filepath.Join(configDir, name)
+		scores, err := LoadResults(path)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(scores) > 0 {
+			return scores, path, nil
+		}
+	}
+	return nil, "", fmt.Errorf("no benchmark results found. Run /benchmark or llemecode -b to generate them")
+}
+
+// SortScores orders scores by one of ReportColumns. Scores sort highest
+// first, latency fastest first and the text columns alphabetically.
+func SortScores(scores []ModelScore, by string) error {
+	var less func(a, b ModelScore) bool
+	switch by {
+	case "", "score":
+		less = func(a, b ModelScore) bool { return a.TotalScore > b.TotalScore }
+	case "rank":
+		less = func(a, b ModelScore) bool { return a.Rank < b.Rank }
+	case "model":
+		less = func(a, b ModelScore) bool { return a.Model < b.Model }
+	case "latency":
+		less = func(a, b ModelScore) bool { return a.AvgLatency < b.AvgLatency }
+	case "format":
+		less = func(a, b ModelScore) bool { return a.Capability.ToolCallFormat < b.Capability.ToolCallFormat }
+	case "strengths":
+		less = func(a, b ModelScore) bool { return len(a.Strengths) > len(b.Strengths) }
+	default:
+		return fmt.Errorf("unknown column %q (sort by one of: %s)", by, strings.Join(ReportColumns, ", "))
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool { return less(scores[i], scores[j]) })
+	return nil
+}
+
+// MarkdownReport renders scores as a markdown table for the chat viewport
+func MarkdownReport(scores []ModelScore) string {
+	var sb strings.Builder
+	sb.WriteString("| Rank | Model | Score | Latency | Tool format | Strengths |\n")
+	sb.WriteString("|---:|---|---:|---:|---|---|\n")
+	for _, score := range scores {
+		sb.WriteString(fmt.Sprintf("| %d | %s | %.2f | %s | %s | %s |\n",
+			score.Rank, score.Model, score.TotalScore, score.AvgLatency.Round(time.Millisecond),
+			formatLabel(score.Capability), strings.Join(score.Strengths, ", ")))
+	}
+	return sb.String()
+}
+
+// TextReport renders scores as an aligned plain text table
+func TextReport(scores []ModelScore) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RANK\tMODEL\tSCORE\tLATENCY\tTOOL FORMAT\tSTRENGTHS")
+	for _, score := range scores {
+		fmt.Fprintf(w, "%d\t%s\t%.2f\t%s\t%s\t%s\n",
+			score.Rank, score.Model, score.TotalScore, score.AvgLatency.Round(time.Millisecond),
+			formatLabel(score.Capability), strings.Join(score.Strengths, ", "))
+	}
+	w.Flush()
+
+	// Scores are only comparable when generated the same way
+	var samplings []string
+	seen := make(map[string]bool)
+	for _, score := range scores {
+		if s := formatOptions(score.Options); s != "" && !seen[s] {
+			seen[s] = true
+			samplings = append(samplings, s)
+		}
+	}
+	switch len(samplings) {
+	case 0:
+	case 1:
+		fmt.Fprintf(&buf, "\nGenerated with %s\n", samplings[0])
+	default:
+		fmt.Fprintf(&buf, "\nModels were benchmarked with different settings, so scores may not compare: %s\n", strings.Join(samplings, "; "))
+	}
+
+	var graders []string
+	graded := make(map[string]bool)
+	for _, score := range scores {
+		if score.Evaluator != "" && !graded[score.Evaluator] {
+			graded[score.Evaluator] = true
+			graders = append(graders, score.Evaluator)
+		}
+	}
+	if len(graders) > 0 {
+		fmt.Fprintf(&buf, "Graded by %s\n", strings.Join(graders, ", "))
+	}
+	return buf.String()
+}
+
+// formatOptions describes generation options, e.g. "seed 42, temperature 0"
+func formatOptions(options map[string]interface{}) string {
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s %v", key, options[key]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatLabel(capability config.ModelCapability) string {
+	format := capability.ToolCallFormat
+	if format == "" {
+		format = "text"
+	}
+	if capability.ToolConfidence > 0 {
+		format += fmt.Sprintf(" (%.0f%%)", capability.ToolConfidence*100)
+	}
+	return format
+}
+
+type htmlRow struct {
+	Rank      int
+	Model     string
+	Score     float64
+	LatencyMS int64
+	Latency   string
+	Format    string
+	Strengths string
+}
+
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Llemecode benchmark report</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; }
+th, td { padding: 0.4em 0.8em; border-bottom: 1px solid #ddd; text-align: left; }
+th { cursor: pointer; background: #f4f4f4; user-select: none; }
+th:hover { background: #e8e8e8; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.meta { color: #666; }
+</style>
+</head>
+<body>
+<h1>Llemecode benchmark report</h1>
+<p class="meta">{{len .Rows}} models • generated {{.Generated}} • click a column to sort</p>
+<table id="report">
+<thead><tr>
+<th data-type="num">Rank</th><th>Model</th><th data-type="num">Score</th><th data-type="num">Latency</th><th>Tool format</th><th>Strengths</th>
+</tr></thead>
+<tbody>
+{{range .Rows}}<tr>
+<td class="num">{{.Rank}}</td><td>{{.Model}}</td><td class="num">{{printf "%.2f" .Score}}</td><td class="num" data-value="{{.LatencyMS}}">{{.Latency}}</td><td>{{.Format}}</td><td>{{.Strengths}}</td>
+</tr>
+{{end}}</tbody>
+</table>
+<script>
+document.querySelectorAll("#report th").forEach(function (th, col) {
+  var ascending = true;
+  th.addEventListener("click", function () {
+    var body = document.querySelector("#report tbody");
+    var rows = Array.from(body.rows);
+    var numeric = th.dataset.type === "num";
+    rows.sort(function (a, b) {
+      var x = a.cells[col].dataset.value || a.cells[col].textContent;
+      var y = b.cells[col].dataset.value || b.cells[col].textContent;
+      var order = numeric ? parseFloat(x) - parseFloat(y) : x.localeCompare(y);
+      return ascending ? order : -order;
+    });
+    ascending = !ascending;
+    rows.forEach(function (row) { body.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// HTMLReport renders scores as a standalone HTML page with a table that
+// sorts when a column header is clicked
+func HTMLReport(scores []ModelScore) (string, error) {
+	rows := make([]htmlRow, 0, len(scores))
+	for _, score := range scores {
+		rows = append(rows, htmlRow{
+			Rank:      score.Rank,
+			Model:     score.Model,
+			Score:     score.TotalScore,
+			LatencyMS: score.AvgLatency.Milliseconds(),
+			Latency:   score.AvgLatency.Round(time.Millisecond).String(),
+			Format:    formatLabel(score.Capability),
+			Strengths: strings.Join(score.Strengths, ", "),
+		})
+	}
+
+	var buf bytes.Buffer
+	err := htmlReport.Execute(&buf, struct {
+		Rows      []htmlRow
+		Generated string
+	}{rows, time.Now().Format("2006-01-02 15:04")})
+	if err != nil {
+		return "", fmt.Errorf("render report: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,179 @@
+package benchmark
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+)
+
+// Categories are the task categories benchmarks know how to score. Model
+// strengths, and so the recommendations shown in /models, are per category.
+var Categories = []string{"coding", "reasoning", "tool_use", "creative", "debugging", "testing", "documentation", "general"}
+
+const (
+	maxPromptLength  = 8000
+	maxPackSize      = 1 << 20 // Bytes read from a task pack file or URL
+	packFetchTimeout = 30 * time.Second
+)
+
+var taskNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+//go:embed packs/*.json
+var builtinPacks embed.FS
+
+// TaskPack is a named set of benchmark tasks that can be imported at once
+type TaskPack struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Tasks       []config.BenchmarkTask `json:"tasks"`
+}
+
+// ValidateTask checks that a task can be run and scored
+func ValidateTask(task config.BenchmarkTask) error {
+	var problems []error
+	if !taskNamePattern.MatchString(task.Name) {
+		problems = append(problems, fmt.Errorf("name %q must be lowercase letters, digits, '_' or '-'", task.Name))
+	}
+	switch prompt := strings.TrimSpace(task.Prompt); {
+	case prompt == "":
+		problems = append(problems, fmt.Errorf("prompt is empty"))
+	case len(prompt) > maxPromptLength:
+		problems = append(problems, fmt.Errorf("prompt is %d characters, the limit is %d", len(prompt), maxPromptLength))
+	}
+	if !isCategory(task.Category) {
+		problems = append(problems, fmt.Errorf("unknown category %q (use one of: %s)", task.Category, strings.Join(Categories, ", ")))
+	}
+
+	if err := errors.Join(problems...); err != nil {
+		return fmt.Errorf("task %q: %w", task.Name, err)
+	}
+	return nil
+}
+
+// ValidateTasks checks every task and that names are unique
+func ValidateTasks(tasks []config.BenchmarkTask) error {
+	var problems []error
+	seen := make(map[string]bool)
+	for _, task := range tasks {
+		if err := ValidateTask(task); err != nil {
+			problems = append(problems, err)
+		}
+		if seen[task.Name] {
+			problems = append(problems, fmt.Errorf("task %q is defined more than once", task.Name))
+		}
+		seen[task.Name] = true
+	}
+	return errors.Join(problems...)
+}
+
+func isCategory(category string) bool {
+	for _, c := range Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// BuiltinPacks lists the task packs shipped with llemecode
+func BuiltinPacks() []string {
+	entries, err := builtinPacks.ReadDir("packs")
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadTaskPack reads a task pack from an http(s) URL, a file, or the name of
+// a built-in pack. The file may hold a pack object or a bare list of tasks.
+// The tasks are validated before the pack is returned.
+func LoadTaskPack(ctx context.Context, source string) (*TaskPack, error) {
+	data, err := readPackSource(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	pack := &TaskPack{}
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(data, &pack.Tasks)
+	} else {
+		err = json.Unmarshal(data, pack)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse task pack %s: %w", source, err)
+	}
+	if pack.Name == "" {
+		pack.Name = strings.TrimSuffix(path.Base(source), ".json")
+	}
+
+	if len(pack.Tasks) == 0 {
+		return nil, fmt.Errorf("task pack %s has no tasks", source)
+	}
+	if err := ValidateTasks(pack.Tasks); err != nil {
+		return nil, fmt.Errorf("invalid task pack %s: %w", source, err)
+	}
+	return pack, nil
+}
+
+func readPackSource(ctx context.Context, source string) ([]byte, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		ctx, cancel := context.WithTimeout(ctx, packFetchTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, fmt.Errorf("fetch task pack: %w", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("fetch task pack: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("fetch task pack: %s", resp.Status)
+		}
+		return readLimited(resp.Body)
+	}
+
+	f, err := os.Open(source)
+	if os.IsNotExist(err) && !strings.ContainsAny(source, "/.") {
+		// Not a file, so maybe a built-in pack name
+		if data, err := builtinPacks.ReadFile("packs/" + source + ".json"); err == nil {
+			return data, nil
+		}
+		return nil, fmt.Errorf("no task pack file or built-in pack named %q (built-in: %s)", source, strings.Join(BuiltinPacks(), ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read task pack: %w", err)
+	}
+	defer f.Close()
+	return readLimited(f)
+}
+
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxPackSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read task pack: %w", err)
+	}
+	if len(data) > maxPackSize {
+		return nil, fmt.Errorf("task pack is larger than %d bytes", maxPackSize)
+	}
+	return data, nil
+}
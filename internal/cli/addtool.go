@@ -58,7 +58,7 @@ func (c *AddToolCommand) Execute(ctx context.Context, args []string, m *chatMode
 				sb.WriteString(fmt.Sprintf("✓ %s (already enabled)\n", model.Name))
 			} else {
 				sb.WriteString(fmt.Sprintf("  %s", model.Name))
-				if cap, ok := c.cfg.ModelCapabilities[model.Name]; ok && len(cap.RecommendedFor) > 0 {
+				if cap, ok := c.cfg.GetCapability(model.Name); ok && len(cap.RecommendedFor) > 0 {
 					sb.WriteString(fmt.Sprintf(" - good for: %s", strings.Join(cap.RecommendedFor, ", ")))
 				}
 				sb.WriteString("\n")
@@ -97,7 +97,7 @@ func (c *AddToolCommand) Execute(ctx context.Context, args []string, m *chatMode
 		description = strings.Join(args[1:], " ")
 	} else {
 		// Auto-generate description from capabilities
-		if cap, ok := c.cfg.ModelCapabilities[modelName]; ok && len(cap.RecommendedFor) > 0 {
+		if cap, ok := c.cfg.GetCapability(modelName); ok && len(cap.RecommendedFor) > 0 {
 			description = fmt.Sprintf("Specialized in: %s", strings.Join(cap.RecommendedFor, ", "))
 		} else {
 			description = fmt.Sprintf("Ask the %s model for help", modelName)
@@ -112,13 +112,14 @@ func (c *AddToolCommand) Execute(ctx context.Context, args []string, m *chatMode
 	}
 
 	// Add to config
-	c.cfg.ModelAsTools = append(c.cfg.ModelAsTools, config.ModelAsTool{
-		ModelName:   modelName,
-		Description: description,
-		Enabled:     true,
+	err = c.cfg.Update(func(cfg *config.Config) {
+		cfg.ModelAsTools = append(cfg.ModelAsTools, config.ModelAsTool{
+			ModelName:   modelName,
+			Description: description,
+			Enabled:     true,
+		})
 	})
-
-	if err := c.cfg.Save(); err != nil {
+	if err != nil {
 		return "", fmt.Errorf("failed to save config: %w", err)
 	}
 
@@ -172,19 +173,21 @@ func (c *RemoveToolCommand) Execute(ctx context.Context, args []string, m *chatM
 
 	// Find and disable
 	found := false
-	for i := range c.cfg.ModelAsTools {
-		if c.cfg.ModelAsTools[i].ModelName == modelName && c.cfg.ModelAsTools[i].Enabled {
-			c.cfg.ModelAsTools[i].Enabled = false
-			found = true
-			break
+	err := c.cfg.Update(func(cfg *config.Config) {
+		for i := range cfg.ModelAsTools {
+			if cfg.ModelAsTools[i].ModelName == modelName && cfg.ModelAsTools[i].Enabled {
+				cfg.ModelAsTools[i].Enabled = false
+				found = true
+				break
+			}
 		}
-	}
+	})
 
 	if !found {
 		return fmt.Sprintf("Model '%s' is not currently enabled as a tool", modelName), nil
 	}
 
-	if err := c.cfg.Save(); err != nil {
+	if err != nil {
 		return "", fmt.Errorf("failed to save config: %w", err)
 	}
 
@@ -243,7 +246,7 @@ func (c *AddAllToolsCommand) Execute(ctx context.Context, args []string, m *chat
 
 		// Auto-generate description from capabilities
 		description := ""
-		if cap, ok := c.cfg.ModelCapabilities[model.Name]; ok && len(cap.RecommendedFor) > 0 {
+		if cap, ok := c.cfg.GetCapability(model.Name); ok && len(cap.RecommendedFor) > 0 {
 			description = fmt.Sprintf("Specialized in: %s", strings.Join(cap.RecommendedFor, ", "))
 		} else {
 			description = fmt.Sprintf("Ask the %s model for help", model.Name)
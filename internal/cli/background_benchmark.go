@@ -3,10 +3,15 @@ package cli
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/LaPingvino/llemecode/internal/benchmark"
 	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/logger"
+	"github.com/LaPingvino/llemecode/internal/ollama"
 )
 
 // BackgroundBenchmark runs benchmarks in the background and updates config when done
@@ -17,14 +22,23 @@ type BackgroundBenchmark struct {
 	cancel        context.CancelFunc
 	done          chan struct{}
 	mu            sync.Mutex
+	started       bool
 	running       bool
-	progress      string
+	state         benchmark.ProgressState          // Built from the benchmarker's progress events
 	partialScores map[string]*benchmark.ModelScore // Store partial results as we go
+
+	resumed     *sync.Cond    // Signalled when pausing ends or the benchmark stops
+	paused      bool          // Paused with /benchmark pause
+	turns       int           // Interactive turns in flight; benchmarking waits for them
+	activeSince time.Time     // Start of the current unpaused stretch
+	active      time.Duration // Time spent benchmarking, not paused
+
+	only []string // Re-run just the models matching these names when set
 }
 
 func NewBackgroundBenchmark(ctx context.Context, benchmarker *benchmark.Benchmarker, cfg *config.Config) *BackgroundBenchmark {
 	ctx, cancel := context.WithCancel(ctx)
-	return &BackgroundBenchmark{
+	bb := &BackgroundBenchmark{
 		benchmarker:   benchmarker,
 		cfg:           cfg,
 		ctx:           ctx,
@@ -32,14 +46,24 @@ func NewBackgroundBenchmark(ctx context.Context, benchmarker *benchmark.Benchmar
 		done:          make(chan struct{}),
 		partialScores: make(map[string]*benchmark.ModelScore),
 	}
+	bb.resumed = sync.NewCond(&bb.mu)
+	benchmarker.SetStepHook(bb.waitStep)
+	return bb
+}
+
+// Select limits the run to the models matching names, re-running them even
+// if unchanged; call it before Start
+func (bb *BackgroundBenchmark) Select(names []string) {
+	bb.only = names
 }
 
 func (bb *BackgroundBenchmark) Start() {
 	bb.mu.Lock()
-	if bb.running {
+	if bb.started {
 		bb.mu.Unlock()
 		return
 	}
+	bb.started = true
 	bb.running = true
 	bb.mu.Unlock()
 
@@ -54,53 +78,87 @@ func (bb *BackgroundBenchmark) run() {
 		bb.mu.Unlock()
 	}()
 
-	progressCh := make(chan string, 100)
+	progressCh := make(chan benchmark.Progress, 100)
 
-	// Consume progress messages
+	// Consume progress events; closing progressCh waits for the last ones so
+	// they can't overwrite the final status
+	consumed := make(chan struct{})
 	go func() {
-		for msg := range progressCh {
+		defer close(consumed)
+		for event := range progressCh {
 			bb.mu.Lock()
-			bb.progress = msg
+			bb.state.Apply(event)
 			bb.mu.Unlock()
 		}
 	}()
+	closeProgress := func() {
+		close(progressCh)
+		<-consumed
+	}
 
-	// Get list of models
-	models, err := bb.benchmarker.ListModels(bb.ctx)
+	// Get list of models, skipping those unchanged since the last run unless
+	// particular models were asked for
+	var models, installed []ollama.ModelInfo
+	var err error
+	found := "Found %d new or changed models to benchmark"
+	if len(bb.only) > 0 {
+		models, installed, err = bb.benchmarker.SelectModels(bb.ctx, bb.only)
+		found = "Found %d matching models to benchmark"
+	} else {
+		models, installed, err = bb.benchmarker.PendingModels(bb.ctx)
+	}
 	if err != nil {
-		close(progressCh)
-		bb.mu.Lock()
-		bb.progress = fmt.Sprintf("Failed to list models: %v", err)
-		bb.mu.Unlock()
+		closeProgress()
+		bb.setProgress(fmt.Sprintf("Failed to list models: %v", err))
 		return
 	}
 
-	progressCh <- fmt.Sprintf("Found %d models to benchmark", len(models))
+	if len(models) == 0 {
+		closeProgress()
+		bb.setProgress(fmt.Sprintf("✓ All %d models are unchanged since the last benchmark (use /benchmark all to re-run)", len(installed)))
+		return
+	}
+	progressCh <- benchmark.Progress{
+		Kind:       benchmark.ProgressModels,
+		ModelCount: len(models),
+		Message:    fmt.Sprintf(found, len(models)),
+	}
+	progressCh <- benchmark.Progress{Message: bb.benchmarker.EvaluatorNote()}
+
+	bb.mu.Lock()
+	bb.activeSince = time.Now()
+	bb.mu.Unlock()
 
 	// Benchmark models one at a time with incremental saving
 	allScores := make([]benchmark.ModelScore, 0, len(models))
 
-	for _, model := range models {
+	for i, model := range models {
 		// Check for cancellation
 		select {
 		case <-bb.ctx.Done():
-			progressCh <- "⚠ Benchmarking interrupted - saving partial results..."
-			close(progressCh)
-			bb.savePartialResults()
-			bb.mu.Lock()
-			bb.progress = fmt.Sprintf("✓ Partial results saved (%d/%d models)", len(allScores), len(models))
-			bb.mu.Unlock()
+			bb.finishInterrupted(progressCh, closeProgress, len(allScores), len(models))
 			return
 		default:
 		}
 
-		progressCh <- fmt.Sprintf("\n=== Benchmarking %s ===", model.Name)
+		progressCh <- benchmark.ModelStarted(model.Name, i+1, len(models))
 
 		score, err := bb.benchmarker.BenchmarkModel(bb.ctx, model.Name, progressCh)
+
+		// A model interrupted mid-run has zeroed scores for the tasks it never
+		// finished; drop it rather than persisting a half-written result
+		if bb.ctx.Err() != nil {
+			bb.finishInterrupted(progressCh, closeProgress, len(allScores), len(models))
+			return
+		}
+
 		if err != nil {
-			progressCh <- fmt.Sprintf("Error benchmarking %s: %v", model.Name, err)
+			progressCh <- benchmark.ModelFinished(model.Name, nil, err)
 			continue
 		}
+		score.Digest = model.Digest
+		score.ModifiedAt = model.ModifiedAt
+		progressCh <- benchmark.ModelFinished(model.Name, score, nil)
 
 		// Store partial score
 		bb.mu.Lock()
@@ -113,36 +171,48 @@ func (bb *BackgroundBenchmark) run() {
 		bb.savePartialResults()
 	}
 
-	close(progressCh)
+	closeProgress()
 
-	// Final save with all results
-	bb.benchmarker.UpdateConfig(bb.cfg, allScores)
-	if err := bb.cfg.Save(); err != nil {
-		bb.mu.Lock()
-		bb.progress = fmt.Sprintf("Failed to save config: %v", err)
-		bb.mu.Unlock()
+	// Final save with all results, including unchanged models from earlier runs
+	allScores = bb.benchmarker.Merge(installed, allScores)
+	err = bb.cfg.Update(func(c *config.Config) {
+		bb.benchmarker.UpdateConfig(c, allScores)
+	})
+	if err != nil {
+		bb.setProgress(fmt.Sprintf("Failed to save config: %v", err))
 		return
 	}
 
 	configDir, err := config.GetConfigDir()
 	if err != nil {
-		bb.mu.Lock()
-		bb.progress = fmt.Sprintf("Failed to get config dir: %v", err)
-		bb.mu.Unlock()
+		bb.setProgress(fmt.Sprintf("Failed to get config dir: %v", err))
 		return
 	}
 
 	resultsPath := configDir + "/benchmark_results.json"
 	if err := bb.benchmarker.SaveResults(allScores, resultsPath); err != nil {
-		bb.mu.Lock()
-		bb.progress = fmt.Sprintf("Failed to save results: %v", err)
-		bb.mu.Unlock()
+		bb.setProgress(fmt.Sprintf("Failed to save results: %v", err))
 		return
 	}
+	if err := recordBenchmarkRun(bb.cfg, allScores); err != nil {
+		logger.Log("background benchmark: failed to save history: %v", err)
+	}
+
+	bb.setProgress("✓ Background benchmarking complete!")
+}
+
+// finishInterrupted flushes whatever completed before cancellation
+func (bb *BackgroundBenchmark) finishInterrupted(progressCh chan<- benchmark.Progress, closeProgress func(), completed, total int) {
+	progressCh <- benchmark.Progress{Message: "⚠ Benchmarking interrupted - saving partial results..."}
+	closeProgress()
+	bb.savePartialResults()
+	bb.setProgress(fmt.Sprintf("✓ Partial results saved (%d/%d models)", completed, total))
+}
 
+func (bb *BackgroundBenchmark) setProgress(msg string) {
 	bb.mu.Lock()
-	bb.progress = "✓ Background benchmarking complete!"
-	bb.mu.Unlock()
+	defer bb.mu.Unlock()
+	bb.state.Last = msg
 }
 
 func (bb *BackgroundBenchmark) savePartialResults() {
@@ -160,22 +230,24 @@ func (bb *BackgroundBenchmark) savePartialResults() {
 	}
 
 	// Update config with partial results
-	bb.benchmarker.UpdateConfig(bb.cfg, scores)
-	if err := bb.cfg.Save(); err != nil {
-		bb.progress = fmt.Sprintf("Failed to save partial config: %v", err)
+	err := bb.cfg.Update(func(c *config.Config) {
+		bb.benchmarker.UpdateConfig(c, scores)
+	})
+	if err != nil {
+		bb.state.Last = fmt.Sprintf("Failed to save partial config: %v", err)
 		return
 	}
 
 	// Save partial benchmark results
 	configDir, err := config.GetConfigDir()
 	if err != nil {
-		bb.progress = fmt.Sprintf("Failed to get config dir: %v", err)
+		bb.state.Last = fmt.Sprintf("Failed to get config dir: %v", err)
 		return
 	}
 
 	resultsPath := configDir + "/benchmark_results_partial.json"
 	if err := bb.benchmarker.SaveResults(scores, resultsPath); err != nil {
-		bb.progress = fmt.Sprintf("Failed to save partial results: %v", err)
+		bb.state.Last = fmt.Sprintf("Failed to save partial results: %v", err)
 	}
 }
 
@@ -185,10 +257,17 @@ func (bb *BackgroundBenchmark) IsRunning() bool {
 	return bb.running
 }
 
+// State returns the progress of the run so far
+func (bb *BackgroundBenchmark) State() benchmark.ProgressState {
+	bb.mu.Lock()
+	defer bb.mu.Unlock()
+	return bb.state.Copy()
+}
+
 func (bb *BackgroundBenchmark) GetProgress() string {
 	bb.mu.Lock()
 	defer bb.mu.Unlock()
-	return bb.progress
+	return bb.state.Last
 }
 
 func (bb *BackgroundBenchmark) Wait() {
@@ -199,9 +278,144 @@ func (bb *BackgroundBenchmark) Done() <-chan struct{} {
 	return bb.done
 }
 
+// Stop cancels the benchmark and waits for partial results to be flushed.
+// It is safe to call more than once and on a benchmark that never started.
 func (bb *BackgroundBenchmark) Stop() {
 	if bb.cancel != nil {
 		bb.cancel()
 	}
-	bb.Wait()
+
+	bb.mu.Lock()
+	started := bb.started
+	bb.resumed.Broadcast() // Wake a paused step so it sees the cancellation
+	bb.mu.Unlock()
+
+	if started {
+		bb.Wait()
+	}
+}
+
+// loadPreviousResults points benchmarker at the last full results so that
+// only new or changed models are benchmarked
+func loadPreviousResults(benchmarker *benchmark.Benchmarker) error {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return fmt.Errorf("get config dir: %w", err)
+	}
+	return benchmarker.LoadPrevious(configDir + "/benchmark_results.json")
+}
+
+// waitStep blocks while benchmarking is paused, either by the user or
+// because an interactive turn needs the GPU
+func (bb *BackgroundBenchmark) waitStep(ctx context.Context, model, step string) error {
+	bb.mu.Lock()
+	defer bb.mu.Unlock()
+
+	if bb.paused || bb.turns > 0 {
+		bb.active += time.Since(bb.activeSince)
+		for (bb.paused || bb.turns > 0) && ctx.Err() == nil {
+			bb.resumed.Wait()
+		}
+		bb.activeSince = time.Now()
+	}
+	return ctx.Err()
+}
+
+// Pause stops benchmarking after the step in progress
+func (bb *BackgroundBenchmark) Pause() {
+	bb.mu.Lock()
+	defer bb.mu.Unlock()
+	bb.paused = true
+}
+
+// Resume continues a paused benchmark
+func (bb *BackgroundBenchmark) Resume() {
+	bb.mu.Lock()
+	defer bb.mu.Unlock()
+	bb.paused = false
+	bb.resumed.Broadcast()
+}
+
+// IsPaused reports whether the user paused benchmarking
+func (bb *BackgroundBenchmark) IsPaused() bool {
+	bb.mu.Lock()
+	defer bb.mu.Unlock()
+	return bb.paused
+}
+
+// HoldForTurn pauses benchmarking while an interactive turn runs. Call the
+// returned function when the turn ends. It is safe on a nil benchmark.
+func (bb *BackgroundBenchmark) HoldForTurn() func() {
+	if bb == nil {
+		return func() {}
+	}
+	bb.mu.Lock()
+	bb.turns++
+	bb.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			bb.mu.Lock()
+			bb.turns--
+			bb.resumed.Broadcast()
+			bb.mu.Unlock()
+		})
+	}
+}
+
+// Status describes the current model, step and estimated time left
+func (bb *BackgroundBenchmark) Status() string {
+	bb.mu.Lock()
+	defer bb.mu.Unlock()
+
+	var sb strings.Builder
+	switch {
+	case !bb.running:
+		sb.WriteString("📊 Benchmark finished")
+	case bb.paused:
+		sb.WriteString("⏸ Benchmark paused (/benchmark resume to continue)")
+	case bb.turns > 0:
+		sb.WriteString("⏸ Benchmark waiting for the current chat turn")
+	default:
+		sb.WriteString("📊 Benchmark running")
+	}
+	sb.WriteString("\n\n")
+
+	state := bb.state
+	if state.Model != "" {
+		sb.WriteString(fmt.Sprintf("- Model: **%s** (%d of %d)\n", state.Model, state.ModelIndex, state.ModelCount))
+	}
+	if state.Step != "" {
+		sb.WriteString(fmt.Sprintf("- Step: %s (%d of %d)\n", state.Step, state.StepIndex, state.StepCount))
+	}
+	if len(state.TaskScores) > 0 {
+		tasks := make([]string, 0, len(state.TaskScores))
+		for task, score := range state.TaskScores {
+			tasks = append(tasks, fmt.Sprintf("%s %.2f", task, score))
+		}
+		sort.Strings(tasks)
+		sb.WriteString(fmt.Sprintf("- Scores so far: %s\n", strings.Join(tasks, ", ")))
+	}
+	for _, score := range state.Finished {
+		sb.WriteString(fmt.Sprintf("- ✓ %s: %.2f\n", score.Model, score.TotalScore))
+	}
+
+	fraction := state.Fraction()
+	if state.ModelCount > 0 {
+		sb.WriteString(fmt.Sprintf("- Progress: %s %.0f%% (%d of %d models done)\n", benchmark.ProgressBar(fraction, 20), fraction*100, state.ModelsDone, state.ModelCount))
+	}
+
+	active := bb.active
+	if bb.running && !bb.paused && bb.turns == 0 && !bb.activeSince.IsZero() {
+		active += time.Since(bb.activeSince)
+	}
+	if bb.running && fraction > 0 && fraction < 1 {
+		eta := time.Duration(float64(active) * (1 - fraction) / fraction)
+		sb.WriteString(fmt.Sprintf("- ETA: about %s of benchmarking\n", eta.Round(time.Second)))
+	}
+	if state.Last != "" {
+		sb.WriteString(fmt.Sprintf("- Last update: %s\n", state.Last))
+	}
+	return sb.String()
 }
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/benchmark"
+	"github.com/LaPingvino/llemecode/internal/config"
+)
+
+const benchmarkTasksUsage = "Usage: /benchmark tasks [list] | add <name> <category> <prompt...> | edit <name> name|description|category|prompt <value...> | remove <name> | import <file|url|pack> | validate"
+
+// tasks lists and edits the benchmark tasks stored in the config
+func (c *BenchmarkCommand) tasks(ctx context.Context, args []string) (string, error) {
+	if len(args) == 0 || args[0] == "list" {
+		return c.listTasks(), nil
+	}
+
+	switch args[0] {
+	case "add":
+		if len(args) < 4 {
+			return "Usage: /benchmark tasks add <name> <category> <prompt...>", nil
+		}
+		task := config.BenchmarkTask{
+			Name:     args[1],
+			Category: args[2],
+			Prompt:   strings.Join(args[3:], " "),
+		}
+		if err := benchmark.ValidateTask(task); err != nil {
+			return "", err
+		}
+		if _, ok := findTask(c.cfg.BenchmarkTasks, task.Name); ok {
+			return "", fmt.Errorf("task %q already exists; use /benchmark tasks edit", task.Name)
+		}
+		if err := c.saveTasks(append(c.currentTasks(), task)); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✓ Added benchmark task **%s** (%s)", task.Name, task.Category), nil
+
+	case "edit":
+		if len(args) < 4 {
+			return "Usage: /benchmark tasks edit <name> name|description|category|prompt <value...>", nil
+		}
+		tasks := c.currentTasks()
+		i, ok := findTask(tasks, args[1])
+		if !ok {
+			return "", fmt.Errorf("no benchmark task named %q", args[1])
+		}
+		value := strings.Join(args[3:], " ")
+		switch args[2] {
+		case "name":
+			tasks[i].Name = value
+		case "description":
+			tasks[i].Description = value
+		case "category":
+			tasks[i].Category = value
+		case "prompt":
+			tasks[i].Prompt = value
+		default:
+			return "", fmt.Errorf("unknown field %q (use name, description, category or prompt)", args[2])
+		}
+		if err := c.saveTasks(tasks); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✓ Updated %s of benchmark task **%s**", args[2], tasks[i].Name), nil
+
+	case "remove", "rm":
+		if len(args) < 2 {
+			return "Usage: /benchmark tasks remove <name>", nil
+		}
+		tasks := c.currentTasks()
+		i, ok := findTask(tasks, args[1])
+		if !ok {
+			return "", fmt.Errorf("no benchmark task named %q", args[1])
+		}
+		if err := c.saveTasks(append(tasks[:i], tasks[i+1:]...)); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✓ Removed benchmark task **%s**", args[1]), nil
+
+	case "import":
+		if len(args) < 2 {
+			return fmt.Sprintf("Usage: /benchmark tasks import <file|url|pack> (built-in packs: %s)", strings.Join(benchmark.BuiltinPacks(), ", ")), nil
+		}
+		pack, err := benchmark.LoadTaskPack(ctx, args[1])
+		if err != nil {
+			return "", err
+		}
+
+		// Tasks with the same name are replaced by the pack's version
+		tasks := c.currentTasks()
+		added, replaced := 0, 0
+		for _, task := range pack.Tasks {
+			if i, ok := findTask(tasks, task.Name); ok {
+				tasks[i] = task
+				replaced++
+			} else {
+				tasks = append(tasks, task)
+				added++
+			}
+		}
+		if err := c.saveTasks(tasks); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✓ Imported task pack **%s**: %d added, %d replaced", pack.Name, added, replaced), nil
+
+	case "validate":
+		if err := benchmark.ValidateTasks(c.currentTasks()); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✓ All %d benchmark tasks are valid", len(c.currentTasks())), nil
+
+	default:
+		return benchmarkTasksUsage, nil
+	}
+}
+
+func (c *BenchmarkCommand) listTasks() string {
+	var sb strings.Builder
+	sb.WriteString("📋 Benchmark tasks\n\n")
+
+	if len(c.cfg.BenchmarkTasks) == 0 {
+		sb.WriteString("No tasks configured; the built-in defaults are used.\n")
+	}
+	for _, task := range c.cfg.BenchmarkTasks {
+		sb.WriteString(fmt.Sprintf("- **%s** (%s)", task.Name, task.Category))
+		if task.Description != "" {
+			sb.WriteString(" - " + task.Description)
+		}
+		prompt := singleLine(task.Prompt)
+		if len(prompt) > 80 {
+			prompt = prompt[:77] + "..."
+		}
+		sb.WriteString(fmt.Sprintf("\n  _%s_\n", prompt))
+	}
+
+	sb.WriteString(fmt.Sprintf("\nCategories: %s\n", strings.Join(benchmark.Categories, ", ")))
+	sb.WriteString(fmt.Sprintf("Built-in packs: %s\n\n", strings.Join(benchmark.BuiltinPacks(), ", ")))
+	sb.WriteString(benchmarkTasksUsage)
+	return sb.String()
+}
+
+// currentTasks returns a copy of the configured tasks, starting from the
+// built-in defaults when none are configured
+func (c *BenchmarkCommand) currentTasks() []config.BenchmarkTask {
+	if len(c.cfg.BenchmarkTasks) == 0 {
+		return config.DefaultConfig().BenchmarkTasks
+	}
+	return append([]config.BenchmarkTask(nil), c.cfg.BenchmarkTasks...)
+}
+
+// saveTasks validates tasks and writes them to the config
+func (c *BenchmarkCommand) saveTasks(tasks []config.BenchmarkTask) error {
+	if err := benchmark.ValidateTasks(tasks); err != nil {
+		return err
+	}
+	if err := c.cfg.Update(func(cfg *config.Config) {
+		cfg.BenchmarkTasks = tasks
+	}); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	return nil
+}
+
+func findTask(tasks []config.BenchmarkTask, name string) (int, bool) {
+	for i, task := range tasks {
+		if task.Name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,496 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/agent"
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/logger"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/storage"
+	"github.com/LaPingvino/llemecode/internal/tools"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+// bridgeClientBuffer is how many events may queue for a slow client before
+// it is dropped
+const bridgeClientBuffer = 1024
+
+// bridgeEvent is sent to every connected client
+type bridgeEvent struct {
+	Type       string                 `json:"type"` // token, tool_start, tool_end, output, permission_request, budget_request, question, answered, done, command, error
+	ID         string                 `json:"id,omitempty"`
+	Iteration  int                    `json:"iteration,omitempty"`
+	Text       string                 `json:"text,omitempty"`
+	Tool       string                 `json:"tool,omitempty"`
+	Args       map[string]interface{} `json:"args,omitempty"`
+	Result     string                 `json:"result,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	DurationMs int64                  `json:"duration_ms,omitempty"`
+	Level      string                 `json:"level,omitempty"`
+	Options    []string               `json:"options,omitempty"`
+	Meta       *ollama.MessageMeta    `json:"meta,omitempty"` // What produced the reply, on done
+}
+
+// bridgeRequest is sent by a client
+type bridgeRequest struct {
+	Type   string `json:"type"` // chat, answer or cancel
+	Text   string `json:"text,omitempty"`
+	ID     string `json:"id,omitempty"`
+	Answer string `json:"answer,omitempty"`
+}
+
+// bridge shares one agent between any number of WebSocket clients: every
+// client sees every event, and any of them may chat or answer a prompt
+type bridge struct {
+	m      *chatModel
+	client *ollama.Client
+	cfg    *config.Config
+	tools  *tools.Registry
+	store  storage.Store
+	saver  *autosaver
+	token  string
+
+	mu      sync.Mutex
+	clients map[*bridgeClient]bool
+	pending map[string]chan string // Open prompts by ID
+	nextID  int
+	cancel  context.CancelFunc // Cancels the running turn, nil when idle
+}
+
+type bridgeClient struct {
+	conn   *websocket.Conn
+	events chan bridgeEvent
+}
+
+// RunBridge serves the agent over WebSocket at addr until ctx is done. Clients
+// connect to ws://addr/ws?token=<token>, with the token printed at startup.
+// The same address serves an OpenAI-compatible API under /v1 that takes the
+// token as its API key.
+func RunBridge(ctx context.Context, client *ollama.Client, cfg *config.Config, toolRegistry *tools.Registry, bgBenchmark *BackgroundBenchmark, addr string) error {
+	model := cfg.DefaultModel
+	if model == "" {
+		return fmt.Errorf("no default model configured. Please run setup first")
+	}
+
+	store, err := storage.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer store.Close()
+
+	token, err := newBridgeToken()
+	if err != nil {
+		return err
+	}
+
+	ag := newChatAgent(client, cfg, toolRegistry, model)
+	watchWorkspace(ag, cfg, toolRegistry)
+	saver := newAutosaver(ag, model, store, newSessionTitler(client, cfg), newSessionSummarizer(client, cfg))
+	b := &bridge{
+		client:  client,
+		cfg:     cfg,
+		tools:   toolRegistry,
+		store:   store,
+		saver:   saver,
+		token:   token,
+		clients: make(map[*bridgeClient]bool),
+		pending: make(map[string]chan string),
+	}
+	b.m = &chatModel{
+		agent:                ag,
+		ctx:                  ctx,
+		bgBenchmark:          bgBenchmark,
+		commands:             newCommandRegistry(client, cfg, toolRegistry),
+		sessionDisabledTools: make(map[string]bool),
+		ctrl:                 newChatController(),
+		autosave:             saver,
+		store:                store,
+	}
+
+	toolRegistry.SetPermissionChecker(&bridgePermissionChecker{bridge: b, permissions: toolRegistry.PermissionConfig()})
+	setCommandExecutor(toolRegistry, &bridgeCommandExecutor{bridge: b})
+	ag.SetBudgetPrompt(func(ctx context.Context, reason string) bool {
+		answer, ok := b.ask(ctx, bridgeEvent{Type: "budget_request", Text: reason, Options: []string{"y", "n"}})
+		return ok && answer == "y"
+	})
+	toolRegistry.SetUserAsker(userAskerFunc(func(ctx context.Context, question string, options []string) (string, error) {
+		answer, _ := b.ask(ctx, bridgeEvent{Type: "question", Text: question, Options: options})
+		return pickOption(answer, options), ctx.Err()
+	}))
+	ag.SetToolObserver(auditObserver(store, func(execution agent.ToolExecution, finished bool) {
+		ev := bridgeEvent{Type: "tool_start", Tool: execution.Name, Args: execution.Args}
+		if finished {
+			ev = bridgeEvent{Type: "tool_end", Tool: execution.Name, Result: execution.Result, DurationMs: execution.Duration.Milliseconds()}
+			if execution.Error != nil {
+				ev.Error = execution.Error.Error()
+			}
+		}
+		b.broadcast(ev)
+	}))
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", b.authorize(b.serveWS))
+	mux.HandleFunc("/v1/chat/completions", b.authorize(b.serveChatCompletions))
+	mux.HandleFunc("/v1/models", b.authorize(b.serveModels))
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	fmt.Printf("🌐 WebSocket bridge for %s at ws://%s/ws?token=%s\n", model, listener.Addr(), token)
+	fmt.Printf("🔌 OpenAI-compatible API at http://%s/v1 with API key %s\n", listener.Addr(), token)
+	fmt.Println("Anyone with the token can use the agent and approve its tools. Press Ctrl+C to stop.")
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serve: %w", err)
+	}
+	saver.save()
+	return nil
+}
+
+func newBridgeToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// authorize lets requests through that carry the token, either as a token
+// query parameter or as a bearer API key
+func (b *bridge) authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimPrefix(auth, "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(b.token)) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (b *bridge) serveWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		// The token is the access check; this lets a front-end on another port connect
+		OriginPatterns: []string{"localhost:*", "127.0.0.1:*", "[::1]:*"},
+	})
+	if err != nil {
+		logger.Log("bridge: accept: %v", err)
+		return
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(1 << 20)
+
+	c := &bridgeClient{conn: conn, events: make(chan bridgeEvent, bridgeClientBuffer)}
+	b.mu.Lock()
+	b.clients[c] = true
+	b.mu.Unlock()
+	logger.Log("bridge: client connected from %s", r.RemoteAddr)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer func() {
+		cancel()
+		b.mu.Lock()
+		delete(b.clients, c)
+		b.mu.Unlock()
+		logger.Log("bridge: client %s disconnected", r.RemoteAddr)
+	}()
+
+	go func() {
+		defer cancel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-c.events:
+				if !ok {
+					conn.Close(websocket.StatusPolicyViolation, "too slow to keep up with events")
+					return
+				}
+				if err := wsjson.Write(ctx, conn, ev); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		var req bridgeRequest
+		if err := wsjson.Read(ctx, conn, &req); err != nil {
+			return
+		}
+		b.handle(req)
+	}
+}
+
+func (b *bridge) handle(req bridgeRequest) {
+	switch req.Type {
+	case "chat":
+		b.startTurn(strings.TrimSpace(req.Text))
+	case "answer":
+		b.mu.Lock()
+		reply, ok := b.pending[req.ID]
+		delete(b.pending, req.ID)
+		b.mu.Unlock()
+		if ok {
+			reply <- strings.ToLower(strings.TrimSpace(req.Answer))
+			b.broadcast(bridgeEvent{Type: "answered", ID: req.ID, Text: req.Answer})
+		}
+	case "cancel":
+		b.mu.Lock()
+		if b.cancel != nil {
+			b.cancel()
+		}
+		b.mu.Unlock()
+	default:
+		b.broadcast(bridgeEvent{Type: "error", Error: fmt.Sprintf("unknown request type %q", req.Type)})
+	}
+}
+
+// startTurn runs a message or slash command in the background; only one
+// runs at a time
+func (b *bridge) startTurn(text string) {
+	if text == "" {
+		return
+	}
+	b.mu.Lock()
+	if b.cancel != nil {
+		b.mu.Unlock()
+		b.broadcast(bridgeEvent{Type: "error", Error: "a response is in progress; wait for it or cancel it"})
+		return
+	}
+	ctx, cancel := context.WithCancel(b.m.ctx)
+	b.cancel = cancel
+	b.mu.Unlock()
+
+	go func() {
+		ev := b.runTurn(ctx, text)
+		cancel()
+		b.mu.Lock()
+		b.cancel = nil
+		b.mu.Unlock()
+		b.broadcast(ev)
+	}()
+}
+
+// runTurn runs one message or slash command and returns the event that
+// reports how it ended
+func (b *bridge) runTurn(ctx context.Context, text string) bridgeEvent {
+	if result, isCmd, err := b.m.commands.Execute(ctx, text, b.m); isCmd {
+		if err != nil {
+			return bridgeEvent{Type: "error", Error: err.Error()}
+		}
+		return bridgeEvent{Type: "command", Text: result}
+	}
+
+	release := b.m.bgBenchmark.HoldForTurn()
+	defer release()
+	defer b.saver.save()
+	started := time.Now()
+	resp, err := b.m.agent.ChatStream(ctx, text, func(iteration int, chunk string) {
+		b.broadcast(bridgeEvent{Type: "token", Iteration: iteration, Text: chunk})
+	})
+	if err != nil {
+		return bridgeEvent{Type: "error", Error: err.Error()}
+	}
+	recordUsage(b.store, b.m.agent.Model(), time.Since(started), resp)
+	return bridgeEvent{Type: "done", Text: resp.Content, DurationMs: time.Since(started).Milliseconds(), Meta: resp.Meta}
+}
+
+// broadcast queues an event for every client, dropping clients that have
+// fallen too far behind
+func (b *bridge) broadcast(ev bridgeEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for c := range b.clients {
+		select {
+		case c.events <- ev:
+		default:
+			close(c.events)
+			delete(b.clients, c)
+		}
+	}
+}
+
+// ask sends a prompt to every client and waits for the first answer that is
+// one of its options; questions take any answer. With no clients connected
+// nobody can answer, so it returns false at once.
+func (b *bridge) ask(ctx context.Context, ev bridgeEvent) (string, bool) {
+	for {
+		b.mu.Lock()
+		if len(b.clients) == 0 {
+			b.mu.Unlock()
+			logger.Log("bridge: no client to answer %s for %s", ev.Type, ev.Tool)
+			return "", false
+		}
+		b.nextID++
+		ev.ID = "q" + strconv.Itoa(b.nextID)
+		reply := make(chan string, 1)
+		b.pending[ev.ID] = reply
+		b.mu.Unlock()
+		b.broadcast(ev)
+
+		select {
+		case answer := <-reply:
+			if ev.Type == "question" {
+				return answer, true // Options only suggest answers to questions
+			}
+			for _, option := range ev.Options {
+				if answer == option {
+					return answer, true
+				}
+			}
+			b.broadcast(bridgeEvent{Type: "error", Error: fmt.Sprintf("answer one of %s", strings.Join(ev.Options, ", "))})
+		case <-ctx.Done():
+			b.mu.Lock()
+			delete(b.pending, ev.ID)
+			b.mu.Unlock()
+			return "", false
+		}
+	}
+}
+
+// bridgePermissionChecker asks the connected clients to approve tool calls,
+// with the same choices as the plain chat
+type bridgePermissionChecker struct {
+	bridge      *bridge
+	permissions *tools.PermissionConfig
+}
+
+func (c *bridgePermissionChecker) RequestPermission(ctx context.Context, tool string, level tools.PermissionLevel, details string) (bool, error) {
+	targetPath := extractPathFromDetails(tool, details)
+	options := []string{"y", "s", "n"}
+	if tool != "run_command" || targetPath == "" {
+		options = append(options, "a")
+	}
+	if tool == "run_command" {
+		options = append(options, "c")
+	}
+	if targetPath != "" {
+		options = append(options, "p")
+	}
+
+	answer, ok := c.bridge.ask(ctx, bridgeEvent{
+		Type:    "permission_request",
+		Tool:    tool,
+		Level:   bridgeLevel(level),
+		Text:    details,
+		Options: options,
+	})
+	if !ok {
+		return false, ctx.Err()
+	}
+	resp := permissionResponse{
+		approved:      answer != "n",
+		alwaysTool:    answer == "a",
+		alwaysCommand: answer == "c",
+		alwaysPath:    answer == "p",
+		session:       answer == "s",
+	}
+	if resp.approved {
+		grantPermission(c.permissions, tool, details, targetPath, resp)
+	}
+	return resp.approved, nil
+}
+
+// RequestOutsideWorkspace asks whether a tool may use a path outside the workspace
+func (c *bridgePermissionChecker) RequestOutsideWorkspace(ctx context.Context, tool, path string) (tools.OutsideWorkspaceDecision, error) {
+	answer, ok := c.bridge.ask(ctx, bridgeEvent{
+		Type:    "permission_request",
+		Tool:    tool,
+		Level:   "outside_workspace",
+		Text:    path,
+		Options: []string{"y", "s", "r", "n"},
+	})
+	if !ok {
+		return tools.OutsideWorkspaceDeny, ctx.Err()
+	}
+	switch answer {
+	case "y":
+		return tools.OutsideWorkspaceAllowOnce, nil
+	case "s":
+		return tools.OutsideWorkspaceAddSessionRoot, nil
+	case "r":
+		saveAllowedRoot(tools.RootFor(path))
+		return tools.OutsideWorkspaceAddRoot, nil
+	default:
+		return tools.OutsideWorkspaceDeny, nil
+	}
+}
+
+// bridgeLevel names a permission level for clients, independent of the
+// interface language
+func bridgeLevel(level tools.PermissionLevel) string {
+	switch level {
+	case tools.PermissionExecute:
+		return "execute"
+	case tools.PermissionWrite:
+		return "write"
+	case tools.PermissionNetwork:
+		return "network"
+	case tools.PermissionSafe:
+		return "safe"
+	default:
+		return "read"
+	}
+}
+
+// bridgeCommandExecutor runs commands and streams their output to the clients
+type bridgeCommandExecutor struct {
+	bridge *bridge
+}
+
+func (e *bridgeCommandExecutor) Execute(ctx context.Context, command string) (output string, exitCode int, err error) {
+	var captured strings.Builder
+	w := &bridgeOutput{bridge: e.bridge, captured: &captured}
+	cmd := exec.CommandContext(ctx, "bash", "-c", command)
+	cmd.Dir = tools.WorkDir(ctx)
+	cmd.Stdout = w
+	cmd.Stderr = w
+
+	err = cmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitCode = exitErr.ExitCode()
+		}
+	}
+	return captured.String(), exitCode, err
+}
+
+type bridgeOutput struct {
+	bridge   *bridge
+	mu       sync.Mutex
+	captured *strings.Builder
+}
+
+func (o *bridgeOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	o.captured.Write(p)
+	o.mu.Unlock()
+	o.bridge.broadcast(bridgeEvent{Type: "output", Tool: "run_command", Text: string(p)})
+	return len(p), nil
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/LaPingvino/llemecode/internal/agent"
+	"github.com/LaPingvino/llemecode/internal/i18n"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// budgetRequest asks the user whether the agent may continue a turn that
+// exceeded its budget
+type budgetRequest struct {
+	reason   string
+	response chan bool
+}
+
+type budgetRequestMsg struct {
+	request *budgetRequest
+}
+
+// newInlineBudgetPrompt shows budget prompts in the chat UI and waits for y/n
+func newInlineBudgetPrompt(program *tea.Program) agent.BudgetPrompt {
+	return func(ctx context.Context, reason string) bool {
+		request := &budgetRequest{
+			reason:   reason,
+			response: make(chan bool, 1),
+		}
+		program.Send(budgetRequestMsg{request: request})
+
+		select {
+		case approved := <-request.response:
+			return approved
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// answerBudget resolves the pending budget prompt
+func (m *chatModel) answerBudget(approved bool) {
+	m.pendingBudget.response <- approved
+	close(m.pendingBudget.response)
+	m.pendingBudget = nil
+	if approved {
+		m.processingStatus = i18n.T("status.continuing")
+	} else {
+		m.processingStatus = i18n.T("status.stopping")
+	}
+}
@@ -0,0 +1,361 @@
+package cli
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/session"
+	"github.com/LaPingvino/llemecode/internal/storage"
+	"github.com/LaPingvino/llemecode/internal/tools"
+	"github.com/LaPingvino/llemecode/internal/version"
+)
+
+// bugReportAuditEntries is how many recent tool calls a report includes
+const bugReportAuditEntries = 50
+
+// BugReport says what goes into a report besides the version, config,
+// environment and doctor output
+type BugReport struct {
+	LogFile   string // Log written with --log-to-file; empty for none
+	LogLines  int    // Lines taken from the end of the log
+	SessionID string // Transcript to include; empty uses the crash recovery file or else the latest session
+	Dir       string // Where the tarball is written
+}
+
+// RunBugReport writes a tarball for attaching to an issue. Everything stays
+// local; secrets, the user name and remote host names are scrubbed, but the
+// transcript still holds what the model saw, so the user should look first.
+func RunBugReport(ctx context.Context, w io.Writer, report BugReport, urlOverride string, newClient func(*config.Config) *ollama.Client) error {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	s := newScrubber(cfg)
+	files := []reportFile{
+		{"version.txt", version.Get().String() + "\n"},
+		{"environment.txt", environmentReport()},
+	}
+
+	var doctorOut bytes.Buffer
+	_ = RunDoctor(ctx, &doctorOut, urlOverride, newClient) // Problems are what the report is for
+	files = append(files, reportFile{"doctor.txt", doctorOut.String()})
+
+	if path, err := config.GetConfigPath(); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			files = append(files, reportFile{"config.json", anonymizeConfig(data)})
+		}
+	}
+
+	if report.LogFile != "" {
+		tail, err := tailLines(report.LogFile, report.LogLines)
+		if err != nil {
+			return err
+		}
+		files = append(files, reportFile{"log.txt", tail})
+	}
+
+	store, err := storage.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer store.Close()
+	transcript, source, err := reportTranscript(store, report.SessionID)
+	if err != nil {
+		return err
+	}
+	if transcript != "" {
+		files = append(files, reportFile{"transcript.md", transcript})
+	}
+	if audit := reportAudit(store); audit != "" {
+		files = append(files, reportFile{"tool_calls.txt", audit})
+	}
+
+	name := "llemecode-report-" + time.Now().Format("20060102-150405")
+	path := filepath.Join(report.Dir, name+".tar.gz")
+	if err := writeReportTarball(path, name, files, s); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "✓ Wrote %s\n", path)
+	for _, f := range files {
+		fmt.Fprintf(w, "  %s\n", f.name)
+	}
+	if source != "" {
+		fmt.Fprintf(w, "The transcript is %s.\n", source)
+	}
+	fmt.Fprintln(w, "Nothing was uploaded. Secrets, your user name and remote host names were replaced, but look through the files before attaching them to an issue.")
+	return nil
+}
+
+type reportFile struct {
+	name    string
+	content string
+}
+
+func writeReportTarball(path, dir string, files []reportFile, s *scrubber) error {
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("create report: %w", err)
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, f := range files {
+		s.learn(f.content)
+	}
+	for _, f := range files {
+		content := []byte(s.scrub(f.content))
+		header := &tar.Header{Name: dir + "/" + f.name, Mode: 0600, Size: int64(len(content)), ModTime: now}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	return out.Close()
+}
+
+func environmentReport() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("os: %s/%s\n", runtime.GOOS, runtime.GOARCH))
+	sb.WriteString(fmt.Sprintf("go: %s\n", runtime.Version()))
+	sb.WriteString(fmt.Sprintf("cpus: %d\n", runtime.NumCPU()))
+	if profile := config.Profile(); profile != "" {
+		sb.WriteString("profile: " + profile + "\n")
+	}
+	if _, err := os.Stat(config.PolicyPath); err == nil {
+		sb.WriteString("team policy: " + config.PolicyPath + "\n")
+	}
+	for _, name := range []string{"TERM", "COLORTERM", "SHELL", "LANG", "LC_ALL", "LLEMECODE_LANG", "LLEMECODE_PROFILE", "OLLAMA_HOST", "SSH_CONNECTION", "TMUX"} {
+		if value, ok := os.LookupEnv(name); ok {
+			if name == "SSH_CONNECTION" {
+				value = "(set)"
+			}
+			sb.WriteString(fmt.Sprintf("%s=%s\n", name, tools.RedactEnv(name, value)))
+		}
+	}
+	return sb.String()
+}
+
+// tailLines returns the last n lines of the file at path
+func tailLines(path string, n int) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open log: %w", err)
+	}
+	defer f.Close()
+
+	lines := make([]string, 0, n)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(lines) == n {
+			lines = lines[1:]
+		}
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("read log: %w", err)
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// reportTranscript renders the chosen conversation and says where it came from
+func reportTranscript(store storage.Store, sessionID string) (string, string, error) {
+	if sessionID == "" {
+		if snapshot, err := session.LoadRecovery(); err == nil && snapshot != nil && len(snapshot.Messages) > 0 {
+			return renderTranscript(snapshot.Model, snapshot.Messages), "the conversation that did not exit cleanly", nil
+		}
+		sessions, err := store.ListSessions()
+		if err != nil || len(sessions) == 0 {
+			return "", "", nil
+		}
+		sessionID = sessions[0].ID
+	}
+	sess, err := store.LoadSession(sessionID)
+	if err != nil {
+		return "", "", fmt.Errorf("load session %s: %w", sessionID, err)
+	}
+	return renderTranscript(sess.Model, sess.Messages), "session " + sess.ID, nil
+}
+
+func renderTranscript(model string, messages []ollama.Message) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Transcript (%s)\n", model))
+	for _, msg := range messages {
+		if msg.Meta != nil {
+			sb.WriteString(fmt.Sprintf("\n## %s (%s)\n\n%s\n", msg.Role, formatMeta(msg.Meta), msg.Content))
+		} else {
+			sb.WriteString(fmt.Sprintf("\n## %s\n\n%s\n", msg.Role, msg.Content))
+		}
+		for _, call := range msg.ToolCalls {
+			args, _ := json.Marshal(call.Function.Arguments)
+			sb.WriteString(fmt.Sprintf("\n→ %s %s\n", call.Function.Name, args))
+		}
+	}
+	return sb.String()
+}
+
+func reportAudit(store storage.Store) string {
+	entries, err := store.Audit(bugReportAuditEntries)
+	if err != nil || len(entries) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for _, e := range entries {
+		sb.WriteString(fmt.Sprintf("%s %s %s", e.Time.Format(time.RFC3339), e.Tool, e.Args))
+		if e.Error != "" {
+			sb.WriteString(" ✗ " + e.Error)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// personalKeys are config settings that identify a person or machine
+var personalKeys = map[string]bool{"host": true, "user": true, "dsn": true, "identity_file": true, "email": true}
+
+// anonymizeConfig blanks secret and personal settings in a config file
+func anonymizeConfig(data []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return "(config.json could not be parsed: " + err.Error() + ")\n"
+	}
+	out, _ := json.MarshalIndent(anonymizeValue("", v), "", "  ")
+	return string(out) + "\n"
+}
+
+func anonymizeValue(key string, v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = anonymizeValue(k, item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = anonymizeValue(key, item)
+		}
+	case string:
+		if value != "" && (personalKeys[key] || tools.IsSecretName(key)) {
+			return "[REDACTED]"
+		}
+	}
+	return v
+}
+
+// remoteURL matches a URL's scheme, user info and host, in any case
+var remoteURL = regexp.MustCompile(`(?i)\b[a-z][a-z0-9+.-]*://[^/\s"'<>(){},;?#]+`)
+
+// publicHosts are left in reports; they say nothing about the user
+var publicHosts = map[string]bool{"localhost": true, "127.0.0.1": true, "0.0.0.0": true, "::1": true, "github.com": true, "ollama.com": true}
+
+// scrubber removes secrets and what identifies the user from report files
+type scrubber struct {
+	replacer *strings.Replacer
+	hosts    []*regexp.Regexp // Known private hosts, matched in any case
+	seen     map[string]bool
+}
+
+// newScrubber prepares to scrub the user's home, names and host, and the
+// Ollama servers and SSH tunnel configured in cfg
+func newScrubber(cfg *config.Config) *scrubber {
+	var pairs []string
+	if home, err := os.UserHomeDir(); err == nil && home != "/" {
+		pairs = append(pairs, home, "~")
+	}
+	if u, err := user.Current(); err == nil && len(u.Username) >= 3 {
+		pairs = append(pairs, u.Username, "[user]")
+	}
+	if host, err := os.Hostname(); err == nil && len(host) >= 3 {
+		pairs = append(pairs, host, "[hostname]")
+	}
+
+	s := &scrubber{seen: make(map[string]bool)}
+	if cfg != nil {
+		s.addURLHost(cfg.OllamaURL)
+		for _, ep := range cfg.Endpoints {
+			s.addURLHost(ep.URL)
+		}
+		if t := cfg.SSHTunnel; t != nil {
+			host := t.Host
+			if at := strings.LastIndex(host, "@"); at >= 0 {
+				if len(host[:at]) >= 3 {
+					pairs = append(pairs, host[:at], "[user]")
+				}
+				host = host[at+1:]
+			}
+			s.addHost(host)
+			if len(t.User) >= 3 {
+				pairs = append(pairs, t.User, "[user]")
+			}
+		}
+	}
+	s.replacer = strings.NewReplacer(pairs...)
+	return s
+}
+
+// addURLHost scrubs the host of rawURL from now on
+func (s *scrubber) addURLHost(rawURL string) {
+	if u, err := url.Parse(rawURL); err == nil {
+		s.addHost(u.Hostname())
+	}
+}
+
+func (s *scrubber) addHost(host string) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if len(host) < 3 || publicHosts[host] || s.seen[host] {
+		return
+	}
+	s.seen[host] = true
+	s.hosts = append(s.hosts, regexp.MustCompile("(?i)"+regexp.QuoteMeta(host)))
+}
+
+// learn notes the hosts of the URLs in text, so they are scrubbed from
+// every file, e.g. in DNS errors
+func (s *scrubber) learn(text string) {
+	for _, match := range remoteURL.FindAllString(text, -1) {
+		s.addURLHost(match)
+	}
+}
+
+func (s *scrubber) scrub(text string) string {
+	s.learn(text)
+	text = tools.RedactSecrets(text)
+	// Credentials in URLs go, whatever the scheme
+	text = remoteURL.ReplaceAllStringFunc(text, func(match string) string {
+		u, err := url.Parse(match)
+		if err != nil || u.User == nil {
+			return match
+		}
+		return u.Scheme + "://[REDACTED]@" + u.Host
+	})
+	for _, host := range s.hosts {
+		text = host.ReplaceAllString(text, "[host]")
+	}
+	return s.replacer.Replace(text)
+}
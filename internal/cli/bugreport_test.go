@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+)
+
+func TestScrubberHosts(t *testing.T) {
+	cfg := &config.Config{
+		OllamaURL: "http://ollama.home.arpa:11434",
+		Endpoints: []config.EndpointConfig{{Name: "gpu", URL: "http://big-gpu.lab:11434"}},
+		SSHTunnel: &config.SSHTunnelConfig{Host: "deploy@bastion.corp.example", User: "tunneler"},
+	}
+	tests := []struct {
+		name, in string
+		gone     []string
+		kept     string
+	}{
+		{"userinfo", "dial postgres://admin:pw@db.internal.corp:5432/app failed", []string{"admin", "pw@", "db.internal.corp"}, "postgres://[REDACTED]@[host]:5432/app"},
+		{"uppercase scheme", "GET HTTP://GPU.LAN:11434/api/tags", []string{"GPU.LAN"}, "HTTP://[host]:11434/api/tags"},
+		{"host elsewhere", "see http://gpu.lan:11434) then lookup GPU.lan: no such host", []string{"gpu.lan", "GPU.lan"}, "lookup [host]: no such host"},
+		{"ollama url", "connection refused: ollama.home.arpa", []string{"ollama.home.arpa"}, "refused: [host]"},
+		{"endpoint", "endpoint big-gpu.lab is down", []string{"big-gpu.lab"}, "endpoint [host] is down"},
+		{"ssh tunnel", "ssh: connect to host bastion.corp.example as deploy, then tunneler", []string{"bastion.corp.example", "deploy", "tunneler"}, "host [host] as [user]"},
+		{"public hosts", "https://github.com/LaPingvino/llemecode and http://localhost:11434", nil, "https://github.com/LaPingvino/llemecode and http://localhost:11434"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newScrubber(cfg).scrub(tt.in)
+			for _, gone := range tt.gone {
+				if strings.Contains(got, gone) {
+					t.Errorf("scrub(%q) = %q, still contains %q", tt.in, got, gone)
+				}
+			}
+			if !strings.Contains(got, tt.kept) {
+				t.Errorf("scrub(%q) = %q, expected it to contain %q", tt.in, got, tt.kept)
+			}
+		})
+	}
+}
@@ -3,20 +3,29 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/LaPingvino/llemecode/internal/agent"
+	"github.com/LaPingvino/llemecode/internal/benchmark"
 	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/i18n"
 	"github.com/LaPingvino/llemecode/internal/logger"
+	"github.com/LaPingvino/llemecode/internal/notes"
 	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/session"
+	"github.com/LaPingvino/llemecode/internal/storage"
 	"github.com/LaPingvino/llemecode/internal/tools"
+	"github.com/LaPingvino/llemecode/internal/version"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"golang.org/x/sys/unix"
 )
 
@@ -32,6 +41,9 @@ type chatModel struct {
 	width                int
 	height               int
 	glamour              *glamour.TermRenderer
+	codeStyle            string   // Chroma style for code in tool results; empty for none
+	imageProtocol        string   // How /image draws images; empty when the terminal can't
+	images               []string // Images tools produced this session, for /image
 	bgBenchmark          *BackgroundBenchmark
 	benchmarkDone        bool
 	commands             *CommandRegistry
@@ -44,28 +56,58 @@ type chatModel struct {
 	searchResults        []int           // Indices in history matching search
 	searchIndex          int             // Current position in search results
 	statusMessage        string          // Current status message from logger
+	pastes               *pasteBuffer    // Long pastes shown as placeholders in the input
+	pendingPaste         int             // Bytes of a large paste awaiting y/n before it is sent
+	pasteConfirmed       bool            // The next send goes ahead without asking
 
 	// Async task management
-	currentTask      context.CancelFunc // Cancel function for current task
-	messageQueue     []string           // Messages queued while task is running
-	processingStatus string             // Current processing status (e.g., "Thinking...", "Running command...")
+	ctrl             *chatController // Task cancellation, queue and streaming state shared with tea.Cmds
+	processingStatus string          // Current processing status (e.g., "Thinking...", "Running command...")
+	queueMode        bool            // Ctrl+Q: arrow keys select and reorder queued messages
+	queueCursor      int             // Selected queued message in queue mode
 
 	// Permission handling
 	pendingPermission *permissionRequest // Current permission request awaiting response
 	permissionMode    bool               // True when waiting for y/n input
+	pendingBudget     *budgetRequest     // Turn budget exceeded, awaiting y/n to continue
+	pendingQuestion   *questionRequest   // Model asked a clarifying question, awaiting the answer
 
 	// Command execution overlay
 	activeCommands []*commandExecution // Currently running/recent commands
+
+	// Crash recovery
+	autosave       *autosaver        // Periodically persists the conversation
+	pendingRestore *session.Snapshot // Recovered conversation awaiting y/n
+	store          storage.Store     // Sessions, usage and the audit log
+
+	attention *attention // Bell and desktop notifications
+
+	panel sidePanel // Ctrl+O: split view with the current file, diff or command output
+
+	modelPicker *inlineModelPicker // Opened by /model without arguments
+
+	startup []string // Configured startup actions not run yet
+
+	toolSpans []messageSpan // Transcript lines of collapsible tool results, for mouse clicks
 }
 
 type message struct {
-	role    string
-	content string
+	role     string
+	content  string
+	expanded bool   // Tool results are collapsed to a few lines until clicked
+	display  string // User input with its pastes collapsed; empty when nothing was pasted
+
+	codeStart   int    // Where the result starts in content when it is highlighted
+	highlighted string // The result with its code highlighted; empty for plain text
+
+	meta *ollama.MessageMeta // What produced an assistant reply
 }
 
 type responseMsg struct {
+	taskID    uint64
 	content   string
 	toolCalls []agent.ToolExecution
+	meta      *ollama.MessageMeta
 	err       error
 }
 
@@ -73,6 +115,13 @@ type statusMsg struct {
 	message string
 }
 
+// sendPromptMsg sends text as if the user typed it, for commands that start
+// a turn; display is what the transcript shows instead, if set
+type sendPromptMsg struct {
+	text    string
+	display string
+}
+
 // Command execution tracking
 type commandExecution struct {
 	id       string   // Unique ID for this command
@@ -81,16 +130,20 @@ type commandExecution struct {
 	running  bool     // Whether still executing
 	exitCode int      // Exit code when done
 	err      error    // Error if any
+	model    string   // Set when this is a delegated model's answer
+	partial  string   // Unfinished last line of a streamed answer
 }
 
 type commandStartMsg struct {
 	id      string
 	command string
+	model   string
 }
 
 type commandOutputMsg struct {
-	id   string
-	line string
+	id      string
+	line    string
+	partial bool // Replaces the unfinished last line instead of adding one
 }
 
 type commandEndMsg struct {
@@ -115,6 +168,9 @@ var (
 			Foreground(lipgloss.Color("196")).
 			Bold(true)
 
+	timingStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241"))
+
 	headerStyle = lipgloss.NewStyle().
 			Bold(true).
 			Foreground(lipgloss.Color("205")).
@@ -123,45 +179,26 @@ var (
 			Padding(0, 1)
 )
 
-func RunChat(ctx context.Context, client *ollama.Client, cfg *config.Config, toolRegistry *tools.Registry, bgBenchmark *BackgroundBenchmark) error {
+// RunChat runs the full-screen chat. A non-empty draft starts out in the
+// input, ready to be edited and sent.
+func RunChat(ctx context.Context, client *ollama.Client, cfg *config.Config, toolRegistry *tools.Registry, bgBenchmark *BackgroundBenchmark, draft string) error {
 	model := cfg.DefaultModel
 	if model == "" {
 		return fmt.Errorf("no default model configured. Please run setup first")
 	}
 
-	ag := agent.New(client, toolRegistry, cfg, model)
-
-	// Set disabled tools from config
-	ag.SetDisabledTools(cfg.DisabledTools)
-
-	// Add system prompt
-	if sysPrompt, ok := cfg.SystemPrompts["default"]; ok {
-		ag.AddSystemPrompt(sysPrompt)
-	} else {
-		ag.AddSystemPrompt("")
+	store, err := storage.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
 	}
+	defer store.Close()
 
-	// Setup command registry
-	cmdRegistry := NewCommandRegistry()
-	cmdRegistry.Register(NewHelpCommand(cmdRegistry))
-	cmdRegistry.Register(NewListModelsCommand(client, cfg))
-	cmdRegistry.Register(NewSwitchModelCommand(client, cfg, toolRegistry))
-	cmdRegistry.Register(NewListPromptsCommand(cfg))
-	cmdRegistry.Register(NewResetCommand())
-	cmdRegistry.Register(NewBenchmarkCommand(client, cfg))
-	cmdRegistry.Register(NewConfigCommand())
-	cmdRegistry.Register(NewToolsCommand(toolRegistry))
-	cmdRegistry.Register(NewAddToolCommand(client, cfg, toolRegistry))
-	cmdRegistry.Register(NewAddAllToolsCommand(client, cfg, toolRegistry))
-	cmdRegistry.Register(NewRemoveToolCommand(cfg, toolRegistry))
-	cmdRegistry.Register(NewEnableToolCommand(cfg, toolRegistry))
-	cmdRegistry.Register(NewDisableToolCommand(cfg, toolRegistry))
-	cmdRegistry.Register(NewListDisabledToolsCommand(cfg))
-	cmdRegistry.Register(NewTestToolCommand(toolRegistry))
-	cmdRegistry.Register(NewClearQueueCommand())
+	ag := newChatAgent(client, cfg, toolRegistry, model)
+	watchWorkspace(ag, cfg, toolRegistry)
+	cmdRegistry := newCommandRegistry(client, cfg, toolRegistry)
 
 	ta := textarea.New()
-	ta.Placeholder = "Type your message or /help for commands..."
+	ta.Placeholder = i18n.T("chat.placeholder")
 	ta.Focus()
 	ta.CharLimit = 4000
 	ta.SetWidth(80)
@@ -175,13 +212,15 @@ func RunChat(ctx context.Context, client *ollama.Client, cfg *config.Config, too
 	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
 
 	gr, err := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
+		glamour.WithStandardStyle(markdownStyle(cfg.Theme)),
 		glamour.WithWordWrap(78),
 	)
 	if err != nil {
 		gr = nil
 	}
 
+	saver := newAutosaver(ag, model, store, newSessionTitler(client, cfg), newSessionSummarizer(client, cfg))
+
 	m := chatModel{
 		agent:                ag,
 		textarea:             ta,
@@ -190,41 +229,71 @@ func RunChat(ctx context.Context, client *ollama.Client, cfg *config.Config, too
 		spinner:              s,
 		ctx:                  ctx,
 		glamour:              gr,
+		codeStyle:            codeStyle(cfg.Theme),
+		imageProtocol:        imageProtocol(cfg.Images),
+		pastes:               &pasteBuffer{settings: cfg.Paste},
 		bgBenchmark:          bgBenchmark,
 		commands:             cmdRegistry,
 		sessionDisabledTools: make(map[string]bool),
 		history:              []string{},
 		historyIndex:         -1,
 		searchMode:           false,
+		ctrl:                 newChatController(),
+		autosave:             saver,
+		store:                store,
+		attention:            newAttention(cfg.Notifications),
 	}
 
 	// Add welcome message
-	welcomeMsg := fmt.Sprintf("Welcome to Llemecode! You are using **%s**.\n\nAvailable commands:\n- `/help` - Show all commands\n- `/model <name>` - Switch model\n- `/models` - List available models\n- `/reset` - Clear conversation\n\nType your message and press Enter to chat.", model)
+	dir, _ := os.Getwd()
+	startup := startupFor(cfg.Startup, dir)
+	m.startup = startup.Actions
 	m.messages = append(m.messages, message{
 		role:    "system",
-		content: welcomeMsg,
+		content: welcomeMessage(startup.Welcome, model, dir),
 	})
+
+	if fit := checkModelFit(ctx, client, cfg, model); fit != nil {
+		m.messages = append(m.messages, message{role: "error", content: fit.warning()})
+	}
+
+	// Offer to restore a conversation left behind by a crash or SIGTERM
+	if snapshot, err := session.LoadRecovery(); err != nil {
+		logger.Log("RunChat: failed to load recovery file: %v", err)
+	} else if snapshot != nil && snapshot.UserMessageCount() == 0 {
+		// Only a draft was left; no need to ask
+		m.restoreDraft(snapshot.Draft)
+		if err := session.ClearRecovery(); err != nil {
+			logger.Log("RunChat: failed to clear recovery file: %v", err)
+		}
+	} else if snapshot != nil {
+		m.pendingRestore = snapshot
+		m.messages = append(m.messages, message{
+			role:    "system",
+			content: restorePromptText(snapshot),
+		})
+	}
+	if draft != "" {
+		m.textarea.SetValue(m.pastes.paste(draft))
+	}
 	m.updateViewport()
 
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx), tea.WithReportFocus(), tea.WithMouseCellMotion())
+	m.ctrl.setProgram(p)
+	ag.SetBudgetPrompt(newInlineBudgetPrompt(p))
+	ag.SetToolObserver(auditObserver(store, newPanelObserver(p)))
+	announceUpdate(ctx, p, cfg)
 
 	// Update tool registry to use inline permission checker and command executor
 	// This replaces the default ChatPermissionChecker with one integrated into the UI
-	toolRegistry.SetPermissionChecker(NewInlineChatPermissionChecker(p))
+	toolRegistry.SetPermissionChecker(NewInlineChatPermissionChecker(p, toolRegistry.PermissionConfig()))
 
 	// Set inline command executor for run_command tool
 	// This streams command output to the UI instead of using a separate window
-	inlineExecutor := NewInlineCommandExecutor(p)
-	for _, tool := range toolRegistry.All() {
-		if tool.Name() == "run_command" {
-			if pt, ok := tool.(*tools.ProtectedTool); ok {
-				if bashTool, ok := pt.UnwrapTool().(*tools.BashTool); ok {
-					bashTool.SetExecutor(inlineExecutor)
-				}
-			}
-			break
-		}
-	}
+	setCommandExecutor(toolRegistry, NewInlineCommandExecutor(p))
+	toolRegistry.SetUserAsker(&inlineUserAsker{program: p})
+	// Stream answers from ask_ tools into live blocks too
+	toolRegistry.SetModelProgress(newInlineModelProgress(p))
 
 	// Set up logger status updater to send status messages to the TUI (non-blocking)
 	logger.SetStatusUpdater(func(msg string) {
@@ -234,15 +303,152 @@ func RunChat(ctx context.Context, client *ollama.Client, cfg *config.Config, too
 		}()
 	})
 
-	_, err = p.Run()
-	return err
+	// Persist the conversation if anything outside the bubbletea loop panics
+	defer func() {
+		if r := recover(); r != nil {
+			saver.save()
+			panic(r)
+		}
+	}()
+
+	finalModel, err := p.Run()
+
+	// Benchmarks started with /benchmark are owned by the chat; stop them so
+	// partial results are flushed before the caller tears down the rest
+	if fm, ok := finalModel.(chatModel); ok && fm.bgBenchmark != nil && fm.bgBenchmark != bgBenchmark {
+		fm.bgBenchmark.Stop()
+	}
+
+	fm, ok := finalModel.(chatModel)
+	if ok {
+		saver.setDraft(fm.draft())
+	}
+	if err != nil {
+		// Panics inside the program and SIGTERM (context cancellation) end up here
+		logger.Log("RunChat: program exited with error, saving recovery file: %v", err)
+		saver.save()
+		return err
+	}
+
+	// Clean exit: the recovery file is no longer needed, unless the user
+	// quit without answering the restore prompt or left a draft to come back to
+	if ok && fm.pendingRestore == nil && fm.draft() != "" {
+		saver.save()
+	} else if !ok || fm.pendingRestore == nil {
+		if err := session.ClearRecovery(); err != nil {
+			logger.Log("RunChat: failed to clear recovery file: %v", err)
+		}
+	}
+	return nil
+}
+
+// newChatAgent creates the agent for a chat session with the configured
+// disabled tools and default system prompt
+func newChatAgent(client *ollama.Client, cfg *config.Config, toolRegistry *tools.Registry, model string) *agent.Agent {
+	ag := agent.New(client, toolRegistry, cfg, model)
+
+	// Set disabled tools from config
+	ag.SetDisabledTools(cfg.DisabledTools)
+	ag.SetNotes(notes.OpenWorkingDir().Prompt())
+	ag.SetLanguageGuidance(agent.LanguageGuidance(cfg.LanguageGuidance, workspaceDirs(toolRegistry)...))
+
+	// Add system prompt
+	if sysPrompt, ok := cfg.SystemPrompts["default"]; ok {
+		ag.AddSystemPrompt(sysPrompt)
+	} else {
+		ag.AddSystemPrompt("")
+	}
+	if live := toolRegistry.PermissionConfig(); live != nil && len(live.ProjectRoots()) > 0 {
+		ag.AddContext(workspaceNote(live.ProjectRoots()))
+	}
+	return ag
+}
+
+// watchWorkspace tells ag about files changed outside the chat, in the
+// working directory and every project root
+func watchWorkspace(ag *agent.Agent, cfg *config.Config, toolRegistry *tools.Registry) {
+	if !cfg.FileWatch.Enabled {
+		return
+	}
+	ag.SetFileWatcher(agent.NewFileWatcher(func() []string {
+		dirs := []string{"."}
+		if live := toolRegistry.PermissionConfig(); live != nil {
+			for _, root := range live.ProjectRoots() {
+				dirs = append(dirs, root.Path)
+			}
+		}
+		return dirs
+	}, cfg.FileWatch))
+}
+
+// newCommandRegistry registers the slash commands available in chat
+func newCommandRegistry(client *ollama.Client, cfg *config.Config, toolRegistry *tools.Registry) *CommandRegistry {
+	cmdRegistry := NewCommandRegistry()
+	cmdRegistry.Register(NewHelpCommand(cmdRegistry))
+	cmdRegistry.Register(NewListModelsCommand(client, cfg))
+	cmdRegistry.Register(NewSwitchModelCommand(client, cfg, toolRegistry))
+	cmdRegistry.Register(NewListPromptsCommand(cfg))
+	cmdRegistry.Register(NewResetCommand())
+	cmdRegistry.Register(NewBenchmarkCommand(client, cfg))
+	cmdRegistry.Register(NewConfigCommand())
+	cmdRegistry.Register(NewToolsCommand(toolRegistry))
+	cmdRegistry.Register(NewAddToolCommand(client, cfg, toolRegistry))
+	cmdRegistry.Register(NewAddAllToolsCommand(client, cfg, toolRegistry))
+	cmdRegistry.Register(NewRemoveToolCommand(cfg, toolRegistry))
+	cmdRegistry.Register(NewEnableToolCommand(cfg, toolRegistry))
+	cmdRegistry.Register(NewDisableToolCommand(cfg, toolRegistry))
+	cmdRegistry.Register(NewListDisabledToolsCommand(cfg))
+	cmdRegistry.Register(NewTestToolCommand(toolRegistry))
+	cmdRegistry.Register(NewClearQueueCommand())
+	cmdRegistry.Register(NewQueueCommand())
+	cmdRegistry.Register(NewExpandCommand())
+	cmdRegistry.Register(NewPermissionsCommand(cfg, toolRegistry))
+	cmdRegistry.Register(NewWorkspaceCommand(toolRegistry))
+	cmdRegistry.Register(NewTrashCommand(toolRegistry.Trash()))
+	cmdRegistry.Register(NewUpdateCommand(cfg))
+	cmdRegistry.Register(NewSearchHistoryCommand())
+	cmdRegistry.Register(NewSessionsCommand(cfg))
+	cmdRegistry.Register(NewRenameCommand())
+	cmdRegistry.Register(NewMemoriesCommand(notes.OpenWorkingDir()))
+	cmdRegistry.Register(NewPinCommand())
+	cmdRegistry.Register(NewPinsCommand())
+	cmdRegistry.Register(NewTodosCommand(toolRegistry.Todos()))
+	cmdRegistry.Register(NewImageCommand())
+	cmdRegistry.Register(NewInterviewCommand(client))
+	cmdRegistry.Register(NewReviewCommand(client, cfg))
+	cmdRegistry.Register(NewDemoCommand(toolRegistry))
+	cmdRegistry.Register(NewWhyCommand())
+	cmdRegistry.Register(NewDebugCommand())
+	cmdRegistry.Register(NewPreviewCommand())
+	cmdRegistry.Register(NewStatsCommand())
+	return cmdRegistry
+}
+
+// setCommandExecutor changes how run_command runs its commands
+func setCommandExecutor(toolRegistry *tools.Registry, executor tools.CommandExecutor) {
+	for _, tool := range toolRegistry.All() {
+		if tool.Name() == "run_command" {
+			if pt, ok := tool.(*tools.ProtectedTool); ok {
+				if bashTool, ok := pt.UnwrapTool().(*tools.BashTool); ok {
+					bashTool.SetExecutor(executor)
+				}
+			}
+			break
+		}
+	}
 }
 
 func (m chatModel) Init() tea.Cmd {
-	return tea.Batch(
+	cmds := []tea.Cmd{
 		textarea.Blink,
 		m.spinner.Tick,
-	)
+		autosaveTick(),
+	}
+	// Startup actions wait for the restore prompt to be answered
+	if len(m.startup) > 0 && m.pendingRestore == nil {
+		cmds = append(cmds, func() tea.Msg { return startupMsg{} })
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -251,6 +457,116 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// Answer the restore prompt before anything else
+		if m.pendingRestore != nil {
+			switch msg.String() {
+			case "y", "Y":
+				snapshot := m.pendingRestore
+				m.pendingRestore = nil
+				m.agent.RestoreMessages(snapshot.Messages)
+				m.autosave.newSession(snapshot.SessionID)
+				m.messages = append(m.messages, transcriptFromMessages(snapshot.Messages)...)
+				m.messages = append(m.messages, message{
+					role:    "system",
+					content: i18n.T("chat.restored", len(snapshot.Messages)),
+				})
+				m.restoreDraft(snapshot.Draft)
+				m.updateViewport()
+				return m, m.startupCmd()
+			case "n", "N", "esc":
+				m.pendingRestore = nil
+				if err := session.ClearRecovery(); err != nil {
+					logger.Log("Failed to clear recovery file: %v", err)
+				}
+				m.messages = append(m.messages, message{
+					role:    "system",
+					content: i18n.T("chat.discarded"),
+				})
+				m.updateViewport()
+				return m, m.startupCmd()
+			}
+			// Ignore other keys until the prompt is answered
+			return m, nil
+		}
+
+		// Turn budget exceeded - continue or stop the turn
+		if m.pendingBudget != nil {
+			switch msg.String() {
+			case "y", "Y":
+				m.answerBudget(true)
+			case "n", "N", "esc":
+				m.answerBudget(false)
+			}
+			return m, nil
+		}
+
+		// Large paste - send it or go back to editing
+		if m.pendingPaste > 0 {
+			switch msg.String() {
+			case "y", "Y":
+				m.pendingPaste = 0
+				m.pasteConfirmed = true
+				return m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+			case "n", "N", "esc":
+				m.pendingPaste = 0
+			}
+			return m, nil
+		}
+
+		// Clarifying question - Enter answers with the input or the selected
+		// option, arrows move the selection, Esc skips it
+		if m.pendingQuestion != nil {
+			switch msg.Type {
+			case tea.KeyEnter:
+				answer, _, _ := m.pastes.expand(m.textarea.Value())
+				m.textarea.Reset()
+				if strings.TrimSpace(answer) == "" && len(m.pendingQuestion.options) > 0 {
+					answer = m.pendingQuestion.options[m.pendingQuestion.selected]
+				}
+				m.answerQuestion(answer)
+				return m, nil
+			case tea.KeyUp:
+				if m.pendingQuestion.selected > 0 {
+					m.pendingQuestion.selected--
+				}
+				return m, nil
+			case tea.KeyDown:
+				if m.pendingQuestion.selected < len(m.pendingQuestion.options)-1 {
+					m.pendingQuestion.selected++
+				}
+				return m, nil
+			case tea.KeyEsc:
+				m.answerQuestion("")
+				return m, nil
+			case tea.KeyCtrlC:
+				return m, tea.Quit
+			}
+			m.textarea, cmd = m.textarea.Update(msg)
+			return m, cmd
+		}
+
+		// Path outside the workspace - allow once, add root, or deny
+		if m.permissionMode && m.pendingPermission != nil && m.pendingPermission.outside {
+			var resp permissionResponse
+			switch msg.String() {
+			case "y", "Y":
+				resp = permissionResponse{approved: true}
+			case "r", "R":
+				resp = permissionResponse{approved: true, addRoot: true}
+			case "s", "S":
+				resp = permissionResponse{approved: true, addRoot: true, session: true}
+			case "n", "N", "esc":
+				resp = permissionResponse{approved: false}
+			default:
+				return m, nil
+			}
+			m.pendingPermission.response <- resp
+			close(m.pendingPermission.response)
+			m.pendingPermission = nil
+			m.permissionMode = false
+			return m, nil
+		}
+
 		// Handle permission mode first - y/n/c/p/a input
 		if m.permissionMode && m.pendingPermission != nil {
 			switch msg.String() {
@@ -287,6 +603,13 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.pendingPermission = nil
 				m.permissionMode = false
 				return m, nil
+			case "s", "S":
+				// Allow the same again until exit, without saving
+				m.pendingPermission.response <- permissionResponse{approved: true, session: true}
+				close(m.pendingPermission.response)
+				m.pendingPermission = nil
+				m.permissionMode = false
+				return m, nil
 			case "esc":
 				// Deny on escape
 				m.pendingPermission.response <- permissionResponse{approved: false}
@@ -299,6 +622,55 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		if m.modelPicker != nil {
+			m.updateModelPicker(msg)
+			return m, nil
+		}
+
+		// Bracketed paste arrives as one message; long ones become a placeholder
+		if msg.Paste && m.pastes != nil && !m.searchMode && !m.queueMode {
+			m.textarea.InsertString(m.pastes.paste(string(msg.Runes)))
+			return m, nil
+		}
+
+		if msg.String() == "ctrl+q" && !m.searchMode {
+			queued, _ := m.ctrl.peekQueue()
+			m.queueMode = !m.queueMode && queued > 0
+			m.queueCursor = 0
+			return m, nil
+		}
+		if m.queueMode {
+			return m.updateQueueMode(msg)
+		}
+
+		// Split view: toggle, resize and move focus between the panes
+		switch msg.String() {
+		case "ctrl+o":
+			m.toggleSplit()
+			return m, nil
+		case "ctrl+left":
+			if m.panel.open {
+				m.resizePanel(panelShareStep)
+				return m, nil
+			}
+		case "ctrl+right":
+			if m.panel.open {
+				m.resizePanel(-panelShareStep)
+				return m, nil
+			}
+		case "tab":
+			if m.panel.open {
+				m.focusPanel(!m.panel.focused)
+				return m, nil
+			}
+		case "up", "down", "pgup", "pgdown", "home", "end":
+			if m.panel.open && m.panel.focused {
+				var cmd tea.Cmd
+				m.panel.viewport, cmd = m.panel.viewport.Update(msg)
+				return m, cmd
+			}
+		}
+
 		switch msg.Type {
 		case tea.KeyCtrlC:
 			// Exit search mode on Ctrl-C if in search mode
@@ -320,37 +692,31 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 
-			// If currently processing and there's a queued message or typed input, interrupt and send
-			if m.waiting && m.currentTask != nil {
-				// Cancel current task
-				m.currentTask()
-				m.currentTask = nil
-
-				// Get first queued message or current input
-				var interruptMsg string
-				if len(m.messageQueue) > 0 {
-					interruptMsg = m.messageQueue[0]
-					m.messageQueue = m.messageQueue[1:]
-				} else {
-					interruptMsg = m.textarea.Value()
-					m.textarea.Reset()
-				}
+			// If currently processing, interrupt and send the next queued
+			// message; a half-typed draft stays in the input
+			if m.waiting && m.ctrl.running() {
+				// Cancel current task; its late response is dropped
+				m.ctrl.interrupt()
+
+				interruptMsg, ok := m.ctrl.dequeue()
+				m.clampQueueCursor()
 
-				if interruptMsg != "" {
+				if ok && interruptMsg != "" {
 					// Add to history
 					if len(m.history) == 0 || m.history[len(m.history)-1] != interruptMsg {
 						m.history = append(m.history, interruptMsg)
 					}
+					interruptMsg, display, _ := m.pastes.expand(interruptMsg)
 					m.historyIndex = -1
 
 					// Add interrupted notice
 					m.messages = append(m.messages, message{
 						role:    "system",
-						content: "⚠️ Previous task interrupted",
+						content: i18n.T("chat.interrupted"),
 					})
 
 					// Send new message
-					m.messages = append(m.messages, message{role: "user", content: interruptMsg})
+					m.messages = append(m.messages, message{role: "user", content: interruptMsg, display: display})
 					m.updateViewport()
 
 					return m, tea.Batch(
@@ -363,7 +729,7 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.waiting = false
 				m.messages = append(m.messages, message{
 					role:    "system",
-					content: "⚠️ Task cancelled",
+					content: i18n.T("chat.cancelled"),
 				})
 				m.updateViewport()
 				return m, nil
@@ -421,48 +787,61 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			if m.textarea.Value() != "" {
 				userMsg := m.textarea.Value()
+				// Ask before sending a paste that big by accident
+				if pasted, confirm := m.pastes.needsConfirm(userMsg); confirm && !m.pasteConfirmed {
+					m.pendingPaste = pasted
+					return m, nil
+				}
+				m.pasteConfirmed = false
 				m.textarea.Reset()
+				typed := userMsg
+				userMsg, display, _ := m.pastes.expand(userMsg)
 
 				// Check if it's a command - execute immediately even if waiting
+				pickerClosed := m.modelPicker == nil
 				if result, isCmd, err := m.commands.Execute(m.ctx, userMsg, &m); isCmd {
 					// Add to history
-					if len(m.history) == 0 || m.history[len(m.history)-1] != userMsg {
-						m.history = append(m.history, userMsg)
+					if len(m.history) == 0 || m.history[len(m.history)-1] != typed {
+						m.history = append(m.history, typed)
 					}
 					m.historyIndex = -1
 
 					if err != nil {
 						m.messages = append(m.messages, message{
 							role:    "error",
-							content: fmt.Sprintf("Command error: %v", err),
+							content: i18n.T("chat.command_error", err),
 						})
-					} else {
+					} else if result != "" {
 						m.messages = append(m.messages, message{
 							role:    "system",
 							content: result,
 						})
 					}
 					m.updateViewport()
+					if pickerClosed && m.modelPicker != nil {
+						// Fetch badges for models not benchmarked yet
+						return m, m.modelPicker.picker.Init()
+					}
 					return m, nil
 				}
 
 				// If currently waiting, queue the message instead of sending
 				if m.waiting {
-					m.messageQueue = append(m.messageQueue, userMsg)
+					m.ctrl.enqueue(userMsg)
 					m.updateViewport() // Update to show queued message indicator
 					return m, nil
 				}
 
 				// Add to history (avoid duplicates of last entry)
-				if len(m.history) == 0 || m.history[len(m.history)-1] != userMsg {
-					m.history = append(m.history, userMsg)
+				if len(m.history) == 0 || m.history[len(m.history)-1] != typed {
+					m.history = append(m.history, typed)
 				}
 				m.historyIndex = -1
 
 				// Regular chat message
-				m.messages = append(m.messages, message{role: "user", content: userMsg})
+				m.messages = append(m.messages, message{role: "user", content: userMsg, display: display})
 				m.waiting = true
-				m.processingStatus = "Thinking..."
+				m.processingStatus = i18n.T("status.thinking")
 				m.updateViewport()
 				return m, tea.Batch(
 					m.spinner.Tick,
@@ -505,10 +884,8 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		m.viewport.Width = msg.Width - 4
-		m.viewport.Height = msg.Height - 8
 		m.textarea.SetWidth(msg.Width - 4)
-		m.updateViewport()
+		m.layout()
 
 	case spinner.TickMsg:
 		if m.waiting {
@@ -516,14 +893,135 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, cmd)
 		}
 
+	case updateAvailableMsg:
+		m.messages = append(m.messages, message{
+			role:    "system",
+			content: i18n.T("update.available", msg.release.Tag, version.Get().Version),
+		})
+		m.updateViewport()
+		return m, nil
+
+	case sendPromptMsg:
+		if m.waiting {
+			m.ctrl.enqueue(msg.text)
+			m.updateViewport()
+			return m, nil
+		}
+		m.messages = append(m.messages, message{role: "user", content: msg.text, display: msg.display})
+		m.waiting = true
+		m.processingStatus = i18n.T("status.thinking")
+		m.updateViewport()
+		return m, tea.Batch(
+			m.spinner.Tick,
+			m.chat(msg.text),
+		)
+
+	case startupMsg:
+		return m, m.startupCmd()
+
+	case startupCommandMsg:
+		pickerClosed := m.modelPicker == nil
+		result, _, err := m.commands.Execute(m.ctx, msg.line, &m)
+		if err != nil {
+			m.messages = append(m.messages, message{role: "error", content: i18n.T("chat.startup_error", msg.line, err)})
+		} else {
+			m.messages = append(m.messages, message{role: "system", content: strings.TrimSpace("🚀 `" + msg.line + "`\n\n" + result)})
+		}
+		m.updateViewport()
+		if pickerClosed && m.modelPicker != nil {
+			return m, m.modelPicker.picker.Init()
+		}
+		return m, nil
+
+	case startupOutputMsg:
+		if msg.err != nil {
+			m.messages = append(m.messages, message{role: "error", content: i18n.T("chat.startup_error", "!"+msg.command, fmt.Errorf("%w\n%s", msg.err, truncateRunes(msg.output, startupOutputLimit)))})
+		} else {
+			m.messages = append(m.messages, message{role: "system", content: startupOutput(msg)})
+		}
+		m.updateViewport()
+		return m, nil
+
+	case interviewDoneMsg:
+		if msg.result != nil && len(msg.result.Turns) > 0 {
+			m.messages = append(m.messages, message{role: "system", content: formatInterview(msg.task, msg.result)})
+			if msg.result.Summary != "" {
+				// Let the user follow up on the outcome
+				m.agent.AddContext(fmt.Sprintf("The user ran an interview on %q. The moderator's summary:\n\n%s", msg.task, msg.result.Summary))
+			}
+		}
+		if msg.err != nil {
+			m.messages = append(m.messages, message{role: "error", content: fmt.Sprintf("Interview stopped: %v", msg.err)})
+		}
+		m.updateViewport()
+		return m, nil
+
+	case reviewDoneMsg:
+		if msg.report != nil && (msg.err == nil || len(msg.report.Findings) > 0) {
+			report := msg.report.Markdown()
+			m.messages = append(m.messages, message{role: "system", content: report})
+			// The user will often ask to fix what was found
+			m.agent.AddContext("The user ran a code review. Its report:\n\n" + report)
+		}
+		if msg.err != nil {
+			m.messages = append(m.messages, message{role: "error", content: fmt.Sprintf("Review stopped: %v", msg.err)})
+		}
+		m.updateViewport()
+		return m, nil
+
+	case modelDetailsMsg:
+		if m.modelPicker != nil {
+			next, _ := m.modelPicker.picker.Update(msg)
+			m.modelPicker.picker = next.(modelPickerModel)
+		}
+		return m, nil
+
+	case showImageMsg:
+		return m, m.viewImage(msg.path)
+
+	case imageShownMsg:
+		if msg.err != nil {
+			m.messages = append(m.messages, message{role: "error", content: i18n.T("chat.command_error", msg.err)})
+			m.updateViewport()
+		}
+		return m, nil
+
 	case statusMsg:
 		m.statusMessage = msg.message
 
+	case autosaveTickMsg:
+		if m.pendingRestore == nil {
+			m.autosave.setDraft(m.draft())
+			m.autosave.save()
+		}
+		return m, autosaveTick()
+
+	case tea.FocusMsg:
+		m.attention.setFocus(true)
+		return m, nil
+
+	case tea.BlurMsg:
+		m.attention.setFocus(false)
+		return m, nil
+
+	case budgetRequestMsg:
+		m.pendingBudget = msg.request
+		m.processingStatus = i18n.T("status.budget")
+		m.attention.notify("Llemecode needs you", "Turn budget exceeded: "+msg.request.reason)
+		return m, nil
+
+	case questionRequestMsg:
+		m.pendingQuestion = msg.request
+		m.processingStatus = i18n.T("status.question")
+		m.attention.notify("Llemecode has a question", msg.request.question)
+		return m, nil
+
 	case permissionRequestMsg:
 		// Store the permission request and enter permission mode
 		m.pendingPermission = msg.request
 		m.permissionMode = true
-		m.processingStatus = "Awaiting permission..."
+		m.processingStatus = i18n.T("status.permission")
+		m.attention.notify("Llemecode needs approval", "Approve "+msg.request.toolName+"?")
 		return m, nil
 
 	case commandStartMsg:
@@ -533,18 +1031,27 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			command: msg.command,
 			output:  []string{},
 			running: true,
+			model:   msg.model,
 		}
 		m.activeCommands = append(m.activeCommands, cmd)
+		m.panel.command = cmd
+		m.updatePanel()
 		return m, nil
 
 	case commandOutputMsg:
 		// Add output line to the appropriate command
 		for _, cmd := range m.activeCommands {
 			if cmd.id == msg.id {
-				cmd.output = append(cmd.output, msg.line)
+				if msg.partial {
+					cmd.partial = msg.line
+				} else {
+					cmd.output = append(cmd.output, msg.line)
+					cmd.partial = ""
+				}
 				break
 			}
 		}
+		m.updatePanel()
 		return m, nil
 
 	case commandEndMsg:
@@ -563,30 +1070,53 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 		}
+		m.updatePanel()
+		return m, nil
+
+	case panelMsg:
+		m.showInPanel(msg)
+		return m, nil
+
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
+
+	case streamMsg:
+		if m.waiting {
+			m.updateViewport()
+		}
 		return m, nil
 
 	case responseMsg:
+		timing := m.ctrl.timing()
+		if !m.ctrl.finish(msg.taskID) {
+			// Interrupted or superseded task finishing late
+			logger.Status("Dropping response from stale task %d", msg.taskID)
+			return m, nil
+		}
 		logger.Status("Received response: err=%v, tool_calls=%d, content_len=%d", msg.err, len(msg.toolCalls), len(msg.content))
 		m.waiting = false
 		m.processingStatus = ""
+		m.pendingQuestion = nil // Left over if the turn failed while asking
+		if queued, _ := m.ctrl.peekQueue(); queued == 0 {
+			// Only once the whole queue is done
+			m.attention.taskFinished(timing.elapsed, msg.err != nil)
+		}
 
 		if msg.err != nil {
 			logger.Status("Processing error: %v", msg.err)
 			m.err = msg.err
 			m.messages = append(m.messages, message{
 				role:    "error",
-				content: fmt.Sprintf("Error: %v", msg.err),
+				content: i18n.T("chat.error", msg.err),
 			})
 		} else {
 			// Add tool calls if any
 			logger.Status("Adding %d tool calls to messages", len(msg.toolCalls))
 			for idx, tc := range msg.toolCalls {
-				formatted := agent.FormatToolCall(tc)
-				logger.Status("Tool call %d formatted, length: %d", idx, len(formatted))
-				m.messages = append(m.messages, message{
-					role:    "tool",
-					content: formatted,
-				})
+				toolMsg := m.newToolMessage(tc)
+				logger.Status("Tool call %d formatted, length: %d", idx, len(toolMsg.content))
+				m.messages = append(m.messages, toolMsg)
+				m.addImages(producedImages(tc))
 			}
 
 			// Add assistant response
@@ -595,18 +1125,21 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.messages = append(m.messages, message{
 					role:    "assistant",
 					content: msg.content,
+					meta:    msg.meta,
 				})
 			} else {
 				logger.Status("No assistant content to add")
 			}
 		}
+		m.messages = append(m.messages, message{role: "timing", content: formatTurnTiming(timing)})
 		logger.Status("Updating viewport, total messages: %d", len(m.messages))
 		m.updateViewport()
+		m.autosave.setDraft(m.draft())
+		m.autosave.save()
 
 		// If there are queued messages, send the first one
-		if len(m.messageQueue) > 0 {
-			queuedMsg := m.messageQueue[0]
-			m.messageQueue = m.messageQueue[1:]
+		if queuedMsg, ok := m.ctrl.dequeue(); ok {
+			m.clampQueueCursor()
 
 			// Add to history
 			if len(m.history) == 0 || m.history[len(m.history)-1] != queuedMsg {
@@ -616,7 +1149,7 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Send the queued message
 			m.messages = append(m.messages, message{role: "user", content: queuedMsg})
 			m.waiting = true
-			m.processingStatus = "Thinking..."
+			m.processingStatus = i18n.T("status.thinking")
 			m.updateViewport()
 
 			return m, tea.Batch(
@@ -636,18 +1169,44 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+func (m chatModel) headerView() string {
+	return headerStyle.Render(i18n.T("chat.header",
+		m.agent.Model(), m.agent.ToolCallFormat(), m.agent.Provider()))
+}
+
 func (m chatModel) View() string {
 	var s strings.Builder
 
 	// Header without memory indicator (moved to bottom)
-	header := headerStyle.Render(fmt.Sprintf("💬 Llemecode Chat - Model: %s", m.agent.GetMessages()[0].Role))
-	s.WriteString(header + "\n\n")
+	s.WriteString(m.headerView() + "\n\n")
 
-	// Viewport with messages
-	s.WriteString(m.viewport.View() + "\n\n")
+	// Viewport with messages, next to the side panel in split view
+	if m.panel.open {
+		s.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, m.viewport.View(), " ", m.panelView()) + "\n\n")
+	} else {
+		s.WriteString(m.viewport.View() + "\n\n")
+	}
+
+	// Outside-workspace prompt (if active)
+	if m.permissionMode && m.pendingPermission != nil && m.pendingPermission.outside {
+		outsideBox := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("214")).
+			Padding(1, 2).
+			Width(m.width - 8)
 
-	// Permission prompt (if active)
-	if m.permissionMode && m.pendingPermission != nil {
+		outsideContent := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214")).
+			Bold(true).
+			Render(i18n.T("perm.outside_title") + "\n\n")
+		outsideContent += i18n.T("perm.tool", m.pendingPermission.toolName) + "\n"
+		outsideContent += i18n.T("perm.path", m.pendingPermission.targetPath) + "\n\n"
+		outsideContent += lipgloss.NewStyle().
+			Foreground(lipgloss.Color("111")).
+			Render(i18n.T("perm.outside_options"))
+
+		s.WriteString(outsideBox.Render(outsideContent) + "\n\n")
+	} else if m.permissionMode && m.pendingPermission != nil {
 		permBox := lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
 			BorderForeground(lipgloss.Color("214")).
@@ -659,63 +1218,140 @@ func (m chatModel) View() string {
 
 		switch m.pendingPermission.level {
 		case tools.PermissionExecute:
-			levelStr = "⚠️  EXECUTE"
+			levelStr = i18n.T("perm.level.execute")
 			levelColor = "196"
 		case tools.PermissionWrite:
-			levelStr = "⚠️  WRITE"
+			levelStr = i18n.T("perm.level.write")
 			levelColor = "214"
 		case tools.PermissionNetwork:
-			levelStr = "🌐 NETWORK"
+			levelStr = i18n.T("perm.level.network")
 			levelColor = "214"
 		case tools.PermissionRead:
-			levelStr = "📖 READ"
+			levelStr = i18n.T("perm.level.read")
 			levelColor = "111"
 		}
 
 		permContent := lipgloss.NewStyle().
 			Foreground(lipgloss.Color(levelColor)).
 			Bold(true).
-			Render(fmt.Sprintf("%s PERMISSION REQUIRED\n\n", levelStr))
+			Render(i18n.T("perm.title", levelStr) + "\n\n")
 
-		permContent += fmt.Sprintf("Tool: %s\n", m.pendingPermission.toolName)
-		permContent += fmt.Sprintf("Details: %s\n", m.pendingPermission.details)
+		permContent += i18n.T("perm.tool", m.pendingPermission.toolName) + "\n"
+		permContent += i18n.T("perm.details", m.pendingPermission.details) + "\n"
 
 		// Show target path if available
 		if m.pendingPermission.targetPath != "" {
-			permContent += fmt.Sprintf("Target: %s\n", m.pendingPermission.targetPath)
+			permContent += i18n.T("perm.target", m.pendingPermission.targetPath) + "\n"
 		}
 
 		permContent += "\n"
 		permContent += lipgloss.NewStyle().
 			Foreground(lipgloss.Color("42")).
-			Render("Allow this operation?\n")
+			Render(i18n.T("perm.question") + "\n")
 
 		// Different options based on tool type
 		if m.pendingPermission.toolName == "run_command" {
 			if m.pendingPermission.targetPath != "" {
 				permContent += lipgloss.NewStyle().
 					Foreground(lipgloss.Color("111")).
-					Render("  y: yes (once)  n: no  c: always allow command  p: always on this path")
+					Render(i18n.T("perm.options.command_path"))
 			} else {
 				permContent += lipgloss.NewStyle().
 					Foreground(lipgloss.Color("111")).
-					Render("  y: yes (once)  n: no  a: always allow  c: always allow command")
+					Render(i18n.T("perm.options.command"))
 			}
 		} else if m.pendingPermission.targetPath != "" {
 			// For file tools with path
 			permContent += lipgloss.NewStyle().
 				Foreground(lipgloss.Color("111")).
-				Render("  y: yes (once)  n: no  a: always allow  p: always on this path")
+				Render(i18n.T("perm.options.path"))
 		} else {
 			// Tools without path - only offer "a" for always
 			permContent += lipgloss.NewStyle().
 				Foreground(lipgloss.Color("111")).
-				Render("  y: yes (once)  n: no  a: always allow")
+				Render(i18n.T("perm.options.tool"))
 		}
 
 		s.WriteString(permBox.Render(permContent) + "\n\n")
 	}
 
+	if m.modelPicker != nil {
+		s.WriteString(m.modelPickerView() + "\n\n")
+	}
+
+	// Turn budget prompt (if active)
+	if m.pendingBudget != nil {
+		budgetBox := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("214")).
+			Padding(1, 2).
+			Width(m.width - 8)
+
+		budgetContent := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214")).
+			Bold(true).
+			Render(i18n.T("budget.title") + "\n\n")
+		budgetContent += i18n.T("budget.reason", m.pendingBudget.reason) + "\n\n"
+		budgetContent += lipgloss.NewStyle().
+			Foreground(lipgloss.Color("111")).
+			Render(i18n.T("budget.options"))
+
+		s.WriteString(budgetBox.Render(budgetContent) + "\n\n")
+	}
+
+	// Large paste confirmation (if active)
+	if m.pendingPaste > 0 {
+		pasteBox := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("214")).
+			Padding(1, 2).
+			Width(m.width - 8)
+
+		pasteContent := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214")).
+			Bold(true).
+			Render(i18n.T("paste.title") + "\n\n")
+		pasteContent += i18n.T("paste.confirm", (m.pendingPaste+1023)/1024) + "\n\n"
+		pasteContent += lipgloss.NewStyle().
+			Foreground(lipgloss.Color("111")).
+			Render(i18n.T("paste.options"))
+
+		s.WriteString(pasteBox.Render(pasteContent) + "\n\n")
+	}
+
+	// Clarifying question (if active)
+	if m.pendingQuestion != nil {
+		questionBox := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("81")).
+			Padding(1, 2).
+			Width(m.width - 8)
+
+		questionContent := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("81")).
+			Bold(true).
+			Render(i18n.T("question.title") + "\n\n")
+		questionContent += m.pendingQuestion.question + "\n"
+		hint := i18n.T("question.options")
+		if len(m.pendingQuestion.options) > 0 {
+			hint = i18n.T("question.choose")
+			questionContent += "\n"
+		}
+		selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("81")).Bold(true)
+		for i, option := range m.pendingQuestion.options {
+			if i == m.pendingQuestion.selected {
+				questionContent += selectedStyle.Render(fmt.Sprintf("› %d. %s", i+1, option)) + "\n"
+			} else {
+				questionContent += fmt.Sprintf("  %d. %s\n", i+1, option)
+			}
+		}
+		questionContent += "\n" + lipgloss.NewStyle().
+			Foreground(lipgloss.Color("111")).
+			Render(hint)
+
+		s.WriteString(questionBox.Render(questionContent) + "\n\n")
+	}
+
 	// Command execution overlay (show running/recent commands)
 	if len(m.activeCommands) > 0 {
 		for _, cmd := range m.activeCommands {
@@ -724,16 +1360,20 @@ func (m chatModel) View() string {
 				BorderForeground(lipgloss.Color("205")).
 				Padding(0, 1).
 				Width(m.width - 8)
+			if cmd.model != "" {
+				s.WriteString(cmdBox.Render(delegationView(cmd)) + "\n\n")
+				continue
+			}
 
 			// Command header
-			status := "⚡ Running"
+			status := i18n.T("command.running")
 			statusColor := "214"
 			if !cmd.running {
 				if cmd.exitCode == 0 {
-					status = "✓ Completed"
+					status = i18n.T("command.completed")
 					statusColor = "42"
 				} else {
-					status = "✗ Failed"
+					status = i18n.T("command.failed")
 					statusColor = "196"
 				}
 			}
@@ -758,9 +1398,9 @@ func (m chatModel) View() string {
 			exitInfo := ""
 			if !cmd.running {
 				if cmd.err != nil {
-					exitInfo = fmt.Sprintf("\nExit code: %d (error: %v)", cmd.exitCode, cmd.err)
+					exitInfo = "\n" + i18n.T("command.exit_error", cmd.exitCode, cmd.err)
 				} else {
-					exitInfo = fmt.Sprintf("\nExit code: %d", cmd.exitCode)
+					exitInfo = "\n" + i18n.T("command.exit", cmd.exitCode)
 				}
 			}
 
@@ -772,31 +1412,27 @@ func (m chatModel) View() string {
 	if m.waiting {
 		waitMsg := m.processingStatus
 		if waitMsg == "" {
-			waitMsg = "Thinking..."
+			waitMsg = i18n.T("status.thinking")
 		}
 		if m.activeBackgroundTask != "" {
-			waitMsg = fmt.Sprintf("Running: %s...", m.activeBackgroundTask)
+			waitMsg = i18n.T("status.running", m.activeBackgroundTask)
 		}
-		statusLine := m.spinner.View() + " " + waitMsg
+		statusLine := m.spinner.View() + " " + waitMsg + " " + formatWaitTiming(m.ctrl.timing())
 
 		// Show queued messages indicator
-		if len(m.messageQueue) > 0 {
-			queueInfo := fmt.Sprintf(" | ⏸ Queue: %d msg", len(m.messageQueue))
-			if len(m.messageQueue) > 1 {
-				queueInfo += "s"
-			}
-			// Show preview of first queued message
-			queuePreview := m.messageQueue[0]
-			if len(queuePreview) > 30 {
-				queuePreview = queuePreview[:27] + "..."
+		if queued, _ := m.ctrl.peekQueue(); queued > 0 {
+			queueInfo := i18n.T("status.queue", queued)
+			if queued > 1 {
+				queueInfo = i18n.T("status.queue_many", queued)
 			}
-			queueInfo += fmt.Sprintf(" (%s) | Esc: interrupt", queuePreview)
+			queueInfo += i18n.T("status.queue_hint")
 
 			statusLine += lipgloss.NewStyle().
 				Foreground(lipgloss.Color("214")).
 				Render(queueInfo)
 		}
 		s.WriteString(statusLine + "\n")
+		s.WriteString(m.queueView())
 	} else if m.err != nil {
 		s.WriteString(errorStyle.Render(fmt.Sprintf("⚠ %v", m.err)) + "\n")
 	} else if m.statusMessage != "" {
@@ -808,7 +1444,7 @@ func (m chatModel) View() string {
 		// Show background task even when not waiting
 		s.WriteString(lipgloss.NewStyle().
 			Foreground(lipgloss.Color("214")).
-			Render(fmt.Sprintf("⚙️  Background: %s", m.activeBackgroundTask)) + "\n")
+			Render(i18n.T("status.background", m.activeBackgroundTask)) + "\n")
 	} else if m.bgBenchmark != nil && !m.benchmarkDone {
 		// Show background benchmark status
 		select {
@@ -816,9 +1452,15 @@ func (m chatModel) View() string {
 			m.benchmarkDone = true
 			s.WriteString(lipgloss.NewStyle().
 				Foreground(lipgloss.Color("42")).
-				Render("📊 ✓ Background benchmarking complete!") + "\n")
+				Render(i18n.T("status.benchmark_done")) + "\n")
 		default:
 			progress := m.bgBenchmark.GetProgress()
+			if state := m.bgBenchmark.State(); state.ModelCount > 0 && state.Model != "" {
+				progress = i18n.T("status.benchmark_progress", benchmark.ProgressBar(state.Fraction(), 10), state.ModelsDone, state.ModelCount, state.Model, state.Step)
+			}
+			if m.bgBenchmark.IsPaused() {
+				progress = i18n.T("status.benchmark_paused")
+			}
 			if progress != "" {
 				s.WriteString(lipgloss.NewStyle().
 					Foreground(lipgloss.Color("241")).
@@ -829,7 +1471,7 @@ func (m chatModel) View() string {
 
 	// Search mode indicator
 	if m.searchMode {
-		searchStatus := fmt.Sprintf("(reverse-search)`%s': ", m.searchQuery)
+		searchStatus := i18n.T("status.search", m.searchQuery)
 		if len(m.searchResults) > 0 {
 			preview := m.history[m.searchResults[m.searchIndex]]
 			if len(preview) > 50 {
@@ -837,7 +1479,7 @@ func (m chatModel) View() string {
 			}
 			searchStatus += preview
 		} else if m.searchQuery != "" {
-			searchStatus += "no matches"
+			searchStatus += i18n.T("status.search_none")
 		}
 		s.WriteString(lipgloss.NewStyle().
 			Foreground(lipgloss.Color("214")).
@@ -851,49 +1493,86 @@ func (m chatModel) View() string {
 	var help string
 	memIndicator := m.getMemoryIndicator()
 
-	if m.searchMode {
+	if m.pendingRestore != nil {
+		help = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241")).
+			Render(i18n.T("help.restore"))
+	} else if m.searchMode {
+		help = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241")).
+			Render(i18n.T("help.search"))
+	} else if m.pendingBudget != nil {
+		help = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241")).
+			Render(i18n.T("help.budget"))
+	} else if m.pendingPaste > 0 {
 		help = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("241")).
-			Render("Ctrl+N: next • Ctrl+P: prev • Enter: use • Esc: cancel")
+			Render(i18n.T("help.paste"))
+	} else if m.pendingQuestion != nil && len(m.pendingQuestion.options) > 0 {
+		help = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241")).
+			Render(i18n.T("help.choose"))
+	} else if m.pendingQuestion != nil {
+		help = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241")).
+			Render(i18n.T("help.question"))
 	} else if m.permissionMode {
 		// Context-aware help based on tool and available options
-		if m.pendingPermission != nil {
+		if m.pendingPermission != nil && m.pendingPermission.outside {
+			help = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("241")).
+				Render(i18n.T("help.outside"))
+		} else if m.pendingPermission != nil {
 			if m.pendingPermission.toolName == "run_command" {
 				if m.pendingPermission.targetPath != "" {
 					help = lipgloss.NewStyle().
 						Foreground(lipgloss.Color("241")).
-						Render("y: once • n: deny • c: always this cmd • p: always this path • Esc: deny")
+						Render(i18n.T("help.command_path"))
 				} else {
 					help = lipgloss.NewStyle().
 						Foreground(lipgloss.Color("241")).
-						Render("y: once • n: deny • a: always allow tool • c: always this cmd • Esc: deny")
+						Render(i18n.T("help.command"))
 				}
 			} else if m.pendingPermission.targetPath != "" {
 				help = lipgloss.NewStyle().
 					Foreground(lipgloss.Color("241")).
-					Render("y: once • n: deny • a: always allow tool • p: always this path • Esc: deny")
+					Render(i18n.T("help.path"))
 			} else {
 				help = lipgloss.NewStyle().
 					Foreground(lipgloss.Color("241")).
-					Render("y: once • n: deny • a: always allow tool • Esc: deny")
+					Render(i18n.T("help.tool"))
 			}
 		} else {
 			help = lipgloss.NewStyle().
 				Foreground(lipgloss.Color("241")).
-				Render("y: approve • n: deny • Esc: deny")
+				Render(i18n.T("help.approve"))
 		}
-	} else if m.waiting && len(m.messageQueue) > 0 {
+	} else if m.modelPicker != nil {
+		help = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241")).
+			Render(m.modelPicker.picker.help(i18n.T("picker.cancel")))
+	} else if m.queueMode {
 		help = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("241")).
-			Render("Esc: interrupt and send next queued • /clear-queue to clear")
+			Render(i18n.T("help.queue_mode"))
+	} else if queued, _ := m.ctrl.peekQueue(); m.waiting && queued > 0 {
+		help = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241")).
+			Render(i18n.T("help.queued"))
 	} else if m.waiting {
 		help = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("241")).
-			Render("Enter: queue message • /cmd: runs immediately • Esc: interrupt")
+			Render(i18n.T("help.waiting"))
 	} else {
 		help = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("241")).
-			Render("Enter: send • ↑↓: history • Ctrl+R: search • Esc: quit")
+			Render(i18n.T("help.idle"))
+	}
+	if m.panel.open && !m.waiting && !m.permissionMode && !m.searchMode && m.pendingBudget == nil && m.pendingQuestion == nil && m.pendingRestore == nil && m.pendingPaste == 0 {
+		help = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241")).
+			Render(i18n.T("help.split"))
 	}
 
 	s.WriteString("\n" + help + " " + memIndicator)
@@ -903,11 +1582,34 @@ func (m chatModel) View() string {
 
 func (m *chatModel) updateViewport() {
 	var content strings.Builder
+	line := 0
+	m.toolSpans = m.toolSpans[:0]
+
+	// Wrap each chunk ourselves so viewport lines match screen rows, which
+	// is what mouse clicks are mapped against
+	add := func(chunk string) {
+		if m.viewport.Width > 0 {
+			chunk = ansi.Wrap(chunk, m.viewport.Width, "")
+		}
+		content.WriteString(chunk)
+		line += strings.Count(chunk, "\n")
+	}
 
-	for _, msg := range m.messages {
+	for i, msg := range m.messages {
 		switch msg.role {
 		case "user":
-			content.WriteString(userStyle.Render("You: ") + msg.content + "\n\n")
+			if msg.display == "" {
+				add(userStyle.Render(i18n.T("chat.you")) + msg.content + "\n\n")
+				break
+			}
+			// Pasted text stays collapsed until clicked
+			start := line
+			if msg.expanded {
+				add(userStyle.Render(i18n.T("chat.you")) + msg.content + "\n" + timingStyle.Render(i18n.T("chat.tool_collapse")) + "\n\n")
+			} else {
+				add(userStyle.Render(i18n.T("chat.you")) + msg.display + "\n" + timingStyle.Render(i18n.T("chat.paste_expand")) + "\n\n")
+			}
+			m.toolSpans = append(m.toolSpans, messageSpan{start: start, end: line, index: i})
 		case "assistant":
 			rendered := msg.content
 			if m.glamour != nil {
@@ -915,11 +1617,22 @@ func (m *chatModel) updateViewport() {
 					rendered = r
 				}
 			}
-			content.WriteString(assistantStyle.Render("Assistant: ") + "\n" + rendered + "\n")
+			header := assistantStyle.Render(i18n.T("chat.assistant"))
+			if msg.meta != nil {
+				header += timingStyle.Render(formatMeta(msg.meta))
+			}
+			add(header + "\n" + rendered + "\n")
 		case "tool":
-			content.WriteString(toolStyle.Render(msg.content) + "\n")
+			start := line
+			text, collapsible := toolResultText(msg)
+			add(text + "\n")
+			if collapsible {
+				m.toolSpans = append(m.toolSpans, messageSpan{start: start, end: line, index: i})
+			}
 		case "error":
-			content.WriteString(errorStyle.Render(msg.content) + "\n\n")
+			add(errorStyle.Render(msg.content) + "\n\n")
+		case "timing":
+			add(timingStyle.Render(msg.content) + "\n\n")
 		case "system":
 			rendered := msg.content
 			if m.glamour != nil {
@@ -927,41 +1640,67 @@ func (m *chatModel) updateViewport() {
 					rendered = r
 				}
 			}
-			content.WriteString(lipgloss.NewStyle().
+			add(lipgloss.NewStyle().
 				Foreground(lipgloss.Color("111")).
 				Render(rendered) + "\n\n")
 		}
 	}
 
+	// Show the reply as it streams in; it is rendered properly once complete
+	if m.waiting {
+		if streamed := m.ctrl.streamed(); streamed != "" {
+			add(assistantStyle.Render(i18n.T("chat.assistant")) + "\n" + streamed + "\n")
+		}
+	}
+
 	m.viewport.SetContent(content.String())
 	m.viewport.GotoBottom()
 }
 
+// chat starts an agent turn. The task is registered with the controller
+// before the command runs so it can be interrupted right away.
 func (m *chatModel) chat(userMsg string) tea.Cmd {
+	taskCtx, taskID, previous := m.ctrl.begin(m.ctx)
+	ag := m.agent
+	onChunk := m.ctrl.streamFunc(taskID)
+	bench := m.bgBenchmark
+	store := m.store
+
 	return func() tea.Msg {
-		// Create cancellable context for this chat
-		taskCtx, cancel := context.WithCancel(m.ctx)
-		m.currentTask = cancel
+		// Turns on one agent must not overlap, so wait for an interrupted
+		// one to stop; begin already cancelled it. Waiting even when this
+		// task is cancelled too keeps the next one waiting behind both.
+		if previous != nil {
+			<-previous
+		}
+		if taskCtx.Err() != nil {
+			return responseMsg{taskID: taskID, err: fmt.Errorf("task cancelled")}
+		}
 
-		logger.Status("Starting agent.Chat call")
-		resp, err := m.agent.Chat(taskCtx, userMsg)
+		// Background benchmarks would compete with the turn for the GPU
+		release := bench.HoldForTurn()
+		defer release()
 
-		// Clear the task when done
-		m.currentTask = nil
+		logger.Status("Starting agent.Chat call")
+		started := time.Now()
+		resp, err := ag.ChatStream(taskCtx, userMsg, onChunk)
 
 		if err != nil {
 			// Check if it was cancelled
 			if taskCtx.Err() == context.Canceled {
 				logger.Status("agent.Chat was cancelled")
-				return responseMsg{err: fmt.Errorf("task cancelled")}
+				return responseMsg{taskID: taskID, err: fmt.Errorf("task cancelled")}
 			}
 			logger.Status("agent.Chat returned error: %v", err)
-			return responseMsg{err: err}
+			return responseMsg{taskID: taskID, err: err}
 		}
 		logger.Status("agent.Chat successful, content length: %d, tool calls: %d", len(resp.Content), len(resp.ToolCalls))
+		recordUsage(store, ag.Model(), time.Since(started), resp)
 		return responseMsg{
+			taskID:    taskID,
 			content:   resp.Content,
 			toolCalls: resp.ToolCalls,
+			meta:      resp.Meta,
 		}
 	}
 }
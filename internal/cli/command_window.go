@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/LaPingvino/llemecode/internal/tools"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -19,6 +20,7 @@ import (
 // CommandWindow is an interactive window for running commands
 type CommandWindow struct {
 	command   string
+	dir       string // Empty for the working directory
 	ctx       context.Context
 	cancel    context.CancelFunc
 	cmd       *exec.Cmd
@@ -216,6 +218,7 @@ func (cw *CommandWindow) startCommand() tea.Cmd {
 		cw.mu.Unlock()
 
 		cw.cmd = exec.CommandContext(cw.ctx, "bash", "-c", cw.command)
+		cw.cmd.Dir = cw.dir
 
 		// Setup stdin for interactive input
 		stdin, err := cw.cmd.StdinPipe()
@@ -299,9 +302,11 @@ func (cw *CommandWindow) GetExitCode() int {
 	return cw.exitCode
 }
 
-// RunCommandInteractive runs a command in an interactive window and returns the output
-func RunCommandInteractive(command string) (output string, exitCode int, err error) {
+// RunCommandInteractive runs a command in dir in an interactive window and
+// returns the output
+func RunCommandInteractive(command, dir string) (output string, exitCode int, err error) {
 	window := NewCommandWindow(command)
+	window.dir = dir
 	p := tea.NewProgram(window, tea.WithAltScreen())
 
 	finalModel, err := p.Run()
@@ -321,7 +326,7 @@ func NewInteractiveCommandExecutor() *InteractiveCommandExecutor {
 }
 
 func (ice *InteractiveCommandExecutor) Execute(ctx context.Context, command string) (output string, exitCode int, err error) {
-	return RunCommandInteractive(command)
+	return RunCommandInteractive(command, tools.WorkDir(ctx))
 }
 
 // SimpleCommandExecutor implements tools.CommandExecutor for non-interactive mode (ACP)
@@ -333,6 +338,7 @@ func NewSimpleCommandExecutor() *SimpleCommandExecutor {
 
 func (sce *SimpleCommandExecutor) Execute(ctx context.Context, command string) (output string, exitCode int, err error) {
 	cmd := exec.CommandContext(ctx, "bash", "-c", command)
+	cmd.Dir = tools.WorkDir(ctx)
 	outputBytes, err := cmd.CombinedOutput()
 
 	exitCode = 0
@@ -368,6 +374,7 @@ func (ice *InlineCommandExecutor) Execute(ctx context.Context, command string) (
 
 	// Execute command
 	cmd := exec.CommandContext(ctx, "bash", "-c", command)
+	cmd.Dir = tools.WorkDir(ctx)
 
 	// Capture stdout and stderr
 	stdout, err := cmd.StdoutPipe()
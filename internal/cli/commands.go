@@ -3,12 +3,15 @@ package cli
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
-	"github.com/LaPingvino/llemecode/internal/agent"
 	"github.com/LaPingvino/llemecode/internal/benchmark"
 	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/i18n"
 	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/session"
 	"github.com/LaPingvino/llemecode/internal/tools"
 )
 
@@ -86,10 +89,14 @@ func (c *HelpCommand) Description() string {
 
 func (c *HelpCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
 	var sb strings.Builder
-	sb.WriteString("## Available Commands\n\n")
+	sb.WriteString(i18n.T("help.commands") + "\n\n")
 
 	for _, cmd := range c.registry.List() {
-		sb.WriteString(fmt.Sprintf("- **/%s** - %s\n", cmd.Name(), cmd.Description()))
+		description := cmd.Description()
+		if key := "cmd." + cmd.Name(); i18n.Has(key) {
+			description = i18n.T(key)
+		}
+		sb.WriteString(fmt.Sprintf("- **/%s** - %s\n", cmd.Name(), description))
 	}
 
 	return sb.String(), nil
@@ -130,17 +137,46 @@ func (c *ListModelsCommand) Execute(ctx context.Context, args []string, m *chatM
 
 		sb.WriteString(fmt.Sprintf("- %s **%s**", marker, model.Name))
 
-		if cap, ok := c.cfg.ModelCapabilities[model.Name]; ok {
+		if cap, ok := c.cfg.GetCapability(model.Name); ok {
 			sb.WriteString(fmt.Sprintf(" _(format: %s", cap.ToolCallFormat))
+			if cap.ToolConfidence > 0 {
+				sb.WriteString(fmt.Sprintf(" %.0f%%", cap.ToolConfidence*100))
+			}
 			if len(cap.RecommendedFor) > 0 {
 				sb.WriteString(fmt.Sprintf(", good for: %s", strings.Join(cap.RecommendedFor, ", ")))
 			}
+			if cap.TotalScore > 0 {
+				sb.WriteString(fmt.Sprintf(", score %.2f, %s per task", cap.TotalScore, formatDuration(cap.AvgLatency)))
+			}
 			sb.WriteString(")_")
 		}
 
 		sb.WriteString("\n")
 	}
 
+	if best := c.cfg.GetBestModels(); len(best) > 0 {
+		weights := c.cfg.GetCategoryWeights()
+		categories := make([]string, 0, len(best))
+		for category := range best {
+			categories = append(categories, category)
+		}
+		sort.Strings(categories)
+
+		sb.WriteString("\n### Best Model per Category\n\n| Category | Model | Score | Weight |\n|---|---|---|---|\n")
+		for _, category := range categories {
+			weight, ok := weights[category]
+			if !ok {
+				weight = 1
+			}
+			score := "-"
+			if cap, ok := c.cfg.GetCapability(best[category]); ok && cap.CategoryScores != nil {
+				score = fmt.Sprintf("%.2f", cap.CategoryScores[category])
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | ×%g |\n", category, best[category], score, weight))
+		}
+		sb.WriteString("\nSwitch with `/model for <category>`.\n")
+	}
+
 	return sb.String(), nil
 }
 
@@ -160,16 +196,56 @@ func (c *SwitchModelCommand) Name() string {
 }
 
 func (c *SwitchModelCommand) Description() string {
-	return "Switch to a different model (usage: /model <model-name>)"
+	return "Switch to a different model, picking from a list without arguments (usage: /model [<model-name> | for <category> | unload])"
 }
 
 func (c *SwitchModelCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
 	if len(args) == 0 {
-		return fmt.Sprintf("Current model: %s\nUsage: /model <model-name>", c.cfg.DefaultModel), nil
+		if m.waiting {
+			return "", fmt.Errorf("a response is in progress; wait for it or press Esc before switching models")
+		}
+		models, err := c.client.ListModels(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to list models: %w", err)
+		}
+		picker := newModelPicker(ctx, c.client, c.cfg, models)
+		picker.maxRows = 8
+		picker.selectModel(m.agent.Model())
+		m.modelPicker = &inlineModelPicker{picker: picker, choose: c.switchTo}
+		return "", nil
 	}
 
 	newModel := args[0]
+	if newModel == "unload" {
+		return c.unloadOthers(ctx, m.agent.Model())
+	}
+	routed := "" // Why the model was picked for a category
+	if newModel == "for" && len(args) > 1 {
+		best, ok := c.cfg.BestModelFor(args[1])
+		if !ok {
+			return "", fmt.Errorf("no benchmarked model for category '%s'. Run /benchmark first", args[1])
+		}
+		newModel = best
+		if cap, ok := c.cfg.GetCapability(best); ok && cap.CategoryScores != nil {
+			routed = fmt.Sprintf("🏆 %s scored best at %s: %.2f", best, args[1], cap.CategoryScores[args[1]])
+		}
+	}
 
+	if m.waiting {
+		return "", fmt.Errorf("a response is in progress; wait for it or press Esc before switching models")
+	}
+	result, err := c.switchTo(ctx, newModel, m)
+	if err != nil {
+		return "", err
+	}
+	if routed != "" {
+		result = routed + "\n" + result
+	}
+	return result, nil
+}
+
+// switchTo makes newModel the model of the conversation and the default
+func (c *SwitchModelCommand) switchTo(ctx context.Context, newModel string, m *chatModel) (string, error) {
 	// Verify model exists
 	models, err := c.client.ListModels(ctx)
 	if err != nil {
@@ -189,20 +265,45 @@ func (c *SwitchModelCommand) Execute(ctx context.Context, args []string, m *chat
 	}
 
 	// Update config
-	c.cfg.DefaultModel = newModel
-	if err := c.cfg.Save(); err != nil {
+	err = c.cfg.Update(func(cfg *config.Config) {
+		cfg.DefaultModel = newModel
+	})
+	if err != nil {
 		return "", fmt.Errorf("failed to save config: %w", err)
 	}
 
-	// Create new agent with the new model
-	m.agent = agent.New(c.client, c.toolRegistry, c.cfg, newModel)
-	if sysPrompt, ok := c.cfg.SystemPrompts["default"]; ok {
-		m.agent.AddSystemPrompt(sysPrompt)
-	} else {
-		m.agent.AddSystemPrompt("")
+	// Switch the agent over, keeping the conversation
+	m.agent.SetModel(newModel, c.cfg.SystemPrompts["default"])
+	m.autosave.track(m.agent, newModel)
+
+	result := fmt.Sprintf("✓ Switched to model: %s (conversation kept, tool format: %s)", newModel, m.agent.ToolCallFormat())
+	if fit := checkModelFit(ctx, c.client, c.cfg, newModel); fit != nil {
+		result += "\n\n" + fit.warning()
 	}
+	return result, nil
+}
 
-	return fmt.Sprintf("✓ Switched to model: %s", newModel), nil
+// unloadOthers frees the memory of every loaded model except current
+func (c *SwitchModelCommand) unloadOthers(ctx context.Context, current string) (string, error) {
+	running, err := c.client.RunningModels(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list loaded models: %w", err)
+	}
+
+	var unloaded []string
+	for _, r := range running {
+		if r.Name == current {
+			continue
+		}
+		if err := c.client.UnloadModel(ctx, r.Name); err != nil {
+			return "", fmt.Errorf("failed to unload %s: %w", r.Name, err)
+		}
+		unloaded = append(unloaded, r.Name)
+	}
+	if len(unloaded) == 0 {
+		return i18n.T("fit.none_loaded"), nil
+	}
+	return i18n.T("fit.unloaded", strings.Join(unloaded, ", ")), nil
 }
 
 // ListPromptsCommand
@@ -234,7 +335,8 @@ func (c *ListPromptsCommand) Execute(ctx context.Context, args []string, m *chat
 		sb.WriteString(fmt.Sprintf("• %s:\n  %s\n\n", name, preview))
 	}
 
-	sb.WriteString("Edit prompts in: ~/.config/llemecode/config.json")
+	path, _ := config.GetConfigPath()
+	sb.WriteString("Edit prompts in: " + path)
 
 	return sb.String(), nil
 }
@@ -256,8 +358,12 @@ func (c *ResetCommand) Description() string {
 
 func (c *ResetCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
 	m.agent.ClearHistory()
+	m.autosave.newSession("")
 	m.messages = []message{}
 	m.updateViewport()
+	if err := session.ClearRecovery(); err != nil {
+		return "", fmt.Errorf("failed to clear recovery file: %w", err)
+	}
 	return "✓ Conversation cleared", nil
 }
 
@@ -276,23 +382,84 @@ func (c *BenchmarkCommand) Name() string {
 }
 
 func (c *BenchmarkCommand) Description() string {
-	return "Run benchmarks in background"
+	return "Benchmark new or changed models in background (usage: /benchmark [all|pause|resume|status] | /benchmark <model|pattern>... | /benchmark report [column] | /benchmark tasks [list|add|edit|remove|import|validate])"
 }
 
 func (c *BenchmarkCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "tasks":
+			return c.tasks(ctx, args[1:])
+		case "report":
+			sortBy := ""
+			if len(args) > 1 {
+				sortBy = args[1]
+			}
+			scores, path, err := benchmark.LoadLatestResults()
+			if err != nil {
+				return "", err
+			}
+			if err := benchmark.SortScores(scores, sortBy); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("## 📊 Benchmark Report\n\n%s\n_From %s. Sort with /benchmark report <%s>._", benchmark.MarkdownReport(scores), path, strings.Join(benchmark.ReportColumns, "|")), nil
+		case "pause", "resume", "status":
+			if m.bgBenchmark == nil || !m.bgBenchmark.IsRunning() {
+				return "No benchmark is running in the background", nil
+			}
+			switch args[0] {
+			case "pause":
+				m.bgBenchmark.Pause()
+				return "⏸ Benchmarking will pause after the current step (/benchmark resume to continue)", nil
+			case "resume":
+				m.bgBenchmark.Resume()
+				return "▶ Benchmarking resumed", nil
+			}
+			return m.bgBenchmark.Status(), nil
+		}
+	}
+
 	if m.bgBenchmark != nil && m.bgBenchmark.IsRunning() {
 		return "Benchmarking is already running in the background", nil
 	}
 
+	if err := benchmark.ValidateTasks(c.cfg.BenchmarkTasks); err != nil {
+		return "", fmt.Errorf("invalid benchmark tasks (fix them with /benchmark tasks): %w", err)
+	}
+
 	benchmarker := benchmark.New(c.client, c.cfg.BenchmarkTasks)
-	if c.cfg.DefaultModel != "" {
-		benchmarker.SetEvaluator(c.cfg.DefaultModel)
+	benchmarker.Configure(c.cfg)
+	all := len(args) > 0 && args[0] == "all"
+	if !all {
+		if err := loadPreviousResults(benchmarker); err != nil {
+			return "", err
+		}
+	}
+
+	// Anything else names the models to re-run, checked now so typos show
+	var selected []ollama.ModelInfo
+	if len(args) > 0 && !all {
+		var err error
+		selected, _, err = benchmarker.SelectModels(ctx, args)
+		if err != nil {
+			return "", err
+		}
 	}
 
 	m.bgBenchmark = NewBackgroundBenchmark(ctx, benchmarker, c.cfg)
+	if len(selected) > 0 {
+		m.bgBenchmark.Select(args)
+	}
 	m.bgBenchmark.Start()
 	m.benchmarkDone = false
 
+	if len(selected) > 0 {
+		names := make([]string, len(selected))
+		for i, model := range selected {
+			names[i] = model.Name
+		}
+		return fmt.Sprintf("✓ Started background benchmarking of %s", strings.Join(names, ", ")), nil
+	}
 	return "✓ Started background benchmarking", nil
 }
 
@@ -377,8 +544,7 @@ func (c *ClearQueueCommand) Description() string {
 }
 
 func (c *ClearQueueCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
-	count := len(m.messageQueue)
-	m.messageQueue = nil
+	count := m.ctrl.clearQueue()
 
 	if count == 0 {
 		return "Queue is already empty.", nil
@@ -386,3 +552,136 @@ func (c *ClearQueueCommand) Execute(ctx context.Context, args []string, m *chatM
 
 	return fmt.Sprintf("✓ Cleared %d queued message(s).", count), nil
 }
+
+// QueueCommand lists and edits the messages queued while a reply is running
+type QueueCommand struct{}
+
+func NewQueueCommand() *QueueCommand {
+	return &QueueCommand{}
+}
+
+func (c *QueueCommand) Name() string {
+	return "queue"
+}
+
+func (c *QueueCommand) Description() string {
+	return "List or edit queued messages (usage: /queue [delete <n>] [move <n> <to>] [up|down <n>] [edit <n>] [clear])"
+}
+
+func (c *QueueCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	if len(args) == 0 {
+		return c.list(m), nil
+	}
+
+	indexArg := func(i int) (int, error) {
+		if len(args) <= i {
+			return 0, fmt.Errorf("missing message number. Usage: /queue %s <n>", args[0])
+		}
+		n, err := strconv.Atoi(args[i])
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid message number '%s'", args[i])
+		}
+		return n - 1, nil
+	}
+
+	switch args[0] {
+	case "delete", "rm":
+		i, err := indexArg(1)
+		if err != nil {
+			return "", err
+		}
+		if _, ok := m.ctrl.removeQueued(i); !ok {
+			return "", fmt.Errorf("no queued message %d", i+1)
+		}
+		return fmt.Sprintf("✓ Removed queued message %d\n\n%s", i+1, c.list(m)), nil
+
+	case "move":
+		from, err := indexArg(1)
+		if err != nil {
+			return "", err
+		}
+		to, err := indexArg(2)
+		if err != nil {
+			return "", err
+		}
+		if !m.ctrl.moveQueued(from, to) {
+			return "", fmt.Errorf("no queued message %d or %d", from+1, to+1)
+		}
+		return c.list(m), nil
+
+	case "up", "down":
+		i, err := indexArg(1)
+		if err != nil {
+			return "", err
+		}
+		to := i - 1
+		if args[0] == "down" {
+			to = i + 1
+		}
+		if !m.ctrl.moveQueued(i, to) {
+			return "", fmt.Errorf("can't move queued message %d %s", i+1, args[0])
+		}
+		return c.list(m), nil
+
+	case "edit":
+		i, err := indexArg(1)
+		if err != nil {
+			return "", err
+		}
+		msg, ok := m.ctrl.removeQueued(i)
+		if !ok {
+			return "", fmt.Errorf("no queued message %d", i+1)
+		}
+		m.textarea.SetValue(msg)
+		return fmt.Sprintf("✓ Moved queued message %d back to the input", i+1), nil
+
+	case "clear":
+		return fmt.Sprintf("✓ Cleared %d queued message(s).", m.ctrl.clearQueue()), nil
+	}
+
+	return "", fmt.Errorf("unknown subcommand '%s'", args[0])
+}
+
+func (c *QueueCommand) list(m *chatModel) string {
+	queue := m.ctrl.queued()
+	if len(queue) == 0 {
+		return "Queue is empty."
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("⏸ Queued messages (%d):\n\n", len(queue)))
+	for i, msg := range queue {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, msg))
+	}
+	sb.WriteString("\nPress Ctrl+Q to select and reorder queued messages with the keyboard")
+	return sb.String()
+}
+
+// ExpandCommand expands or collapses every long tool result and paste in the transcript
+type ExpandCommand struct{}
+
+func NewExpandCommand() *ExpandCommand {
+	return &ExpandCommand{}
+}
+
+func (c *ExpandCommand) Name() string {
+	return "expand"
+}
+
+func (c *ExpandCommand) Description() string {
+	return "Expand all tool results and pastes, or collapse them again (usage: /expand [off])"
+}
+
+func (c *ExpandCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	expanded := len(args) == 0 || args[0] != "off"
+	for i := range m.messages {
+		if m.messages[i].role == "tool" || m.messages[i].display != "" {
+			m.messages[i].expanded = expanded
+		}
+	}
+
+	if expanded {
+		return "✓ Tool results and pastes expanded. Use /expand off to collapse them.", nil
+	}
+	return "✓ Tool results and pastes collapsed.", nil
+}
@@ -0,0 +1,259 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// chatController holds chat state that outlives a single Update call.
+// chatModel is copied on every Update, and tea.Cmd closures run on other
+// goroutines, so task cancellation, the message queue and the streaming
+// buffer live here behind a mutex and are shared by pointer.
+type chatController struct {
+	mu       sync.Mutex
+	program  *tea.Program
+	taskID   uint64             // ID of the running task, 0 when idle
+	started  time.Time          // When the running task began
+	first    time.Time          // When the first streamed chunk of the task arrived
+	last     time.Time          // When the latest streamed chunk arrived
+	nextID   uint64             // Last issued task ID
+	cancel   context.CancelFunc // Cancels the running task
+	queue    []string           // Messages queued while a task is running
+	stream   strings.Builder    // Content streamed so far for the current reply
+	streamIt int                // Agent iteration the stream buffer belongs to
+
+	done     map[uint64]chan struct{} // Closed by finish once a task's turn has stopped, cancelled or not
+	lastDone chan struct{}            // Of the task begun last
+}
+
+// streamMsg tells the UI that the stream buffer of a task changed
+type streamMsg struct {
+	taskID uint64
+}
+
+func newChatController() *chatController {
+	return &chatController{done: make(map[uint64]chan struct{})}
+}
+
+// setProgram lets streaming callbacks notify the running program
+func (c *chatController) setProgram(p *tea.Program) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.program = p
+}
+
+// currentProgram returns the running program, or nil outside the TUI
+func (c *chatController) currentProgram() *tea.Program {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.program
+}
+
+// begin starts a new task, cancelling any task still running. The task must
+// not touch the agent before previous is closed: a cancelled turn can still
+// be adding tool results to the history.
+func (c *chatController) begin(parent context.Context) (ctx context.Context, id uint64, previous <-chan struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	c.nextID++
+	c.taskID = c.nextID
+	done := make(chan struct{})
+	c.done[c.taskID] = done
+	previous = c.lastDone
+	c.lastDone = done
+	c.started = time.Now()
+	c.first = time.Time{}
+	c.last = time.Time{}
+	c.cancel = cancel
+	c.stream.Reset()
+	c.streamIt = 0
+	return ctx, c.taskID, previous
+}
+
+// finish marks the task as done, letting the task begun after it start. It
+// returns false if the task was already cancelled or superseded, in which
+// case its result should be dropped.
+func (c *chatController) finish(id uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if done, ok := c.done[id]; ok {
+		close(done)
+		delete(c.done, id)
+	}
+	if id == 0 || id != c.taskID {
+		return false
+	}
+	c.cancel()
+	c.cancel = nil
+	c.taskID = 0
+	c.stream.Reset()
+	return true
+}
+
+// interrupt cancels the running task, if any
+func (c *chatController) interrupt() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cancel == nil {
+		return false
+	}
+	c.cancel()
+	c.cancel = nil
+	c.taskID = 0
+	c.stream.Reset()
+	return true
+}
+
+// taskTiming describes how far along the running (or just finished) task is
+type taskTiming struct {
+	elapsed    time.Duration // Since the task started
+	firstToken time.Duration // From start to the first streamed chunk, 0 if none yet
+	idle       time.Duration // Since the latest chunk, 0 if none yet
+}
+
+func (c *chatController) timing() taskTiming {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.started.IsZero() {
+		return taskTiming{}
+	}
+	t := taskTiming{elapsed: time.Since(c.started)}
+	if !c.first.IsZero() {
+		t.firstToken = c.first.Sub(c.started)
+		t.idle = time.Since(c.last)
+	}
+	return t
+}
+
+// elapsed returns how long the running (or just finished) task has taken
+func (c *chatController) elapsed() time.Duration {
+	return c.timing().elapsed
+}
+
+func (c *chatController) running() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.taskID != 0
+}
+
+func (c *chatController) enqueue(msg string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queue = append(c.queue, msg)
+}
+
+// dequeue pops the oldest queued message
+func (c *chatController) dequeue() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.queue) == 0 {
+		return "", false
+	}
+	msg := c.queue[0]
+	c.queue = c.queue[1:]
+	return msg, true
+}
+
+// peekQueue returns the number of queued messages and the oldest one
+func (c *chatController) peekQueue() (int, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.queue) == 0 {
+		return 0, ""
+	}
+	return len(c.queue), c.queue[0]
+}
+
+// queued returns a copy of the queued messages, oldest first
+func (c *chatController) queued() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.queue...)
+}
+
+// removeQueued drops the queued message at index i
+func (c *chatController) removeQueued(i int) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if i < 0 || i >= len(c.queue) {
+		return "", false
+	}
+	msg := c.queue[i]
+	c.queue = append(c.queue[:i], c.queue[i+1:]...)
+	return msg, true
+}
+
+// moveQueued moves the queued message at index from to index to
+func (c *chatController) moveQueued(from, to int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if from < 0 || from >= len(c.queue) || to < 0 || to >= len(c.queue) {
+		return false
+	}
+	msg := c.queue[from]
+	c.queue = append(c.queue[:from], c.queue[from+1:]...)
+	c.queue = append(c.queue[:to], append([]string{msg}, c.queue[to:]...)...)
+	return true
+}
+
+// clearQueue drops all queued messages and returns how many there were
+func (c *chatController) clearQueue() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := len(c.queue)
+	c.queue = nil
+	return n
+}
+
+// streamFunc returns an agent.StreamFunc that feeds the stream buffer for
+// the given task and wakes the UI
+func (c *chatController) streamFunc(id uint64) func(iteration int, chunk string) {
+	return func(iteration int, chunk string) {
+		c.mu.Lock()
+		if id != c.taskID {
+			c.mu.Unlock()
+			return
+		}
+		if iteration != c.streamIt {
+			// A new model request after tool calls starts a fresh reply
+			c.stream.Reset()
+			c.streamIt = iteration
+		}
+		c.stream.WriteString(chunk)
+		c.last = time.Now()
+		if c.first.IsZero() {
+			c.first = c.last
+		}
+		p := c.program
+		c.mu.Unlock()
+
+		if p != nil {
+			p.Send(streamMsg{taskID: id})
+		}
+	}
+}
+
+// streamed returns the content streamed so far for the running task
+func (c *chatController) streamed() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stream.String()
+}
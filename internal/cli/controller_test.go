@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"context"
+	"testing"
+)
+
+func TestControllerWaitsForInterruptedTurn(t *testing.T) {
+	c := newChatController()
+	first, firstID, previous := c.begin(context.Background())
+	if previous != nil {
+		t.Fatal("Expected nothing to wait for before the first task")
+	}
+
+	c.interrupt()
+	if first.Err() == nil {
+		t.Fatal("Expected the interrupted task to be cancelled")
+	}
+	_, secondID, previous := c.begin(context.Background())
+	select {
+	case <-previous:
+		t.Fatal("Expected the next task to wait while the interrupted one still runs")
+	default:
+	}
+
+	// The interrupted turn's late response is dropped but lets the next start
+	if c.finish(firstID) {
+		t.Error("Expected the interrupted task's result to be dropped")
+	}
+	select {
+	case <-previous:
+	default:
+		t.Fatal("Expected the next task to start once the interrupted one finished")
+	}
+	if !c.finish(secondID) {
+		t.Error("Expected the running task to finish")
+	}
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DebugCommand shows the raw traffic with Ollama, for tuning prompts and
+// tool formats
+type DebugCommand struct{}
+
+func NewDebugCommand() *DebugCommand {
+	return &DebugCommand{}
+}
+
+func (c *DebugCommand) Name() string {
+	return "debug"
+}
+
+func (c *DebugCommand) Description() string {
+	return "Show the exact JSON sent to Ollama and the raw response for the last turn in the side panel (usage: /debug last)"
+}
+
+func (c *DebugCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	if len(args) > 1 || (len(args) == 1 && args[0] != "last") {
+		return "", fmt.Errorf("usage: /debug last")
+	}
+	if m.waiting {
+		return "", fmt.Errorf("a response is in progress; wait for it or press Esc first")
+	}
+	exchanges := m.agent.LastExchanges()
+	if len(exchanges) == 0 {
+		return "No requests yet. Send a message, then /debug last shows what was sent to Ollama and what came back.", nil
+	}
+
+	var sb strings.Builder
+	for i, ex := range exchanges {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(diffHunkStyle.Render(fmt.Sprintf("── Request %d of %d: POST %s ──", i+1, len(exchanges), ex.URL)))
+		sb.WriteString("\n" + ex.Indented() + "\n\n")
+		if ex.Status == 0 {
+			sb.WriteString(diffHunkStyle.Render("── No response ──"))
+		} else {
+			sb.WriteString(diffHunkStyle.Render(fmt.Sprintf("── Response: status %d, %d bytes ──", ex.Status, len(ex.Response))))
+			sb.WriteString("\n" + strings.TrimRight(string(ex.Response), "\n"))
+		}
+	}
+
+	if !m.panel.open {
+		m.toggleSplit()
+	}
+	m.showInPanel(panelMsg{title: fmt.Sprintf("🐞 Last turn: %d requests", len(exchanges)), content: sb.String()})
+	m.focusPanel(true)
+	return fmt.Sprintf("🐞 %d raw requests of the last turn are in the side panel. PgUp/PgDn scroll it, Tab goes back to the input and Ctrl+O closes it.", len(exchanges)), nil
+}
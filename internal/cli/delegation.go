@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/LaPingvino/llemecode/internal/i18n"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// delegationPreviewLines is how much of a streaming answer the live block shows
+const delegationPreviewLines = 6
+
+// inlineModelProgress streams ask_ tool answers into the transcript as
+// live blocks, reusing the command output boxes
+type inlineModelProgress struct {
+	program *tea.Program
+
+	mu      sync.Mutex
+	pending map[string]string // Unfinished line per answer
+}
+
+func newInlineModelProgress(program *tea.Program) *inlineModelProgress {
+	return &inlineModelProgress{program: program, pending: make(map[string]string)}
+}
+
+func (p *inlineModelProgress) Start(id, model, question string) {
+	p.program.Send(commandStartMsg{id: id, command: singleLine(question), model: model})
+}
+
+func (p *inlineModelProgress) Chunk(id, text string) {
+	p.mu.Lock()
+	lines := strings.Split(p.pending[id]+text, "\n")
+	p.pending[id] = lines[len(lines)-1]
+	p.mu.Unlock()
+
+	for _, line := range lines[:len(lines)-1] {
+		p.program.Send(commandOutputMsg{id: id, line: line})
+	}
+	p.program.Send(commandOutputMsg{id: id, line: lines[len(lines)-1], partial: true})
+}
+
+func (p *inlineModelProgress) End(id string, err error) {
+	p.mu.Lock()
+	rest := p.pending[id]
+	delete(p.pending, id)
+	p.mu.Unlock()
+
+	if rest != "" {
+		p.program.Send(commandOutputMsg{id: id, line: rest})
+	}
+	exitCode := 0
+	if err != nil {
+		exitCode = 1
+	}
+	p.program.Send(commandEndMsg{id: id, exitCode: exitCode, err: err})
+}
+
+// delegationView renders a delegated answer: the last lines while it
+// streams, then folded to one line, since the answer is in the tool result
+func delegationView(cmd *commandExecution) string {
+	if !cmd.running {
+		if cmd.err != nil {
+			return lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true).
+				Render(i18n.T("delegation.failed", cmd.model, cmd.err))
+		}
+		lines := len(cmd.output)
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Bold(true).
+			Render(i18n.T("delegation.done", cmd.model, lines))
+	}
+
+	header := lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true).
+		Render(truncateRunes(i18n.T("delegation.running", cmd.model)+": "+cmd.command, 120))
+	lines := append(append([]string{}, cmd.output...), cmd.partial)
+	if len(lines) > delegationPreviewLines {
+		lines = lines[len(lines)-delegationPreviewLines:]
+	}
+	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(i18n.T("delegation.hint"))
+	return header + "\n" + strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n" + hint
+}
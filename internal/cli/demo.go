@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/LaPingvino/llemecode/internal/tools"
+)
+
+// demoRoot is the project root name the demo workspace is added under
+const demoRoot = "demo"
+
+// demoFiles is the sample project the demo works on. greet.sh has two bugs
+// the README gives away, so there is an edit to make and a command to check.
+var demoFiles = map[string]string{
+	"README.md": "# Greeter\n\nA tiny script that greets whoever you name:\n\n```sh\nsh greet.sh Ada\n```\n\nshould print `Hello, Ada!`. Without a name it greets `there`.\n",
+	"greet.sh":  "#!/bin/sh\n# Greets the person named as the first argument\nname=\"${1:-there}\"\necho \"Helo, $nme!\"\n",
+}
+
+// demoTask walks the model through reading, editing behind a diff and
+// running a command in the workspace at %s
+const demoTask = `This is a demo for a user trying llemecode for the first time, to show them how you work with tools. A sample project is in the project root "demo", at %s. Pass root="demo" to list_files, read_file and run_command, and give apply_changes full paths. Say in one short sentence what you are about to do before each step:
+
+1. List the files in the demo root, then read README.md and greet.sh.
+2. greet.sh doesn't do what the README says. Fix it with apply_changes, so the user sees the diff before approving it.
+3. Run "sh greet.sh Ada" with run_command to show the fix works.
+
+Finish with two sentences on what the user just saw and that they can now ask you for real work in their own project.`
+
+// DemoCommand runs a scripted showcase of the tool loop on a throwaway
+// sample project
+type DemoCommand struct {
+	toolRegistry *tools.Registry
+	dir          string // Workspace of the last demo, replaced by the next
+}
+
+func NewDemoCommand(toolRegistry *tools.Registry) *DemoCommand {
+	return &DemoCommand{toolRegistry: toolRegistry}
+}
+
+func (c *DemoCommand) Name() string {
+	return "demo"
+}
+
+func (c *DemoCommand) Description() string {
+	return "Watch the agent read, edit and test a sample project in a temporary workspace"
+}
+
+func (c *DemoCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	if m.waiting {
+		return "", fmt.Errorf("a response is in progress; wait for it or press Esc before starting the demo")
+	}
+	p := m.ctrl.currentProgram()
+	if p == nil {
+		return "", fmt.Errorf("the demo needs the interactive chat")
+	}
+	live := c.toolRegistry.PermissionConfig()
+	if live == nil {
+		return "", fmt.Errorf("no permission-checked tools are registered")
+	}
+
+	dir, err := newDemoWorkspace()
+	if err != nil {
+		return "", err
+	}
+	if _, err := live.AddProjectRoot(demoRoot, dir); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	if c.dir != "" {
+		os.RemoveAll(c.dir)
+	}
+	c.dir = dir
+	m.agent.AddContext(workspaceNote(live.ProjectRoots()))
+
+	// Commands can't start a turn themselves; Send blocks until Update returns
+	go p.Send(sendPromptMsg{text: fmt.Sprintf(demoTask, dir), display: "🎬 /demo: read the sample project, fix its bug, run it"})
+
+	return fmt.Sprintf("🎬 Demo workspace: %s\n\nThe agent will read the sample project, fix a bug behind a diff you approve, and run it. Each edit and command asks you first; Esc stops the demo.", dir), nil
+}
+
+// newDemoWorkspace writes the sample project to a new temporary directory
+func newDemoWorkspace() (string, error) {
+	dir, err := os.MkdirTemp("", "llemecode-demo-")
+	if err != nil {
+		return "", fmt.Errorf("create demo workspace: %w", err)
+	}
+	for name, content := range demoFiles {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			os.RemoveAll(dir)
+			return "", fmt.Errorf("create demo workspace: %w", err)
+		}
+	}
+	return dir, nil
+}
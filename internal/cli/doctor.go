@@ -0,0 +1,279 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/agent"
+	"github.com/LaPingvino/llemecode/internal/benchmark"
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/i18n"
+	"github.com/LaPingvino/llemecode/internal/mcp"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/sshtunnel"
+	"github.com/LaPingvino/llemecode/internal/storage"
+)
+
+// doctor collects the results of the environment checks
+type doctor struct {
+	w        io.Writer
+	problems int
+}
+
+func (d *doctor) section(title string) {
+	fmt.Fprintf(d.w, "\n%s\n", title)
+}
+
+func (d *doctor) ok(format string, args ...interface{}) {
+	fmt.Fprintf(d.w, "  ✓ %s\n", fmt.Sprintf(format, args...))
+}
+
+// fail reports a problem and how to fix it
+func (d *doctor) fail(fix, format string, args ...interface{}) {
+	d.problems++
+	fmt.Fprintf(d.w, "  ✗ %s\n", fmt.Sprintf(format, args...))
+	if fix != "" {
+		fmt.Fprintf(d.w, "    → %s\n", fix)
+	}
+}
+
+// RunDoctor checks the environment Llemecode needs and prints what to do
+// about each problem. urlOverride is the --url flag; newClient builds the
+// client for a loaded config the same way a normal run would.
+func RunDoctor(ctx context.Context, w io.Writer, urlOverride string, newClient func(*config.Config) *ollama.Client) error {
+	d := &doctor{w: w}
+	fmt.Fprintln(w, "🩺 Llemecode doctor")
+
+	d.section("Configuration")
+	configDir := d.checkConfigDir()
+	cfg, err := config.Load()
+	if err != nil {
+		d.fail(fmt.Sprintf("Fix or remove %s; a fresh default config is written when it is missing", configDir+"/config.json"), "%v", err)
+		cfg = config.DefaultConfig()
+	} else {
+		d.ok("config.json loaded")
+		d.checkConfig(cfg)
+		d.checkStorage(cfg)
+	}
+	if policy, err := config.LoadPolicy(); err != nil {
+		d.fail("Ask whoever manages "+config.PolicyPath+" to fix it; llemecode won't start until it can be read", "%v", err)
+	} else if policy != nil {
+		d.ok("team policy %s loaded", config.PolicyPath)
+	}
+
+	d.section("Ollama")
+	if urlOverride != "" {
+		cfg.OverrideOllamaURL(urlOverride)
+	} else if cfg.SSHTunnel != nil {
+		tunnel, err := sshtunnel.Open(ctx, *cfg.SSHTunnel)
+		if err != nil {
+			d.fail("Check ssh_tunnel in config.json and that 'ssh "+cfg.SSHTunnel.Host+"' works without a password prompt", "SSH tunnel to %s: %v", cfg.SSHTunnel.Host, err)
+		} else {
+			defer tunnel.Close()
+			d.ok("SSH tunnel to %s open at %s", cfg.SSHTunnel.Host, tunnel.URL())
+			cfg.OverrideOllamaURL(tunnel.URL())
+		}
+	}
+	client := newClient(cfg)
+	installed := d.checkOllama(ctx, client)
+
+	d.section("Models")
+	d.checkModels(cfg, installed)
+
+	d.section("MCP servers")
+	d.checkMCP(ctx, cfg)
+
+	fmt.Fprintln(w)
+	if d.problems > 0 {
+		return fmt.Errorf("%d problem(s) found", d.problems)
+	}
+	fmt.Fprintln(w, "✓ Everything looks good")
+	return nil
+}
+
+// checkConfigDir verifies that the config directory can be written to
+func (d *doctor) checkConfigDir() string {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		d.fail("Set HOME to your home directory", "config directory: %v", err)
+		return ""
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		d.fail(fmt.Sprintf("Create %s and make it writable by your user", dir), "config directory: %v", err)
+		return dir
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		d.fail(fmt.Sprintf("Make %s writable by your user (chown or chmod u+w)", dir), "config directory %s is not writable: %v", dir, err)
+		return dir
+	}
+	f.Close()
+	os.Remove(f.Name())
+	d.ok("config directory %s is writable", dir)
+	return dir
+}
+
+// checkConfig looks for values a run would reject or silently ignore
+func (d *doctor) checkConfig(cfg *config.Config) {
+	if err := benchmark.ValidateTasks(cfg.BenchmarkTasks); err != nil {
+		d.fail("Fix the task with /benchmark tasks edit, or check it with /benchmark tasks validate", "benchmark tasks: %v", err)
+	} else {
+		d.ok("%d benchmark tasks valid", len(cfg.BenchmarkTasks))
+	}
+
+	for _, ep := range cfg.Endpoints {
+		if u, err := url.Parse(ep.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			d.fail("Use a URL like http://host:11434", "endpoint %s has an invalid url %q", ep.Name, ep.URL)
+		}
+	}
+	if cfg.SSHTunnel != nil && cfg.SSHTunnel.Host == "" {
+		d.fail("Set ssh_tunnel.host or remove ssh_tunnel", "ssh_tunnel has no host")
+	}
+
+	for model, cap := range cfg.ModelCapabilities {
+		switch cap.ToolCallFormat {
+		case "native", "xml", "json", "text", "":
+		default:
+			d.fail("Use native, xml, json or text", "model %s has unknown tool_call_format %q", model, cap.ToolCallFormat)
+		}
+	}
+
+	for _, p := range cfg.PostProcessors {
+		if err := agent.ValidatePostProcessor(p); err != nil {
+			d.fail("Fix the entry in post_processors; templates can use stripANSI, head, tail, grep and trim", "%v", err)
+		}
+	}
+
+	if cfg.Theme != "" && markdownStyle(cfg.Theme) != cfg.Theme {
+		d.fail("Use one of: "+strings.Join(Themes, ", "), "unknown theme %q", cfg.Theme)
+	}
+	if cfg.Language != "" && !i18n.Supported(cfg.Language) {
+		d.fail("Use one of: "+strings.Join(i18n.Languages(), ", "), "unknown language %q", cfg.Language)
+	}
+}
+
+// checkStorage opens the configured store, which imports the files the
+// first time SQLite is used
+func (d *doctor) checkStorage(cfg *config.Config) {
+	store, err := storage.Open(cfg)
+	if err != nil {
+		d.fail("Set storage to \"files\" or \"sqlite\" in config.json, or move a damaged llemecode.db aside", "storage: %v", err)
+		return
+	}
+	defer store.Close()
+
+	sessions, err := store.ListSessions()
+	if err != nil {
+		d.fail("Move the damaged file aside", "storage: %v", err)
+		return
+	}
+	kind := cfg.Storage
+	if kind == "" {
+		kind = "files"
+	}
+	d.ok("%s storage readable, %d sessions", kind, len(sessions))
+}
+
+// checkOllama reports every server's reachability and version and returns
+// the installed models
+func (d *doctor) checkOllama(ctx context.Context, client *ollama.Client) []ollama.ModelInfo {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	for _, status := range client.CheckEndpoints(ctx) {
+		if status.Err != nil {
+			d.fail("Start Ollama with 'ollama serve', or fix ollama_url/endpoints in config.json or --url", "%s (%s) is unreachable: %v", status.Name, status.URL, status.Err)
+			continue
+		}
+		version, err := client.Version(ctx, status.URL)
+		if err != nil || version == "" {
+			version = "unknown"
+		}
+		d.ok("%s (%s) reachable, Ollama %s, %d models", status.Name, status.URL, version, status.Models)
+	}
+
+	models, err := client.ListModels(ctx)
+	if err != nil {
+		return nil
+	}
+	return models
+}
+
+func (d *doctor) checkModels(cfg *config.Config, installed []ollama.ModelInfo) {
+	if len(installed) == 0 {
+		d.fail("Pull a model, e.g. 'ollama pull qwen2.5-coder:7b'", "no models found")
+		return
+	}
+
+	names := make([]string, 0, len(installed))
+	for _, model := range installed {
+		names = append(names, model.Name)
+	}
+	d.ok("%d models: %s", len(names), strings.Join(names, ", "))
+
+	switch {
+	case cfg.DefaultModel == "":
+		d.fail("Run 'llemecode --setup' or start once with --model <name> --save", "no default model configured")
+	case !contains(names, cfg.DefaultModel):
+		d.fail(fmt.Sprintf("Run 'ollama pull %s', or pick another model with /model", cfg.DefaultModel), "default model %s is not installed", cfg.DefaultModel)
+	default:
+		d.ok("default model %s is installed", cfg.DefaultModel)
+	}
+
+	if cfg.Evaluator != "" && cfg.Evaluator != "none" && !contains(names, cfg.Evaluator) {
+		d.fail(fmt.Sprintf("Run 'ollama pull %s', or set evaluator to \"none\"", cfg.Evaluator), "evaluator %s is not installed", cfg.Evaluator)
+	}
+	if model := cfg.ContextPruning.EmbeddingModel; model != "" && !contains(names, model) && !contains(names, model+":latest") {
+		d.fail(fmt.Sprintf("Run 'ollama pull %s', or clear context_pruning.embedding_model to score by shared words", model), "embedding model %s is not installed", model)
+	}
+	for _, model := range cfg.BenchmarkModels {
+		if !contains(names, model) {
+			d.fail("Remove it from benchmark_models or pull it", "benchmark model %s is not installed", model)
+		}
+	}
+}
+
+// checkMCP starts every enabled MCP server and stops it again
+func (d *doctor) checkMCP(ctx context.Context, cfg *config.Config) {
+	enabled := 0
+	for _, server := range cfg.MCPServers {
+		if !server.Enabled {
+			continue
+		}
+		enabled++
+
+		if _, err := exec.LookPath(server.Command); err != nil {
+			d.fail(fmt.Sprintf("Install %s or fix the command of %s in config.json", server.Command, server.Name), "%s: command %q not found", server.Name, server.Command)
+			continue
+		}
+
+		startCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		client := mcp.NewMCPClient(server.Name, server.Command, server.Args)
+		err := client.Start(startCtx)
+		if err != nil {
+			d.fail(fmt.Sprintf("Run '%s' by hand to see why it fails to start", strings.Join(append([]string{server.Command}, server.Args...), " ")), "%s failed to start: %v", server.Name, err)
+		} else {
+			d.ok("%s started with %d tools", server.Name, len(client.GetTools()))
+			client.Close()
+		}
+		cancel()
+	}
+	if enabled == 0 {
+		d.ok("none enabled")
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,263 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/agent"
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/logger"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/session"
+	"github.com/LaPingvino/llemecode/internal/storage"
+	"github.com/LaPingvino/llemecode/internal/tools"
+)
+
+// headlessAbort is the input line that stops the running turn
+const headlessAbort = "/abort"
+
+// headlessEvent is one line of headless output
+type headlessEvent struct {
+	Type    string `json:"type"` // ready, progress, question, reply, command, permission_denied or error
+	Model   string `json:"model,omitempty"`
+	Session string `json:"session,omitempty"`
+	Content string `json:"content,omitempty"`
+	Tool    string `json:"tool,omitempty"`
+	Details string `json:"details,omitempty"`
+	Error   string `json:"error,omitempty"`
+
+	Options  []string            `json:"options,omitempty"`
+	Progress *agent.Checkpoint   `json:"progress,omitempty"`
+	Meta     *ollama.MessageMeta `json:"meta,omitempty"` // What produced a reply
+
+	PromptTokens     int `json:"prompt_tokens,omitempty"`
+	CompletionTokens int `json:"completion_tokens,omitempty"`
+}
+
+// headlessOutput writes events to stdout as line-delimited JSON
+type headlessOutput struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func newHeadlessOutput(w io.Writer) *headlessOutput {
+	return &headlessOutput{enc: json.NewEncoder(w)}
+}
+
+func (o *headlessOutput) emit(ev headlessEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.enc.Encode(ev); err != nil {
+		logger.Log("headless: %v", err)
+	}
+}
+
+// headlessPermissionChecker approves nothing that needs asking, since no
+// one is there to answer; always_allow patterns in the config still apply
+type headlessPermissionChecker struct {
+	out *headlessOutput
+}
+
+func (c *headlessPermissionChecker) RequestPermission(ctx context.Context, tool string, level tools.PermissionLevel, details string) (bool, error) {
+	c.out.emit(headlessEvent{Type: "permission_denied", Tool: tool, Details: details})
+	return false, nil
+}
+
+func (c *headlessPermissionChecker) RequestOutsideWorkspace(ctx context.Context, tool, path string) (tools.OutsideWorkspaceDecision, error) {
+	c.out.emit(headlessEvent{Type: "permission_denied", Tool: tool, Details: "outside the workspace: " + path})
+	return tools.OutsideWorkspaceDeny, nil
+}
+
+// headlessUserAsker emits the model's questions as events; the next input
+// line answers it instead of being queued
+type headlessUserAsker struct {
+	out     *headlessOutput
+	mu      sync.Mutex
+	waiting chan string
+}
+
+func (a *headlessUserAsker) AskUser(ctx context.Context, question string, options []string) (string, error) {
+	answer := make(chan string, 1)
+	a.mu.Lock()
+	a.waiting = answer
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		a.waiting = nil
+		a.mu.Unlock()
+	}()
+
+	a.out.emit(headlessEvent{Type: "question", Content: question, Options: options})
+	select {
+	case line := <-answer:
+		return pickOption(line, options), nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// answer hands line to a waiting question and reports whether there was one
+func (a *headlessUserAsker) answer(line string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.waiting == nil {
+		return false
+	}
+	a.waiting <- line
+	a.waiting = nil
+	return true
+}
+
+// headlessCommandExecutor runs commands without echoing them, keeping stdout
+// for events
+type headlessCommandExecutor struct{}
+
+func (e *headlessCommandExecutor) Execute(ctx context.Context, command string) (output string, exitCode int, err error) {
+	cmd := exec.CommandContext(ctx, "bash", "-c", command)
+	cmd.Dir = tools.WorkDir(ctx)
+	out, err := cmd.CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exitCode = exitErr.ExitCode()
+	}
+	return string(out), exitCode, err
+}
+
+// RunHeadless is for scripts: every stdin line is a message or slash command
+// and everything on stdout is a JSON event, including progress checkpoints.
+// A line reading /abort while a turn runs stops that turn.
+func RunHeadless(ctx context.Context, client *ollama.Client, cfg *config.Config, toolRegistry *tools.Registry) error {
+	model := cfg.DefaultModel
+	if model == "" {
+		return fmt.Errorf("no default model configured. Specify one with --model")
+	}
+
+	store, err := storage.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer store.Close()
+
+	out := newHeadlessOutput(os.Stdout)
+	input := newPlainInput(os.Stdin)
+	ag := newChatAgent(client, cfg, toolRegistry, model)
+	saver := newAutosaver(ag, model, store, newSessionTitler(client, cfg), newSessionSummarizer(client, cfg))
+	m := &chatModel{
+		agent:                ag,
+		ctx:                  ctx,
+		commands:             newCommandRegistry(client, cfg, toolRegistry),
+		sessionDisabledTools: make(map[string]bool),
+		ctrl:                 newChatController(),
+		autosave:             saver,
+		store:                store,
+	}
+	toolRegistry.SetPermissionChecker(&headlessPermissionChecker{out: out})
+	setCommandExecutor(toolRegistry, &headlessCommandExecutor{})
+	asker := &headlessUserAsker{out: out}
+	toolRegistry.SetUserAsker(asker)
+
+	out.emit(headlessEvent{Type: "ready", Model: model, Session: saver.sessionID()})
+
+	var queue []string
+	for {
+		var line string
+		if len(queue) > 0 {
+			line, queue = queue[0], queue[1:]
+		} else {
+			var ok bool
+			if line, ok = input.readLine(ctx); !ok {
+				break
+			}
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || line == headlessAbort {
+			continue
+		}
+		if line == "/quit" || line == "/exit" {
+			break
+		}
+
+		if result, isCmd, err := m.commands.Execute(ctx, line, m); isCmd {
+			if err != nil {
+				out.emit(headlessEvent{Type: "error", Error: err.Error()})
+			} else {
+				out.emit(headlessEvent{Type: "command", Content: result})
+			}
+			continue
+		}
+
+		turnCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			runHeadlessTurn(turnCtx, out, m.agent, store, line)
+		}()
+		// Keep reading while the turn runs, so it can be aborted
+		lines := input.lines
+	wait:
+		for {
+			select {
+			case <-done:
+				break wait
+			case next, ok := <-lines:
+				if !ok {
+					lines = nil
+					continue
+				}
+				if strings.TrimSpace(next) == headlessAbort {
+					cancel()
+				} else if !asker.answer(next) {
+					queue = append(queue, next)
+				}
+			}
+		}
+		cancel()
+		saver.save()
+		if lines == nil && len(queue) == 0 {
+			break
+		}
+	}
+
+	if ctx.Err() != nil {
+		saver.save()
+		return ctx.Err()
+	}
+	if err := session.ClearRecovery(); err != nil {
+		logger.Log("RunHeadless: failed to clear recovery file: %v", err)
+	}
+	return nil
+}
+
+// runHeadlessTurn sends one message, reporting progress as it goes and the
+// reply at the end
+func runHeadlessTurn(ctx context.Context, out *headlessOutput, ag *agent.Agent, store storage.Store, userMsg string) {
+	progress := agent.NewProgressTracker(ag, func(cp agent.Checkpoint) {
+		out.emit(headlessEvent{Type: "progress", Progress: &cp})
+	})
+	ag.SetToolObserver(auditObserver(store, progress.Observe(nil)))
+
+	started := time.Now()
+	resp, err := ag.ChatStream(ctx, userMsg, progress.Stream(nil))
+	progress.Finish(err)
+	if err != nil {
+		if ctx.Err() == context.Canceled {
+			err = fmt.Errorf("aborted")
+		}
+		out.emit(headlessEvent{Type: "error", Error: err.Error()})
+		return
+	}
+	recordUsage(store, ag.Model(), time.Since(started), resp)
+	out.emit(headlessEvent{
+		Type:             "reply",
+		Content:          resp.Content,
+		Meta:             resp.Meta,
+		PromptTokens:     resp.PromptTokens,
+		CompletionTokens: resp.CompletionTokens,
+	})
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/agent"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/quick"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxHighlightBytes keeps huge results from slowing down the transcript
+const maxHighlightBytes = 256 * 1024
+
+// codeStyles are the highlighting styles for the chat themes
+var codeStyles = map[string]string{"dark": "monokai", "light": "github", "dracula": "dracula"}
+
+// codeStyle picks the highlighting style for theme; ascii gets none
+func codeStyle(theme string) string {
+	switch style := markdownStyle(theme); style {
+	case "ascii":
+		return ""
+	case "auto":
+		if lipgloss.HasDarkBackground() {
+			return codeStyles["dark"]
+		}
+		return codeStyles["light"]
+	default:
+		return codeStyles[style]
+	}
+}
+
+// fileCommands print a file given as their last argument
+var fileCommands = map[string]bool{"cat": true, "head": true, "tail": true, "bat": true, "less": true, "more": true, "nl": true}
+
+// interpreters maps shebang interpreters chroma doesn't know by name
+var interpreters = map[string]string{"node": "javascript", "nodejs": "javascript", "deno": "typescript", "env": ""}
+
+// resultLanguage guesses the language of a tool result: from the file name
+// for read_file and commands that print one file, then from a shebang, then
+// whether it looks like a diff. Empty means plain text.
+func resultLanguage(tc agent.ToolExecution) string {
+	if tc.Error != nil || tc.Result == "" || len(tc.Result) > maxHighlightBytes {
+		return ""
+	}
+	var path string
+	switch tc.Name {
+	case "read_file":
+		path, _ = tc.Args["path"].(string)
+	case "run_command":
+		command, _ := tc.Args["command"].(string)
+		path = printedFile(command)
+	}
+	if path != "" {
+		if lexer := lexers.Match(filepath.Base(path)); lexer != nil {
+			return lexer.Config().Name
+		}
+	}
+	if lang := shebangLanguage(tc.Result); lang != "" {
+		return lang
+	}
+	if strings.HasPrefix(tc.Result, "diff --git ") || strings.HasPrefix(tc.Result, "--- ") && strings.Contains(tc.Result, "\n+++ ") {
+		return "diff"
+	}
+	return ""
+}
+
+// printedFile returns the file a simple command like "cat main.go" prints
+func printedFile(command string) string {
+	if strings.ContainsAny(command, "|;&<>$`") {
+		return ""
+	}
+	fields := strings.Fields(command)
+	if len(fields) < 2 || !fileCommands[filepath.Base(fields[0])] {
+		return ""
+	}
+	last := fields[len(fields)-1]
+	if strings.HasPrefix(last, "-") {
+		return ""
+	}
+	return strings.Trim(last, `"'`)
+}
+
+// shebangLanguage reads the language from a "#!" first line
+func shebangLanguage(text string) string {
+	if !strings.HasPrefix(text, "#!") {
+		return ""
+	}
+	line, _, _ := strings.Cut(text[2:], "\n")
+	fields := strings.Fields(line)
+	for len(fields) > 0 {
+		name := filepath.Base(fields[0])
+		fields = fields[1:]
+		if mapped, ok := interpreters[name]; ok {
+			if mapped == "" {
+				continue // env: the interpreter is the next word
+			}
+			name = mapped
+		}
+		if strings.HasPrefix(name, "-") {
+			continue
+		}
+		// python3.12 → python3
+		if i := strings.IndexByte(name, '.'); i > 0 {
+			name = name[:i]
+		}
+		if lexer := lexers.Get(name); lexer != nil {
+			return lexer.Config().Name
+		}
+		return ""
+	}
+	return ""
+}
+
+// highlightCode colours code for the terminal, or returns it unchanged
+func highlightCode(code, lang, style string) string {
+	var sb strings.Builder
+	if err := quick.Highlight(&sb, code, lang, "terminal256", style); err != nil {
+		return code
+	}
+	return sb.String()
+}
+
+// newToolMessage shows a tool call in the transcript. The result's code is
+// highlighted for display only; the model sees the raw text.
+func (m *chatModel) newToolMessage(tc agent.ToolExecution) message {
+	msg := message{role: "tool", content: agent.FormatToolCall(tc)}
+	if m.codeStyle == "" {
+		return msg
+	}
+	lang := resultLanguage(tc)
+	if lang == "" {
+		return msg
+	}
+	// FormatToolCall ends with the result and a newline
+	start := len(msg.content) - len(tc.Result) - 1
+	if start < 0 || msg.content[start:] != tc.Result+"\n" {
+		return msg
+	}
+	msg.codeStart = start
+	msg.highlighted = highlightCode(strings.TrimSuffix(tc.Result, "\n"), lang, m.codeStyle)
+	return msg
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/storage"
+)
+
+// searchResultLimit is how many hits /search-history lists
+const searchResultLimit = 10
+
+// SearchHistoryCommand searches stored sessions and remembers the last
+// results so one can be opened or imported by number
+type SearchHistoryCommand struct {
+	hits []storage.SearchHit
+}
+
+func NewSearchHistoryCommand() *SearchHistoryCommand {
+	return &SearchHistoryCommand{}
+}
+
+func (c *SearchHistoryCommand) Name() string {
+	return "search-history"
+}
+
+func (c *SearchHistoryCommand) Description() string {
+	return "Search past conversations and tool results (usage: /search-history <query> | open <n> | import <n>)"
+}
+
+func (c *SearchHistoryCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("usage: /search-history <query> | open <n> | import <n>")
+	}
+	if m.store == nil {
+		return "", fmt.Errorf("no session storage available")
+	}
+
+	if len(args) == 2 && (args[0] == "open" || args[0] == "import") {
+		if n, err := strconv.Atoi(args[1]); err == nil {
+			return c.useHit(m, args[0], n)
+		}
+	}
+
+	query := strings.Join(args, " ")
+	hits, err := m.store.SearchSessions(query, searchResultLimit)
+	if err != nil {
+		return "", err
+	}
+	c.hits = hits
+	if len(hits) == 0 {
+		return fmt.Sprintf("No past messages match %q", query), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔎 %d matches for %q:\n", len(hits), query))
+	for i, hit := range hits {
+		sb.WriteString(fmt.Sprintf("\n%d. %s · %s (%s) · %s\n   %s\n",
+			i+1, hit.Session.Updated.Format("2006-01-02 15:04"), hit.Session.Title, hit.Session.Model, hit.Role, hit.Snippet))
+	}
+	sb.WriteString("\nShow one with /search-history open <n>, or add it to this conversation with /search-history import <n>")
+	return sb.String(), nil
+}
+
+// useHit shows the exchange around a hit or adds it to the agent's context
+func (c *SearchHistoryCommand) useHit(m *chatModel, action string, n int) (string, error) {
+	if n < 1 || n > len(c.hits) {
+		return "", fmt.Errorf("no result %d; search first with /search-history <query>", n)
+	}
+	hit := c.hits[n-1]
+	session, err := m.store.LoadSession(hit.Session.ID)
+	if err != nil {
+		return "", err
+	}
+	msgs := exchangeAround(session.Messages, hit.Index)
+	text := formatExchange(msgs)
+
+	if action == "open" {
+		return fmt.Sprintf("📜 %s · %s (%s)\n\n%s", session.Updated.Format("2006-01-02 15:04"), session.Title, session.Model, text), nil
+	}
+	m.agent.AddContext(fmt.Sprintf("The user imported this exchange from an earlier conversation (%s, %s) for reference:\n\n%s",
+		session.Updated.Format("2006-01-02"), session.Title, text))
+	return fmt.Sprintf("✓ Added %d messages from %q to the context", len(msgs), session.Title), nil
+}
+
+// exchangeAround returns the user message before index up to the next user
+// message, so a hit comes with the question and the answer it belongs to
+func exchangeAround(msgs []ollama.Message, index int) []ollama.Message {
+	if index < 0 || index >= len(msgs) {
+		return nil
+	}
+	start := index
+	for start > 0 && msgs[start].Role != "user" {
+		start--
+	}
+	end := index + 1
+	for end < len(msgs) && msgs[end].Role != "user" {
+		end++
+	}
+	return msgs[start:end]
+}
+
+func formatExchange(msgs []ollama.Message) string {
+	var sb strings.Builder
+	for _, msg := range msgs {
+		switch msg.Role {
+		case "user":
+			sb.WriteString("👤 User: " + msg.Content + "\n\n")
+		case "assistant":
+			if msg.Content != "" {
+				sb.WriteString("🤖 Assistant: " + msg.Content + "\n\n")
+			}
+		case "tool":
+			sb.WriteString(fmt.Sprintf("🔧 Tool %s:\n%s\n\n", msg.ToolName, msg.Content))
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
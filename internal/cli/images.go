@@ -0,0 +1,275 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/agent"
+	"golang.org/x/term"
+)
+
+// Terminal image protocols
+const (
+	imageKitty = "kitty"
+	imageITerm = "iterm2"
+	imageSixel = "sixel"
+)
+
+// maxImageBytes keeps huge files from being decoded for display
+const maxImageBytes = 20 * 1024 * 1024
+
+// imagePath matches paths to image files in tool arguments and results
+var imagePath = regexp.MustCompile(`(?i)[\w~./-]*[\w-]\.(?:png|jpe?g|gif)\b`)
+
+// imageProtocol picks how images are drawn: the configured protocol, or for
+// auto one the terminal is known to support. Empty means none.
+func imageProtocol(setting string) string {
+	switch setting {
+	case imageKitty, imageITerm, imageSixel:
+		return setting
+	case "off":
+		return ""
+	}
+	term := os.Getenv("TERM")
+	program := os.Getenv("TERM_PROGRAM")
+	switch {
+	case os.Getenv("TMUX") != "" || strings.HasPrefix(term, "screen") || strings.HasPrefix(term, "tmux"):
+		return "" // Multiplexers swallow the escape sequences
+	case os.Getenv("KITTY_WINDOW_ID") != "" || term == "xterm-kitty" || program == "ghostty":
+		return imageKitty
+	case program == "iTerm.app" || program == "WezTerm":
+		return imageITerm
+	case strings.HasPrefix(term, "foot") || term == "mlterm" || term == "contour" || strings.Contains(term, "sixel"):
+		return imageSixel
+	}
+	return ""
+}
+
+// producedImages returns the image files a tool call made: paths in its
+// arguments or result that were written while it ran, e.g. a plot
+func producedImages(tc agent.ToolExecution) []string {
+	if tc.Error != nil || tc.Started.IsZero() {
+		return nil
+	}
+	args, _ := json.Marshal(tc.Args)
+	seen := make(map[string]bool)
+	var paths []string
+	for _, match := range imagePath.FindAllString(string(args)+"\n"+tc.Result, -1) {
+		path := expandHome(match)
+		if !filepath.IsAbs(path) {
+			if abs, err := filepath.Abs(path); err == nil {
+				path = abs
+			}
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		info, err := os.Stat(path)
+		// Modification times can be a little coarser than the clock
+		if err != nil || info.IsDir() || info.Size() > maxImageBytes || info.ModTime().Before(tc.Started.Add(-time.Second)) {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// imageSummary describes an image file for placeholders: its size in pixels,
+// format and file size
+func imageSummary(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open image: %w", err)
+	}
+	defer f.Close()
+	cfg, format, err := image.DecodeConfig(f)
+	if err != nil {
+		return "", fmt.Errorf("read image: %w", err)
+	}
+	size := ""
+	if info, err := f.Stat(); err == nil {
+		size = fmt.Sprintf(", %d KB", (info.Size()+1023)/1024)
+	}
+	return fmt.Sprintf("%d×%d %s%s", cfg.Width, cfg.Height, strings.ToUpper(format), size), nil
+}
+
+// terminalColumns returns the width of the terminal on stdout
+func terminalColumns() int {
+	if cols, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && cols > 0 {
+		return cols
+	}
+	return 80
+}
+
+// writeImage draws the image at path with protocol, at most cols cells wide
+func writeImage(w io.Writer, path, protocol string, cols int) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read image: %w", err)
+	}
+	if len(data) > maxImageBytes {
+		return fmt.Errorf("%s is too large to show", filepath.Base(path))
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("read image: %w", err)
+	}
+	// Roughly 8 pixels to a cell; only shrink, never blow images up
+	maxWidth := cols * 8
+	bw := bufio.NewWriter(w)
+
+	switch protocol {
+	case imageITerm:
+		width := "auto"
+		if cfg.Width > maxWidth {
+			width = fmt.Sprint(cols)
+		}
+		fmt.Fprintf(bw, "\x1b]1337;File=inline=1;size=%d;width=%s;preserveAspectRatio=1:%s\a",
+			len(data), width, base64.StdEncoding.EncodeToString(data))
+	case imageKitty:
+		if format != "png" {
+			img, _, err := image.Decode(bytes.NewReader(data))
+			if err != nil {
+				return fmt.Errorf("decode image: %w", err)
+			}
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, img); err != nil {
+				return fmt.Errorf("encode image: %w", err)
+			}
+			data = buf.Bytes()
+		}
+		writeKitty(bw, data, cfg.Width > maxWidth, cols)
+	case imageSixel:
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("decode image: %w", err)
+		}
+		writeSixel(bw, img, maxWidth)
+	default:
+		return fmt.Errorf("this terminal can't show images")
+	}
+	bw.WriteString("\n")
+	return bw.Flush()
+}
+
+// writeKitty sends a PNG with the kitty graphics protocol, in the chunks
+// it requires
+func writeKitty(w *bufio.Writer, data []byte, fit bool, cols int) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	const chunk = 4096
+	for i := 0; i < len(encoded); i += chunk {
+		end := min(i+chunk, len(encoded))
+		more := 0
+		if end < len(encoded) {
+			more = 1
+		}
+		if i == 0 {
+			control := "a=T,f=100,q=2"
+			if fit {
+				control += fmt.Sprintf(",c=%d", cols)
+			}
+			fmt.Fprintf(w, "\x1b_G%s,m=%d;%s\x1b\\", control, more, encoded[i:end])
+		} else {
+			fmt.Fprintf(w, "\x1b_Gm=%d;%s\x1b\\", more, encoded[i:end])
+		}
+	}
+}
+
+// writeSixel draws img as sixels, scaled to maxWidth pixels at most and
+// dithered to the 216 web-safe colours
+func writeSixel(w *bufio.Writer, img image.Image, maxWidth int) {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width > maxWidth {
+		height = max(height*maxWidth/width, 1)
+		width = maxWidth
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			scaled.Set(x, y, img.At(b.Min.X+x*b.Dx()/width, b.Min.Y+y*b.Dy()/height))
+		}
+	}
+	paletted := image.NewPaletted(scaled.Rect, palette.WebSafe)
+	draw.FloydSteinberg.Draw(paletted, paletted.Rect, scaled, image.Point{})
+
+	fmt.Fprintf(w, "\x1bP0;1;0q\"1;1;%d;%d", width, height)
+	for i, c := range paletted.Palette {
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(w, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+	for top := 0; top < height; top += 6 {
+		// Each colour in the band is one pass over its six rows
+		used := make(map[uint8]bool)
+		var colors []uint8
+		for y := top; y < min(top+6, height); y++ {
+			for x := 0; x < width; x++ {
+				if c := paletted.ColorIndexAt(x, y); !used[c] {
+					used[c] = true
+					colors = append(colors, c)
+				}
+			}
+		}
+		for _, c := range colors {
+			fmt.Fprintf(w, "#%d", c)
+			var last byte
+			run := 0
+			for x := 0; x <= width; x++ {
+				var ch byte
+				if x < width {
+					bits := 0
+					for dy := 0; dy < 6 && top+dy < height; dy++ {
+						if paletted.ColorIndexAt(x, top+dy) == c {
+							bits |= 1 << dy
+						}
+					}
+					ch = byte(63 + bits)
+				}
+				if ch == last && x < width {
+					run++
+					continue
+				}
+				writeSixelRun(w, last, run)
+				last, run = ch, 1
+			}
+			w.WriteByte('$')
+		}
+		w.WriteByte('-')
+	}
+	w.WriteString("\x1b\\")
+}
+
+func writeSixelRun(w *bufio.Writer, ch byte, run int) {
+	switch {
+	case run == 0:
+	case run > 3:
+		fmt.Fprintf(w, "!%d%c", run, ch)
+	default:
+		w.Write(bytes.Repeat([]byte{ch}, run))
+	}
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+
+	"github.com/LaPingvino/llemecode/internal/i18n"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// showImageMsg asks the chat to show an image outside the alternate screen,
+// whose redraws would wipe it
+type showImageMsg struct {
+	path string
+}
+
+type imageShownMsg struct {
+	err error
+}
+
+// addImages puts a placeholder for each image in the transcript; /image
+// shows them when the terminal can
+func (m *chatModel) addImages(paths []string) {
+	for _, path := range paths {
+		summary, err := imageSummary(path)
+		if err != nil {
+			continue // Not an image after all
+		}
+		m.images = append(m.images, path)
+		content := i18n.T("image.placeholder", path, summary)
+		if m.imageProtocol != "" {
+			content = i18n.T("image.placeholder_view", path, summary, len(m.images))
+		}
+		m.messages = append(m.messages, message{role: "system", content: content})
+	}
+}
+
+// imageViewer draws one image on the normal screen and waits for Enter
+type imageViewer struct {
+	path     string
+	protocol string
+	stdin    io.Reader
+	stdout   io.Writer
+}
+
+func (v *imageViewer) SetStdin(r io.Reader)  { v.stdin = r }
+func (v *imageViewer) SetStdout(w io.Writer) { v.stdout = w }
+func (v *imageViewer) SetStderr(io.Writer)   {}
+
+func (v *imageViewer) Run() error {
+	fmt.Fprint(v.stdout, "\x1b[2J\x1b[H"+v.path+"\n\n")
+	if err := writeImage(v.stdout, v.path, v.protocol, terminalColumns()); err != nil {
+		return err
+	}
+	fmt.Fprint(v.stdout, "\n"+i18n.T("image.return"))
+	_, err := bufio.NewReader(v.stdin).ReadString('\n')
+	return err
+}
+
+// viewImage shows an image, then returns to the chat
+func (m *chatModel) viewImage(path string) tea.Cmd {
+	return tea.Exec(&imageViewer{path: path, protocol: m.imageProtocol}, func(err error) tea.Msg {
+		return imageShownMsg{err: err}
+	})
+}
+
+// ImageCommand shows the images tools produced this session
+type ImageCommand struct{}
+
+func NewImageCommand() *ImageCommand {
+	return &ImageCommand{}
+}
+
+func (c *ImageCommand) Name() string {
+	return "image"
+}
+
+func (c *ImageCommand) Description() string {
+	return "Show an image a tool produced, the latest by default (usage: /image [<n>])"
+}
+
+func (c *ImageCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	if len(m.images) == 0 {
+		return "🖼️ No images this session", nil
+	}
+	n := len(m.images)
+	if len(args) > 0 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 || n > len(m.images) {
+			return "", fmt.Errorf("no image %s (there are %d)", args[0], len(m.images))
+		}
+	}
+	path := m.images[n-1]
+	if m.imageProtocol == "" {
+		return "", fmt.Errorf("this terminal can't show images (set \"images\" in the config to kitty, iterm2 or sixel if it can); the file is %s", path)
+	}
+	p := m.ctrl.currentProgram()
+	if p == nil {
+		return "", fmt.Errorf("images can only be shown in the interactive chat; the file is %s", path)
+	}
+	go p.Send(showImageMsg{path: path})
+	return fmt.Sprintf("🖼️ Showing %s", filepath.Base(path)), nil
+}
@@ -0,0 +1,273 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// handshakeTimeout leaves time for the ACP server to check its Ollama
+// servers before it answers
+const handshakeTimeout = 30 * time.Second
+
+// Integration is how an editor should start llemecode as its ACP agent
+type Integration struct {
+	Command string
+	Args    []string
+	Env     map[string]string
+	Write   bool // Write the configuration into the editor's config directory
+}
+
+// RunIntegrate prints the agent configuration for editor (zed or nvim),
+// checks that the command answers an ACP handshake, and writes the
+// configuration when asked to
+func RunIntegrate(ctx context.Context, w io.Writer, editor string, in Integration) error {
+	var snippet, path string
+	var write func(path string) error
+	switch editor {
+	case "zed":
+		path = zedSettingsPath()
+		snippet = zedSnippet(in, path)
+		write = func(path string) error { return writeZedSettings(path, in) }
+	case "nvim", "neovim":
+		snippet = nvimSnippet(in)
+		path = nvimModulePath()
+		write = func(path string) error { return writeNvimModule(path, snippet) }
+	default:
+		return fmt.Errorf("unknown editor %q (try zed or nvim)", editor)
+	}
+
+	fmt.Fprintln(w, snippet)
+	fmt.Fprintf(w, "Checking %s %s ...\n", in.Command, strings.Join(in.Args, " "))
+	info, err := acpHandshake(ctx, in)
+	if err != nil {
+		return fmt.Errorf("ACP handshake failed, so the editor would not be able to start llemecode either: %w", err)
+	}
+	fmt.Fprintf(w, "✓ ACP handshake OK (%s)\n", info)
+
+	if !in.Write {
+		fmt.Fprintf(w, "\nRun again with --write to write this to %s\n", path)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(path), err)
+	}
+	if err := write(path); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "✓ Wrote %s\n", path)
+	if editor != "zed" {
+		fmt.Fprintln(w, `Add it to avante.nvim with: acp_providers = { llemecode = require("llemecode_acp") }`)
+	}
+	return nil
+}
+
+// zedAgentServer is the agent_servers entry Zed needs
+func zedAgentServer(in Integration) map[string]interface{} {
+	env := in.Env
+	if env == nil {
+		env = map[string]string{}
+	}
+	args := in.Args
+	if args == nil {
+		args = []string{}
+	}
+	return map[string]interface{}{"command": in.Command, "args": args, "env": env}
+}
+
+func zedSnippet(in Integration, path string) string {
+	block := map[string]interface{}{"agent_servers": map[string]interface{}{"llemecode": zedAgentServer(in)}}
+	data, _ := json.MarshalIndent(block, "", "  ")
+	return "// Add to " + path + "\n" + string(data) + "\n"
+}
+
+func zedSettingsPath() string {
+	if runtime.GOOS == "windows" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			return filepath.Join(dir, "Zed", "settings.json")
+		}
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "zed", "settings.json")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "zed", "settings.json")
+}
+
+// writeZedSettings adds the agent server to Zed's settings, keeping a backup.
+// Settings with comments or trailing commas are left alone, since rewriting
+// them would lose the comments.
+func writeZedSettings(path string, in Integration) error {
+	settings := map[string]interface{}{}
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return fmt.Errorf("read %s: %w", path, err)
+	case len(bytes.TrimSpace(data)) > 0:
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return fmt.Errorf("%s is not plain JSON (it may have comments); add the snippet above by hand", path)
+		}
+		if err := os.WriteFile(path+".bak", data, 0644); err != nil {
+			return fmt.Errorf("back up %s: %w", path, err)
+		}
+	}
+
+	servers, _ := settings["agent_servers"].(map[string]interface{})
+	if servers == nil {
+		servers = map[string]interface{}{}
+	}
+	servers["llemecode"] = zedAgentServer(in)
+	settings["agent_servers"] = servers
+
+	out, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode settings: %w", err)
+	}
+	if err := os.WriteFile(path, append(out, '\n'), 0644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeNvimModule writes the Lua module, backing up a file already there
+// unless it is the same
+func writeNvimModule(path, snippet string) error {
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return fmt.Errorf("read %s: %w", path, err)
+	case string(data) != snippet:
+		if err := os.WriteFile(path+".bak", data, 0644); err != nil {
+			return fmt.Errorf("back up %s: %w", path, err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(snippet), 0644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
+func nvimSnippet(in Integration) string {
+	var sb strings.Builder
+	sb.WriteString("-- Generated by llemecode integrate nvim. Use it as an ACP provider, e.g. in avante.nvim:\n")
+	sb.WriteString("--   acp_providers = { llemecode = require(\"llemecode_acp\") }\n")
+	sb.WriteString("return {\n")
+	sb.WriteString("  command = " + strconv.Quote(in.Command) + ",\n")
+	quoted := make([]string, len(in.Args))
+	for i, arg := range in.Args {
+		quoted[i] = strconv.Quote(arg)
+	}
+	sb.WriteString("  args = { " + strings.Join(quoted, ", ") + " },\n")
+	sb.WriteString("  env = {")
+	keys := make([]string, 0, len(in.Env))
+	for key := range in.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		sb.WriteString(fmt.Sprintf(" %s = %s,", key, strconv.Quote(in.Env[key])))
+	}
+	if len(keys) > 0 {
+		sb.WriteString(" ")
+	}
+	sb.WriteString("},\n}\n")
+	return sb.String()
+}
+
+func nvimModulePath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	switch {
+	case dir != "":
+		dir = filepath.Join(dir, "nvim")
+	case runtime.GOOS == "windows":
+		dir = filepath.Join(os.Getenv("LOCALAPPDATA"), "nvim")
+	default:
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config", "nvim")
+	}
+	return filepath.Join(dir, "lua", "llemecode_acp.lua")
+}
+
+// acpHandshake starts the agent the way the editor would and sends it an
+// initialize request, returning the server name and version
+func acpHandshake(ctx context.Context, in Integration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, handshakeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, in.Command, in.Args...)
+	cmd.Env = os.Environ()
+	for key, value := range in.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return "", err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("start %s: %w", in.Command, err)
+	}
+	// Wait may only be called once, here or after the server exits
+	wait := sync.OnceValue(cmd.Wait)
+	defer func() {
+		stdin.Close()
+		cmd.Process.Kill()
+		wait()
+	}()
+
+	if _, err := io.WriteString(stdin, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`+"\n"); err != nil {
+		return "", fmt.Errorf("send initialize: %w", err)
+	}
+
+	// The server may print other lines (e.g. MCP connection notes) first
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		var resp struct {
+			ID     interface{} `json:"id"`
+			Result struct {
+				ServerInfo struct {
+					Name    string `json:"name"`
+					Version string `json:"version"`
+				} `json:"serverInfo"`
+			} `json:"result"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &resp) != nil || resp.ID == nil {
+			continue
+		}
+		if resp.Error != nil {
+			return "", fmt.Errorf("initialize: %s", resp.Error.Message)
+		}
+		return resp.Result.ServerInfo.Name + " " + resp.Result.ServerInfo.Version, nil
+	}
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("no answer within %s", handshakeTimeout)
+	}
+	// Wait for stderr to be copied; its last line says why the server stopped
+	wait()
+	if lines := strings.Split(strings.TrimSpace(stderr.String()), "\n"); lines[len(lines)-1] != "" {
+		return "", fmt.Errorf("server exited: %s", lines[len(lines)-1])
+	}
+	return "", fmt.Errorf("server exited without answering")
+}
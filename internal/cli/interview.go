@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/LaPingvino/llemecode/internal/agent"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+)
+
+const interviewUsage = "usage: /interview [-n rounds] <model>[=role] <model>[=role] <task> | /interview stop"
+
+// defaultInterviewRoles are used for speakers given without a role
+var defaultInterviewRoles = [2]string{"designer", "critic"}
+
+// interviewDoneMsg carries a finished (or failed) interview back to the chat
+type interviewDoneMsg struct {
+	task   string
+	result *agent.InterviewResult
+	err    error
+}
+
+// InterviewCommand has two models discuss a task while the current model
+// moderates, streaming the turns into the transcript
+type InterviewCommand struct {
+	client *ollama.Client
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // Stops the running interview; nil when idle
+}
+
+func NewInterviewCommand(client *ollama.Client) *InterviewCommand {
+	return &InterviewCommand{client: client}
+}
+
+func (c *InterviewCommand) Name() string {
+	return "interview"
+}
+
+func (c *InterviewCommand) Description() string {
+	return "Let two models discuss a task in turns, e.g. designer vs critic, then summarize what they agree on (" + interviewUsage + ")"
+}
+
+func (c *InterviewCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	if len(args) == 1 && args[0] == "stop" {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.cancel == nil {
+			return "No interview is running", nil
+		}
+		c.cancel()
+		return "⏹ Stopping the interview", nil
+	}
+
+	opts, err := parseInterviewArgs(args)
+	if err != nil {
+		return "", err
+	}
+	if err := c.checkModels(ctx, opts); err != nil {
+		return "", err
+	}
+	p := m.ctrl.currentProgram()
+	if p == nil {
+		return "", fmt.Errorf("interviews need the interactive chat")
+	}
+
+	c.mu.Lock()
+	if c.cancel != nil {
+		c.mu.Unlock()
+		return "", fmt.Errorf("an interview is already running (/interview stop ends it)")
+	}
+	interviewCtx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+	c.mu.Unlock()
+
+	ag := m.agent
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.cancel()
+			c.cancel = nil
+			c.mu.Unlock()
+		}()
+		result, err := ag.Interview(interviewCtx, opts, newInlineModelProgress(p))
+		p.Send(interviewDoneMsg{task: opts.Task, result: result, err: err})
+	}()
+
+	a, b := opts.Speakers[0], opts.Speakers[1]
+	return fmt.Sprintf("🎙 Interview started: %s (%s) and %s (%s), %d rounds, moderated by %s", a.Model, a.Role, b.Model, b.Role, opts.Rounds, ag.Model()), nil
+}
+
+// checkModels makes sure both speakers are installed, when the server can
+// tell; a typo should not cost a round of the other model's time
+func (c *InterviewCommand) checkModels(ctx context.Context, opts agent.InterviewOptions) error {
+	models, err := c.client.ListModels(ctx)
+	if err != nil {
+		return nil
+	}
+	installed := make(map[string]bool, len(models))
+	for _, model := range models {
+		installed[model.Name] = true
+	}
+	for _, speaker := range opts.Speakers {
+		if !installed[speaker.Model] && !installed[speaker.Model+":latest"] {
+			return fmt.Errorf("model %s is not installed (/models lists models)", speaker.Model)
+		}
+	}
+	return nil
+}
+
+func parseInterviewArgs(args []string) (agent.InterviewOptions, error) {
+	opts := agent.InterviewOptions{Rounds: agent.DefaultInterviewRounds}
+	if len(args) >= 2 && (args[0] == "-n" || args[0] == "--rounds") {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 || n > 20 {
+			return opts, fmt.Errorf("rounds must be a number from 1 to 20")
+		}
+		opts.Rounds = n
+		args = args[2:]
+	}
+	if len(args) < 3 {
+		return opts, fmt.Errorf(interviewUsage)
+	}
+	for i := range opts.Speakers {
+		model, role, _ := strings.Cut(args[i], "=")
+		if model == "" {
+			return opts, fmt.Errorf(interviewUsage)
+		}
+		if role == "" {
+			role = defaultInterviewRoles[i]
+		}
+		opts.Speakers[i] = agent.InterviewSpeaker{Model: model, Role: strings.ReplaceAll(role, "_", " ")}
+	}
+	if opts.Speakers[0].Role == opts.Speakers[1].Role {
+		return opts, fmt.Errorf("give the speakers different roles, e.g. %s=designer %s=critic", opts.Speakers[0].Model, opts.Speakers[1].Model)
+	}
+	opts.Task = strings.Join(args[2:], " ")
+	return opts, nil
+}
+
+// formatInterview renders the full exchange for the transcript
+func formatInterview(task string, result *agent.InterviewResult) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## 🎙 Interview: %s\n\n", task))
+	for _, turn := range result.Turns {
+		sb.WriteString(fmt.Sprintf("### %s (%s), round %d\n\n%s\n\n", turn.Speaker.Role, turn.Speaker.Model, turn.Round, turn.Content))
+	}
+	if result.Summary != "" {
+		sb.WriteString("### Consensus\n\n" + result.Summary)
+	}
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/i18n"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"golang.org/x/sys/unix"
+)
+
+// memoryHeadroom covers the context cache and runtime on top of the weights
+const memoryHeadroom = 1.2
+
+// modelFit compares what a model needs with the memory free on this machine
+type modelFit struct {
+	model        string
+	need         int64    // Estimated bytes to load the model
+	available    int64    // Free RAM plus free VRAM
+	reclaimable  int64    // Held by other loaded models
+	loaded       []string // The other loaded models
+	alternatives []string // Installed models that fit, best first
+}
+
+// checkModelFit returns nil when model fits or when it can't tell, such as
+// for a server on another machine
+func checkModelFit(ctx context.Context, client *ollama.Client, cfg *config.Config, model string) *modelFit {
+	if cfg.SSHTunnel != nil || !isLoopback(client.HostFor(model)) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	models, err := client.ListModels(ctx)
+	if err != nil {
+		return nil
+	}
+	fit := &modelFit{model: model}
+	for _, info := range models {
+		if info.Name == model {
+			fit.need = int64(float64(info.Size) * memoryHeadroom)
+		}
+	}
+	available, ok := availableMemory(ctx)
+	if fit.need == 0 || !ok {
+		return nil
+	}
+	fit.available = available
+
+	// Memory of loaded models is already taken out of what is available
+	running, _ := client.RunningModels(ctx)
+	for _, r := range running {
+		if r.Name == model {
+			return nil
+		}
+		fit.reclaimable += r.Size
+		fit.loaded = append(fit.loaded, r.Name)
+	}
+	if fit.need <= fit.available {
+		return nil
+	}
+
+	// The same model in another size or quantization first, then the largest
+	family := strings.SplitN(model, ":", 2)[0]
+	var fitting []ollama.ModelInfo
+	for _, info := range models {
+		if info.Name != model && int64(float64(info.Size)*memoryHeadroom) <= fit.available {
+			fitting = append(fitting, info)
+		}
+	}
+	sort.SliceStable(fitting, func(i, j int) bool {
+		iSame := strings.SplitN(fitting[i].Name, ":", 2)[0] == family
+		jSame := strings.SplitN(fitting[j].Name, ":", 2)[0] == family
+		if iSame != jSame {
+			return iSame
+		}
+		return fitting[i].Size > fitting[j].Size
+	})
+	for i := 0; i < len(fitting) && i < 3; i++ {
+		fit.alternatives = append(fit.alternatives, fitting[i].Name)
+	}
+	return fit
+}
+
+// warning explains the shortfall and what to do about it
+func (f *modelFit) warning() string {
+	lines := []string{i18n.T("fit.warning", f.model, formatSize(f.need), formatSize(f.available))}
+	if f.reclaimable > 0 {
+		lines = append(lines, i18n.T("fit.unload", formatSize(f.reclaimable), strings.Join(f.loaded, ", ")))
+	}
+	if len(f.alternatives) > 0 {
+		lines = append(lines, i18n.T("fit.alternatives", strings.Join(f.alternatives, ", ")))
+	} else {
+		lines = append(lines, i18n.T("fit.quantize", f.model))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isLoopback(hostport string) bool {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// availableMemory returns the RAM the kernel can hand out without swapping
+// plus the free memory of NVIDIA GPUs, in bytes
+func availableMemory(ctx context.Context) (int64, bool) {
+	ram, ok := availableRAM()
+	if !ok {
+		return 0, false
+	}
+	return ram + freeVRAM(ctx), true
+}
+
+func availableRAM() (int64, bool) {
+	if f, err := os.Open("/proc/meminfo"); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) >= 2 && fields[0] == "MemAvailable:" {
+				if kb, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+					return kb * 1024, true
+				}
+			}
+		}
+	}
+
+	// Older kernels have no MemAvailable
+	var sysinfo unix.Sysinfo_t
+	if err := unix.Sysinfo(&sysinfo); err != nil {
+		return 0, false
+	}
+	return int64(sysinfo.Freeram+sysinfo.Bufferram) * int64(sysinfo.Unit), true
+}
+
+// freeVRAM asks nvidia-smi for free GPU memory; 0 without an NVIDIA GPU
+func freeVRAM(ctx context.Context) int64 {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "nvidia-smi", "--query-gpu=memory.free", "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return 0
+	}
+	var total int64
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if mib, err := strconv.ParseInt(strings.TrimSpace(line), 10, 64); err == nil {
+			total += mib * 1024 * 1024
+		}
+	}
+	return total
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/notes"
+)
+
+// MemoriesCommand reviews what the agent remembered about the project
+type MemoriesCommand struct {
+	notes *notes.Store
+}
+
+func NewMemoriesCommand(store *notes.Store) *MemoriesCommand {
+	return &MemoriesCommand{notes: store}
+}
+
+func (c *MemoriesCommand) Name() string {
+	return "memories"
+}
+
+func (c *MemoriesCommand) Description() string {
+	return "Review what the agent remembered about this project (usage: /memories [add <text>] [edit <n> <text>] [delete <n>] [clear])"
+}
+
+func (c *MemoriesCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	if len(args) == 0 {
+		return c.list()
+	}
+
+	switch args[0] {
+	case "add":
+		if len(args) < 2 {
+			return "", fmt.Errorf("usage: /memories add <text>")
+		}
+		added, err := c.notes.Add(strings.Join(args[1:], " "))
+		if err != nil {
+			return "", err
+		}
+		if !added {
+			return "Already remembered", nil
+		}
+		return "✓ Remembered", nil
+
+	case "edit":
+		if len(args) < 3 {
+			return "", fmt.Errorf("usage: /memories edit <n> <text>")
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			return "", fmt.Errorf("invalid number: %s", args[1])
+		}
+		if err := c.notes.Update(n-1, strings.Join(args[2:], " ")); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✓ Memory %d updated", n), nil
+
+	case "delete", "forget":
+		if len(args) != 2 {
+			return "", fmt.Errorf("usage: /memories delete <n>")
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			return "", fmt.Errorf("invalid number: %s", args[1])
+		}
+		if err := c.notes.Remove(n - 1); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✓ Memory %d forgotten", n), nil
+
+	case "clear":
+		all, err := c.notes.List()
+		if err != nil {
+			return "", err
+		}
+		for i := len(all) - 1; i >= 0; i-- {
+			if err := c.notes.Remove(i); err != nil {
+				return "", err
+			}
+		}
+		return fmt.Sprintf("✓ Forgot %d memories", len(all)), nil
+
+	default:
+		return "", fmt.Errorf("unknown option %q (usage: /memories [add <text>] [edit <n> <text>] [delete <n>] [clear])", args[0])
+	}
+}
+
+func (c *MemoriesCommand) list() (string, error) {
+	all, err := c.notes.List()
+	if err != nil {
+		return "", err
+	}
+	if len(all) == 0 {
+		return "Nothing remembered about this project yet. The agent adds facts with its remember tool, or add one with /memories add <text>", nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🧠 %d memories in %s:\n\n", len(all), c.notes.Path()))
+	for i, note := range all {
+		sb.WriteString(fmt.Sprintf("%d. %s", i+1, note.Text))
+		if note.Added != "" {
+			sb.WriteString(" (" + note.Added + ")")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\nEdit with /memories edit <n> <text>, forget with /memories delete <n>, or edit the file directly")
+	return sb.String(), nil
+}
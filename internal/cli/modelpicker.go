@@ -3,24 +3,52 @@ package cli
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"unicode"
 
+	"github.com/LaPingvino/llemecode/internal/benchmark"
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/i18n"
 	"github.com/LaPingvino/llemecode/internal/ollama"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
+// pickerSorts are the orders the picker cycles through with s. The first
+// is the server's order, or best match first while searching.
+var pickerSorts = []string{"default", "size", "score", "name"}
+
 type modelPickerModel struct {
-	models   []ollama.ModelInfo
-	cursor   int
-	selected int
-	done     bool
-	err      error
+	ctx       context.Context
+	client    *ollama.Client
+	models    []ollama.ModelInfo
+	caps      map[string]config.ModelCapability
+	scores    map[string]float64 // Latest benchmark score per model
+	ranks     map[string]int
+	described map[string]string // Benchmark descriptions of each model
+	current   string            // Model in use, marked in the list
+	maxRows   int               // Models shown at once around the cursor, 0 for all
+	visible   []int             // Indices into models after filtering and sorting
+	query     string
+	searching bool
+	sortBy    int
+	cursor    int
+	selected  int
+	done      bool
+	err       error
 }
 
 type modelSelectedMsg struct {
 	model string
 }
 
+// modelDetailsMsg carries server details for a model without saved capabilities
+type modelDetailsMsg struct {
+	model   string
+	details *ollama.ModelDetails
+}
+
 var (
 	selectedStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("205")).
@@ -28,9 +56,45 @@ var (
 
 	cursorStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("86"))
+
+	badgeStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("110"))
 )
 
-func RunModelPicker(ctx context.Context, client *ollama.Client) (string, error) {
+// newModelPicker lists models with the badges cfg and the latest benchmark
+// results know about
+func newModelPicker(ctx context.Context, client *ollama.Client, cfg *config.Config, models []ollama.ModelInfo) modelPickerModel {
+	m := modelPickerModel{
+		ctx:       ctx,
+		client:    client,
+		models:    models,
+		caps:      make(map[string]config.ModelCapability),
+		scores:    make(map[string]float64),
+		ranks:     make(map[string]int),
+		described: make(map[string]string),
+		selected:  -1,
+	}
+	for _, model := range models {
+		if cap, ok := cfg.GetCapability(model.Name); ok {
+			m.caps[model.Name] = cap
+			if cap.TotalScore > 0 {
+				m.scores[model.Name] = cap.TotalScore
+			}
+		}
+	}
+	// Scores are a nicety; a first run has none
+	if scores, _, err := benchmark.LoadLatestResults(); err == nil {
+		for _, score := range scores {
+			m.scores[score.Model] = score.TotalScore
+			m.ranks[score.Model] = score.Rank
+			m.described[score.Model] = score.Description
+		}
+	}
+	m.refresh()
+	return m
+}
+
+func RunModelPicker(ctx context.Context, client *ollama.Client, cfg *config.Config) (string, error) {
 	models, err := client.ListModels(ctx)
 	if err != nil {
 		return "", fmt.Errorf("list models: %w", err)
@@ -40,10 +104,7 @@ func RunModelPicker(ctx context.Context, client *ollama.Client) (string, error)
 		return "", fmt.Errorf("no models found. Please pull at least one model with 'ollama pull <model>'")
 	}
 
-	m := modelPickerModel{
-		models:   models,
-		selected: -1,
-	}
+	m := newModelPicker(ctx, client, cfg, models)
 
 	p := tea.NewProgram(m)
 	finalModel, err := p.Run()
@@ -63,39 +124,225 @@ func RunModelPicker(ctx context.Context, client *ollama.Client) (string, error)
 	return "", fmt.Errorf("no model selected")
 }
 
+// Init asks the server about models that have not been benchmarked yet, so
+// their badges are not empty
 func (m modelPickerModel) Init() tea.Cmd {
-	return nil
+	var cmds []tea.Cmd
+	for _, model := range m.models {
+		if _, ok := m.caps[model.Name]; ok {
+			continue
+		}
+		name := model.Name
+		cmds = append(cmds, func() tea.Msg {
+			details, err := m.client.ShowModel(m.ctx, name)
+			if err != nil {
+				return nil
+			}
+			return modelDetailsMsg{model: name, details: details}
+		})
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m modelPickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case modelDetailsMsg:
+		m.caps[msg.model] = config.ModelCapability{
+			SupportsTools: msg.details.HasCapability("tools"),
+			Vision:        msg.details.HasCapability("vision"),
+			ContextLength: msg.details.ContextLength,
+		}
+
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "ctrl+c", "q", "esc":
+		case "ctrl+c":
 			m.err = fmt.Errorf("cancelled")
 			m.done = true
 			return m, tea.Quit
 
-		case "up", "k":
-			if m.cursor > 0 {
-				m.cursor--
-			}
+		case "up":
+			m.move(-1)
+			return m, nil
 
-		case "down", "j":
-			if m.cursor < len(m.models)-1 {
-				m.cursor++
+		case "down":
+			m.move(1)
+			return m, nil
+
+		case "enter":
+			if len(m.visible) > 0 {
+				m.selected = m.visible[m.cursor]
+				m.done = true
+				return m, tea.Quit
 			}
+			return m, nil
+		}
+
+		if m.searching {
+			return m.updateSearch(msg), nil
+		}
 
-		case "enter", " ":
-			m.selected = m.cursor
+		switch msg.String() {
+		case "q", "esc":
+			if m.query != "" {
+				m.query = ""
+				m.refresh()
+				return m, nil
+			}
+			m.err = fmt.Errorf("cancelled")
 			m.done = true
 			return m, tea.Quit
+
+		case "k":
+			m.move(-1)
+
+		case "j":
+			m.move(1)
+
+		case "/":
+			m.searching = true
+
+		case "s":
+			m.sortBy = (m.sortBy + 1) % len(pickerSorts)
+			m.refresh()
+
+		case " ":
+			if len(m.visible) > 0 {
+				m.selected = m.visible[m.cursor]
+				m.done = true
+				return m, tea.Quit
+			}
 		}
 	}
 
 	return m, nil
 }
 
+func (m modelPickerModel) updateSearch(msg tea.KeyMsg) modelPickerModel {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.searching = false
+		m.query = ""
+	case tea.KeyBackspace:
+		if runes := []rune(m.query); len(runes) > 0 {
+			m.query = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.query += string(msg.Runes)
+	default:
+		return m
+	}
+	m.refresh()
+	return m
+}
+
+// selectModel marks name as the current model and moves the cursor to it
+func (m *modelPickerModel) selectModel(name string) {
+	m.current = name
+	for i, idx := range m.visible {
+		if m.models[idx].Name == name {
+			m.cursor = i
+		}
+	}
+}
+
+func (m *modelPickerModel) move(delta int) {
+	m.cursor += delta
+	if m.cursor >= len(m.visible) {
+		m.cursor = len(m.visible) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+// refresh recomputes the visible models after the query or sort changed
+func (m *modelPickerModel) refresh() {
+	match := make(map[int]int)
+	m.visible = nil
+	for i, model := range m.models {
+		if score, ok := fuzzyMatch(m.query, model.Name); ok {
+			match[i] = score
+			m.visible = append(m.visible, i)
+		}
+	}
+
+	models := m.models
+	var less func(a, b int) bool
+	switch pickerSorts[m.sortBy] {
+	case "default":
+		less = func(a, b int) bool { return match[a] > match[b] }
+	case "size":
+		less = func(a, b int) bool { return models[a].Size > models[b].Size }
+	case "score":
+		less = func(a, b int) bool { return m.scores[models[a].Name] > m.scores[models[b].Name] }
+	case "name":
+		less = func(a, b int) bool { return models[a].Name < models[b].Name }
+	}
+	sort.SliceStable(m.visible, func(i, j int) bool { return less(m.visible[i], m.visible[j]) })
+	m.move(0)
+}
+
+// fuzzyMatch reports whether the letters of query appear in order in name,
+// ignoring case. Higher scores mean consecutive letters and letters at the
+// start of words, so "qc" ranks qwen-coder above unrelated matches.
+func fuzzyMatch(query, name string) (int, bool) {
+	q := []rune(strings.ToLower(strings.TrimSpace(query)))
+	if len(q) == 0 {
+		return 0, true
+	}
+
+	score, qi := 0, 0
+	prevMatched := false
+	runes := []rune(strings.ToLower(name))
+	for i, r := range runes {
+		if qi == len(q) {
+			break
+		}
+		if r != q[qi] {
+			prevMatched = false
+			continue
+		}
+		score++
+		if prevMatched {
+			score += 2
+		}
+		if i == 0 || !unicode.IsLetter(runes[i-1]) && !unicode.IsDigit(runes[i-1]) {
+			score += 3
+		}
+		prevMatched = true
+		qi++
+	}
+	return score, qi == len(q)
+}
+
+// modelBadges describes a model's capabilities and benchmark score in a few
+// short tags
+func modelBadges(cap config.ModelCapability, score float64, rank int, hasScore bool) string {
+	var badges []string
+	if cap.SupportsTools {
+		badges = append(badges, "🔧 tools")
+	} else if cap.ToolCallFormat == "xml" || cap.ToolCallFormat == "json" {
+		badges = append(badges, "🔧 "+cap.ToolCallFormat)
+	}
+	if cap.Vision {
+		badges = append(badges, "👁 vision")
+	}
+	if cap.ContextLength > 0 {
+		badges = append(badges, fmt.Sprintf("%dk ctx", cap.ContextLength/1024))
+	}
+	if hasScore {
+		if rank > 0 {
+			badges = append(badges, fmt.Sprintf("★ %.2f (#%d)", score, rank))
+		} else {
+			badges = append(badges, fmt.Sprintf("★ %.2f", score))
+		}
+	}
+	if cap.AvgLatency > 0 {
+		badges = append(badges, "⏱ "+formatDuration(cap.AvgLatency))
+	}
+	return strings.Join(badges, "  ")
+}
+
 func (m modelPickerModel) View() string {
 	if m.done {
 		return ""
@@ -103,33 +350,129 @@ func (m modelPickerModel) View() string {
 
 	s := titleStyle.Render("🚀 Welcome to Llemecode!") + "\n\n"
 	s += statusStyle.Render("Select a model to start with:") + "\n\n"
+	s += m.listView()
+	s += "\n" + statusStyle.Render(m.help("q: quit"))
+	s += "\n" + lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241")).
+		Render("Benchmarking will run in the background while you chat.")
 
-	for i, model := range m.models {
+	return s
+}
+
+// listView renders the search line and the visible models
+func (m modelPickerModel) listView() string {
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	s := ""
+	if m.searching || m.query != "" {
+		cursor := ""
+		if m.searching {
+			cursor = "█"
+		}
+		s += fmt.Sprintf("  🔍 %s%s  %s\n\n", m.query, cursor, dim.Render(fmt.Sprintf("%d of %d models", len(m.visible), len(m.models))))
+	}
+
+	if len(m.visible) == 0 {
+		s += dim.Render("  No models match") + "\n"
+	}
+
+	first, last := 0, len(m.visible)
+	if m.maxRows > 0 && last > m.maxRows {
+		first = m.cursor - m.maxRows/2
+		if first < 0 {
+			first = 0
+		}
+		if first > last-m.maxRows {
+			first = last - m.maxRows
+		}
+		last = first + m.maxRows
+	}
+
+	for i := first; i < last; i++ {
+		model := m.models[m.visible[i]]
 		cursor := " "
 		if m.cursor == i {
 			cursor = cursorStyle.Render(">")
 		}
 
 		modelName := model.Name
+		if model.Name == m.current {
+			modelName += " (current)"
+		}
 		if m.cursor == i {
 			modelName = selectedStyle.Render(modelName)
 		}
 
-		// Format size
-		sizeMB := float64(model.Size) / 1024 / 1024
-		sizeStr := fmt.Sprintf("%.1f MB", sizeMB)
-		if sizeMB > 1024 {
-			sizeStr = fmt.Sprintf("%.1f GB", sizeMB/1024)
+		score, hasScore := m.scores[model.Name]
+		line := fmt.Sprintf("%s %s %s", cursor, modelName, dim.Render(fmt.Sprintf("(%s)", formatSize(model.Size))))
+		if badges := modelBadges(m.caps[model.Name], score, m.ranks[model.Name], hasScore); badges != "" {
+			line += "  " + badgeStyle.Render(badges)
+		}
+		s += line + "\n"
+		if description := m.described[model.Name]; m.cursor == i && description != "" {
+			description = strings.Join(strings.Fields(description), " ")
+			if runes := []rune(description); len(runes) > 100 {
+				description = string(runes[:100]) + "..."
+			}
+			s += dim.Render("    "+description) + "\n"
 		}
+	}
+	if first > 0 || last < len(m.visible) {
+		s += dim.Render(fmt.Sprintf("  %d-%d of %d", first+1, last, len(m.visible))) + "\n"
+	}
 
-		s += fmt.Sprintf("%s %s %s\n", cursor, modelName,
-			lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(fmt.Sprintf("(%s)", sizeStr)))
+	return s
+}
+
+// help describes the picker keys, ending with quit for leaving the list
+func (m modelPickerModel) help(quit string) string {
+	if m.searching {
+		return "Type to filter • ↑/↓: navigate • Enter: select • Esc: stop searching"
 	}
+	return fmt.Sprintf("↑/↓: navigate • Enter: select • /: search • s: sort (%s) • %s", pickerSorts[m.sortBy], quit)
+}
 
-	s += "\n" + statusStyle.Render("↑/↓: navigate • Enter: select • q: quit")
-	s += "\n" + lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
-		Render("Benchmarking will run in the background while you chat.")
+// inlineModelPicker is the picker /model opens over the chat
+type inlineModelPicker struct {
+	picker modelPickerModel
+	choose func(ctx context.Context, model string, m *chatModel) (string, error)
+}
 
-	return s
+// updateModelPicker passes keys to the open /model picker and switches
+// models once one is chosen
+func (m *chatModel) updateModelPicker(msg tea.KeyMsg) {
+	next, _ := m.modelPicker.picker.Update(msg)
+	picker := next.(modelPickerModel)
+	m.modelPicker.picker = picker
+	if !picker.done {
+		return
+	}
+
+	choose := m.modelPicker.choose
+	m.modelPicker = nil
+	if picker.err != nil {
+		return
+	}
+	result, err := choose(m.ctx, picker.models[picker.selected].Name, m)
+	if err != nil {
+		m.messages = append(m.messages, message{role: "error", content: i18n.T("chat.command_error", err)})
+	} else {
+		m.messages = append(m.messages, message{role: "system", content: result})
+	}
+	m.updateViewport()
+}
+
+func (m chatModel) modelPickerView() string {
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("86")).
+		Padding(1, 2).
+		Width(m.width - 8)
+
+	content := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("86")).
+		Bold(true).
+		Render(i18n.T("picker.title")) + "\n\n"
+	content += m.modelPicker.picker.listView()
+	return box.Render(content)
 }
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/i18n"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// collapsedToolLines is how much of a tool result is shown until it is expanded
+const collapsedToolLines = 6
+
+// messageSpan maps transcript lines [start, end) back to a message
+type messageSpan struct {
+	start, end int
+	index      int
+}
+
+// promptOption matches the "y: yes" style hints in prompts and help lines
+var promptOption = regexp.MustCompile(`(?:^|\s)([a-zA-Z]|Esc): `)
+
+// toolResultText renders a tool result as shown in the transcript, cut to a
+// few lines unless expanded, and whether it is long enough to collapse
+func toolResultText(msg message) (string, bool) {
+	text := strings.TrimSuffix(msg.content, "\n")
+	lines := strings.Split(text, "\n")
+	collapsible := len(lines) > collapsedToolLines+1
+	if collapsible && !msg.expanded {
+		hidden := len(lines) - collapsedToolLines
+		return toolStyle.Render(strings.Join(lines[:collapsedToolLines], "\n") +
+			"\n" + i18n.T("chat.tool_expand", hidden) + "\n"), true
+	}
+
+	if msg.highlighted == "" {
+		if !collapsible {
+			return toolStyle.Render(msg.content), false
+		}
+		return toolStyle.Render(text + "\n" + i18n.T("chat.tool_collapse") + "\n"), true
+	}
+
+	// The header keeps the tool style; the code brings its own colours
+	rendered := toolStyle.Render(strings.TrimSuffix(msg.content[:msg.codeStart], "\n")) + "\n" + msg.highlighted + "\n"
+	if collapsible {
+		rendered += toolStyle.Render(i18n.T("chat.tool_collapse")) + "\n"
+	}
+	return rendered, collapsible
+}
+
+// updateMouse scrolls the pane under the wheel, answers prompts when one of
+// their options is clicked, expands tool results and moves focus between the
+// transcript, side panel and input
+func (m chatModel) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	overPanel := m.panel.open && msg.X > m.viewport.Width
+
+	if tea.MouseEvent(msg).IsWheel() {
+		var cmd tea.Cmd
+		if overPanel {
+			m.panel.viewport, cmd = m.panel.viewport.Update(msg)
+		} else {
+			m.viewport, cmd = m.viewport.Update(msg)
+		}
+		return m, cmd
+	}
+	if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+		return m, nil
+	}
+
+	// The renderer keeps the bottom of a view that is taller than the terminal
+	lines := strings.Split(m.View(), "\n")
+	row := msg.Y + max(len(lines)-m.height, 0)
+	if row < 0 || row >= len(lines) {
+		return m, nil
+	}
+
+	if m.pendingRestore != nil || m.pendingBudget != nil || m.pendingPaste > 0 || (m.permissionMode && m.pendingPermission != nil) {
+		if key, ok := optionAt(lines[row], msg.X); ok {
+			return m.Update(key)
+		}
+		return m, nil
+	}
+
+	viewportTop := lipgloss.Height(m.headerView()) + 1
+	inputTop := len(lines) - 2 - lipgloss.Height(m.textarea.View())
+
+	switch {
+	case row >= viewportTop && row < viewportTop+m.viewport.Height:
+		if overPanel {
+			m.focusPanel(true)
+			return m, nil
+		}
+		m.focusPanel(false)
+		m.toggleToolResult(row - viewportTop + m.viewport.YOffset)
+	case row >= inputTop && row < len(lines)-2:
+		m.focusPanel(false)
+	}
+	return m, nil
+}
+
+// toggleToolResult expands or collapses the tool result on a transcript line,
+// keeping the scroll position
+func (m *chatModel) toggleToolResult(line int) {
+	for _, span := range m.toolSpans {
+		if line >= span.start && line < span.end {
+			m.messages[span.index].expanded = !m.messages[span.index].expanded
+			offset := m.viewport.YOffset
+			m.updateViewport()
+			m.viewport.SetYOffset(offset)
+			return
+		}
+	}
+}
+
+// optionAt returns the key for the prompt option under column x of a
+// rendered line, such as "n" for a click on "n: no"
+func optionAt(line string, x int) (tea.KeyMsg, bool) {
+	plain := ansi.Strip(line)
+	if x >= ansi.StringWidth(strings.TrimRight(plain, " │")) {
+		return tea.KeyMsg{}, false
+	}
+	matches := promptOption.FindAllStringSubmatchIndex(plain, -1)
+
+	for i := len(matches) - 1; i >= 0; i-- {
+		start := ansi.StringWidth(plain[:matches[i][2]])
+		if x < start {
+			continue
+		}
+		key := plain[matches[i][2]:matches[i][3]]
+		if key == "Esc" {
+			return tea.KeyMsg{Type: tea.KeyEsc}, true
+		}
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}, true
+	}
+	return tea.KeyMsg{}, false
+}
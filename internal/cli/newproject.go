@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+)
+
+// defaultTemplateTask is the first message for templates that don't set one
+const defaultTemplateTask = "This project was just created from the {{TEMPLATE}} template in {{PROJECT}}. Look around to see what the template contains, ask me what the project is called and what it is for, then customize it to match: names, module paths, README and anything left as a placeholder."
+
+// ListTemplates prints the configured project templates
+func ListTemplates(w io.Writer, cfg *config.Config) {
+	if len(cfg.Templates) == 0 {
+		fmt.Fprintln(w, `No project templates configured. Add some under "templates" in the config, e.g. {"go-cli": {"source": "https://github.com/you/go-cli-template"}}`)
+		return
+	}
+	names := make([]string, 0, len(cfg.Templates))
+	for name := range cfg.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tmpl := cfg.Templates[name]
+		line := fmt.Sprintf("%s  %s", name, tmpl.Source)
+		if tmpl.Description != "" {
+			line += " - " + tmpl.Description
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// NewProject creates dir from the named template and returns the first
+// message for the chat that customizes it. An empty dir uses the template
+// name.
+func NewProject(ctx context.Context, w io.Writer, cfg *config.Config, name, dir string) (string, string, error) {
+	tmpl, ok := cfg.Templates[name]
+	if !ok {
+		return "", "", fmt.Errorf("no template %q in the config (llemecode new lists them)", name)
+	}
+	if dir == "" {
+		dir = name
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", fmt.Errorf("resolve %s: %w", dir, err)
+	}
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return "", "", fmt.Errorf("%s already exists and is not empty", dir)
+	}
+
+	source := expandHome(tmpl.Source)
+	if info, err := os.Stat(source); err == nil && info.IsDir() {
+		fmt.Fprintf(w, "📁 Copying %s to %s...\n", source, dir)
+		if err := copyTemplate(source, dir); err != nil {
+			return "", "", fmt.Errorf("copy template: %w", err)
+		}
+	} else {
+		fmt.Fprintf(w, "📥 Cloning %s into %s...\n", tmpl.Source, dir)
+		clone := exec.CommandContext(ctx, "git", "clone", "--depth", "1", tmpl.Source, dir)
+		clone.Stdout, clone.Stderr = w, w
+		if err := clone.Run(); err != nil {
+			return "", "", fmt.Errorf("clone %s: %w", tmpl.Source, err)
+		}
+		// The new project starts its own history
+		if err := os.RemoveAll(filepath.Join(dir, ".git")); err != nil {
+			return "", "", fmt.Errorf("remove template history: %w", err)
+		}
+	}
+	if err := exec.CommandContext(ctx, "git", "init", "-q", dir).Run(); err != nil {
+		fmt.Fprintf(w, "⚠️ Could not start a git repository: %v\n", err)
+	}
+	fmt.Fprintf(w, "✓ Created %s from the %s template\n", dir, name)
+
+	task := tmpl.Task
+	if task == "" {
+		task = defaultTemplateTask
+	}
+	task = strings.ReplaceAll(task, "{{TEMPLATE}}", name)
+	task = strings.ReplaceAll(task, "{{PROJECT}}", filepath.Base(dir))
+	return dir, task, nil
+}
+
+// copyTemplate copies a template directory, leaving out its git history
+func copyTemplate(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(target, data, info.Mode().Perm())
+		}
+		return nil
+	})
+}
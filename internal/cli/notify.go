@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"os"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/logger"
+	"github.com/LaPingvino/llemecode/internal/notify"
+)
+
+// attention gets the user back to the terminal when the agent needs them.
+// If the terminal reports focus changes, nothing is sent while it's focused.
+type attention struct {
+	cfg        config.NotificationConfig
+	focusKnown bool
+	focused    bool
+}
+
+func newAttention(cfg config.NotificationConfig) *attention {
+	return &attention{cfg: cfg}
+}
+
+func (a *attention) setFocus(focused bool) {
+	a.focusKnown = true
+	a.focused = focused
+}
+
+// notify rings the bell and sends a desktop notification, as configured
+func (a *attention) notify(title, body string) {
+	if a.focusKnown && a.focused {
+		return
+	}
+
+	if a.cfg.Bell {
+		os.Stdout.WriteString("\a")
+	}
+	if a.cfg.Desktop {
+		go func() {
+			if err := notify.Desktop(title, body); err != nil {
+				logger.Log("Desktop notification failed: %v", err)
+			}
+		}()
+	}
+}
+
+// taskFinished notifies when a reply took long enough that the user has
+// probably looked away
+func (a *attention) taskFinished(elapsed time.Duration, failed bool) {
+	limit := time.Duration(a.cfg.LongTaskSeconds) * time.Second
+	if limit <= 0 || elapsed < limit {
+		return
+	}
+
+	if failed {
+		a.notify("Llemecode task failed", "Finished with an error after "+elapsed.Round(time.Second).String())
+		return
+	}
+	a.notify("Llemecode task finished", "Done after "+elapsed.Round(time.Second).String())
+}
@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/agent"
+	"github.com/LaPingvino/llemecode/internal/logger"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+)
+
+// openAIModel is the model name that means the configured default model
+const openAIModel = "llemecode"
+
+// foldedResultLimit caps how much of a tool result is folded into the reply
+const foldedResultLimit = 1500
+
+type openAIRequest struct {
+	Model    string          `json:"model"`
+	Messages []openAIMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+}
+
+type openAIMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"` // A string or a list of parts
+}
+
+// text returns the message content, joining the text parts of multi-part
+// content
+func (m openAIMessage) text() string {
+	var s string
+	if json.Unmarshal(m.Content, &s) == nil {
+		return s
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	json.Unmarshal(m.Content, &parts)
+	var texts []string
+	for _, part := range parts {
+		if part.Type == "text" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// serveChatCompletions answers OpenAI chat completion requests with the
+// agent, running tools and folding their results into the reply
+func (b *bridge) serveChatCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		openAIError(w, http.StatusMethodNotAllowed, "use POST")
+		return
+	}
+	var req openAIRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16<<20)).Decode(&req); err != nil {
+		openAIError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	if len(req.Messages) == 0 || req.Messages[len(req.Messages)-1].Role != "user" {
+		openAIError(w, http.StatusBadRequest, "the last message must be from the user")
+		return
+	}
+
+	model := req.Model
+	if model == "" || model == openAIModel {
+		model = b.cfg.DefaultModel
+	}
+
+	// Each request carries its whole conversation, so it gets its own agent
+	ag := newChatAgent(b.client, b.cfg, b.tools, model)
+	var history []ollama.Message
+	var clientSystem []string
+	for _, msg := range req.Messages[:len(req.Messages)-1] {
+		switch msg.Role {
+		case "system", "developer":
+			clientSystem = append(clientSystem, msg.text())
+		case "user", "assistant":
+			history = append(history, ollama.Message{Role: msg.Role, Content: msg.text()})
+		}
+	}
+	ag.RestoreMessages(history)
+	if len(clientSystem) > 0 {
+		ag.AddContext(strings.Join(clientSystem, "\n\n"))
+	}
+
+	id := "chatcmpl-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	created := time.Now().Unix()
+	var content strings.Builder
+	write := func(text string) {
+		content.WriteString(text)
+	}
+	if req.Stream {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			openAIError(w, http.StatusInternalServerError, "streaming is not supported")
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		write = func(text string) {
+			writeSSE(w, openAIChunk(id, created, model, map[string]string{"role": "assistant", "content": text}, nil))
+			flusher.Flush()
+		}
+	}
+
+	ag.SetToolObserver(auditObserver(b.store, func(execution agent.ToolExecution, finished bool) {
+		if finished {
+			write(foldToolResult(execution))
+		}
+	}))
+
+	release := b.m.bgBenchmark.HoldForTurn()
+	defer release()
+	started := time.Now()
+	resp, err := ag.ChatStream(r.Context(), req.Messages[len(req.Messages)-1].text(), func(iteration int, chunk string) {
+		write(chunk)
+	})
+	if err != nil {
+		logger.Log("openai: %v", err)
+		if req.Stream {
+			write(fmt.Sprintf("\n\n❌ Error: %v", err))
+			writeSSE(w, openAIChunk(id, created, model, map[string]string{}, "stop"))
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		openAIError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	recordUsage(b.store, model, time.Since(started), resp)
+
+	if req.Stream {
+		writeSSE(w, openAIChunk(id, created, model, map[string]string{}, "stop"))
+		fmt.Fprint(w, "data: [DONE]\n\n")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":      id,
+		"object":  "chat.completion",
+		"created": created,
+		"model":   model,
+		"choices": []map[string]interface{}{{
+			"index":         0,
+			"message":       map[string]string{"role": "assistant", "content": content.String()},
+			"finish_reason": "stop",
+		}},
+		"usage": map[string]int{
+			"prompt_tokens":     resp.PromptTokens,
+			"completion_tokens": resp.CompletionTokens,
+			"total_tokens":      resp.PromptTokens + resp.CompletionTokens,
+		},
+	})
+}
+
+// serveModels lists the default model under openAIModel and every
+// installed model under its own name
+func (b *bridge) serveModels(w http.ResponseWriter, r *http.Request) {
+	models, err := b.client.ListModels(r.Context())
+	if err != nil {
+		openAIError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	data := []map[string]interface{}{{"id": openAIModel, "object": "model", "owned_by": "llemecode"}}
+	for _, m := range models {
+		data = append(data, map[string]interface{}{"id": m.Name, "object": "model", "created": m.ModifiedAt.Unix(), "owned_by": "ollama"})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"object": "list", "data": data})
+}
+
+// foldToolResult shows a finished tool call as part of the assistant reply
+func foldToolResult(execution agent.ToolExecution) string {
+	header := fmt.Sprintf("\n\n🔧 %s (%s) %s\n", execution.Name, agent.FormatToolDuration(execution.Duration), plainArgs(execution.Args))
+	if execution.Error != nil {
+		return header + fmt.Sprintf("❌ %v\n\n", execution.Error)
+	}
+	return header + "```\n" + strings.TrimRight(truncateRunes(execution.Result, foldedResultLimit), "\n") + "\n```\n\n"
+}
+
+func openAIChunk(id string, created int64, model string, delta map[string]string, finish interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"id":      id,
+		"object":  "chat.completion.chunk",
+		"created": created,
+		"model":   model,
+		"choices": []map[string]interface{}{{"index": 0, "delta": delta, "finish_reason": finish}},
+	}
+}
+
+func writeSSE(w http.ResponseWriter, v interface{}) {
+	data, _ := json.Marshal(v)
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
+func openAIError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{"message": message, "type": "invalid_request_error"},
+	})
+}
@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/agent"
+	"github.com/LaPingvino/llemecode/internal/i18n"
+	"github.com/LaPingvino/llemecode/internal/tools"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	defaultPanelShare = 50 // Percent of the width the side panel starts with
+	minPanelShare     = 20
+	maxPanelShare     = 80
+	panelShareStep    = 5
+)
+
+var (
+	diffAddStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("78"))
+	diffRemoveStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	diffHunkStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("111"))
+)
+
+// sidePanel is the right-hand pane of the split view. It shows the file the
+// agent last read, the diff of its last write or the output of its last
+// command, while the transcript stays on the left.
+type sidePanel struct {
+	open     bool
+	focused  bool // Keys scroll the panel; the input is blurred
+	share    int  // Percent of the width given to the panel
+	title    string
+	content  string
+	command  *commandExecution // Streamed live instead of content while set
+	viewport viewport.Model
+}
+
+// panelMsg replaces what the side panel shows
+type panelMsg struct {
+	title   string
+	content string
+}
+
+// newPanelObserver turns finished tool calls into side panel content. Files
+// are snapshotted before write_file runs so the panel can show a diff;
+// run_command is skipped because its output is streamed separately.
+func newPanelObserver(program *tea.Program) agent.ToolObserver {
+	var before string
+	var existed bool
+
+	return func(execution agent.ToolExecution, finished bool) {
+		path, _ := execution.Args["path"].(string)
+		if !finished {
+			if execution.Name == "write_file" && path != "" {
+				data, err := os.ReadFile(path)
+				before, existed = string(data), err == nil
+			}
+			return
+		}
+
+		var msg panelMsg
+		switch {
+		case execution.Name == "run_command":
+			return
+		case execution.Error != nil:
+			msg = panelMsg{title: "✗ " + execution.Name, content: execution.Error.Error()}
+		case execution.Name == "write_file":
+			content, _ := execution.Args["content"].(string)
+			if existed {
+				msg = panelMsg{title: "✎ " + path, content: lineDiff(before, content)}
+			} else {
+				msg = panelMsg{title: "✚ " + path + " (new file)", content: content}
+			}
+		case execution.Name == "read_file":
+			msg = panelMsg{title: "📄 " + path, content: execution.Result}
+		case strings.HasPrefix(execution.Name, "todo_"):
+			msg = panelMsg{title: "📋 Tasks", content: execution.Result}
+		default:
+			msg = panelMsg{title: "🔧 " + execution.Name, content: execution.Result}
+		}
+		program.Send(msg)
+	}
+}
+
+// toggleSplit opens or closes the side panel
+func (m *chatModel) toggleSplit() {
+	m.panel.open = !m.panel.open
+	m.focusPanel(false)
+	if m.panel.share == 0 {
+		m.panel.share = defaultPanelShare
+	}
+	m.layout()
+}
+
+// focusPanel moves keyboard focus to the side panel or back to the input
+func (m *chatModel) focusPanel(focused bool) {
+	m.panel.focused = focused && m.panel.open
+	if m.panel.focused {
+		m.textarea.Blur()
+	} else {
+		m.textarea.Focus()
+	}
+}
+
+// resizePanel grows the side panel by delta percent of the width
+func (m *chatModel) resizePanel(delta int) {
+	m.panel.share = max(minPanelShare, min(maxPanelShare, m.panel.share+delta))
+	m.layout()
+}
+
+// layout sizes the transcript and side panel to the terminal
+func (m *chatModel) layout() {
+	width := m.width - 4
+	m.viewport.Height = m.height - 8
+	if !m.panel.open {
+		m.viewport.Width = width
+		m.updateViewport()
+		return
+	}
+
+	panelWidth := width * m.panel.share / 100
+	m.viewport.Width = width - panelWidth - 1
+	// Border and padding take 4 columns, border and title 3 rows
+	m.panel.viewport.Width = max(panelWidth-4, 1)
+	m.panel.viewport.Height = max(m.viewport.Height-3, 1)
+	m.updateViewport()
+	m.updatePanel()
+}
+
+// updatePanel refreshes the side panel content, following command output
+// as it arrives
+func (m *chatModel) updatePanel() {
+	if !m.panel.open {
+		return
+	}
+
+	content := m.panel.content
+	if cmd := m.panel.command; cmd != nil {
+		content = "$ " + cmd.command + "\n" + strings.Join(cmd.output, "\n")
+		if cmd.model != "" {
+			content = cmd.command + "\n\n" + strings.Join(append(cmd.output, cmd.partial), "\n")
+		}
+		if !cmd.running && cmd.model != "" {
+			if cmd.err != nil {
+				content += "\n" + errorStyle.Render(fmt.Sprintf("✗ %v", cmd.err))
+			}
+		} else if !cmd.running {
+			if cmd.err != nil {
+				content += "\n" + errorStyle.Render(fmt.Sprintf("✗ %v", cmd.err))
+			} else {
+				content += fmt.Sprintf("\n✓ exit %d", cmd.exitCode)
+			}
+		}
+	}
+
+	// Wrap up front so the viewport's line count matches what is shown
+	m.panel.viewport.SetContent(lipgloss.NewStyle().Width(m.panel.viewport.Width).Render(content))
+	if m.panel.command != nil {
+		m.panel.viewport.GotoBottom()
+	}
+}
+
+// showInPanel replaces the side panel content with a file or tool result
+func (m *chatModel) showInPanel(msg panelMsg) {
+	m.panel.title = msg.title
+	m.panel.content = msg.content
+	m.panel.command = nil
+	m.updatePanel()
+	m.panel.viewport.GotoTop()
+}
+
+// panelView renders the side panel next to the transcript
+func (m chatModel) panelView() string {
+	borderColor := lipgloss.Color("241")
+	if m.panel.focused {
+		borderColor = lipgloss.Color("205")
+	}
+
+	title := m.panel.title
+	if m.panel.command != nil {
+		title = "⚡ " + m.panel.command.command
+		if m.panel.command.model != "" {
+			title = "🤖 " + m.panel.command.model
+		}
+	}
+	if title == "" {
+		title = i18n.T("chat.panel_empty")
+	}
+	if runes := []rune(singleLine(title)); len(runes) > m.panel.viewport.Width {
+		title = string(runes[:max(m.panel.viewport.Width-1, 0)]) + "…"
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Padding(0, 1).
+		Render(lipgloss.NewStyle().Bold(true).Render(title) + "\n" + m.panel.viewport.View())
+}
+
+// lineDiff shows the changed lines between before and after with a few
+// lines of context, or after in full when the files are too large to diff
+func lineDiff(before, after string) string {
+	hunks, ok := tools.LineDiff(before, after)
+	if !ok {
+		return after
+	}
+
+	var out strings.Builder
+	for _, hunk := range hunks {
+		out.WriteString(diffHunkStyle.Render(fmt.Sprintf("@@ line %d @@", hunk[0].Line)) + "\n")
+		for _, line := range hunk {
+			switch line.Op {
+			case '+':
+				out.WriteString(diffAddStyle.Render("+ "+line.Text) + "\n")
+			case '-':
+				out.WriteString(diffRemoveStyle.Render("- "+line.Text) + "\n")
+			default:
+				out.WriteString("  " + line.Text + "\n")
+			}
+		}
+	}
+
+	if out.Len() == 0 {
+		return "(no changes)"
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
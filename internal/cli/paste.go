@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+)
+
+// pastePlaceholder marks where a collapsed paste goes in the input
+var pastePlaceholder = regexp.MustCompile(`\[pasted \d+ lines? #(\d+)\]`)
+
+// collapseBytes collapses pastes with few but long lines, which would hit
+// the input's character limit
+const collapseBytes = 2000
+
+// pasteBuffer keeps long pastes out of the input box, which would lag
+// rendering them and cut them at its character limit
+type pasteBuffer struct {
+	settings config.PasteConfig
+	pastes   []string // By placeholder number - 1; kept so history recalls work
+}
+
+// paste returns what to insert into the input for pasted text: the text, or
+// a placeholder when it is long
+func (b *pasteBuffer) paste(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Count(strings.TrimSuffix(text, "\n"), "\n") + 1
+	collapse := b.settings.Collapse()
+	if collapse == 0 || lines <= collapse && len(text) <= collapseBytes {
+		return text
+	}
+	b.pastes = append(b.pastes, text)
+	unit := "lines"
+	if lines == 1 {
+		unit = "line"
+	}
+	return fmt.Sprintf("[pasted %d %s #%d]", lines, unit, len(b.pastes))
+}
+
+// expand returns input with its placeholders replaced by the pasted text,
+// the version the transcript shows collapsed, and how many bytes were pasted
+func (b *pasteBuffer) expand(input string) (full, display string, pasted int) {
+	full = pastePlaceholder.ReplaceAllStringFunc(input, func(token string) string {
+		n, _ := strconv.Atoi(pastePlaceholder.FindStringSubmatch(token)[1])
+		if n < 1 || n > len(b.pastes) {
+			return token
+		}
+		pasted += len(b.pastes[n-1])
+		return "\n" + strings.TrimSuffix(b.pastes[n-1], "\n") + "\n"
+	})
+	if full == input {
+		return input, "", 0
+	}
+	display = pastePlaceholder.ReplaceAllStringFunc(input, func(token string) string {
+		return strings.TrimSuffix(token, " #"+pastePlaceholder.FindStringSubmatch(token)[1]+"]") + "]"
+	})
+	return strings.TrimSpace(full), display, pasted
+}
+
+// needsConfirm reports whether sending input needs a yes first
+func (b *pasteBuffer) needsConfirm(input string) (int, bool) {
+	_, _, pasted := b.expand(input)
+	limit := b.settings.Confirm()
+	return pasted, limit > 0 && pasted > limit
+}
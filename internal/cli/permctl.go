@@ -0,0 +1,260 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/tools"
+)
+
+// PermissionsCommand shows and changes the workspace jail and command
+// allowlist
+type PermissionsCommand struct {
+	cfg          *config.Config
+	toolRegistry *tools.Registry
+}
+
+func NewPermissionsCommand(cfg *config.Config, toolRegistry *tools.Registry) *PermissionsCommand {
+	return &PermissionsCommand{cfg: cfg, toolRegistry: toolRegistry}
+}
+
+func (c *PermissionsCommand) Name() string {
+	return "permissions"
+}
+
+func (c *PermissionsCommand) Description() string {
+	return "Show permissions (usage: /permissions [jail on|off] [roots add|remove <dir>] [allowlist on|off|add|remove <cmd>])"
+}
+
+func (c *PermissionsCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	live := c.toolRegistry.PermissionConfig()
+	if live == nil {
+		return "", fmt.Errorf("no permission-checked tools are registered")
+	}
+
+	if len(args) == 0 {
+		return c.show(live), nil
+	}
+
+	switch args[0] {
+	case "jail":
+		if len(args) < 2 || (args[1] != "on" && args[1] != "off") {
+			return "Usage: /permissions jail on|off", nil
+		}
+		restrict := args[1] == "on"
+		if policy := live.Policy(); !restrict && policy != nil && policy.RestrictToWorkingDir {
+			return "🔒 The team policy keeps the workspace jail on", nil
+		}
+		err := c.cfg.Update(func(cfg *config.Config) {
+			cfg.Permissions.RestrictToWorkingDir = restrict
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to save config: %w", err)
+		}
+		live.SetRestrictToWorkingDir(restrict)
+		if restrict {
+			return "✓ Tools are restricted to the workspace", nil
+		}
+		return "⚠️ Workspace jail disabled - tools may access any path", nil
+
+	case "roots":
+		if len(args) < 3 || (args[1] != "add" && args[1] != "remove") {
+			return "Usage: /permissions roots add|remove <dir>", nil
+		}
+		if args[1] == "add" && live.JailLocked() {
+			return "🔒 The team policy keeps tools inside the workspace, so allowed roots don't apply", nil
+		}
+		root, err := filepath.Abs(args[2])
+		if err != nil {
+			return "", fmt.Errorf("invalid path: %w", err)
+		}
+
+		err = c.cfg.Update(func(cfg *config.Config) {
+			kept := []string{}
+			for _, existing := range cfg.Permissions.AllowedRoots {
+				if existing != root {
+					kept = append(kept, existing)
+				}
+			}
+			if args[1] == "add" {
+				kept = append(kept, root)
+			}
+			cfg.Permissions.AllowedRoots = kept
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to save config: %w", err)
+		}
+		// Update the live roots in place so session-only ones survive
+		if args[1] == "add" {
+			live.AddAllowedRoot(root)
+			return fmt.Sprintf("✓ Added allowed root: %s", root), nil
+		}
+		live.RemoveAllowedRoot(root)
+		return fmt.Sprintf("✓ Removed allowed root: %s", root), nil
+
+	case "allowlist":
+		return c.allowlist(args[1:], live)
+	}
+
+	return "", fmt.Errorf("unknown subcommand '%s'. Use /permissions jail, roots or allowlist", args[0])
+}
+
+func (c *PermissionsCommand) allowlist(args []string, live *tools.PermissionConfig) (string, error) {
+	usage := "Usage: /permissions allowlist on|off or /permissions allowlist add|remove <command>"
+	if len(args) == 0 {
+		return usage, nil
+	}
+
+	switch args[0] {
+	case "on", "off":
+		on := args[0] == "on"
+		err := c.cfg.Update(func(cfg *config.Config) {
+			cfg.Permissions.CommandAllowlist = on
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to save config: %w", err)
+		}
+		live.SetCommandAllowlist(on)
+		if on {
+			return "✓ Allowlist mode on - only allowed commands run without asking", nil
+		}
+		return "✓ Allowlist mode off", nil
+
+	case "add", "remove":
+		if len(args) < 2 {
+			return usage, nil
+		}
+		_, current := live.Allowlist()
+		kept := []string{}
+		for _, existing := range current {
+			if existing != args[1] {
+				kept = append(kept, existing)
+			}
+		}
+		if args[0] == "add" {
+			kept = append(kept, args[1])
+		}
+		err := c.cfg.Update(func(cfg *config.Config) {
+			cfg.Permissions.AllowedCommands = kept
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to save config: %w", err)
+		}
+		live.SetAllowedCommands(kept)
+		if args[0] == "add" {
+			return fmt.Sprintf("✓ Allowed command: %s", args[1]), nil
+		}
+		return fmt.Sprintf("✓ Removed allowed command: %s", args[1]), nil
+	}
+
+	return usage, nil
+}
+
+func (c *PermissionsCommand) show(live *tools.PermissionConfig) string {
+	restrict, roots := live.Workspace()
+
+	var sb strings.Builder
+	sb.WriteString("🔒 Permissions\n\n")
+	if restrict {
+		sb.WriteString("- Workspace jail: **on**\n")
+	} else {
+		sb.WriteString("- Workspace jail: **off**\n")
+	}
+	sb.WriteString(fmt.Sprintf("- Write approval: %v\n", live.RequireApprovalWrite))
+	sb.WriteString(fmt.Sprintf("- Execute approval: %v\n", live.RequireApprovalExecute))
+	sb.WriteString(fmt.Sprintf("- Network approval: %v\n", live.RequireApprovalNetwork))
+
+	sb.WriteString("\nAllowed roots:\n")
+	sb.WriteString("- (working directory)\n")
+	for _, root := range roots {
+		if live.IsSessionRoot(root) {
+			sb.WriteString(fmt.Sprintf("- %s _(this session)_\n", root))
+		} else {
+			sb.WriteString(fmt.Sprintf("- %s\n", root))
+		}
+	}
+
+	if projectRoots := live.ProjectRoots(); len(projectRoots) > 0 {
+		sb.WriteString("\nProject roots:\n")
+		for _, root := range projectRoots {
+			sb.WriteString(fmt.Sprintf("- %s: %s\n", root.Name, root.Path))
+		}
+	}
+
+	var saved, session []string
+	for _, pattern := range live.Patterns() {
+		if !pattern.Enabled {
+			continue
+		}
+		if pattern.Session {
+			session = append(session, describePattern(pattern))
+		} else {
+			saved = append(saved, describePattern(pattern))
+		}
+	}
+	if len(session) > 0 {
+		sb.WriteString("\nAllowed this session (not saved):\n")
+		for _, grant := range session {
+			sb.WriteString(fmt.Sprintf("- %s\n", grant))
+		}
+	}
+	if len(saved) > 0 {
+		sb.WriteString("\nAlways allowed (saved):\n")
+		for _, grant := range saved {
+			sb.WriteString(fmt.Sprintf("- %s\n", grant))
+		}
+	}
+
+	if len(c.cfg.Permissions.FileRoots) > 0 {
+		sb.WriteString("\nFile tools only work inside:\n")
+		for _, root := range c.cfg.Permissions.FileRoots {
+			sb.WriteString(fmt.Sprintf("- %s\n", root))
+		}
+	}
+	if len(c.cfg.Permissions.DeniedRoots) > 0 {
+		sb.WriteString("\nFile tools never work inside:\n")
+		for _, root := range c.cfg.Permissions.DeniedRoots {
+			sb.WriteString(fmt.Sprintf("- %s\n", root))
+		}
+	}
+
+	allowlist, commands := live.Allowlist()
+	if allowlist {
+		sb.WriteString("\nAllowlist mode: **on** - other commands always ask\n")
+	} else {
+		sb.WriteString("\nAllowlist mode: **off**\n")
+	}
+	if len(commands) > 0 {
+		sb.WriteString(fmt.Sprintf("Allowed commands: %s\n", strings.Join(commands, ", ")))
+	}
+
+	if rules := live.Policy().Summary(); len(rules) > 0 {
+		sb.WriteString(fmt.Sprintf("\nTeam policy (%s, can't be changed here):\n", config.PolicyPath))
+		for _, rule := range rules {
+			sb.WriteString(fmt.Sprintf("- %s\n", rule))
+		}
+	}
+
+	sb.WriteString("\nUse `/permissions jail on|off`, `/permissions roots add|remove <dir>` or `/permissions allowlist on|off|add|remove <cmd>`")
+	return sb.String()
+}
+
+// describePattern summarizes what an always-allow pattern grants
+func describePattern(pattern tools.PermissionPattern) string {
+	scope := ""
+	if pattern.Root != "" {
+		scope = " in root " + pattern.Root
+	}
+	switch {
+	case pattern.AlwaysAllow:
+		return fmt.Sprintf("%s: any use%s", pattern.Tool, scope)
+	case pattern.CommandPattern != "":
+		return fmt.Sprintf("%s: `%s` commands%s", pattern.Tool, pattern.CommandPattern, scope)
+	case pattern.PathPattern != "":
+		return fmt.Sprintf("%s: under %s%s", pattern.Tool, pattern.PathPattern, scope)
+	}
+	return pattern.Tool + scope
+}
@@ -17,6 +17,7 @@ type permissionRequest struct {
 	level      tools.PermissionLevel
 	details    string
 	targetPath string // Path being accessed (for "always allow")
+	outside    bool   // Path is outside the workspace; answers are once/add root/deny
 	response   chan permissionResponse
 }
 
@@ -25,6 +26,8 @@ type permissionResponse struct {
 	alwaysTool    bool // Always allow this tool (no restrictions)
 	alwaysCommand bool // For run_command: always allow this specific command
 	alwaysPath    bool // Always allow when using this path/directory
+	addRoot       bool // Outside workspace: add the directory to the allowed roots
+	session       bool // Remember the approval until the program exits, without saving it
 }
 
 type permissionRequestMsg struct {
@@ -131,12 +134,14 @@ func (cpc *ChatPermissionChecker) RequestPermission(ctx context.Context, tool st
 
 // InlineChatPermissionChecker sends permission requests to the main chat UI
 type InlineChatPermissionChecker struct {
-	program *tea.Program
+	program     *tea.Program
+	permissions *tools.PermissionConfig // Live config grants are added to
 }
 
-func NewInlineChatPermissionChecker(program *tea.Program) *InlineChatPermissionChecker {
+func NewInlineChatPermissionChecker(program *tea.Program, permissions *tools.PermissionConfig) *InlineChatPermissionChecker {
 	return &InlineChatPermissionChecker{
-		program: program,
+		program:     program,
+		permissions: permissions,
 	}
 }
 
@@ -159,10 +164,8 @@ func (icpc *InlineChatPermissionChecker) RequestPermission(ctx context.Context,
 	// Wait for response or context cancellation
 	select {
 	case resp := <-request.response:
-		// TODO: Save permission patterns if needed
-		if resp.alwaysTool || resp.alwaysCommand || resp.alwaysPath {
-			// Save to config
-			savePermissionPattern(tool, details, targetPath, resp)
+		if resp.approved {
+			grantPermission(icpc.permissions, tool, details, targetPath, resp)
 		}
 		return resp.approved, nil
 	case <-ctx.Done():
@@ -170,11 +173,68 @@ func (icpc *InlineChatPermissionChecker) RequestPermission(ctx context.Context,
 	}
 }
 
+// RequestOutsideWorkspace asks whether a tool may use a path outside the workspace
+func (icpc *InlineChatPermissionChecker) RequestOutsideWorkspace(ctx context.Context, tool, path string) (tools.OutsideWorkspaceDecision, error) {
+	request := &permissionRequest{
+		toolName:   tool,
+		level:      tools.PermissionRead,
+		details:    fmt.Sprintf("Path: %s", path),
+		targetPath: path,
+		outside:    true,
+		response:   make(chan permissionResponse, 1),
+	}
+
+	icpc.program.Send(permissionRequestMsg{request: request})
+
+	select {
+	case resp := <-request.response:
+		switch {
+		case resp.addRoot && resp.session:
+			return tools.OutsideWorkspaceAddSessionRoot, nil
+		case resp.addRoot:
+			saveAllowedRoot(tools.RootFor(path))
+			return tools.OutsideWorkspaceAddRoot, nil
+		case resp.approved:
+			return tools.OutsideWorkspaceAllowOnce, nil
+		default:
+			return tools.OutsideWorkspaceDeny, nil
+		}
+	case <-ctx.Done():
+		return tools.OutsideWorkspaceDeny, ctx.Err()
+	}
+}
+
+// saveAllowedRoot adds a directory to the allowed roots in the config
+func saveAllowedRoot(root string) {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Warning: Failed to save allowed root: %v\n", err)
+		return
+	}
+
+	err = cfg.Update(func(c *config.Config) {
+		for _, existing := range c.Permissions.AllowedRoots {
+			if existing == root {
+				return
+			}
+		}
+		c.Permissions.AllowedRoots = append(c.Permissions.AllowedRoots, root)
+	})
+	if err != nil {
+		fmt.Printf("Warning: Failed to save config: %v\n", err)
+	}
+}
+
 // extractPathFromDetails attempts to extract a file path or directory from the tool details
 func extractPathFromDetails(tool, details string) string {
 	switch tool {
-	case "read_file", "write_file", "list_directory":
+	case "read_file", "write_file", "list_files", "list_directory", "move_file", "delete_file", "scaffold", "append_to_file", "insert_at_line", "download_file", "list_archive", "extract_archive":
 		// These tools typically have the path in the details string
+		// ProtectedTool puts the resolved path in a header line; prefer it
+		// since the args themselves may contain anything
+		if path := detailField(details, "Path"); path != "" {
+			return path
+		}
 		// Look for common patterns like "File: /path/to/file" or "Directory: /path/to/dir"
 		if strings.Contains(details, "File: ") {
 			parts := strings.SplitN(details, "File: ", 2)
@@ -191,13 +251,6 @@ func extractPathFromDetails(tool, details string) string {
 				return strings.TrimSpace(path)
 			}
 		}
-		if strings.Contains(details, "Path: ") {
-			parts := strings.SplitN(details, "Path: ", 2)
-			if len(parts) == 2 {
-				path := strings.Split(parts[1], "\n")[0]
-				return strings.TrimSpace(path)
-			}
-		}
 	case "run_command":
 		// For commands, extract the first path-like argument
 		// Look for patterns like "Command: ls /path/to/dir"
@@ -220,74 +273,129 @@ func extractPathFromDetails(tool, details string) string {
 	return ""
 }
 
-// savePermissionPattern saves a permission pattern to the config
-func savePermissionPattern(tool, details, targetPath string, resp permissionResponse) {
-	// Load current config
-	cfg, err := config.Load()
-	if err != nil {
-		// Log error but don't fail - permission was already granted for this operation
-		fmt.Printf("Warning: Failed to save permission pattern: %v\n", err)
+// grantPermission remembers an approval given with one of the "always" or
+// session options, so the live tools honour it right away
+func grantPermission(permissions *tools.PermissionConfig, tool, details, targetPath string, resp permissionResponse) {
+	pattern, ok := newPermissionPattern(tool, details, targetPath, resp)
+	if !ok {
 		return
 	}
 
-	// Create new pattern based on response type
-	var pattern config.PermissionPattern
-	pattern.Tool = tool
-	pattern.Enabled = true
+	if permissions != nil {
+		permissions.AddAlwaysAllowPattern(tools.PermissionPattern{
+			Tool:           pattern.Tool,
+			PathPattern:    pattern.PathPattern,
+			CommandPattern: pattern.CommandPattern,
+			AlwaysAllow:    pattern.AlwaysAllow,
+			Root:           pattern.Root,
+			Enabled:        true,
+			Session:        resp.session,
+		})
+	}
+	if !resp.session {
+		savePermissionPattern(pattern)
+	}
+}
+
+// newPermissionPattern builds the pattern an approval stands for. Session
+// approvals use the narrowest scope available: the command, then the path,
+// then the whole tool.
+func newPermissionPattern(tool, details, targetPath string, resp permissionResponse) (config.PermissionPattern, bool) {
+	pattern := config.PermissionPattern{Tool: tool, Enabled: true}
+
+	alwaysCommand := resp.alwaysCommand || resp.session && tool == "run_command"
+	alwaysPath := resp.alwaysPath || resp.session && tool != "run_command" && targetPath != ""
+	alwaysTool := resp.alwaysTool || resp.session && tool != "run_command" && targetPath == ""
 
-	if resp.alwaysTool {
+	switch {
+	case alwaysTool:
 		// Always allow this tool, no restrictions
 		pattern.AlwaysAllow = true
-	} else if resp.alwaysCommand && tool == "run_command" {
+	case alwaysCommand && tool == "run_command":
 		// Extract command prefix (first word) from details
 		command := extractCommandFromDetails(details)
-		if command != "" {
+		switch {
+		case command != "":
 			pattern.CommandPattern = command
-		} else {
+		case resp.session:
+			// Don't widen a session grant to every command
+			return pattern, false
+		default:
 			// Fallback to always allow if we can't extract command
 			pattern.AlwaysAllow = true
 		}
-	} else if resp.alwaysPath && targetPath != "" {
-		// Use the target path as a pattern
+	case alwaysPath && targetPath != "":
+		// Use the target path as a pattern, canonicalized so it matches the
+		// resolved paths ProtectedTool compares against
 		pattern.PathPattern = targetPath
-	} else {
-		// Invalid combination, don't save
-		return
+		if resolved, err := tools.ResolvePath(targetPath); err == nil {
+			pattern.PathPattern = resolved
+		}
+	default:
+		// Invalid combination
+		return pattern, false
 	}
 
-	// Check if this pattern already exists
-	for _, existing := range cfg.Permissions.AlwaysAllowPatterns {
-		if existing.Tool == pattern.Tool &&
-			existing.PathPattern == pattern.PathPattern &&
-			existing.CommandPattern == pattern.CommandPattern &&
-			existing.AlwaysAllow == pattern.AlwaysAllow {
-			// Pattern already exists, no need to save again
-			return
-		}
+	// A tool or command grant made in a project root stays in that root
+	if pattern.PathPattern == "" {
+		pattern.Root = extractRootFromDetails(details)
 	}
+	return pattern, true
+}
 
-	// Add pattern to config
-	cfg.Permissions.AlwaysAllowPatterns = append(cfg.Permissions.AlwaysAllowPatterns, pattern)
+// savePermissionPattern saves a permission pattern to the config
+func savePermissionPattern(pattern config.PermissionPattern) {
+	// Load current config
+	cfg, err := config.Load()
+	if err != nil {
+		// Log error but don't fail - permission was already granted for this operation
+		fmt.Printf("Warning: Failed to save permission pattern: %v\n", err)
+		return
+	}
 
-	// Save config
-	if err := cfg.Save(); err != nil {
+	// Add pattern to config unless it already exists, and save
+	err = cfg.Update(func(c *config.Config) {
+		for _, existing := range c.Permissions.AlwaysAllowPatterns {
+			if existing.Tool == pattern.Tool &&
+				existing.PathPattern == pattern.PathPattern &&
+				existing.CommandPattern == pattern.CommandPattern &&
+				existing.AlwaysAllow == pattern.AlwaysAllow {
+				return
+			}
+		}
+		c.Permissions.AlwaysAllowPatterns = append(c.Permissions.AlwaysAllowPatterns, pattern)
+	})
+	if err != nil {
 		fmt.Printf("Warning: Failed to save config: %v\n", err)
 	}
 }
 
+// detailField returns the value of a "Name: value" header line. ProtectedTool
+// puts these before the args, so text inside the args can't pose as one.
+func detailField(details, name string) string {
+	for _, line := range strings.Split(details, "\n") {
+		if strings.HasPrefix(line, "Args: ") {
+			break
+		}
+		if value, ok := strings.CutPrefix(line, name+": "); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
 // extractCommandFromDetails extracts the command name from the details string
+// extractRootFromDetails returns the project root named in the "Root: <name>"
+// header, if the call was made in one
+func extractRootFromDetails(details string) string {
+	return detailField(details, "Root")
+}
+
 func extractCommandFromDetails(details string) string {
-	// Look for "Command: <cmd>" pattern
-	if strings.Contains(details, "Command: ") {
-		parts := strings.SplitN(details, "Command: ", 2)
-		if len(parts) == 2 {
-			cmdLine := strings.Split(parts[1], "\n")[0]
-			// Get first word (the actual command)
-			fields := strings.Fields(cmdLine)
-			if len(fields) > 0 {
-				return fields[0]
-			}
-		}
+	// Get first word (the actual command) of the "Command: <cmd>" header
+	fields := strings.Fields(detailField(details, "Command"))
+	if len(fields) > 0 {
+		return fields[0]
 	}
 	return ""
 }
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/agent"
+)
+
+// PinCommand keeps a message, a file or some text in the context for good
+type PinCommand struct{}
+
+func NewPinCommand() *PinCommand {
+	return &PinCommand{}
+}
+
+func (c *PinCommand) Name() string {
+	return "pin"
+}
+
+func (c *PinCommand) Description() string {
+	return "Always keep something in context: the last reply, a file or text (usage: /pin [last] | /pin file <path> | /pin <text>)"
+}
+
+func (c *PinCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	var pin agent.Pin
+	switch {
+	case len(args) == 0 || (len(args) == 1 && args[0] == "last"):
+		msgs := m.agent.GetMessages()
+		for i := len(msgs) - 1; i >= 0; i-- {
+			if msgs[i].Role == "assistant" && msgs[i].Content != "" {
+				pin = agent.Pin{Label: "Reply: " + truncateLabel(msgs[i].Content), Text: msgs[i].Content}
+				break
+			}
+		}
+		if pin.Text == "" {
+			return "", fmt.Errorf("no reply to pin yet")
+		}
+
+	case args[0] == "file":
+		if len(args) != 2 {
+			return "", fmt.Errorf("usage: /pin file <path>")
+		}
+		path, err := filepath.Abs(args[1])
+		if err != nil {
+			return "", err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("cannot pin %s: %w", args[1], err)
+		}
+		if info.IsDir() {
+			return "", fmt.Errorf("%s is a directory", args[1])
+		}
+		pin = agent.Pin{Label: "File " + args[1], Path: path}
+
+	default:
+		text := strings.Join(args, " ")
+		pin = agent.Pin{Label: "Note: " + truncateLabel(text), Text: text}
+	}
+
+	m.agent.AddPin(pin)
+	return fmt.Sprintf("📌 Pinned %s (sent with every request; /pins lists pins)", pin.Label), nil
+}
+
+// PinsCommand lists and removes pins
+type PinsCommand struct{}
+
+func NewPinsCommand() *PinsCommand {
+	return &PinsCommand{}
+}
+
+func (c *PinsCommand) Name() string {
+	return "pins"
+}
+
+func (c *PinsCommand) Description() string {
+	return "List pinned context (usage: /pins [unpin <n>] [clear])"
+}
+
+func (c *PinsCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "unpin", "remove":
+			if len(args) != 2 {
+				return "", fmt.Errorf("usage: /pins unpin <n>")
+			}
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				return "", fmt.Errorf("invalid number: %s", args[1])
+			}
+			if err := m.agent.RemovePin(n - 1); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("✓ Unpinned %d", n), nil
+		case "clear":
+			pins := m.agent.Pins()
+			for i := len(pins) - 1; i >= 0; i-- {
+				m.agent.RemovePin(i)
+			}
+			return fmt.Sprintf("✓ Unpinned %d items", len(pins)), nil
+		default:
+			return "", fmt.Errorf("unknown option %q (usage: /pins [unpin <n>] [clear])", args[0])
+		}
+	}
+
+	pins := m.agent.Pins()
+	if len(pins) == 0 {
+		return "Nothing pinned. Pin the last reply with /pin, a file with /pin file <path>, or text with /pin <text>", nil
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📌 %d pinned:\n\n", len(pins)))
+	for i, pin := range pins {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, pin.Label))
+	}
+	sb.WriteString("\nUnpin with /pins unpin <n>")
+	return sb.String(), nil
+}
+
+func truncateLabel(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > 50 {
+		return string(r[:49]) + "…"
+	}
+	return s
+}
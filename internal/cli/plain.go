@@ -0,0 +1,385 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/agent"
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/i18n"
+	"github.com/LaPingvino/llemecode/internal/logger"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/session"
+	"github.com/LaPingvino/llemecode/internal/storage"
+	"github.com/LaPingvino/llemecode/internal/tools"
+	"github.com/LaPingvino/llemecode/internal/version"
+)
+
+// plainInput reads stdin line by line in the background so prompts can give
+// up when the context is cancelled
+type plainInput struct {
+	lines chan string
+}
+
+func newPlainInput(r io.Reader) *plainInput {
+	in := &plainInput{lines: make(chan string)}
+	go func() {
+		defer close(in.lines)
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			in.lines <- scanner.Text()
+		}
+	}()
+	return in
+}
+
+// readLine waits for the next line; ok is false at EOF or cancellation
+func (in *plainInput) readLine(ctx context.Context) (string, bool) {
+	select {
+	case line, ok := <-in.lines:
+		return line, ok
+	case <-ctx.Done():
+		return "", false
+	}
+}
+
+// ask prints a prompt and returns the first answer that is one of choices
+func (in *plainInput) ask(ctx context.Context, prompt string, choices ...string) (string, bool) {
+	for {
+		fmt.Print(prompt)
+		line, ok := in.readLine(ctx)
+		if !ok {
+			fmt.Println()
+			return "", false
+		}
+		answer := strings.ToLower(strings.TrimSpace(line))
+		for _, choice := range choices {
+			if answer == choice {
+				return answer, true
+			}
+		}
+		fmt.Println(i18n.T("plain.answer_one_of", strings.Join(choices, ", ")))
+	}
+}
+
+// PlainPermissionChecker asks for approval with one-line prompts on stdin,
+// for screen readers and terminals without cursor control
+type PlainPermissionChecker struct {
+	input       *plainInput
+	permissions *tools.PermissionConfig
+}
+
+func NewPlainPermissionChecker(input *plainInput, permissions *tools.PermissionConfig) *PlainPermissionChecker {
+	return &PlainPermissionChecker{input: input, permissions: permissions}
+}
+
+func (ppc *PlainPermissionChecker) RequestPermission(ctx context.Context, tool string, level tools.PermissionLevel, details string) (bool, error) {
+	targetPath := extractPathFromDetails(tool, details)
+
+	fmt.Println("\n" + i18n.T("plain.permission", tool, plainLevel(level)))
+	fmt.Println(details)
+
+	options := []string{"y", "s", "n"}
+	descriptions := []string{i18n.T("plain.option.once"), i18n.T("plain.option.session")}
+	if tool != "run_command" || targetPath == "" {
+		options = append(options, "a")
+		descriptions = append(descriptions, i18n.T("plain.option.tool"))
+	}
+	if tool == "run_command" {
+		options = append(options, "c")
+		descriptions = append(descriptions, i18n.T("plain.option.command"))
+	}
+	if targetPath != "" {
+		options = append(options, "p")
+		descriptions = append(descriptions, i18n.T("plain.option.path"))
+	}
+	descriptions = append(descriptions, i18n.T("plain.option.no"))
+
+	answer, ok := ppc.input.ask(ctx, i18n.T("plain.allow", strings.Join(descriptions, ", ")), options...)
+	if !ok {
+		return false, ctx.Err()
+	}
+
+	resp := permissionResponse{
+		approved:      answer != "n",
+		alwaysTool:    answer == "a",
+		alwaysCommand: answer == "c",
+		alwaysPath:    answer == "p",
+		session:       answer == "s",
+	}
+	if resp.approved {
+		grantPermission(ppc.permissions, tool, details, targetPath, resp)
+		fmt.Println(i18n.T("plain.allowed"))
+	} else {
+		fmt.Println(i18n.T("plain.denied"))
+	}
+	return resp.approved, nil
+}
+
+// RequestOutsideWorkspace asks whether a tool may use a path outside the workspace
+func (ppc *PlainPermissionChecker) RequestOutsideWorkspace(ctx context.Context, tool, path string) (tools.OutsideWorkspaceDecision, error) {
+	fmt.Println("\n" + i18n.T("plain.outside", tool, path))
+	answer, ok := ppc.input.ask(ctx, i18n.T("plain.outside_options"), "y", "s", "r", "n")
+	if !ok {
+		return tools.OutsideWorkspaceDeny, ctx.Err()
+	}
+
+	switch answer {
+	case "y":
+		return tools.OutsideWorkspaceAllowOnce, nil
+	case "s":
+		return tools.OutsideWorkspaceAddSessionRoot, nil
+	case "r":
+		saveAllowedRoot(tools.RootFor(path))
+		return tools.OutsideWorkspaceAddRoot, nil
+	default:
+		return tools.OutsideWorkspaceDeny, nil
+	}
+}
+
+func plainLevel(level tools.PermissionLevel) string {
+	switch level {
+	case tools.PermissionExecute:
+		return i18n.T("plain.level.execute")
+	case tools.PermissionWrite:
+		return i18n.T("plain.level.write")
+	case tools.PermissionNetwork:
+		return i18n.T("plain.level.network")
+	default:
+		return i18n.T("plain.level.read")
+	}
+}
+
+// PlainCommandExecutor runs commands and copies their output to stdout as it arrives
+type PlainCommandExecutor struct{}
+
+func NewPlainCommandExecutor() *PlainCommandExecutor {
+	return &PlainCommandExecutor{}
+}
+
+func (pce *PlainCommandExecutor) Execute(ctx context.Context, command string) (output string, exitCode int, err error) {
+	fmt.Println(i18n.T("plain.running", command))
+
+	var captured strings.Builder
+	cmd := exec.CommandContext(ctx, "bash", "-c", command)
+	cmd.Dir = tools.WorkDir(ctx)
+	cmd.Stdout = io.MultiWriter(&captured, os.Stdout)
+	cmd.Stderr = io.MultiWriter(&captured, os.Stdout)
+
+	err = cmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitCode = exitErr.ExitCode()
+		}
+	}
+	fmt.Println(i18n.T("plain.exit_code", exitCode))
+
+	return captured.String(), exitCode, err
+}
+
+// RunPlainChat is the chat without the full-screen interface: output is
+// printed line by line with no colours or cursor movement, and prompts are
+// answered by typing a letter and Enter
+func RunPlainChat(ctx context.Context, client *ollama.Client, cfg *config.Config, toolRegistry *tools.Registry, bgBenchmark *BackgroundBenchmark) error {
+	model := cfg.DefaultModel
+	if model == "" {
+		return fmt.Errorf("no default model configured. Please run setup first")
+	}
+
+	store, err := storage.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer store.Close()
+
+	input := newPlainInput(os.Stdin)
+	ag := newChatAgent(client, cfg, toolRegistry, model)
+	watchWorkspace(ag, cfg, toolRegistry)
+	saver := newAutosaver(ag, model, store, newSessionTitler(client, cfg), newSessionSummarizer(client, cfg))
+
+	// Slash commands run against a chat model that is never displayed
+	m := &chatModel{
+		agent:                ag,
+		ctx:                  ctx,
+		bgBenchmark:          bgBenchmark,
+		commands:             newCommandRegistry(client, cfg, toolRegistry),
+		sessionDisabledTools: make(map[string]bool),
+		ctrl:                 newChatController(),
+		autosave:             saver,
+		store:                store,
+	}
+
+	toolRegistry.SetPermissionChecker(NewPlainPermissionChecker(input, toolRegistry.PermissionConfig()))
+	setCommandExecutor(toolRegistry, NewPlainCommandExecutor())
+	// The tool line is printed before the question, so it starts on a new line
+	toolRegistry.SetUserAsker(userAskerFunc(func(ctx context.Context, question string, options []string) (string, error) {
+		fmt.Println(i18n.T("plain.question", question))
+		for i, option := range options {
+			fmt.Printf("  %d. %s\n", i+1, option)
+		}
+		fmt.Print(i18n.T("plain.question_ask"))
+		line, ok := input.readLine(ctx)
+		if !ok {
+			fmt.Println()
+			return "", ctx.Err()
+		}
+		return pickOption(line, options), nil
+	}))
+
+	fmt.Println(i18n.T("plain.intro", model))
+	fmt.Println(i18n.T("plain.usage"))
+	if fit := checkModelFit(ctx, client, cfg, model); fit != nil {
+		fmt.Println(fit.warning())
+	}
+	if release := checkForUpdate(ctx, cfg); release != nil {
+		fmt.Println(i18n.T("update.available", release.Tag, version.Get().Version))
+	}
+
+	if snapshot, err := session.LoadRecovery(); err != nil {
+		logger.Log("RunPlainChat: failed to load recovery file: %v", err)
+	} else if snapshot != nil {
+		fmt.Println(i18n.T("plain.restore_found",
+			snapshot.SavedAt.Format("2006-01-02 15:04"), snapshot.UserMessageCount(), snapshot.Model))
+		answer, ok := input.ask(ctx, i18n.T("plain.restore_ask"), "y", "n")
+		if !ok {
+			return nil
+		}
+		if answer == "y" {
+			ag.RestoreMessages(snapshot.Messages)
+			saver.newSession(snapshot.SessionID)
+			fmt.Println(i18n.T("plain.restored", len(snapshot.Messages)))
+		} else if err := session.ClearRecovery(); err != nil {
+			logger.Log("RunPlainChat: failed to clear recovery file: %v", err)
+		}
+	}
+
+	for {
+		fmt.Print("\n" + i18n.T("chat.you"))
+		line, ok := input.readLine(ctx)
+		if !ok {
+			fmt.Println()
+			break
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if line == "/quit" || line == "/exit" {
+			break
+		}
+
+		if result, isCmd, err := m.commands.Execute(ctx, line, m); isCmd {
+			if err != nil {
+				fmt.Println(i18n.T("chat.command_error", err))
+			} else {
+				fmt.Println(result)
+			}
+			continue
+		}
+
+		release := m.bgBenchmark.HoldForTurn()
+		runPlainTurn(ctx, input, m.agent, store, imageProtocol(cfg.Images), line)
+		release()
+		saver.save()
+	}
+
+	if ctx.Err() != nil {
+		saver.save()
+		return ctx.Err()
+	}
+	if err := session.ClearRecovery(); err != nil {
+		logger.Log("RunPlainChat: failed to clear recovery file: %v", err)
+	}
+	return nil
+}
+
+// runPlainTurn sends one message and prints the reply as it streams in,
+// announcing each tool call on its own line and drawing the images tools
+// produce when the terminal can
+func runPlainTurn(ctx context.Context, input *plainInput, ag *agent.Agent, store storage.Store, images, userMsg string) {
+	lastIteration := -1
+	midLine := false
+	endLine := func() {
+		if midLine {
+			fmt.Println()
+			midLine = false
+		}
+	}
+
+	ag.SetBudgetPrompt(func(ctx context.Context, reason string) bool {
+		endLine()
+		fmt.Println(i18n.T("budget.reason", reason))
+		answer, ok := input.ask(ctx, i18n.T("plain.budget_ask"), "y", "n")
+		return ok && answer == "y"
+	})
+	ag.SetToolObserver(auditObserver(store, func(execution agent.ToolExecution, finished bool) {
+		endLine()
+		switch {
+		case !finished:
+			fmt.Println(i18n.T("plain.tool", execution.Name, plainArgs(execution.Args)))
+		case execution.Error != nil:
+			fmt.Println(i18n.T("plain.tool_failed", execution.Name, agent.FormatToolDuration(execution.Duration), execution.Error))
+		default:
+			fmt.Println(i18n.T("plain.tool_done", execution.Name, agent.FormatToolDuration(execution.Duration)))
+			for _, path := range producedImages(execution) {
+				summary, err := imageSummary(path)
+				if err != nil {
+					continue
+				}
+				fmt.Println(i18n.T("plain.image", path, summary))
+				if images != "" {
+					if err := writeImage(os.Stdout, path, images, terminalColumns()); err != nil {
+						logger.Log("runPlainTurn: %v", err)
+					}
+				}
+			}
+		}
+		lastIteration = -1
+	}))
+
+	started := time.Now()
+	resp, err := ag.ChatStream(ctx, userMsg, func(iteration int, chunk string) {
+		if iteration != lastIteration {
+			endLine()
+			fmt.Print(i18n.T("chat.assistant"))
+			lastIteration = iteration
+		}
+		fmt.Print(chunk)
+		midLine = !strings.HasSuffix(chunk, "\n")
+	})
+	endLine()
+
+	if err != nil {
+		fmt.Println(i18n.T("chat.error", err))
+		return
+	}
+	recordUsage(store, ag.Model(), time.Since(started), resp)
+}
+
+// plainArgs shows tool arguments on one line, leaving out long values
+// such as file contents
+func plainArgs(args map[string]interface{}) string {
+	keys := make([]string, 0, len(args))
+	for key := range args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		text := singleLine(fmt.Sprint(args[key]))
+		if len(text) > 80 {
+			text = fmt.Sprintf("(%d characters)", len(text))
+		}
+		parts = append(parts, key+"="+text)
+	}
+	return strings.Join(parts, ", ")
+}
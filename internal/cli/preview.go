@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PreviewCommand shows the request the next message would send, so a long
+// history can be trimmed before it costs a slow or truncated reply
+type PreviewCommand struct{}
+
+func NewPreviewCommand() *PreviewCommand {
+	return &PreviewCommand{}
+}
+
+func (c *PreviewCommand) Name() string {
+	return "preview"
+}
+
+func (c *PreviewCommand) Description() string {
+	return "Show the full request the next message would send, with estimated tokens, in the side panel (usage: /preview [message])"
+}
+
+func (c *PreviewCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	preview := m.agent.Preview(ctx, strings.Join(args, " "))
+	req := preview.Request
+
+	var sb strings.Builder
+	for i, msg := range req.Messages {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(diffHunkStyle.Render(fmt.Sprintf("── %d. %s · ≈%d tokens ──", i+1, msg.Role, preview.MessageTokens[i])))
+		if msg.Content != "" {
+			sb.WriteString("\n" + msg.Content)
+		}
+		for _, call := range msg.ToolCalls {
+			callArgs, _ := json.Marshal(call.Function.Arguments)
+			sb.WriteString(fmt.Sprintf("\n→ %s %s", call.Function.Name, callArgs))
+		}
+		if len(msg.Images) > 0 {
+			sb.WriteString(fmt.Sprintf("\n🖼️ %d images, not counted", len(msg.Images)))
+		}
+	}
+	if len(req.Tools) > 0 {
+		tools, _ := json.MarshalIndent(req.Tools, "", "  ")
+		sb.WriteString("\n\n" + diffHunkStyle.Render(fmt.Sprintf("── tools: %d definitions · ≈%d tokens ──", len(req.Tools), preview.ToolTokens)))
+		sb.WriteString("\n" + string(tools))
+	}
+
+	if !m.panel.open {
+		m.toggleSplit()
+	}
+	m.showInPanel(panelMsg{title: fmt.Sprintf("🔍 Next request: ≈%d tokens", preview.Tokens), content: sb.String()})
+	m.focusPanel(true)
+
+	result := fmt.Sprintf("🔍 The next request to %s is ≈%d tokens of a %d token budget: %d messages sent of %d in the history", req.Model, preview.Tokens, preview.Budget, len(req.Messages), preview.History)
+	if len(req.Tools) > 0 {
+		result += fmt.Sprintf(", plus %d tool definitions", len(req.Tools))
+	}
+	result += ". It is in the side panel; PgUp/PgDn scroll it and Tab goes back to the input."
+	if preview.Tokens > preview.Budget {
+		result += "\n\n⚠️ That is over the budget. Turn on context_pruning in the config, drop pins you no longer need with /pins unpin <n> or /reset the conversation."
+	}
+	return result, nil
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/i18n"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// questionRequest is a clarifying question from the model; the turn waits
+// for the answer
+type questionRequest struct {
+	question string
+	options  []string
+	selected int // Option Enter picks when nothing is typed
+	response chan string
+}
+
+type questionRequestMsg struct {
+	request *questionRequest
+}
+
+// inlineUserAsker shows the model's questions in the chat UI; the answer is
+// typed into the input box
+type inlineUserAsker struct {
+	program *tea.Program
+}
+
+func (a *inlineUserAsker) AskUser(ctx context.Context, question string, options []string) (string, error) {
+	request := &questionRequest{
+		question: question,
+		options:  options,
+		response: make(chan string, 1),
+	}
+	a.program.Send(questionRequestMsg{request: request})
+
+	select {
+	case answer := <-request.response:
+		return answer, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// userAskerFunc adapts a function to tools.UserAsker
+type userAskerFunc func(ctx context.Context, question string, options []string) (string, error)
+
+func (f userAskerFunc) AskUser(ctx context.Context, question string, options []string) (string, error) {
+	return f(ctx, question, options)
+}
+
+// pickOption turns an option number into the option; other answers are kept
+func pickOption(answer string, options []string) string {
+	answer = strings.TrimSpace(answer)
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(options) {
+		return options[n-1]
+	}
+	return answer
+}
+
+// answerQuestion resolves the pending question
+func (m *chatModel) answerQuestion(answer string) {
+	answer = pickOption(answer, m.pendingQuestion.options)
+	m.messages = append(m.messages, message{role: "system", content: "❓ " + m.pendingQuestion.question})
+	if answer == "" {
+		m.messages = append(m.messages, message{role: "system", content: i18n.T("question.skipped")})
+	} else {
+		m.messages = append(m.messages, message{role: "user", content: answer})
+	}
+	m.pendingQuestion.response <- answer
+	close(m.pendingQuestion.response)
+	m.pendingQuestion = nil
+	m.processingStatus = i18n.T("status.thinking")
+	m.updateViewport()
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/LaPingvino/llemecode/internal/i18n"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxQueueLines is how many queued messages are listed below the status line
+const maxQueueLines = 8
+
+// updateQueueMode handles keys while queued messages are being managed:
+// up/down select, shift+up/down reorder, d or delete removes, e moves the
+// message back to the input and esc leaves the mode
+func (m chatModel) updateQueueMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	queued, _ := m.ctrl.peekQueue()
+
+	switch msg.String() {
+	case "up", "k":
+		if m.queueCursor > 0 {
+			m.queueCursor--
+		}
+	case "down", "j":
+		if m.queueCursor < queued-1 {
+			m.queueCursor++
+		}
+	case "shift+up", "K":
+		if m.ctrl.moveQueued(m.queueCursor, m.queueCursor-1) {
+			m.queueCursor--
+		}
+	case "shift+down", "J":
+		if m.ctrl.moveQueued(m.queueCursor, m.queueCursor+1) {
+			m.queueCursor++
+		}
+	case "d", "x", "delete", "backspace":
+		m.ctrl.removeQueued(m.queueCursor)
+	case "e", "enter":
+		if text, ok := m.ctrl.removeQueued(m.queueCursor); ok {
+			m.textarea.SetValue(text)
+			m.queueMode = false
+		}
+	case "esc":
+		m.queueMode = false
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+
+	m.clampQueueCursor()
+	return m, nil
+}
+
+// clampQueueCursor keeps the selection valid after the queue shrank, leaving
+// queue mode once it is empty
+func (m *chatModel) clampQueueCursor() {
+	queued, _ := m.ctrl.peekQueue()
+	if queued == 0 {
+		m.queueMode = false
+	}
+	if m.queueCursor >= queued {
+		m.queueCursor = queued - 1
+	}
+	if m.queueCursor < 0 {
+		m.queueCursor = 0
+	}
+}
+
+// queueView lists the queued messages, highlighting the selection in queue
+// mode
+func (m chatModel) queueView() string {
+	queue := m.ctrl.queued()
+	if len(queue) == 0 {
+		return ""
+	}
+
+	itemStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	selectedStyle := itemStyle.Reverse(true)
+	width := m.width - 8
+	if width < 20 {
+		width = 20
+	}
+
+	// Keep the selection visible when the queue is longer than the list
+	start := 0
+	if m.queueMode && m.queueCursor >= maxQueueLines {
+		start = m.queueCursor - maxQueueLines + 1
+	}
+
+	var out string
+	for i := start; i < len(queue) && i < start+maxQueueLines; i++ {
+		line := fmt.Sprintf("  %d. %s", i+1, singleLine(queue[i]))
+		if len([]rune(line)) > width {
+			line = string([]rune(line)[:width-3]) + "..."
+		}
+		if m.queueMode && i == m.queueCursor {
+			out += selectedStyle.Render(line) + "\n"
+		} else {
+			out += itemStyle.Render(line) + "\n"
+		}
+	}
+	if hidden := len(queue) - maxQueueLines; hidden > 0 {
+		out += itemStyle.Render(i18n.T("status.queue_more", hidden)) + "\n"
+	}
+	return out
+}
+
+func singleLine(s string) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		if r == '\n' || r == '\r' || r == '\t' {
+			runes[i] = ' '
+		}
+	}
+	return string(runes)
+}
@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/agent"
+	"github.com/LaPingvino/llemecode/internal/i18n"
+	"github.com/LaPingvino/llemecode/internal/logger"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/session"
+	"github.com/LaPingvino/llemecode/internal/storage"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// autosaveInterval is how often the in-flight conversation is written to the recovery file
+const autosaveInterval = 30 * time.Second
+
+type autosaveTickMsg struct{}
+
+// autosaver persists the current conversation so it survives panics, SIGTERM
+// and machine sleep. It is shared by pointer between the chat model copies so
+// the crash handler in RunChat always sees the latest agent.
+type autosaver struct {
+	mu      sync.Mutex
+	agent   *agent.Agent
+	model   string
+	store   storage.Store    // Also keeps the conversation as a session
+	session *storage.Session // Guarded by mu while it is saved
+	titler  *sessionTitler   // Names the session after the first exchange; nil leaves the default title
+	titled  bool             // A title was asked for this session
+	draft   string           // Unsent input, saved with the conversation
+
+	summarizer  *sessionSummarizer // Keeps the session's rolling summary; nil keeps none
+	summarizing bool               // A summary update is running
+	earlier     []ollama.Message   // Turns a resume left to the summary, saved before the agent's history
+}
+
+func newAutosaver(ag *agent.Agent, model string, store storage.Store, titler *sessionTitler, summarizer *sessionSummarizer) *autosaver {
+	return &autosaver{agent: ag, model: model, store: store, titler: titler, summarizer: summarizer, session: &storage.Session{ID: storage.NewSessionID()}}
+}
+
+// sessionID returns the ID the conversation is saved under
+func (a *autosaver) sessionID() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.session.ID
+}
+
+// rename sets the session title, saving it right away once the session has
+// been stored
+func (a *autosaver) rename(title string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.session.Title = title
+	a.titled = true
+	if a.store == nil || len(a.session.Messages) == 0 {
+		return nil
+	}
+	return a.store.SaveSession(a.session)
+}
+
+// newSession saves from now on under a fresh session, e.g. after /reset.
+// An empty id starts a new one; a recovered snapshot passes its own.
+func (a *autosaver) newSession(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if id == "" {
+		id = storage.NewSessionID()
+	}
+	a.session = &storage.Session{ID: id}
+	a.titled = false
+	a.earlier = nil
+	if a.store != nil {
+		// Keep the original title and creation time of a resumed session
+		if saved, err := a.store.LoadSession(id); err == nil {
+			a.session = saved
+		}
+	}
+	// The task list belongs to the session: resumed with it, empty for a new one
+	if a.agent != nil {
+		a.agent.GetToolRegistry().Todos().Restore(a.session.Todos)
+	}
+}
+
+// resumedFrom records that the agent was given the session's messages from
+// start on, after its summary, so the earlier ones are still saved
+func (a *autosaver) resumedFrom(start int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.earlier = append([]ollama.Message(nil), a.session.Messages[:start]...)
+}
+
+// setDraft records the unsent input for the next save
+func (a *autosaver) setDraft(draft string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.draft = draft
+}
+
+// track records the agent that owns the conversation (it changes on /model)
+func (a *autosaver) track(ag *agent.Agent, model string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.agent = ag
+	a.model = model
+}
+
+// save writes the tracked conversation to the recovery file if it has any
+// user turns or a draft
+func (a *autosaver) save() {
+	a.mu.Lock()
+	ag, model, sessionID, draft, earlier := a.agent, a.model, a.session.ID, a.draft, a.earlier
+	a.mu.Unlock()
+
+	if ag == nil {
+		return
+	}
+
+	snapshot := &session.Snapshot{
+		SessionID: sessionID,
+		Model:     model,
+		Messages:  append(earlier[:len(earlier):len(earlier)], ag.GetMessages()...),
+		Draft:     draft,
+	}
+	if snapshot.UserMessageCount() == 0 && draft == "" {
+		return
+	}
+
+	if err := session.SaveRecovery(snapshot); err != nil {
+		logger.Log("autosave: failed to save recovery file: %v", err)
+	}
+
+	// A draft alone doesn't make a session
+	if a.store == nil || snapshot.UserMessageCount() == 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.session.Model = model
+	a.session.Messages = snapshot.Messages
+	a.session.Draft = draft
+	a.session.Todos = ag.GetToolRegistry().Todos().Items()
+	if err := a.store.SaveSession(a.session); err != nil {
+		logger.Log("autosave: failed to save session: %v", err)
+		return
+	}
+	if a.titler != nil && !a.titled && a.session.HasDefaultTitle() {
+		a.titled = true
+		go a.generateTitle(sessionID, model, snapshot.Messages)
+	}
+	if a.summarizer != nil && !a.summarizing {
+		if turns, msgs, ok := a.summarizer.due(a.session); ok {
+			a.summarizing = true
+			go a.updateSummary(sessionID, model, a.session.Summary, a.session.SummaryTurns, turns, msgs)
+		}
+	}
+}
+
+// updateSummary folds msgs into the session summary in the background. The
+// result is dropped if the session changed meanwhile.
+func (a *autosaver) updateSummary(sessionID, model, previous string, from, turns int, msgs []ollama.Message) {
+	summary, err := a.summarizer.summarize(context.Background(), model, previous, msgs)
+	if err != nil {
+		logger.Log("autosave: %v", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.summarizing = false
+	if err != nil || a.session.ID != sessionID || a.session.SummaryTurns != from {
+		return
+	}
+	a.session.Summary, a.session.SummaryTurns = summary, turns
+	if err := a.store.SaveSession(a.session); err != nil {
+		logger.Log("autosave: failed to save session summary: %v", err)
+	}
+}
+
+// generateTitle names a session in the background, unless the user renamed
+// it or moved on to another session meanwhile
+func (a *autosaver) generateTitle(sessionID, model string, msgs []ollama.Message) {
+	title, ok, err := a.titler.title(context.Background(), model, msgs)
+	if err != nil {
+		logger.Log("autosave: %v", err)
+	}
+	if !ok || title == "" {
+		a.mu.Lock()
+		// Try again after the next exchange if there was no reply to name yet
+		if err == nil && !ok && a.session.ID == sessionID {
+			a.titled = false
+		}
+		a.mu.Unlock()
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.session.ID != sessionID || !a.session.HasDefaultTitle() {
+		return
+	}
+	a.session.Title = title
+	if err := a.store.SaveSession(a.session); err != nil {
+		logger.Log("autosave: failed to save session title: %v", err)
+	}
+}
+
+func autosaveTick() tea.Cmd {
+	return tea.Tick(autosaveInterval, func(time.Time) tea.Msg {
+		return autosaveTickMsg{}
+	})
+}
+
+// draft returns the unsent input, with its pastes filled in since they
+// aren't saved
+func (m *chatModel) draft() string {
+	full, _, _ := m.pastes.expand(m.textarea.Value())
+	return full
+}
+
+// restoreDraft puts a saved draft back in the input
+func (m *chatModel) restoreDraft(draft string) {
+	if draft == "" {
+		return
+	}
+	m.textarea.SetValue(m.pastes.paste(draft))
+	m.messages = append(m.messages, message{role: "system", content: i18n.T("chat.draft_restored")})
+}
+
+// restorePromptText describes a recovered session for the restore prompt
+func restorePromptText(snapshot *session.Snapshot) string {
+	return i18n.T("chat.restore_prompt",
+		snapshot.SavedAt.Format("2006-01-02 15:04"), snapshot.UserMessageCount(), snapshot.Model)
+}
+
+// transcriptFromMessages rebuilds the visible transcript from agent messages
+func transcriptFromMessages(msgs []ollama.Message) []message {
+	var transcript []message
+	for _, msg := range msgs {
+		switch msg.Role {
+		case "user":
+			transcript = append(transcript, message{role: "user", content: msg.Content})
+		case "assistant":
+			if msg.Content != "" {
+				transcript = append(transcript, message{role: "assistant", content: msg.Content, meta: msg.Meta})
+			}
+		case "tool":
+			transcript = append(transcript, message{role: "tool", content: fmt.Sprintf("🔧 Tool: %s\n✅ Result:\n%s\n", msg.ToolName, msg.Content)})
+		}
+	}
+	return transcript
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/replay"
+	"github.com/LaPingvino/llemecode/internal/storage"
+	"github.com/LaPingvino/llemecode/internal/tools"
+)
+
+// RunReplay sends the user turns of a stored session to model and prints how
+// its replies and tool calls differ from the recorded ones. Progress goes to
+// status and the report to w.
+func RunReplay(ctx context.Context, w, status io.Writer, client *ollama.Client, cfg *config.Config, toolRegistry *tools.Registry, sessionID, model, mode string) error {
+	replayer, err := replay.New(client, cfg, toolRegistry, model, mode)
+	if err != nil {
+		return err
+	}
+
+	store, err := storage.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer store.Close()
+	sess, err := store.LoadSession(sessionID)
+	if err != nil {
+		return fmt.Errorf("load session %s: %w", sessionID, err)
+	}
+	turns := len(replay.Turns(sess.Messages))
+	if turns == 0 {
+		return fmt.Errorf("session %s has no user messages to replay", sessionID)
+	}
+
+	fmt.Fprintf(status, "🔁 Replaying %d turns of %q with %s (tools: %s)\n", turns, sess.Title, model, mode)
+	results := replayer.Run(ctx, sess.Messages, func(r replay.Result) {
+		if r.Err != nil {
+			fmt.Fprintf(status, "  ✗ turn %d/%d: %v\n", r.Turn, turns, r.Err)
+			return
+		}
+		fmt.Fprintf(status, "  ✓ turn %d/%d\n", r.Turn, turns)
+	})
+	fmt.Fprint(w, replay.Report(sess.Title, sess.Model, model, results))
+	return ctx.Err()
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/review"
+)
+
+// reviewDoneMsg carries a finished (or stopped) review back to the chat
+type reviewDoneMsg struct {
+	report *review.Report
+	err    error
+}
+
+// ReviewCommand reviews a diff chunk by chunk with the current model
+type ReviewCommand struct {
+	client *ollama.Client
+	cfg    *config.Config
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // Stops the running review; nil when idle
+}
+
+func NewReviewCommand(client *ollama.Client, cfg *config.Config) *ReviewCommand {
+	return &ReviewCommand{client: client, cfg: cfg}
+}
+
+func (c *ReviewCommand) Name() string {
+	return "review"
+}
+
+func (c *ReviewCommand) Description() string {
+	return "Review uncommitted changes, a branch or a commit range and list findings by severity (usage: /review [ref|a..b] | /review stop)"
+}
+
+func (c *ReviewCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	if len(args) > 1 {
+		return "", fmt.Errorf("usage: /review [ref|a..b] | /review stop")
+	}
+	ref := ""
+	if len(args) == 1 {
+		ref = args[0]
+	}
+	if ref == "stop" {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.cancel == nil {
+			return "No review is running", nil
+		}
+		c.cancel()
+		return "⏹ Stopping the review", nil
+	}
+
+	diff, description, err := review.Diff(ctx, ".", ref)
+	if err != nil {
+		return "", err
+	}
+	chunks := review.Split(diff)
+	if len(chunks) == 0 {
+		return fmt.Sprintf("Nothing to review in %s", description), nil
+	}
+	p := m.ctrl.currentProgram()
+	if p == nil {
+		return "", fmt.Errorf("reviews need the interactive chat")
+	}
+
+	c.mu.Lock()
+	if c.cancel != nil {
+		c.mu.Unlock()
+		return "", fmt.Errorf("a review is already running (/review stop ends it)")
+	}
+	reviewCtx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+	c.mu.Unlock()
+
+	prompt := c.cfg.SystemPrompts["review"]
+	if prompt == "" {
+		// Configs written before reviews existed have no review prompt
+		prompt = config.DefaultConfig().SystemPrompts["review"]
+	}
+	reviewer := review.New(c.client, m.agent.Model(), prompt)
+	reviewer.SetProgress(newInlineModelProgress(p))
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.cancel()
+			c.cancel = nil
+			c.mu.Unlock()
+		}()
+		report, err := reviewer.Review(reviewCtx, description, chunks)
+		p.Send(reviewDoneMsg{report: report, err: err})
+	}()
+
+	return fmt.Sprintf("🔍 Reviewing %s with %s, chunk by chunk (%d in all); the report follows when it is done", description, m.agent.Model(), len(chunks)), nil
+}
@@ -0,0 +1,243 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/storage"
+)
+
+// sessionListLimit is how many sessions /sessions lists
+const sessionListLimit = 20
+
+const titlePrompt = `Write a title of at most six words for the conversation below. Reply with the title only, without quotes or a trailing period.
+
+User: %s
+
+Assistant: %s`
+
+// sessionTitler asks a model for a short session title
+type sessionTitler struct {
+	client *ollama.Client
+	cfg    *config.Config
+}
+
+func newSessionTitler(client *ollama.Client, cfg *config.Config) *sessionTitler {
+	return &sessionTitler{client: client, cfg: cfg}
+}
+
+// title names a conversation from its first exchange. chatModel is used when
+// no title model is configured; ok is false when titles are turned off.
+func (t *sessionTitler) title(ctx context.Context, chatModel string, msgs []ollama.Message) (title string, ok bool, err error) {
+	model := t.cfg.TitleModel
+	if model == "none" {
+		return "", false, nil
+	}
+	if model == "" {
+		model = chatModel
+	}
+
+	var question, answer string
+	for _, msg := range msgs {
+		if msg.Role == "user" && question == "" {
+			question = msg.Content
+		} else if msg.Role == "assistant" && question != "" && msg.Content != "" {
+			answer = msg.Content
+			break
+		}
+	}
+	if answer == "" {
+		return "", false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	resp, err := t.client.Chat(ctx, ollama.ChatRequest{
+		Model: model,
+		Messages: []ollama.Message{{
+			Role:    "user",
+			Content: fmt.Sprintf(titlePrompt, truncateRunes(question, 1000), truncateRunes(answer, 1000)),
+		}},
+	})
+	if err != nil {
+		return "", true, fmt.Errorf("generate title: %w", err)
+	}
+	title = cleanTitle(resp.Message.Content)
+	if title == "" {
+		return "", true, fmt.Errorf("generate title: %s gave an empty reply", model)
+	}
+	return title, true, nil
+}
+
+// cleanTitle keeps the first line of a reply without quotes, markup or
+// thinking, shortened to fit a list
+func cleanTitle(reply string) string {
+	if i := strings.LastIndex(reply, "</think>"); i >= 0 {
+		reply = reply[i+len("</think>"):]
+	}
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.Trim(strings.TrimSpace(line), `"'*#`+"`")
+		line = strings.TrimPrefix(line, "Title:")
+		line = strings.TrimSuffix(strings.TrimSpace(line), ".")
+		if line != "" {
+			return truncateRunes(line, 60)
+		}
+	}
+	return ""
+}
+
+func truncateRunes(s string, max int) string {
+	if r := []rune(s); len(r) > max {
+		return string(r[:max-1]) + "…"
+	}
+	return s
+}
+
+// SessionsCommand lists stored sessions and resumes one
+type SessionsCommand struct {
+	cfg    *config.Config
+	listed []storage.SessionInfo
+}
+
+func NewSessionsCommand(cfg *config.Config) *SessionsCommand {
+	return &SessionsCommand{cfg: cfg}
+}
+
+func (c *SessionsCommand) Name() string {
+	return "sessions"
+}
+
+func (c *SessionsCommand) Description() string {
+	return "List past sessions by title (usage: /sessions [all] | /sessions resume <n|last>)"
+}
+
+func (c *SessionsCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	if m.store == nil {
+		return "", fmt.Errorf("no session storage available")
+	}
+	if len(args) == 2 && args[0] == "resume" {
+		return c.resume(m, args[1])
+	}
+	if len(args) > 1 || (len(args) == 1 && args[0] != "all") {
+		return "", fmt.Errorf("usage: /sessions [all] | /sessions resume <n|last>")
+	}
+
+	sessions, err := m.store.ListSessions()
+	if err != nil {
+		return "", err
+	}
+	if len(sessions) == 0 {
+		return "No saved sessions yet.", nil
+	}
+	shown := sessions
+	if len(args) == 0 && len(shown) > sessionListLimit {
+		shown = shown[:sessionListLimit]
+	}
+	c.listed = shown
+
+	current := m.autosave.sessionID()
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🗂 %d sessions:\n\n", len(sessions)))
+	for i, s := range shown {
+		marker := ""
+		if s.ID == current {
+			marker = " ← current"
+		}
+		sb.WriteString(fmt.Sprintf("%d. %s · %s (%s, %d turns)%s\n",
+			i+1, s.Updated.Format("2006-01-02 15:04"), s.Title, s.Model, s.Turns, marker))
+	}
+	if len(shown) < len(sessions) {
+		sb.WriteString(fmt.Sprintf("\n... and %d older (/sessions all)", len(sessions)-len(shown)))
+	}
+	sb.WriteString("\nResume one with /sessions resume <n>, name this one with /rename <title>")
+	return sb.String(), nil
+}
+
+func (c *SessionsCommand) resume(m *chatModel, arg string) (string, error) {
+	if m.waiting {
+		return "", fmt.Errorf("a response is in progress; wait for it or press Esc before resuming a session")
+	}
+	id, err := c.sessionToResume(m, arg)
+	if err != nil {
+		return "", err
+	}
+	s, err := m.store.LoadSession(id)
+	if err != nil {
+		return "", err
+	}
+
+	// The current conversation is already saved under its own session
+	m.autosave.save()
+	m.agent.ClearHistory()
+	start := resumeStart(s, c.cfg, m.agent)
+	if start > 0 {
+		m.agent.RestoreSummarized(s.Summary, s.Messages[start:])
+	} else {
+		m.agent.RestoreMessages(s.Messages)
+	}
+	m.autosave.newSession(s.ID)
+	if start > 0 {
+		m.autosave.resumedFrom(start)
+	}
+	m.messages = transcriptFromMessages(s.Messages)
+	m.restoreDraft(s.Draft)
+	m.updateViewport()
+	if start > 0 {
+		return fmt.Sprintf("✓ Resumed %q (%d messages): the model gets a summary of the first %d turns and the %d messages since", s.Title, len(s.Messages), s.SummaryTurns, len(s.Messages)-start), nil
+	}
+	return fmt.Sprintf("✓ Resumed %q (%d messages)", s.Title, len(s.Messages)), nil
+}
+
+// sessionToResume returns the ID of session n in the last list, or of the
+// newest session other than this one for "last"
+func (c *SessionsCommand) sessionToResume(m *chatModel, arg string) (string, error) {
+	if arg == "last" {
+		sessions, err := m.store.ListSessions()
+		if err != nil {
+			return "", err
+		}
+		current := m.autosave.sessionID()
+		for _, s := range sessions {
+			if s.ID != current {
+				return s.ID, nil
+			}
+		}
+		return "", fmt.Errorf("no earlier session to resume")
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(c.listed) {
+		return "", fmt.Errorf("no session %s in the last list (run /sessions first)", arg)
+	}
+	return c.listed[n-1].ID, nil
+}
+
+// RenameCommand sets the title of the current session
+type RenameCommand struct{}
+
+func NewRenameCommand() *RenameCommand {
+	return &RenameCommand{}
+}
+
+func (c *RenameCommand) Name() string {
+	return "rename"
+}
+
+func (c *RenameCommand) Description() string {
+	return "Set the title of this session (usage: /rename <title>)"
+}
+
+func (c *RenameCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	title := strings.Join(args, " ")
+	if title == "" {
+		return "", fmt.Errorf("usage: /rename <title>")
+	}
+	if err := m.autosave.rename(title); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("✓ Session renamed to %q", title), nil
+}
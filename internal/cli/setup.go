@@ -22,11 +22,12 @@ type setupModel struct {
 	done        bool
 	err         error
 	ctx         context.Context
-	progressCh  chan string
+	progressCh  chan benchmark.Progress
+	progress    benchmark.ProgressState
 	benchmarker *benchmark.Benchmarker
 }
 
-type progressMsg string
+type progressMsg benchmark.Progress
 type doneMsg struct {
 	err error
 }
@@ -51,15 +52,25 @@ var (
 			MarginLeft(2)
 )
 
-func RunSetup(ctx context.Context, client *ollama.Client, cfg *config.Config) error {
-	progressCh := make(chan string, 100)
+// RunSetup benchmarks models added or changed since the last run, or every
+// model when all is set, or just the models matching names when given.
+// evaluator picks the model that grades the results; when empty the
+// configured evaluator is used.
+func RunSetup(ctx context.Context, client *ollama.Client, cfg *config.Config, evaluator string, all bool, names []string) error {
+	if err := benchmark.ValidateTasks(cfg.BenchmarkTasks); err != nil {
+		return fmt.Errorf("invalid benchmark tasks: %w", err)
+	}
 
-	benchmarker := benchmark.New(client, cfg.BenchmarkTasks)
+	progressCh := make(chan benchmark.Progress, 100)
 
-	// If a default model is set, use it as the evaluator
-	if cfg.DefaultModel != "" {
-		progressCh <- fmt.Sprintf("Using %s to evaluate other models", cfg.DefaultModel)
-		benchmarker.SetEvaluator(cfg.DefaultModel)
+	benchmarker := benchmark.New(client, cfg.BenchmarkTasks)
+	benchmarker.Configure(cfg)
+	benchmarker.SetEvaluator(evaluator)
+	// The other models keep their previous scores when only some are re-run
+	if !all || len(names) > 0 {
+		if err := loadPreviousResults(benchmarker); err != nil {
+			return err
+		}
 	}
 
 	m := setupModel{
@@ -80,24 +91,31 @@ func RunSetup(ctx context.Context, client *ollama.Client, cfg *config.Config) er
 
 	// Start benchmarking in background
 	go func() {
-		scores, err := m.benchmarker.BenchmarkAll(ctx, progressCh)
+		var scores []benchmark.ModelScore
+		var err error
+		if len(names) > 0 {
+			scores, err = m.benchmarker.BenchmarkModels(ctx, names, progressCh)
+		} else {
+			scores, err = m.benchmarker.BenchmarkAll(ctx, progressCh)
+		}
 		if err != nil {
 			p.Send(doneMsg{err: err})
 			return
 		}
 
-		// Update config with benchmark results
-		m.benchmarker.UpdateConfig(cfg, scores)
-
 		// Save config
 		configDir, err := config.GetConfigDir()
 		if err != nil {
-			progressCh <- fmt.Sprintf("Warning: Could not get config dir: %v", err)
+			progressCh <- benchmark.Progress{Message: fmt.Sprintf("Warning: Could not get config dir: %v", err)}
 		} else {
-			progressCh <- fmt.Sprintf("\nSaving configuration to %s", configDir)
+			progressCh <- benchmark.Progress{Message: fmt.Sprintf("\nSaving configuration to %s", configDir)}
 		}
 
-		if err := cfg.Save(); err != nil {
+		// Update config with benchmark results
+		err = cfg.Update(func(c *config.Config) {
+			m.benchmarker.UpdateConfig(c, scores)
+		})
+		if err != nil {
 			p.Send(doneMsg{err: err})
 			return
 		}
@@ -105,10 +123,13 @@ func RunSetup(ctx context.Context, client *ollama.Client, cfg *config.Config) er
 		// Save benchmark results
 		resultsPath := configDir + "/benchmark_results.json"
 		if err := m.benchmarker.SaveResults(scores, resultsPath); err != nil {
-			progressCh <- fmt.Sprintf("Warning: Could not save benchmark results: %v", err)
+			progressCh <- benchmark.Progress{Message: fmt.Sprintf("Warning: Could not save benchmark results: %v", err)}
+		}
+		if err := recordBenchmarkRun(cfg, scores); err != nil {
+			progressCh <- benchmark.Progress{Message: fmt.Sprintf("Warning: Could not save benchmark history: %v", err)}
 		}
 
-		progressCh <- fmt.Sprintf("\n✓ Setup complete! Default model: %s", cfg.DefaultModel)
+		progressCh <- benchmark.Progress{Message: fmt.Sprintf("\n✓ Setup complete! Default model: %s", cfg.DefaultModel)}
 		p.Send(doneMsg{err: nil})
 	}()
 
@@ -137,11 +158,15 @@ func (m setupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 
 	case progressMsg:
-		m.status = string(msg)
-		m.logs = append(m.logs, string(msg))
-		// Keep only last 15 lines
-		if len(m.logs) > 15 {
-			m.logs = m.logs[len(m.logs)-15:]
+		event := benchmark.Progress(msg)
+		m.progress.Apply(event)
+		if event.Message != "" {
+			m.status = strings.TrimSpace(event.Message)
+			m.logs = append(m.logs, event.Message)
+			// Keep only last 15 lines
+			if len(m.logs) > 15 {
+				m.logs = m.logs[len(m.logs)-15:]
+			}
 		}
 		return m, waitForProgress(m.progressCh)
 
@@ -174,6 +199,21 @@ func (m setupModel) View() string {
 
 	s.WriteString(fmt.Sprintf("%s %s\n\n", m.spinner.View(), statusStyle.Render(m.status)))
 
+	if p := m.progress; p.ModelCount > 0 {
+		line := fmt.Sprintf("%s %d/%d models", benchmark.ProgressBar(p.Fraction(), 30), p.ModelsDone, p.ModelCount)
+		if p.Model != "" && p.ModelsDone < p.ModelCount {
+			line += fmt.Sprintf(" • %s", p.Model)
+			if p.Step != "" {
+				line += fmt.Sprintf(": %s (%d/%d)", p.Step, p.StepIndex, p.StepCount)
+			}
+		}
+		s.WriteString(statusStyle.Render(line) + "\n")
+		for _, score := range p.Finished {
+			s.WriteString(logStyle.Render(fmt.Sprintf("✓ %s %.2f", score.Model, score.TotalScore)) + "\n")
+		}
+		s.WriteString("\n")
+	}
+
 	// Show recent logs
 	if len(m.logs) > 0 {
 		s.WriteString(statusStyle.Render("Progress:") + "\n")
@@ -187,7 +227,7 @@ func (m setupModel) View() string {
 	return s.String()
 }
 
-func waitForProgress(progressCh chan string) tea.Cmd {
+func waitForProgress(progressCh chan benchmark.Progress) tea.Cmd {
 	return func() tea.Msg {
 		msg, ok := <-progressCh
 		if !ok {
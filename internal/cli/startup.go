@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/i18n"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	startupOutputLimit = 4000 // Runes of a startup shell command's output shown
+	startupTimeout     = time.Minute
+)
+
+// startupMsg starts the startup actions once the chat is up
+type startupMsg struct{}
+
+// startupCommandMsg runs a slash command from the startup actions
+type startupCommandMsg struct {
+	line string
+}
+
+// startupOutputMsg carries what a startup shell command printed
+type startupOutputMsg struct {
+	command string
+	output  string
+	err     error
+}
+
+// startupFor returns the startup settings for dir. Projects containing dir
+// override the welcome and actions they set, nested ones last.
+func startupFor(cfg config.StartupConfig, dir string) config.StartupConfig {
+	var roots []string
+	projects := make(map[string]config.StartupConfig)
+	for path, project := range cfg.Projects {
+		root, err := filepath.Abs(expandHome(path))
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(root, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		roots = append(roots, root)
+		projects[root] = project
+	}
+	sort.Slice(roots, func(i, j int) bool { return len(roots[i]) < len(roots[j]) })
+
+	result := config.StartupConfig{Welcome: cfg.Welcome, Actions: cfg.Actions}
+	for _, root := range roots {
+		project := projects[root]
+		if project.Welcome != "" {
+			result.Welcome = project.Welcome
+		}
+		if project.Actions != nil {
+			result.Actions = project.Actions
+		}
+	}
+	return result
+}
+
+// welcomeMessage fills in a configured welcome, or returns the built-in one
+func welcomeMessage(welcome, model, dir string) string {
+	if welcome == "" {
+		return i18n.T("chat.welcome", model)
+	}
+	welcome = strings.ReplaceAll(welcome, "{{MODEL}}", model)
+	return strings.ReplaceAll(welcome, "{{PROJECT}}", filepath.Base(dir))
+}
+
+// startupCmd runs the startup actions that haven't run yet, one after
+// another: slash commands as if typed, !commands in the shell and anything
+// else as a message to the model, queued behind the ones before it
+func (m *chatModel) startupCmd() tea.Cmd {
+	actions := m.startup
+	m.startup = nil
+	var cmds []tea.Cmd
+	for _, action := range actions {
+		action := strings.TrimSpace(action)
+		switch {
+		case action == "":
+		case strings.HasPrefix(action, "/"):
+			cmds = append(cmds, func() tea.Msg { return startupCommandMsg{line: action} })
+		case strings.HasPrefix(action, "!"):
+			ctx := m.ctx
+			cmds = append(cmds, func() tea.Msg { return runStartupShell(ctx, strings.TrimSpace(action[1:])) })
+		default:
+			cmds = append(cmds, func() tea.Msg { return sendPromptMsg{text: action} })
+		}
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+	return tea.Sequence(cmds...)
+}
+
+// runStartupShell runs a startup shell command in the working directory
+func runStartupShell(ctx context.Context, command string) startupOutputMsg {
+	ctx, cancel := context.WithTimeout(ctx, startupTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "sh", "-c", command).CombinedOutput()
+	return startupOutputMsg{command: command, output: strings.TrimRight(string(output), "\n"), err: err}
+}
+
+// startupOutput renders what a startup shell command printed
+func startupOutput(msg startupOutputMsg) string {
+	output := truncateRunes(msg.output, startupOutputLimit)
+	if output == "" {
+		output = "(no output)"
+	}
+	return "🚀 `$ " + msg.command + "`\n\n```\n" + output + "\n```"
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/benchmark"
+)
+
+const (
+	defaultStatsDays   = 30
+	statsBenchmarkRuns = 5
+)
+
+// StatsCommand reports what chats cost per model and the latest benchmark runs
+type StatsCommand struct{}
+
+func NewStatsCommand() *StatsCommand {
+	return &StatsCommand{}
+}
+
+func (c *StatsCommand) Name() string {
+	return "stats"
+}
+
+func (c *StatsCommand) Description() string {
+	return "Show turns, tokens and time per model and the latest benchmark runs (usage: /stats [days])"
+}
+
+func (c *StatsCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	if m.store == nil {
+		return "", fmt.Errorf("no storage available")
+	}
+	days := defaultStatsDays
+	if len(args) == 1 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return "", fmt.Errorf("usage: /stats [days]")
+		}
+		days = n
+	} else if len(args) > 1 {
+		return "", fmt.Errorf("usage: /stats [days]")
+	}
+
+	usage, err := m.store.Usage(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return "", err
+	}
+	type modelUsage struct {
+		turns, prompt, completion, toolCalls int
+		duration                             time.Duration
+	}
+	byModel := make(map[string]*modelUsage)
+	var models []string
+	for _, u := range usage {
+		mu := byModel[u.Model]
+		if mu == nil {
+			mu = &modelUsage{}
+			byModel[u.Model] = mu
+			models = append(models, u.Model)
+		}
+		mu.turns++
+		mu.prompt += u.PromptTokens
+		mu.completion += u.CompletionTokens
+		mu.toolCalls += u.ToolCalls
+		mu.duration += u.Duration
+	}
+	sort.Slice(models, func(i, j int) bool { return byModel[models[i]].turns > byModel[models[j]].turns })
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## 📊 Last %d days\n\n", days))
+	if len(models) == 0 {
+		sb.WriteString("No chat turns recorded.\n")
+	} else {
+		sb.WriteString("| Model | Turns | Prompt tokens | Completion tokens | Tool calls | Avg per turn |\n|---|---|---|---|---|---|\n")
+		for _, model := range models {
+			mu := byModel[model]
+			sb.WriteString(fmt.Sprintf("| %s | %d | %d | %d | %d | %s |\n",
+				model, mu.turns, mu.prompt, mu.completion, mu.toolCalls, (mu.duration / time.Duration(mu.turns)).Round(100*time.Millisecond)))
+		}
+	}
+
+	runs, err := m.store.BenchmarkRuns(statsBenchmarkRuns)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString("\n### Benchmark runs\n\n")
+	if len(runs) == 0 {
+		sb.WriteString("None yet; run /benchmark.\n")
+	}
+	for _, run := range runs {
+		var scores []benchmark.ModelScore
+		if err := json.Unmarshal(run.Results, &scores); err != nil {
+			continue
+		}
+		sort.Slice(scores, func(i, j int) bool { return scores[i].TotalScore > scores[j].TotalScore })
+		line := fmt.Sprintf("- %s: %d models", run.Time.Format("2006-01-02 15:04"), len(scores))
+		if len(scores) > 0 {
+			line += fmt.Sprintf(", best %s (%.2f)", scores[0].Model, scores[0].TotalScore)
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String(), nil
+}
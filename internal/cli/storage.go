@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/agent"
+	"github.com/LaPingvino/llemecode/internal/benchmark"
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/logger"
+	"github.com/LaPingvino/llemecode/internal/storage"
+)
+
+// auditObserver writes every finished tool call to the audit log and then
+// passes it on to next, if set
+func auditObserver(store storage.Store, next agent.ToolObserver) agent.ToolObserver {
+	return func(execution agent.ToolExecution, finished bool) {
+		if finished {
+			args, _ := json.Marshal(execution.Args)
+			entry := storage.AuditEntry{Time: time.Now(), Tool: execution.Name, Args: string(args), Duration: execution.Duration}
+			if execution.Error != nil {
+				entry.Error = execution.Error.Error()
+			}
+			if err := store.AppendAudit(entry); err != nil {
+				logger.Log("audit: %v", err)
+			}
+		}
+		if next != nil {
+			next(execution, finished)
+		}
+	}
+}
+
+// recordUsage stores what a finished turn cost
+func recordUsage(store storage.Store, model string, elapsed time.Duration, resp *agent.Response) {
+	err := store.RecordUsage(storage.Usage{
+		Time:             time.Now(),
+		Model:            model,
+		Duration:         elapsed,
+		PromptTokens:     resp.PromptTokens,
+		CompletionTokens: resp.CompletionTokens,
+		ToolCalls:        len(resp.ToolCalls),
+	})
+	if err != nil {
+		logger.Log("usage: %v", err)
+	}
+}
+
+// recordBenchmarkRun adds finished results to the benchmark history. It
+// opens its own store because benchmarks also run outside a chat.
+func recordBenchmarkRun(cfg *config.Config, scores []benchmark.ModelScore) error {
+	store, err := storage.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	results, err := json.Marshal(scores)
+	if err != nil {
+		return err
+	}
+	return store.SaveBenchmarkRun(storage.BenchmarkRun{Time: time.Now(), Results: results})
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/agent"
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/storage"
+)
+
+const (
+	defaultSummaryKeep = 20
+	summaryStep        = 2000 // Estimated tokens of new history worth updating the summary for
+	summaryToolLimit   = 1000 // Runes of a tool result the summarizer sees
+)
+
+const summaryPrompt = `Summarize the conversation below between a user and a coding assistant, so it can be continued from the summary alone. Keep the goals, the decisions and why they were made, the files, commands and names involved, what was done and what is still open. Leave out pleasantries and tool output that no longer matters. Reply with the summary only.
+
+%s`
+
+const summaryUpdatePrompt = `Below is a summary of a conversation between a user and a coding assistant, followed by how the conversation went on. Write one updated summary that covers both, so the conversation can be continued from it alone. Keep the goals, the decisions and why they were made, the files, commands and names involved, what was done and what is still open. Reply with the summary only.
+
+Summary so far:
+%s
+
+The conversation went on:
+%s`
+
+// sessionSummarizer keeps a rolling summary of a session's older turns
+type sessionSummarizer struct {
+	client *ollama.Client
+	cfg    *config.Config
+}
+
+func newSessionSummarizer(client *ollama.Client, cfg *config.Config) *sessionSummarizer {
+	return &sessionSummarizer{client: client, cfg: cfg}
+}
+
+// due returns the user turns the summary of session should cover next and the
+// messages it doesn't cover yet, once enough history is left to cover.
+// The newest messages are never summarized.
+func (s *sessionSummarizer) due(session *storage.Session) (turns int, msgs []ollama.Message, ok bool) {
+	settings := s.cfg.SessionSummary
+	if !settings.Enabled {
+		return 0, nil, false
+	}
+	keep := settings.KeepRecent
+	if keep <= 0 {
+		keep = defaultSummaryKeep
+	}
+
+	start := turnStart(session.Messages, session.SummaryTurns)
+	end := len(session.Messages) - keep
+	// End at a question so replies and tool results stay with it
+	for end > start && session.Messages[end].Role != "user" {
+		end--
+	}
+	if end <= start || agent.EstimateTokens(session.Messages[start:end]) < summaryStep {
+		return 0, nil, false
+	}
+	turns = session.SummaryTurns
+	for _, msg := range session.Messages[start:end] {
+		if msg.Role == "user" {
+			turns++
+		}
+	}
+	return turns, session.Messages[start:end], true
+}
+
+// summarize folds msgs into the previous summary. chatModel is used when no
+// summary model is configured.
+func (s *sessionSummarizer) summarize(ctx context.Context, chatModel, previous string, msgs []ollama.Message) (string, error) {
+	model := s.cfg.SessionSummary.Model
+	if model == "" {
+		model = chatModel
+	}
+
+	var transcript []ollama.Message
+	for _, msg := range msgs {
+		if msg.Role == "tool" {
+			msg.Content = truncateRunes(msg.Content, summaryToolLimit)
+		}
+		transcript = append(transcript, msg)
+	}
+	prompt := fmt.Sprintf(summaryPrompt, formatExchange(transcript))
+	if previous != "" {
+		prompt = fmt.Sprintf(summaryUpdatePrompt, previous, formatExchange(transcript))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	resp, err := s.client.Chat(ctx, ollama.ChatRequest{
+		Model:    model,
+		Messages: []ollama.Message{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("summarize session: %w", err)
+	}
+	summary := resp.Message.Content
+	if i := strings.LastIndex(summary, "</think>"); i >= 0 {
+		summary = summary[i+len("</think>"):]
+	}
+	summary = strings.TrimSpace(summary)
+	if summary == "" {
+		return "", fmt.Errorf("summarize session: %s gave an empty reply", model)
+	}
+	return summary, nil
+}
+
+// resumeStart is where resuming s with the model of ag starts replaying
+// messages, after the turns its summary covers. It is 0, replaying them
+// all, when there is no summary or the whole history fits.
+func resumeStart(s *storage.Session, cfg *config.Config, ag *agent.Agent) int {
+	settings := cfg.SessionSummary
+	if !settings.Enabled || s.Summary == "" || s.SummaryTurns == 0 {
+		return 0
+	}
+	limit := settings.MaxTokens
+	if limit <= 0 {
+		limit = ag.ContextLength() / 2
+	}
+	if agent.EstimateTokens(s.Messages) <= limit {
+		return 0
+	}
+	start := turnStart(s.Messages, s.SummaryTurns)
+	if start >= len(s.Messages) {
+		return 0
+	}
+	return start
+}
+
+// turnStart is the index of the user message that follows the first turns
+// user messages, or len(msgs) if there is none
+func turnStart(msgs []ollama.Message, turns int) int {
+	seen := 0
+	for i, msg := range msgs {
+		if msg.Role != "user" {
+			continue
+		}
+		if seen == turns {
+			return i
+		}
+		seen++
+	}
+	return len(msgs)
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/ollama"
+)
+
+// stallAfter is how long a stream may go quiet before the status line says so
+const stallAfter = 10 * time.Second
+
+// formatWaitTiming describes a running task for the status line, e.g.
+// "12s" while waiting for the model and "20s · first token 3.2s" once it
+// streams
+func formatWaitTiming(t taskTiming) string {
+	s := formatDuration(t.elapsed)
+	if t.firstToken > 0 {
+		s += " · first token " + formatDuration(t.firstToken)
+		if t.idle >= stallAfter {
+			s += fmt.Sprintf(" · no output for %s", formatDuration(t.idle))
+		}
+	}
+	return s
+}
+
+// formatTurnTiming summarizes a finished turn for the transcript
+func formatTurnTiming(t taskTiming) string {
+	s := "⏱ " + formatDuration(t.elapsed)
+	if t.firstToken > 0 {
+		s += " (first token " + formatDuration(t.firstToken) + ")"
+	}
+	return s
+}
+
+// formatMeta describes what produced a reply, e.g. "qwen3:8b · 4.2s"
+func formatMeta(meta *ollama.MessageMeta) string {
+	s := meta.Model
+	if meta.Temperature != nil {
+		s += fmt.Sprintf(" · temperature %g", *meta.Temperature)
+	}
+	return s + " · " + formatDuration(meta.Duration)
+}
+
+func formatDuration(d time.Duration) string {
+	if d < 10*time.Second {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/todo"
+)
+
+// TodosCommand shows and edits the task list the agent keeps with the
+// todo_ tools
+type TodosCommand struct {
+	list *todo.List
+}
+
+func NewTodosCommand(list *todo.List) *TodosCommand {
+	return &TodosCommand{list: list}
+}
+
+func (c *TodosCommand) Name() string {
+	return "todos"
+}
+
+func (c *TodosCommand) Description() string {
+	return "Show the session's task list, or change it (usage: /todos [add <text>] [start <n>] [done <n>] [remove <n>] [clear])"
+}
+
+func (c *TodosCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	if len(args) == 0 {
+		if len(c.list.Items()) == 0 {
+			return "📋 No tasks yet. The agent adds them for multi-step work, or use /todos add <text>", nil
+		}
+		return c.show(), nil
+	}
+
+	switch args[0] {
+	case "add":
+		text := strings.Join(args[1:], " ")
+		if strings.TrimSpace(text) == "" {
+			return "", fmt.Errorf("usage: /todos add <text>")
+		}
+		c.list.Add(text)
+	case "start", "done", "remove":
+		if len(args) != 2 {
+			return "", fmt.Errorf("usage: /todos %s <n>", args[0])
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return "", fmt.Errorf("%q is not a task number", args[1])
+		}
+		switch args[0] {
+		case "start":
+			_, err = c.list.Update(id, "", todo.InProgress, "")
+		case "done":
+			_, err = c.list.Update(id, "", todo.Done, "")
+		default:
+			err = c.list.Remove(id)
+		}
+		if err != nil {
+			return "", err
+		}
+	case "clear":
+		if n := c.list.ClearDone(); n != 1 {
+			return fmt.Sprintf("✓ Cleared %d finished tasks", n), nil
+		}
+		return "✓ Cleared 1 finished task", nil
+	default:
+		return "", fmt.Errorf("unknown option %q (usage: /todos [add <text>] [start <n>] [done <n>] [remove <n>] [clear])", args[0])
+	}
+	return c.show(), nil
+}
+
+func (c *TodosCommand) show() string {
+	return fmt.Sprintf("## 📋 Tasks (%d open)\n\n%s", c.list.Open(), c.list.Markdown())
+}
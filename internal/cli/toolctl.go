@@ -59,8 +59,10 @@ func (c *DisableToolCommand) Execute(ctx context.Context, args []string, m *chat
 		}
 
 		// Add to config
-		c.cfg.DisabledTools = append(c.cfg.DisabledTools, toolName)
-		if err := c.cfg.Save(); err != nil {
+		err := c.cfg.Update(func(cfg *config.Config) {
+			cfg.DisabledTools = append(cfg.DisabledTools, toolName)
+		})
+		if err != nil {
 			return "", fmt.Errorf("failed to save config: %w", err)
 		}
 
@@ -133,8 +135,10 @@ func (c *EnableToolCommand) Execute(ctx context.Context, args []string, m *chatM
 			return fmt.Sprintf("Tool '%s' was not disabled", toolName), nil
 		}
 
-		c.cfg.DisabledTools = newDisabled
-		if err := c.cfg.Save(); err != nil {
+		err := c.cfg.Update(func(cfg *config.Config) {
+			cfg.DisabledTools = newDisabled
+		})
+		if err != nil {
 			return "", fmt.Errorf("failed to save config: %w", err)
 		}
 
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/tools"
+)
+
+// TrashCommand lists and restores what delete_file removed this session
+type TrashCommand struct {
+	trash *tools.Trash
+}
+
+func NewTrashCommand(trash *tools.Trash) *TrashCommand {
+	return &TrashCommand{trash: trash}
+}
+
+func (c *TrashCommand) Name() string {
+	return "trash"
+}
+
+func (c *TrashCommand) Description() string {
+	return "List files deleted this session (usage: /trash [restore <n>] [empty])"
+}
+
+func (c *TrashCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	if len(args) == 0 {
+		items := c.trash.Items()
+		if len(items) == 0 {
+			return "🗑️ The trash is empty", nil
+		}
+		var sb strings.Builder
+		sb.WriteString("🗑️ Deleted this session\n\n")
+		for i, item := range items {
+			kind := ""
+			if item.IsDir {
+				kind = " (directory)"
+			}
+			sb.WriteString(fmt.Sprintf("%d. %s%s, %s\n", i+1, item.Original, kind, item.Deleted.Format("15:04")))
+		}
+		sb.WriteString("\nUse `/trash restore <n>` to put one back")
+		return sb.String(), nil
+	}
+
+	switch args[0] {
+	case "restore":
+		if len(args) != 2 {
+			return "Usage: /trash restore <n>", nil
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			return "", fmt.Errorf("not a number: %s", args[1])
+		}
+		item, err := c.trash.Restore(n - 1)
+		if err != nil {
+			return "", err
+		}
+		m.agent.AddContext(fmt.Sprintf("The user restored %s from the trash.", item.Original))
+		return fmt.Sprintf("✓ Restored %s", item.Original), nil
+
+	case "empty":
+		if err := c.trash.Empty(); err != nil {
+			return "", fmt.Errorf("empty trash: %w", err)
+		}
+		return "✓ Emptied the trash", nil
+	}
+
+	return "", fmt.Errorf("unknown subcommand '%s'. Use /trash restore or empty", args[0])
+}
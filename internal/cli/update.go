@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/i18n"
+	"github.com/LaPingvino/llemecode/internal/logger"
+	"github.com/LaPingvino/llemecode/internal/version"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// updateAvailableMsg announces a release newer than the running build
+type updateAvailableMsg struct {
+	release *version.Release
+}
+
+// checkForUpdate returns the latest release when update checks are on and it
+// is newer than this build, or nil
+func checkForUpdate(ctx context.Context, cfg *config.Config) *version.Release {
+	if !cfg.UpdateCheck {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	release, err := version.CachedLatestRelease(ctx)
+	if err != nil {
+		logger.Log("Update check failed: %v", err)
+		return nil
+	}
+	if !version.Newer(release.Tag, version.Get().Version) {
+		return nil
+	}
+	return release
+}
+
+// announceUpdate checks for a release in the background and tells the chat
+func announceUpdate(ctx context.Context, p *tea.Program, cfg *config.Config) {
+	go func() {
+		if release := checkForUpdate(ctx, cfg); release != nil {
+			p.Send(updateAvailableMsg{release: release})
+		}
+	}()
+}
+
+// UpdateCommand shows the running version and whether a newer one is out
+type UpdateCommand struct {
+	cfg *config.Config
+}
+
+func NewUpdateCommand(cfg *config.Config) *UpdateCommand {
+	return &UpdateCommand{cfg: cfg}
+}
+
+func (c *UpdateCommand) Name() string {
+	return "update"
+}
+
+func (c *UpdateCommand) Description() string {
+	return "Check for a newer release, or turn the daily check on or off (usage: /update [on|off])"
+}
+
+func (c *UpdateCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "on", "off":
+			enabled := args[0] == "on"
+			if err := c.cfg.Update(func(cfg *config.Config) { cfg.UpdateCheck = enabled }); err != nil {
+				return "", fmt.Errorf("failed to save config: %w", err)
+			}
+			if enabled {
+				return "✓ Llemecode will check GitHub for new releases once a day", nil
+			}
+			return "✓ Update checks turned off", nil
+		default:
+			return "", fmt.Errorf("unknown option %q (usage: /update [on|off])", args[0])
+		}
+	}
+
+	info := version.Get()
+	var sb strings.Builder
+	sb.WriteString(info.String() + "\n\n")
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	release, err := version.LatestRelease(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if version.Newer(release.Tag, info.Version) {
+		sb.WriteString(i18n.T("update.available", release.Tag, info.Version) + "\n\n")
+		sb.WriteString(fmt.Sprintf("Release notes and binaries: %s\n", release.URL))
+		sb.WriteString(fmt.Sprintf("Installed with go install? Run: %s", version.InstallCommand))
+	} else {
+		sb.WriteString(fmt.Sprintf("✓ Up to date (latest release: %s)", release.Tag))
+	}
+	if !c.cfg.UpdateCheck {
+		sb.WriteString("\n\nTurn on the daily check with /update on")
+	}
+	return sb.String(), nil
+}
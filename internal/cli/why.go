@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// whyExcerpt is how much of the reply /why quotes
+const whyExcerpt = 400
+
+// WhyCommand explains how the last reply was searched for tool calls, for
+// when the model talks about tools instead of calling them
+type WhyCommand struct{}
+
+func NewWhyCommand() *WhyCommand {
+	return &WhyCommand{}
+}
+
+func (c *WhyCommand) Name() string {
+	return "why"
+}
+
+func (c *WhyCommand) Description() string {
+	return "Explain why the last reply did or didn't call tools: the format, what the parser looked for and the tools offered"
+}
+
+func (c *WhyCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	report := m.agent.LastParse()
+	if report == nil {
+		return "No reply yet. Send a message, then /why explains how it was searched for tool calls.", nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("## 🔎 Why\n\n")
+	sb.WriteString(fmt.Sprintf("Reply %d of the last turn from **%s**, at %s, parsed with the **%s** tool format.\n\n", report.Iteration+1, report.Model, report.At.Format("15:04:05"), report.Format))
+
+	switch {
+	case report.Native > 0:
+		sb.WriteString(fmt.Sprintf("The server returned %d native tool calls: %s.\n", report.Native, strings.Join(report.Calls, ", ")))
+	case len(report.Calls) > 0:
+		sb.WriteString(fmt.Sprintf("The parser found %d tool calls in the text: %s.\n", len(report.Calls), strings.Join(report.Calls, ", ")))
+	case report.Iteration > 0:
+		sb.WriteString("No tool calls: this was the answer after the turn's earlier tool calls, which is how a turn ends.\n")
+	default:
+		sb.WriteString("**No tool calls were found.**\n")
+	}
+
+	if report.Format == "native" {
+		sb.WriteString("\nWith the native format the Ollama server turns the model's output into tool calls; llemecode only reads the tool_calls the server sends, so no text parsing ran.\n")
+	} else if len(report.Checks) > 0 {
+		sb.WriteString("\n### What the parser looked for\n\n| Pattern | Matches | Note |\n|---|---|---|\n")
+		for _, check := range report.Checks {
+			sb.WriteString(fmt.Sprintf("| `%s` | %d | %s |\n", strings.ReplaceAll(check.Pattern, "|", "\\|"), check.Matches, check.Note))
+		}
+	}
+
+	if len(report.Hints) > 0 {
+		sb.WriteString("\n### Likely causes\n\n")
+		for _, hint := range report.Hints {
+			sb.WriteString("- " + hint + "\n")
+		}
+	}
+
+	how := "as function definitions in the request"
+	if report.Format != "native" {
+		how = "in the system prompt"
+	}
+	sb.WriteString(fmt.Sprintf("\n### Tools offered (%d, %s)\n\n", len(report.Tools), how))
+	if len(report.Tools) > 0 {
+		sb.WriteString(strings.Join(report.Tools, ", ") + "\n")
+	}
+
+	excerpt := strings.TrimSpace(report.Content)
+	if runes := []rune(excerpt); len(runes) > whyExcerpt {
+		excerpt = string(runes[:whyExcerpt]) + "..."
+	}
+	if excerpt != "" {
+		sb.WriteString("\n### Reply\n\n```\n" + excerpt + "\n```\n")
+	}
+	sb.WriteString("\nChange the format with \"tool_call_format\" under the model in model_capabilities, or re-detect it with /benchmark " + report.Model + ".\n")
+	return sb.String(), nil
+}
@@ -0,0 +1,489 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type wizardStep int
+
+const (
+	stepURL wizardStep = iota
+	stepModels
+	stepDefault
+	stepEvaluator
+	stepPermissions
+	stepTheme
+	stepConfirm
+)
+
+var wizardStepNames = []string{"Ollama server", "Models to benchmark", "Default model", "Evaluator", "Permissions", "Theme", "Review"}
+
+// Themes are the markdown styles the chat can render with
+var Themes = []string{"auto", "dark", "light", "dracula", "ascii"}
+
+func markdownStyle(theme string) string {
+	for _, t := range Themes {
+		if t == theme {
+			return theme
+		}
+	}
+	return "auto"
+}
+
+// permissionPreset is a starting point for the permission settings; the
+// details can be changed later with /permissions or in the config file
+type permissionPreset struct {
+	name        string
+	description string
+	apply       func(p *config.PermissionConfig)
+}
+
+var permissionPresets = []permissionPreset{
+	{"Cautious", "Ask before every read, write, command and network access", func(p *config.PermissionConfig) {
+		p.AutoApproveRead = false
+		p.RequireApprovalWrite = true
+		p.RequireApprovalExecute = true
+		p.RequireApprovalNetwork = true
+		p.CommandAllowlist = false
+	}},
+	{"Balanced", "Read files freely; ask before writes, commands and network access", func(p *config.PermissionConfig) {
+		p.AutoApproveRead = true
+		p.RequireApprovalWrite = true
+		p.RequireApprovalExecute = true
+		p.RequireApprovalNetwork = true
+		p.CommandAllowlist = false
+	}},
+	{"Trusted", "Read and write freely; run allowlisted commands (go, git, make...) without asking", func(p *config.PermissionConfig) {
+		p.AutoApproveRead = true
+		p.RequireApprovalWrite = false
+		p.RequireApprovalExecute = true
+		p.RequireApprovalNetwork = true
+		p.CommandAllowlist = true
+	}},
+}
+
+type modelsListedMsg struct {
+	client *ollama.Client
+	models []ollama.ModelInfo
+	err    error
+}
+
+type wizardModel struct {
+	ctx       context.Context
+	client    *ollama.Client
+	cfg       *config.Config
+	step      wizardStep
+	url       textinput.Model
+	fixedURL  bool // The server comes from --url, a tunnel or endpoints
+	checking  bool
+	urlErr    error
+	models    []ollama.ModelInfo
+	checked   []bool
+	cursor    int
+	picker    modelPickerModel // Chooses the default model among chosen()
+	defaultIx int              // Index into chosen()
+	evaluator int              // 0 is heuristics, otherwise 1 + index into chosen()
+	preset    int
+	theme     int
+	done      bool
+	cancelled bool
+}
+
+// RunSetupWizard walks through the first-run choices and saves them to cfg.
+// editURL is false when the server is fixed by flags, a tunnel or endpoints,
+// in which case client is used as it is.
+func RunSetupWizard(ctx context.Context, client *ollama.Client, cfg *config.Config, editURL bool) error {
+	url := textinput.New()
+	url.SetValue(cfg.OllamaURL)
+	url.Placeholder = "http://localhost:11434"
+	url.CharLimit = 200
+	url.Width = 50
+	url.Focus()
+
+	m := wizardModel{ctx: ctx, client: client, cfg: cfg, url: url, fixedURL: !editURL, preset: 1}
+	if !editURL {
+		m.checking = true
+	}
+
+	final, err := tea.NewProgram(m).Run()
+	if err != nil {
+		return err
+	}
+	result := final.(wizardModel)
+	if result.cancelled || !result.done {
+		return fmt.Errorf("setup cancelled")
+	}
+
+	chosen := result.chosen()
+	return cfg.Update(func(c *config.Config) {
+		if !result.fixedURL {
+			c.OllamaURL = strings.TrimSpace(result.url.Value())
+		}
+		c.DefaultModel = chosen[result.defaultIx]
+		c.BenchmarkModels = nil
+		if len(chosen) < len(result.models) {
+			c.BenchmarkModels = chosen
+		}
+		c.Evaluator = "none"
+		if result.evaluator > 0 {
+			c.Evaluator = chosen[result.evaluator-1]
+		}
+		permissionPresets[result.preset].apply(&c.Permissions)
+		c.Theme = Themes[result.theme]
+	})
+}
+
+func (m wizardModel) Init() tea.Cmd {
+	if m.fixedURL {
+		return m.listModels()
+	}
+	return textinput.Blink
+}
+
+// listModels checks the server in the URL field and lists its models
+func (m wizardModel) listModels() tea.Cmd {
+	url := strings.TrimSpace(m.url.Value())
+	ctx, client := m.ctx, m.client
+	if !m.fixedURL {
+		client = ollama.NewClient(url)
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		models, err := client.ListModels(ctx)
+		if err == nil && len(models) == 0 {
+			err = fmt.Errorf("no models found. Please pull at least one model with 'ollama pull <model>'")
+		}
+		return modelsListedMsg{client: client, models: models, err: err}
+	}
+}
+
+// chosen returns the names of the models ticked for benchmarking
+func (m wizardModel) chosen() []string {
+	var names []string
+	for i, model := range m.models {
+		if m.checked[i] {
+			names = append(names, model.Name)
+		}
+	}
+	return names
+}
+
+func (m wizardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case modelsListedMsg:
+		m.checking = false
+		if msg.err != nil {
+			m.urlErr = msg.err
+			if m.fixedURL {
+				// Nothing to edit, so there is no way forward
+				m.cancelled = true
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+		m.urlErr = nil
+		m.client = msg.client
+		m.models = msg.models
+		m.checked = make([]bool, len(msg.models))
+		for i := range m.checked {
+			m.checked[i] = true
+		}
+		m.step, m.cursor = stepModels, 0
+		m.url.Blur()
+		return m, nil
+
+	case modelDetailsMsg:
+		next, _ := m.picker.Update(msg)
+		m.picker = next.(modelPickerModel)
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			m.cancelled = true
+			return m, tea.Quit
+		}
+		if m.step == stepDefault {
+			return m.updateDefault(msg), nil
+		}
+		if msg.String() == "esc" {
+			if m.step == stepURL || (m.step == stepModels && m.fixedURL) {
+				m.cancelled = true
+				return m, tea.Quit
+			}
+			m.back()
+			return m, nil
+		}
+		if m.step == stepURL {
+			return m.updateURL(msg)
+		}
+		step := m.step
+		m = m.updateChoice(msg)
+		if m.done {
+			return m, tea.Quit
+		}
+		if step == stepModels && m.step == stepDefault {
+			return m, m.picker.Init()
+		}
+		return m, nil
+	}
+
+	if m.step == stepURL {
+		var cmd tea.Cmd
+		m.url, cmd = m.url.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m wizardModel) updateURL(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "enter" && !m.checking {
+		m.checking = true
+		m.urlErr = nil
+		return m, m.listModels()
+	}
+	var cmd tea.Cmd
+	m.url, cmd = m.url.Update(msg)
+	return m, cmd
+}
+
+// updateDefault lets the model picker handle keys; Esc outside a search
+// goes back a step
+func (m wizardModel) updateDefault(msg tea.KeyMsg) wizardModel {
+	next, _ := m.picker.Update(msg)
+	m.picker = next.(modelPickerModel)
+	if !m.picker.done {
+		return m
+	}
+
+	if m.picker.err != nil {
+		m.picker.done, m.picker.err = false, nil
+		m.back()
+		return m
+	}
+	selected := m.picker.models[m.picker.selected].Name
+	for i, name := range m.chosen() {
+		if name == selected {
+			m.defaultIx = i
+		}
+	}
+	m.next()
+	return m
+}
+
+// options returns how many choices the current step offers
+func (m wizardModel) options() int {
+	switch m.step {
+	case stepModels:
+		return len(m.models)
+	case stepEvaluator:
+		return len(m.chosen()) + 1
+	case stepPermissions:
+		return len(permissionPresets)
+	case stepTheme:
+		return len(Themes)
+	}
+	return 0
+}
+
+func (m wizardModel) updateChoice(msg tea.KeyMsg) wizardModel {
+	switch msg.String() {
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < m.options()-1 {
+			m.cursor++
+		}
+	case " ", "x":
+		if m.step == stepModels {
+			m.checked[m.cursor] = !m.checked[m.cursor]
+		}
+	case "a":
+		if m.step == stepModels {
+			// Tick everything, or clear everything when all are ticked
+			all := len(m.chosen()) == len(m.models)
+			for i := range m.checked {
+				m.checked[i] = !all
+			}
+		}
+	case "enter":
+		m.next()
+	}
+	return m
+}
+
+// next stores the choice under the cursor and moves to the next step
+func (m *wizardModel) next() {
+	switch m.step {
+	case stepModels:
+		if len(m.chosen()) == 0 {
+			return
+		}
+		var chosen []ollama.ModelInfo
+		for i, model := range m.models {
+			if m.checked[i] {
+				chosen = append(chosen, model)
+			}
+		}
+		m.defaultIx = 0
+		m.picker = newModelPicker(m.ctx, m.client, m.cfg, chosen)
+		m.step = stepDefault
+	case stepDefault:
+		m.step, m.cursor = stepEvaluator, m.evaluator
+		if m.cursor >= m.options() {
+			m.cursor = 0
+		}
+	case stepEvaluator:
+		m.evaluator = m.cursor
+		m.step, m.cursor = stepPermissions, m.preset
+	case stepPermissions:
+		m.preset = m.cursor
+		m.step, m.cursor = stepTheme, m.theme
+	case stepTheme:
+		m.theme = m.cursor
+		m.step = stepConfirm
+	case stepConfirm:
+		m.done = true
+	}
+}
+
+func (m *wizardModel) back() {
+	switch m.step {
+	case stepModels:
+		m.step = stepURL
+		m.url.Focus()
+	case stepDefault:
+		m.step, m.cursor = stepModels, 0
+	case stepEvaluator:
+		m.step = stepDefault
+		m.picker.done = false
+	case stepPermissions:
+		m.step, m.cursor = stepEvaluator, m.evaluator
+	case stepTheme:
+		m.step, m.cursor = stepPermissions, m.preset
+	case stepConfirm:
+		m.step, m.cursor = stepTheme, m.theme
+	}
+}
+
+func (m wizardModel) View() string {
+	if m.done || m.cancelled {
+		return ""
+	}
+
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("🚀 Welcome to Llemecode!") + "\n")
+	s.WriteString(statusStyle.Render(fmt.Sprintf("Step %d of %d: %s", m.step+1, len(wizardStepNames), wizardStepNames[m.step])) + "\n\n")
+
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	help := "↑/↓: move • Enter: next • Esc: back"
+
+	switch m.step {
+	case stepURL:
+		s.WriteString("  Where is your Ollama server?\n\n")
+		s.WriteString("  " + m.url.View() + "\n\n")
+		if m.checking {
+			s.WriteString(dim.Render("  Connecting...") + "\n")
+		}
+		help = "Enter: connect • Esc: quit"
+
+	case stepModels:
+		s.WriteString("  Which models should be benchmarked?\n\n")
+		for i, model := range m.models {
+			box := "[ ]"
+			if m.checked[i] {
+				box = "[x]"
+			}
+			s.WriteString(m.option(i, fmt.Sprintf("%s %s", box, model.Name), dim.Render(formatSize(model.Size))))
+		}
+		if len(m.chosen()) == 0 {
+			s.WriteString("\n" + dim.Render("  Tick at least one model") + "\n")
+		}
+		help = "↑/↓: move • Space: tick • a: all/none • Enter: next • Esc: back"
+
+	case stepDefault:
+		s.WriteString("  Which model do you want to chat with?\n\n")
+		s.WriteString(m.picker.listView())
+		help = m.picker.help("Esc: back")
+
+	case stepEvaluator:
+		s.WriteString("  Which model should grade benchmark answers?\n\n")
+		s.WriteString(m.option(0, "None", dim.Render("fast heuristics based on length and speed")))
+		for i, name := range m.chosen() {
+			s.WriteString(m.option(i+1, name, ""))
+		}
+
+	case stepPermissions:
+		s.WriteString("  How much should the assistant do without asking?\n\n")
+		for i, preset := range permissionPresets {
+			s.WriteString(m.option(i, preset.name, dim.Render(preset.description)))
+		}
+
+	case stepTheme:
+		s.WriteString("  How should replies be rendered?\n\n")
+		for i, theme := range Themes {
+			note := ""
+			if theme == "auto" {
+				note = dim.Render("dark or light, following the terminal")
+			}
+			s.WriteString(m.option(i, theme, note))
+		}
+
+	case stepConfirm:
+		chosen := m.chosen()
+		evaluator := "none (heuristics)"
+		if m.evaluator > 0 {
+			evaluator = chosen[m.evaluator-1]
+		}
+		benchmarked := "all models"
+		if len(chosen) < len(m.models) {
+			benchmarked = strings.Join(chosen, ", ")
+		}
+		s.WriteString(fmt.Sprintf("  Server:      %s\n", strings.TrimSpace(m.url.Value())))
+		s.WriteString(fmt.Sprintf("  Benchmark:   %s\n", benchmarked))
+		s.WriteString(fmt.Sprintf("  Chat model:  %s\n", chosen[m.defaultIx]))
+		s.WriteString(fmt.Sprintf("  Evaluator:   %s\n", evaluator))
+		s.WriteString(fmt.Sprintf("  Permissions: %s\n", permissionPresets[m.preset].name))
+		s.WriteString(fmt.Sprintf("  Theme:       %s\n\n", Themes[m.theme]))
+		s.WriteString(dim.Render("  Benchmarking will run in the background while you chat.") + "\n")
+		help = "Enter: save and start • Esc: back"
+	}
+
+	if m.urlErr != nil {
+		s.WriteString("\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(fmt.Sprintf("  ❌ %v", m.urlErr)) + "\n")
+	}
+	s.WriteString("\n" + statusStyle.Render(help))
+	return s.String()
+}
+
+// option renders one line of a choice list
+func (m wizardModel) option(i int, label, note string) string {
+	cursor := " "
+	if m.cursor == i {
+		cursor = cursorStyle.Render(">")
+		label = selectedStyle.Render(label)
+	}
+	if note != "" {
+		return fmt.Sprintf("%s %s  %s\n", cursor, label, note)
+	}
+	return fmt.Sprintf("%s %s\n", cursor, label)
+}
+
+func formatSize(size int64) string {
+	sizeMB := float64(size) / 1024 / 1024
+	if sizeMB > 1024 {
+		return fmt.Sprintf("%.1f GB", sizeMB/1024)
+	}
+	return fmt.Sprintf("%.1f MB", sizeMB)
+}
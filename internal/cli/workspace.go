@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/tools"
+)
+
+// workspaceMapEntries caps how many top-level entries of each root the
+// model is shown
+const workspaceMapEntries = 30
+
+// WorkspaceCommand lists and changes the named project roots tools can work in
+type WorkspaceCommand struct {
+	toolRegistry *tools.Registry
+}
+
+func NewWorkspaceCommand(toolRegistry *tools.Registry) *WorkspaceCommand {
+	return &WorkspaceCommand{toolRegistry: toolRegistry}
+}
+
+func (c *WorkspaceCommand) Name() string {
+	return "workspace"
+}
+
+func (c *WorkspaceCommand) Description() string {
+	return "Show project roots (usage: /workspace [add <name> <dir>] [remove <name>])"
+}
+
+func (c *WorkspaceCommand) Execute(ctx context.Context, args []string, m *chatModel) (string, error) {
+	live := c.toolRegistry.PermissionConfig()
+	if live == nil {
+		return "", fmt.Errorf("no permission-checked tools are registered")
+	}
+
+	if len(args) == 0 {
+		roots := live.ProjectRoots()
+		if len(roots) == 0 {
+			return "No project roots. Add one with /workspace add <name> <dir> or start with --root name=dir", nil
+		}
+		var sb strings.Builder
+		sb.WriteString("🗂️ Project roots\n\n")
+		for _, root := range roots {
+			sb.WriteString(fmt.Sprintf("- **%s**: %s\n", root.Name, root.Path))
+		}
+		return sb.String(), nil
+	}
+
+	switch args[0] {
+	case "add":
+		if len(args) < 3 {
+			return "Usage: /workspace add <name> <dir>", nil
+		}
+		if info, err := os.Stat(tools.ExpandHome(args[2])); err != nil || !info.IsDir() {
+			return "", fmt.Errorf("%s is not a directory", args[2])
+		}
+		root, err := live.AddProjectRoot(args[1], args[2])
+		if err != nil {
+			return "", err
+		}
+		m.agent.AddContext(workspaceNote(live.ProjectRoots()))
+		return fmt.Sprintf("✓ Added project root %s: %s", root.Name, root.Path), nil
+
+	case "remove":
+		if len(args) < 2 {
+			return "Usage: /workspace remove <name>", nil
+		}
+		if !live.RemoveProjectRoot(args[1]) {
+			return "", fmt.Errorf("no project root named %s", args[1])
+		}
+		m.agent.AddContext(workspaceNote(live.ProjectRoots()))
+		return fmt.Sprintf("✓ Removed project root %s", args[1]), nil
+	}
+
+	return "", fmt.Errorf("unknown subcommand '%s'. Use /workspace add or remove", args[0])
+}
+
+// workspaceNote tells the model which roots there are and what is at the
+// top of each, so it knows which root to pass to tools
+func workspaceNote(roots []tools.ProjectRoot) string {
+	if len(roots) == 0 {
+		return "There are no project roots any more. Do not pass root to tools; paths are relative to the working directory."
+	}
+	var sb strings.Builder
+	sb.WriteString("This session works in several project roots. Pass root=<name> to file tools and run_command to work in one; relative paths are then relative to that root. Without root, paths are relative to the working directory.\n")
+	for _, root := range roots {
+		sb.WriteString(fmt.Sprintf("\nRoot %s (%s):\n", root.Name, root.Path))
+		entries, err := os.ReadDir(root.Path)
+		if err != nil {
+			sb.WriteString(fmt.Sprintf("  (cannot list: %v)\n", err))
+			continue
+		}
+		var names []string
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			if entry.IsDir() {
+				names = append(names, entry.Name()+"/")
+			} else {
+				names = append(names, entry.Name())
+			}
+		}
+		sort.Strings(names)
+		if len(names) > workspaceMapEntries {
+			names = append(names[:workspaceMapEntries], fmt.Sprintf("... %d more", len(names)-workspaceMapEntries))
+		}
+		sb.WriteString("  " + strings.Join(names, "  ") + "\n")
+	}
+	return sb.String()
+}
+
+// workspaceDirs returns the working directory and every project root, the
+// places whose languages the system prompt gives advice for
+func workspaceDirs(registry *tools.Registry) []string {
+	var dirs []string
+	if wd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, wd)
+	}
+	if live := registry.PermissionConfig(); live != nil {
+		for _, root := range live.ProjectRoots() {
+			dirs = append(dirs, root.Path)
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,132 @@
+// Package clipboard reads and writes the system clipboard with the platform
+// utilities, falling back to the OSC 52 terminal escape for writing, which
+// also reaches the local clipboard over SSH.
+package clipboard
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// timeout is how long a clipboard utility gets to run
+const timeout = 5 * time.Second
+
+type command struct {
+	name string
+	args []string
+}
+
+// candidates lists the utilities to try in order, for reading or writing
+func candidates(write bool) []command {
+	switch runtime.GOOS {
+	case "darwin":
+		if write {
+			return []command{{"pbcopy", nil}}
+		}
+		return []command{{"pbpaste", nil}}
+	case "windows":
+		if write {
+			return []command{{"clip.exe", nil}}
+		}
+		return []command{{"powershell.exe", []string{"-NoProfile", "-Command", "Get-Clipboard -Raw"}}}
+	}
+
+	var cmds []command
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		if write {
+			cmds = append(cmds, command{"wl-copy", nil})
+		} else {
+			cmds = append(cmds, command{"wl-paste", []string{"--no-newline"}})
+		}
+	}
+	if write {
+		cmds = append(cmds,
+			command{"xclip", []string{"-selection", "clipboard"}},
+			command{"xsel", []string{"--clipboard", "--input"}},
+			command{"termux-clipboard-set", nil})
+	} else {
+		cmds = append(cmds,
+			command{"xclip", []string{"-selection", "clipboard", "-o"}},
+			command{"xsel", []string{"--clipboard", "--output"}},
+			command{"termux-clipboard-get", nil})
+	}
+	return cmds
+}
+
+// overSSH reports whether the clipboard utilities would reach the remote
+// machine's clipboard instead of the user's
+func overSSH() bool {
+	return os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+}
+
+// Read returns the clipboard contents
+func Read(ctx context.Context) (string, error) {
+	var lastErr error
+	for _, c := range candidates(false) {
+		if _, err := exec.LookPath(c.name); err != nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		out, err := exec.CommandContext(ctx, c.name, c.args...).Output()
+		cancel()
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", c.name, err)
+			continue
+		}
+		return string(out), nil
+	}
+	if lastErr != nil {
+		return "", lastErr
+	}
+	return "", fmt.Errorf("no clipboard utility found (install wl-clipboard, xclip or xsel); the terminal clipboard (OSC 52) can only be written")
+}
+
+// Write puts text on the clipboard and returns how it got there. Over SSH
+// the terminal escape is used so the text lands on the user's machine.
+func Write(ctx context.Context, text string) (string, error) {
+	if !overSSH() {
+		for _, c := range candidates(true) {
+			if _, err := exec.LookPath(c.name); err != nil {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			cmd := exec.CommandContext(ctx, c.name, c.args...)
+			cmd.Stdin = bytes.NewBufferString(text)
+			err := cmd.Run()
+			cancel()
+			if err == nil {
+				return c.name, nil
+			}
+		}
+	}
+
+	if err := writeOSC52(text); err != nil {
+		return "", err
+	}
+	return "terminal (OSC 52)", nil
+}
+
+// writeOSC52 asks the terminal to set the clipboard. Inside tmux the escape
+// is wrapped so tmux passes it on.
+func writeOSC52(text string) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("no clipboard utility worked and there is no terminal for OSC 52: %w", err)
+	}
+	defer tty.Close()
+
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;\x1b" + seq + "\x1b\\"
+	}
+	if _, err := tty.WriteString(seq); err != nil {
+		return fmt.Errorf("write OSC 52: %w", err)
+	}
+	return nil
+}
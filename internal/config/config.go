@@ -5,19 +5,103 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sync"
+	"time"
 )
 
+// configVersion is bumped when a saved setting needs migrating, see migrate
+const configVersion = 3
+
+// saveMu serializes writes to the config file across all Config values in the process
+var saveMu sync.Mutex
+
 type Config struct {
+	Version           int                        `json:"version"` // Settings format, see configVersion
 	OllamaURL         string                     `json:"ollama_url"`
+	Endpoints         []EndpointConfig           `json:"endpoints,omitempty"`
+	SSHTunnel         *SSHTunnelConfig           `json:"ssh_tunnel,omitempty"`
 	DefaultModel      string                     `json:"default_model"`
 	BenchmarkTasks    []BenchmarkTask            `json:"benchmark_tasks"`
+	BenchmarkModels   []string                   `json:"benchmark_models,omitempty"` // Models to benchmark; empty means all installed models
+	Evaluator         string                     `json:"evaluator,omitempty"`        // Model that grades benchmark answers; empty picks the best ranked one, "none" uses heuristics
+	TitleModel        string                     `json:"title_model,omitempty"`      // Model that names sessions after the first exchange; empty uses the chat model, "none" keeps the first message as the title
 	SystemPrompts     map[string]string          `json:"system_prompts"`
+	LanguageGuidance  map[string]string          `json:"language_guidance,omitempty"` // System prompt advice per workspace language, replacing the built-in one; "" turns a language's off
 	ModelCapabilities map[string]ModelCapability `json:"model_capabilities"`
+	CategoryWeights   map[string]float64         `json:"category_weights,omitempty"` // Score multipliers per benchmark category when picking a model; unlisted categories count 1
+	BestModels        map[string]string          `json:"best_models,omitempty"`      // Highest scoring model per benchmark category, filled in by benchmarks
 	ModelAsTools      []ModelAsTool              `json:"model_as_tools,omitempty"`
 	Permissions       PermissionConfig           `json:"permissions"`
+	TurnBudget        TurnBudgetConfig           `json:"turn_budget"`
+	ContextPruning    ContextPruningConfig       `json:"context_pruning"`
+	SessionSummary    SessionSummaryConfig       `json:"session_summary"`
+	FileWatch         FileWatchConfig            `json:"file_watch"`
+	ToolOutput        ToolOutputConfig           `json:"tool_output"`
+	Paste             PasteConfig                `json:"paste"`
+	PostProcessors    []ToolPostProcessor        `json:"post_processors,omitempty"` // Applied in order to matching tool results
+	Notifications     NotificationConfig         `json:"notifications"`
+	ToolDetection     ToolDetectionConfig        `json:"tool_detection"`
+	BenchmarkSampling BenchmarkSamplingConfig    `json:"benchmark_sampling"`
+	Language          string                     `json:"language,omitempty"`     // Interface language, e.g. "eo"; empty follows the environment
+	Theme             string                     `json:"theme,omitempty"`        // Markdown style: auto, dark, light, dracula or ascii
+	Images            string                     `json:"images,omitempty"`       // Terminal image protocol: auto (default), kitty, iterm2, sixel or off
+	UpdateCheck       bool                       `json:"update_check,omitempty"` // Look for new releases on GitHub once a day
+	Storage           string                     `json:"storage,omitempty"`      // Where sessions, usage, audit log and benchmark history are kept: "files" (default) or "sqlite"
 	DisabledTools     []string                   `json:"disabled_tools,omitempty"`
 	CustomTools       []map[string]interface{}   `json:"custom_tools,omitempty"`
 	MCPServers        []MCPServerConfig          `json:"mcp_servers,omitempty"`
+	Databases         map[string]DatabaseProfile `json:"databases,omitempty"` // Connection profiles for the query_database tool, by name
+	Kubernetes        KubernetesConfig           `json:"kubernetes"`
+	Templates         map[string]ProjectTemplate `json:"templates,omitempty"` // Project templates for llemecode new, by name
+	Startup           StartupConfig              `json:"startup"`
+
+	mu        sync.RWMutex           // Guards fields mutated at runtime
+	base      map[string]interface{} // File contents at last load/save, used to merge concurrent writers
+	overrides map[string]override    // In-memory values (by JSON key) that are never saved
+}
+
+// override is a value set for this run only, e.g. from a command line flag
+type override struct {
+	value    interface{} // Value in memory
+	original interface{} // Value to keep writing to disk
+}
+
+// EndpointConfig describes one of several Ollama servers. When endpoints are
+// configured they are used instead of ollama_url, in the order listed.
+type EndpointConfig struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Models []string `json:"models,omitempty"` // Models to route to this server first
+}
+
+// SSHTunnelConfig reaches Ollama on a remote host through an ssh port
+// forward opened at startup. It takes the place of ollama_url.
+type SSHTunnelConfig struct {
+	Host         string `json:"host"`
+	User         string `json:"user,omitempty"`
+	Port         int    `json:"port,omitempty"`        // SSH port, default 22
+	RemotePort   int    `json:"remote_port,omitempty"` // Ollama port on the remote host, default 11434
+	IdentityFile string `json:"identity_file,omitempty"`
+}
+
+// DatabaseProfile is a database the query_database tool may connect to.
+// Passwords live in the system keyring (llemecode db password <name>) and
+// replace "{password}" in the DSN.
+type DatabaseProfile struct {
+	Driver    string `json:"driver"`               // sqlite, postgres or mysql
+	DSN       string `json:"dsn"`                  // File path for sqlite, connection URL or DSN otherwise
+	ReadWrite bool   `json:"read_write,omitempty"` // Allow statements that change data; profiles are read-only by default
+	MaxRows   int    `json:"max_rows,omitempty"`   // Rows returned per query; 0 means 100
+	MaxBytes  int    `json:"max_bytes,omitempty"`  // Size of a result; 0 means 32 KB
+}
+
+// KubernetesConfig controls the kubectl tools. They only read unless
+// mutating verbs are allowed explicitly.
+type KubernetesConfig struct {
+	Context       string `json:"context,omitempty"`        // kubeconfig context; empty uses the current one
+	AllowMutating bool   `json:"allow_mutating,omitempty"` // Add a kubectl tool that can apply, delete, scale, etc. (asks for execute approval)
 }
 
 type MCPServerConfig struct {
@@ -36,6 +120,152 @@ type PermissionConfig struct {
 	BlockedCommands        []string            `json:"blocked_commands"`
 	AlwaysAllowPatterns    []PermissionPattern `json:"always_allow_patterns,omitempty"`
 	RestrictToWorkingDir   bool                `json:"restrict_to_working_dir"`
+	AllowedRoots           []string            `json:"allowed_roots,omitempty"` // Directories outside the working directory tools may use
+	FileRoots              []string            `json:"file_roots,omitempty"`    // If set, file tools only work inside these, whatever is approved
+	DeniedRoots            []string            `json:"denied_roots,omitempty"`  // File tools never work inside these
+	CommandAllowlist       bool                `json:"command_allowlist"`       // Only allowed_commands run without asking
+	AllowedCommands        []string            `json:"allowed_commands,omitempty"`
+}
+
+// TurnBudgetConfig limits what the agent may do in response to a single
+// message before the user is asked whether to continue. Zero means no limit.
+type TurnBudgetConfig struct {
+	MaxToolCalls    int `json:"max_tool_calls"`
+	MaxFilesWritten int `json:"max_files_written"`
+	MaxCommands     int `json:"max_commands"`
+	MaxMinutes      int `json:"max_minutes"`
+}
+
+// FileWatchConfig tells the model which workspace files changed outside the
+// chat, e.g. in an editor, before its next turn
+type FileWatchConfig struct {
+	Enabled  bool `json:"enabled"`
+	MaxFiles int  `json:"max_files,omitempty"` // Files tracked; 0 means 2000
+	Reread   bool `json:"reread,omitempty"`    // Also send the new contents of small changed files
+}
+
+// ContextPruningConfig leaves older, less relevant messages out of requests
+// once the history outgrows the token budget. The history itself is kept.
+type ContextPruningConfig struct {
+	Enabled        bool   `json:"enabled"`
+	MaxTokens      int    `json:"max_tokens,omitempty"`      // Estimated tokens per request; 0 uses 3/4 of the model's context length
+	KeepRecent     int    `json:"keep_recent,omitempty"`     // Newest messages that are always sent; 0 means 8
+	EmbeddingModel string `json:"embedding_model,omitempty"` // e.g. nomic-embed-text; empty scores relevance by shared words
+}
+
+// SessionSummaryConfig keeps a rolling summary of long sessions as they are
+// saved, so resuming one sends the summary and the latest messages instead
+// of the whole history
+type SessionSummaryConfig struct {
+	Enabled    bool   `json:"enabled"`
+	Model      string `json:"model,omitempty"`       // Writes the summaries; empty uses the chat model
+	KeepRecent int    `json:"keep_recent,omitempty"` // Newest messages never summarized; 0 means 20
+	MaxTokens  int    `json:"max_tokens,omitempty"`  // Larger histories resume from the summary; 0 uses half the model's context length
+}
+
+// ToolOutputConfig limits how much of a tool result the model sees at once.
+// Longer results are kept whole and the model pages through them with
+// fetch_more.
+type ToolOutputConfig struct {
+	MaxTokens int `json:"max_tokens,omitempty"` // Estimated tokens; 0 means 4000, -1 never truncates
+}
+
+// Budget returns the token limit for a tool result, 0 for none
+func (c ToolOutputConfig) Budget() int {
+	switch {
+	case c.MaxTokens < 0:
+		return 0
+	case c.MaxTokens == 0:
+		return 4000
+	}
+	return c.MaxTokens
+}
+
+// PageBytes is how much of a long result is shown at once, about three
+// quarters of the budget at four bytes a token, leaving room for the note
+func (c ToolOutputConfig) PageBytes() int {
+	return c.Budget() * 3
+}
+
+// PasteConfig controls how large pastes into the chat input are handled
+type PasteConfig struct {
+	CollapseLines int `json:"collapse_lines,omitempty"` // Longer pastes show as a placeholder; 0 means 10, -1 never
+	ConfirmBytes  int `json:"confirm_bytes,omitempty"`  // Ask before sending pastes larger than this; 0 means 16384, -1 never
+}
+
+// Collapse returns from how many lines a paste is collapsed, 0 for never
+func (c PasteConfig) Collapse() int {
+	switch {
+	case c.CollapseLines < 0:
+		return 0
+	case c.CollapseLines == 0:
+		return 10
+	}
+	return c.CollapseLines
+}
+
+// Confirm returns the paste size that needs confirming, 0 for never
+func (c PasteConfig) Confirm() int {
+	switch {
+	case c.ConfirmBytes < 0:
+		return 0
+	case c.ConfirmBytes == 0:
+		return 16384
+	}
+	return c.ConfirmBytes
+}
+
+// ToolPostProcessor rewrites a tool's result before it enters the context,
+// with a shell command, a template or both (the command runs first)
+type ToolPostProcessor struct {
+	Tool     string `json:"tool"`               // Tool name, or "*" for every tool
+	Command  string `json:"command,omitempty"`  // Gets the result on stdin and prints the new one, e.g. "head -100"
+	Template string `json:"template,omitempty"` // Go template over .Result, .Tool and .Args, e.g. "{{stripANSI .Result}}"
+}
+
+// ToolDetectionConfig controls how tool call formats are tested. Each format
+// is tried Trials times and used when at least Threshold of the trials
+// succeed. Zero values use the defaults.
+type ToolDetectionConfig struct {
+	Trials    int     `json:"trials"`
+	Threshold float64 `json:"threshold"` // Fraction of trials, 0-1
+}
+
+// BenchmarkSamplingConfig fixes how benchmark answers are generated, so
+// scores can be reproduced. Unset values use seed 42 and temperature 0; -1
+// leaves either to the model.
+type BenchmarkSamplingConfig struct {
+	Seed        *int     `json:"seed,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+}
+
+// Options returns the generation options for benchmark requests
+func (c BenchmarkSamplingConfig) Options() map[string]interface{} {
+	options := make(map[string]interface{})
+	switch {
+	case c.Seed == nil:
+		options["seed"] = 42
+	case *c.Seed >= 0:
+		options["seed"] = *c.Seed
+	}
+	switch {
+	case c.Temperature == nil:
+		options["temperature"] = 0.0
+	case *c.Temperature >= 0:
+		options["temperature"] = *c.Temperature
+	}
+	if len(options) == 0 {
+		return nil
+	}
+	return options
+}
+
+// NotificationConfig controls how Llemecode gets your attention when it
+// needs approval or finishes a long task while you're in another window
+type NotificationConfig struct {
+	Bell            bool `json:"bell"`              // Ring the terminal bell
+	Desktop         bool `json:"desktop"`           // Send a desktop notification (notify-send or osascript)
+	LongTaskSeconds int  `json:"long_task_seconds"` // Notify when a reply took at least this long; 0 disables
 }
 
 type PermissionPattern struct {
@@ -43,13 +273,40 @@ type PermissionPattern struct {
 	PathPattern    string `json:"path_pattern,omitempty"`    // Glob pattern (e.g., "/home/user/project/**", "*.txt")
 	CommandPattern string `json:"command_pattern,omitempty"` // Command prefix for run_command (e.g., "ls", "git status")
 	AlwaysAllow    bool   `json:"always_allow,omitempty"`    // If true, always allow this tool regardless of path/command
+	Root           string `json:"root,omitempty"`            // Project root name (see --root) the pattern is limited to
 	Enabled        bool   `json:"enabled"`
 }
 
+// ProjectTemplate is a starting point for llemecode new: a git repository
+// that is cloned, or a local directory that is copied
+type ProjectTemplate struct {
+	Source      string `json:"source"`
+	Description string `json:"description,omitempty"`
+	Task        string `json:"task,omitempty"` // First message, ready in the input; {{TEMPLATE}} and {{PROJECT}} are filled in. Empty asks to customize the template.
+}
+
+// StartupConfig sets how the chat opens: a welcome message of its own and
+// actions run before the first message. Projects override it for the
+// directories they name and everything inside them.
+type StartupConfig struct {
+	Welcome  string                   `json:"welcome,omitempty"`  // Replaces the built-in welcome; {{MODEL}} and {{PROJECT}} are filled in
+	Actions  []string                 `json:"actions,omitempty"`  // Run in order: /commands, !shell commands, or prompts sent to the model
+	Projects map[string]StartupConfig `json:"projects,omitempty"` // By directory, e.g. "~/src/api"
+}
+
 type ModelAsTool struct {
 	ModelName   string `json:"model_name"`
 	Description string `json:"description"`
 	Enabled     bool   `json:"enabled"`
+
+	// Optional overrides for every question sent to the model
+	SystemPrompt string   `json:"system_prompt,omitempty"`
+	Temperature  *float64 `json:"temperature,omitempty"`
+	MaxTokens    int      `json:"max_tokens,omitempty"`
+
+	// Limits on a delegated question; zero means 300 seconds and 2000 tokens
+	TimeoutSeconds  int `json:"timeout_seconds,omitempty"`
+	MaxResultTokens int `json:"max_result_tokens,omitempty"` // Longer answers are summarized to fit
 }
 
 type BenchmarkTask struct {
@@ -63,10 +320,43 @@ type ModelCapability struct {
 	SupportsTools  bool     `json:"supports_tools"`
 	ToolCallFormat string   `json:"tool_call_format"`
 	MaxTokens      int      `json:"max_tokens,omitempty"`
+	ToolConfidence float64  `json:"tool_confidence,omitempty"` // Fraction of detection trials the format passed
+	Vision         bool     `json:"vision,omitempty"`
+	ContextLength  int      `json:"context_length,omitempty"` // Tokens, 0 if unknown
 	RecommendedFor []string `json:"recommended_for,omitempty"`
+
+	// From the last benchmark, so comparisons don't need the results file
+	TotalScore     float64            `json:"total_score,omitempty"`
+	CategoryScores map[string]float64 `json:"category_scores,omitempty"`
+	AvgLatency     time.Duration      `json:"avg_latency_ns,omitempty"`
 }
 
-func GetConfigDir() (string, error) {
+// profile is the named profile this process uses; empty for the default
+var profile string
+
+// profileName allows names that are safe as a directory name
+var profileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// SetProfile makes GetConfigDir return the directory of the named profile,
+// so its config, permissions, sessions and history are kept apart from the
+// default ones. An empty name or "default" selects the default profile.
+func SetProfile(name string) error {
+	if name == "default" {
+		name = ""
+	}
+	if name != "" && !profileName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q (use letters, digits, - and _)", name)
+	}
+	profile = name
+	return nil
+}
+
+// Profile returns the profile set with SetProfile, empty for the default
+func Profile() string {
+	return profile
+}
+
+func baseConfigDir() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("get home dir: %w", err)
@@ -74,6 +364,36 @@ func GetConfigDir() (string, error) {
 	return filepath.Join(home, ".config", "llemecode"), nil
 }
 
+func GetConfigDir() (string, error) {
+	dir, err := baseConfigDir()
+	if err != nil || profile == "" {
+		return dir, err
+	}
+	return filepath.Join(dir, "profiles", profile), nil
+}
+
+// ListProfiles returns the names of the profiles that have been used
+func ListProfiles() ([]string, error) {
+	dir, err := baseConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "profiles"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read profiles: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && profileName.MatchString(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
 func GetConfigPath() (string, error) {
 	dir, err := GetConfigDir()
 	if err != nil {
@@ -105,11 +425,78 @@ func Load() (*Config, error) {
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("parse config: %w", err)
 	}
+	if err := json.Unmarshal(data, &cfg.base); err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+
+	if cfg.migrate() {
+		if err := cfg.Save(); err != nil {
+			return nil, fmt.Errorf("save migrated config: %w", err)
+		}
+	}
 
 	return &cfg, nil
 }
 
+// migrate updates settings saved by older versions and reports whether it
+// changed anything
+func (c *Config) migrate() bool {
+	if c.Version >= configVersion {
+		return false
+	}
+	if c.Version < 1 {
+		// Unversioned configs always saved the jail as off, so that says
+		// nothing about what the user wants; turn on the new default
+		c.Permissions.RestrictToWorkingDir = true
+	}
+	if c.Version < 2 && len(c.Permissions.BlockedCommands) > 0 {
+		// Rules added to the defaults in version 2; a list blocking nothing
+		// was emptied on purpose and stays that way
+		have := make(map[string]bool)
+		for _, rule := range c.Permissions.BlockedCommands {
+			have[rule] = true
+		}
+		for _, rule := range []string{"curl | python*", "wget | python*", "curl | perl", "wget | perl", "tee /dev/sd*", "tee /dev/nvme*"} {
+			if !have[rule] {
+				c.Permissions.BlockedCommands = append(c.Permissions.BlockedCommands, rule)
+			}
+		}
+	}
+	if c.Version < 3 && reflect.DeepEqual(c.Permissions.AllowedCommands, []string{
+		"go", "gofmt", "git", "npm", "npx", "node", "yarn", "pnpm", "make", "cargo",
+		"python", "python3", "pip", "pytest",
+		"ls", "cat", "head", "tail", "wc", "grep", "diff", "sort", "uniq",
+		"echo", "pwd", "cd", "true", "false", "test", "which", "env",
+	}) {
+		// The version 2 default let interpreters and env run any code
+		// without asking; lists changed by the user are theirs to keep
+		c.Permissions.AllowedCommands = DefaultConfig().Permissions.AllowedCommands
+	}
+	c.Version = configVersion
+	return true
+}
+
+// Save writes the config to disk. Fields changed on disk by other writers
+// since this config was loaded are kept unless this config changed them too.
 func (c *Config) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.saveLocked()
+}
+
+// Update applies fn while holding the config lock and then saves.
+// fn must modify fields directly rather than calling other Config methods.
+func (c *Config) Update(fn func(*Config)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn(c)
+	return c.saveLocked()
+}
+
+func (c *Config) saveLocked() error {
+	saveMu.Lock()
+	defer saveMu.Unlock()
+
 	configPath, err := GetConfigPath()
 	if err != nil {
 		return err
@@ -120,20 +507,258 @@ func (c *Config) Save() error {
 		return fmt.Errorf("create config dir: %w", err)
 	}
 
-	data, err := json.MarshalIndent(c, "", "  ")
+	ours, err := toGeneric(c)
 	if err != nil {
 		return fmt.Errorf("marshal config: %w", err)
 	}
 
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
+	// Write saved values for overridden fields, unless they were changed
+	// again since, which makes the new value intentional
+	for key, o := range c.overrides {
+		if reflect.DeepEqual(ours[key], o.value) {
+			ours[key] = o.original
+		} else {
+			delete(c.overrides, key)
+		}
+	}
+
+	merged := ours
+	if data, err := os.ReadFile(configPath); err == nil {
+		var theirs map[string]interface{}
+		if json.Unmarshal(data, &theirs) == nil {
+			merged = mergeObjects(c.base, ours, theirs)
+		}
+	}
+
+	data, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal config: %w", err)
+	}
+
+	if err := WriteFileAtomic(configPath, data, 0644); err != nil {
 		return fmt.Errorf("write config: %w", err)
 	}
 
+	// Pick up fields merged in from other writers, keeping overrides in effect
+	inMemory := data
+	if len(c.overrides) > 0 {
+		withOverrides := make(map[string]interface{}, len(merged))
+		for k, v := range merged {
+			withOverrides[k] = v
+		}
+		for key, o := range c.overrides {
+			o.original = merged[key]
+			c.overrides[key] = o
+			withOverrides[key] = o.value
+		}
+		if inMemory, err = json.Marshal(withOverrides); err != nil {
+			return fmt.Errorf("marshal config: %w", err)
+		}
+	}
+
+	var fresh Config
+	if err := json.Unmarshal(inMemory, &fresh); err != nil {
+		return fmt.Errorf("parse merged config: %w", err)
+	}
+	c.copyFields(&fresh)
+	c.base = merged
+
 	return nil
 }
 
+// OverrideOllamaURL points this run at another Ollama server without
+// changing the saved config
+func (c *Config) OverrideOllamaURL(url string) {
+	c.setOverride("ollama_url", func() { c.OllamaURL = url })
+}
+
+// OverrideDefaultModel uses another model for this run without changing the
+// saved config
+func (c *Config) OverrideDefaultModel(model string) {
+	c.setOverride("default_model", func() { c.DefaultModel = model })
+}
+
+// PersistOverrides saves values set with the Override methods
+func (c *Config) PersistOverrides() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.overrides = nil
+	return c.saveLocked()
+}
+
+func (c *Config) setOverride(key string, apply func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	before, _ := toGeneric(c)
+	apply()
+	after, _ := toGeneric(c)
+
+	if c.overrides == nil {
+		c.overrides = make(map[string]override)
+	}
+	o, ok := c.overrides[key]
+	if !ok {
+		o.original = before[key]
+	}
+	o.value = after[key]
+	c.overrides[key] = o
+}
+
+// copyFields copies every serialized field from src, leaving the lock and merge base alone
+func (c *Config) copyFields(src *Config) {
+	dst := reflect.ValueOf(c).Elem()
+	from := reflect.ValueOf(src).Elem()
+	for i := 0; i < dst.NumField(); i++ {
+		if dst.Type().Field(i).IsExported() {
+			dst.Field(i).Set(from.Field(i))
+		}
+	}
+}
+
+func toGeneric(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// mergeObjects performs a three-way merge of JSON objects: keys this writer
+// changed relative to base win, everything else comes from disk. Nested
+// objects are merged recursively; arrays and scalars are replaced whole.
+func mergeObjects(base, ours, theirs map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(theirs))
+	for k, v := range theirs {
+		merged[k] = v
+	}
+
+	keys := make(map[string]bool)
+	for k := range ours {
+		keys[k] = true
+	}
+	for k := range base {
+		keys[k] = true
+	}
+
+	for k := range keys {
+		ov, inOurs := ours[k]
+		bv, inBase := base[k]
+		if inOurs == inBase && reflect.DeepEqual(ov, bv) {
+			continue // Unchanged here, keep disk value
+		}
+		if !inOurs {
+			delete(merged, k) // Removed here
+			continue
+		}
+		om, oIsObj := ov.(map[string]interface{})
+		tm, tIsObj := merged[k].(map[string]interface{})
+		if oIsObj && tIsObj {
+			bm, _ := bv.(map[string]interface{})
+			merged[k] = mergeObjects(bm, om, tm)
+			continue
+		}
+		merged[k] = ov
+	}
+
+	return merged
+}
+
+// WriteFileAtomic writes through a temporary file in the same directory and
+// renames it over path, so readers never see a half-written file
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// GetCapability returns the recorded capability for a model
+func (c *Config) GetCapability(modelName string) (ModelCapability, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	cap, ok := c.ModelCapabilities[modelName]
+	return cap, ok
+}
+
+// GetCapabilities returns a copy of the recorded capabilities by model
+func (c *Config) GetCapabilities() map[string]ModelCapability {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	caps := make(map[string]ModelCapability, len(c.ModelCapabilities))
+	for model, cap := range c.ModelCapabilities {
+		caps[model] = cap
+	}
+	return caps
+}
+
+// SetCapability records the capability for a model without saving
+func (c *Config) SetCapability(modelName string, cap ModelCapability) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ModelCapabilities == nil {
+		c.ModelCapabilities = make(map[string]ModelCapability)
+	}
+	c.ModelCapabilities[modelName] = cap
+}
+
+// BestModelFor returns the best benchmarked model for a category
+func (c *Config) BestModelFor(category string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	model, ok := c.BestModels[category]
+	return model, ok && model != ""
+}
+
+// GetBestModels returns a copy of the best model per category
+func (c *Config) GetBestModels() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	best := make(map[string]string, len(c.BestModels))
+	for category, model := range c.BestModels {
+		best[category] = model
+	}
+	return best
+}
+
+// GetCategoryWeights returns a copy of the configured category weights
+func (c *Config) GetCategoryWeights() map[string]float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	weights := make(map[string]float64, len(c.CategoryWeights))
+	for category, w := range c.CategoryWeights {
+		weights[category] = w
+	}
+	return weights
+}
+
 func (c *Config) ModelSupportsTools(modelName string) bool {
-	if cap, ok := c.ModelCapabilities[modelName]; ok {
+	if cap, ok := c.GetCapability(modelName); ok {
 		return cap.SupportsTools
 	}
 	// Default: assume tools are NOT supported for unknown models (use fallback)
@@ -142,7 +767,7 @@ func (c *Config) ModelSupportsTools(modelName string) bool {
 }
 
 func (c *Config) GetToolCallFormat(modelName string) string {
-	if cap, ok := c.ModelCapabilities[modelName]; ok {
+	if cap, ok := c.GetCapability(modelName); ok {
 		return cap.ToolCallFormat
 	}
 	// Default to text fallback for unknown models (simplest, most reliable)
@@ -152,9 +777,11 @@ func (c *Config) GetToolCallFormat(modelName string) string {
 
 func DefaultConfig() *Config {
 	return &Config{
+		Version:      configVersion,
 		OllamaURL:    "http://localhost:11434",
 		DefaultModel: "",
 		Permissions: PermissionConfig{
+			RestrictToWorkingDir:   true,
 			AutoApproveSafe:        true,
 			AutoApproveRead:        false, // Ask for read operations
 			RequireApprovalWrite:   true,
@@ -162,11 +789,60 @@ func DefaultConfig() *Config {
 			RequireApprovalNetwork: true, // Ask for network operations
 			BlockedCommands: []string{
 				"rm -rf /",
-				"dd if=",
+				"rm -rf ~",
+				"dd of=/dev/*",
 				"mkfs",
 				":(){ :|:& };:",
-				"> /dev/sda",
+				"> /dev/sd*",
+				"> /dev/nvme*",
+				"chmod -R 777 /",
+				"curl | sh", // Any shell
+				"wget | sh",
+				"curl | python*",
+				"wget | python*",
+				"curl | perl",
+				"wget | perl",
+				"tee /dev/sd*",
+				"tee /dev/nvme*",
+			},
+			DeniedRoots: []string{
+				"~/.ssh",
+				"~/.gnupg",
+				"~/.aws",
+				"~/.config/llemecode",
 			},
+			AllowedCommands: []string{
+				"go", "gofmt", "git", "npm", "yarn", "pnpm", "make", "cargo", "pip", "pytest",
+				"ls", "cat", "head", "tail", "wc", "grep", "diff", "sort", "uniq",
+				"echo", "pwd", "cd", "true", "false", "test", "which",
+			},
+		},
+		TurnBudget: TurnBudgetConfig{
+			MaxToolCalls:    50,
+			MaxFilesWritten: 20,
+			MaxCommands:     25,
+			MaxMinutes:      15,
+		},
+		ContextPruning: ContextPruningConfig{
+			Enabled: true,
+		},
+		SessionSummary: SessionSummaryConfig{
+			Enabled: true,
+		},
+		FileWatch: FileWatchConfig{
+			Enabled: true,
+		},
+		Notifications: NotificationConfig{
+			Bell:            true,
+			LongTaskSeconds: 30,
+		},
+		ToolDetection: ToolDetectionConfig{
+			Trials:    3,
+			Threshold: 0.66,
+		},
+		CategoryWeights: map[string]float64{
+			"coding":   2,
+			"creative": 0.5,
 		},
 		BenchmarkTasks: []BenchmarkTask{
 			{
@@ -258,6 +934,15 @@ Available tools:
 {{TOOLS}}
 
 Use tools when needed to help answer the user's questions.`,
+
+			"review": `You are a senior engineer reviewing a change. You get one chunk of a diff; lines start with their line number in the new file and + for added, - for removed or a space for unchanged context.
+
+Report real problems in the added and changed lines: bugs, security issues, races, resource leaks, missing error handling, broken edge cases and unclear code. Do not comment on unchanged context, style a formatter would fix, or things you cannot see from the chunk.
+
+Answer with JSON only:
+{"findings": [{"severity": "critical|major|minor|nit", "file": "path", "line": 42, "title": "short summary", "detail": "what is wrong and why", "suggestion": "how to fix it"}]}
+
+Use an empty findings list when the chunk looks fine.`,
 		},
 		ModelCapabilities: make(map[string]ModelCapability),
 	}
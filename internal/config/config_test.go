@@ -1,6 +1,10 @@
 package config
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 )
 
@@ -22,6 +26,10 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.ModelCapabilities == nil {
 		t.Error("Expected initialized model capabilities map")
 	}
+
+	if cfg.TurnBudget.MaxToolCalls == 0 || cfg.TurnBudget.MaxMinutes == 0 {
+		t.Error("Expected default turn budget limits")
+	}
 }
 
 func TestConfigSaveAndLoad(t *testing.T) {
@@ -71,11 +79,6 @@ func TestModelSupportsTools(t *testing.T) {
 	if cfg.ModelSupportsTools("model-without-tools") {
 		t.Error("Expected model-without-tools to not support tools")
 	}
-
-	// Unknown model should default to true
-	if !cfg.ModelSupportsTools("unknown-model") {
-		t.Error("Expected unknown model to default to supporting tools")
-	}
 }
 
 func TestGetToolCallFormat(t *testing.T) {
@@ -88,9 +91,232 @@ func TestGetToolCallFormat(t *testing.T) {
 	if format := cfg.GetToolCallFormat("xml-model"); format != "xml" {
 		t.Errorf("Expected 'xml', got '%s'", format)
 	}
+}
+
+func TestUnknownModelDefaults(t *testing.T) {
+	cfg := DefaultConfig()
+
+	// Unknown models use the text fallback until detection says otherwise
+	if cfg.ModelSupportsTools("unknown-model") {
+		t.Error("Expected unknown model to default to not supporting tools")
+	}
+	if format := cfg.GetToolCallFormat("unknown"); format != "text" {
+		t.Errorf("Expected 'text' for unknown model, got '%s'", format)
+	}
+}
+
+func TestSaveMergesConcurrentWriters(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	first, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	second, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if err := first.Update(func(c *Config) { c.DefaultModel = "first-model" }); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := second.Update(func(c *Config) { c.DisabledTools = []string{"web_fetch"} }); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if loaded.DefaultModel != "first-model" {
+		t.Errorf("Expected default model from first writer, got '%s'", loaded.DefaultModel)
+	}
+	if len(loaded.DisabledTools) != 1 || loaded.DisabledTools[0] != "web_fetch" {
+		t.Errorf("Expected disabled tools from second writer, got %v", loaded.DisabledTools)
+	}
+	if second.DefaultModel != "first-model" {
+		t.Errorf("Expected second writer to pick up merged default model, got '%s'", second.DefaultModel)
+	}
+}
+
+func TestConcurrentSave(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cfg.SetCapability(fmt.Sprintf("model-%d", i), ModelCapability{ToolCallFormat: "text"})
+			if err := cfg.Save(); err != nil {
+				t.Errorf("Save failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(loaded.ModelCapabilities) != 10 {
+		t.Errorf("Expected 10 capabilities, got %d", len(loaded.ModelCapabilities))
+	}
+}
+
+func TestOverridesAreNotSaved(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if err := cfg.Update(func(c *Config) { c.DefaultModel = "saved-model" }); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	cfg.OverrideDefaultModel("flag-model")
+	cfg.OverrideOllamaURL("http://remote:11434")
+	if err := cfg.Update(func(c *Config) { c.DisabledTools = []string{"bash"} }); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	if cfg.DefaultModel != "flag-model" {
+		t.Errorf("Expected override to stay in effect, got '%s'", cfg.DefaultModel)
+	}
 
-	// Unknown model should default to native
-	if format := cfg.GetToolCallFormat("unknown"); format != "native" {
-		t.Errorf("Expected 'native' for unknown model, got '%s'", format)
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.DefaultModel != "saved-model" {
+		t.Errorf("Expected saved model on disk, got '%s'", loaded.DefaultModel)
+	}
+	if loaded.OllamaURL != "http://localhost:11434" {
+		t.Errorf("Expected saved URL on disk, got '%s'", loaded.OllamaURL)
+	}
+	if len(loaded.DisabledTools) != 1 {
+		t.Errorf("Expected other changes to be saved, got %v", loaded.DisabledTools)
+	}
+
+	if err := cfg.PersistOverrides(); err != nil {
+		t.Fatalf("PersistOverrides failed: %v", err)
+	}
+	loaded, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.DefaultModel != "flag-model" {
+		t.Errorf("Expected persisted override on disk, got '%s'", loaded.DefaultModel)
+	}
+}
+
+func TestLoadMigratesUnversionedConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path, err := GetConfigPath()
+	if err != nil {
+		t.Fatalf("GetConfigPath failed: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := `{"ollama_url": "http://localhost:11434", "permissions": {"restrict_to_working_dir": false, "blocked_commands": ["rm -rf /", "curl | sh"]}}`
+	if err := os.WriteFile(path, []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !cfg.Permissions.RestrictToWorkingDir || cfg.Version != configVersion {
+		t.Errorf("Expected the jail on at version %d, got %v at version %d", configVersion, cfg.Permissions.RestrictToWorkingDir, cfg.Version)
+	}
+
+	if allowed := cfg.Permissions.AllowedCommands; len(allowed) != 0 {
+		t.Errorf("Expected an unset allowlist to stay unset, got %v", allowed)
+	}
+	if rules := cfg.Permissions.BlockedCommands; len(rules) != 8 || rules[0] != "rm -rf /" || rules[7] != "tee /dev/nvme*" {
+		t.Errorf("Expected the new default rules after the saved ones, got %v", rules)
+	}
+
+	// Once migrated, turning the jail off sticks
+	if err := cfg.Update(func(c *Config) { c.Permissions.RestrictToWorkingDir = false }); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.Permissions.RestrictToWorkingDir {
+		t.Error("Expected the saved choice to be kept after migration")
+	}
+}
+
+func TestLoadMigratesOldAllowlist(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path, err := GetConfigPath()
+	if err != nil {
+		t.Fatalf("GetConfigPath failed: %v", err)
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+
+	old := `{"version": 2, "permissions": {"allowed_commands": ["go", "gofmt", "git", "npm", "npx", "node", "yarn", "pnpm", "make", "cargo", "python", "python3", "pip", "pytest", "ls", "cat", "head", "tail", "wc", "grep", "diff", "sort", "uniq", "echo", "pwd", "cd", "true", "false", "test", "which", "env"]}}`
+	os.WriteFile(path, []byte(old), 0644)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	for _, cmd := range cfg.Permissions.AllowedCommands {
+		if cmd == "python3" || cmd == "node" || cmd == "env" {
+			t.Errorf("Expected %s to be dropped from the old default allowlist, got %v", cmd, cfg.Permissions.AllowedCommands)
+		}
+	}
+
+	custom := `{"version": 2, "permissions": {"allowed_commands": ["go", "python3"]}}`
+	os.WriteFile(path, []byte(custom), 0644)
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if allowed := cfg.Permissions.AllowedCommands; len(allowed) != 2 || allowed[1] != "python3" {
+		t.Errorf("Expected a list the user changed to be kept, got %v", allowed)
+	}
+}
+
+func TestProfileDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	defer SetProfile("")
+
+	if err := SetProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := GetConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, ".config", "llemecode", "profiles", "work"); dir != want {
+		t.Errorf("Expected %s, got %s", want, dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := ListProfiles(); len(names) != 1 || names[0] != "work" {
+		t.Errorf("Expected [work], got %v", names)
+	}
+
+	for _, bad := range []string{"../x", "a/b", "-x", " "} {
+		if err := SetProfile(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+	if err := SetProfile("default"); err != nil || Profile() != "" {
+		t.Errorf("Expected default to select the default profile, got %q, %v", Profile(), err)
 	}
 }
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// PolicyPath is where administrators put the team policy
+var PolicyPath = "/etc/llemecode/policy.json"
+
+// Policy is set by an administrator outside the user's config and limits
+// how permissive any profile can be
+type Policy struct {
+	RequireApproval      []string `json:"require_approval,omitempty"` // Levels that always ask: read, write, execute or network
+	BlockedCommands      []string `json:"blocked_commands,omitempty"` // Refused on top of the user's blocked commands
+	DisabledTools        []string `json:"disabled_tools,omitempty"`
+	DeniedRoots          []string `json:"denied_roots,omitempty"` // File tools never work inside these
+	RestrictToWorkingDir bool     `json:"restrict_to_working_dir,omitempty"`
+}
+
+// LoadPolicy reads the policy at PolicyPath, returning nil when there is
+// none. A policy that can't be read is an error rather than no policy, so a
+// typo never silently lifts the restrictions.
+func LoadPolicy() (*Policy, error) {
+	data, err := os.ReadFile(PolicyPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read policy %s: %w", PolicyPath, err)
+	}
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("parse policy %s: %w", PolicyPath, err)
+	}
+	for _, level := range policy.RequireApproval {
+		switch level {
+		case "read", "write", "execute", "network":
+		default:
+			return nil, fmt.Errorf("policy %s: unknown permission level %q in require_approval (use read, write, execute or network)", PolicyPath, level)
+		}
+	}
+	return &policy, nil
+}
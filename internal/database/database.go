@@ -0,0 +1,278 @@
+// Package database runs queries against the connection profiles in the
+// config, read-only unless a profile allows writes, with row and size limits.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/zalando/go-keyring"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	defaultMaxRows  = 100
+	defaultMaxBytes = 32 * 1024
+	maxCellRunes    = 200
+	queryTimeout    = 30 * time.Second
+	keyringService  = "llemecode"
+)
+
+// readPrefixes are the statements a read-only profile accepts
+var readPrefixes = []string{"select", "with", "explain", "show", "describe", "desc", "pragma", "values", "table"}
+
+// SetPassword stores the password for a profile in the system keyring
+func SetPassword(profile, password string) error {
+	if err := keyring.Set(keyringService, "database:"+profile, password); err != nil {
+		return fmt.Errorf("store password in keyring: %w", err)
+	}
+	return nil
+}
+
+// DeletePassword removes the password for a profile from the keyring
+func DeletePassword(profile string) error {
+	if err := keyring.Delete(keyringService, "database:"+profile); err != nil {
+		return fmt.Errorf("delete password from keyring: %w", err)
+	}
+	return nil
+}
+
+// Conn is an open connection for one profile
+type Conn struct {
+	Name    string
+	profile config.DatabaseProfile
+	db      *sql.DB
+}
+
+// Open connects to a profile
+func Open(name string, profile config.DatabaseProfile) (*Conn, error) {
+	driver, dsn, err := driverDSN(name, profile)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", name, err)
+	}
+	db.SetMaxOpenConns(1)
+	return &Conn{Name: name, profile: profile, db: db}, nil
+}
+
+// Close closes the connection
+func (c *Conn) Close() error {
+	return c.db.Close()
+}
+
+// driverDSN returns the database/sql driver and the DSN with the password
+// filled in. Read-only sqlite databases are opened read-only.
+func driverDSN(name string, profile config.DatabaseProfile) (string, string, error) {
+	dsn := profile.DSN
+	if strings.Contains(dsn, "{password}") {
+		password, err := keyring.Get(keyringService, "database:"+name)
+		if err != nil {
+			return "", "", fmt.Errorf("no password for %s in the keyring (run llemecode db password %s): %w", name, name, err)
+		}
+		if strings.Contains(dsn, "://") {
+			// Escape for the userinfo part of a URL
+			password = strings.TrimPrefix(url.UserPassword("", password).String(), ":")
+		}
+		dsn = strings.ReplaceAll(dsn, "{password}", password)
+	}
+
+	switch profile.Driver {
+	case "sqlite", "sqlite3":
+		if !profile.ReadWrite {
+			dsn = "file:" + strings.TrimPrefix(dsn, "file:")
+			sep := "?"
+			if strings.Contains(dsn, "?") {
+				sep = "&"
+			}
+			dsn += sep + "mode=ro&_pragma=query_only(1)"
+		}
+		return "sqlite", dsn, nil
+	case "postgres", "postgresql":
+		return "pgx", dsn, nil
+	case "mysql", "mariadb":
+		return "mysql", dsn, nil
+	}
+	return "", "", fmt.Errorf("database %s: unknown driver %q (use sqlite, postgres or mysql)", name, profile.Driver)
+}
+
+// isRead reports whether a statement only reads
+func isRead(query string) bool {
+	fields := strings.Fields(strings.ToLower(query))
+	if len(fields) == 0 {
+		return false
+	}
+	for _, prefix := range readPrefixes {
+		if fields[0] == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// Query runs a statement and formats the result as a table. Read-only
+// profiles only accept reads, which also run in a read-only transaction that
+// is rolled back.
+func (c *Conn) Query(ctx context.Context, query string, args ...interface{}) (string, error) {
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+	read := isRead(query)
+	if !read && !c.profile.ReadWrite {
+		return "", fmt.Errorf("%s is read-only: only SELECT, WITH, EXPLAIN, SHOW, DESCRIBE and PRAGMA are allowed (set read_write in its profile to allow changes)", c.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	tx, err := c.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: !c.profile.ReadWrite && c.profile.Driver != "sqlite" && c.profile.Driver != "sqlite3"})
+	if err != nil {
+		return "", fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	if !read {
+		res, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return "", err
+		}
+		if err := tx.Commit(); err != nil {
+			return "", fmt.Errorf("commit: %w", err)
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			return fmt.Sprintf("OK, %d rows affected", n), nil
+		}
+		return "OK", nil
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	result, err := c.format(rows)
+	if err != nil {
+		return "", err
+	}
+	if c.profile.ReadWrite {
+		// A WITH can wrap a change
+		rows.Close()
+		if err := tx.Commit(); err != nil {
+			return "", fmt.Errorf("commit: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// format writes rows as a pipe-separated table within the profile's limits
+func (c *Conn) format(rows *sql.Rows) (string, error) {
+	maxRows := c.profile.MaxRows
+	if maxRows <= 0 {
+		maxRows = defaultMaxRows
+	}
+	maxBytes := c.profile.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBytes
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	if len(columns) == 0 {
+		return "OK", nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.Join(columns, " | ") + "\n")
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	n := 0
+	truncated := ""
+	for rows.Next() {
+		if n == maxRows {
+			truncated = fmt.Sprintf("(stopped at %d rows; add LIMIT/OFFSET or WHERE to see others)", maxRows)
+			break
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return "", err
+		}
+		cells := make([]string, len(values))
+		for i, v := range values {
+			cells[i] = cell(v)
+		}
+		line := strings.Join(cells, " | ") + "\n"
+		if sb.Len()+len(line) > maxBytes {
+			truncated = fmt.Sprintf("(stopped after %d rows at the %d byte limit)", n, maxBytes)
+			break
+		}
+		sb.WriteString(line)
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	if truncated != "" {
+		sb.WriteString(truncated)
+	} else {
+		sb.WriteString(fmt.Sprintf("(%d rows)", n))
+	}
+	return sb.String(), nil
+}
+
+func cell(v interface{}) string {
+	var s string
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		s = string(v)
+	case time.Time:
+		s = v.Format(time.RFC3339)
+	default:
+		s = fmt.Sprint(v)
+	}
+	s = strings.ReplaceAll(strings.ReplaceAll(s, "\n", `\n`), "|", `\|`)
+	if r := []rune(s); len(r) > maxCellRunes {
+		s = string(r[:maxCellRunes]) + "…"
+	}
+	return s
+}
+
+// Tables lists the tables and views
+func (c *Conn) Tables(ctx context.Context) (string, error) {
+	switch c.profile.Driver {
+	case "sqlite", "sqlite3":
+		return c.Query(ctx, "SELECT name, type FROM sqlite_master WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	case "postgres", "postgresql":
+		return c.Query(ctx, "SELECT table_schema, table_name, table_type FROM information_schema.tables WHERE table_schema NOT IN ('pg_catalog', 'information_schema') ORDER BY 1, 2")
+	default:
+		return c.Query(ctx, "SELECT table_name, table_type FROM information_schema.tables WHERE table_schema = DATABASE() ORDER BY 1")
+	}
+}
+
+// Schema describes the columns of a table
+func (c *Conn) Schema(ctx context.Context, table string) (string, error) {
+	switch c.profile.Driver {
+	case "sqlite", "sqlite3":
+		return c.Query(ctx, "SELECT sql FROM sqlite_master WHERE name = ?", table)
+	case "postgres", "postgresql":
+		schema, name := "public", table
+		if i := strings.Index(table, "."); i >= 0 {
+			schema, name = table[:i], table[i+1:]
+		}
+		return c.Query(ctx, "SELECT column_name, data_type, is_nullable, column_default FROM information_schema.columns WHERE table_schema = $1 AND table_name = $2 ORDER BY ordinal_position", schema, name)
+	default:
+		return c.Query(ctx, "SELECT column_name, column_type, is_nullable, column_default, column_key FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position", table)
+	}
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+)
+
+func TestSQLiteProfiles(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "app.db")
+
+	rw, err := Open("rw", config.DatabaseProfile{Driver: "sqlite", DSN: path, ReadWrite: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rw.Close()
+	for _, stmt := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, bio TEXT)",
+		"INSERT INTO users (name, bio) VALUES ('ada', 'line one\nline two'), ('bob', NULL), ('cy', 'x')",
+	} {
+		if _, err := rw.Query(ctx, stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	ro, err := Open("ro", config.DatabaseProfile{Driver: "sqlite", DSN: path, MaxRows: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ro.Close()
+
+	out, err := ro.Query(ctx, "SELECT name, bio FROM users ORDER BY id;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"name | bio", `ada | line one\nline two`, "bob | NULL", "stopped at 2 rows"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("result lacks %q:\n%s", want, out)
+		}
+	}
+
+	if _, err := ro.Query(ctx, "DELETE FROM users"); err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Errorf("delete on a read-only profile: %v", err)
+	}
+	if _, err := ro.Query(ctx, "WITH gone AS (SELECT 1) DELETE FROM users"); err == nil {
+		t.Error("a write wrapped in WITH ran on a read-only profile")
+	}
+
+	out, err = ro.Schema(ctx, "users")
+	if err != nil || !strings.Contains(out, "CREATE TABLE users") {
+		t.Errorf("schema: %v\n%s", err, out)
+	}
+	out, err = ro.Tables(ctx)
+	if err != nil || !strings.Contains(out, "users | table") {
+		t.Errorf("tables: %v\n%s", err, out)
+	}
+}
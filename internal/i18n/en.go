@@ -0,0 +1,163 @@
+package i18n
+
+// english is the reference catalog; every key used in the code must be here
+var english = map[string]string{
+	// Chat transcript
+	"chat.header":         "💬 Llemecode Chat - Model: %s • Tools: %s • %s",
+	"chat.welcome":        "Welcome to Llemecode! You are using **%s**.\n\nAvailable commands:\n- `/help` - Show all commands\n- `/model <name>` - Switch model\n- `/models` - List available models\n- `/reset` - Clear conversation\n\nType your message and press Enter to chat.",
+	"chat.placeholder":    "Type your message or /help for commands...",
+	"chat.you":            "You: ",
+	"chat.assistant":      "Assistant: ",
+	"chat.restored":       "✓ Restored %d messages from previous session",
+	"chat.draft_restored": "✏️ Restored the message you were typing",
+	"chat.discarded":      "Previous session discarded",
+	"chat.interrupted":    "⚠️ Previous task interrupted",
+	"chat.cancelled":      "⚠️ Task cancelled",
+	"chat.command_error":  "Command error: %v",
+	"chat.startup_error":  "Startup action %s failed: %v",
+	"chat.error":          "Error: %v",
+	"chat.restore_prompt": "💾 Found an unsaved conversation from %s (%d messages, model **%s**).\n\n**Restore previous session?** (y/n)",
+	"chat.tool_collapse":  "▾ click to collapse",
+	"chat.tool_expand":    "▸ %d more lines (click to expand)",
+	"chat.paste_expand":   "▸ click to show the pasted text",
+	"chat.panel_empty":    "Nothing to show yet",
+
+	// Status line
+	"status.thinking":           "Thinking...",
+	"status.continuing":         "Continuing...",
+	"status.stopping":           "Stopping...",
+	"status.budget":             "Budget exceeded, awaiting confirmation...",
+	"status.question":           "Waiting for your answer...",
+	"status.permission":         "Awaiting permission...",
+	"status.running":            "Running: %s...",
+	"status.queue":              " | ⏸ Queue: %d msg",
+	"status.queue_many":         " | ⏸ Queue: %d msgs",
+	"status.queue_hint":         " | Esc: interrupt",
+	"status.queue_more":         "  ... %d more (/queue)",
+	"status.background":         "⚙️  Background: %s",
+	"status.benchmark_done":     "📊 ✓ Background benchmarking complete!",
+	"status.benchmark_paused":   "⏸ Benchmark paused (/benchmark resume)",
+	"status.benchmark_progress": "%s %d/%d models • %s: %s",
+	"status.search":             "(reverse-search)`%s': ",
+	"status.search_none":        "no matches",
+
+	// Command output boxes
+	"command.running":    "⚡ Running",
+	"command.completed":  "✓ Completed",
+	"command.failed":     "✗ Failed",
+	"command.exit":       "Exit code: %d",
+	"command.exit_error": "Exit code: %d (error: %v)",
+
+	// Delegated model answers
+	"delegation.running": "🤖 %s is answering",
+	"delegation.done":    "✓ %s answered (%d lines)",
+	"delegation.failed":  "✗ %s failed: %v",
+	"delegation.hint":    "Esc: interrupt • Ctrl+O: whole answer",
+
+	// Permission prompts
+	"perm.title":                "%s PERMISSION REQUIRED",
+	"perm.level.execute":        "⚠️  EXECUTE",
+	"perm.level.write":          "⚠️  WRITE",
+	"perm.level.network":        "🌐 NETWORK",
+	"perm.level.read":           "📖 READ",
+	"perm.tool":                 "Tool: %s",
+	"perm.details":              "Details: %s",
+	"perm.target":               "Target: %s",
+	"perm.path":                 "Path: %s",
+	"perm.question":             "Allow this operation?",
+	"perm.options.command_path": "  y: yes (once)  s: command this session  n: no  c: always allow command  p: always on this path",
+	"perm.options.command":      "  y: yes (once)  s: command this session  n: no  a: always allow  c: always allow command",
+	"perm.options.path":         "  y: yes (once)  s: path this session  n: no  a: always allow  p: always on this path",
+	"perm.options.tool":         "  y: yes (once)  s: this session  n: no  a: always allow",
+	"perm.outside_title":        "📁 OUTSIDE WORKSPACE",
+	"perm.outside_options":      "  y: allow once  s: allow this session  r: add to allowed roots  n: deny",
+
+	// Turn budget prompt
+	"budget.title":   "⏱️  TURN BUDGET EXCEEDED",
+	"budget.reason":  "The agent stopped because %s.",
+	"budget.options": "  y: continue  n: stop this turn",
+
+	// Clarifying question
+	"question.title":   "❓ THE MODEL HAS A QUESTION",
+	"question.options": "  Type your answer (or an option number) and press Enter  Esc: skip",
+	"question.choose":  "  ↑/↓: choose  Enter: pick it  or type your own answer  Esc: skip",
+	"question.skipped": "Question skipped; the model will make its own assumption.",
+
+	// Large paste
+	"paste.title":   "📋 LARGE PASTE",
+	"paste.confirm": "This message includes %d KB of pasted text. Send it?",
+	"paste.options": "  y: send  n: keep editing",
+
+	// Images
+	"image.placeholder":      "🖼️  %s (%s)",
+	"image.placeholder_view": "🖼️  %s (%s) · /image %d to view",
+	"image.return":           "Press Enter to return to the chat",
+
+	// Update check
+	"update.available": "⬆️  Llemecode %s is available (you have %s). Type /update for details.",
+
+	// Memory fit check
+	"fit.warning":      "⚠️  %s needs about %s but only %s of memory is free; loading it may make the machine swap.",
+	"fit.unload":       "   Loaded models hold another %s (%s); /model unload frees it.",
+	"fit.alternatives": "   Installed models that fit: %s",
+	"fit.quantize":     "   Try a smaller quantization of %s (for example a q4_K_M tag) or a model with fewer parameters.",
+	"fit.unloaded":     "✓ Unloaded %s",
+	"fit.none_loaded":  "No other models are loaded",
+
+	// /model picker
+	"picker.title":  "🤖 SWITCH MODEL",
+	"picker.cancel": "Esc: cancel",
+
+	// Help line
+	"help.restore":      "y: restore • n: discard",
+	"help.search":       "Ctrl+N: next • Ctrl+P: prev • Enter: use • Esc: cancel",
+	"help.budget":       "y: continue • n: stop • Esc: stop",
+	"help.paste":        "y: send • n: keep editing • Esc: keep editing",
+	"help.question":     "Enter: answer • Esc: skip and let the model assume",
+	"help.choose":       "↑/↓: choose • Enter: pick • type to answer freely • Esc: skip",
+	"help.outside":      "y: allow once • s: this session • r: add to allowed roots • n: deny • Esc: deny",
+	"help.command_path": "y: once • s: session • n: deny • c: always this cmd • p: always this path • Esc: deny",
+	"help.command":      "y: once • s: session • n: deny • a: always allow tool • c: always this cmd • Esc: deny",
+	"help.path":         "y: once • s: session • n: deny • a: always allow tool • p: always this path • Esc: deny",
+	"help.tool":         "y: once • s: session • n: deny • a: always allow tool • Esc: deny",
+	"help.approve":      "y: approve • n: deny • Esc: deny",
+	"help.queue_mode":   "↑↓: select • Shift+↑↓: move • d: delete • e: edit • Esc: done",
+	"help.queued":       "Esc: interrupt and send next queued • Ctrl+Q: manage queue • /queue",
+	"help.waiting":      "Enter: queue message • /cmd: runs immediately • Esc: interrupt",
+	"help.idle":         "Enter: send • ↑↓: history • Ctrl+R: search • Ctrl+O: split view • Esc: quit",
+	"help.split":        "Tab: switch pane • Ctrl+←→: resize • ↑↓ PgUp PgDn: scroll focused pane • click a tool result to expand • Ctrl+O: close split",
+	"help.commands":     "## Available Commands",
+
+	// Plain mode
+	"plain.intro":           "Llemecode plain mode. Model: %s.",
+	"plain.usage":           "Type a message and press Enter. /help lists commands, /quit or end of input exits.",
+	"plain.answer_one_of":   "Please answer one of: %s",
+	"plain.permission":      "Permission required: %s wants %s access.",
+	"plain.level.execute":   "execute",
+	"plain.level.write":     "write",
+	"plain.level.network":   "network",
+	"plain.level.read":      "read",
+	"plain.allow":           "Allow? %s: ",
+	"plain.option.once":     "y = yes once",
+	"plain.option.session":  "s = this session",
+	"plain.option.tool":     "a = always allow this tool",
+	"plain.option.command":  "c = always allow this command",
+	"plain.option.path":     "p = always allow this path",
+	"plain.option.no":       "n = no",
+	"plain.allowed":         "Allowed.",
+	"plain.denied":          "Denied.",
+	"plain.outside":         "Outside workspace: %s wants to use %s",
+	"plain.outside_options": "Allow? y = once, s = this directory this session, r = add to allowed roots, n = no: ",
+	"plain.running":         "Running: %s",
+	"plain.exit_code":       "Command finished with exit code %d.",
+	"plain.restore_found":   "Found an unsaved conversation from %s (%d messages, model %s).",
+	"plain.restore_ask":     "Restore it? y or n: ",
+	"plain.restored":        "Restored %d messages.",
+	"plain.budget_ask":      "Continue? y or n: ",
+	"plain.question":        "Question from the model: %s",
+	"plain.question_ask":    "Answer (or Enter to skip): ",
+	"plain.image":           "Image: %s (%s)",
+	"plain.tool":            "Tool: %s %s",
+	"plain.tool_failed":     "Tool %s failed after %s: %v",
+	"plain.tool_done":       "Tool %s done in %s.",
+}
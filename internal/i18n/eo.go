@@ -0,0 +1,203 @@
+package i18n
+
+// esperanto keeps the English option keys (y, n, s, ...) so the shortcuts
+// are the same in every language
+var esperanto = map[string]string{
+	// Chat transcript
+	"chat.header":         "💬 Llemecode-Babilo - Modelo: %s • Iloj: %s • %s",
+	"chat.welcome":        "Bonvenon al Llemecode! Vi uzas **%s**.\n\nDisponeblaj komandoj:\n- `/help` - Montri ĉiujn komandojn\n- `/model <nomo>` - Ŝanĝi modelon\n- `/models` - Listigi disponeblajn modelojn\n- `/reset` - Forviŝi la konversacion\n\nTajpu vian mesaĝon kaj premu Enter por babili.",
+	"chat.placeholder":    "Tajpu vian mesaĝon aŭ /help por komandoj...",
+	"chat.you":            "Vi: ",
+	"chat.assistant":      "Asistanto: ",
+	"chat.restored":       "✓ Restaŭris %d mesaĝojn el la antaŭa seanco",
+	"chat.draft_restored": "✏️ Restaŭris la mesaĝon kiun vi tajpis",
+	"chat.discarded":      "Antaŭa seanco forĵetita",
+	"chat.interrupted":    "⚠️ Antaŭa tasko interrompita",
+	"chat.cancelled":      "⚠️ Tasko nuligita",
+	"chat.command_error":  "Komanda eraro: %v",
+	"chat.startup_error":  "Komenca ago %s malsukcesis: %v",
+	"chat.error":          "Eraro: %v",
+	"chat.restore_prompt": "💾 Troviĝis nekonservita konversacio de %s (%d mesaĝoj, modelo **%s**).\n\n**Ĉu restaŭri la antaŭan seancon?** (y/n)",
+	"chat.tool_collapse":  "▾ klaku por faldi",
+	"chat.tool_expand":    "▸ %d pliaj linioj (klaku por malfaldi)",
+	"chat.paste_expand":   "▸ klaku por montri la algluitan tekston",
+	"chat.panel_empty":    "Ankoraŭ nenio por montri",
+
+	// Status line
+	"status.thinking":           "Pensante...",
+	"status.continuing":         "Daŭrigante...",
+	"status.stopping":           "Ĉesante...",
+	"status.budget":             "Buĝeto superita, atendante konfirmon...",
+	"status.question":           "Atendante vian respondon...",
+	"status.permission":         "Atendante permeson...",
+	"status.running":            "Rulante: %s...",
+	"status.queue":              " | ⏸ Vico: %d mesaĝo",
+	"status.queue_many":         " | ⏸ Vico: %d mesaĝoj",
+	"status.queue_hint":         " | Esc: interrompi",
+	"status.queue_more":         "  ... %d pliaj (/queue)",
+	"status.background":         "⚙️  Fone: %s",
+	"status.benchmark_done":     "📊 ✓ Fona komparmezurado finiĝis!",
+	"status.benchmark_paused":   "⏸ Komparmezurado paŭzas (/benchmark resume)",
+	"status.benchmark_progress": "%s %d/%d modeloj • %s: %s",
+	"status.search":             "(inversa-serĉo)`%s': ",
+	"status.search_none":        "neniu trafo",
+
+	// Command output boxes
+	"command.running":    "⚡ Rulante",
+	"command.completed":  "✓ Finita",
+	"command.failed":     "✗ Malsukcesis",
+	"command.exit":       "Elira kodo: %d",
+	"command.exit_error": "Elira kodo: %d (eraro: %v)",
+
+	// Delegated model answers
+	"delegation.running": "🤖 %s respondas",
+	"delegation.done":    "✓ %s respondis (%d linioj)",
+	"delegation.failed":  "✗ %s malsukcesis: %v",
+	"delegation.hint":    "Esc: interrompi • Ctrl+O: la tuta respondo",
+
+	// Permission prompts
+	"perm.title":                "%s PERMESO BEZONATA",
+	"perm.level.execute":        "⚠️  RULI",
+	"perm.level.write":          "⚠️  SKRIBI",
+	"perm.level.network":        "🌐 RETO",
+	"perm.level.read":           "📖 LEGI",
+	"perm.tool":                 "Ilo: %s",
+	"perm.details":              "Detaloj: %s",
+	"perm.target":               "Celo: %s",
+	"perm.path":                 "Vojo: %s",
+	"perm.question":             "Ĉu permesi ĉi tiun operacion?",
+	"perm.options.command_path": "  y: jes (unufoje)  s: komando dum ĉi tiu seanco  n: ne  c: ĉiam permesi komandon  p: ĉiam en ĉi tiu vojo",
+	"perm.options.command":      "  y: jes (unufoje)  s: komando dum ĉi tiu seanco  n: ne  a: ĉiam permesi  c: ĉiam permesi komandon",
+	"perm.options.path":         "  y: jes (unufoje)  s: vojo dum ĉi tiu seanco  n: ne  a: ĉiam permesi  p: ĉiam en ĉi tiu vojo",
+	"perm.options.tool":         "  y: jes (unufoje)  s: dum ĉi tiu seanco  n: ne  a: ĉiam permesi",
+	"perm.outside_title":        "📁 EKSTER LA LABORSPACO",
+	"perm.outside_options":      "  y: permesi unufoje  s: permesi dum ĉi tiu seanco  r: aldoni al permesitaj radikoj  n: rifuzi",
+
+	// Turn budget prompt
+	"budget.title":   "⏱️  BUĜETO DE LA VICO SUPERITA",
+	"budget.reason":  "La agento haltis ĉar %s.",
+	"budget.options": "  y: daŭrigi  n: ĉesigi ĉi tiun vicon",
+
+	// Klariga demando
+	"question.title":   "❓ LA MODELO HAVAS DEMANDON",
+	"question.options": "  Tajpu vian respondon (aŭ numeron de elekto) kaj premu Enter  Esc: preterlasi",
+	"question.choose":  "  ↑/↓: elekti  Enter: preni ĝin  aŭ tajpu vian propran respondon  Esc: preterlasi",
+	"question.skipped": "Demando preterlasita; la modelo mem supozos.",
+
+	// Granda algluaĵo
+	"paste.title":   "📋 GRANDA ALGLUAĴO",
+	"paste.confirm": "Ĉi tiu mesaĝo enhavas %d KB da algluita teksto. Ĉu sendi ĝin?",
+	"paste.options": "  y: sendi  n: plu redakti",
+
+	// Bildoj
+	"image.placeholder":      "🖼️  %s (%s)",
+	"image.placeholder_view": "🖼️  %s (%s) · /image %d por vidi",
+	"image.return":           "Premu Enter por reveni al la babilo",
+
+	// Update check
+	"update.available": "⬆️  Llemecode %s haveblas (vi havas %s). Tajpu /update por detaloj.",
+
+	// Memory fit check
+	"fit.warning":      "⚠️  %s bezonas ĉirkaŭ %s sed nur %s da memoro estas libera; ŝargi ĝin povas igi la maŝinon paĝumi.",
+	"fit.unload":       "   Ŝargitaj modeloj tenas pliajn %s (%s); /model unload liberigas ĝin.",
+	"fit.alternatives": "   Instalitaj modeloj kiuj enireblas: %s",
+	"fit.quantize":     "   Provu pli malgrandan kvantigon de %s (ekzemple etikedon q4_K_M) aŭ modelon kun malpli da parametroj.",
+	"fit.unloaded":     "✓ Malŝargis %s",
+	"fit.none_loaded":  "Neniuj aliaj modeloj estas ŝargitaj",
+
+	// /model picker
+	"picker.title":  "🤖 ŜANĜI MODELON",
+	"picker.cancel": "Esc: nuligi",
+
+	// Help line
+	"help.restore":      "y: restaŭri • n: forĵeti",
+	"help.search":       "Ctrl+N: sekva • Ctrl+P: antaŭa • Enter: uzi • Esc: nuligi",
+	"help.budget":       "y: daŭrigi • n: ĉesi • Esc: ĉesi",
+	"help.paste":        "y: sendi • n: plu redakti • Esc: plu redakti",
+	"help.question":     "Enter: respondi • Esc: preterlasi kaj lasi la modelon supozi",
+	"help.choose":       "↑/↓: elekti • Enter: preni • tajpu por libera respondo • Esc: preterlasi",
+	"help.outside":      "y: unufoje • s: ĉi tiu seanco • r: aldoni al permesitaj radikoj • n: rifuzi • Esc: rifuzi",
+	"help.command_path": "y: unufoje • s: seanco • n: rifuzi • c: ĉiam ĉi tiu komando • p: ĉiam ĉi tiu vojo • Esc: rifuzi",
+	"help.command":      "y: unufoje • s: seanco • n: rifuzi • a: ĉiam permesi ilon • c: ĉiam ĉi tiu komando • Esc: rifuzi",
+	"help.path":         "y: unufoje • s: seanco • n: rifuzi • a: ĉiam permesi ilon • p: ĉiam ĉi tiu vojo • Esc: rifuzi",
+	"help.tool":         "y: unufoje • s: seanco • n: rifuzi • a: ĉiam permesi ilon • Esc: rifuzi",
+	"help.approve":      "y: aprobi • n: rifuzi • Esc: rifuzi",
+	"help.queue_mode":   "↑↓: elekti • Shift+↑↓: movi • d: forigi • e: redakti • Esc: preta",
+	"help.queued":       "Esc: interrompi kaj sendi la sekvan • Ctrl+Q: administri la vicon • /queue",
+	"help.waiting":      "Enter: envicigi mesaĝon • /komando: tuj ruliĝas • Esc: interrompi",
+	"help.idle":         "Enter: sendi • ↑↓: historio • Ctrl+R: serĉi • Ctrl+O: dividita vido • Esc: eliri",
+	"help.split":        "Tab: ŝanĝi panelon • Ctrl+←→: regrandigi • ↑↓ PgUp PgDn: rulumi la fokusitan panelon • klaku ilan rezulton por malfaldi • Ctrl+O: fermi la dividon",
+	"help.commands":     "## Disponeblaj komandoj",
+
+	// Plain mode
+	"plain.intro":           "Llemecode en simpla reĝimo. Modelo: %s.",
+	"plain.usage":           "Tajpu mesaĝon kaj premu Enter. /help listigas komandojn, /quit aŭ fino de la enigo eliras.",
+	"plain.answer_one_of":   "Bonvolu respondi per unu el: %s",
+	"plain.permission":      "Permeso bezonata: %s volas aliron por %s.",
+	"plain.level.execute":   "rulado",
+	"plain.level.write":     "skribado",
+	"plain.level.network":   "reto",
+	"plain.level.read":      "legado",
+	"plain.allow":           "Ĉu permesi? %s: ",
+	"plain.option.once":     "y = jes unufoje",
+	"plain.option.session":  "s = dum ĉi tiu seanco",
+	"plain.option.tool":     "a = ĉiam permesi ĉi tiun ilon",
+	"plain.option.command":  "c = ĉiam permesi ĉi tiun komandon",
+	"plain.option.path":     "p = ĉiam permesi ĉi tiun vojon",
+	"plain.option.no":       "n = ne",
+	"plain.allowed":         "Permesite.",
+	"plain.denied":          "Rifuzite.",
+	"plain.outside":         "Ekster la laborspaco: %s volas uzi %s",
+	"plain.outside_options": "Ĉu permesi? y = unufoje, s = ĉi tiun dosierujon dum ĉi tiu seanco, r = aldoni al permesitaj radikoj, n = ne: ",
+	"plain.running":         "Rulante: %s",
+	"plain.exit_code":       "La komando finiĝis kun elira kodo %d.",
+	"plain.restore_found":   "Troviĝis nekonservita konversacio de %s (%d mesaĝoj, modelo %s).",
+	"plain.restore_ask":     "Ĉu restaŭri ĝin? y aŭ n: ",
+	"plain.restored":        "Restaŭris %d mesaĝojn.",
+	"plain.budget_ask":      "Ĉu daŭrigi? y aŭ n: ",
+	"plain.question":        "Demando de la modelo: %s",
+	"plain.question_ask":    "Respondo (aŭ Enter por preterlasi): ",
+	"plain.image":           "Bildo: %s (%s)",
+	"plain.tool":            "Ilo: %s %s",
+	"plain.tool_failed":     "Ilo %s malsukcesis post %s: %v",
+	"plain.tool_done":       "Ilo %s finita en %s.",
+
+	// Slash command descriptions for /help; English uses Description()
+	"cmd.help":           "Montri disponeblajn komandojn",
+	"cmd.models":         "Listigi disponeblajn modelojn",
+	"cmd.model":          "Ŝanĝi al alia modelo, elektante el listo sen argumentoj (uzo: /model [<modelnomo> | for <kategorio> | unload])",
+	"cmd.prompts":        "Listigi disponeblajn sistemajn instigojn",
+	"cmd.reset":          "Forviŝi la konversacian historion",
+	"cmd.benchmark":      "Komparmezuri novajn aŭ ŝanĝitajn modelojn fone (uzo: /benchmark [all|pause|resume|status] | /benchmark <modelo|ŝablono>... | /benchmark report [kolumno] | /benchmark tasks [list|add|edit|remove|import|validate])",
+	"cmd.config":         "Montri la lokon de la agorda dosiero",
+	"cmd.tools":          "Listigi disponeblajn ilojn",
+	"cmd.addtool":        "Ebligi modelon kiel ilon (uzo: /addtool <modelnomo> [priskribo])",
+	"cmd.addalltools":    "Ebligi ĉiujn disponeblajn modelojn kiel ilojn",
+	"cmd.removetool":     "Malebligi modelan ilon (uzo: /removetool <modelnomo>)",
+	"cmd.enabletool":     "Ebligi malebligitan ilon (uzo: /enabletool <ilnomo> [--permanent])",
+	"cmd.disabletool":    "Malebligi ilon (uzo: /disabletool <ilnomo> [--permanent])",
+	"cmd.disabledtools":  "Listigi ĉiujn malebligitajn ilojn",
+	"cmd.test-tool":      "Testi ilon rekte (uzo: /test-tool <ilnomo> <json-argumentoj>)",
+	"cmd.clear-queue":    "Forviŝi ĉiujn envicigitajn mesaĝojn",
+	"cmd.queue":          "Listigi aŭ redakti envicigitajn mesaĝojn (uzo: /queue [delete <n>] [move <n> <al>] [up|down <n>] [edit <n>] [clear])",
+	"cmd.expand":         "Malfaldi ĉiujn ilajn rezultojn, aŭ refaldi ilin (uzo: /expand [off])",
+	"cmd.trash":          "Listigi dosierojn forigitajn ĉi-sesie (uzo: /trash [restore <n>] [empty])",
+	"cmd.workspace":      "Montri projektajn radikojn (uzo: /workspace [add <nomo> <dosierujo>] [remove <nomo>])",
+	"cmd.permissions":    "Montri permesojn (uzo: /permissions [jail on|off] [roots add|remove <dosierujo>] [allowlist on|off|add|remove <komando>])",
+	"cmd.update":         "Kontroli ĉu pli nova eldono ekzistas, aŭ ŝalti aŭ malŝalti la ĉiutagan kontrolon (uzo: /update [on|off])",
+	"cmd.search-history": "Serĉi en pasintaj konversacioj kaj ilaj rezultoj (uzo: /search-history <serĉo> | open <n> | import <n>)",
+	"cmd.sessions":       "Listigi pasintajn seancojn laŭ titolo (uzo: /sessions [all] | /sessions resume <n|last>)",
+	"cmd.rename":         "Doni titolon al ĉi tiu seanco (uzo: /rename <titolo>)",
+	"cmd.memories":       "Revizii kion la agento memoras pri ĉi tiu projekto (uzo: /memories [add <teksto>] [edit <n> <teksto>] [delete <n>] [clear])",
+	"cmd.pin":            "Ĉiam teni ion en la kunteksto: la lastan respondon, dosieron aŭ tekston (uzo: /pin [last] | /pin file <vojo> | /pin <teksto>)",
+	"cmd.pins":           "Listigi alpinglitan kuntekston (uzo: /pins [unpin <n>] [clear])",
+	"cmd.todos":          "Montri la tasko-liston de la seanco, aŭ ŝanĝi ĝin (uzo: /todos [add <teksto>] [start <n>] [done <n>] [remove <n>] [clear])",
+	"cmd.image":          "Montri bildon faritan de ilo, defaŭlte la lastan (uzo: /image [<n>])",
+	"cmd.interview":      "Lasi du modelojn diskuti taskon laŭvice, ekz. dizajnisto kontraŭ kritikisto, kaj resumi la interkonsenton (uzo: /interview [-n raŭndoj] <modelo>[=rolo] <modelo>[=rolo] <tasko> | /interview stop)",
+	"cmd.review":         "Revizii nekomititajn ŝanĝojn, branĉon aŭ komitan intervalon kaj listigi trovojn laŭ graveco (uzo: /review [ref|a..b] | /review stop)",
+	"cmd.demo":           "Vidi la agenton legi, redakti kaj testi ekzemplan projekton en provizora laborspaco",
+	"cmd.why":            "Klarigi kial la lasta respondo vokis aŭ ne vokis ilojn: la formato, kion la analizilo serĉis kaj la ofertitaj iloj",
+	"cmd.debug":          "Montri la ĝustan JSON senditan al Ollama kaj la krudan respondon de la lasta vico en la flanka panelo (uzo: /debug last)",
+	"cmd.preview":        "Montri la plenan peton, kiun la sekva mesaĝo sendus, kun taksitaj ĵetonoj, en la flanka panelo (uzo: /preview [mesaĝo])",
+	"cmd.stats":          "Montri vicojn, ĵetonojn kaj tempon po modelo kaj la lastajn komparajn testojn (uzo: /stats [tagoj])",
+}
@@ -0,0 +1,118 @@
+// Package i18n holds the translated user interface strings. Messages are
+// looked up by key in the current language, falling back to English.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLanguage is used when no catalog matches the requested language
+const DefaultLanguage = "en"
+
+var catalogs = map[string]map[string]string{
+	"en": english,
+	"eo": esperanto,
+}
+
+var (
+	mu      sync.RWMutex
+	current = DefaultLanguage
+)
+
+// SetLanguage selects the catalog to use. An empty language is detected from
+// the environment; unknown languages fall back to English.
+func SetLanguage(lang string) {
+	if lang == "" {
+		lang = Detect()
+	}
+	lang = normalize(lang)
+	if _, ok := catalogs[lang]; !ok {
+		lang = DefaultLanguage
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	current = lang
+}
+
+// Language returns the selected language code
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Languages lists the languages that have a catalog
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Supported reports whether a language or locale name has a catalog
+func Supported(lang string) bool {
+	_, ok := catalogs[normalize(lang)]
+	return ok
+}
+
+// Detect reads the language from LLEMECODE_LANG or the usual locale
+// variables, in the order gettext uses them
+func Detect() string {
+	for _, name := range []string{"LLEMECODE_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return normalize(value)
+		}
+	}
+	return DefaultLanguage
+}
+
+// T returns the message for key in the current language, formatted with args
+// when given. Missing translations use the English text, then the key.
+func T(key string, args ...interface{}) string {
+	mu.RLock()
+	lang := current
+	mu.RUnlock()
+
+	msg, ok := catalogs[lang][key]
+	if !ok {
+		if msg, ok = english[key]; !ok {
+			msg = key
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// Has reports whether key has a message in the current language or English
+func Has(key string) bool {
+	mu.RLock()
+	lang := current
+	mu.RUnlock()
+
+	if _, ok := catalogs[lang][key]; ok {
+		return true
+	}
+	_, ok := english[key]
+	return ok
+}
+
+// normalize turns locale names such as "eo_XX.UTF-8" into "eo"
+func normalize(locale string) string {
+	lang := strings.ToLower(locale)
+	if i := strings.IndexAny(lang, "_.@-"); i >= 0 {
+		lang = lang[:i]
+	}
+	if lang == "c" || lang == "posix" {
+		return DefaultLanguage
+	}
+	return lang
+}
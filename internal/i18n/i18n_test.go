@@ -0,0 +1,53 @@
+package i18n
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var verb = regexp.MustCompile(`%[-+# 0]*[0-9.]*[a-zA-Z%]`)
+
+func TestCatalogsMatchEnglish(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for key, msg := range catalog {
+			if strings.HasPrefix(key, "cmd.") {
+				// Command descriptions come from the commands in English
+				continue
+			}
+			en, ok := english[key]
+			if !ok {
+				t.Errorf("%s: key %q is not in the English catalog", lang, key)
+				continue
+			}
+			if got, want := verb.FindAllString(msg, -1), verb.FindAllString(en, -1); strings.Join(got, " ") != strings.Join(want, " ") {
+				t.Errorf("%s: %q uses verbs %v, English uses %v", lang, key, got, want)
+			}
+		}
+	}
+}
+
+func TestSetLanguage(t *testing.T) {
+	defer SetLanguage(DefaultLanguage)
+
+	t.Setenv("LLEMECODE_LANG", "")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "eo_XX.UTF-8")
+
+	SetLanguage("")
+	if got := Language(); got != "eo" {
+		t.Errorf("Language() = %q after detecting LANG=eo_XX.UTF-8, want eo", got)
+	}
+	if got := T("plain.allowed"); got != "Permesite." {
+		t.Errorf("T(plain.allowed) = %q, want Esperanto", got)
+	}
+
+	SetLanguage("xx")
+	if got := Language(); got != DefaultLanguage {
+		t.Errorf("Language() = %q for an unknown language, want %s", got, DefaultLanguage)
+	}
+	if got := T("no.such.key"); got != "no.such.key" {
+		t.Errorf("T of a missing key = %q, want the key", got)
+	}
+}
@@ -13,6 +13,7 @@ var (
 	logWriter     *bufio.Writer
 	mu            sync.Mutex
 	enabled       bool
+	quiet         bool         // Don't echo to stderr when file logging is off
 	statusUpdater func(string) // Callback to update status bar in TUI
 	sessionID     string
 	logChan       chan string   // Async logging channel
@@ -91,10 +92,13 @@ func Close() {
 // Log writes a log message
 func Log(format string, args ...interface{}) {
 	mu.Lock()
-	isEnabled := enabled
+	isEnabled, isQuiet := enabled, quiet
 	mu.Unlock()
 
 	if !isEnabled {
+		if isQuiet {
+			return
+		}
 		// Still print to stderr for debugging even without file logging
 		fmt.Fprintf(os.Stderr, "[DEBUG] "+format+"\n", args...)
 		return
@@ -134,7 +138,7 @@ func LogConversation(role, content string) {
 }
 
 // LogToolCall logs a tool invocation
-func LogToolCall(name string, args map[string]interface{}, result string, err error) {
+func LogToolCall(name string, args map[string]interface{}, result string, err error, duration time.Duration) {
 	mu.Lock()
 	isEnabled := enabled
 	mu.Unlock()
@@ -146,11 +150,11 @@ func LogToolCall(name string, args map[string]interface{}, result string, err er
 	timestamp := time.Now().Format("15:04:05.000")
 	var logLine string
 	if err != nil {
-		logLine = fmt.Sprintf("\n[%s] === TOOL CALL: %s ===\nArguments: %v\nError: %v\n=========================",
-			timestamp, name, args, err)
+		logLine = fmt.Sprintf("\n[%s] === TOOL CALL: %s (%s) ===\nArguments: %v\nError: %v\n=========================",
+			timestamp, name, duration, args, err)
 	} else {
-		logLine = fmt.Sprintf("\n[%s] === TOOL CALL: %s ===\nArguments: %v\nResult: %s\n=========================",
-			timestamp, name, args, result)
+		logLine = fmt.Sprintf("\n[%s] === TOOL CALL: %s (%s) ===\nArguments: %v\nResult: %s\n=========================",
+			timestamp, name, duration, args, result)
 	}
 
 	select {
@@ -167,6 +171,14 @@ func IsEnabled() bool {
 	return enabled
 }
 
+// SetQuiet stops debug messages from being echoed to stderr, which would
+// otherwise be mixed into plain-mode output
+func SetQuiet(q bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	quiet = q
+}
+
 // SetStatusUpdater sets a callback function to update the status bar
 func SetStatusUpdater(updater func(string)) {
 	mu.Lock()
@@ -8,8 +8,12 @@ import (
 	"io"
 	"os/exec"
 	"sync"
+	"time"
 )
 
+// closeTimeout is how long an MCP server gets to exit after its stdin is closed
+const closeTimeout = 2 * time.Second
+
 // MCPClient manages a connection to an MCP server
 type MCPClient struct {
 	serverName string
@@ -244,13 +248,28 @@ func (c *MCPClient) getNextID() int {
 	return id
 }
 
-// Close terminates the connection to the MCP server
+// Close terminates the connection to the MCP server. Closing stdin asks the
+// server to exit on its own; it is killed if it is still running after closeTimeout.
 func (c *MCPClient) Close() error {
-	if c.cmd != nil && c.cmd.Process != nil {
-		c.stdin.Close()
+	if c.cmd == nil || c.cmd.Process == nil {
+		return nil
+	}
+
+	c.stdin.Close()
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- c.cmd.Wait()
+	}()
+
+	select {
+	case <-exited:
+	case <-time.After(closeTimeout):
 		c.cmd.Process.Kill()
-		c.cmd.Wait()
+		<-exited
 	}
+
+	c.cmd = nil
 	return nil
 }
 
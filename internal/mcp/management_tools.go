@@ -107,14 +107,15 @@ func (t *AddMCPServerTool) Execute(ctx context.Context, args map[string]interfac
 
 	// Save to config if permanent
 	if permanent {
-		t.config.MCPServers = append(t.config.MCPServers, config.MCPServerConfig{
-			Name:    name,
-			Command: command,
-			Args:    cmdArgs,
-			Enabled: true,
+		err := t.config.Update(func(c *config.Config) {
+			c.MCPServers = append(c.MCPServers, config.MCPServerConfig{
+				Name:    name,
+				Command: command,
+				Args:    cmdArgs,
+				Enabled: true,
+			})
 		})
-
-		if err := t.config.Save(); err != nil {
+		if err != nil {
 			return "", fmt.Errorf("tools added but failed to save config: %w", err)
 		}
 
@@ -178,8 +179,10 @@ func (t *RemoveMCPServerTool) Execute(ctx context.Context, args map[string]inter
 		return "", fmt.Errorf("MCP server '%s' not found in config", name)
 	}
 
-	t.config.MCPServers = newServers
-	if err := t.config.Save(); err != nil {
+	err := t.config.Update(func(c *config.Config) {
+		c.MCPServers = newServers
+	})
+	if err != nil {
 		return "", fmt.Errorf("failed to save config: %w", err)
 	}
 
@@ -90,10 +90,13 @@ func (r *MCPToolRegistry) GetTools() []tools.Tool {
 
 // Close closes all MCP server connections
 func (r *MCPToolRegistry) Close() error {
-	for _, client := range r.clients {
-		client.Close()
+	var firstErr error
+	for name, client := range r.clients {
+		if err := client.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("close MCP server %s: %w", name, err)
+		}
 	}
-	return nil
+	return firstErr
 }
 
 // GetServerNames returns the names of all registered servers
@@ -0,0 +1,202 @@
+// Package notes keeps facts the agent remembers about a project in a
+// markdown file inside the project, so they can be reviewed and edited by hand.
+package notes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// promptLimit is how many of the newest notes go into the system prompt
+const promptLimit = 50
+
+const header = `# Project memories
+
+Facts Llemecode remembered about this project. Edit freely: every list item is one memory.
+`
+
+// Note is one remembered fact
+type Note struct {
+	Text  string
+	Added string // YYYY-MM-DD, empty for items written by hand
+	line  int    // Line in the file, so edits keep the rest of it intact
+}
+
+// Store is the notes file of one project
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// Open returns the store of the project in dir; nothing is created until a
+// note is added
+func Open(dir string) *Store {
+	return &Store{path: filepath.Join(dir, ".llemecode", "memories.md")}
+}
+
+// OpenWorkingDir opens the store of the project Llemecode was started in
+func OpenWorkingDir() *Store {
+	dir, err := os.Getwd()
+	if err != nil {
+		dir = "."
+	}
+	return Open(dir)
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+var itemPattern = regexp.MustCompile(`^[-*] (?:\[(\d{4}-\d{2}-\d{2})\] )?(.+)$`)
+
+// List returns the notes in file order, oldest first
+func (s *Store) List() ([]Note, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	notes, _, err := s.read()
+	return notes, err
+}
+
+func (s *Store) read() ([]Note, []string, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("read memories: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	var notes []Note
+	for i, line := range lines {
+		if m := itemPattern.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			notes = append(notes, Note{Text: m[2], Added: m[1], line: i})
+		}
+	}
+	return notes, lines, nil
+}
+
+func (s *Store) write(lines []string) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(s.path), err)
+	}
+	if err := os.WriteFile(s.path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("write memories: %w", err)
+	}
+	return nil
+}
+
+// Add remembers text. It returns false when the same fact is already there.
+func (s *Store) Add(text string) (bool, error) {
+	text = oneLine(text)
+	if text == "" {
+		return false, fmt.Errorf("nothing to remember")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	notes, lines, err := s.read()
+	if err != nil {
+		return false, err
+	}
+	for _, note := range notes {
+		if strings.EqualFold(note.Text, text) {
+			return false, nil
+		}
+	}
+	if lines == nil {
+		lines = strings.Split(header, "\n")
+	}
+	lines = append(lines, fmt.Sprintf("- [%s] %s", time.Now().Format("2006-01-02"), text))
+	return true, s.write(lines)
+}
+
+// Update replaces the text of note i (0-based), keeping its date
+func (s *Store) Update(i int, text string) error {
+	text = oneLine(text)
+	if text == "" {
+		return fmt.Errorf("memory text is empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	notes, lines, err := s.read()
+	if err != nil {
+		return err
+	}
+	if i < 0 || i >= len(notes) {
+		return fmt.Errorf("no memory %d", i+1)
+	}
+	note := notes[i]
+	if note.Added != "" {
+		lines[note.line] = fmt.Sprintf("- [%s] %s", note.Added, text)
+	} else {
+		lines[note.line] = "- " + text
+	}
+	return s.write(lines)
+}
+
+// Remove forgets note i (0-based)
+func (s *Store) Remove(i int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	notes, lines, err := s.read()
+	if err != nil {
+		return err
+	}
+	if i < 0 || i >= len(notes) {
+		return fmt.Errorf("no memory %d", i+1)
+	}
+	line := notes[i].line
+	return s.write(append(lines[:line], lines[line+1:]...))
+}
+
+// Search returns the notes containing every word of query, case-insensitively
+func (s *Store) Search(query string) ([]Note, error) {
+	notes, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	terms := strings.Fields(strings.ToLower(query))
+	var matches []Note
+	for _, note := range notes {
+		lower := strings.ToLower(note.Text)
+		matched := true
+		for _, term := range terms {
+			if !strings.Contains(lower, term) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			matches = append(matches, note)
+		}
+	}
+	return matches, nil
+}
+
+// Prompt lists the newest notes for the system prompt; empty without notes
+func (s *Store) Prompt() string {
+	notes, err := s.List()
+	if err != nil || len(notes) == 0 {
+		return ""
+	}
+	if len(notes) > promptLimit {
+		notes = notes[len(notes)-promptLimit:]
+	}
+	var sb strings.Builder
+	sb.WriteString("Facts remembered about this project in earlier sessions (use recall to search them and remember to add new ones):\n")
+	for _, note := range notes {
+		sb.WriteString("- " + note.Text + "\n")
+	}
+	return sb.String()
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
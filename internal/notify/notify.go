@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// timeout is how long the notification helper gets to run
+const timeout = 5 * time.Second
+
+// Desktop shows a desktop notification using notify-send on Linux and the
+// BSDs or osascript on macOS
+func Desktop(title, body string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	case "linux", "freebsd", "openbsd", "netbsd":
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=llemecode", title, body)
+	default:
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("notify: %w: %s", err, out)
+	}
+	return nil
+}
+
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -7,13 +7,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
 const DefaultOllamaURL = "http://localhost:11434"
 
 type Client struct {
-	baseURL    string
+	endpoints  []*Endpoint // In order of preference
 	httpClient *http.Client
 }
 
@@ -22,6 +23,17 @@ type Message struct {
 	Content   string     `json:"content"`
 	ToolName  string     `json:"tool_name,omitempty"`  // Required for tool result messages
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"` // Tool calls from assistant
+	Images    []string   `json:"images,omitempty"`     // Base64 images for vision models
+
+	Meta *MessageMeta `json:"meta,omitempty"` // What produced an assistant message; Ollama ignores it
+}
+
+// MessageMeta records how an assistant message was generated, so sessions
+// that mix models stay auditable
+type MessageMeta struct {
+	Model       string        `json:"model"`
+	Temperature *float64      `json:"temperature,omitempty"` // Unset means the model's default
+	Duration    time.Duration `json:"duration_ns"`
 }
 
 type Tool struct {
@@ -40,6 +52,9 @@ type ChatRequest struct {
 	Messages []Message `json:"messages"`
 	Stream   bool      `json:"stream"`
 	Tools    []Tool    `json:"tools,omitempty"`
+
+	Options map[string]interface{} `json:"options,omitempty"` // Sampling options such as temperature and num_predict
+	Format  string                 `json:"format,omitempty"`  // "json" constrains the answer to valid JSON
 }
 
 type ChatResponse struct {
@@ -47,6 +62,9 @@ type ChatResponse struct {
 	CreatedAt time.Time `json:"created_at"`
 	Message   Message   `json:"message"`
 	Done      bool      `json:"done"`
+
+	PromptEvalCount int `json:"prompt_eval_count,omitempty"` // Prompt tokens, set on the final chunk
+	EvalCount       int `json:"eval_count,omitempty"`        // Generated tokens, set on the final chunk
 }
 
 type ToolCall struct {
@@ -62,6 +80,27 @@ type ModelInfo struct {
 	Name       string    `json:"name"`
 	ModifiedAt time.Time `json:"modified_at"`
 	Size       int64     `json:"size"`
+	Digest     string    `json:"digest"`
+}
+
+// ModelDetails is what a server reports about one model
+type ModelDetails struct {
+	Capabilities  []string // Such as "completion", "tools" and "vision"
+	ContextLength int      // Tokens, 0 if unknown
+	Modelfile     string   // The Modelfile the model was built from
+	System        string   // System prompt baked into the model
+	Parameters    string   // Default parameters, one per line
+	Template      string   // Prompt template
+}
+
+// HasCapability reports whether the server lists capability for the model
+func (d ModelDetails) HasCapability(capability string) bool {
+	for _, c := range d.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
 }
 
 type ListModelsResponse struct {
@@ -72,47 +111,190 @@ func NewClient(baseURL string) *Client {
 	if baseURL == "" {
 		baseURL = DefaultOllamaURL
 	}
+	return NewMultiClient([]*Endpoint{NewEndpoint("default", baseURL, nil)})
+}
+
+// NewMultiClient creates a client that routes requests across several Ollama
+// servers, failing over to the next one that has the model when a server is
+// unreachable
+func NewMultiClient(endpoints []*Endpoint) *Client {
 	return &Client{
-		baseURL: baseURL,
+		endpoints: endpoints,
 		httpClient: &http.Client{
 			Timeout: 5 * time.Minute,
 		},
 	}
 }
 
+// BaseURL returns the URL of the preferred Ollama server
+func (c *Client) BaseURL() string {
+	return c.endpoints[0].URL
+}
+
+// Host returns the host:port of the preferred Ollama server, for display
+func (c *Client) Host() string {
+	return c.endpoints[0].Host()
+}
+
+// HostFor returns the host:port of the server requests for model go to
+func (c *Client) HostFor(model string) string {
+	if candidates := c.candidates(model); len(candidates) > 0 {
+		return candidates[0].Host()
+	}
+	return c.Host()
+}
+
 func (c *Client) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/chat", bytes.NewReader(body))
+	var chatResp *ChatResponse
+	ex := exchangeFrom(ctx)
+	err = c.withFailover(ctx, req.Model, func(ep *Endpoint) error {
+		httpReq, err := http.NewRequestWithContext(ctx, "POST", ep.URL+"/api/chat", bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		ex.record(httpReq.URL.String(), body)
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return &unreachableError{fmt.Errorf("do request: %w", err)}
+		}
+		ex.watch(resp)
+		defer resp.Body.Close()
+
+		if err := checkStatus(resp); err != nil {
+			return err
+		}
+
+		chatResp = &ChatResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(chatResp); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return nil, err
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(httpReq)
+	return chatResp, nil
+}
+
+// ChatStream sends a streaming chat request, calling onChunk with each piece
+// of content as it arrives. The returned response has the full message with
+// content and tool calls accumulated across chunks.
+func (c *Client) ChatStream(ctx context.Context, req ChatRequest, onChunk func(content string)) (*ChatResponse, error) {
+	req.Stream = true
+	body, err := json.Marshal(req)
 	if err != nil {
-		return nil, fmt.Errorf("do request: %w", err)
+		return nil, fmt.Errorf("marshal request: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	var resp *http.Response
+	ex := exchangeFrom(ctx)
+	err = c.withFailover(ctx, req.Model, func(ep *Endpoint) error {
+		httpReq, err := http.NewRequestWithContext(ctx, "POST", ep.URL+"/api/chat", bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		ex.record(httpReq.URL.String(), body)
+
+		r, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return &unreachableError{fmt.Errorf("do request: %w", err)}
+		}
+		ex.watch(r)
+		if err := checkStatus(r); err != nil {
+			r.Body.Close()
+			return err
+		}
+		resp = r
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	var chatResp ChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	// Once content has been streamed there's no failing over
+	var final ChatResponse
+	var content strings.Builder
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var chunk ChatResponse
+		if err := decoder.Decode(&chunk); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("decode response: %w", err)
+		}
+
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			if onChunk != nil {
+				onChunk(chunk.Message.Content)
+			}
+		}
+		final.Message.ToolCalls = append(final.Message.ToolCalls, chunk.Message.ToolCalls...)
+		if chunk.Message.Role != "" {
+			final.Message.Role = chunk.Message.Role
+		}
+		final.Model = chunk.Model
+		final.CreatedAt = chunk.CreatedAt
+
+		if chunk.Done {
+			final.Done = true
+			final.PromptEvalCount = chunk.PromptEvalCount
+			final.EvalCount = chunk.EvalCount
+			break
+		}
 	}
 
-	return &chatResp, nil
+	if final.Message.Role == "" {
+		final.Message.Role = "assistant"
+	}
+	final.Message.Content = content.String()
+	return &final, nil
 }
 
+// ListModels returns the models available across all reachable servers
 func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/tags", nil)
+	var all []ModelInfo
+	seen := make(map[string]bool)
+	var firstErr error
+	reached := false
+
+	for _, ep := range c.endpoints {
+		models, err := c.listEndpointModels(ctx, ep)
+		ep.recordHealth(models, err)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		reached = true
+		for _, model := range models {
+			if !seen[model.Name] {
+				seen[model.Name] = true
+				all = append(all, model)
+			}
+		}
+	}
+
+	if !reached {
+		return nil, firstErr
+	}
+	return all, nil
+}
+
+func (c *Client) listEndpointModels(ctx context.Context, ep *Endpoint) ([]ModelInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", ep.URL+"/api/tags", nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -136,17 +318,227 @@ func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
 	return listResp.Models, nil
 }
 
-func (c *Client) IsAvailable(ctx context.Context) bool {
-	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/tags", nil)
+// ShowModel asks the server hosting model for its details
+func (c *Client) ShowModel(ctx context.Context, model string) (*ModelDetails, error) {
+	body, err := json.Marshal(map[string]string{"model": model})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	var details *ModelDetails
+	err = c.withFailover(ctx, model, func(ep *Endpoint) error {
+		httpReq, err := http.NewRequestWithContext(ctx, "POST", ep.URL+"/api/show", bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return &unreachableError{fmt.Errorf("do request: %w", err)}
+		}
+		defer resp.Body.Close()
+
+		if err := checkStatus(resp); err != nil {
+			return err
+		}
+
+		var showResp struct {
+			Capabilities []string               `json:"capabilities"`
+			ModelInfo    map[string]interface{} `json:"model_info"`
+			Modelfile    string                 `json:"modelfile"`
+			System       string                 `json:"system"`
+			Parameters   string                 `json:"parameters"`
+			Template     string                 `json:"template"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&showResp); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+
+		details = &ModelDetails{
+			Capabilities: showResp.Capabilities,
+			Modelfile:    showResp.Modelfile,
+			System:       showResp.System,
+			Parameters:   showResp.Parameters,
+			Template:     showResp.Template,
+		}
+		// The key is prefixed with the architecture, e.g. llama.context_length
+		for key, value := range showResp.ModelInfo {
+			if n, ok := value.(float64); ok && strings.HasSuffix(key, ".context_length") {
+				details.ContextLength = int(n)
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return false
+		return nil, err
+	}
+
+	return details, nil
+}
+
+// Embed returns an embedding vector per input from an embedding model such
+// as nomic-embed-text
+func (c *Client) Embed(ctx context.Context, model string, inputs []string) ([][]float64, error) {
+	body, err := json.Marshal(map[string]interface{}{"model": model, "input": inputs})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	var embeddings [][]float64
+	err = c.withFailover(ctx, model, func(ep *Endpoint) error {
+		httpReq, err := http.NewRequestWithContext(ctx, "POST", ep.URL+"/api/embed", bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return &unreachableError{fmt.Errorf("do request: %w", err)}
+		}
+		defer resp.Body.Close()
+
+		if err := checkStatus(resp); err != nil {
+			return err
+		}
+
+		var embedResp struct {
+			Embeddings [][]float64 `json:"embeddings"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+		embeddings = embedResp.Embeddings
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) != len(inputs) {
+		return nil, fmt.Errorf("got %d embeddings for %d inputs", len(embeddings), len(inputs))
+	}
+	return embeddings, nil
+}
+
+// RunningModel is a model a server currently holds in memory
+type RunningModel struct {
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`      // Bytes in memory, RAM and VRAM together
+	SizeVRAM int64  `json:"size_vram"` // Bytes of Size on the GPU
+}
+
+// RunningModels returns the models loaded on any reachable server
+func (c *Client) RunningModels(ctx context.Context) ([]RunningModel, error) {
+	var all []RunningModel
+	var firstErr error
+	reached := false
+
+	for _, ep := range c.endpoints {
+		req, err := http.NewRequestWithContext(ctx, "GET", ep.URL+"/api/ps", nil)
+		if err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("do request: %w", err)
+			}
+			continue
+		}
+
+		var psResp struct {
+			Models []RunningModel `json:"models"`
+		}
+		err = checkStatus(resp)
+		if err == nil {
+			err = json.NewDecoder(resp.Body).Decode(&psResp)
+		}
+		resp.Body.Close()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		reached = true
+		all = append(all, psResp.Models...)
+	}
+
+	if !reached {
+		return nil, firstErr
+	}
+	return all, nil
+}
+
+// UnloadModel asks the server holding model to free its memory now rather
+// than when its keep-alive runs out
+func (c *Client) UnloadModel(ctx context.Context, model string) error {
+	body, err := json.Marshal(map[string]interface{}{"model": model, "keep_alive": 0})
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	return c.withFailover(ctx, model, func(ep *Endpoint) error {
+		httpReq, err := http.NewRequestWithContext(ctx, "POST", ep.URL+"/api/generate", bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return &unreachableError{fmt.Errorf("do request: %w", err)}
+		}
+		defer resp.Body.Close()
+		return checkStatus(resp)
+	})
+}
+
+// Version returns the Ollama version of the server at baseURL
+func (c *Client) Version(ctx context.Context, baseURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/api/version", nil)
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return false
+		return "", fmt.Errorf("do request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	return resp.StatusCode == http.StatusOK
+	if err := checkStatus(resp); err != nil {
+		return "", err
+	}
+
+	var versionResp struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&versionResp); err != nil {
+		return "", fmt.Errorf("decode response: %w", err)
+	}
+	return versionResp.Version, nil
+}
+
+// IsAvailable reports whether at least one server responds
+func (c *Client) IsAvailable(ctx context.Context) bool {
+	for _, status := range c.CheckEndpoints(ctx) {
+		if status.Err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	body, _ := io.ReadAll(resp.Body)
+	err := fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	if resp.StatusCode >= 500 {
+		return &unreachableError{err}
+	}
+	return err
 }
@@ -0,0 +1,159 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CreateRequest describes a model built from an existing one, as sent to
+// /api/create
+type CreateRequest struct {
+	Model      string                 `json:"model"`
+	From       string                 `json:"from"`
+	System     string                 `json:"system,omitempty"`
+	Template   string                 `json:"template,omitempty"`
+	License    string                 `json:"license,omitempty"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Messages   []Message              `json:"messages,omitempty"`
+	Stream     bool                   `json:"stream"`
+}
+
+// CreateModel creates a model on the server hosting req.From, passing each
+// progress status to onStatus
+func (c *Client) CreateModel(ctx context.Context, req CreateRequest, onStatus func(status string)) error {
+	req.Stream = true
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	return c.withFailover(ctx, req.From, func(ep *Endpoint) error {
+		httpReq, err := http.NewRequestWithContext(ctx, "POST", ep.URL+"/api/create", bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return &unreachableError{fmt.Errorf("do request: %w", err)}
+		}
+		defer resp.Body.Close()
+		if err := checkStatus(resp); err != nil {
+			return err
+		}
+
+		decoder := json.NewDecoder(resp.Body)
+		for {
+			var event struct {
+				Status string `json:"status"`
+				Error  string `json:"error"`
+			}
+			if err := decoder.Decode(&event); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return fmt.Errorf("decode response: %w", err)
+			}
+			if event.Error != "" {
+				return fmt.Errorf("create %s: %s", req.Model, event.Error)
+			}
+			if event.Status != "" && onStatus != nil {
+				onStatus(event.Status)
+			}
+		}
+	})
+}
+
+// ParseModelfile reads the FROM, SYSTEM, TEMPLATE, PARAMETER, MESSAGE and
+// LICENSE instructions of a Modelfile into a create request. Values may be
+// quoted with """ to span lines.
+func ParseModelfile(modelfile string) (*CreateRequest, error) {
+	req := &CreateRequest{}
+	scanner := bufio.NewScanner(strings.NewReader(modelfile))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		instruction, value, _ := strings.Cut(line, " ")
+		value = strings.TrimSpace(value)
+
+		// Multi-line values are wrapped in triple quotes
+		if strings.HasPrefix(value, `"""`) {
+			value = strings.TrimPrefix(value, `"""`)
+			var sb strings.Builder
+			for !strings.Contains(value, `"""`) {
+				sb.WriteString(value + "\n")
+				if !scanner.Scan() {
+					return nil, fmt.Errorf("modelfile: unterminated \"\"\" in %s", instruction)
+				}
+				value = scanner.Text()
+			}
+			sb.WriteString(value[:strings.Index(value, `"""`)])
+			value = sb.String()
+		} else if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+			value = value[1 : len(value)-1]
+		}
+
+		switch strings.ToUpper(instruction) {
+		case "FROM":
+			req.From = value
+		case "SYSTEM":
+			req.System = value
+		case "TEMPLATE":
+			req.Template = value
+		case "LICENSE":
+			req.License = value
+		case "PARAMETER":
+			key, raw, ok := strings.Cut(value, " ")
+			if !ok {
+				return nil, fmt.Errorf("modelfile: PARAMETER needs a name and a value: %q", line)
+			}
+			addParameter(req, key, strings.Trim(strings.TrimSpace(raw), `"`))
+		case "MESSAGE":
+			role, content, ok := strings.Cut(value, " ")
+			if !ok {
+				return nil, fmt.Errorf("modelfile: MESSAGE needs a role and content: %q", line)
+			}
+			req.Messages = append(req.Messages, Message{Role: role, Content: strings.TrimSpace(content)})
+		default:
+			return nil, fmt.Errorf("modelfile: unsupported instruction %s", instruction)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("modelfile: %w", err)
+	}
+	if req.From == "" {
+		return nil, fmt.Errorf("modelfile: FROM is required")
+	}
+	return req, nil
+}
+
+// addParameter sets a parameter as a number or bool where it looks like
+// one; stop may be given more than once
+func addParameter(req *CreateRequest, key, raw string) {
+	if req.Parameters == nil {
+		req.Parameters = make(map[string]interface{})
+	}
+	if key == "stop" {
+		stops, _ := req.Parameters[key].([]string)
+		req.Parameters[key] = append(stops, raw)
+		return
+	}
+	if n, err := strconv.ParseFloat(raw, 64); err == nil {
+		req.Parameters[key] = n
+	} else if b, err := strconv.ParseBool(raw); err == nil {
+		req.Parameters[key] = b
+	} else {
+		req.Parameters[key] = raw
+	}
+}
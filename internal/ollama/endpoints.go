@@ -0,0 +1,170 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// Endpoint is one Ollama server a Client can route requests to
+type Endpoint struct {
+	Name   string
+	URL    string
+	Models []string // Models pinned to this server; requests for them go here first
+
+	mu        sync.Mutex
+	checked   bool            // Whether a health check has completed
+	healthy   bool            // Result of the last request or health check
+	available map[string]bool // Models reported by the server at the last check
+}
+
+// EndpointStatus is the result of health-checking one endpoint
+type EndpointStatus struct {
+	Name   string
+	URL    string
+	Models int
+	Err    error
+}
+
+// unreachableError marks failures that should fail over to another endpoint
+type unreachableError struct {
+	err error
+}
+
+func (e *unreachableError) Error() string { return e.err.Error() }
+func (e *unreachableError) Unwrap() error { return e.err }
+
+func NewEndpoint(name, baseURL string, models []string) *Endpoint {
+	return &Endpoint{Name: name, URL: baseURL, Models: models}
+}
+
+// Host returns the host:port of the endpoint, for display
+func (e *Endpoint) Host() string {
+	if u, err := url.Parse(e.URL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return e.URL
+}
+
+func (e *Endpoint) recordHealth(models []ModelInfo, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.checked = true
+	e.healthy = err == nil
+	if err == nil {
+		e.available = make(map[string]bool, len(models))
+		for _, model := range models {
+			e.available[model.Name] = true
+		}
+	}
+}
+
+func (e *Endpoint) setHealthy(healthy bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.healthy = healthy
+}
+
+// state returns whether the endpoint is known to be down and whether it is
+// known to serve model
+func (e *Endpoint) state(model string) (down, pinned, discovered, unknown bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, m := range e.Models {
+		if m == model {
+			pinned = true
+		}
+	}
+	down = e.checked && !e.healthy
+	discovered = e.available[model]
+	unknown = !e.checked
+	return
+}
+
+// CheckEndpoints health-checks every endpoint concurrently and refreshes
+// which models each one serves
+func (c *Client) CheckEndpoints(ctx context.Context) []EndpointStatus {
+	statuses := make([]EndpointStatus, len(c.endpoints))
+
+	var wg sync.WaitGroup
+	for i, ep := range c.endpoints {
+		wg.Add(1)
+		go func(i int, ep *Endpoint) {
+			defer wg.Done()
+			models, err := c.listEndpointModels(ctx, ep)
+			ep.recordHealth(models, err)
+			statuses[i] = EndpointStatus{Name: ep.Name, URL: ep.URL, Models: len(models), Err: err}
+		}(i, ep)
+	}
+	wg.Wait()
+
+	return statuses
+}
+
+// candidates orders the endpoints to try for model: servers the model is
+// pinned to, then servers known to have it, then unchecked ones. Servers
+// known to be down are only tried when nothing else is left.
+func (c *Client) candidates(model string) []*Endpoint {
+	if model == "" {
+		return c.endpoints
+	}
+
+	var pinned, discovered, unknown, down []*Endpoint
+	for _, ep := range c.endpoints {
+		isDown, isPinned, isDiscovered, isUnknown := ep.state(model)
+		switch {
+		case !isPinned && !isDiscovered && !isUnknown:
+			continue
+		case isDown:
+			down = append(down, ep)
+		case isPinned:
+			pinned = append(pinned, ep)
+		case isDiscovered:
+			discovered = append(discovered, ep)
+		default:
+			unknown = append(unknown, ep)
+		}
+	}
+
+	result := append(append(pinned, discovered...), unknown...)
+	if len(result) == 0 {
+		result = down
+	}
+	if len(result) == 0 {
+		// Nobody is known to have the model; it may have been pulled since
+		// the last check, so let every server have a go
+		result = c.endpoints
+	}
+	return result
+}
+
+// withFailover runs fn against each candidate endpoint for model until one
+// succeeds or fails with an error other than being unreachable
+func (c *Client) withFailover(ctx context.Context, model string, fn func(ep *Endpoint) error) error {
+	candidates := c.candidates(model)
+
+	var lastErr error
+	for _, ep := range candidates {
+		err := fn(ep)
+		if err == nil {
+			ep.setHealthy(true)
+			return nil
+		}
+
+		var unreachable *unreachableError
+		if !errors.As(err, &unreachable) || ctx.Err() != nil {
+			return err
+		}
+		ep.setHealthy(false)
+		lastErr = err
+	}
+
+	if len(candidates) == 1 {
+		return lastErr
+	}
+	return fmt.Errorf("all %d endpoints failed, last error: %w", len(candidates), lastErr)
+}
@@ -0,0 +1,67 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// Exchange is the raw HTTP conversation of one chat request, kept for
+// debugging prompts and tool formats
+type Exchange struct {
+	URL      string
+	Request  []byte // The JSON body as sent
+	Status   int
+	Response []byte // The body as received; for streams, every chunk line
+}
+
+type exchangeKey struct{}
+
+// WithExchange makes chat requests made with ctx record their raw request
+// and response in ex
+func WithExchange(ctx context.Context, ex *Exchange) context.Context {
+	return context.WithValue(ctx, exchangeKey{}, ex)
+}
+
+func exchangeFrom(ctx context.Context) *Exchange {
+	ex, _ := ctx.Value(exchangeKey{}).(*Exchange)
+	return ex
+}
+
+// record notes the request about to be sent to url, replacing an earlier
+// attempt at another endpoint
+func (ex *Exchange) record(url string, body []byte) {
+	if ex != nil {
+		ex.URL, ex.Request, ex.Status, ex.Response = url, body, 0, nil
+	}
+}
+
+// watch copies the response body into ex as the client reads it
+func (ex *Exchange) watch(resp *http.Response) {
+	if ex == nil {
+		return
+	}
+	ex.Status = resp.StatusCode
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.TeeReader(resp.Body, (*exchangeWriter)(ex)), resp.Body}
+}
+
+type exchangeWriter Exchange
+
+func (w *exchangeWriter) Write(p []byte) (int, error) {
+	w.Response = append(w.Response, p...)
+	return len(p), nil
+}
+
+// Indented returns the request with its JSON indented for reading
+func (ex *Exchange) Indented() string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, ex.Request, "", "  "); err != nil {
+		return string(ex.Request)
+	}
+	return buf.String()
+}
@@ -0,0 +1,280 @@
+// Package replay feeds the user turns of a saved session to another model
+// and compares its answers with the recorded ones, to see how a model does
+// on real workloads.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/agent"
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/tools"
+)
+
+// How tool calls are answered during a replay
+const (
+	ToolsRecorded = "recorded" // With the results recorded in the session
+	ToolsDryRun   = "dry-run"  // By running read-only tools; the rest only report they didn't run
+)
+
+// Call is a recorded tool call
+type Call struct {
+	Name   string
+	Args   map[string]interface{} // Nil when the model wrote calls into its text
+	Result string
+}
+
+// Turn is one user message of a session and what followed it
+type Turn struct {
+	User    string
+	Reply   string
+	Calls   []Call
+	History []ollama.Message // The recorded conversation before this turn
+}
+
+// Turns splits a session into its user turns
+func Turns(messages []ollama.Message) []Turn {
+	var turns []Turn
+	var pending []ollama.ToolCall // Native calls still waiting for their result
+	for i, msg := range messages {
+		switch msg.Role {
+		case "user":
+			turns = append(turns, Turn{User: msg.Content, History: messages[:i]})
+			pending = nil
+		case "assistant":
+			if len(turns) == 0 {
+				continue
+			}
+			turn := &turns[len(turns)-1]
+			turn.Reply = msg.Content
+			pending = append(pending, msg.ToolCalls...)
+		case "tool":
+			if len(turns) == 0 {
+				continue
+			}
+			turn := &turns[len(turns)-1]
+			call := Call{Name: msg.ToolName, Result: msg.Content}
+			for j, tc := range pending {
+				if tc.Function.Name == msg.ToolName {
+					call.Args = tc.Function.Arguments
+					pending = append(pending[:j], pending[j+1:]...)
+					break
+				}
+			}
+			turn.Calls = append(turn.Calls, call)
+		}
+	}
+	return turns
+}
+
+// Result compares one replayed turn with the recording
+type Result struct {
+	Turn          int
+	User          string
+	Recorded      string
+	Replayed      string
+	RecordedTools []string
+	ReplayedTools []string
+	Unrecorded    int // Calls the recording had no result for
+	Tokens        int
+	Duration      time.Duration
+	Err           error
+}
+
+// Replayer runs turns against one model
+type Replayer struct {
+	client   *ollama.Client
+	cfg      *config.Config
+	registry *tools.Registry // Supplies the tool definitions and, in dry runs, the read-only tools
+	model    string
+	mode     string
+}
+
+func New(client *ollama.Client, cfg *config.Config, registry *tools.Registry, model, mode string) (*Replayer, error) {
+	if mode != ToolsRecorded && mode != ToolsDryRun {
+		return nil, fmt.Errorf("unknown tool mode %q (use %s or %s)", mode, ToolsRecorded, ToolsDryRun)
+	}
+	return &Replayer{client: client, cfg: cfg, registry: registry, model: model, mode: mode}, nil
+}
+
+// Run replays every turn of messages, reporting each result as it is done.
+// Turns are independent: each starts from the recorded conversation, so
+// one bad answer doesn't spoil the comparison of the next.
+func (r *Replayer) Run(ctx context.Context, messages []ollama.Message, done func(Result)) []Result {
+	var results []Result
+	for i, turn := range Turns(messages) {
+		if ctx.Err() != nil {
+			break
+		}
+		result := r.replayTurn(ctx, i+1, turn)
+		results = append(results, result)
+		if done != nil {
+			done(result)
+		}
+	}
+	return results
+}
+
+func (r *Replayer) replayTurn(ctx context.Context, n int, turn Turn) Result {
+	result := Result{Turn: n, User: turn.User, Recorded: turn.Reply}
+	for _, call := range turn.Calls {
+		result.RecordedTools = append(result.RecordedTools, call.Name)
+	}
+
+	stubs := newStubs(turn.Calls)
+	registry := tools.NewRegistry()
+	for _, tool := range r.registry.All() {
+		registry.Register(&stubTool{Tool: tool, replayer: r, stubs: stubs})
+	}
+
+	ag := agent.New(r.client, registry, r.cfg, r.model)
+	ag.SetDisabledTools(r.cfg.DisabledTools)
+	ag.AddSystemPrompt("") // The prompt for the model's own tool format
+	ag.RestoreMessages(turn.History)
+	ag.SetToolObserver(func(execution agent.ToolExecution, finished bool) {
+		if !finished {
+			result.ReplayedTools = append(result.ReplayedTools, execution.Name)
+		}
+	})
+
+	started := time.Now()
+	resp, err := ag.Chat(ctx, turn.User)
+	result.Duration = time.Since(started)
+	result.Unrecorded = stubs.missed
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Replayed = resp.Content
+	result.Tokens = resp.PromptTokens + resp.CompletionTokens
+	return result
+}
+
+// stubs hands out the recorded results of one turn
+type stubs struct {
+	calls  []Call
+	used   []bool
+	missed int
+}
+
+func newStubs(calls []Call) *stubs {
+	return &stubs{calls: calls, used: make([]bool, len(calls))}
+}
+
+// take returns the recorded result for a call: one with the same arguments
+// if there is one, otherwise the next unused result of the same tool
+func (s *stubs) take(name string, args map[string]interface{}) (string, bool) {
+	want, _ := json.Marshal(args)
+	fallback := -1
+	for i, call := range s.calls {
+		if s.used[i] || call.Name != name {
+			continue
+		}
+		if got, _ := json.Marshal(call.Args); string(got) == string(want) {
+			s.used[i] = true
+			return call.Result, true
+		}
+		if fallback < 0 {
+			fallback = i
+		}
+	}
+	if fallback < 0 {
+		s.missed++
+		return "", false
+	}
+	s.used[fallback] = true
+	return s.calls[fallback].Result, true
+}
+
+// stubTool keeps a real tool's definition but answers from the recording,
+// or in a dry run only runs it when it can't change anything
+type stubTool struct {
+	tools.Tool
+	replayer *Replayer
+	stubs    *stubs
+}
+
+func (t *stubTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	if t.replayer.mode == ToolsDryRun {
+		if pt, ok := t.Tool.(*tools.ProtectedTool); ok && pt.Level() <= tools.PermissionRead {
+			return t.Tool.Execute(ctx, args)
+		}
+		return fmt.Sprintf("Dry run: %s was not executed.", t.Name()), nil
+	}
+	if result, ok := t.stubs.take(t.Name(), args); ok {
+		return result, nil
+	}
+	return fmt.Sprintf("No recorded result: the original session did not call %s here.", t.Name()), nil
+}
+
+// Report renders the comparison as Markdown
+func Report(sessionTitle, recordedModel, model string, results []Result) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Replay of %q: %s → %s\n", sessionTitle, recordedModel, model))
+
+	identical, failed, tokens := 0, 0, 0
+	var elapsed time.Duration
+	for _, r := range results {
+		sb.WriteString(fmt.Sprintf("\n## Turn %d: %s\n\n", r.Turn, firstLine(r.User)))
+		sb.WriteString(fmt.Sprintf("Tools: %s → %s\n", toolList(r.RecordedTools), toolList(r.ReplayedTools)))
+		if r.Unrecorded > 0 {
+			sb.WriteString(fmt.Sprintf("%d call(s) had no recorded result\n", r.Unrecorded))
+		}
+		tokens += r.Tokens
+		elapsed += r.Duration
+		if r.Err != nil {
+			failed++
+			sb.WriteString(fmt.Sprintf("\n✗ %v\n", r.Err))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%d tokens in %s\n\n", r.Tokens, agent.FormatToolDuration(r.Duration)))
+		if strings.TrimSpace(r.Recorded) == strings.TrimSpace(r.Replayed) {
+			identical++
+			sb.WriteString("Same reply.\n")
+			continue
+		}
+		sb.WriteString("```diff\n" + replyDiff(r.Recorded, r.Replayed) + "\n```\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("\n## Summary\n\n%d turns: %d identical, %d different, %d failed. %d tokens in %s.\n",
+		len(results), identical, len(results)-identical-failed, failed, tokens, agent.FormatToolDuration(elapsed)))
+	return sb.String()
+}
+
+// replyDiff shows how the replayed reply differs from the recorded one
+func replyDiff(recorded, replayed string) string {
+	hunks, ok := tools.LineDiff(strings.TrimSpace(recorded), strings.TrimSpace(replayed))
+	if !ok {
+		return "- " + strings.ReplaceAll(recorded, "\n", "\n- ") + "\n+ " + strings.ReplaceAll(replayed, "\n", "\n+ ")
+	}
+	var lines []string
+	for i, hunk := range hunks {
+		if i > 0 {
+			lines = append(lines, "…")
+		}
+		for _, line := range hunk {
+			lines = append(lines, string(line.Op)+" "+line.Text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func toolList(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+func firstLine(s string) string {
+	line := strings.TrimSpace(strings.SplitN(strings.TrimSpace(s), "\n", 2)[0])
+	if len(line) > 80 {
+		line = line[:77] + "..."
+	}
+	return line
+}
@@ -0,0 +1,50 @@
+package replay
+
+import (
+	"testing"
+
+	"github.com/LaPingvino/llemecode/internal/ollama"
+)
+
+func TestTurns(t *testing.T) {
+	messages := []ollama.Message{
+		{Role: "system", Content: "prompt"},
+		{Role: "user", Content: "read it"},
+		{Role: "assistant", ToolCalls: []ollama.ToolCall{
+			{Function: ollama.ToolCallFunction{Name: "read_file", Arguments: map[string]interface{}{"path": "a.go"}}},
+			{Function: ollama.ToolCallFunction{Name: "read_file", Arguments: map[string]interface{}{"path": "b.go"}}},
+		}},
+		{Role: "tool", ToolName: "read_file", Content: "package a"},
+		{Role: "tool", ToolName: "read_file", Content: "package b"},
+		{Role: "assistant", Content: "Two packages."},
+		{Role: "user", Content: "thanks"},
+		{Role: "assistant", Content: "You're welcome."},
+	}
+
+	turns := Turns(messages)
+	if len(turns) != 2 {
+		t.Fatalf("Expected 2 turns, got %d", len(turns))
+	}
+	first := turns[0]
+	if first.User != "read it" || first.Reply != "Two packages." || len(first.History) != 1 {
+		t.Errorf("Unexpected first turn: %+v", first)
+	}
+	if len(first.Calls) != 2 || first.Calls[1].Args["path"] != "b.go" || first.Calls[1].Result != "package b" {
+		t.Errorf("Expected both calls with their arguments, got %+v", first.Calls)
+	}
+	if len(turns[1].History) != 6 || len(turns[1].Calls) != 0 {
+		t.Errorf("Expected the second turn to start from the recorded conversation, got %+v", turns[1])
+	}
+
+	// Calls with the same arguments get their own result, others the next one
+	stubs := newStubs(first.Calls)
+	if result, _ := stubs.take("read_file", map[string]interface{}{"path": "b.go"}); result != "package b" {
+		t.Errorf("Expected the matching result, got %q", result)
+	}
+	if result, _ := stubs.take("read_file", map[string]interface{}{"path": "c.go"}); result != "package a" {
+		t.Errorf("Expected the next unused result, got %q", result)
+	}
+	if _, ok := stubs.take("read_file", nil); ok || stubs.missed != 1 {
+		t.Error("Expected a miss once the results are used up")
+	}
+}
@@ -0,0 +1,172 @@
+// Package review runs a model over a git diff, chunk by chunk, and collects
+// its findings into a report.
+package review
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// maxChunkLines is roughly how many diff lines go to the model at once
+const maxChunkLines = 300
+
+// Line is one line of a hunk
+type Line struct {
+	Kind    byte // '+', '-' or ' '
+	Number  int  // In the new file; in the old file for removed lines
+	Content string
+}
+
+// Chunk is a piece of one file's diff, small enough for one request
+type Chunk struct {
+	File  string
+	Lines []Line
+}
+
+// Diff runs git diff in dir for ref and describes what it compared. An empty
+// ref means uncommitted changes, or the last commit if there are none; a
+// range (a..b or a...b) is used as is; any other ref is compared with HEAD
+// from where they diverged, so /review main reviews the current branch.
+func Diff(ctx context.Context, dir, ref string) (diff, description string, err error) {
+	args := []string{"diff", "--no-color", "--no-ext-diff", "-U3"}
+	switch {
+	case ref == "":
+		if diff, err = git(ctx, dir, append(args, "HEAD")...); err != nil || strings.TrimSpace(diff) != "" {
+			return diff, "uncommitted changes", err
+		}
+		diff, err = git(ctx, dir, append(args, "HEAD~1", "HEAD")...)
+		return diff, "the last commit", err
+	case strings.Contains(ref, ".."):
+		diff, err = git(ctx, dir, append(args, ref)...)
+		return diff, ref, err
+	default:
+		diff, err = git(ctx, dir, append(args, ref+"...HEAD")...)
+		return diff, ref + "...HEAD", err
+	}
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n"); msg != "" {
+			return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimPrefix(msg, "fatal: "))
+		}
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return string(out), nil
+}
+
+// Split parses a unified diff into chunks of at most about maxChunkLines
+// lines, keeping hunks whole unless a single hunk is longer. Deleted and
+// binary files are left out, since there is nothing left to fix.
+func Split(diff string) []Chunk {
+	var chunks []Chunk
+	var file string
+	var current Chunk
+	var hunk []Line
+	oldLine, newLine := 0, 0
+	inHeader := false
+
+	flushHunk := func() {
+		if len(hunk) == 0 {
+			return
+		}
+		if len(current.Lines)+len(hunk) > maxChunkLines && len(current.Lines) > 0 {
+			chunks = append(chunks, current)
+			current = Chunk{File: file}
+		}
+		for len(hunk) > maxChunkLines {
+			chunks = append(chunks, Chunk{File: file, Lines: hunk[:maxChunkLines]})
+			hunk = hunk[maxChunkLines:]
+		}
+		current.Lines = append(current.Lines, hunk...)
+		hunk = nil
+	}
+	flushFile := func() {
+		flushHunk()
+		if len(current.Lines) > 0 {
+			chunks = append(chunks, current)
+		}
+		current = Chunk{}
+	}
+
+	for _, text := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(text, "diff --git "):
+			flushFile()
+			file = ""
+			inHeader = true
+		case inHeader && strings.HasPrefix(text, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(text, "+++ "), "b/")
+			if file == "/dev/null" {
+				file = ""
+			}
+			current = Chunk{File: file}
+		case file == "":
+			// Headers, binary files and deletions
+		case strings.HasPrefix(text, "@@"):
+			inHeader = false
+			flushHunk()
+			oldLine, newLine = hunkStart(text)
+		case strings.HasPrefix(text, "+"):
+			hunk = append(hunk, Line{Kind: '+', Number: newLine, Content: text[1:]})
+			newLine++
+		case strings.HasPrefix(text, "-"):
+			hunk = append(hunk, Line{Kind: '-', Number: oldLine, Content: text[1:]})
+			oldLine++
+		case strings.HasPrefix(text, " "):
+			hunk = append(hunk, Line{Kind: ' ', Number: newLine, Content: text[1:]})
+			oldLine++
+			newLine++
+		}
+	}
+	flushFile()
+	return chunks
+}
+
+// hunkStart reads the old and new start lines from "@@ -a,b +c,d @@"
+func hunkStart(header string) (int, int) {
+	fields := strings.Fields(header)
+	start := func(field string) int {
+		n, _, _ := strings.Cut(field[1:], ",")
+		v, _ := strconv.Atoi(n)
+		return v
+	}
+	if len(fields) < 3 {
+		return 0, 0
+	}
+	return start(fields[1]), start(fields[2])
+}
+
+// Render formats the chunk the way the review prompt describes
+func (c Chunk) Render() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("File: %s\n\n", c.File))
+	for _, line := range c.Lines {
+		sb.WriteString(fmt.Sprintf("%5d %c %s\n", line.Number, line.Kind, line.Content))
+	}
+	return sb.String()
+}
+
+// Span is the first and last new-file line the chunk covers
+func (c Chunk) Span() (int, int) {
+	first, last := 0, 0
+	for _, line := range c.Lines {
+		if line.Kind == '-' {
+			continue
+		}
+		if first == 0 {
+			first = line.Number
+		}
+		last = line.Number
+	}
+	return first, last
+}
@@ -0,0 +1,220 @@
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/tools"
+)
+
+// Severities from worst to mildest; anything else a model answers counts as minor
+var Severities = []string{"critical", "major", "minor", "nit"}
+
+var severityIcons = map[string]string{"critical": "🔴", "major": "🟠", "minor": "🟡", "nit": "⚪"}
+
+type Finding struct {
+	Severity   string `json:"severity"`
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	Title      string `json:"title"`
+	Detail     string `json:"detail"`
+	Suggestion string `json:"suggestion"`
+}
+
+// Report is everything one review found
+type Report struct {
+	Description string // What was compared, e.g. main...HEAD
+	Files       int
+	Chunks      int
+	Findings    []Finding
+	Failed      []string // Chunks the model could not review, with the reason
+}
+
+// Reviewer asks a model about each chunk of a diff
+type Reviewer struct {
+	client   *ollama.Client
+	model    string
+	prompt   string
+	progress tools.ModelProgress
+}
+
+func New(client *ollama.Client, model, prompt string) *Reviewer {
+	return &Reviewer{client: client, model: model, prompt: prompt}
+}
+
+// SetProgress streams each chunk's answer as it is written
+func (r *Reviewer) SetProgress(progress tools.ModelProgress) {
+	r.progress = progress
+}
+
+// Review asks about every chunk in turn. A chunk that fails is noted in the
+// report and the rest are still reviewed, unless ctx is cancelled.
+func (r *Reviewer) Review(ctx context.Context, description string, chunks []Chunk) (*Report, error) {
+	report := &Report{Description: description, Chunks: len(chunks)}
+	files := make(map[string]bool)
+	for i, chunk := range chunks {
+		files[chunk.File] = true
+		findings, err := r.reviewChunk(ctx, chunk, fmt.Sprintf("review_%d", i), fmt.Sprintf("%s (%d/%d)", chunk.File, i+1, len(chunks)))
+		if err != nil {
+			if ctx.Err() != nil {
+				return report, ctx.Err()
+			}
+			first, last := chunk.Span()
+			report.Failed = append(report.Failed, fmt.Sprintf("%s:%d-%d: %v", chunk.File, first, last, err))
+			continue
+		}
+		report.Findings = append(report.Findings, findings...)
+	}
+	report.Files = len(files)
+	sortFindings(report.Findings)
+	return report, nil
+}
+
+func (r *Reviewer) reviewChunk(ctx context.Context, chunk Chunk, id, label string) ([]Finding, error) {
+	req := ollama.ChatRequest{
+		Model: r.model,
+		Messages: []ollama.Message{
+			{Role: "system", Content: r.prompt},
+			{Role: "user", Content: chunk.Render()},
+		},
+		Format: "json",
+	}
+	var resp *ollama.ChatResponse
+	var err error
+	if r.progress != nil {
+		r.progress.Start(id, r.model, label)
+		resp, err = r.client.ChatStream(ctx, req, func(content string) {
+			r.progress.Chunk(id, content)
+		})
+		r.progress.End(id, err)
+	} else {
+		resp, err = r.client.Chat(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseFindings(resp.Message.Content, chunk)
+}
+
+// parseFindings reads the model's JSON answer, tolerating a code fence or
+// text around it, and fills in what the model left out
+func parseFindings(answer string, chunk Chunk) ([]Finding, error) {
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("the model did not answer with JSON")
+	}
+	var parsed struct {
+		Findings []Finding `json:"findings"`
+	}
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &parsed); err != nil {
+		return nil, fmt.Errorf("parse the model's answer: %w", err)
+	}
+
+	first, last := chunk.Span()
+	var findings []Finding
+	for _, f := range parsed.Findings {
+		if strings.TrimSpace(f.Title) == "" && strings.TrimSpace(f.Detail) == "" {
+			continue
+		}
+		f.Severity = strings.ToLower(strings.TrimSpace(f.Severity))
+		if severityRank(f.Severity) == len(Severities) {
+			f.Severity = "minor"
+		}
+		// The model only saw this file; a different path is a hallucination
+		f.File = chunk.File
+		if f.Line < first || f.Line > last {
+			f.Line = first
+		}
+		findings = append(findings, f)
+	}
+	return findings, nil
+}
+
+func severityRank(severity string) int {
+	for i, s := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return len(Severities)
+}
+
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if ra, rb := severityRank(a.Severity), severityRank(b.Severity); ra != rb {
+			return ra < rb
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+}
+
+// Markdown renders the report grouped by severity, worst first
+func (r *Report) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## 🔍 Review of %s\n\n", r.Description))
+	sb.WriteString(fmt.Sprintf("%d %s in %d %s: ", r.Chunks, plural(r.Chunks, "chunk"), r.Files, plural(r.Files, "file")))
+	if len(r.Findings) == 0 {
+		sb.WriteString("no findings.\n")
+	} else {
+		var counts []string
+		for _, severity := range Severities {
+			if n := r.count(severity); n > 0 {
+				counts = append(counts, fmt.Sprintf("%d %s", n, severity))
+			}
+		}
+		sb.WriteString(strings.Join(counts, ", ") + ".\n")
+	}
+
+	for _, severity := range Severities {
+		if r.count(severity) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n### %s %s\n\n", severityIcons[severity], strings.ToUpper(severity[:1])+severity[1:]))
+		for _, f := range r.Findings {
+			if f.Severity != severity {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("- `%s:%d` **%s**", f.File, f.Line, strings.TrimSpace(f.Title)))
+			if detail := strings.TrimSpace(f.Detail); detail != "" {
+				sb.WriteString(" — " + detail)
+			}
+			if suggestion := strings.TrimSpace(f.Suggestion); suggestion != "" {
+				sb.WriteString("\n  _Fix:_ " + suggestion)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	if len(r.Failed) > 0 {
+		sb.WriteString(fmt.Sprintf("\n⚠ %d %s could not be reviewed:\n", len(r.Failed), plural(len(r.Failed), "chunk")))
+		for _, failed := range r.Failed {
+			sb.WriteString("- " + failed + "\n")
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func (r *Report) count(severity string) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
@@ -0,0 +1,76 @@
+package review
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -10,4 +10,5 @@ func main() {
+ 	a := 1
+-	b := 2
++	b := 3
++	+++ not a header
+ 	fmt.Println(a, b)
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package old
+-
+diff --git a/logo.png b/logo.png
+Binary files a/logo.png and b/logo.png differ
+`
+
+func TestSplit(t *testing.T) {
+	chunks := Split(sampleDiff)
+	if len(chunks) != 1 {
+		t.Fatalf("expected only main.go, got %d chunks", len(chunks))
+	}
+	chunk := chunks[0]
+	if chunk.File != "main.go" || len(chunk.Lines) != 5 {
+		t.Fatalf("unexpected chunk: %+v", chunk)
+	}
+	if first, last := chunk.Span(); first != 10 || last != 13 {
+		t.Errorf("expected span 10-13, got %d-%d", first, last)
+	}
+	if !strings.Contains(chunk.Render(), "   12 + \t+++ not a header") {
+		t.Errorf("added lines should be numbered in the new file:\n%s", chunk.Render())
+	}
+}
+
+func TestParseFindings(t *testing.T) {
+	chunk := Split(sampleDiff)[0]
+	answer := "```json\n" + `{"findings": [
+		{"severity": "MAJOR", "file": "other.go", "line": 11, "title": "Wrong value"},
+		{"severity": "bogus", "line": 500, "title": "Odd"},
+		{"severity": "nit"}
+	]}` + "\n```"
+	findings, err := parseFindings(answer, chunk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("expected the empty finding to be dropped, got %+v", findings)
+	}
+	if f := findings[0]; f.Severity != "major" || f.File != "main.go" || f.Line != 11 {
+		t.Errorf("unexpected first finding: %+v", f)
+	}
+	if f := findings[1]; f.Severity != "minor" || f.Line != 10 {
+		t.Errorf("unknown severities and lines outside the chunk should be normalized: %+v", f)
+	}
+
+	report := &Report{Description: "HEAD~1..HEAD", Files: 1, Chunks: 1, Findings: findings}
+	sortFindings(report.Findings)
+	md := report.Markdown()
+	if !strings.Contains(md, "1 major, 1 minor") || !strings.Contains(md, "`main.go:11` **Wrong value**") {
+		t.Errorf("unexpected report:\n%s", md)
+	}
+	if _, err := parseFindings("looks fine to me", chunk); err == nil {
+		t.Error("expected an error for an answer without JSON")
+	}
+}
@@ -0,0 +1,113 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+)
+
+// Snapshot is the persisted state of an in-flight conversation
+type Snapshot struct {
+	SessionID string           `json:"session_id,omitempty"` // Stored session the conversation continues
+	Model     string           `json:"model"`
+	Messages  []ollama.Message `json:"messages"`
+	Draft     string           `json:"draft,omitempty"` // Input typed but not sent
+	SavedAt   time.Time        `json:"saved_at"`
+}
+
+// UserMessageCount returns the number of user turns in the snapshot
+func (s *Snapshot) UserMessageCount() int {
+	count := 0
+	for _, msg := range s.Messages {
+		if msg.Role == "user" {
+			count++
+		}
+	}
+	return count
+}
+
+func GetRecoveryPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "recovery.json"), nil
+}
+
+// SaveRecovery writes the snapshot to the recovery file.
+// The file is written to a temp file first and renamed so a crash mid-write
+// never leaves a truncated recovery file behind.
+func SaveRecovery(snapshot *Snapshot) error {
+	path, err := GetRecoveryPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+
+	snapshot.SavedAt = time.Now()
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal snapshot: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("write recovery file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("rename recovery file: %w", err)
+	}
+
+	return nil
+}
+
+// LoadRecovery reads the recovery file. It returns nil without error when
+// there is nothing to recover.
+func LoadRecovery() (*Snapshot, error) {
+	path, err := GetRecoveryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read recovery file: %w", err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("parse recovery file: %w", err)
+	}
+
+	if snapshot.UserMessageCount() == 0 && snapshot.Draft == "" {
+		return nil, nil
+	}
+
+	return &snapshot, nil
+}
+
+// ClearRecovery removes the recovery file after a clean exit
+func ClearRecovery() error {
+	path, err := GetRecoveryPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove recovery file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package sshtunnel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+)
+
+// readyTimeout is how long ssh gets to connect and open the forward
+const readyTimeout = 15 * time.Second
+
+// closeTimeout is how long ssh gets to exit after being interrupted
+const closeTimeout = 2 * time.Second
+
+// Tunnel forwards a local port to Ollama on a remote host using the system
+// ssh client, so the user's ssh config, agent and known_hosts all apply
+type Tunnel struct {
+	localPort int
+	cmd       *exec.Cmd
+	exited    chan struct{}
+	stderr    *bytes.Buffer
+	closeOnce sync.Once
+}
+
+// Open starts ssh and waits until the forwarded port accepts connections
+func Open(ctx context.Context, cfg config.SSHTunnelConfig) (*Tunnel, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("ssh tunnel: host is required")
+	}
+
+	localPort, err := freePort()
+	if err != nil {
+		return nil, fmt.Errorf("ssh tunnel: find local port: %w", err)
+	}
+
+	remotePort := cfg.RemotePort
+	if remotePort == 0 {
+		remotePort = 11434
+	}
+
+	target := cfg.Host
+	if cfg.User != "" {
+		target = cfg.User + "@" + cfg.Host
+	}
+
+	args := []string{
+		"-N",
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "BatchMode=yes",
+		"-o", "ServerAliveInterval=30",
+		"-L", fmt.Sprintf("127.0.0.1:%d:localhost:%d", localPort, remotePort),
+	}
+	if cfg.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(cfg.Port))
+	}
+	if cfg.IdentityFile != "" {
+		args = append(args, "-i", cfg.IdentityFile)
+	}
+	args = append(args, target)
+
+	t := &Tunnel{
+		localPort: localPort,
+		cmd:       exec.Command("ssh", args...),
+		exited:    make(chan struct{}),
+		stderr:    &bytes.Buffer{},
+	}
+	t.cmd.Stderr = t.stderr
+
+	if err := t.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("ssh tunnel: start ssh: %w", err)
+	}
+	go func() {
+		t.cmd.Wait()
+		close(t.exited)
+	}()
+
+	if err := t.waitReady(ctx); err != nil {
+		t.Close()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// URL is the local address to reach the remote Ollama through the tunnel
+func (t *Tunnel) URL() string {
+	return fmt.Sprintf("http://127.0.0.1:%d", t.localPort)
+}
+
+// Close stops the ssh process
+func (t *Tunnel) Close() error {
+	t.closeOnce.Do(func() {
+		if t.cmd.Process == nil {
+			return
+		}
+		t.cmd.Process.Signal(os.Interrupt)
+		select {
+		case <-t.exited:
+		case <-time.After(closeTimeout):
+			t.cmd.Process.Kill()
+			<-t.exited
+		}
+	})
+	return nil
+}
+
+func (t *Tunnel) waitReady(ctx context.Context) error {
+	deadline := time.After(readyTimeout)
+	addr := fmt.Sprintf("127.0.0.1:%d", t.localPort)
+
+	for {
+		conn, err := net.DialTimeout("tcp", addr, 200*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+
+		select {
+		case <-t.exited:
+			msg := strings.TrimSpace(t.stderr.String())
+			if msg == "" {
+				msg = "ssh exited"
+			}
+			return fmt.Errorf("ssh tunnel: %s", msg)
+		case <-deadline:
+			return fmt.Errorf("ssh tunnel: timed out after %v waiting for forward", readyTimeout)
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
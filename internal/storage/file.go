@@ -0,0 +1,327 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileStore keeps everything as JSON files in the config directory:
+// sessions/<id>.json, usage.jsonl, audit.jsonl and benchmark_history/
+type FileStore struct {
+	dir string
+	mu  sync.Mutex // Serializes appends to the .jsonl files
+}
+
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+func (f *FileStore) sessionsDir() string { return filepath.Join(f.dir, "sessions") }
+func (f *FileStore) historyDir() string  { return filepath.Join(f.dir, "benchmark_history") }
+
+func (f *FileStore) SaveSession(s *Session) error {
+	s.touch()
+	return writeJSON(filepath.Join(f.sessionsDir(), s.ID+".json"), s)
+}
+
+func (f *FileStore) LoadSession(id string) (*Session, error) {
+	if strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("invalid session id %q", id)
+	}
+	var s Session
+	if err := readJSON(filepath.Join(f.sessionsDir(), id+".json"), &s); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("session %s not found", id)
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (f *FileStore) ListSessions() ([]SessionInfo, error) {
+	sessions, err := f.sessions()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		infos = append(infos, s.info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Updated.After(infos[j].Updated) })
+	return infos, nil
+}
+
+// SearchSessions scans every message; a message must contain all terms and
+// scores by how often they occur
+func (f *FileStore) SearchSessions(query string, limit int) ([]SearchHit, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	sessions, err := f.sessions()
+	if err != nil {
+		return nil, err
+	}
+
+	var hits []SearchHit
+	for _, s := range sessions {
+		for i, msg := range s.Messages {
+			if msg.Role == "system" {
+				continue
+			}
+			lower := strings.ToLower(msg.Content)
+			score := 0
+			for _, term := range terms {
+				n := strings.Count(lower, term)
+				if n == 0 {
+					score = 0
+					break
+				}
+				score += n
+			}
+			if score > 0 {
+				hits = append(hits, SearchHit{Session: s.info(), Index: i, Role: msg.Role, Snippet: snippet(msg.Content, terms), Score: float64(score)})
+			}
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Session.Updated.After(hits[j].Session.Updated)
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// snippet cuts the text around the first term and marks every term
+func snippet(content string, terms []string) string {
+	const context = 60
+	text := []rune(strings.Join(strings.Fields(content), " "))
+	lower := []rune(strings.ToLower(string(text)))
+	if len(lower) != len(text) {
+		// A few runes change length when lowered; match case-sensitively then
+		lower = text
+	}
+
+	first := -1
+	for _, term := range terms {
+		if i := runeIndex(lower, []rune(term)); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	start, end := 0, len(text)
+	if first > context {
+		start = first - context
+	}
+	if end > first+2*context {
+		end = first + 2*context
+	}
+
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString("…")
+	}
+	for i := start; i < end; {
+		matched := false
+		for _, term := range terms {
+			t := []rune(term)
+			if i+len(t) <= len(lower) && string(lower[i:i+len(t)]) == term {
+				sb.WriteString("«" + string(text[i:i+len(t)]) + "»")
+				i += len(t)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			sb.WriteRune(text[i])
+			i++
+		}
+	}
+	if end < len(text) {
+		sb.WriteString("…")
+	}
+	return sb.String()
+}
+
+func runeIndex(s, sub []rune) int {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		if string(s[i:i+len(sub)]) == string(sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// sessions reads every saved session, skipping files that don't parse
+func (f *FileStore) sessions() ([]*Session, error) {
+	entries, err := os.ReadDir(f.sessionsDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read sessions: %w", err)
+	}
+	var sessions []*Session
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		var s Session
+		if err := readJSON(filepath.Join(f.sessionsDir(), entry.Name()), &s); err != nil {
+			continue
+		}
+		sessions = append(sessions, &s)
+	}
+	return sessions, nil
+}
+
+func (f *FileStore) RecordUsage(u Usage) error {
+	return f.appendLine("usage.jsonl", u)
+}
+
+func (f *FileStore) Usage(since time.Time) ([]Usage, error) {
+	var usage []Usage
+	err := readLines(filepath.Join(f.dir, "usage.jsonl"), func(line []byte) {
+		var u Usage
+		if json.Unmarshal(line, &u) == nil && !u.Time.Before(since) {
+			usage = append(usage, u)
+		}
+	})
+	for i, j := 0, len(usage)-1; i < j; i, j = i+1, j-1 {
+		usage[i], usage[j] = usage[j], usage[i]
+	}
+	return usage, err
+}
+
+func (f *FileStore) AppendAudit(e AuditEntry) error {
+	return f.appendLine("audit.jsonl", e)
+}
+
+func (f *FileStore) Audit(limit int) ([]AuditEntry, error) {
+	var entries []AuditEntry
+	err := readLines(filepath.Join(f.dir, "audit.jsonl"), func(line []byte) {
+		var e AuditEntry
+		if json.Unmarshal(line, &e) == nil {
+			entries = append(entries, e)
+		}
+	})
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, err
+}
+
+func (f *FileStore) SaveBenchmarkRun(run BenchmarkRun) error {
+	if run.Time.IsZero() {
+		run.Time = time.Now()
+	}
+	return writeJSON(filepath.Join(f.historyDir(), run.Time.Format("20060102-150405.000")+".json"), run)
+}
+
+func (f *FileStore) BenchmarkRuns(limit int) ([]BenchmarkRun, error) {
+	entries, err := os.ReadDir(f.historyDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read benchmark history: %w", err)
+	}
+
+	// File names sort by time
+	var runs []BenchmarkRun
+	for i := len(entries) - 1; i >= 0 && (limit <= 0 || len(runs) < limit); i-- {
+		var run BenchmarkRun
+		if err := readJSON(filepath.Join(f.historyDir(), entries[i].Name()), &run); err == nil {
+			runs = append(runs, run)
+		}
+	}
+	return runs, nil
+}
+
+func (f *FileStore) Close() error { return nil }
+
+func (f *FileStore) appendLine(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", name, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	file, err := os.OpenFile(filepath.Join(f.dir, name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", name, err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// writeJSON writes through a temp file so a crash never leaves a truncated file
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", filepath.Base(path), err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("rename %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// readLines calls fn for each line of a .jsonl file; a missing file has none
+func readLines(path string, fn func(line []byte)) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open %s: %w", filepath.Base(path), err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		fn(scanner.Bytes())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
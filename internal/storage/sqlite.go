@@ -0,0 +1,508 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS meta (key TEXT PRIMARY KEY, value TEXT NOT NULL);
+CREATE TABLE IF NOT EXISTS sessions (
+	id TEXT PRIMARY KEY,
+	title TEXT NOT NULL,
+	model TEXT NOT NULL,
+	messages TEXT NOT NULL,
+	todos TEXT NOT NULL DEFAULT '[]',
+	draft TEXT NOT NULL DEFAULT '',
+	summary TEXT NOT NULL DEFAULT '',
+	summary_turns INTEGER NOT NULL DEFAULT 0,
+	turns INTEGER NOT NULL,
+	created INTEGER NOT NULL,
+	updated INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS sessions_updated ON sessions(updated);
+CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(
+	session_id UNINDEXED,
+	idx UNINDEXED,
+	role UNINDEXED,
+	content
+);
+CREATE TABLE IF NOT EXISTS usage (
+	time INTEGER NOT NULL,
+	model TEXT NOT NULL,
+	duration INTEGER NOT NULL,
+	prompt_tokens INTEGER NOT NULL,
+	completion_tokens INTEGER NOT NULL,
+	tool_calls INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS usage_time ON usage(time);
+CREATE TABLE IF NOT EXISTS audit (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	time INTEGER NOT NULL,
+	tool TEXT NOT NULL,
+	args TEXT NOT NULL,
+	error TEXT NOT NULL,
+	duration INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS benchmark_runs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	time INTEGER NOT NULL,
+	results TEXT NOT NULL
+);
+`
+
+// SQLiteStore keeps everything in one database file. It needs no CGO.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens or creates the database at path. The first time, the
+// files a FileStore wrote to configDir are imported; they are left in place.
+func OpenSQLite(path, configDir string) (*SQLiteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create config dir: %w", err)
+	}
+	// WAL and a busy timeout let a background benchmark write while the chat has it open
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema in %s: %w", path, err)
+	}
+	// Columns added after the first release
+	if err := addColumn(db, "audit", "duration", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate %s: %w", path, err)
+	}
+	if err := addColumn(db, "sessions", "todos", "TEXT NOT NULL DEFAULT '[]'"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate %s: %w", path, err)
+	}
+	if err := addColumn(db, "sessions", "draft", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate %s: %w", path, err)
+	}
+	if err := addColumn(db, "sessions", "summary", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate %s: %w", path, err)
+	}
+	if err := addColumn(db, "sessions", "summary_turns", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate %s: %w", path, err)
+	}
+
+	s := &SQLiteStore{db: db}
+	if err := s.importFiles(configDir); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("import files into %s: %w", path, err)
+	}
+	if err := s.indexSessions(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("index sessions in %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// addColumn adds a column to tables created before it existed
+func addColumn(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
+	return err
+}
+
+// indexSessions fills the search index once for databases created before it
+// existed; from then on SaveSession keeps it current
+func (s *SQLiteStore) indexSessions() error {
+	var done string
+	err := s.db.QueryRow(`SELECT value FROM meta WHERE key = 'indexed'`).Scan(&done)
+	if err == nil {
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	infos, err := s.ListSessions()
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, info := range infos {
+		session, err := s.LoadSession(info.ID)
+		if err != nil {
+			return err
+		}
+		if err := indexMessages(tx, session); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO meta (key, value) VALUES ('indexed', ?)`, time.Now().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// importFiles copies the file store's data in once, in one transaction
+func (s *SQLiteStore) importFiles(dir string) error {
+	var done string
+	err := s.db.QueryRow(`SELECT value FROM meta WHERE key = 'imported'`).Scan(&done)
+	if err == nil {
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	files := NewFileStore(dir)
+	sessions, err := files.sessions()
+	if err != nil {
+		return err
+	}
+	usage, err := files.Usage(time.Time{})
+	if err != nil {
+		return err
+	}
+	audit, err := files.Audit(0)
+	if err != nil {
+		return err
+	}
+	runs, err := files.BenchmarkRuns(0)
+	if err != nil {
+		return err
+	}
+	// Results from before there was a history become its first run
+	if len(runs) == 0 {
+		if run, ok := legacyBenchmarkRun(dir); ok {
+			runs = append(runs, run)
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, session := range sessions {
+		if err := insertSession(tx, session); err != nil {
+			return err
+		}
+	}
+	// Oldest first, so IDs follow time
+	for i := len(usage) - 1; i >= 0; i-- {
+		if err := insertUsage(tx, usage[i]); err != nil {
+			return err
+		}
+	}
+	for i := len(audit) - 1; i >= 0; i-- {
+		if err := insertAudit(tx, audit[i]); err != nil {
+			return err
+		}
+	}
+	for i := len(runs) - 1; i >= 0; i-- {
+		if err := insertBenchmarkRun(tx, runs[i]); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO meta (key, value) VALUES ('imported', ?)`, time.Now().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// legacyBenchmarkRun reads benchmark_results.json as a run dated by its mtime
+func legacyBenchmarkRun(dir string) (BenchmarkRun, bool) {
+	path := filepath.Join(dir, "benchmark_results.json")
+	info, err := os.Stat(path)
+	if err != nil {
+		return BenchmarkRun{}, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !json.Valid(data) {
+		return BenchmarkRun{}, false
+	}
+	return BenchmarkRun{Time: info.ModTime(), Results: data}, true
+}
+
+// execer is a *sql.DB or *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertSession(db execer, s *Session) error {
+	messages, err := json.Marshal(s.Messages)
+	if err != nil {
+		return fmt.Errorf("marshal messages: %w", err)
+	}
+	todos, err := json.Marshal(s.Todos)
+	if err != nil {
+		return fmt.Errorf("marshal todos: %w", err)
+	}
+	info := s.info()
+	_, err = db.Exec(`INSERT INTO sessions (id, title, model, messages, todos, draft, summary, summary_turns, turns, created, updated)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET title = excluded.title, model = excluded.model,
+			messages = excluded.messages, todos = excluded.todos, draft = excluded.draft,
+			summary = excluded.summary, summary_turns = excluded.summary_turns, turns = excluded.turns, updated = excluded.updated`,
+		s.ID, s.Title, s.Model, string(messages), string(todos), s.Draft, s.Summary, s.SummaryTurns, info.Turns, s.Created.UnixNano(), s.Updated.UnixNano())
+	if err != nil {
+		return fmt.Errorf("save session %s: %w", s.ID, err)
+	}
+	return indexMessages(db, s)
+}
+
+// indexMessages replaces the search index entries of a session
+func indexMessages(db execer, s *Session) error {
+	if _, err := db.Exec(`DELETE FROM messages_fts WHERE session_id = ?`, s.ID); err != nil {
+		return fmt.Errorf("index session %s: %w", s.ID, err)
+	}
+	for i, msg := range s.Messages {
+		if msg.Role == "system" || msg.Content == "" {
+			continue
+		}
+		if _, err := db.Exec(`INSERT INTO messages_fts (session_id, idx, role, content) VALUES (?, ?, ?, ?)`, s.ID, i, msg.Role, msg.Content); err != nil {
+			return fmt.Errorf("index session %s: %w", s.ID, err)
+		}
+	}
+	return nil
+}
+
+func insertUsage(db execer, u Usage) error {
+	_, err := db.Exec(`INSERT INTO usage (time, model, duration, prompt_tokens, completion_tokens, tool_calls) VALUES (?, ?, ?, ?, ?, ?)`,
+		u.Time.UnixNano(), u.Model, int64(u.Duration), u.PromptTokens, u.CompletionTokens, u.ToolCalls)
+	if err != nil {
+		return fmt.Errorf("record usage: %w", err)
+	}
+	return nil
+}
+
+func insertAudit(db execer, e AuditEntry) error {
+	_, err := db.Exec(`INSERT INTO audit (time, tool, args, error, duration) VALUES (?, ?, ?, ?, ?)`,
+		e.Time.UnixNano(), e.Tool, e.Args, e.Error, int64(e.Duration))
+	if err != nil {
+		return fmt.Errorf("append audit entry: %w", err)
+	}
+	return nil
+}
+
+func insertBenchmarkRun(db execer, run BenchmarkRun) error {
+	_, err := db.Exec(`INSERT INTO benchmark_runs (time, results) VALUES (?, ?)`, run.Time.UnixNano(), string(run.Results))
+	if err != nil {
+		return fmt.Errorf("save benchmark run: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) SaveSession(session *Session) error {
+	session.touch()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("save session %s: %w", session.ID, err)
+	}
+	defer tx.Rollback()
+	if err := insertSession(tx, session); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) LoadSession(id string) (*Session, error) {
+	session := &Session{ID: id}
+	var messages, todos string
+	var created, updated int64
+	err := s.db.QueryRow(`SELECT title, model, messages, todos, draft, summary, summary_turns, created, updated FROM sessions WHERE id = ?`, id).
+		Scan(&session.Title, &session.Model, &messages, &todos, &session.Draft, &session.Summary, &session.SummaryTurns, &created, &updated)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("session %s not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load session %s: %w", id, err)
+	}
+	if err := json.Unmarshal([]byte(messages), &session.Messages); err != nil {
+		return nil, fmt.Errorf("parse session %s: %w", id, err)
+	}
+	if err := json.Unmarshal([]byte(todos), &session.Todos); err != nil {
+		return nil, fmt.Errorf("parse todos of session %s: %w", id, err)
+	}
+	session.Created, session.Updated = time.Unix(0, created), time.Unix(0, updated)
+	return session, nil
+}
+
+func (s *SQLiteStore) ListSessions() ([]SessionInfo, error) {
+	rows, err := s.db.Query(`SELECT id, title, model, turns, created, updated FROM sessions ORDER BY updated DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var infos []SessionInfo
+	for rows.Next() {
+		var info SessionInfo
+		var created, updated int64
+		if err := rows.Scan(&info.ID, &info.Title, &info.Model, &info.Turns, &created, &updated); err != nil {
+			return nil, fmt.Errorf("list sessions: %w", err)
+		}
+		info.Created, info.Updated = time.Unix(0, created), time.Unix(0, updated)
+		infos = append(infos, info)
+	}
+	return infos, rows.Err()
+}
+
+// SearchSessions ranks matching messages with FTS5's bm25. Every term must
+// occur, as a word or the start of one.
+func (s *SQLiteStore) SearchSessions(query string, limit int) ([]SearchHit, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := s.db.Query(`SELECT messages_fts.session_id, messages_fts.idx, messages_fts.role,
+			snippet(messages_fts, 3, '«', '»', '…', 24), bm25(messages_fts),
+			sessions.title, sessions.model, sessions.turns, sessions.created, sessions.updated
+		FROM messages_fts JOIN sessions ON sessions.id = messages_fts.session_id
+		WHERE messages_fts MATCH ? ORDER BY bm25(messages_fts) LIMIT ?`, strings.Join(quoted, " "), limit)
+	if err != nil {
+		return nil, fmt.Errorf("search sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []SearchHit
+	for rows.Next() {
+		var hit SearchHit
+		var rank float64
+		var created, updated int64
+		if err := rows.Scan(&hit.Session.ID, &hit.Index, &hit.Role, &hit.Snippet, &rank,
+			&hit.Session.Title, &hit.Session.Model, &hit.Session.Turns, &created, &updated); err != nil {
+			return nil, fmt.Errorf("search sessions: %w", err)
+		}
+		// bm25 is lower for better matches
+		hit.Score = -rank
+		hit.Snippet = strings.Join(strings.Fields(hit.Snippet), " ")
+		hit.Session.Created, hit.Session.Updated = time.Unix(0, created), time.Unix(0, updated)
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+func (s *SQLiteStore) RecordUsage(u Usage) error {
+	return insertUsage(s.db, u)
+}
+
+func (s *SQLiteStore) Usage(since time.Time) ([]Usage, error) {
+	rows, err := s.db.Query(`SELECT time, model, duration, prompt_tokens, completion_tokens, tool_calls
+		FROM usage WHERE time >= ? ORDER BY time DESC`, since.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("read usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []Usage
+	for rows.Next() {
+		var u Usage
+		var t, d int64
+		if err := rows.Scan(&t, &u.Model, &d, &u.PromptTokens, &u.CompletionTokens, &u.ToolCalls); err != nil {
+			return nil, fmt.Errorf("read usage: %w", err)
+		}
+		u.Time, u.Duration = time.Unix(0, t), time.Duration(d)
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+func (s *SQLiteStore) AppendAudit(e AuditEntry) error {
+	return insertAudit(s.db, e)
+}
+
+func (s *SQLiteStore) Audit(limit int) ([]AuditEntry, error) {
+	if limit <= 0 {
+		limit = -1 // No limit in SQLite
+	}
+	rows, err := s.db.Query(`SELECT time, tool, args, error, duration FROM audit ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		var t, d int64
+		if err := rows.Scan(&t, &e.Tool, &e.Args, &e.Error, &d); err != nil {
+			return nil, fmt.Errorf("read audit log: %w", err)
+		}
+		e.Time = time.Unix(0, t)
+		e.Duration = time.Duration(d)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (s *SQLiteStore) SaveBenchmarkRun(run BenchmarkRun) error {
+	if run.Time.IsZero() {
+		run.Time = time.Now()
+	}
+	return insertBenchmarkRun(s.db, run)
+}
+
+func (s *SQLiteStore) BenchmarkRuns(limit int) ([]BenchmarkRun, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.Query(`SELECT time, results FROM benchmark_runs ORDER BY time DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("read benchmark history: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []BenchmarkRun
+	for rows.Next() {
+		var run BenchmarkRun
+		var t int64
+		var results string
+		if err := rows.Scan(&t, &results); err != nil {
+			return nil, fmt.Errorf("read benchmark history: %w", err)
+		}
+		run.Time, run.Results = time.Unix(0, t), json.RawMessage(results)
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
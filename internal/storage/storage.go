@@ -0,0 +1,180 @@
+// Package storage keeps sessions, usage stats, the tool audit log and
+// benchmark history, either as files in the config directory or in a
+// SQLite database.
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/todo"
+)
+
+// Store is implemented by the file and SQLite backends. Lists are returned
+// newest first.
+type Store interface {
+	SaveSession(s *Session) error
+	LoadSession(id string) (*Session, error)
+	ListSessions() ([]SessionInfo, error)
+	SearchSessions(query string, limit int) ([]SearchHit, error) // Best match first
+
+	RecordUsage(u Usage) error
+	Usage(since time.Time) ([]Usage, error)
+
+	AppendAudit(e AuditEntry) error
+	Audit(limit int) ([]AuditEntry, error)
+
+	SaveBenchmarkRun(run BenchmarkRun) error
+	BenchmarkRuns(limit int) ([]BenchmarkRun, error)
+
+	Close() error
+}
+
+// Session is a saved conversation
+type Session struct {
+	ID       string           `json:"id"`
+	Title    string           `json:"title"` // Generated or set with /rename; the first user message, shortened, until then
+	Model    string           `json:"model"`
+	Messages []ollama.Message `json:"messages"`
+	Todos    []todo.Item      `json:"todos,omitempty"` // Task list kept with the todo_ tools
+	Draft    string           `json:"draft,omitempty"` // Input typed but not sent
+	Created  time.Time        `json:"created"`
+	Updated  time.Time        `json:"updated"`
+
+	// Rolling summary of the first SummaryTurns user turns, so a long
+	// session can be resumed without replaying them
+	Summary      string `json:"summary,omitempty"`
+	SummaryTurns int    `json:"summary_turns,omitempty"`
+}
+
+// SessionInfo describes a session without its messages
+type SessionInfo struct {
+	ID      string
+	Title   string
+	Model   string
+	Turns   int // User messages
+	Created time.Time
+	Updated time.Time
+}
+
+// SearchHit is a stored message that matches a search
+type SearchHit struct {
+	Session SessionInfo
+	Index   int // Position of the message in the session
+	Role    string
+	Snippet string  // Text around the match, with the terms marked «like this»
+	Score   float64 // Higher is better
+}
+
+// Usage is what one chat turn cost
+type Usage struct {
+	Time             time.Time     `json:"time"`
+	Model            string        `json:"model"`
+	Duration         time.Duration `json:"duration"`
+	PromptTokens     int           `json:"prompt_tokens"`
+	CompletionTokens int           `json:"completion_tokens"`
+	ToolCalls        int           `json:"tool_calls"`
+}
+
+// AuditEntry records one tool call the model made
+type AuditEntry struct {
+	Time     time.Time     `json:"time"`
+	Tool     string        `json:"tool"`
+	Args     string        `json:"args"`            // JSON encoded arguments
+	Error    string        `json:"error,omitempty"` // Includes permission denials
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// BenchmarkRun holds the scores of one finished benchmark run as JSON
+type BenchmarkRun struct {
+	Time    time.Time       `json:"time"`
+	Results json.RawMessage `json:"results"`
+}
+
+// Open returns the store selected by the storage setting in cfg
+func Open(cfg *config.Config) (Store, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("get config dir: %w", err)
+	}
+
+	switch cfg.Storage {
+	case "", "files":
+		return NewFileStore(dir), nil
+	case "sqlite":
+		return OpenSQLite(filepath.Join(dir, "llemecode.db"), dir)
+	default:
+		return nil, fmt.Errorf("unknown storage %q (use files or sqlite)", cfg.Storage)
+	}
+}
+
+// NewSessionID returns a sortable, unique session ID
+func NewSessionID() string {
+	b := make([]byte, 3)
+	rand.Read(b)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
+
+// touch fills in the title and timestamps before a session is saved
+func (s *Session) touch() {
+	now := time.Now()
+	if s.Created.IsZero() {
+		s.Created = now
+	}
+	s.Updated = now
+	if s.Title == "" {
+		s.Title = s.defaultTitle()
+	}
+}
+
+// defaultTitle is the first user message, shortened
+func (s *Session) defaultTitle() string {
+	for _, msg := range s.Messages {
+		if msg.Role == "user" {
+			return shorten(msg.Content, 60)
+		}
+	}
+	return ""
+}
+
+// HasDefaultTitle reports whether the session has not been given a title of
+// its own yet
+func (s *Session) HasDefaultTitle() bool {
+	return s.Title == "" || s.Title == s.defaultTitle()
+}
+
+func (s *Session) info() SessionInfo {
+	turns := 0
+	for _, msg := range s.Messages {
+		if msg.Role == "user" {
+			turns++
+		}
+	}
+	return SessionInfo{ID: s.ID, Title: s.Title, Model: s.Model, Turns: turns, Created: s.Created, Updated: s.Updated}
+}
+
+// searchTerms splits a query into lowercase words
+func searchTerms(query string) []string {
+	var terms []string
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		if term = strings.Trim(term, `"'*`); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+func shorten(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > max {
+		return string(r[:max-1]) + "…"
+	}
+	return s
+}
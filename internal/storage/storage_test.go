@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/todo"
+)
+
+func TestStores(t *testing.T) {
+	dir := t.TempDir()
+	sqlite, err := OpenSQLite(filepath.Join(dir, "test.db"), dir)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer sqlite.Close()
+
+	for name, store := range map[string]Store{"files": NewFileStore(t.TempDir()), "sqlite": sqlite} {
+		t.Run(name, func(t *testing.T) {
+			session := &Session{ID: NewSessionID(), Model: "m", Messages: []ollama.Message{{Role: "user", Content: "hello\nthere"}}}
+			if err := store.SaveSession(session); err != nil {
+				t.Fatalf("SaveSession: %v", err)
+			}
+			session.Messages = append(session.Messages, ollama.Message{Role: "assistant", Content: "hi"})
+			session.Todos = []todo.Item{{ID: 1, Text: "say hi", Status: todo.Done}}
+			session.Draft = "and then"
+			session.Summary, session.SummaryTurns = "the user said hello", 1
+			if err := store.SaveSession(session); err != nil {
+				t.Fatalf("SaveSession again: %v", err)
+			}
+
+			loaded, err := store.LoadSession(session.ID)
+			if err != nil {
+				t.Fatalf("LoadSession: %v", err)
+			}
+			if loaded.Title != "hello there" || len(loaded.Messages) != 2 || len(loaded.Todos) != 1 || loaded.Todos[0].Status != todo.Done || loaded.Draft != "and then" || loaded.Summary != "the user said hello" || loaded.SummaryTurns != 1 {
+				t.Errorf("loaded session = %+v", loaded)
+			}
+			infos, err := store.ListSessions()
+			if err != nil || len(infos) != 1 || infos[0].Turns != 1 {
+				t.Errorf("ListSessions = %+v, %v", infos, err)
+			}
+			hits, err := store.SearchSessions("HELLO", 0)
+			if err != nil || len(hits) != 1 || hits[0].Index != 0 || hits[0].Snippet != "«hello» there" {
+				t.Errorf("SearchSessions = %+v, %v", hits, err)
+			}
+			if hits, _ := store.SearchSessions("hello nowhere", 0); len(hits) != 0 {
+				t.Errorf("expected every term to be required, got %+v", hits)
+			}
+
+			for i := 1; i <= 2; i++ {
+				store.RecordUsage(Usage{Time: time.Now(), Model: "m", PromptTokens: i})
+				store.AppendAudit(AuditEntry{Time: time.Now(), Tool: "read_file", Args: "{}", Error: ""})
+				store.SaveBenchmarkRun(BenchmarkRun{Time: time.Now().Add(time.Duration(i) * time.Second), Results: json.RawMessage(`[]`)})
+			}
+			if usage, err := store.Usage(time.Time{}); err != nil || len(usage) != 2 || usage[0].PromptTokens != 2 {
+				t.Errorf("Usage = %+v, %v", usage, err)
+			}
+			if audit, err := store.Audit(1); err != nil || len(audit) != 1 {
+				t.Errorf("Audit = %+v, %v", audit, err)
+			}
+			if runs, err := store.BenchmarkRuns(0); err != nil || len(runs) != 2 || !runs[0].Time.After(runs[1].Time) {
+				t.Errorf("BenchmarkRuns = %+v, %v", runs, err)
+			}
+		})
+	}
+}
+
+func TestSQLiteImportsFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := NewFileStore(dir)
+	files.SaveSession(&Session{ID: "old", Model: "m", Messages: []ollama.Message{{Role: "user", Content: "hi"}}})
+	files.RecordUsage(Usage{Time: time.Now(), Model: "m"})
+	os.WriteFile(filepath.Join(dir, "benchmark_results.json"), []byte(`[{"model":"m"}]`), 0644)
+
+	store, err := OpenSQLite(filepath.Join(dir, "llemecode.db"), dir)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	if _, err := store.LoadSession("old"); err != nil {
+		t.Errorf("session not imported: %v", err)
+	}
+	if runs, _ := store.BenchmarkRuns(0); len(runs) != 1 {
+		t.Errorf("expected benchmark_results.json as a run, got %d", len(runs))
+	}
+	store.Close()
+
+	// Reopening must not import twice
+	store, err = OpenSQLite(filepath.Join(dir, "llemecode.db"), dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer store.Close()
+	if usage, _ := store.Usage(time.Time{}); len(usage) != 1 {
+		t.Errorf("expected 1 usage record after reopening, got %d", len(usage))
+	}
+}
@@ -0,0 +1,159 @@
+// Package todo keeps the task list the user and the agent share during a
+// session, so long multi-step work stays legible and can be resumed.
+package todo
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Task statuses
+const (
+	Pending    = "pending"
+	InProgress = "in_progress"
+	Done       = "done"
+)
+
+var statusMarks = map[string]string{Pending: "[ ]", InProgress: "[~]", Done: "[x]"}
+
+// Item is one task
+type Item struct {
+	ID     int    `json:"id"`
+	Text   string `json:"text"`
+	Status string `json:"status"`
+	Note   string `json:"note,omitempty"` // Outcome or blocker, e.g. why it was skipped
+}
+
+// List is safe for concurrent use; tools change it while the UI reads it
+type List struct {
+	mu     sync.Mutex
+	items  []Item
+	nextID int
+}
+
+func NewList() *List {
+	return &List{nextID: 1}
+}
+
+// Add appends pending tasks and returns them
+func (l *List) Add(texts ...string) []Item {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var added []Item
+	for _, text := range texts {
+		if text = strings.TrimSpace(text); text == "" {
+			continue
+		}
+		item := Item{ID: l.nextID, Text: text, Status: Pending}
+		l.nextID++
+		l.items = append(l.items, item)
+		added = append(added, item)
+	}
+	return added
+}
+
+// Update changes the fields of task id that are not empty
+func (l *List) Update(id int, text, status, note string) (Item, error) {
+	if status != "" && statusMarks[status] == "" {
+		return Item{}, fmt.Errorf("unknown status %q (use %s, %s or %s)", status, Pending, InProgress, Done)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.items {
+		if l.items[i].ID != id {
+			continue
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			l.items[i].Text = text
+		}
+		if status != "" {
+			l.items[i].Status = status
+		}
+		if note = strings.TrimSpace(note); note != "" {
+			l.items[i].Note = note
+		}
+		return l.items[i], nil
+	}
+	return Item{}, fmt.Errorf("no task %d", id)
+}
+
+// Remove deletes task id
+func (l *List) Remove(id int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, item := range l.items {
+		if item.ID == id {
+			l.items = append(l.items[:i], l.items[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no task %d", id)
+}
+
+// ClearDone removes finished tasks and returns how many there were
+func (l *List) ClearDone() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	kept := l.items[:0]
+	for _, item := range l.items {
+		if item.Status != Done {
+			kept = append(kept, item)
+		}
+	}
+	removed := len(l.items) - len(kept)
+	l.items = kept
+	return removed
+}
+
+// Items returns a copy of the tasks in order
+func (l *List) Items() []Item {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	items := make([]Item, len(l.items))
+	copy(items, l.items)
+	return items
+}
+
+// Restore replaces the list, e.g. with the tasks of a resumed session
+func (l *List) Restore(items []Item) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.items = append([]Item(nil), items...)
+	l.nextID = 1
+	for _, item := range items {
+		if item.ID >= l.nextID {
+			l.nextID = item.ID + 1
+		}
+	}
+}
+
+// Open counts the tasks that are not done
+func (l *List) Open() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for _, item := range l.items {
+		if item.Status != Done {
+			n++
+		}
+	}
+	return n
+}
+
+// Markdown renders the list as a checklist; empty when there are no tasks
+func (l *List) Markdown() string {
+	items := l.Items()
+	var sb strings.Builder
+	for _, item := range items {
+		sb.WriteString(fmt.Sprintf("- %s %d. %s", statusMarks[item.Status], item.ID, item.Text))
+		if item.Status == InProgress {
+			sb.WriteString(" (in progress)")
+		}
+		if item.Note != "" {
+			sb.WriteString(" — " + item.Note)
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
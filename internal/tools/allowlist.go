@@ -0,0 +1,52 @@
+package tools
+
+// CommandAllowlist is the set of programs run_command may start without
+// asking when allowlist mode is on
+type CommandAllowlist struct {
+	allowed map[string]bool
+}
+
+func NewCommandAllowlist(binaries []string) *CommandAllowlist {
+	a := &CommandAllowlist{allowed: make(map[string]bool, len(binaries))}
+	for _, binary := range binaries {
+		a.allowed[binary] = true
+	}
+	return a
+}
+
+// Allows reports whether every program command would run is on the list,
+// including wrappers like sudo and commands nested in pipelines, $(...) or
+// sh -c. Programs are compared as written, so ./go or /tmp/ls don't pass for
+// go or ls. Commands that can't be parsed are never allowed, and neither are
+// commands that redirect output anywhere but /dev/null, since that writes
+// files without asking.
+func (a *CommandAllowlist) Allows(command string) bool {
+	pipelines, err := parseShell(command, 0)
+	if err != nil {
+		return false
+	}
+
+	ran := false
+	for _, pipeline := range pipelines {
+		for _, cmd := range pipeline {
+			for _, target := range cmd.redirects {
+				if target != "/dev/null" {
+					return false
+				}
+			}
+			for _, wrapper := range cmd.wrappers {
+				if !a.allowed[wrapper] {
+					return false
+				}
+			}
+			if cmd.program == "" {
+				continue
+			}
+			if !a.allowed[cmd.program] {
+				return false
+			}
+			ran = true
+		}
+	}
+	return ran
+}
@@ -0,0 +1,417 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ApplyChangesTool creates, modifies and deletes several files in one call.
+// Every change is prepared before any file is touched, and a failure while
+// applying puts back the files already changed.
+type ApplyChangesTool struct {
+	policy   *PathPolicy
+	versions *FileVersions
+}
+
+func NewApplyChangesTool() *ApplyChangesTool {
+	return &ApplyChangesTool{}
+}
+
+// SetPathPolicy restricts which paths the tool may use
+func (t *ApplyChangesTool) SetPathPolicy(policy *PathPolicy) {
+	t.policy = policy
+}
+
+// SetFileVersions makes the tool refuse to change files that changed since
+// the model last read or wrote them
+func (t *ApplyChangesTool) SetFileVersions(versions *FileVersions) {
+	t.versions = versions
+}
+
+func (t *ApplyChangesTool) Name() string {
+	return "apply_changes"
+}
+
+func (t *ApplyChangesTool) Description() string {
+	return "Create, modify and delete several files at once, e.g. for a refactor across files. All changes are approved together and either all are applied or none are"
+}
+
+func (t *ApplyChangesTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"changes": map[string]interface{}{
+				"type":        "array",
+				"description": "The changes to make, in order",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"action": map[string]interface{}{
+							"type": "string",
+							"enum": []string{"create", "modify", "delete"},
+						},
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "File to change",
+						},
+						"diff": map[string]interface{}{
+							"type":        "string",
+							"description": "For modify: unified diff hunks (@@ -12,3 +12,4 @@ followed by ' ', '-' and '+' lines)",
+						},
+						"content": map[string]interface{}{
+							"type":        "string",
+							"description": "For create: the file contents. For modify: the whole new contents, instead of diff",
+						},
+					},
+					"required": []string{"action", "path"},
+				},
+			},
+		},
+		"required": []string{"changes"},
+	}
+}
+
+// fileChange is one change, worked out in full before anything is written
+type fileChange struct {
+	action string
+	path   string
+	before []byte // Empty for create
+	after  []byte // Nil for delete
+}
+
+// TargetPaths returns every path the call would change, so each can be
+// checked against the workspace jail
+func (t *ApplyChangesTool) TargetPaths(args map[string]interface{}) []string {
+	items, _ := args["changes"].([]interface{})
+	var paths []string
+	for _, item := range items {
+		change, _ := item.(map[string]interface{})
+		if path, ok := change["path"].(string); ok && path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// ApprovalDetails shows every change the call would make
+func (t *ApplyChangesTool) ApprovalDetails(args map[string]interface{}) (string, error) {
+	changes, err := t.plan(args)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d changes\n", len(changes)))
+	for _, change := range changes {
+		switch change.action {
+		case "create":
+			sb.WriteString(fmt.Sprintf("\n=== create %s\n%s\n", change.path, plainDiff("", string(change.after))))
+		case "modify":
+			sb.WriteString(fmt.Sprintf("\n=== modify %s\n%s\n", change.path, plainDiff(string(change.before), string(change.after))))
+		case "delete":
+			sb.WriteString(fmt.Sprintf("\n=== delete %s (%d lines)\n", change.path, strings.Count(string(change.before), "\n")))
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+func (t *ApplyChangesTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	changes, err := t.plan(args)
+	if err != nil {
+		return "", err
+	}
+
+	if err := applyFileChanges(changes); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Applied %d changes:\n", len(changes)))
+	for _, change := range changes {
+		switch change.action {
+		case "create":
+			sb.WriteString(fmt.Sprintf("- created %s\n", change.path))
+		case "modify":
+			sb.WriteString(fmt.Sprintf("- modified %s\n", change.path))
+		case "delete":
+			sb.WriteString(fmt.Sprintf("- deleted %s\n", change.path))
+		}
+		if change.after != nil {
+			t.versions.Record(change.path, change.after)
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// plan checks every change and works out the new file contents
+func (t *ApplyChangesTool) plan(args map[string]interface{}) ([]fileChange, error) {
+	items, ok := args["changes"].([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, fmt.Errorf("changes must be a non-empty list")
+	}
+
+	seen := make(map[string]bool)
+	changes := make([]fileChange, 0, len(items))
+	for n, item := range items {
+		spec, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("change %d must be an object", n+1)
+		}
+		action, _ := spec["action"].(string)
+		path, _ := spec["path"].(string)
+		if path == "" {
+			return nil, fmt.Errorf("change %d has no path", n+1)
+		}
+		if err := t.policy.Check(path); err != nil {
+			return nil, err
+		}
+		resolved, err := ResolvePath(path)
+		if err != nil {
+			return nil, fmt.Errorf("resolve path '%s': %w", path, err)
+		}
+		if seen[resolved] {
+			return nil, fmt.Errorf("%s is changed more than once; combine those changes", path)
+		}
+		seen[resolved] = true
+
+		change := fileChange{action: action, path: path}
+		existing, readErr := os.ReadFile(path)
+		switch action {
+		case "create":
+			if readErr == nil {
+				return nil, fmt.Errorf("cannot create %s: it already exists, use modify", path)
+			}
+			content, ok := spec["content"].(string)
+			if !ok {
+				return nil, fmt.Errorf("create %s needs content", path)
+			}
+			change.after = []byte(content)
+
+		case "modify", "delete":
+			if readErr != nil {
+				return nil, fmt.Errorf("cannot %s %s: %w", action, path, readErr)
+			}
+			if _, _, conflict := t.versions.Check(path); conflict {
+				return nil, &ConflictError{Path: path}
+			}
+			change.before = existing
+			if action == "delete" {
+				break
+			}
+			if content, ok := spec["content"].(string); ok {
+				change.after = []byte(content)
+			} else if diff, ok := spec["diff"].(string); ok {
+				patched, err := applyUnifiedDiff(string(existing), diff)
+				if err != nil {
+					return nil, fmt.Errorf("modify %s: %w", path, err)
+				}
+				change.after = []byte(patched)
+			} else {
+				return nil, fmt.Errorf("modify %s needs diff or content", path)
+			}
+
+		default:
+			return nil, fmt.Errorf("change %d: unknown action %q (use create, modify or delete)", n+1, action)
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// applyFileChanges makes every change or, if one fails, none of them
+func applyFileChanges(changes []fileChange) error {
+	// New contents go to temporary files next to their targets first, so
+	// the renames that apply them are unlikely to fail halfway
+	temps := make([]string, len(changes))
+	defer func() {
+		for _, temp := range temps {
+			if temp != "" {
+				os.Remove(temp)
+			}
+		}
+	}()
+	for i, change := range changes {
+		if change.after == nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(change.path), 0755); err != nil {
+			return fmt.Errorf("create directory: %w", err)
+		}
+		temp, err := os.CreateTemp(filepath.Dir(change.path), "."+filepath.Base(change.path)+".llemecode-*")
+		if err != nil {
+			return fmt.Errorf("prepare %s: %w", change.path, err)
+		}
+		temps[i] = temp.Name()
+		_, err = temp.Write(change.after)
+		if closeErr := temp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = keepMode(change.path, temp.Name())
+		}
+		if err != nil {
+			return fmt.Errorf("prepare %s: %w", change.path, err)
+		}
+	}
+
+	for i, change := range changes {
+		var err error
+		if change.after == nil {
+			err = os.Remove(change.path)
+		} else {
+			err = os.Rename(temps[i], change.path)
+			temps[i] = ""
+		}
+		if err != nil {
+			if undoErr := undoChanges(changes[:i]); undoErr != nil {
+				return fmt.Errorf("%s %s: %w (and undoing the earlier changes failed: %v)", change.action, change.path, err, undoErr)
+			}
+			return fmt.Errorf("%s %s: %w; no files were changed", change.action, change.path, err)
+		}
+	}
+	return nil
+}
+
+// undoChanges puts back files changed before a later change failed
+func undoChanges(applied []fileChange) error {
+	var failed []string
+	for i := len(applied) - 1; i >= 0; i-- {
+		change := applied[i]
+		var err error
+		if change.action == "create" {
+			err = os.Remove(change.path)
+		} else {
+			err = os.WriteFile(change.path, change.before, 0644)
+		}
+		if err != nil {
+			failed = append(failed, change.path)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("could not restore %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// keepMode gives temp the permissions of the file it replaces
+func keepMode(path, temp string) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return os.Chmod(temp, mode)
+}
+
+type diffHunk struct {
+	start    int // 1-based line in the original, from the @@ header; 0 if unknown
+	old, new []string
+}
+
+// applyUnifiedDiff applies the hunks of a unified diff to content. Hunks are
+// found by their context and removed lines, so line numbers that are a bit
+// off still apply; trailing whitespace is ignored when matching.
+func applyUnifiedDiff(content, diff string) (string, error) {
+	hunks, err := parseHunks(diff)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(content, "\n")
+	var out []string
+	pos := 0
+	for n, hunk := range hunks {
+		at := -1
+		if len(hunk.old) == 0 {
+			at = max(min(hunk.start, len(lines)), pos)
+		} else {
+			at = findLines(lines, hunk.old, pos, hunk.start-1)
+		}
+		if at < 0 {
+			return "", fmt.Errorf("hunk %d does not match the file; its context and removed lines were not found:\n%s", n+1, strings.Join(hunk.old, "\n"))
+		}
+		out = append(out, lines[pos:at]...)
+		out = append(out, hunk.new...)
+		pos = at + len(hunk.old)
+	}
+	out = append(out, lines[pos:]...)
+	return strings.Join(out, "\n"), nil
+}
+
+func parseHunks(diff string) ([]diffHunk, error) {
+	var hunks []diffHunk
+	var current *diffHunk
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			hunks = append(hunks, diffHunk{start: hunkStart(line)})
+			current = &hunks[len(hunks)-1]
+			continue
+		case current == nil && (strings.HasPrefix(line, "---") || strings.HasPrefix(line, "+++") ||
+			strings.HasPrefix(line, "diff ") || strings.HasPrefix(line, "index ")):
+			continue
+		case strings.HasPrefix(line, `\`):
+			continue // "\ No newline at end of file"
+		}
+		if current == nil {
+			// Hunks without a header are matched by content alone
+			hunks = append(hunks, diffHunk{})
+			current = &hunks[len(hunks)-1]
+		}
+		switch {
+		case line == "":
+			current.old = append(current.old, "")
+			current.new = append(current.new, "")
+		case line[0] == ' ':
+			current.old = append(current.old, line[1:])
+			current.new = append(current.new, line[1:])
+		case line[0] == '-':
+			current.old = append(current.old, line[1:])
+		case line[0] == '+':
+			current.new = append(current.new, line[1:])
+		default:
+			return nil, fmt.Errorf("invalid diff line %q: lines must start with ' ', '-' or '+'", line)
+		}
+	}
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("the diff has no hunks")
+	}
+	return hunks, nil
+}
+
+// hunkStart reads the original start line from "@@ -12,3 +12,4 @@"
+func hunkStart(header string) int {
+	fields := strings.Fields(header)
+	if len(fields) < 2 || !strings.HasPrefix(fields[1], "-") {
+		return 0
+	}
+	start, _ := strconv.Atoi(strings.SplitN(fields[1][1:], ",", 2)[0])
+	return start
+}
+
+// findLines returns where want occurs in lines at or after from, preferring
+// the occurrence closest to hint, or -1
+func findLines(lines, want []string, from, hint int) int {
+	best := -1
+	for i := from; i+len(want) <= len(lines); i++ {
+		match := true
+		for j, line := range want {
+			if strings.TrimRight(lines[i+j], " \t\r") != strings.TrimRight(line, " \t\r") {
+				match = false
+				break
+			}
+		}
+		if match && (best < 0 || abs(i-hint) < abs(best-hint)) {
+			best = i
+		}
+	}
+	return best
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,536 @@
+package tools
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxListedEntries caps how many entries list_archive shows
+	maxListedEntries = 500
+	// Limits for extract_archive when the model gives none
+	defaultMaxExtractBytes = 1 << 30
+	defaultMaxExtractFiles = 10000
+)
+
+// archiveEntry is one file, directory or link in a zip or tar archive
+type archiveEntry struct {
+	name string // As stored in the archive
+	size int64
+	mode os.FileMode
+	kind byte   // 'f' file, 'd' directory, 'l' symlink, 'h' hard link
+	link string // Link target for 'l' and 'h'
+}
+
+// walkArchive calls fn for each entry of the zip, tar or tar.gz archive at
+// archivePath. r reads a file entry's contents and is nil for other kinds.
+func walkArchive(archivePath string, fn func(entry archiveEntry, r io.Reader) error) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer f.Close()
+	buffered := bufio.NewReader(f)
+	magic, _ := buffered.Peek(4)
+
+	if bytes.HasPrefix(magic, []byte("PK\x03\x04")) || bytes.HasPrefix(magic, []byte("PK\x05\x06")) {
+		return walkZip(archivePath, fn)
+	}
+	var r io.Reader = buffered
+	if bytes.HasPrefix(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return fmt.Errorf("read gzip: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read tar (only zip, tar and tar.gz are supported): %w", err)
+		}
+		entry := archiveEntry{name: header.Name, size: header.Size, mode: header.FileInfo().Mode(), link: header.Linkname}
+		var contents io.Reader
+		switch header.Typeflag {
+		case tar.TypeDir:
+			entry.kind = 'd'
+		case tar.TypeSymlink:
+			entry.kind = 'l'
+		case tar.TypeLink:
+			entry.kind = 'h'
+		case tar.TypeReg, tar.TypeRegA:
+			entry.kind = 'f'
+			contents = tr
+		default:
+			// Devices, FIFOs and PAX metadata are never extracted
+			continue
+		}
+		if err := fn(entry, contents); err != nil {
+			return err
+		}
+	}
+}
+
+func walkZip(archivePath string, fn func(entry archiveEntry, r io.Reader) error) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("read zip: %w", err)
+	}
+	defer zr.Close()
+	for _, file := range zr.File {
+		mode := file.Mode()
+		entry := archiveEntry{name: file.Name, size: int64(file.UncompressedSize64), mode: mode, kind: 'f'}
+		switch {
+		case mode.IsDir():
+			entry.kind = 'd'
+		case mode&os.ModeSymlink != 0:
+			entry.kind = 'l'
+		}
+		if entry.kind != 'f' {
+			if entry.kind == 'l' {
+				rc, err := file.Open()
+				if err != nil {
+					return fmt.Errorf("read %s: %w", file.Name, err)
+				}
+				target, err := io.ReadAll(io.LimitReader(rc, 4096))
+				rc.Close()
+				if err != nil {
+					return fmt.Errorf("read %s: %w", file.Name, err)
+				}
+				entry.link = string(target)
+			}
+			if err := fn(entry, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("read %s: %w", file.Name, err)
+		}
+		err = fn(entry, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unsafeEntry says why an entry could land outside the extraction
+// directory, or returns "" if it is safe
+func unsafeEntry(entry archiveEntry) string {
+	name := strings.ReplaceAll(entry.name, "\\", "/")
+	if path.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "absolute path"
+	}
+	if escapes(path.Clean(name)) {
+		return "path leaves the archive"
+	}
+	switch entry.kind {
+	case 'l':
+		if path.IsAbs(entry.link) || escapes(path.Join(path.Dir(path.Clean(name)), entry.link)) {
+			return "symlink points outside the archive"
+		}
+	case 'h':
+		if path.IsAbs(entry.link) || escapes(path.Clean(entry.link)) {
+			return "hard link points outside the archive"
+		}
+	}
+	return ""
+}
+
+func escapes(name string) bool {
+	return name == ".." || strings.HasPrefix(name, "../")
+}
+
+// ListArchiveTool shows what is in an archive without extracting it
+type ListArchiveTool struct {
+	policy *PathPolicy
+}
+
+func NewListArchiveTool() *ListArchiveTool {
+	return &ListArchiveTool{}
+}
+
+// SetPathPolicy restricts which paths the tool may use
+func (t *ListArchiveTool) SetPathPolicy(policy *PathPolicy) {
+	t.policy = policy
+}
+
+func (t *ListArchiveTool) Name() string {
+	return "list_archive"
+}
+
+func (t *ListArchiveTool) Description() string {
+	return "List the files in a zip, tar or tar.gz archive with their sizes, without extracting it. Entries that would escape the extraction directory are flagged"
+}
+
+func (t *ListArchiveTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Archive to list",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+func (t *ListArchiveTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	archivePath, ok := args["path"].(string)
+	if !ok {
+		return "", fmt.Errorf("path must be a string")
+	}
+	if err := t.policy.Check(archivePath); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	var files, listed, entries int
+	var total int64
+	var unsafe []string
+	err := walkArchive(archivePath, func(entry archiveEntry, r io.Reader) error {
+		entries++
+		if reason := unsafeEntry(entry); reason != "" {
+			unsafe = append(unsafe, fmt.Sprintf("%s (%s)", entry.name, reason))
+		}
+		if entry.kind == 'f' {
+			files++
+			total += entry.size
+		}
+		if listed >= maxListedEntries {
+			return nil
+		}
+		listed++
+		switch entry.kind {
+		case 'd':
+			sb.WriteString(fmt.Sprintf("  %s/\n", strings.TrimSuffix(entry.name, "/")))
+		case 'l', 'h':
+			sb.WriteString(fmt.Sprintf("  %s -> %s\n", entry.name, entry.link))
+		default:
+			sb.WriteString(fmt.Sprintf("  %s (%s)\n", entry.name, formatBytes(entry.size)))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("%s: %s, %s uncompressed\n%s", archivePath, plural(files, "file"), formatBytes(total), sb.String())
+	if entries > listed {
+		result += fmt.Sprintf("  ... (%d more entries not shown)\n", entries-listed)
+	}
+	if len(unsafe) > 0 {
+		result += fmt.Sprintf("⚠️ %d entries would land outside the extraction directory and are never extracted:\n  %s\n", len(unsafe), strings.Join(unsafe, "\n  "))
+	}
+	return strings.TrimSuffix(result, "\n"), nil
+}
+
+// ExtractArchiveTool unpacks an archive into a directory, refusing entries
+// that would escape it, existing files and archives over the size limits
+type ExtractArchiveTool struct {
+	policy *PathPolicy
+}
+
+func NewExtractArchiveTool() *ExtractArchiveTool {
+	return &ExtractArchiveTool{}
+}
+
+// SetPathPolicy restricts which paths the tool may use
+func (t *ExtractArchiveTool) SetPathPolicy(policy *PathPolicy) {
+	t.policy = policy
+}
+
+func (t *ExtractArchiveTool) Name() string {
+	return "extract_archive"
+}
+
+func (t *ExtractArchiveTool) Description() string {
+	return "Extract a zip, tar or tar.gz archive into a directory. Use this instead of unzip or tar in run_command; existing files are never overwritten and nothing is extracted if an entry would escape the directory"
+}
+
+func (t *ExtractArchiveTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Archive to extract",
+			},
+			"destination": map[string]interface{}{
+				"type":        "string",
+				"description": "Directory to extract into; default a directory next to the archive named after it",
+			},
+			"max_bytes": map[string]interface{}{
+				"type":        "integer",
+				"description": "Largest total uncompressed size accepted; default 1 GB",
+			},
+			"max_files": map[string]interface{}{
+				"type":        "integer",
+				"description": "Most entries accepted; default 10000",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+// extraction is a checked extract_archive call
+type extraction struct {
+	archive     string
+	destination string
+	maxBytes    int64
+	files       int
+	total       int64
+	top         []string // Top-level names in the archive
+}
+
+// TargetPaths returns the archive and the destination, so both are checked
+// against the workspace jail
+func (t *ExtractArchiveTool) TargetPaths(args map[string]interface{}) []string {
+	archivePath, _ := args["path"].(string)
+	if archivePath == "" {
+		return nil
+	}
+	return []string{archivePath, extractDestination(archivePath, stringArg(args["destination"]))}
+}
+
+// ApprovalDetails shows what the archive holds and where it will go
+func (t *ExtractArchiveTool) ApprovalDetails(args map[string]interface{}) (string, error) {
+	x, err := t.plan(args)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("extract %s\n  into %s\n  %s, %s\n  top level: %s", x.archive, x.destination, plural(x.files, "file"), formatBytes(x.total), strings.Join(x.top, ", ")), nil
+}
+
+func (t *ExtractArchiveTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	x, err := t.plan(args)
+	if err != nil {
+		return "", err
+	}
+
+	_, statErr := os.Stat(x.destination)
+	if err := os.MkdirAll(x.destination, 0755); err != nil {
+		return "", fmt.Errorf("create directory: %w", err)
+	}
+	root, err := ResolvePath(x.destination)
+	if err != nil {
+		return "", err
+	}
+	var created []string
+	cleanup := func() {
+		if os.IsNotExist(statErr) {
+			os.RemoveAll(x.destination)
+			return
+		}
+		for i := len(created) - 1; i >= 0; i-- {
+			os.Remove(created[i])
+		}
+	}
+
+	var written int64
+	var files int
+	var links []string
+	err = walkArchive(x.archive, func(entry archiveEntry, r io.Reader) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		target := filepath.Join(root, filepath.FromSlash(path.Clean(entry.name)))
+		// Guard against symlinks, in the archive or already on disk, that
+		// would lead the write elsewhere
+		resolved, err := ResolvePath(target)
+		if err != nil || !isWithin(root, resolved) {
+			return fmt.Errorf("%s would be written outside %s", entry.name, x.destination)
+		}
+		dir := target
+		if entry.kind != 'd' {
+			dir = filepath.Dir(target)
+		}
+		var missing []string
+		for ; dir != root; dir = filepath.Dir(dir) {
+			if _, err := os.Stat(dir); err == nil {
+				break
+			}
+			missing = append(missing, dir)
+		}
+		for i := len(missing) - 1; i >= 0; i-- {
+			if err := os.Mkdir(missing[i], 0755); err != nil {
+				return fmt.Errorf("create directory: %w", err)
+			}
+			created = append(created, missing[i])
+		}
+		if entry.kind == 'd' {
+			return nil
+		}
+		if _, err := os.Lstat(target); err == nil {
+			return fmt.Errorf("%s already exists; extract into a new directory", target)
+		}
+
+		switch entry.kind {
+		case 'l':
+			if err := os.Symlink(entry.link, target); err != nil {
+				return fmt.Errorf("extract %s: %w", entry.name, err)
+			}
+			created = append(created, target)
+			links = append(links, target)
+			// Links extracted before may make a harmless looking target
+			// lead out, e.g. l2 -> l1/.. after l1 -> .
+			if resolved, err := ResolvePath(target); err != nil || !isWithin(root, resolved) {
+				return fmt.Errorf("%s: symlink points outside %s", entry.name, x.destination)
+			}
+			return nil
+		case 'h':
+			// The source may lead through extracted symlinks
+			var source string
+			source, err = filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(path.Clean(entry.link))))
+			if err == nil && !isWithin(root, source) {
+				return fmt.Errorf("%s: hard link points outside %s", entry.name, x.destination)
+			}
+			if err == nil {
+				err = os.Link(source, target)
+			}
+		default:
+			var n int64
+			n, err = writeEntry(target, entry.mode, r, x.maxBytes-written)
+			written += n
+			files++
+		}
+		if err != nil {
+			return fmt.Errorf("extract %s: %w", entry.name, err)
+		}
+		created = append(created, target)
+		return nil
+	})
+	// A symlink that didn't resolve yet when it was made can lead out
+	// through links extracted after it
+	for _, link := range links {
+		if err != nil {
+			break
+		}
+		if resolved, rerr := ResolvePath(link); rerr != nil || !isWithin(root, resolved) {
+			err = fmt.Errorf("%s: symlink points outside %s", link, x.destination)
+		}
+	}
+	if err != nil {
+		cleanup()
+		return "", err
+	}
+
+	return fmt.Sprintf("Extracted %s (%s) from %s into %s\nTop level: %s", plural(files, "file"), formatBytes(written), x.archive, x.destination, strings.Join(x.top, ", ")), nil
+}
+
+// plan checks every entry before anything is written
+func (t *ExtractArchiveTool) plan(args map[string]interface{}) (extraction, error) {
+	archivePath, _ := args["path"].(string)
+	if archivePath == "" {
+		return extraction{}, fmt.Errorf("path must be a string")
+	}
+	x := extraction{
+		archive:     archivePath,
+		destination: extractDestination(archivePath, stringArg(args["destination"])),
+		maxBytes:    defaultMaxExtractBytes,
+	}
+	for _, p := range []string{x.archive, x.destination} {
+		if err := t.policy.Check(p); err != nil {
+			return extraction{}, err
+		}
+	}
+	if info, err := os.Stat(x.destination); err == nil && !info.IsDir() {
+		return extraction{}, fmt.Errorf("%s exists and is not a directory", x.destination)
+	}
+	if n, ok := intArg(args["max_bytes"]); ok && n > 0 {
+		x.maxBytes = int64(n)
+	}
+	maxFiles := defaultMaxExtractFiles
+	if n, ok := intArg(args["max_files"]); ok && n > 0 {
+		maxFiles = n
+	}
+
+	top := make(map[string]bool)
+	entries := 0
+	err := walkArchive(x.archive, func(entry archiveEntry, r io.Reader) error {
+		if reason := unsafeEntry(entry); reason != "" {
+			return fmt.Errorf("refusing to extract %s: %s has an unsafe entry %s (%s)", x.archive, x.archive, entry.name, reason)
+		}
+		if entries++; entries > maxFiles {
+			return fmt.Errorf("%s has more than %d entries; raise max_files if that is expected", x.archive, maxFiles)
+		}
+		if entry.kind == 'f' {
+			x.files++
+			x.total += entry.size
+		}
+		if x.total > x.maxBytes {
+			return fmt.Errorf("%s unpacks to more than %s; raise max_bytes if that is expected", x.archive, formatBytes(x.maxBytes))
+		}
+		name := strings.SplitN(path.Clean(strings.ReplaceAll(entry.name, "\\", "/")), "/", 2)[0]
+		if name != "." {
+			top[name] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return extraction{}, err
+	}
+	for name := range top {
+		x.top = append(x.top, name)
+	}
+	sort.Strings(x.top)
+	return x, nil
+}
+
+// writeEntry writes one file, failing once more than limit bytes arrive,
+// since zip headers can understate the real size
+func writeEntry(target string, mode os.FileMode, r io.Reader, limit int64) (int64, error) {
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm()&0755|0644)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, io.LimitReader(r, limit+1))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n > limit {
+		err = fmt.Errorf("the archive holds more data than its headers say")
+	}
+	return n, err
+}
+
+// extractDestination defaults to the archive's path without its extension
+func extractDestination(archivePath, destination string) string {
+	if destination != "" {
+		return destination
+	}
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+		if strings.HasSuffix(strings.ToLower(archivePath), ext) {
+			return archivePath[:len(archivePath)-len(ext)]
+		}
+	}
+	return archivePath + ".d"
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
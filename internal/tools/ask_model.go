@@ -2,26 +2,81 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/LaPingvino/llemecode/internal/config"
 	"github.com/LaPingvino/llemecode/internal/ollama"
 )
 
+const (
+	// Limits for a delegated question when the config gives none
+	defaultAskTimeout      = 5 * time.Minute
+	defaultAskResultTokens = 2000
+)
+
+// ModelProgress shows a delegated model's answer while it streams in, so
+// the user can see the delegation making progress
+type ModelProgress interface {
+	Start(id, model, question string)
+	Chunk(id, text string)
+	End(id string, err error)
+}
+
 // AskModelTool allows the LLM to invoke other specialized models
 type AskModelTool struct {
 	client      *ollama.Client
 	modelName   string
 	description string
+
+	systemPrompt    string
+	options         map[string]interface{}
+	timeout         time.Duration
+	maxResultTokens int // Longer answers are summarized to fit
+	progress        ModelProgress
+	readFile        func(ctx context.Context, path string) (string, error)
 }
 
 func NewAskModelTool(client *ollama.Client, modelName, description string) *AskModelTool {
 	return &AskModelTool{
-		client:      client,
-		modelName:   modelName,
-		description: description,
+		client:          client,
+		modelName:       modelName,
+		description:     description,
+		timeout:         defaultAskTimeout,
+		maxResultTokens: defaultAskResultTokens,
 	}
 }
 
+// NewModelAsTool makes the ask_ tool for a configured model, with its
+// system prompt, sampling options and limits
+func NewModelAsTool(client *ollama.Client, mat config.ModelAsTool) *AskModelTool {
+	t := NewAskModelTool(client, mat.ModelName, mat.Description)
+	t.systemPrompt = mat.SystemPrompt
+	if mat.TimeoutSeconds > 0 {
+		t.timeout = time.Duration(mat.TimeoutSeconds) * time.Second
+	}
+	if mat.MaxResultTokens > 0 {
+		t.maxResultTokens = mat.MaxResultTokens
+	}
+	if mat.Temperature != nil || mat.MaxTokens > 0 {
+		t.options = make(map[string]interface{})
+		if mat.Temperature != nil {
+			t.options["temperature"] = *mat.Temperature
+		}
+		if mat.MaxTokens > 0 {
+			t.options["num_predict"] = mat.MaxTokens
+		}
+	}
+	return t
+}
+
+// SetProgress streams answers to progress; nil asks without streaming
+func (t *AskModelTool) SetProgress(progress ModelProgress) {
+	t.progress = progress
+}
+
 func (t *AskModelTool) Name() string {
 	return fmt.Sprintf("ask_%s", t.modelName)
 }
@@ -41,6 +96,20 @@ func (t *AskModelTool) Parameters() map[string]interface{} {
 				"type":        "string",
 				"description": "The question or prompt to send to the model",
 			},
+			"context": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"none", "recent", "brief"},
+				"description": "What the model gets to know about this conversation: nothing (default), the recent messages, or a brief written from them",
+			},
+			"files": map[string]interface{}{
+				"type":        "array",
+				"description": "Files to include for the model to look at",
+				"items":       map[string]interface{}{"type": "string"},
+			},
+			"context_tokens": map[string]interface{}{
+				"type":        "integer",
+				"description": "Budget for context and files together; default 1500",
+			},
 		},
 		"required": []string{"question"},
 	}
@@ -52,16 +121,75 @@ func (t *AskModelTool) Execute(ctx context.Context, args map[string]interface{})
 		return "", fmt.Errorf("question must be a string")
 	}
 
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	var messages []ollama.Message
+	if t.systemPrompt != "" {
+		messages = append(messages, ollama.Message{Role: "system", Content: t.systemPrompt})
+	}
+	handoff, err := t.handoff(ctx, args)
+	if err != nil {
+		return "", err
+	}
+	messages = append(messages, ollama.Message{Role: "user", Content: handoff + question})
+
+	req := ollama.ChatRequest{
+		Model:    t.modelName,
+		Messages: messages,
+		Stream:   false,
+		Options:  t.options,
+	}
+	var resp *ollama.ChatResponse
+	if t.progress != nil {
+		id := fmt.Sprintf("ask_%d", time.Now().UnixNano())
+		t.progress.Start(id, t.modelName, question)
+		resp, err = t.client.ChatStream(ctx, req, func(content string) {
+			t.progress.Chunk(id, content)
+		})
+		t.progress.End(id, err)
+	} else {
+		resp, err = t.client.Chat(ctx, req)
+	}
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("ask %s: no answer within %s; ask a narrower question or raise timeout_seconds", t.modelName, t.timeout)
+		}
+		return "", fmt.Errorf("ask %s: %w", t.modelName, err)
+	}
+
+	answer := resp.Message.Content
+	note := ""
+	if tokens := len(answer) / 4; tokens > t.maxResultTokens {
+		answer, note = t.shorten(ctx, answer, tokens)
+	}
+	return fmt.Sprintf("%s\n\n[%s answered in %s%s]", answer, t.modelName, time.Since(start).Round(100*time.Millisecond), note), nil
+}
+
+// shorten has the model summarize an answer that is over maxResultTokens,
+// and cuts it if that fails, so one delegated question can't flood the
+// caller's context
+func (t *AskModelTool) shorten(ctx context.Context, answer string, tokens int) (string, string) {
 	resp, err := t.client.Chat(ctx, ollama.ChatRequest{
 		Model: t.modelName,
 		Messages: []ollama.Message{
-			{Role: "user", Content: question},
+			{Role: "system", Content: fmt.Sprintf("Summarize the answer below in at most %d tokens. Keep code, commands, numbers and conclusions exactly; drop explanations first.", t.maxResultTokens)},
+			{Role: "user", Content: answer},
 		},
-		Stream: false,
+		Stream:  false,
+		Options: map[string]interface{}{"num_predict": t.maxResultTokens},
 	})
-	if err != nil {
-		return "", fmt.Errorf("ask %s: %w", t.modelName, err)
+	if err == nil && resp.Message.Content != "" && len(resp.Message.Content)/4 <= t.maxResultTokens {
+		return resp.Message.Content, fmt.Sprintf(", summarized from ~%d tokens", tokens)
 	}
+	return truncateUTF8(answer, t.maxResultTokens*4) + "\n...", fmt.Sprintf(", cut from ~%d tokens", tokens)
+}
 
-	return resp.Message.Content, nil
+// truncateUTF8 cuts s to at most max bytes without splitting a character
+func truncateUTF8(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return strings.ToValidUTF8(s[:max], "")
 }
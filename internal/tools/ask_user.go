@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// UserAsker puts a clarifying question to the user and waits for the answer.
+// An empty answer means the user skipped the question.
+type UserAsker interface {
+	AskUser(ctx context.Context, question string, options []string) (string, error)
+}
+
+// AskUserTool lets the model ask the user a question in the middle of a
+// turn instead of guessing; the turn carries on with the answer
+type AskUserTool struct {
+	mu    sync.Mutex
+	asker UserAsker
+}
+
+func NewAskUserTool() *AskUserTool {
+	return &AskUserTool{}
+}
+
+// SetAsker sets who answers; without one the model is told to assume
+func (t *AskUserTool) SetAsker(asker UserAsker) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.asker = asker
+}
+
+func (t *AskUserTool) Name() string {
+	return "ask_user"
+}
+
+func (t *AskUserTool) Description() string {
+	return "Ask the user a clarifying question and wait for the answer. Use it when the request is ambiguous or a decision only the user can make would change the result; don't use it for things you can find out with other tools"
+}
+
+func (t *AskUserTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"question": map[string]interface{}{
+				"type":        "string",
+				"description": "One short, specific question",
+			},
+			"options": map[string]interface{}{
+				"type":        "array",
+				"description": "Answers to choose from, e.g. [\"npm\", \"pnpm\", \"yarn\"], shown as a list the user picks from (optional); they may still answer freely",
+				"items":       map[string]interface{}{"type": "string"},
+			},
+			"assumption": map[string]interface{}{
+				"type":        "string",
+				"description": "What you would do if you had to guess, shown to the user",
+			},
+		},
+		"required": []string{"question"},
+	}
+}
+
+func (t *AskUserTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	question := strings.TrimSpace(stringArg(args["question"]))
+	if question == "" {
+		return "", fmt.Errorf("question is required")
+	}
+	if assumption := strings.TrimSpace(stringArg(args["assumption"])); assumption != "" {
+		question += "\n(If you don't say otherwise: " + assumption + ")"
+	}
+	var options []string
+	if list, ok := args["options"].([]interface{}); ok {
+		for _, option := range list {
+			if s, ok := option.(string); ok && strings.TrimSpace(s) != "" {
+				options = append(options, strings.TrimSpace(s))
+			}
+		}
+	}
+
+	t.mu.Lock()
+	asker := t.asker
+	t.mu.Unlock()
+	if asker == nil {
+		return "No one can answer questions here. Go ahead with the most reasonable assumption and say which one you made.", nil
+	}
+
+	answer, err := asker.AskUser(ctx, question, options)
+	if err != nil {
+		return "", fmt.Errorf("ask user: %w", err)
+	}
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return "The user skipped the question. Go ahead with your best judgement and say what you assumed.", nil
+	}
+	for _, option := range options {
+		if answer == option {
+			return "The user chose: " + answer, nil
+		}
+	}
+	return "The user answered: " + answer, nil
+}
@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"context"
+	"sync"
+)
+
+type attachmentsKey struct{}
+
+// Attachments collects images that tools produce during a call, so the agent
+// can send them to vision-capable models next to the text result
+type Attachments struct {
+	mu     sync.Mutex
+	images [][]byte
+}
+
+// WithAttachments returns a context that tools can attach images to
+func WithAttachments(ctx context.Context) (context.Context, *Attachments) {
+	att := &Attachments{}
+	return context.WithValue(ctx, attachmentsKey{}, att), att
+}
+
+// AttachImage adds an image to the call's result. It reports false when the
+// caller doesn't collect attachments.
+func AttachImage(ctx context.Context, data []byte) bool {
+	att, ok := ctx.Value(attachmentsKey{}).(*Attachments)
+	if !ok {
+		return false
+	}
+	att.mu.Lock()
+	defer att.mu.Unlock()
+	att.images = append(att.images, data)
+	return true
+}
+
+// Images returns the attached images
+func (a *Attachments) Images() [][]byte {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.images
+}
@@ -0,0 +1,937 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// maxShellDepth limits how deeply sh -c, eval and $(...) are unwrapped
+const maxShellDepth = 8
+
+// CommandBlocklist refuses shell commands matching any of its rules. Rules
+// are matched against the parsed command, not the raw string, so quoting,
+// wrappers like sudo and chained or nested commands can't hide a match and
+// "echo rm -rf /" isn't mistaken for the real thing.
+//
+// Rule syntax:
+//
+//	rm -rf /         binary, flags that must all be set, args that must all appear
+//	dd of=/dev/*     args are globs; "key=" matches any value
+//	curl | sh        a pipeline stage feeding the next, which includes
+//	                 sh <(curl ...) and sh -c "$(curl ...)"
+//	> /dev/sd*       output redirected to a matching target
+//	re:^git push .*--force
+//	                 regex over each normalized command ("name arg1 arg2")
+//
+// A rule naming sh matches every shell and eval. Paths only known when the command
+// runs, like $DIR, ${HOME:?}, $(...) or ~user, match / and ~ in rules,
+// since nothing says they aren't one of them.
+//
+// Anything containing other shell syntax (like the fork bomb) is matched
+// literally against the command with whitespace removed.
+type CommandBlocklist struct {
+	rules []commandRule
+}
+
+type commandRule struct {
+	source   string
+	regex    *regexp.Regexp
+	literal  string
+	redirect string
+	stages   []commandPattern
+}
+
+type commandPattern struct {
+	binary string
+	flags  []string
+	args   []string
+}
+
+// shellCommand is one simple command after unwrapping
+type shellCommand struct {
+	name      string // Base name of the program
+	program   string // The program as written, possibly with a path
+	flags     map[string]bool
+	args      []string
+	words     []string // Flags and args in their original order, for regex rules
+	redirects []string
+	wrappers  []string // Programs like sudo or env that were stripped, as written
+}
+
+func NewCommandBlocklist(rules []string) (*CommandBlocklist, error) {
+	b := &CommandBlocklist{}
+	for _, source := range rules {
+		rule, err := parseCommandRule(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blocked command rule %q: %w", source, err)
+		}
+		b.rules = append(b.rules, rule)
+	}
+	return b, nil
+}
+
+// Check returns an error if command matches a rule. Commands that can't be
+// parsed are refused too, since they can't be checked.
+func (b *CommandBlocklist) Check(command string) error {
+	if len(b.rules) == 0 {
+		return nil
+	}
+
+	pipelines, err := parseShell(command, 0)
+	if err != nil {
+		return fmt.Errorf("blocked: could not parse command: %w", err)
+	}
+
+	compact := removeSpace(command)
+	for _, rule := range b.rules {
+		if ok, unknown := rule.matches(compact, pipelines); ok {
+			if unknown != "" {
+				return fmt.Errorf("blocked command pattern detected: %s (%s is only known when the command runs; use the literal path)", rule.source, unknown)
+			}
+			return fmt.Errorf("blocked command pattern detected: %s", rule.source)
+		}
+	}
+	return nil
+}
+
+func parseCommandRule(source string) (commandRule, error) {
+	rule := commandRule{source: source}
+	trimmed := strings.TrimSpace(source)
+
+	switch {
+	case strings.HasPrefix(trimmed, "re:"):
+		re, err := regexp.Compile(strings.TrimPrefix(trimmed, "re:"))
+		if err != nil {
+			return rule, err
+		}
+		rule.regex = re
+		return rule, nil
+	case strings.ContainsAny(trimmed, ";&(){}`"):
+		rule.literal = removeSpace(trimmed)
+		return rule, nil
+	case strings.HasPrefix(trimmed, ">"):
+		rule.redirect = normalizeArg(strings.TrimSpace(strings.TrimLeft(trimmed, ">")))
+		return rule, nil
+	}
+
+	for _, stage := range strings.Split(trimmed, "|") {
+		tokens, _, err := lexShell(stage)
+		if err != nil {
+			rule.literal = removeSpace(trimmed)
+			return rule, nil
+		}
+		var words []string
+		for _, tok := range tokens {
+			if !tok.op {
+				words = append(words, tok.text)
+			}
+		}
+		if len(words) == 0 {
+			return rule, fmt.Errorf("empty command")
+		}
+
+		pattern := commandPattern{binary: words[0]}
+		for _, word := range words[1:] {
+			if flags, ok := splitFlags(pattern.binary, word); ok {
+				pattern.flags = append(pattern.flags, flags...)
+			} else {
+				pattern.args = append(pattern.args, normalizeArg(word))
+			}
+		}
+		rule.stages = append(rule.stages, pattern)
+	}
+	return rule, nil
+}
+
+// matches reports whether the rule matches, and the argument only known at
+// run time it matched if it took one
+func (r commandRule) matches(compact string, pipelines [][]shellCommand) (bool, string) {
+	if r.literal != "" {
+		return strings.Contains(compact, r.literal), ""
+	}
+
+	for _, pipeline := range pipelines {
+		for i, cmd := range pipeline {
+			switch {
+			case r.regex != nil:
+				if r.regex.MatchString(cmd.String()) {
+					return true, ""
+				}
+			case r.redirect != "":
+				for _, target := range cmd.redirects {
+					if matchArg(r.redirect, target) {
+						return true, ""
+					}
+				}
+			default:
+				if i+len(r.stages) > len(pipeline) {
+					continue
+				}
+				if ok, unknown := r.matchStages(pipeline[i:]); ok {
+					return true, unknown
+				}
+			}
+		}
+	}
+	return false, ""
+}
+
+func (r commandRule) matchStages(cmds []shellCommand) (bool, string) {
+	var unknown string
+	for i, stage := range r.stages {
+		ok, arg := stage.matches(cmds[i])
+		if !ok {
+			return false, ""
+		}
+		if arg != "" {
+			unknown = arg
+		}
+	}
+	return true, unknown
+}
+
+func (p commandPattern) matches(cmd shellCommand) (bool, string) {
+	if !matchBinary(p.binary, cmd.name) {
+		return false, ""
+	}
+	for _, flag := range p.flags {
+		if !cmd.flags[flag] {
+			return false, ""
+		}
+	}
+	var unknown string
+	for _, want := range p.args {
+		found := false
+		for _, arg := range cmd.args {
+			if matchArg(want, arg) {
+				found = true
+				break
+			}
+		}
+		if !found && (want == "/" || want == "~") {
+			for _, arg := range cmd.args {
+				if runtimeTarget(arg) {
+					found, unknown = true, arg
+					break
+				}
+			}
+		}
+		if !found {
+			return false, ""
+		}
+	}
+	return true, unknown
+}
+
+func (c shellCommand) String() string {
+	parts := append([]string{c.name}, c.words...)
+	for _, target := range c.redirects {
+		parts = append(parts, ">", target)
+	}
+	return strings.Join(parts, " ")
+}
+
+// matchBinary matches a program name against a glob, also accepting
+// variants like mkfs.ext4 for mkfs and any shell for sh
+func matchBinary(pattern, name string) bool {
+	if pattern == name || strings.HasPrefix(name, pattern+".") || pattern == "sh" && (shells[name] || name == "eval") {
+		return true
+	}
+	matched, err := filepath.Match(pattern, name)
+	return err == nil && matched
+}
+
+func matchArg(pattern, arg string) bool {
+	if strings.HasSuffix(pattern, "=") {
+		return strings.HasPrefix(arg, pattern)
+	}
+	if pattern == arg {
+		return true
+	}
+	matched, err := filepath.Match(pattern, arg)
+	return err == nil && matched
+}
+
+// flagAliases maps long and alternative flags to the short form rules use
+var flagAliases = map[string]map[string]string{
+	"rm":    {"R": "r", "recursive": "r", "force": "f"},
+	"chmod": {"recursive": "R"},
+	"chown": {"recursive": "R"},
+}
+
+// splitFlags expands "-rf" into r and f and "--force" into force, mapped
+// through the binary's aliases
+func splitFlags(binary, word string) ([]string, bool) {
+	if len(word) < 2 || word[0] != '-' || word == "--" {
+		return nil, false
+	}
+
+	aliases := flagAliases[filepath.Base(binary)]
+	alias := func(flag string) string {
+		if a, ok := aliases[flag]; ok {
+			return a
+		}
+		return flag
+	}
+
+	if strings.HasPrefix(word, "--") {
+		name := strings.SplitN(word[2:], "=", 2)[0]
+		return []string{alias(name)}, true
+	}
+
+	var flags []string
+	for _, c := range word[1:] {
+		flags = append(flags, alias(string(c)))
+	}
+	return flags, true
+}
+
+// normalizeArg makes equivalent paths compare equal: $HOME and the home
+// directory become ~, paths are cleaned and a trailing /* is dropped since
+// it covers the same files as its directory
+func normalizeArg(arg string) string {
+	for _, prefix := range []string{"${HOME}", "$HOME"} {
+		if arg == prefix || strings.HasPrefix(arg, prefix+"/") {
+			arg = "~" + strings.TrimPrefix(arg, prefix)
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil && home != "/" {
+		if arg == home || strings.HasPrefix(arg, home+"/") {
+			arg = "~" + strings.TrimPrefix(arg, home)
+		}
+	}
+
+	if !strings.HasPrefix(arg, "/") && !strings.HasPrefix(arg, "~") {
+		return arg
+	}
+	for strings.HasSuffix(arg, "/*") || strings.HasSuffix(arg, "/.*") {
+		arg = strings.TrimSuffix(strings.TrimSuffix(arg, "/*"), "/.*")
+	}
+	if arg == "" {
+		return "/"
+	}
+	return path.Clean(arg)
+}
+
+// runtimeTarget reports whether arg is a path that is only known when the
+// command runs and could be / or a home directory: an expansion or ~user,
+// followed by nothing but slashes, dots and globs
+func runtimeTarget(arg string) bool {
+	r := []rune(arg)
+	end := -1
+	switch {
+	case strings.HasPrefix(arg, "$("):
+		end = matchParen(r, 1)
+	case strings.HasPrefix(arg, "`"):
+		end = indexRune(r, 1, '`')
+	case strings.HasPrefix(arg, "${"):
+		end = indexRune(r, 2, '}')
+	case strings.HasPrefix(arg, "$") && len(r) > 1:
+		end = 1
+		if !strings.ContainsRune("@*#?$!-0123456789", r[1]) {
+			for end+1 < len(r) && (r[end+1] == '_' || unicode.IsLetter(r[end+1]) || unicode.IsDigit(r[end+1])) {
+				end++
+			}
+		}
+	case strings.HasPrefix(arg, "~") && len(r) > 1 && r[1] != '/':
+		end = 0
+		for end+1 < len(r) && r[end+1] != '/' {
+			end++
+		}
+	}
+	if end < 0 {
+		return false
+	}
+	return strings.Trim(string(r[end+1:]), "/.*") == ""
+}
+
+func removeSpace(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
+
+// parseShell splits a command line into pipelines of simple commands,
+// including those nested in $(...), backticks, sh -c and eval
+func parseShell(src string, depth int) ([][]shellCommand, error) {
+	if depth > maxShellDepth {
+		return nil, fmt.Errorf("commands nested too deeply")
+	}
+
+	tokens, subs, err := lexShell(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var pipelines [][]shellCommand
+	var pipeline []shellCommand
+	var words, redirects []string
+	redirectNext, skipNext := false, false
+
+	endCommand := func() error {
+		cmds, nested, err := unwrapCommand(words, redirects, depth)
+		if err != nil {
+			return err
+		}
+		pipeline = append(pipeline, cmds...)
+		pipelines = append(pipelines, nested...)
+		words, redirects = nil, nil
+		return nil
+	}
+
+	for _, tok := range tokens {
+		if !tok.op {
+			switch {
+			case redirectNext:
+				redirects = append(redirects, normalizeArg(tok.text))
+			case skipNext:
+			default:
+				words = append(words, tok.text)
+			}
+			redirectNext, skipNext = false, false
+			continue
+		}
+
+		switch tok.text {
+		case ">":
+			redirectNext = true
+		case ">&", "<", "<<", "<<<":
+			skipNext = true
+		case "|", "|&":
+			if err := endCommand(); err != nil {
+				return nil, err
+			}
+		default:
+			if err := endCommand(); err != nil {
+				return nil, err
+			}
+			if len(pipeline) > 0 {
+				pipelines = append(pipelines, pipeline)
+			}
+			pipeline = nil
+		}
+	}
+	if err := endCommand(); err != nil {
+		return nil, err
+	}
+	if len(pipeline) > 0 {
+		pipelines = append(pipelines, pipeline)
+	}
+
+	for _, sub := range subs {
+		nested, err := parseShell(sub, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		pipelines = append(pipelines, nested...)
+	}
+
+	return pipelines, nil
+}
+
+// shellKeywords can precede a command without being the command
+var shellKeywords = map[string]bool{
+	"!": true, "{": true, "}": true, "if": true, "then": true, "else": true,
+	"elif": true, "fi": true, "while": true, "until": true, "do": true,
+	"done": true, "time": true,
+}
+
+// wrapperFlagArgs lists the flags of wrapper programs that take a value
+var wrapperFlagArgs = map[string]map[string]bool{
+	"sudo":    {"-u": true, "-g": true, "-C": true, "-D": true, "-U": true, "-r": true, "-t": true, "-p": true},
+	"doas":    {"-u": true, "-C": true},
+	"env":     {"-u": true, "-C": true, "-S": true},
+	"nice":    {"-n": true},
+	"ionice":  {"-c": true, "-n": true, "-p": true},
+	"nohup":   {},
+	"command": {},
+	"builtin": {},
+	"exec":    {"-a": true},
+	"stdbuf":  {"-i": true, "-o": true, "-e": true},
+	"timeout": {"-s": true, "-k": true},
+	"xargs":   {"-I": true, "-n": true, "-P": true, "-d": true, "-L": true, "-s": true, "-E": true, "-a": true},
+	"busybox": {},
+}
+
+var shells = map[string]bool{"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true, "fish": true}
+
+// runsCode reports whether a program runs code given as an argument, so
+// $(...) passed to it runs whatever that command prints
+func runsCode(name string) bool {
+	switch name {
+	case "eval", "perl", "ruby", "node", "php":
+		return true
+	}
+	return shells[name] || strings.HasPrefix(name, "python")
+}
+
+// wholeSubstitution returns the command inside a word that is nothing but
+// $(...), `...` or <(...)
+func wholeSubstitution(word string) (string, bool) {
+	r := []rune(word)
+	switch {
+	case strings.HasPrefix(word, "$(") || strings.HasPrefix(word, "<("):
+		if matchParen(r, 1) == len(r)-1 {
+			return string(r[2 : len(r)-1]), true
+		}
+	case strings.HasPrefix(word, "`") && len(r) > 1:
+		if indexRune(r, 1, '`') == len(r)-1 {
+			return string(r[1 : len(r)-1]), true
+		}
+	}
+	return "", false
+}
+
+// feeding parses src as pipelines whose output cmd reads, as if piped into
+// it, so curl | sh rules see sh <(curl ...) too
+func feeding(src string, cmd shellCommand, depth int) ([][]shellCommand, error) {
+	inner, err := parseShell(src, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	for i, pipeline := range inner {
+		inner[i] = append(append([]shellCommand(nil), pipeline...), cmd)
+	}
+	return inner, nil
+}
+
+// unwrapCommand strips assignments, keywords and wrappers like sudo from a
+// simple command. Scripts passed to a shell or eval are parsed and returned
+// as nested pipelines, and find -exec commands are split out.
+func unwrapCommand(words, redirects []string, depth int) ([]shellCommand, [][]shellCommand, error) {
+	var wrappers []string
+unwrap:
+	for len(words) > 0 {
+		name := filepath.Base(words[0])
+		switch {
+		case shellKeywords[words[0]] || isAssignment(words[0]):
+			words = words[1:]
+		case name == "eval":
+			script := strings.Join(words[1:], " ")
+			nested, err := parseShell(script, depth+1)
+			if sub, ok := wholeSubstitution(script); ok && err == nil {
+				var fed [][]shellCommand
+				fed, err = feeding(sub, shellCommand{name: name, program: words[0], flags: map[string]bool{}}, depth)
+				nested = append(nested, fed...)
+			}
+			return nil, withWrappers(nested, wrappers), err
+		case shells[name]:
+			if script, ok := shellScript(words[1:]); ok {
+				nested, err := parseShell(script, depth+1)
+				if sub, ok := wholeSubstitution(script); ok && err == nil {
+					var fed [][]shellCommand
+					fed, err = feeding(sub, shellCommand{name: name, program: words[0], flags: map[string]bool{}}, depth)
+					nested = append(nested, fed...)
+				}
+				return nil, withWrappers(nested, wrappers), err
+			}
+			break unwrap
+		case wrapperFlagArgs[name] != nil:
+			wrappers = append(wrappers, words[0])
+			words = skipWrapper(name, words[1:])
+		default:
+			break unwrap
+		}
+	}
+
+	if len(words) == 0 {
+		if len(redirects) == 0 && len(wrappers) == 0 {
+			return nil, nil, nil
+		}
+		return []shellCommand{{redirects: redirects, wrappers: wrappers}}, nil, nil
+	}
+
+	cmd := shellCommand{
+		name:      filepath.Base(words[0]),
+		program:   words[0],
+		flags:     make(map[string]bool),
+		redirects: redirects,
+		wrappers:  wrappers,
+	}
+	var extra []shellCommand
+	var nested [][]shellCommand
+	var fed []string // Commands whose output cmd reads or runs
+	for i := 1; i < len(words); i++ {
+		word := words[i]
+		if sub, ok := wholeSubstitution(word); ok && (strings.HasPrefix(word, "<(") || runsCode(cmd.name)) {
+			fed = append(fed, sub)
+		}
+		if cmd.name == "find" && findExecFlags[word] {
+			end := i + 1
+			for end < len(words) && words[end] != ";" && words[end] != "+" {
+				end++
+			}
+			cmds, inner, err := unwrapCommand(words[i+1:end], nil, depth)
+			if err != nil {
+				return nil, nil, err
+			}
+			extra = append(extra, cmds...)
+			nested = append(nested, inner...)
+			i = end
+			continue
+		}
+		if flags, ok := splitFlags(cmd.name, word); ok {
+			for _, flag := range flags {
+				cmd.flags[flag] = true
+			}
+			cmd.words = append(cmd.words, word)
+			continue
+		}
+		arg := normalizeArg(word)
+		cmd.args = append(cmd.args, arg)
+		cmd.words = append(cmd.words, arg)
+	}
+
+	for _, src := range fed {
+		pipelines, err := feeding(src, cmd, depth)
+		if err != nil {
+			return nil, nil, err
+		}
+		nested = append(nested, pipelines...)
+	}
+
+	return append([]shellCommand{cmd}, extra...), nested, nil
+}
+
+// withWrappers records wrappers on commands nested inside them, so
+// sudo bash -c '...' still counts as running sudo
+func withWrappers(pipelines [][]shellCommand, wrappers []string) [][]shellCommand {
+	if len(wrappers) == 0 {
+		return pipelines
+	}
+	for _, pipeline := range pipelines {
+		for i := range pipeline {
+			pipeline[i].wrappers = append(append([]string(nil), wrappers...), pipeline[i].wrappers...)
+		}
+	}
+	return pipelines
+}
+
+var findExecFlags = map[string]bool{"-exec": true, "-execdir": true, "-ok": true, "-okdir": true}
+
+// skipWrapper drops a wrapper's own flags and arguments, returning the
+// command it runs
+func skipWrapper(name string, words []string) []string {
+	takesValue := wrapperFlagArgs[name]
+	for len(words) > 0 {
+		word := words[0]
+		switch {
+		case word == "--":
+			return words[1:]
+		case strings.HasPrefix(word, "-") && len(word) > 1:
+			words = words[1:]
+			if takesValue[word] && len(words) > 0 {
+				words = words[1:]
+			}
+		case name == "env" && isAssignment(word):
+			words = words[1:]
+		case name == "timeout":
+			// The first plain argument is the duration
+			return words[1:]
+		default:
+			return words
+		}
+	}
+	return words
+}
+
+// shellScript returns the script passed to a shell with -c
+func shellScript(args []string) (string, bool) {
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "--") {
+			continue
+		}
+		if strings.Contains(arg[1:], "c") {
+			for _, rest := range args[i+1:] {
+				if !strings.HasPrefix(rest, "-") {
+					return rest, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+func isAssignment(word string) bool {
+	eq := strings.Index(word, "=")
+	if eq <= 0 {
+		return false
+	}
+	for i, c := range word[:eq] {
+		if c != '_' && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+type shellToken struct {
+	text string
+	op   bool // Operator such as | or >, rather than a word
+}
+
+// lexShell splits src into words and operators following sh quoting rules.
+// The contents of $(...) and backticks are returned separately so they can
+// be checked as commands of their own. Heredoc bodies and comments are
+// skipped.
+func lexShell(src string) ([]shellToken, []string, error) {
+	r := []rune(src)
+	var tokens []shellToken
+	var subs []string
+	var word strings.Builder
+	inWord := false
+
+	var heredocs []string // Delimiters whose bodies start at the next newline
+	heredocNext := false
+
+	flush := func() {
+		if !inWord {
+			return
+		}
+		if heredocNext {
+			heredocs = append(heredocs, word.String())
+			heredocNext = false
+		}
+		tokens = append(tokens, shellToken{text: word.String()})
+		word.Reset()
+		inWord = false
+	}
+	op := func(text string) {
+		flush()
+		tokens = append(tokens, shellToken{text: text, op: true})
+	}
+	// substitution records $(...), <(...), >(...) or `...` starting at i and returns its end
+	substitution := func(i int) (int, error) {
+		var end int
+		var body string
+		if r[i] == '`' {
+			end = indexRune(r, i+1, '`')
+			if end < 0 {
+				return 0, fmt.Errorf("unterminated backquote")
+			}
+			body = string(r[i+1 : end])
+		} else {
+			end = matchParen(r, i+1)
+			if end < 0 {
+				return 0, fmt.Errorf("unterminated %c(", r[i])
+			}
+			body = string(r[i+2 : end])
+		}
+		subs = append(subs, body)
+		word.WriteString(string(r[i : end+1]))
+		return end, nil
+	}
+
+	for i := 0; i < len(r); i++ {
+		c := r[i]
+		next := rune(0)
+		if i+1 < len(r) {
+			next = r[i+1]
+		}
+
+		switch {
+		case c == '\\':
+			if next == '\n' {
+				i++
+				continue
+			}
+			if next != 0 {
+				word.WriteRune(next)
+				i++
+			}
+			inWord = true
+		case c == '\'':
+			end := indexRune(r, i+1, '\'')
+			if end < 0 {
+				return nil, nil, fmt.Errorf("unterminated single quote")
+			}
+			word.WriteString(string(r[i+1 : end]))
+			i = end
+			inWord = true
+		case c == '"':
+			j := i + 1
+			for ; j < len(r) && r[j] != '"'; j++ {
+				switch {
+				case r[j] == '\\' && j+1 < len(r) && strings.ContainsRune("\"\\$`\n", r[j+1]):
+					word.WriteRune(r[j+1])
+					j++
+				case r[j] == '`' || r[j] == '$' && j+1 < len(r) && r[j+1] == '(':
+					end, err := substitution(j)
+					if err != nil {
+						return nil, nil, err
+					}
+					j = end
+				default:
+					word.WriteRune(r[j])
+				}
+			}
+			if j >= len(r) {
+				return nil, nil, fmt.Errorf("unterminated double quote")
+			}
+			i = j
+			inWord = true
+		case c == '`' || c == '$' && next == '(' || (c == '<' || c == '>') && next == '(' && !inWord:
+			// <(...) and >(...) run their command like $(...)
+			end, err := substitution(i)
+			if err != nil {
+				return nil, nil, err
+			}
+			i = end
+			inWord = true
+		case c == '#' && !inWord:
+			for i+1 < len(r) && r[i+1] != '\n' {
+				i++
+			}
+		case c == ' ' || c == '\t' || c == '\r':
+			flush()
+		case c == '\n':
+			op("\n")
+			for _, delim := range heredocs {
+				i = skipHeredoc(r, i, delim)
+			}
+			heredocs = nil
+		case c == ';':
+			op(";")
+			if next == ';' {
+				i++
+			}
+		case c == '&':
+			switch next {
+			case '&':
+				op("&&")
+				i++
+			case '>':
+				op(">")
+				i++
+				if i+1 < len(r) && r[i+1] == '>' {
+					i++
+				}
+			default:
+				op("&")
+			}
+		case c == '|':
+			switch next {
+			case '|':
+				op("||")
+				i++
+			case '&':
+				op("|&")
+				i++
+			default:
+				op("|")
+			}
+		case c == '(' || c == ')':
+			op(string(c))
+		case c == '>':
+			// A file descriptor number before > isn't part of a word
+			if inWord && isDigits(word.String()) {
+				word.Reset()
+				inWord = false
+			}
+			if next == '>' || next == '|' {
+				i++
+			}
+			if i+1 < len(r) && r[i+1] == '&' {
+				op(">&")
+				i++
+			} else {
+				op(">")
+			}
+		case c == '<':
+			if inWord && isDigits(word.String()) {
+				word.Reset()
+				inWord = false
+			}
+			switch {
+			case next == '<' && i+2 < len(r) && r[i+2] == '<':
+				op("<<<")
+				i += 2
+			case next == '<':
+				op("<<")
+				i++
+				if i+1 < len(r) && r[i+1] == '-' {
+					i++
+				}
+				heredocNext = true
+			case next == '&' || next == '>':
+				op("<")
+				i++
+			default:
+				op("<")
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	flush()
+
+	return tokens, subs, nil
+}
+
+// skipHeredoc returns the index of the newline ending the heredoc body that
+// starts after the newline at i, or the end of input if it isn't closed
+func skipHeredoc(r []rune, i int, delim string) int {
+	for i < len(r)-1 {
+		start := i + 1
+		end := indexRune(r, start, '\n')
+		if end < 0 {
+			end = len(r)
+		}
+		i = end
+		if strings.TrimLeft(string(r[start:end]), "\t") == delim {
+			break
+		}
+	}
+	return i
+}
+
+func indexRune(r []rune, from int, c rune) int {
+	for i := from; i < len(r); i++ {
+		if r[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// matchParen returns the index of the ) closing the ( at open, skipping
+// quoted text
+func matchParen(r []rune, open int) int {
+	depth := 0
+	for i := open; i < len(r); i++ {
+		switch r[i] {
+		case '\\':
+			i++
+		case '\'':
+			end := indexRune(r, i+1, '\'')
+			if end < 0 {
+				return -1
+			}
+			i = end
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
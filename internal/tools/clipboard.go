@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/LaPingvino/llemecode/internal/clipboard"
+)
+
+// maxClipboardRead caps how much of the clipboard is returned to the model
+const maxClipboardRead = 100 * 1024
+
+// ClipboardReadTool returns what the user copied, such as an error message
+type ClipboardReadTool struct{}
+
+func NewClipboardReadTool() *ClipboardReadTool {
+	return &ClipboardReadTool{}
+}
+
+func (t *ClipboardReadTool) Name() string {
+	return "clipboard_read"
+}
+
+func (t *ClipboardReadTool) Description() string {
+	return "Read the text on the user's clipboard, e.g. an error message they just copied"
+}
+
+func (t *ClipboardReadTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	}
+}
+
+func (t *ClipboardReadTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	text, err := clipboard.Read(ctx)
+	if err != nil {
+		return "", err
+	}
+	if text == "" {
+		return "The clipboard is empty.", nil
+	}
+	if len(text) > maxClipboardRead {
+		text = text[:maxClipboardRead] + fmt.Sprintf("\n... (truncated, %d bytes total)", len(text))
+	}
+	return text, nil
+}
+
+// ClipboardWriteTool puts text on the user's clipboard
+type ClipboardWriteTool struct{}
+
+func NewClipboardWriteTool() *ClipboardWriteTool {
+	return &ClipboardWriteTool{}
+}
+
+func (t *ClipboardWriteTool) Name() string {
+	return "clipboard_write"
+}
+
+func (t *ClipboardWriteTool) Description() string {
+	return "Put text on the user's clipboard, e.g. a generated snippet or command they asked for"
+}
+
+func (t *ClipboardWriteTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"text": map[string]interface{}{
+				"type":        "string",
+				"description": "The text to copy",
+			},
+		},
+		"required": []string{"text"},
+	}
+}
+
+func (t *ClipboardWriteTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	text, ok := args["text"].(string)
+	if !ok {
+		return "", fmt.Errorf("text parameter is required")
+	}
+	via, err := clipboard.Write(ctx, text)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Copied %d characters to the clipboard (via %s)", len([]rune(text)), via), nil
+}
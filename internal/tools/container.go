@@ -0,0 +1,416 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxContainerOutput caps what a container tool returns; logs keep the end
+const maxContainerOutput = 32 * 1024
+
+// containerCLI runs docker, or podman when docker isn't installed
+type containerCLI struct{}
+
+func (containerCLI) binary() (string, error) {
+	for _, name := range []string{"docker", "podman"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("neither docker nor podman is installed")
+}
+
+// run runs the container CLI and returns its combined output
+func (c containerCLI) run(ctx context.Context, timeout time.Duration, args ...string) (string, error) {
+	bin, err := c.binary()
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err = cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %w\n%s", bin, strings.Join(args, " "), err, tail(out.String(), maxContainerOutput))
+	}
+	return out.String(), nil
+}
+
+// tail keeps the last max bytes of s
+func tail(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	s = s[len(s)-max:]
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return "... (earlier output cut)\n" + s
+}
+
+// containerInfo is the part of docker and podman ps output worth showing
+type containerInfo struct {
+	ID      string
+	Name    string
+	Service string
+	Image   string
+	State   string
+	Status  string
+	Ports   string
+}
+
+// parseContainers reads ps output in JSON: docker prints one object per
+// line, podman and older compose versions print an array
+func parseContainers(out string) ([]containerInfo, error) {
+	var raw []map[string]interface{}
+	out = strings.TrimSpace(out)
+	if strings.HasPrefix(out, "[") {
+		if err := json.Unmarshal([]byte(out), &raw); err != nil {
+			return nil, fmt.Errorf("parse container list: %w", err)
+		}
+	} else {
+		for _, line := range strings.Split(out, "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			var obj map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &obj); err != nil {
+				return nil, fmt.Errorf("parse container list: %w", err)
+			}
+			raw = append(raw, obj)
+		}
+	}
+
+	containers := make([]containerInfo, 0, len(raw))
+	for _, obj := range raw {
+		c := containerInfo{
+			ID:      field(obj, "ID", "Id"),
+			Name:    field(obj, "Names", "Name"),
+			Service: field(obj, "Service"),
+			Image:   field(obj, "Image"),
+			State:   field(obj, "State"),
+			Status:  field(obj, "Status"),
+			Ports:   field(obj, "Ports", "Publishers"),
+		}
+		if len(c.ID) > 12 {
+			c.ID = c.ID[:12]
+		}
+		containers = append(containers, c)
+	}
+	return containers, nil
+}
+
+// field returns the first of keys that is set, flattening lists and port
+// mappings to text
+func field(obj map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if v, ok := obj[key]; ok && v != nil {
+			if s := flatten(v); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+func flatten(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			if s := flatten(item); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ", ")
+	case map[string]interface{}:
+		// A port mapping from podman or compose
+		host := field(v, "host_port", "PublishedPort")
+		container := field(v, "container_port", "TargetPort")
+		proto := field(v, "protocol", "Protocol")
+		if container == "" || container == "0" {
+			return ""
+		}
+		if host == "" || host == "0" {
+			return container + "/" + proto
+		}
+		return host + "->" + container + "/" + proto
+	}
+	return fmt.Sprint(v)
+}
+
+func formatContainers(containers []containerInfo) string {
+	if len(containers) == 0 {
+		return "No containers."
+	}
+	var sb strings.Builder
+	for _, c := range containers {
+		name := c.Name
+		if c.Service != "" && c.Service != c.Name {
+			name += " (service " + c.Service + ")"
+		}
+		status := c.Status
+		if status == "" {
+			status = c.State
+		}
+		sb.WriteString(fmt.Sprintf("%s  %s  %s  %s", c.ID, name, c.Image, status))
+		if c.Ports != "" {
+			sb.WriteString("  ports " + c.Ports)
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// ContainerListTool lists containers with their state and ports
+type ContainerListTool struct {
+	cli containerCLI
+}
+
+func NewContainerListTool() *ContainerListTool {
+	return &ContainerListTool{}
+}
+
+func (t *ContainerListTool) Name() string {
+	return "container_list"
+}
+
+func (t *ContainerListTool) Description() string {
+	return "List Docker/Podman containers with image, status and ports"
+}
+
+func (t *ContainerListTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"all": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Include stopped containers",
+			},
+		},
+	}
+}
+
+func (t *ContainerListTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	psArgs := []string{"ps", "--format", "json"}
+	if all, _ := args["all"].(bool); all {
+		psArgs = append(psArgs, "--all")
+	}
+	bin, err := t.cli.binary()
+	if err != nil {
+		return "", err
+	}
+	if bin == "docker" {
+		psArgs[2] = "{{json .}}"
+	}
+	out, err := t.cli.run(ctx, 30*time.Second, psArgs...)
+	if err != nil {
+		return "", err
+	}
+	containers, err := parseContainers(out)
+	if err != nil {
+		return "", err
+	}
+	return formatContainers(containers), nil
+}
+
+// ContainerLogsTool shows what a container has been printing
+type ContainerLogsTool struct {
+	cli containerCLI
+}
+
+func NewContainerLogsTool() *ContainerLogsTool {
+	return &ContainerLogsTool{}
+}
+
+func (t *ContainerLogsTool) Name() string {
+	return "container_logs"
+}
+
+func (t *ContainerLogsTool) Description() string {
+	return "Show the recent output (stdout and stderr) of a Docker/Podman container"
+}
+
+func (t *ContainerLogsTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"container": map[string]interface{}{
+				"type":        "string",
+				"description": "Container name or ID",
+			},
+			"lines": map[string]interface{}{
+				"type":        "number",
+				"description": "Number of lines from the end (default 100)",
+			},
+			"since": map[string]interface{}{
+				"type":        "string",
+				"description": "Only logs since this time or duration, e.g. 10m or 2024-01-02T15:04:05",
+			},
+		},
+		"required": []string{"container"},
+	}
+}
+
+func (t *ContainerLogsTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	container, ok := args["container"].(string)
+	if !ok || container == "" {
+		return "", fmt.Errorf("container parameter is required")
+	}
+	lines := 100
+	if n, ok := args["lines"].(float64); ok && n > 0 {
+		lines = int(n)
+	}
+	logArgs := []string{"logs", "--tail", strconv.Itoa(lines)}
+	if since, _ := args["since"].(string); since != "" {
+		logArgs = append(logArgs, "--since", since)
+	}
+	out, err := t.cli.run(ctx, 30*time.Second, append(logArgs, container)...)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(out) == "" {
+		return fmt.Sprintf("%s printed nothing", container), nil
+	}
+	return tail(out, maxContainerOutput), nil
+}
+
+// ContainerExecTool runs a command inside a running container
+type ContainerExecTool struct {
+	cli containerCLI
+}
+
+func NewContainerExecTool() *ContainerExecTool {
+	return &ContainerExecTool{}
+}
+
+func (t *ContainerExecTool) Name() string {
+	return "container_exec"
+}
+
+func (t *ContainerExecTool) Description() string {
+	return "Run a shell command inside a running Docker/Podman container"
+}
+
+func (t *ContainerExecTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"container": map[string]interface{}{
+				"type":        "string",
+				"description": "Container name or ID",
+			},
+			"command": map[string]interface{}{
+				"type":        "string",
+				"description": "Command to run with sh -c",
+			},
+		},
+		"required": []string{"container", "command"},
+	}
+}
+
+func (t *ContainerExecTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	container, _ := args["container"].(string)
+	command, _ := args["command"].(string)
+	if container == "" || command == "" {
+		return "", fmt.Errorf("container and command parameters are required")
+	}
+	out, err := t.cli.run(ctx, 5*time.Minute, "exec", container, "sh", "-c", command)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(out) == "" {
+		return "(no output)", nil
+	}
+	return tail(out, maxContainerOutput), nil
+}
+
+// ComposeTool starts, stops and lists Compose services
+type ComposeTool struct {
+	cli containerCLI
+}
+
+func NewComposeTool() *ComposeTool {
+	return &ComposeTool{}
+}
+
+func (t *ComposeTool) Name() string {
+	return "compose"
+}
+
+func (t *ComposeTool) Description() string {
+	return "Manage Docker/Podman Compose services: up (detached), down, ps or restart"
+}
+
+func (t *ComposeTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "up, down, ps or restart",
+			},
+			"file": map[string]interface{}{
+				"type":        "string",
+				"description": "Compose file (default: compose.yaml or docker-compose.yml in the working directory)",
+			},
+			"services": map[string]interface{}{
+				"type":        "string",
+				"description": "Space-separated services to act on (default all)",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *ComposeTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	action, _ := args["action"].(string)
+	composeArgs := []string{"compose"}
+	if file, _ := args["file"].(string); file != "" {
+		composeArgs = append(composeArgs, "-f", file)
+	}
+	services, _ := args["services"].(string)
+
+	switch action {
+	case "up":
+		composeArgs = append(composeArgs, "up", "--detach")
+	case "down", "restart":
+		composeArgs = append(composeArgs, action)
+	case "ps":
+		composeArgs = append(composeArgs, "ps", "--all", "--format", "json")
+	default:
+		return "", fmt.Errorf("unknown action %q (use up, down, ps or restart)", action)
+	}
+	if action != "down" {
+		composeArgs = append(composeArgs, strings.Fields(services)...)
+	}
+
+	out, err := t.cli.run(ctx, 10*time.Minute, composeArgs...)
+	if err != nil {
+		return "", err
+	}
+	if action == "ps" {
+		containers, err := parseContainers(out)
+		if err != nil {
+			return "", err
+		}
+		return formatContainers(containers), nil
+	}
+	if strings.TrimSpace(out) == "" {
+		return fmt.Sprintf("compose %s done", action), nil
+	}
+	return tail(out, maxContainerOutput), nil
+}
@@ -0,0 +1,25 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/LaPingvino/llemecode/internal/ollama"
+)
+
+type conversationKey struct{}
+
+// WithConversation lets tools called through ctx read the conversation so
+// far, e.g. to brief a model they delegate to
+func WithConversation(ctx context.Context, messages func() []ollama.Message) context.Context {
+	return context.WithValue(ctx, conversationKey{}, messages)
+}
+
+// Conversation returns the messages of the calling conversation, or nil
+// when the caller didn't share them
+func Conversation(ctx context.Context) []ollama.Message {
+	messages, ok := ctx.Value(conversationKey{}).(func() []ollama.Message)
+	if !ok {
+		return nil
+	}
+	return messages()
+}
@@ -0,0 +1,162 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/logger"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+)
+
+// CreateModelTool builds a new Ollama model from an installed one, e.g. a
+// variant with its own system prompt and parameters
+type CreateModelTool struct {
+	client *ollama.Client
+}
+
+func NewCreateModelTool(client *ollama.Client) *CreateModelTool {
+	return &CreateModelTool{client: client}
+}
+
+func (t *CreateModelTool) Name() string {
+	return "create_model"
+}
+
+func (t *CreateModelTool) Description() string {
+	return "Create an Ollama model based on an installed one, with its own system prompt and parameters (e.g. a 'strict-go-reviewer' based on qwen2.5-coder). Give either a Modelfile or from/system/parameters."
+}
+
+func (t *CreateModelTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the new model",
+			},
+			"modelfile": map[string]interface{}{
+				"type":        "string",
+				"description": "Modelfile text with FROM, SYSTEM, PARAMETER, TEMPLATE and MESSAGE instructions",
+			},
+			"from": map[string]interface{}{
+				"type":        "string",
+				"description": "Installed model to base it on, when no modelfile is given",
+			},
+			"system": map[string]interface{}{
+				"type":        "string",
+				"description": "System prompt, when no modelfile is given",
+			},
+			"parameters": map[string]interface{}{
+				"type":        "object",
+				"description": "Parameters such as temperature or num_ctx, when no modelfile is given",
+			},
+		},
+		"required": []string{"name"},
+	}
+}
+
+func (t *CreateModelTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	name, _ := args["name"].(string)
+	if name == "" {
+		return "", fmt.Errorf("name parameter is required")
+	}
+
+	req := &ollama.CreateRequest{}
+	if modelfile, _ := args["modelfile"].(string); modelfile != "" {
+		parsed, err := ollama.ParseModelfile(modelfile)
+		if err != nil {
+			return "", err
+		}
+		req = parsed
+	} else {
+		req.From, _ = args["from"].(string)
+		req.System, _ = args["system"].(string)
+		req.Parameters, _ = args["parameters"].(map[string]interface{})
+		if req.From == "" {
+			return "", fmt.Errorf("either modelfile or from is required")
+		}
+	}
+	req.Model = name
+
+	var steps []string
+	err := t.client.CreateModel(ctx, *req, func(status string) {
+		logger.Status("create_model %s: %s", name, status)
+		if len(steps) == 0 || steps[len(steps)-1] != status {
+			steps = append(steps, status)
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Created model %s from %s", name, req.From))
+	if len(steps) > 0 {
+		sb.WriteString("\n\nProgress:\n- " + strings.Join(steps, "\n- "))
+	}
+	sb.WriteString(fmt.Sprintf("\n\nThe user can switch to it with /model %s", name))
+	return sb.String(), nil
+}
+
+// ShowModelfileTool shows how an installed model is configured
+type ShowModelfileTool struct {
+	client *ollama.Client
+}
+
+func NewShowModelfileTool(client *ollama.Client) *ShowModelfileTool {
+	return &ShowModelfileTool{client: client}
+}
+
+func (t *ShowModelfileTool) Name() string {
+	return "show_modelfile"
+}
+
+func (t *ShowModelfileTool) Description() string {
+	return "Show the Modelfile of an installed Ollama model: its base, system prompt, parameters and template"
+}
+
+func (t *ShowModelfileTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"model": map[string]interface{}{
+				"type":        "string",
+				"description": "Model name",
+			},
+		},
+		"required": []string{"model"},
+	}
+}
+
+func (t *ShowModelfileTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	model, _ := args["model"].(string)
+	if model == "" {
+		return "", fmt.Errorf("model parameter is required")
+	}
+	details, err := t.client.ShowModel(ctx, model)
+	if err != nil {
+		return "", err
+	}
+	if details.Modelfile != "" {
+		return details.Modelfile, nil
+	}
+
+	// Servers that don't return the Modelfile still return its parts
+	var sb strings.Builder
+	if details.System != "" {
+		sb.WriteString("SYSTEM \"\"\"" + details.System + "\"\"\"\n")
+	}
+	for _, line := range strings.Split(strings.TrimSpace(details.Parameters), "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 {
+			sb.WriteString("PARAMETER " + strings.Join(fields, " ") + "\n")
+		}
+	}
+	if details.Template != "" {
+		sb.WriteString("TEMPLATE \"\"\"" + details.Template + "\"\"\"\n")
+	}
+	if sb.Len() == 0 {
+		return fmt.Sprintf("%s has no Modelfile details", model), nil
+	}
+	return sb.String(), nil
+}
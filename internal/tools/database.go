@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/database"
+)
+
+// QueryDatabaseTool inspects schemas and data in the configured databases
+type QueryDatabaseTool struct {
+	profiles map[string]config.DatabaseProfile
+}
+
+func NewQueryDatabaseTool(profiles map[string]config.DatabaseProfile) *QueryDatabaseTool {
+	return &QueryDatabaseTool{profiles: profiles}
+}
+
+func (t *QueryDatabaseTool) Name() string {
+	return "query_database"
+}
+
+func (t *QueryDatabaseTool) Description() string {
+	var desc []string
+	for _, name := range t.names() {
+		p := t.profiles[name]
+		mode := "read-only"
+		if p.ReadWrite {
+			mode = "read-write"
+		}
+		desc = append(desc, fmt.Sprintf("%s (%s, %s)", name, p.Driver, mode))
+	}
+	return "Query a configured database: list tables, show a table's schema, or run SQL to look at sample data. Databases: " + strings.Join(desc, ", ")
+}
+
+func (t *QueryDatabaseTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"database": map[string]interface{}{
+				"type":        "string",
+				"description": "Profile name; may be left out when only one database is configured",
+			},
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "tables (list tables), schema (columns of table) or query (run sql, the default)",
+			},
+			"table": map[string]interface{}{
+				"type":        "string",
+				"description": "Table for the schema action",
+			},
+			"sql": map[string]interface{}{
+				"type":        "string",
+				"description": "Statement for the query action; results are limited, so use LIMIT for samples",
+			},
+		},
+	}
+}
+
+func (t *QueryDatabaseTool) names() []string {
+	names := make([]string, 0, len(t.profiles))
+	for name := range t.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (t *QueryDatabaseTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	name, _ := args["database"].(string)
+	if name == "" {
+		if len(t.profiles) != 1 {
+			return "", fmt.Errorf("database is required, one of: %s", strings.Join(t.names(), ", "))
+		}
+		name = t.names()[0]
+	}
+	profile, ok := t.profiles[name]
+	if !ok {
+		return "", fmt.Errorf("unknown database %q, configured: %s", name, strings.Join(t.names(), ", "))
+	}
+
+	conn, err := database.Open(name, profile)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	action, _ := args["action"].(string)
+	switch action {
+	case "tables":
+		return conn.Tables(ctx)
+	case "schema":
+		table, _ := args["table"].(string)
+		if table == "" {
+			return "", fmt.Errorf("table is required for the schema action")
+		}
+		return conn.Schema(ctx, table)
+	case "", "query":
+		query, _ := args["sql"].(string)
+		if strings.TrimSpace(query) == "" {
+			return "", fmt.Errorf("sql is required for the query action")
+		}
+		return conn.Query(ctx, query)
+	}
+	return "", fmt.Errorf("unknown action %q (use tables, schema or query)", action)
+}
@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/ollama"
+)
+
+const (
+	// defaultContextTokens is the handoff budget when the model gives none
+	defaultContextTokens = 1500
+	maxContextTokens     = 8000
+	// maxMessageChars caps one message in a recent-messages handoff, so a
+	// single long tool result doesn't use up the budget
+	maxMessageChars = 1500
+)
+
+// handoff builds what a delegated model is told before the question: some
+// of the conversation and the requested files, within context_tokens
+func (t *AskModelTool) handoff(ctx context.Context, args map[string]interface{}) (string, error) {
+	mode := stringArg(args["context"])
+	files, _ := args["files"].([]interface{})
+	if (mode == "" || mode == "none") && len(files) == 0 {
+		return "", nil
+	}
+	budget := defaultContextTokens
+	if n, ok := intArg(args["context_tokens"]); ok && n > 0 {
+		budget = min(n, maxContextTokens)
+	}
+	chars := budget * 4
+
+	var sb strings.Builder
+	switch mode {
+	case "", "none":
+	case "recent", "brief":
+		conversation := Conversation(ctx)
+		text := recentMessages(conversation, chars)
+		if mode == "brief" && text != "" {
+			// Read more than fits and let the model condense it
+			if brief, err := t.brief(ctx, recentMessages(conversation, chars*4), budget); err == nil {
+				text = brief
+			}
+		}
+		if text != "" {
+			sb.WriteString("Context from the conversation that asked you this:\n" + text + "\n\n")
+		}
+	default:
+		return "", fmt.Errorf("context must be none, recent or brief")
+	}
+
+	for _, raw := range files {
+		path, _ := raw.(string)
+		if path == "" {
+			continue
+		}
+		if t.readFile == nil {
+			return "", fmt.Errorf("files can't be read here")
+		}
+		content, err := t.readFile(ctx, path)
+		if err != nil {
+			return "", fmt.Errorf("read %s for %s: %w", path, t.modelName, err)
+		}
+		left := chars - sb.Len()
+		if left <= 0 {
+			sb.WriteString(fmt.Sprintf("(%s left out: over the context budget)\n\n", path))
+			continue
+		}
+		if len(content) > left {
+			content = truncateUTF8(content, left) + "\n... (cut to fit the context budget)"
+		}
+		sb.WriteString(fmt.Sprintf("File %s:\n```\n%s\n```\n\n", path, strings.TrimSuffix(content, "\n")))
+	}
+	if sb.Len() == 0 {
+		return "", nil
+	}
+	return sb.String() + "Question:\n", nil
+}
+
+// recentMessages renders the newest messages that fit in chars, oldest
+// first, leaving out system prompts
+func recentMessages(messages []ollama.Message, chars int) string {
+	var picked []string
+	used := 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		content := strings.TrimSpace(msg.Content)
+		if msg.Role == "system" || content == "" {
+			continue
+		}
+		if len(content) > maxMessageChars {
+			content = truncateUTF8(content, maxMessageChars) + " ..."
+		}
+		label := strings.ToUpper(msg.Role[:1]) + msg.Role[1:]
+		if msg.Role == "tool" && msg.ToolName != "" {
+			label = "Tool " + msg.ToolName
+		}
+		line := label + ": " + content
+		if used+len(line) > chars {
+			break
+		}
+		used += len(line) + 1
+		picked = append(picked, line)
+	}
+	for i, j := 0, len(picked)-1; i < j; i, j = i+1, j-1 {
+		picked[i], picked[j] = picked[j], picked[i]
+	}
+	return strings.Join(picked, "\n")
+}
+
+// brief has the delegated model condense the conversation to tokens
+func (t *AskModelTool) brief(ctx context.Context, transcript string, tokens int) (string, error) {
+	resp, err := t.client.Chat(ctx, ollama.ChatRequest{
+		Model: t.modelName,
+		Messages: []ollama.Message{
+			{Role: "system", Content: fmt.Sprintf("Write a brief of the conversation below in at most %d tokens for someone who will be asked a question about it: the goal, decisions made, and relevant facts, file names and code. No preamble.", tokens)},
+			{Role: "user", Content: transcript},
+		},
+		Stream:  false,
+		Options: map[string]interface{}{"num_predict": tokens},
+	})
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(resp.Message.Content) == "" {
+		return "", fmt.Errorf("empty brief")
+	}
+	return strings.TrimSpace(resp.Message.Content), nil
+}
@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// DeleteFileTool moves files and directories to the session trash, where
+// the user can restore them
+type DeleteFileTool struct {
+	policy *PathPolicy
+	trash  *Trash
+}
+
+func NewDeleteFileTool(trash *Trash) *DeleteFileTool {
+	return &DeleteFileTool{trash: trash}
+}
+
+// SetPathPolicy restricts which paths the tool may use
+func (t *DeleteFileTool) SetPathPolicy(policy *PathPolicy) {
+	t.policy = policy
+}
+
+func (t *DeleteFileTool) Name() string {
+	return "delete_file"
+}
+
+func (t *DeleteFileTool) Description() string {
+	return "Delete a file, or a directory with recursive=true. It goes to the trash, so the user can restore it"
+}
+
+func (t *DeleteFileTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File or directory to delete",
+			},
+			"recursive": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Needed to delete a directory and everything in it",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+func (t *DeleteFileTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	path, ok := args["path"].(string)
+	if !ok || path == "" {
+		return "", fmt.Errorf("path must be a string")
+	}
+	if err := t.policy.Check(path); err != nil {
+		return "", err
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot delete %s: %w", path, err)
+	}
+	if recursive, _ := args["recursive"].(bool); info.IsDir() && !recursive {
+		return "", fmt.Errorf("%s is a directory; pass recursive=true to delete it", path)
+	}
+
+	item, err := t.trash.Put(path)
+	if err != nil {
+		return "", fmt.Errorf("delete %s: %w", path, err)
+	}
+	return fmt.Sprintf("Moved %s to the trash (the user can restore it with /trash restore %d)", item.Original, len(t.trash.Items())), nil
+}
@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	diffContext  = 3       // Unchanged lines kept around each change
+	maxDiffCells = 4000000 // Larger files are not diffed
+)
+
+// DiffLine is one line of a line diff
+type DiffLine struct {
+	Op   byte // ' ', '-' or '+'
+	Text string
+	Line int // Line number in after, or before for removals
+}
+
+// LineDiff returns the changed lines between before and after, grouped into
+// hunks with a few lines of context. ok is false when the files are too
+// large to diff.
+func LineDiff(before, after string) (hunks [][]DiffLine, ok bool) {
+	a := strings.Split(before, "\n")
+	b := strings.Split(after, "\n")
+	if len(a)*len(b) > maxDiffCells {
+		return nil, false
+	}
+
+	// lcs[i][j] is the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []DiffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, DiffLine{' ', a[i], j + 1})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, DiffLine{'-', a[i], i + 1})
+			i++
+		default:
+			lines = append(lines, DiffLine{'+', b[j], j + 1})
+			j++
+		}
+	}
+
+	// Keep changed lines and the context around them
+	keep := make([]bool, len(lines))
+	for k, line := range lines {
+		if line.Op == ' ' {
+			continue
+		}
+		for n := max(k-diffContext, 0); n <= min(k+diffContext, len(lines)-1); n++ {
+			keep[n] = true
+		}
+	}
+	for k, line := range lines {
+		if !keep[k] {
+			continue
+		}
+		if k == 0 || !keep[k-1] {
+			hunks = append(hunks, nil)
+		}
+		hunks[len(hunks)-1] = append(hunks[len(hunks)-1], line)
+	}
+	return hunks, true
+}
+
+// plainDiff renders LineDiff as text, or just counts the lines of files too
+// large to diff
+func plainDiff(before, after string) string {
+	hunks, ok := LineDiff(before, after)
+	if !ok {
+		return fmt.Sprintf("(too large to diff: %d lines now)", strings.Count(after, "\n")+1)
+	}
+	if len(hunks) == 0 {
+		return "(no changes)"
+	}
+	var out strings.Builder
+	for _, hunk := range hunks {
+		out.WriteString(fmt.Sprintf("@@ line %d @@\n", hunk[0].Line))
+		for _, line := range hunk {
+			out.WriteString(string(line.Op) + " " + line.Text + "\n")
+		}
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
@@ -0,0 +1,202 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultMaxDownload is the size limit when the model gives none
+const defaultMaxDownload = 100 << 20
+
+// DownloadFileTool saves a URL to a file, with a size limit and an optional
+// SHA-256 check, instead of piping curl into a shell
+type DownloadFileTool struct {
+	client *http.Client
+	policy *PathPolicy
+}
+
+func NewDownloadFileTool() *DownloadFileTool {
+	return &DownloadFileTool{
+		client: &http.Client{
+			Timeout: 10 * time.Minute,
+		},
+	}
+}
+
+// SetPathPolicy restricts which paths the tool may use
+func (t *DownloadFileTool) SetPathPolicy(policy *PathPolicy) {
+	t.policy = policy
+}
+
+func (t *DownloadFileTool) Name() string {
+	return "download_file"
+}
+
+func (t *DownloadFileTool) Description() string {
+	return "Download a URL to a file, with a size limit and an optional SHA-256 checksum. Use this instead of curl or wget in run_command"
+}
+
+func (t *DownloadFileTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"url": map[string]interface{}{
+				"type":        "string",
+				"description": "http or https URL to download",
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File to save it as; it must not exist yet",
+			},
+			"max_bytes": map[string]interface{}{
+				"type":        "integer",
+				"description": "Largest download accepted; default 100 MB",
+			},
+			"sha256": map[string]interface{}{
+				"type":        "string",
+				"description": "Expected SHA-256 in hex; the file is only kept if it matches",
+			},
+		},
+		"required": []string{"url", "path"},
+	}
+}
+
+// download is a checked download request
+type download struct {
+	url      string
+	path     string
+	maxBytes int64
+	sha256   string // Lower case hex, or empty
+}
+
+// ApprovalDetails shows what will be downloaded and where it will land
+func (t *DownloadFileTool) ApprovalDetails(args map[string]interface{}) (string, error) {
+	d, err := t.parse(args)
+	if err != nil {
+		return "", err
+	}
+	check := "not checked"
+	if d.sha256 != "" {
+		check = d.sha256
+	}
+	return fmt.Sprintf("download %s\n  to %s\n  at most %s\n  SHA-256 %s", d.url, d.path, formatBytes(d.maxBytes), check), nil
+}
+
+func (t *DownloadFileTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	d, err := t.parse(args)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", d.url, nil)
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("download: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	if resp.ContentLength > d.maxBytes {
+		return "", fmt.Errorf("%s is %s, over the %s limit", d.url, formatBytes(resp.ContentLength), formatBytes(d.maxBytes))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(d.path), 0755); err != nil {
+		return "", fmt.Errorf("create directory: %w", err)
+	}
+	temp, err := os.CreateTemp(filepath.Dir(d.path), "."+filepath.Base(d.path)+".download-*")
+	if err != nil {
+		return "", fmt.Errorf("create file: %w", err)
+	}
+	defer os.Remove(temp.Name())
+
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(temp, hash), io.LimitReader(resp.Body, d.maxBytes+1))
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(temp.Name(), 0644)
+	}
+	if err != nil {
+		return "", fmt.Errorf("download: %w", err)
+	}
+	if n > d.maxBytes {
+		return "", fmt.Errorf("%s is over the %s limit; nothing was saved", d.url, formatBytes(d.maxBytes))
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+	if d.sha256 != "" && sum != d.sha256 {
+		return "", fmt.Errorf("checksum mismatch for %s: expected %s, got %s; nothing was saved", d.url, d.sha256, sum)
+	}
+
+	// Link rather than rename, so a file created meanwhile is not replaced
+	if err := os.Link(temp.Name(), d.path); err != nil {
+		if _, statErr := os.Lstat(d.path); statErr == nil {
+			return "", fmt.Errorf("%s appeared during the download; nothing was saved", d.path)
+		}
+		if err := os.Rename(temp.Name(), d.path); err != nil {
+			return "", fmt.Errorf("save %s: %w", d.path, err)
+		}
+	}
+
+	verified := ""
+	if d.sha256 != "" {
+		verified = ", checksum verified"
+	}
+	return fmt.Sprintf("Downloaded %s to %s (%s, SHA-256 %s%s)", d.url, d.path, formatBytes(n), sum, verified), nil
+}
+
+func (t *DownloadFileTool) parse(args map[string]interface{}) (download, error) {
+	rawURL, _ := args["url"].(string)
+	path, _ := args["path"].(string)
+	if rawURL == "" || path == "" {
+		return download{}, fmt.Errorf("url and path must be strings")
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return download{}, fmt.Errorf("invalid url %q: use an http or https URL", rawURL)
+	}
+	if err := t.policy.Check(path); err != nil {
+		return download{}, err
+	}
+	if _, err := os.Lstat(path); err == nil {
+		return download{}, fmt.Errorf("%s already exists; download to a new path", path)
+	}
+
+	d := download{url: rawURL, path: path, maxBytes: defaultMaxDownload}
+	if n, ok := intArg(args["max_bytes"]); ok && n > 0 {
+		d.maxBytes = int64(n)
+	}
+	if sum, _ := args["sha256"].(string); sum != "" {
+		sum = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(sum, "sha256:")))
+		if _, err := hex.DecodeString(sum); err != nil || len(sum) != sha256.Size*2 {
+			return download{}, fmt.Errorf("sha256 must be 64 hex digits")
+		}
+		d.sha256 = sum
+	}
+	return d, nil
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
@@ -0,0 +1,216 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// environmentVars are the variables get_environment always reports
+var environmentVars = []string{"SHELL", "TERM", "LANG", "EDITOR", "GOPATH", "GOROOT", "VIRTUAL_ENV", "CONDA_DEFAULT_ENV", "NODE_ENV", "JAVA_HOME", "container", "WSL_DISTRO_NAME"}
+
+// versionCommands are the toolchains looked up on PATH, with how to ask
+// each for its version
+var versionCommands = [][]string{
+	{"go", "version"},
+	{"python3", "--version"},
+	{"python", "--version"},
+	{"node", "--version"},
+	{"npm", "--version"},
+	{"rustc", "--version"},
+	{"java", "-version"},
+	{"git", "--version"},
+	{"docker", "--version"},
+	{"make", "--version"},
+}
+
+// packageManagers are checked in order; every one on PATH is reported
+var packageManagers = []string{"apt", "dnf", "yum", "pacman", "zypper", "apk", "emerge", "xbps-install", "nix", "brew", "port", "winget", "choco", "scoop"}
+
+// EnvironmentTool describes the machine the session runs on, so the model
+// doesn't have to guess the platform
+type EnvironmentTool struct{}
+
+func NewEnvironmentTool() *EnvironmentTool {
+	return &EnvironmentTool{}
+}
+
+func (t *EnvironmentTool) Name() string {
+	return "get_environment"
+}
+
+func (t *EnvironmentTool) Description() string {
+	return "Describe the user's machine: OS and distribution, architecture, CPU and memory, package managers, toolchain versions on PATH and key environment variables. Call this before suggesting platform-specific commands"
+}
+
+func (t *EnvironmentTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"variables": map[string]interface{}{
+				"type":        "array",
+				"description": "Extra environment variables to report; secrets are redacted",
+				"items":       map[string]interface{}{"type": "string"},
+			},
+		},
+	}
+}
+
+func (t *EnvironmentTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("OS: %s\n", osDescription(ctx)))
+	sb.WriteString(fmt.Sprintf("Architecture: %s\n", runtime.GOARCH))
+	sb.WriteString(fmt.Sprintf("CPUs: %d\n", runtime.NumCPU()))
+	if total := totalMemory(ctx); total > 0 {
+		sb.WriteString(fmt.Sprintf("Memory: %s\n", formatBytes(total)))
+	}
+
+	var managers []string
+	for _, name := range packageManagers {
+		if _, err := exec.LookPath(name); err == nil {
+			managers = append(managers, name)
+		}
+	}
+	if len(managers) > 0 {
+		sb.WriteString(fmt.Sprintf("Package managers: %s\n", strings.Join(managers, ", ")))
+	}
+
+	sb.WriteString("Toolchains:\n")
+	versions := toolVersions(ctx)
+	for _, command := range versionCommands {
+		version := versions[command[0]]
+		if version == "" {
+			version = "not found"
+		}
+		sb.WriteString(fmt.Sprintf("  %s: %s\n", command[0], version))
+	}
+
+	names := append([]string{}, environmentVars...)
+	if extra, ok := args["variables"].([]interface{}); ok {
+		for _, name := range extra {
+			if s, ok := name.(string); ok && s != "" {
+				names = append(names, s)
+			}
+		}
+	}
+	sb.WriteString("Environment:\n")
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if value, ok := os.LookupEnv(name); ok {
+			sb.WriteString(fmt.Sprintf("  %s=%s\n", name, RedactEnv(name, value)))
+		}
+	}
+	if path := os.Getenv("PATH"); path != "" {
+		sb.WriteString(fmt.Sprintf("  PATH=%s\n", RedactSecrets(path)))
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// osDescription names the OS, with the Linux distribution or macOS version
+func osDescription(ctx context.Context) string {
+	switch runtime.GOOS {
+	case "linux":
+		release := readOSRelease()
+		if name := release["PRETTY_NAME"]; name != "" {
+			if id := release["ID"]; id != "" {
+				if like := release["ID_LIKE"]; like != "" {
+					return fmt.Sprintf("linux, %s (id %s, like %s)", name, id, like)
+				}
+				return fmt.Sprintf("linux, %s (id %s)", name, id)
+			}
+			return "linux, " + name
+		}
+	case "darwin":
+		if version := commandOutput(ctx, "sw_vers", "-productVersion"); version != "" {
+			return "macOS " + version
+		}
+	}
+	return runtime.GOOS
+}
+
+func readOSRelease() map[string]string {
+	release := make(map[string]string)
+	f, err := os.Open("/etc/os-release")
+	if err != nil {
+		return release
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok {
+			release[key] = strings.Trim(value, `"'`)
+		}
+	}
+	return release
+}
+
+// totalMemory returns the installed RAM in bytes, or 0 if unknown
+func totalMemory(ctx context.Context) int64 {
+	switch runtime.GOOS {
+	case "linux":
+		data, err := os.ReadFile("/proc/meminfo")
+		if err != nil {
+			return 0
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == "MemTotal:" {
+				kb, _ := strconv.ParseInt(fields[1], 10, 64)
+				return kb * 1024
+			}
+		}
+	case "darwin":
+		n, _ := strconv.ParseInt(commandOutput(ctx, "sysctl", "-n", "hw.memsize"), 10, 64)
+		return n
+	}
+	return 0
+}
+
+// toolVersions asks every toolchain on PATH for its version in parallel
+func toolVersions(ctx context.Context) map[string]string {
+	versions := make(map[string]string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, command := range versionCommands {
+		if _, err := exec.LookPath(command[0]); err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(command []string) {
+			defer wg.Done()
+			version := commandOutput(ctx, command[0], command[1:]...)
+			if version == "" {
+				version = "installed (version unknown)"
+			}
+			mu.Lock()
+			versions[command[0]] = version
+			mu.Unlock()
+		}(command)
+	}
+	wg.Wait()
+	return versions
+}
+
+// commandOutput runs a quick command and returns the first line it prints
+func commandOutput(ctx context.Context, name string, args ...string) string {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	// java -version prints to stderr
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(line)
+}
@@ -0,0 +1,212 @@
+package tools
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// maxEvalSize caps the items (or string bytes) a value built in one step by
+// evaluate_expression may have. The step limit doesn't see these: a single
+// [0] * 200000000 is one step but gigabytes of memory.
+const maxEvalSize = 1_000_000
+
+// Predeclared names that + and * and their assignments are rewritten to.
+// They aren't identifiers, so code can't redefine them.
+const (
+	guardAdd       = "+"
+	guardMul       = "*"
+	guardAddAssign = "+="
+	guardMulAssign = "*="
+)
+
+// sizedBuiltins turn an iterable into a value of the same size
+var sizedBuiltins = []string{"list", "tuple", "sorted", "enumerate", "zip"}
+
+// evalGuards returns the predeclared values guarded code needs
+func evalGuards() starlark.StringDict {
+	guards := starlark.StringDict{
+		guardAdd:       starlark.NewBuiltin(guardAdd, guardedBinary(syntax.PLUS, true)),
+		guardMul:       starlark.NewBuiltin(guardMul, guardedBinary(syntax.STAR, true)),
+		guardAddAssign: starlark.NewBuiltin(guardAddAssign, guardedBinary(syntax.PLUS, false)),
+		guardMulAssign: starlark.NewBuiltin(guardMulAssign, guardedBinary(syntax.STAR, false)),
+	}
+	for _, name := range sizedBuiltins {
+		builtin := starlark.Universe[name].(*starlark.Builtin)
+		guards[name] = starlark.NewBuiltin(name, func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			for _, arg := range args {
+				if n := starlark.Len(arg); n > maxEvalSize {
+					return nil, fmt.Errorf("%s: %d items is over the limit of %d", name, n, maxEvalSize)
+				}
+			}
+			return starlark.Call(thread, builtin, args, kwargs)
+		})
+	}
+	return guards
+}
+
+// guardedBinary checks the size x op y would have before computing it. For
+// augmented assignments apply is false and y is returned for the
+// assignment to combine.
+func guardedBinary(op syntax.Token, apply bool) func(*starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error) {
+	return func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var x, y starlark.Value
+		if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 2, &x, &y); err != nil {
+			return nil, err
+		}
+		if size, ok := resultSize(op, x, y); ok && size > maxEvalSize {
+			return nil, fmt.Errorf("the result of %s would have %d items, over the limit of %d", op, size, maxEvalSize)
+		}
+		if !apply {
+			return y, nil
+		}
+		return starlark.Binary(op, x, y)
+	}
+}
+
+// resultSize is the length of concatenating or repeating sequences and
+// strings, if op does that
+func resultSize(op syntax.Token, x, y starlark.Value) (int64, bool) {
+	switch op {
+	case syntax.PLUS:
+		nx, ny := starlark.Len(x), starlark.Len(y)
+		if nx < 0 || ny < 0 {
+			return 0, false
+		}
+		return int64(nx) + int64(ny), true
+	case syntax.STAR:
+		seq, count := x, y
+		if _, ok := x.(starlark.Int); ok {
+			seq, count = y, x
+		}
+		n, ok := count.(starlark.Int)
+		if !ok || starlark.Len(seq) < 0 {
+			return 0, false
+		}
+		times, ok := n.Int64()
+		if !ok {
+			// Too big for int64, so certainly too big
+			return maxEvalSize + 1, true
+		}
+		if times <= 0 {
+			return 0, true
+		}
+		size := int64(starlark.Len(seq))
+		if size > 0 && times > maxEvalSize/size+1 {
+			return maxEvalSize + 1, true
+		}
+		return size * times, true
+	}
+	return 0, false
+}
+
+// guardStmts rewrites + and * in stmts into calls to the size guards
+func guardStmts(stmts []syntax.Stmt) {
+	for _, stmt := range stmts {
+		switch s := stmt.(type) {
+		case *syntax.ExprStmt:
+			s.X = guardExpr(s.X)
+		case *syntax.IfStmt:
+			s.Cond = guardExpr(s.Cond)
+			guardStmts(s.True)
+			guardStmts(s.False)
+		case *syntax.AssignStmt:
+			s.LHS = guardExpr(s.LHS)
+			s.RHS = guardExpr(s.RHS)
+			// x += y still extends x in place; the guard only checks y
+			switch s.Op {
+			case syntax.PLUS_EQ:
+				s.RHS = guardCall(guardAddAssign, s.OpPos, s.LHS, s.RHS)
+			case syntax.STAR_EQ:
+				s.RHS = guardCall(guardMulAssign, s.OpPos, s.LHS, s.RHS)
+			}
+		case *syntax.DefStmt:
+			guardExprs(s.Params)
+			guardStmts(s.Body)
+		case *syntax.ForStmt:
+			s.X = guardExpr(s.X)
+			guardStmts(s.Body)
+		case *syntax.WhileStmt:
+			s.Cond = guardExpr(s.Cond)
+			guardStmts(s.Body)
+		case *syntax.ReturnStmt:
+			if s.Result != nil {
+				s.Result = guardExpr(s.Result)
+			}
+		}
+	}
+}
+
+// guardExpr rewrites + and * in e into calls to the size guards
+func guardExpr(e syntax.Expr) syntax.Expr {
+	switch x := e.(type) {
+	case *syntax.BinaryExpr:
+		x.X, x.Y = guardExpr(x.X), guardExpr(x.Y)
+		switch x.Op {
+		case syntax.PLUS:
+			return guardCall(guardAdd, x.OpPos, x.X, x.Y)
+		case syntax.STAR:
+			return guardCall(guardMul, x.OpPos, x.X, x.Y)
+		}
+	case *syntax.UnaryExpr:
+		if x.X != nil {
+			x.X = guardExpr(x.X)
+		}
+	case *syntax.ParenExpr:
+		x.X = guardExpr(x.X)
+	case *syntax.CallExpr:
+		x.Fn = guardExpr(x.Fn)
+		guardExprs(x.Args)
+	case *syntax.DotExpr:
+		x.X = guardExpr(x.X)
+	case *syntax.IndexExpr:
+		x.X, x.Y = guardExpr(x.X), guardExpr(x.Y)
+	case *syntax.SliceExpr:
+		x.X = guardExpr(x.X)
+		for _, bound := range []*syntax.Expr{&x.Lo, &x.Hi, &x.Step} {
+			if *bound != nil {
+				*bound = guardExpr(*bound)
+			}
+		}
+	case *syntax.CondExpr:
+		x.Cond, x.True, x.False = guardExpr(x.Cond), guardExpr(x.True), guardExpr(x.False)
+	case *syntax.ListExpr:
+		guardExprs(x.List)
+	case *syntax.TupleExpr:
+		guardExprs(x.List)
+	case *syntax.DictExpr:
+		guardExprs(x.List)
+	case *syntax.DictEntry:
+		x.Key, x.Value = guardExpr(x.Key), guardExpr(x.Value)
+	case *syntax.LambdaExpr:
+		guardExprs(x.Params)
+		x.Body = guardExpr(x.Body)
+	case *syntax.Comprehension:
+		x.Body = guardExpr(x.Body)
+		for _, clause := range x.Clauses {
+			switch c := clause.(type) {
+			case *syntax.ForClause:
+				c.X = guardExpr(c.X)
+			case *syntax.IfClause:
+				c.Cond = guardExpr(c.Cond)
+			}
+		}
+	}
+	return e
+}
+
+func guardExprs(list []syntax.Expr) {
+	for i, e := range list {
+		list[i] = guardExpr(e)
+	}
+}
+
+func guardCall(name string, pos syntax.Position, x, y syntax.Expr) *syntax.CallExpr {
+	return &syntax.CallExpr{
+		Fn:     &syntax.Ident{NamePos: pos, Name: name},
+		Lparen: pos,
+		Args:   []syntax.Expr{x, y},
+		Rparen: pos,
+	}
+}
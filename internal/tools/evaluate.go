@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.starlark.net/lib/json"
+	"go.starlark.net/lib/math"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+const (
+	// Limits for one evaluate_expression call
+	maxEvalSteps   = 10_000_000
+	evalTimeout    = 5 * time.Second
+	maxEvalOutput  = 20_000
+	evalResultName = "result"
+)
+
+// evalOptions enables the Starlark features small scripts need; recursion
+// is safe because of the step limit
+var evalOptions = &syntax.FileOptions{
+	Set:             true,
+	While:           true,
+	TopLevelControl: true,
+	GlobalReassign:  true,
+	Recursion:       true,
+}
+
+// EvaluateTool runs math and small pure computations in a Starlark
+// interpreter, which has no access to files, the network or the clock
+type EvaluateTool struct{}
+
+func NewEvaluateTool() *EvaluateTool {
+	return &EvaluateTool{}
+}
+
+func (t *EvaluateTool) Name() string {
+	return "evaluate_expression"
+}
+
+func (t *EvaluateTool) Description() string {
+	return "Compute something exactly instead of doing it in your head: arithmetic, unit conversions, or small data transforms on values you already have. " +
+		"Takes a Starlark (Python-like) expression such as `sum([x*x for x in range(1, 101)])`, or statements that set `result` or call print(). " +
+		"sum, math (math.sqrt, math.pi, ...) and json (json.encode, json.decode) are available; there is no file, network or clock access"
+}
+
+func (t *EvaluateTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"code": map[string]interface{}{
+				"type":        "string",
+				"description": "Starlark expression, or statements that assign result or print",
+			},
+		},
+		"required": []string{"code"},
+	}
+}
+
+func (t *EvaluateTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	code, ok := args["code"].(string)
+	if !ok || strings.TrimSpace(code) == "" {
+		return "", fmt.Errorf("code must be a non-empty string")
+	}
+
+	var printed strings.Builder
+	thread := &starlark.Thread{
+		Name: "evaluate_expression",
+		Print: func(_ *starlark.Thread, msg string) {
+			if printed.Len() < maxEvalOutput {
+				printed.WriteString(msg + "\n")
+			}
+		},
+		Load: func(_ *starlark.Thread, module string) (starlark.StringDict, error) {
+			return nil, fmt.Errorf("load is not available; math and json are predeclared")
+		},
+	}
+	thread.SetMaxExecutionSteps(maxEvalSteps)
+	ctx, cancel := context.WithTimeout(ctx, evalTimeout)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		thread.Cancel("took longer than " + evalTimeout.String())
+	}()
+
+	predeclared := starlark.StringDict{
+		"math": math.Module,
+		"json": json.Module,
+		"sum":  starlark.NewBuiltin("sum", starlarkSum),
+	}
+	for name, guard := range evalGuards() {
+		predeclared[name] = guard
+	}
+	var value starlark.Value
+	var err error
+	if expr, parseErr := evalOptions.ParseExpr("code", code, 0); parseErr == nil {
+		value, err = starlark.EvalExprOptions(evalOptions, thread, guardExpr(expr), predeclared)
+	} else {
+		value, err = evalFile(thread, code, predeclared)
+	}
+	if err != nil {
+		if evalErr, ok := err.(*starlark.EvalError); ok {
+			return "", fmt.Errorf("evaluate: %s", evalErr.Backtrace())
+		}
+		return "", fmt.Errorf("evaluate: %w", err)
+	}
+
+	output := printed.String()
+	if value != nil && value != starlark.None {
+		output += value.String()
+	}
+	if output == "" {
+		return "", fmt.Errorf("the code produced nothing: end with an expression, assign %s or call print()", evalResultName)
+	}
+	if len(output) > maxEvalOutput {
+		output = output[:maxEvalOutput] + "\n... (output truncated)"
+	}
+	return strings.TrimSuffix(output, "\n"), nil
+}
+
+// evalFile runs statements with + and * guarded and returns result
+func evalFile(thread *starlark.Thread, code string, predeclared starlark.StringDict) (starlark.Value, error) {
+	f, err := evalOptions.Parse("code", code, 0)
+	if err != nil {
+		return nil, err
+	}
+	guardStmts(f.Stmts)
+	prog, err := starlark.FileProgram(f, predeclared.Has)
+	if err != nil {
+		return nil, err
+	}
+	globals, err := prog.Init(thread, predeclared)
+	if err != nil {
+		return nil, err
+	}
+	return globals[evalResultName], nil
+}
+
+// starlarkSum is Python's sum, which models reach for and Starlark lacks
+func starlarkSum(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var iterable starlark.Iterable
+	var total starlark.Value = starlark.MakeInt(0)
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &iterable, &total); err != nil {
+		return nil, err
+	}
+	iter := iterable.Iterate()
+	defer iter.Done()
+	var x starlark.Value
+	for iter.Next(&x) {
+		if size, ok := resultSize(syntax.PLUS, total, x); ok && size > maxEvalSize {
+			return nil, fmt.Errorf("sum: the result would have %d items, over the limit of %d", size, maxEvalSize)
+		}
+		var err error
+		if total, err = starlark.Binary(syntax.PLUS, total, x); err != nil {
+			return nil, err
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+)
+
+// maxMergeBaseBytes caps the file contents kept as merge bases; larger files
+// are only hashed
+const maxMergeBaseBytes = 1 << 20
+
+// FileVersions remembers each file as the model last read or wrote it, so
+// write_file can tell when someone else changed it in between
+type FileVersions struct {
+	mu   sync.Mutex
+	seen map[string]fileVersion // By resolved path
+}
+
+type fileVersion struct {
+	hash    [sha256.Size]byte
+	content []byte // Nil when too large to merge
+}
+
+// ConflictError is returned by write_file when the file changed since the
+// model last saw it
+type ConflictError struct {
+	Path      string
+	Mergeable bool
+}
+
+func (e *ConflictError) Error() string {
+	msg := fmt.Sprintf("conflict: %s was changed by someone else since you last read or wrote it, so it was not overwritten. Read it again and write your changes on top", e.Path)
+	if e.Mergeable {
+		msg += ", or call write_file again with merge=true for a three-way merge of their changes and yours"
+	}
+	return msg
+}
+
+func NewFileVersions() *FileVersions {
+	return &FileVersions{seen: make(map[string]fileVersion)}
+}
+
+// Record notes content as the version of path the model has seen. A nil
+// FileVersions records nothing.
+func (v *FileVersions) Record(path string, content []byte) {
+	if v == nil {
+		return
+	}
+	key, err := ResolvePath(path)
+	if err != nil {
+		return
+	}
+	version := fileVersion{hash: sha256.Sum256(content)}
+	if len(content) <= maxMergeBaseBytes {
+		version.content = bytes.Clone(content)
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.seen[key] = version
+}
+
+// Check compares path with the version the model saw. It returns that
+// version as base and the file as it is now when they differ; files the
+// model never saw, or that are gone, never conflict.
+func (v *FileVersions) Check(path string) (base, current []byte, conflict bool) {
+	if v == nil {
+		return nil, nil, false
+	}
+	key, err := ResolvePath(path)
+	if err != nil {
+		return nil, nil, false
+	}
+	v.mu.Lock()
+	version, ok := v.seen[key]
+	v.mu.Unlock()
+	if !ok {
+		return nil, nil, false
+	}
+	current, err = os.ReadFile(path)
+	if err != nil {
+		return nil, nil, false
+	}
+	if sha256.Sum256(current) == version.hash {
+		return nil, nil, false
+	}
+	return version.content, current, true
+}
+
+// mergeFiles does a three-way merge with git merge-file. conflicts is the
+// number of hunks left with conflict markers in merged.
+func mergeFiles(ctx context.Context, base, current, mine []byte) (merged []byte, conflicts int, err error) {
+	dir, err := os.MkdirTemp("", "llemecode-merge-")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	paths := make([]string, 3)
+	for i, content := range [][]byte{current, base, mine} {
+		paths[i] = filepath.Join(dir, fmt.Sprint(i))
+		if err := os.WriteFile(paths[i], content, 0600); err != nil {
+			return nil, 0, err
+		}
+	}
+	cmd := exec.CommandContext(ctx, "git", "merge-file", "-p",
+		"-L", "theirs (current file)", "-L", "base (as you last saw it)", "-L", "yours",
+		paths[0], paths[1], paths[2])
+	merged, err = cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 && exitErr.ExitCode() < 128 {
+		return merged, exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("git merge-file: %w", err)
+	}
+	return merged, 0, nil
+}
@@ -0,0 +1,319 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultKubeLogLines = 100
+	maxKubeLogLines     = 2000
+)
+
+// kubectl runs kubectl against the configured context
+type kubectl struct {
+	context string
+}
+
+func (k kubectl) run(ctx context.Context, args ...string) (string, error) {
+	if k.context != "" {
+		args = append([]string{"--context", k.context}, args...)
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("kubectl %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	if stdout.Len() == 0 {
+		return strings.TrimSpace(stderr.String()), nil
+	}
+	return tail(stdout.String(), maxContainerOutput), nil
+}
+
+// namespaceArgs returns the namespace flags from the tool arguments
+func namespaceArgs(args map[string]interface{}) []string {
+	if all, _ := args["all_namespaces"].(bool); all {
+		return []string{"--all-namespaces"}
+	}
+	if ns, _ := args["namespace"].(string); ns != "" {
+		return []string{"--namespace", ns}
+	}
+	return nil
+}
+
+// kubeArgs collects string arguments, refusing values that kubectl would
+// read as flags
+func kubeArgs(args map[string]interface{}, names ...string) ([]string, error) {
+	var values []string
+	for _, name := range names {
+		v, _ := args[name].(string)
+		if v == "" {
+			continue
+		}
+		if strings.HasPrefix(v, "-") {
+			return nil, fmt.Errorf("%s must not start with '-': %q", name, v)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+var namespaceParams = map[string]interface{}{
+	"namespace": map[string]interface{}{
+		"type":        "string",
+		"description": "Namespace (default: the context's namespace)",
+	},
+}
+
+func withNamespaceParams(properties map[string]interface{}) map[string]interface{} {
+	for k, v := range namespaceParams {
+		properties[k] = v
+	}
+	return properties
+}
+
+// KubeGetTool lists Kubernetes resources
+type KubeGetTool struct {
+	kubectl kubectl
+}
+
+func NewKubeGetTool(kubeContext string) *KubeGetTool {
+	return &KubeGetTool{kubectl: kubectl{context: kubeContext}}
+}
+
+func (t *KubeGetTool) Name() string {
+	return "kube_get"
+}
+
+func (t *KubeGetTool) Description() string {
+	return "List Kubernetes resources with kubectl get -o wide, e.g. pods, deployments, services, events"
+}
+
+func (t *KubeGetTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": withNamespaceParams(map[string]interface{}{
+			"resource": map[string]interface{}{
+				"type":        "string",
+				"description": "Resource type, e.g. pods, deploy, svc, events",
+			},
+			"name": map[string]interface{}{
+				"type":        "string",
+				"description": "A single resource to get",
+			},
+			"selector": map[string]interface{}{
+				"type":        "string",
+				"description": "Label selector, e.g. app=web",
+			},
+			"all_namespaces": map[string]interface{}{
+				"type":        "boolean",
+				"description": "List across all namespaces",
+			},
+		}),
+		"required": []string{"resource"},
+	}
+}
+
+func (t *KubeGetTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	target, err := kubeArgs(args, "resource", "name")
+	if err != nil {
+		return "", err
+	}
+	if len(target) == 0 {
+		return "", fmt.Errorf("resource parameter is required")
+	}
+	cmdArgs := append([]string{"get"}, target...)
+	cmdArgs = append(cmdArgs, namespaceArgs(args)...)
+	if selector, _ := args["selector"].(string); selector != "" {
+		cmdArgs = append(cmdArgs, "--selector", selector)
+	}
+	return t.kubectl.run(ctx, append(cmdArgs, "-o", "wide")...)
+}
+
+// KubeDescribeTool shows a resource's details and recent events
+type KubeDescribeTool struct {
+	kubectl kubectl
+}
+
+func NewKubeDescribeTool(kubeContext string) *KubeDescribeTool {
+	return &KubeDescribeTool{kubectl: kubectl{context: kubeContext}}
+}
+
+func (t *KubeDescribeTool) Name() string {
+	return "kube_describe"
+}
+
+func (t *KubeDescribeTool) Description() string {
+	return "Describe a Kubernetes resource with kubectl describe, including its recent events"
+}
+
+func (t *KubeDescribeTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": withNamespaceParams(map[string]interface{}{
+			"resource": map[string]interface{}{
+				"type":        "string",
+				"description": "Resource type, e.g. pod, deployment, node",
+			},
+			"name": map[string]interface{}{
+				"type":        "string",
+				"description": "Resource name",
+			},
+		}),
+		"required": []string{"resource", "name"},
+	}
+}
+
+func (t *KubeDescribeTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	target, err := kubeArgs(args, "resource", "name")
+	if err != nil {
+		return "", err
+	}
+	if len(target) != 2 {
+		return "", fmt.Errorf("resource and name parameters are required")
+	}
+	return t.kubectl.run(ctx, append(append([]string{"describe"}, target...), namespaceArgs(args)...)...)
+}
+
+// KubeLogsTool shows the end of a pod's logs
+type KubeLogsTool struct {
+	kubectl kubectl
+}
+
+func NewKubeLogsTool(kubeContext string) *KubeLogsTool {
+	return &KubeLogsTool{kubectl: kubectl{context: kubeContext}}
+}
+
+func (t *KubeLogsTool) Name() string {
+	return "kube_logs"
+}
+
+func (t *KubeLogsTool) Description() string {
+	return "Show the last lines of a Kubernetes pod's logs, or of its previous run after a crash"
+}
+
+func (t *KubeLogsTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": withNamespaceParams(map[string]interface{}{
+			"pod": map[string]interface{}{
+				"type":        "string",
+				"description": "Pod name, or deploy/<name> for a deployment's pod",
+			},
+			"container": map[string]interface{}{
+				"type":        "string",
+				"description": "Container in the pod, if it has several",
+			},
+			"lines": map[string]interface{}{
+				"type":        "number",
+				"description": fmt.Sprintf("Lines from the end (default %d, max %d)", defaultKubeLogLines, maxKubeLogLines),
+			},
+			"previous": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Logs of the previous, crashed container",
+			},
+			"since": map[string]interface{}{
+				"type":        "string",
+				"description": "Only logs newer than a duration, e.g. 15m",
+			},
+		}),
+		"required": []string{"pod"},
+	}
+}
+
+func (t *KubeLogsTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	target, err := kubeArgs(args, "pod")
+	if err != nil {
+		return "", err
+	}
+	if len(target) == 0 {
+		return "", fmt.Errorf("pod parameter is required")
+	}
+	lines := defaultKubeLogLines
+	if n, ok := args["lines"].(float64); ok && n > 0 {
+		lines = min(int(n), maxKubeLogLines)
+	}
+	cmdArgs := append([]string{"logs", target[0], "--tail", strconv.Itoa(lines)}, namespaceArgs(args)...)
+	container, err := kubeArgs(args, "container")
+	if err != nil {
+		return "", err
+	}
+	if len(container) > 0 {
+		cmdArgs = append(cmdArgs, "--container", container[0])
+	}
+	if previous, _ := args["previous"].(bool); previous {
+		cmdArgs = append(cmdArgs, "--previous")
+	}
+	if since, _ := args["since"].(string); since != "" {
+		cmdArgs = append(cmdArgs, "--since", since)
+	}
+	out, err := t.kubectl.run(ctx, cmdArgs...)
+	if err != nil {
+		return "", err
+	}
+	if out == "" {
+		return "(no log output)", nil
+	}
+	return out, nil
+}
+
+// KubectlTool runs any kubectl command, including ones that change the
+// cluster. It is only available when mutating verbs are allowed.
+type KubectlTool struct {
+	kubectl kubectl
+}
+
+func NewKubectlTool(kubeContext string) *KubectlTool {
+	return &KubectlTool{kubectl: kubectl{context: kubeContext}}
+}
+
+func (t *KubectlTool) Name() string {
+	return "kubectl"
+}
+
+func (t *KubectlTool) Description() string {
+	return "Run a kubectl command that changes the cluster, e.g. apply, rollout restart, scale or delete. Prefer kube_get, kube_describe and kube_logs for looking."
+}
+
+func (t *KubectlTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"command": map[string]interface{}{
+				"type":        "string",
+				"description": "Arguments after kubectl, e.g. \"rollout restart deploy/web -n shop\"",
+			},
+		},
+		"required": []string{"command"},
+	}
+}
+
+func (t *KubectlTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	command, _ := args["command"].(string)
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(command), "kubectl "))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("command parameter is required")
+	}
+	for _, f := range fields {
+		if f == "--context" || strings.HasPrefix(f, "--context=") || f == "--kubeconfig" || strings.HasPrefix(f, "--kubeconfig=") {
+			return "", fmt.Errorf("%s can't be changed from a tool call", strings.SplitN(f, "=", 2)[0])
+		}
+	}
+	out, err := t.kubectl.run(ctx, fields...)
+	if err != nil {
+		return "", err
+	}
+	if out == "" {
+		return "(no output)", nil
+	}
+	return out, nil
+}
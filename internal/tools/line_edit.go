@@ -0,0 +1,288 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// contextPreviewLines is how many lines around the target an expectation
+// mismatch shows, so the model can retry without reading the whole file
+const contextPreviewLines = 3
+
+// AppendToFileTool adds text to the end of a file
+type AppendToFileTool struct {
+	policy   *PathPolicy
+	versions *FileVersions
+}
+
+func NewAppendToFileTool() *AppendToFileTool {
+	return &AppendToFileTool{}
+}
+
+// SetPathPolicy restricts which paths the tool may use
+func (t *AppendToFileTool) SetPathPolicy(policy *PathPolicy) {
+	t.policy = policy
+}
+
+// SetFileVersions keeps the versions write_file checks up to date
+func (t *AppendToFileTool) SetFileVersions(versions *FileVersions) {
+	t.versions = versions
+}
+
+func (t *AppendToFileTool) Name() string {
+	return "append_to_file"
+}
+
+func (t *AppendToFileTool) Description() string {
+	return "Add lines to the end of a file, creating it if needed. Cheaper and safer than rewriting the whole file"
+}
+
+func (t *AppendToFileTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File to append to",
+			},
+			"content": map[string]interface{}{
+				"type":        "string",
+				"description": "Lines to add",
+			},
+			"expect_last": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional: the lines you expect the file to end with now; nothing is written if it doesn't",
+			},
+		},
+		"required": []string{"path", "content"},
+	}
+}
+
+func (t *AppendToFileTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	path, ok := args["path"].(string)
+	if !ok {
+		return "", fmt.Errorf("path must be a string")
+	}
+	content, ok := args["content"].(string)
+	if !ok {
+		return "", fmt.Errorf("content must be a string")
+	}
+	if err := t.policy.Check(path); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("read file: %w", err)
+	}
+	lines := fileLines(string(data))
+	if expect, _ := args["expect_last"].(string); expect != "" {
+		want := fileLines(expect)
+		if !linesMatch(lines, len(lines)-len(want), want) {
+			return "", fmt.Errorf("%s does not end as expected; nothing was written. It ends with:\n%s", path, numberedLines(lines, len(lines)-max(len(want), contextPreviewLines), len(lines)))
+		}
+	}
+
+	text := string(data)
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	text += content
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("create directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		return "", fmt.Errorf("write file: %w", err)
+	}
+	t.versions.Record(path, []byte(text))
+
+	added := len(fileLines(content))
+	return fmt.Sprintf("Appended %d lines to %s (lines %d-%d)", added, path, len(lines)+1, len(lines)+added), nil
+}
+
+// InsertAtLineTool inserts lines into a file, after checking the lines
+// around the insertion point are what the model expects
+type InsertAtLineTool struct {
+	policy   *PathPolicy
+	versions *FileVersions
+}
+
+func NewInsertAtLineTool() *InsertAtLineTool {
+	return &InsertAtLineTool{}
+}
+
+// SetPathPolicy restricts which paths the tool may use
+func (t *InsertAtLineTool) SetPathPolicy(policy *PathPolicy) {
+	t.policy = policy
+}
+
+// SetFileVersions keeps the versions write_file checks up to date
+func (t *InsertAtLineTool) SetFileVersions(versions *FileVersions) {
+	t.versions = versions
+}
+
+func (t *InsertAtLineTool) Name() string {
+	return "insert_at_line"
+}
+
+func (t *InsertAtLineTool) Description() string {
+	return "Insert lines into a file before a given line number. Give the lines you expect right before and after that point, so the edit lands where you mean even if the line numbers you saw are stale"
+}
+
+func (t *InsertAtLineTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File to insert into",
+			},
+			"line": map[string]interface{}{
+				"type":        "integer",
+				"description": "1-based line number the new lines go before; one past the last line appends",
+			},
+			"content": map[string]interface{}{
+				"type":        "string",
+				"description": "Lines to insert",
+			},
+			"expect_before": map[string]interface{}{
+				"type":        "string",
+				"description": "Lines you expect right before the insertion point",
+			},
+			"expect_after": map[string]interface{}{
+				"type":        "string",
+				"description": "Lines you expect right after the insertion point",
+			},
+		},
+		"required": []string{"path", "line", "content"},
+	}
+}
+
+func (t *InsertAtLineTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	path, ok := args["path"].(string)
+	if !ok {
+		return "", fmt.Errorf("path must be a string")
+	}
+	content, ok := args["content"].(string)
+	if !ok {
+		return "", fmt.Errorf("content must be a string")
+	}
+	line, ok := intArg(args["line"])
+	if !ok {
+		return "", fmt.Errorf("line must be a number")
+	}
+	if err := t.policy.Check(path); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read file: %w", err)
+	}
+	lines := fileLines(string(data))
+	if line < 1 || line > len(lines)+1 {
+		return "", fmt.Errorf("line %d is outside %s, which has %d lines", line, path, len(lines))
+	}
+
+	at := line - 1
+	before := fileLines(stringArg(args["expect_before"]))
+	after := fileLines(stringArg(args["expect_after"]))
+	if !linesMatch(lines, at-len(before), before) || !linesMatch(lines, at, after) {
+		// The model's line numbers are often a little off; use the one
+		// place the expected lines fit, if there is exactly one
+		found := -1
+		if len(before)+len(after) > 0 {
+			for i := 0; i <= len(lines); i++ {
+				if linesMatch(lines, i-len(before), before) && linesMatch(lines, i, after) {
+					if found >= 0 {
+						found = -1
+						break
+					}
+					found = i
+				}
+			}
+		}
+		if found < 0 {
+			from := max(at-max(len(before), contextPreviewLines), 0)
+			to := min(at+max(len(after), contextPreviewLines), len(lines))
+			return "", fmt.Errorf("the lines around line %d of %s are not what you expected; nothing was written. They are:\n%s", line, path, numberedLines(lines, from, to))
+		}
+		at = found
+	}
+
+	inserted := fileLines(content)
+	updated := append(append(append([]string{}, lines[:at]...), inserted...), lines[at:]...)
+	text := strings.Join(updated, "\n")
+	if len(updated) > 0 {
+		text += "\n"
+	}
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		return "", fmt.Errorf("write file: %w", err)
+	}
+	t.versions.Record(path, []byte(text))
+
+	if at != line-1 {
+		return fmt.Sprintf("Inserted %d lines into %s at line %d, where the expected lines are (not line %d)", len(inserted), path, at+1, line), nil
+	}
+	return fmt.Sprintf("Inserted %d lines into %s at line %d", len(inserted), path, at+1), nil
+}
+
+// fileLines splits text into lines without a trailing empty one
+func fileLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// linesMatch reports whether want occurs in lines at index at, ignoring
+// trailing whitespace
+func linesMatch(lines []string, at int, want []string) bool {
+	if at < 0 || at+len(want) > len(lines) {
+		return false
+	}
+	for i, line := range want {
+		if strings.TrimRight(lines[at+i], " \t\r") != strings.TrimRight(line, " \t\r") {
+			return false
+		}
+	}
+	return true
+}
+
+// numberedLines shows lines[from:to] with 1-based line numbers
+func numberedLines(lines []string, from, to int) string {
+	from = max(from, 0)
+	if from >= to {
+		return "(the file is empty)"
+	}
+	var sb strings.Builder
+	for i := from; i < to; i++ {
+		sb.WriteString(fmt.Sprintf("%5d  %s\n", i+1, lines[i]))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// intArg reads a number the model may have sent as a JSON number or a string
+func intArg(v interface{}) (int, bool) {
+	switch v := v.(type) {
+	case float64:
+		return int(v), true
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func stringArg(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
@@ -8,12 +8,19 @@ import (
 	"strings"
 )
 
-type ListFilesTool struct{}
+type ListFilesTool struct {
+	policy *PathPolicy
+}
 
 func NewListFilesTool() *ListFilesTool {
 	return &ListFilesTool{}
 }
 
+// SetPathPolicy restricts which paths the tool may use
+func (t *ListFilesTool) SetPathPolicy(policy *PathPolicy) {
+	t.policy = policy
+}
+
 func (t *ListFilesTool) Name() string {
 	return "list_files"
 }
@@ -44,6 +51,9 @@ func (t *ListFilesTool) Execute(ctx context.Context, args map[string]interface{}
 	if !ok {
 		return "", fmt.Errorf("path must be a string")
 	}
+	if err := t.policy.Check(path); err != nil {
+		return "", err
+	}
 
 	recursive := false
 	if r, ok := args["recursive"].(bool); ok {
@@ -56,6 +66,13 @@ func (t *ListFilesTool) Execute(ctx context.Context, args map[string]interface{}
 			if err != nil {
 				return err
 			}
+			if p != path && t.policy.Check(p) != nil {
+				// Don't descend into denied directories
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
 			files = append(files, p)
 			return nil
 		})
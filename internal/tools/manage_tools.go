@@ -117,17 +117,15 @@ func (t *AddCustomToolTool) Execute(ctx context.Context, args map[string]interfa
 	t.registry.Register(customTool)
 
 	// Save to config
-	if t.config.CustomTools == nil {
-		t.config.CustomTools = []map[string]interface{}{}
-	}
-
 	toolData, err := SerializeCustomTool(customTool)
 	if err != nil {
 		return "", fmt.Errorf("failed to serialize tool: %w", err)
 	}
 
-	t.config.CustomTools = append(t.config.CustomTools, toolData)
-	if err := t.config.Save(); err != nil {
+	err = t.config.Update(func(c *config.Config) {
+		c.CustomTools = append(c.CustomTools, toolData)
+	})
+	if err != nil {
 		return "", fmt.Errorf("failed to save config: %w", err)
 	}
 
@@ -193,8 +191,10 @@ func (t *RemoveCustomToolTool) Execute(ctx context.Context, args map[string]inte
 		return "", fmt.Errorf("custom tool '%s' not found", name)
 	}
 
-	t.config.CustomTools = newCustomTools
-	if err := t.config.Save(); err != nil {
+	err := t.config.Update(func(c *config.Config) {
+		c.CustomTools = newCustomTools
+	})
+	if err != nil {
 		return "", fmt.Errorf("failed to save config: %w", err)
 	}
 
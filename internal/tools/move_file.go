@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// MoveFileTool renames or moves a file or directory
+type MoveFileTool struct {
+	policy *PathPolicy
+}
+
+func NewMoveFileTool() *MoveFileTool {
+	return &MoveFileTool{}
+}
+
+// SetPathPolicy restricts which paths the tool may use
+func (t *MoveFileTool) SetPathPolicy(policy *PathPolicy) {
+	t.policy = policy
+}
+
+func (t *MoveFileTool) Name() string {
+	return "move_file"
+}
+
+func (t *MoveFileTool) Description() string {
+	return "Rename or move a file or directory. The destination must not exist yet; missing parent directories are created"
+}
+
+func (t *MoveFileTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File or directory to move",
+			},
+			"destination": map[string]interface{}{
+				"type":        "string",
+				"description": "New path, including the new name",
+			},
+		},
+		"required": []string{"path", "destination"},
+	}
+}
+
+// TargetPaths returns the source and destination, so both are checked
+// against the workspace jail
+func (t *MoveFileTool) TargetPaths(args map[string]interface{}) []string {
+	var paths []string
+	for _, key := range []string{"path", "destination"} {
+		if path, ok := args[key].(string); ok && path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// ApprovalDetails shows where the file is going
+func (t *MoveFileTool) ApprovalDetails(args map[string]interface{}) (string, error) {
+	from, to, err := t.paths(args)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("move %s → %s", from, to), nil
+}
+
+func (t *MoveFileTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	from, to, err := t.paths(args)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return "", fmt.Errorf("create directory: %w", err)
+	}
+	if err := movePath(from, to); err != nil {
+		return "", fmt.Errorf("move %s: %w", from, err)
+	}
+	return fmt.Sprintf("Moved %s to %s", from, to), nil
+}
+
+func (t *MoveFileTool) paths(args map[string]interface{}) (from, to string, err error) {
+	from, _ = args["path"].(string)
+	to, _ = args["destination"].(string)
+	if from == "" || to == "" {
+		return "", "", fmt.Errorf("path and destination must be strings")
+	}
+	for _, path := range []string{from, to} {
+		if err := t.policy.Check(path); err != nil {
+			return "", "", err
+		}
+	}
+	if _, err := os.Lstat(from); err != nil {
+		return "", "", fmt.Errorf("cannot move %s: %w", from, err)
+	}
+	if _, err := os.Lstat(to); err == nil {
+		return "", "", fmt.Errorf("cannot move to %s: it already exists", to)
+	}
+	return from, to, nil
+}
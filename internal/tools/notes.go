@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/notes"
+)
+
+// RememberTool lets the agent keep a fact about the project for later sessions
+type RememberTool struct {
+	notes *notes.Store
+}
+
+func NewRememberTool(store *notes.Store) *RememberTool {
+	return &RememberTool{notes: store}
+}
+
+func (t *RememberTool) Name() string {
+	return "remember"
+}
+
+func (t *RememberTool) Description() string {
+	return "Remember a fact about this project for future sessions, such as where configuration lives or what tests need (e.g. \"tests need docker compose up\"). Keep it to one short sentence."
+}
+
+func (t *RememberTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"fact": map[string]interface{}{
+				"type":        "string",
+				"description": "The fact to remember",
+			},
+		},
+		"required": []string{"fact"},
+	}
+}
+
+func (t *RememberTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	fact, ok := args["fact"].(string)
+	if !ok || strings.TrimSpace(fact) == "" {
+		return "", fmt.Errorf("fact parameter is required")
+	}
+	added, err := t.notes.Add(fact)
+	if err != nil {
+		return "", err
+	}
+	if !added {
+		return "Already remembered.", nil
+	}
+	return fmt.Sprintf("Remembered in %s", t.notes.Path()), nil
+}
+
+// RecallTool searches the facts remembered about the project
+type RecallTool struct {
+	notes *notes.Store
+}
+
+func NewRecallTool(store *notes.Store) *RecallTool {
+	return &RecallTool{notes: store}
+}
+
+func (t *RecallTool) Name() string {
+	return "recall"
+}
+
+func (t *RecallTool) Description() string {
+	return "Recall facts remembered about this project in earlier sessions. Give words to search for, or nothing to list every fact."
+}
+
+func (t *RecallTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Words the fact must contain (optional)",
+			},
+		},
+	}
+}
+
+func (t *RecallTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	query, _ := args["query"].(string)
+	matches, err := t.notes.Search(query)
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		if query == "" {
+			return "Nothing has been remembered about this project yet.", nil
+		}
+		return fmt.Sprintf("Nothing remembered matches %q.", query), nil
+	}
+
+	var sb strings.Builder
+	for _, note := range matches {
+		sb.WriteString("- " + note.Text)
+		if note.Added != "" {
+			sb.WriteString(" (" + note.Added + ")")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxStoredOutput caps the bytes of full tool outputs kept for fetch_more;
+// the oldest are dropped first
+const maxStoredOutput = 64 * 1024 * 1024
+
+// OutputStore keeps the full text of tool results that were cut short for
+// the model, for the rest of the session
+type OutputStore struct {
+	mu      sync.Mutex
+	outputs map[string]string
+	order   []string
+	size    int
+	next    int
+}
+
+func NewOutputStore() *OutputStore {
+	return &OutputStore{outputs: make(map[string]string)}
+}
+
+// Put stores text and returns its handle
+func (s *OutputStore) Put(text string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next++
+	handle := fmt.Sprintf("out-%d", s.next)
+	s.outputs[handle] = text
+	s.order = append(s.order, handle)
+	s.size += len(text)
+	for s.size > maxStoredOutput && len(s.order) > 1 {
+		oldest := s.order[0]
+		s.order = s.order[1:]
+		s.size -= len(s.outputs[oldest])
+		delete(s.outputs, oldest)
+	}
+	return handle
+}
+
+// Get returns the text stored under handle
+func (s *OutputStore) Get(handle string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	text, ok := s.outputs[handle]
+	return text, ok
+}
+
+// Page returns up to size bytes of text from offset, ending at a line break
+// when there is one in the second half, and the offset of the rest
+func Page(text string, offset, size int) (string, int) {
+	for offset < len(text) && !utf8.RuneStart(text[offset]) {
+		offset++
+	}
+	end := offset + size
+	if end >= len(text) {
+		return text[offset:], len(text)
+	}
+	if i := strings.LastIndexByte(text[offset:end], '\n'); i >= size/2 {
+		end = offset + i + 1
+	}
+	for end > offset && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return text[offset:end], end
+}
+
+// FetchMoreTool pages through tool results that were too long to show whole
+type FetchMoreTool struct {
+	store    *OutputStore
+	pageSize int // Bytes per page
+}
+
+func NewFetchMoreTool(store *OutputStore, pageSize int) *FetchMoreTool {
+	return &FetchMoreTool{store: store, pageSize: pageSize}
+}
+
+func (t *FetchMoreTool) Name() string {
+	return "fetch_more"
+}
+
+func (t *FetchMoreTool) Description() string {
+	return "Read more of a tool result that was truncated, using the handle and offset given in the truncation note"
+}
+
+func (t *FetchMoreTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"handle": map[string]interface{}{
+				"type":        "string",
+				"description": "Handle from the truncation note, e.g. out-3",
+			},
+			"offset": map[string]interface{}{
+				"type":        "number",
+				"description": "Byte offset to continue from",
+			},
+		},
+		"required": []string{"handle"},
+	}
+}
+
+func (t *FetchMoreTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	handle, _ := args["handle"].(string)
+	text, ok := t.store.Get(handle)
+	if !ok {
+		return "", fmt.Errorf("no stored output %q; it may have been dropped, so run the tool again", handle)
+	}
+	offset := 0
+	if n, ok := args["offset"].(float64); ok && n > 0 {
+		offset = int(n)
+	}
+	if offset >= len(text) {
+		return fmt.Sprintf("%s has only %d bytes; there is nothing after offset %d", handle, len(text), offset), nil
+	}
+
+	page, next := Page(text, offset, t.pageSize)
+	if next >= len(text) {
+		return page + fmt.Sprintf("\n[End of %s, bytes %d-%d of %d]", handle, offset, next, len(text)), nil
+	}
+	return page + fmt.Sprintf("\n[%s bytes %d-%d of %d. Call fetch_more with handle %q and offset %d for more.]", handle, offset, next, len(text), handle, next), nil
+}
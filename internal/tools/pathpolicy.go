@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// PathPolicy limits which paths the file tools may touch. It is enforced by
+// the tools themselves, so approvals and always-allow patterns can't bypass
+// it. Symlinks are resolved before checking.
+type PathPolicy struct {
+	mu      sync.RWMutex
+	allowed []string // If non-empty, paths must be inside one of these
+	denied  []string // Paths inside these are always refused
+}
+
+func NewPathPolicy(allowed, denied []string) *PathPolicy {
+	p := &PathPolicy{}
+	p.SetRoots(allowed, denied)
+	return p
+}
+
+// SetRoots replaces the allowed and denied roots
+func (p *PathPolicy) SetRoots(allowed, denied []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.allowed = resolveRoots(allowed)
+	p.denied = resolveRoots(denied)
+}
+
+// Roots returns the resolved allowed and denied roots
+func (p *PathPolicy) Roots() (allowed, denied []string) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]string(nil), p.allowed...), append([]string(nil), p.denied...)
+}
+
+// Check returns an error if path may not be used. A nil policy allows everything.
+func (p *PathPolicy) Check(path string) error {
+	if p == nil {
+		return nil
+	}
+
+	resolved, err := ResolvePath(path)
+	if err != nil {
+		return fmt.Errorf("resolve path '%s': %w", path, err)
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, root := range p.denied {
+		if isWithin(root, resolved) {
+			return fmt.Errorf("access denied: '%s' is inside denied root '%s'", path, root)
+		}
+	}
+
+	if len(p.allowed) == 0 {
+		return nil
+	}
+	for _, root := range p.allowed {
+		if isWithin(root, resolved) {
+			return nil
+		}
+	}
+	return fmt.Errorf("access denied: '%s' is not inside any allowed root", path)
+}
+
+// ResolvePath makes path absolute and resolves symlinks. For paths that don't
+// exist yet, the deepest existing parent is resolved and the rest appended,
+// so a new file under a symlinked directory is attributed to its target.
+func ResolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(ExpandHome(path))
+	if err != nil {
+		return "", err
+	}
+
+	existing := abs
+	var rest []string
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return abs, nil
+		}
+		rest = append([]string{filepath.Base(existing)}, rest...)
+		existing = parent
+	}
+}
+
+// ExpandHome replaces a leading ~ with the user's home directory
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+func resolveRoots(roots []string) []string {
+	resolved := make([]string, 0, len(roots))
+	for _, root := range roots {
+		if r, err := ResolvePath(root); err == nil {
+			resolved = append(resolved, r)
+		}
+	}
+	return resolved
+}
+
+// isWithin reports whether path is root or inside it
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 // PermissionLevel defines how dangerous a tool operation is
@@ -26,6 +27,23 @@ type PermissionChecker interface {
 	RequestPermission(ctx context.Context, tool string, level PermissionLevel, details string) (bool, error)
 }
 
+// OutsideWorkspaceDecision is the user's answer when a tool targets a path
+// outside the workspace
+type OutsideWorkspaceDecision int
+
+const (
+	OutsideWorkspaceDeny           OutsideWorkspaceDecision = iota
+	OutsideWorkspaceAllowOnce                               // Allow this one operation
+	OutsideWorkspaceAddRoot                                 // Allow and add the directory to the allowed roots
+	OutsideWorkspaceAddSessionRoot                          // Allow the directory until the program exits
+)
+
+// OutsideWorkspaceChecker is implemented by permission checkers that can ask
+// about paths outside the workspace. Without one such paths are refused.
+type OutsideWorkspaceChecker interface {
+	RequestOutsideWorkspace(ctx context.Context, tool, path string) (OutsideWorkspaceDecision, error)
+}
+
 // PermissionPattern represents a permission rule
 type PermissionPattern struct {
 	Tool           string
@@ -33,6 +51,8 @@ type PermissionPattern struct {
 	CommandPattern string
 	AlwaysAllow    bool
 	Enabled        bool
+	Session        bool   // Granted for this session only, never saved
+	Root           string // If set, only applies inside this project root
 }
 
 // PermissionConfig defines what requires approval
@@ -53,22 +73,213 @@ type PermissionConfig struct {
 	AlwaysAllowPatterns []PermissionPattern
 	// Restrict to working directory
 	RestrictToWorkingDir bool
+	// Directories outside the working directory that are still allowed
+	AllowedRoots []string
+	// Run only AllowedCommands without asking; anything else always asks
+	CommandAllowlist bool
+	// Programs run_command may start without approval in allowlist mode
+	AllowedCommands []string
+
+	policy       *Policy         // Administrator limits on all of the above
+	mu           sync.RWMutex    // Guards the fields above that change at runtime
+	sessionRoots map[string]bool // Allowed roots granted for this session only
+	projectRoots []ProjectRoot   // Named roots of a multi-root workspace
+}
+
+// Workspace returns whether the working directory jail is on and the extra
+// roots it allows, none when the policy keeps the jail on
+func (c *PermissionConfig) Workspace() (bool, []string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.policy != nil && c.policy.RestrictToWorkingDir {
+		return true, nil
+	}
+	return c.RestrictToWorkingDir, append([]string(nil), c.AllowedRoots...)
+}
+
+// JailLocked reports whether the policy keeps the jail on, so paths outside
+// the workspace are refused without asking
+func (c *PermissionConfig) JailLocked() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.policy != nil && c.policy.RestrictToWorkingDir
+}
+
+// SetPolicy enforces policy on every tool using this config
+func (c *PermissionConfig) SetPolicy(policy *Policy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.policy = policy
+}
+
+// Policy returns the enforced policy, nil when there is none
+func (c *PermissionConfig) Policy() *Policy {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.policy
+}
+
+// SetRestrictToWorkingDir turns the working directory jail on or off
+func (c *PermissionConfig) SetRestrictToWorkingDir(restrict bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.RestrictToWorkingDir = restrict
+}
+
+// SetAllowedRoots replaces the extra roots allowed by the jail
+func (c *PermissionConfig) SetAllowedRoots(roots []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.AllowedRoots = append([]string(nil), roots...)
+}
+
+// AddAllowedRoot allows another directory, returning false if it already was
+func (c *PermissionConfig) AddAllowedRoot(root string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.sessionRoots, root)
+	for _, existing := range c.AllowedRoots {
+		if existing == root {
+			return false
+		}
+	}
+	c.AllowedRoots = append(c.AllowedRoots, root)
+	return true
+}
+
+// AddSessionRoot allows a directory until the program exits
+func (c *PermissionConfig) AddSessionRoot(root string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, existing := range c.AllowedRoots {
+		if existing == root {
+			return false
+		}
+	}
+	if c.sessionRoots == nil {
+		c.sessionRoots = make(map[string]bool)
+	}
+	c.sessionRoots[root] = true
+	c.AllowedRoots = append(c.AllowedRoots, root)
+	return true
+}
+
+// RemoveAllowedRoot stops allowing a directory
+func (c *PermissionConfig) RemoveAllowedRoot(root string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.sessionRoots, root)
+	kept := []string{}
+	for _, existing := range c.AllowedRoots {
+		if existing != root {
+			kept = append(kept, existing)
+		}
+	}
+	c.AllowedRoots = kept
+}
+
+// IsSessionRoot reports whether root was only allowed for this session
+func (c *PermissionConfig) IsSessionRoot(root string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.sessionRoots[root]
+}
+
+// Patterns returns the always-allow patterns, saved and session-only
+func (c *PermissionConfig) Patterns() []PermissionPattern {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]PermissionPattern(nil), c.AlwaysAllowPatterns...)
+}
+
+// AddAlwaysAllowPattern starts honouring a new grant right away. A saved
+// grant replaces an equivalent session one; otherwise duplicates are
+// ignored and false is returned.
+func (c *PermissionConfig) AddAlwaysAllowPattern(pattern PermissionPattern) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, existing := range c.AlwaysAllowPatterns {
+		if existing.Tool == pattern.Tool &&
+			existing.PathPattern == pattern.PathPattern &&
+			existing.CommandPattern == pattern.CommandPattern &&
+			existing.AlwaysAllow == pattern.AlwaysAllow &&
+			existing.Root == pattern.Root {
+			if existing.Session && !pattern.Session {
+				c.AlwaysAllowPatterns[i] = pattern
+				return true
+			}
+			return false
+		}
+	}
+	c.AlwaysAllowPatterns = append(c.AlwaysAllowPatterns, pattern)
+	return true
+}
+
+// SetCommandAllowlist turns allowlist mode for run_command on or off
+func (c *PermissionConfig) SetCommandAllowlist(on bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.CommandAllowlist = on
+}
+
+// Allowlist returns whether allowlist mode is on and the allowed programs
+func (c *PermissionConfig) Allowlist() (bool, []string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.CommandAllowlist, append([]string(nil), c.AllowedCommands...)
+}
+
+// SetAllowedCommands replaces the programs allowed in allowlist mode
+func (c *PermissionConfig) SetAllowedCommands(commands []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.AllowedCommands = append([]string(nil), commands...)
+}
+
+// DefaultAllowedCommands returns the programs allowed in allowlist mode out
+// of the box: build tools, version control and read-only utilities.
+// Interpreters and env are left out, since they run any code they're given.
+func DefaultAllowedCommands() []string {
+	return []string{
+		"go", "gofmt", "git", "npm", "yarn", "pnpm", "make", "cargo", "pip", "pytest",
+		"ls", "cat", "head", "tail", "wc", "grep", "diff", "sort", "uniq",
+		"echo", "pwd", "cd", "true", "false", "test", "which",
+	}
+}
+
+// DefaultBlockedCommands returns the rules refused out of the box, in
+// CommandBlocklist syntax
+func DefaultBlockedCommands() []string {
+	return []string{
+		"rm -rf /",
+		"rm -rf ~",
+		"dd of=/dev/*",
+		"mkfs",
+		":(){ :|:& };:", // Fork bomb
+		"> /dev/sd*",
+		"> /dev/nvme*",
+		"chmod -R 777 /",
+		"curl | sh", // Any shell
+		"wget | sh",
+		"curl | python*",
+		"wget | python*",
+		"curl | perl",
+		"wget | perl",
+		"tee /dev/sd*",
+		"tee /dev/nvme*",
+	}
 }
 
 func DefaultPermissionConfig() *PermissionConfig {
 	return &PermissionConfig{
+		RestrictToWorkingDir:   true,
 		AutoApproveSafe:        true,
 		AutoApproveRead:        true,
 		RequireApprovalWrite:   true,
 		RequireApprovalExecute: true,
 		RequireApprovalNetwork: false,
-		BlockedCommands: []string{
-			"rm -rf /",
-			"dd if=",
-			"mkfs",
-			":(){ :|:& };:", // Fork bomb
-			"> /dev/sda",
-		},
+		BlockedCommands:        DefaultBlockedCommands(),
+		AllowedCommands:        DefaultAllowedCommands(),
 	}
 }
 
@@ -76,6 +287,7 @@ func DefaultPermissionConfig() *PermissionConfig {
 type ProtectedTool struct {
 	tool             Tool
 	level            PermissionLevel
+	extraLevels      []PermissionLevel // Also need approval when any of these does
 	checker          PermissionChecker
 	permissionConfig *PermissionConfig
 }
@@ -100,107 +312,233 @@ func (pt *ProtectedTool) Description() string {
 	return pt.tool.Description()
 }
 
+// Parameters adds the optional root argument to tools that use paths or
+// run commands once project roots are registered
 func (pt *ProtectedTool) Parameters() map[string]interface{} {
-	return pt.tool.Parameters()
+	params := pt.tool.Parameters()
+	if roots := pt.permissionConfig.ProjectRoots(); len(roots) > 0 && takesRoot(pt.tool) {
+		return withRootParameter(params, roots)
+	}
+	return params
 }
 
 func (pt *ProtectedTool) SetChecker(checker PermissionChecker) {
 	pt.checker = checker
 }
 
+// AlsoNeeds makes the tool ask for approval when level would, as well as
+// when its own level would, e.g. for a download that is network and write
+func (pt *ProtectedTool) AlsoNeeds(level PermissionLevel) *ProtectedTool {
+	pt.extraLevels = append(pt.extraLevels, level)
+	return pt
+}
+
+// Level returns the permission level the tool was registered with
+func (pt *ProtectedTool) Level() PermissionLevel {
+	return pt.level
+}
+
 func (pt *ProtectedTool) UnwrapTool() Tool {
 	return pt.tool
 }
 
 func (pt *ProtectedTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	policy := pt.permissionConfig.Policy()
+	if policy.disablesTool(pt.tool) {
+		return "", fmt.Errorf("%s is disabled by the team policy", pt.tool.Name())
+	}
+
+	args, root, err := pt.permissionConfig.applyProjectRoot(pt.tool, args)
+	if err != nil {
+		return "", err
+	}
+	if root.Path != "" {
+		ctx = WithWorkDir(ctx, root.Path)
+	}
+
 	// Extract path from args if present
-	var targetPath string
+	var pathArg, command string
 	if path, ok := args["path"].(string); ok {
-		targetPath = path
+		pathArg = path
 	} else if path, ok := args["file_path"].(string); ok {
-		targetPath = path
-	} else if cmd, ok := args["command"].(string); ok {
-		// For commands, we'll check the command string itself
-		targetPath = cmd
+		pathArg = path
 	}
+	if cmd, ok := args["command"].(string); ok {
+		command = cmd
+	}
+
+	// Canonicalize the path so the jail and always-allow patterns see the
+	// real target, not "../" tricks or a symlink pointing somewhere else
+	var resolvedPath string
+	if pathArg != "" {
+		resolved, err := ResolvePath(pathArg)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve path '%s': %w", pathArg, err)
+		}
+		resolvedPath = resolved
 
-	// Check if operation is outside working directory (if restricted)
-	if pt.permissionConfig.RestrictToWorkingDir && targetPath != "" {
-		if err := checkWorkingDirRestriction(targetPath); err != nil {
+		// Check if operation is outside the workspace (if restricted)
+		if err := pt.checkWorkspace(ctx, pathArg, resolvedPath); err != nil {
 			return "", err
 		}
 	}
-
-	// Check if this matches an "always allow" pattern
-	if pt.matchesAlwaysAllowPattern(targetPath) {
-		return pt.tool.Execute(ctx, args)
+	if multi, ok := pt.tool.(MultiPathTool); ok {
+		for _, path := range multi.TargetPaths(args) {
+			resolved, err := ResolvePath(path)
+			if err != nil {
+				return "", fmt.Errorf("failed to resolve path '%s': %w", path, err)
+			}
+			if err := pt.checkWorkspace(ctx, path, resolved); err != nil {
+				return "", err
+			}
+		}
 	}
 
-	// Check if approval is needed
-	needsApproval := false
+	// Blocked commands are refused before anything can approve them
+	if pt.tool.Name() == "run_command" && command != "" {
+		blocklist, err := NewCommandBlocklist(pt.permissionConfig.BlockedCommands)
+		if err != nil {
+			return "", err
+		}
+		if err := blocklist.Check(command); err != nil {
+			return "", err
+		}
+		if policy != nil && len(policy.BlockedCommands) > 0 {
+			enforced, err := NewCommandBlocklist(policy.BlockedCommands)
+			if err != nil {
+				return "", fmt.Errorf("team policy: %w", err)
+			}
+			if err := enforced.Check(command); err != nil {
+				return "", fmt.Errorf("%w (team policy)", err)
+			}
+		}
 
-	switch pt.level {
-	case PermissionSafe:
-		needsApproval = !pt.permissionConfig.AutoApproveSafe
-	case PermissionRead:
-		needsApproval = !pt.permissionConfig.AutoApproveRead
-	case PermissionWrite:
-		needsApproval = pt.permissionConfig.RequireApprovalWrite
-	case PermissionExecute:
-		needsApproval = pt.permissionConfig.RequireApprovalExecute
-	case PermissionNetwork:
-		needsApproval = pt.permissionConfig.RequireApprovalNetwork
+		// In allowlist mode listed programs run without asking and
+		// everything else asks, whatever the levels or saved patterns say
+		if on, allowed := pt.permissionConfig.Allowlist(); on && !pt.lockedByPolicy(policy) {
+			if NewCommandAllowlist(allowed).Allows(command) {
+				return pt.tool.Execute(ctx, args)
+			}
+			if pt.checker == nil {
+				return "", fmt.Errorf("command not on the allowlist and approval can't be requested: %s", command)
+			}
+			if err := pt.requestApproval(ctx, args, resolvedPath, root); err != nil {
+				return "", err
+			}
+			return pt.tool.Execute(ctx, args)
+		}
 	}
 
-	// Special handling for run_command tool
-	if pt.tool.Name() == "run_command" {
-		if cmd, ok := args["command"].(string); ok {
-			// Check blocked commands
-			for _, blocked := range pt.permissionConfig.BlockedCommands {
-				if contains(cmd, blocked) {
-					return "", fmt.Errorf("blocked command pattern detected: %s", blocked)
-				}
-			}
+	// Levels the policy locks always ask someone who can say no
+	if pt.lockedByPolicy(policy) {
+		if _, auto := pt.checker.(*AutoApproveChecker); auto || pt.checker == nil {
+			return "", fmt.Errorf("%s needs approval under the team policy, which can't be asked for here", pt.tool.Name())
 		}
+		if err := pt.requestApproval(ctx, args, resolvedPath, root); err != nil {
+			return "", err
+		}
+		return pt.tool.Execute(ctx, args)
+	}
+
+	// Check if this matches an "always allow" pattern
+	if pt.matchesAlwaysAllowPattern(command, resolvedPath, root) {
+		return pt.tool.Execute(ctx, args)
+	}
+
+	// Check if approval is needed
+	needsApproval := pt.needsApproval(pt.level)
+	for _, level := range pt.extraLevels {
+		needsApproval = needsApproval || pt.needsApproval(level)
 	}
 
 	if needsApproval && pt.checker != nil {
-		details := fmt.Sprintf("Args: %v", args)
-		approved, err := pt.checker.RequestPermission(ctx, pt.tool.Name(), pt.level, details)
-		if err != nil {
-			return "", fmt.Errorf("permission check failed: %w", err)
-		}
-		if !approved {
-			return "", fmt.Errorf("permission denied by user")
+		if err := pt.requestApproval(ctx, args, resolvedPath, root); err != nil {
+			return "", err
 		}
 	}
 
 	return pt.tool.Execute(ctx, args)
 }
 
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) &&
-		(s == substr || len(s) > len(substr) &&
-			(s[:len(substr)] == substr ||
-				s[len(s)-len(substr):] == substr ||
-				findInString(s, substr)))
-}
-
-func findInString(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
+// lockedByPolicy reports whether the policy makes this tool always ask
+func (pt *ProtectedTool) lockedByPolicy(policy *Policy) bool {
+	if policy.requiresApproval(pt.level) {
+		return true
+	}
+	for _, level := range pt.extraLevels {
+		if policy.requiresApproval(level) {
 			return true
 		}
 	}
 	return false
 }
 
-func (pt *ProtectedTool) matchesAlwaysAllowPattern(targetPath string) bool {
-	for _, pattern := range pt.permissionConfig.AlwaysAllowPatterns {
+func (pt *ProtectedTool) needsApproval(level PermissionLevel) bool {
+	switch level {
+	case PermissionSafe:
+		return !pt.permissionConfig.AutoApproveSafe
+	case PermissionRead:
+		return !pt.permissionConfig.AutoApproveRead
+	case PermissionWrite:
+		return pt.permissionConfig.RequireApprovalWrite
+	case PermissionExecute:
+		return pt.permissionConfig.RequireApprovalExecute
+	case PermissionNetwork:
+		return pt.permissionConfig.RequireApprovalNetwork
+	}
+	return false
+}
+
+// requestApproval asks the checker to approve this call
+func (pt *ProtectedTool) requestApproval(ctx context.Context, args map[string]interface{}, resolvedPath string, root ProjectRoot) error {
+	// Header lines come before the args so nothing in them can spoof one.
+	// The path shown is the real target, so a link or "../" can't disguise it.
+	var details strings.Builder
+	if command, ok := args["command"].(string); ok && command != "" {
+		details.WriteString(fmt.Sprintf("Command: %s\n", strings.SplitN(command, "\n", 2)[0]))
+	}
+	if resolvedPath != "" {
+		details.WriteString(fmt.Sprintf("Path: %s\n", resolvedPath))
+	}
+	if root.Name != "" {
+		details.WriteString(fmt.Sprintf("Root: %s\n", root.Name))
+	}
+	if detailer, ok := pt.tool.(ApprovalDetailer); ok {
+		// A call that can't be described would fail anyway, so don't ask
+		text, err := detailer.ApprovalDetails(args)
+		if err != nil {
+			return err
+		}
+		details.WriteString("Args: " + text)
+	} else {
+		details.WriteString(fmt.Sprintf("Args: %v", args))
+	}
+	approved, err := pt.checker.RequestPermission(ctx, pt.tool.Name(), pt.level, details.String())
+	if err != nil {
+		return fmt.Errorf("permission check failed: %w", err)
+	}
+	if !approved {
+		return fmt.Errorf("permission denied by user")
+	}
+	return nil
+}
+
+// matchesAlwaysAllowPattern checks the saved patterns. path must already be
+// resolved with ResolvePath; root is the project root the call named.
+func (pt *ProtectedTool) matchesAlwaysAllowPattern(command, path string, root ProjectRoot) bool {
+	for _, pattern := range pt.permissionConfig.Patterns() {
 		if !pattern.Enabled {
 			continue
 		}
 
+		// Root-scoped patterns apply to calls made in that root or on paths inside it
+		if pattern.Root != "" && pattern.Root != root.Name {
+			scope, ok := pt.permissionConfig.ProjectRoot(pattern.Root)
+			if !ok || path == "" || !isWithin(scope.Path, path) {
+				continue
+			}
+		}
+
 		// Check if tool matches
 		if pattern.Tool != "*" && pattern.Tool != pt.tool.Name() {
 			continue
@@ -212,62 +550,124 @@ func (pt *ProtectedTool) matchesAlwaysAllowPattern(targetPath string) bool {
 		}
 
 		// Check command pattern for run_command tool
-		if pattern.CommandPattern != "" && pt.tool.Name() == "run_command" && targetPath != "" {
-			// targetPath contains the command for run_command
-			fields := strings.Fields(targetPath)
+		if pattern.CommandPattern != "" && pt.tool.Name() == "run_command" && command != "" {
+			fields := strings.Fields(command)
 			if len(fields) > 0 && fields[0] == pattern.CommandPattern {
 				return true
 			}
 		}
 
 		// Check path pattern
-		if pattern.PathPattern != "" && targetPath != "" {
-			// Check if path matches the pattern
-			matched, err := filepath.Match(pattern.PathPattern, targetPath)
-			if err == nil && matched {
-				return true
-			}
-
-			// Also check if the path is within the pattern directory
-			if strings.HasPrefix(filepath.Clean(targetPath), filepath.Clean(pattern.PathPattern)) {
-				return true
-			}
+		if pattern.PathPattern != "" && path != "" && matchesPathPattern(pattern.PathPattern, path) {
+			return true
 		}
 	}
 
 	return false
 }
 
-func checkWorkingDirRestriction(targetPath string) error {
-	if targetPath == "" {
+// matchesPathPattern reports whether the resolved path matches a glob
+// pattern or lies inside the pattern's directory. The pattern is
+// canonicalized the same way, so "/proj" doesn't match "/project" and a
+// symlinked pattern directory still matches its target.
+func matchesPathPattern(pattern, path string) bool {
+	if strings.ContainsAny(pattern, "*?[") {
+		abs, err := filepath.Abs(ExpandHome(pattern))
+		if err != nil {
+			return false
+		}
+		matched, err := filepath.Match(abs, path)
+		return err == nil && matched
+	}
+
+	root, err := ResolvePath(pattern)
+	if err != nil {
+		return false
+	}
+	return isWithin(root, path)
+}
+
+// checkWorkspace enforces the working directory jail, asking the user when
+// the checker supports it
+func (pt *ProtectedTool) checkWorkspace(ctx context.Context, targetPath, resolvedPath string) error {
+	restrict, roots := pt.permissionConfig.Workspace()
+	if !restrict {
 		return nil
 	}
 
-	// Get current working directory
-	wd, err := os.Getwd()
+	// Project roots are part of the workspace
+	for _, root := range pt.permissionConfig.ProjectRoots() {
+		roots = append(roots, root.Path)
+	}
+	inside, err := withinWorkspace(resolvedPath, roots)
 	if err != nil {
-		return fmt.Errorf("failed to get working directory: %w", err)
+		return err
+	}
+	if inside {
+		return nil
+	}
+
+	if pt.permissionConfig.JailLocked() {
+		return fmt.Errorf("access denied: path '%s' is outside the workspace, which the team policy enforces", targetPath)
+	}
+	denied := fmt.Errorf("access denied: path '%s' is outside the workspace", targetPath)
+	asker, ok := pt.checker.(OutsideWorkspaceChecker)
+	if !ok {
+		return denied
 	}
 
-	// Clean and make absolute
-	absTarget, err := filepath.Abs(targetPath)
+	decision, err := asker.RequestOutsideWorkspace(ctx, pt.tool.Name(), targetPath)
 	if err != nil {
-		// If we can't resolve it, allow it (might be a command, not a path)
+		return fmt.Errorf("permission check failed: %w", err)
+	}
+
+	switch decision {
+	case OutsideWorkspaceAllowOnce:
+		return nil
+	case OutsideWorkspaceAddRoot:
+		pt.permissionConfig.AddAllowedRoot(RootFor(resolvedPath))
+		return nil
+	case OutsideWorkspaceAddSessionRoot:
+		pt.permissionConfig.AddSessionRoot(RootFor(resolvedPath))
 		return nil
+	default:
+		return denied
 	}
+}
 
-	absWd, err := filepath.Abs(wd)
+// withinWorkspace reports whether the resolved path is inside the working
+// directory or one of the extra allowed roots
+func withinWorkspace(resolvedPath string, roots []string) (bool, error) {
+	wd, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("failed to resolve working directory: %w", err)
+		return false, fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	// Check if target is within working directory
-	rel, err := filepath.Rel(absWd, absTarget)
-	if err != nil || strings.HasPrefix(rel, "..") {
-		return fmt.Errorf("access denied: path '%s' is outside working directory '%s'", targetPath, wd)
+	// Roots are resolved too so a symlinked workspace still matches itself
+	for _, root := range append([]string{wd}, roots...) {
+		absRoot, err := ResolvePath(root)
+		if err != nil {
+			continue
+		}
+		if isWithin(absRoot, resolvedPath) {
+			return true, nil
+		}
 	}
 
-	return nil
+	return false, nil
+}
+
+// RootFor returns the directory to allow for path: the path itself if it is
+// a directory, otherwise the directory containing it
+func RootFor(path string) string {
+	abs, err := ResolvePath(path)
+	if err != nil {
+		abs = path
+	}
+	if info, err := os.Stat(abs); err == nil && info.IsDir() {
+		return abs
+	}
+	return filepath.Dir(abs)
 }
 
 // AutoApproveChecker automatically approves all permission requests (for ACP mode)
@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Policy is what an administrator enforces on top of the user's permissions.
+// Nothing changed at runtime loosens it: saved grants, the allowlist and
+// /permissions all stop at it.
+type Policy struct {
+	RequireApproval      map[PermissionLevel]bool // Always asked, never approved by a pattern or the allowlist
+	BlockedCommands      []string                 // Refused on top of the user's blocked commands
+	DisabledTools        map[string]bool
+	RestrictToWorkingDir bool
+}
+
+// ParsePermissionLevel reads a level name as used in config files
+func ParsePermissionLevel(name string) (PermissionLevel, error) {
+	switch name {
+	case "safe":
+		return PermissionSafe, nil
+	case "read":
+		return PermissionRead, nil
+	case "write":
+		return PermissionWrite, nil
+	case "execute":
+		return PermissionExecute, nil
+	case "network":
+		return PermissionNetwork, nil
+	}
+	return 0, fmt.Errorf("unknown permission level %q", name)
+}
+
+// requiresApproval reports whether the policy makes level always ask
+func (p *Policy) requiresApproval(level PermissionLevel) bool {
+	return p != nil && p.RequireApproval[level]
+}
+
+// disables reports whether the policy turns the tool off
+func (p *Policy) disables(name string) bool {
+	return p != nil && p.DisabledTools[name]
+}
+
+// disablesTool is disables for a tool, which also turns off custom tools
+// and add_custom_tool when run_command is off since they run shell commands
+// too
+func (p *Policy) disablesTool(tool Tool) bool {
+	if p.disables(tool.Name()) {
+		return true
+	}
+	if !p.disables("run_command") {
+		return false
+	}
+	if pt, ok := tool.(*ProtectedTool); ok {
+		tool = pt.UnwrapTool()
+	}
+	_, custom := tool.(*CustomCommandTool)
+	return custom || tool.Name() == "add_custom_tool"
+}
+
+// Summary lists what the policy enforces, one rule per line
+func (p *Policy) Summary() []string {
+	if p == nil {
+		return nil
+	}
+	var lines []string
+	names := map[PermissionLevel]string{PermissionSafe: "safe", PermissionRead: "read", PermissionWrite: "write", PermissionExecute: "execute", PermissionNetwork: "network"}
+	for _, level := range []PermissionLevel{PermissionSafe, PermissionRead, PermissionWrite, PermissionExecute, PermissionNetwork} {
+		if p.RequireApproval[level] {
+			lines = append(lines, fmt.Sprintf("%s tools always ask", names[level]))
+		}
+	}
+	if p.RestrictToWorkingDir {
+		lines = append(lines, "workspace jail stays on, without allowed roots")
+	}
+	for _, rule := range p.BlockedCommands {
+		lines = append(lines, "blocked: "+rule)
+	}
+	var disabled []string
+	for name := range p.DisabledTools {
+		disabled = append(disabled, name)
+	}
+	sort.Strings(disabled)
+	for _, name := range disabled {
+		lines = append(lines, "disabled: "+name)
+	}
+	return lines
+}
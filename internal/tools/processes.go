@@ -0,0 +1,254 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultProcessLimit is how many processes list_processes shows by default
+const defaultProcessLimit = 20
+
+// processInfo is one row of ps output
+type processInfo struct {
+	pid     int
+	ppid    int
+	user    string
+	cpu     float64
+	mem     float64
+	rss     int64 // Bytes
+	elapsed string
+	command string
+}
+
+// name is the program's base name
+func (p processInfo) name() string {
+	program, _, _ := strings.Cut(p.command, " ")
+	return filepath.Base(program)
+}
+
+// listProcesses runs ps for every process, or only for pid if it is not 0
+func listProcesses(ctx context.Context, pid int) ([]processInfo, error) {
+	if _, err := exec.LookPath("ps"); err != nil {
+		return nil, fmt.Errorf("process tools need ps, which is not available on %s", runtime.GOOS)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	args := []string{"-ww", "-o", "pid=,ppid=,user=,pcpu=,pmem=,rss=,etime=,args="}
+	if pid != 0 {
+		args = append(args, "-p", strconv.Itoa(pid))
+	} else {
+		args = append(args, "-ax")
+	}
+	out, err := exec.CommandContext(ctx, "ps", args...).Output()
+	if err != nil && (pid == 0 || len(out) == 0) {
+		if pid != 0 {
+			return nil, fmt.Errorf("no process with PID %d", pid)
+		}
+		return nil, fmt.Errorf("ps: %w", err)
+	}
+	return parseProcesses(string(out)), nil
+}
+
+func parseProcesses(out string) []processInfo {
+	var processes []processInfo
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			continue
+		}
+		p := processInfo{user: fields[2], elapsed: fields[6], command: strings.Join(fields[7:], " ")}
+		p.pid, _ = strconv.Atoi(fields[0])
+		p.ppid, _ = strconv.Atoi(fields[1])
+		p.cpu, _ = strconv.ParseFloat(fields[3], 64)
+		p.mem, _ = strconv.ParseFloat(fields[4], 64)
+		kb, _ := strconv.ParseInt(fields[5], 10, 64)
+		p.rss = kb * 1024
+		processes = append(processes, p)
+	}
+	return processes
+}
+
+func formatProcesses(processes []processInfo) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%7s %7s %-10s %6s %6s %9s %11s  %s\n", "PID", "PPID", "USER", "%CPU", "%MEM", "RSS", "ELAPSED", "COMMAND"))
+	for _, p := range processes {
+		sb.WriteString(fmt.Sprintf("%7d %7d %-10s %6.1f %6.1f %9s %11s  %s\n", p.pid, p.ppid, p.user, p.cpu, p.mem, formatBytes(p.rss), p.elapsed, shortCommand(p.command, 200)))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// shortCommand redacts a command line and cuts it to max bytes
+func shortCommand(command string, max int) string {
+	command = RedactSecrets(command)
+	if len(command) > max {
+		return command[:max] + "..."
+	}
+	return command
+}
+
+// ListProcessesTool lists running processes, busiest first
+type ListProcessesTool struct{}
+
+func NewListProcessesTool() *ListProcessesTool {
+	return &ListProcessesTool{}
+}
+
+func (t *ListProcessesTool) Name() string {
+	return "list_processes"
+}
+
+func (t *ListProcessesTool) Description() string {
+	return "List running processes with PID, CPU, memory and command line, e.g. to find what is using the CPU or the PID of a dev server. Filter by name or user and sort by cpu, memory or pid"
+}
+
+func (t *ListProcessesTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"filter": map[string]interface{}{
+				"type":        "string",
+				"description": "Only processes whose command line contains this text (case-insensitive)",
+			},
+			"user": map[string]interface{}{
+				"type":        "string",
+				"description": "Only processes of this user",
+			},
+			"sort": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"cpu", "memory", "pid"},
+				"description": "Sort order (default cpu)",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Most processes to show (default 20)",
+			},
+		},
+	}
+}
+
+func (t *ListProcessesTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	processes, err := listProcesses(ctx, 0)
+	if err != nil {
+		return "", err
+	}
+
+	filter := strings.ToLower(stringArg(args["filter"]))
+	user := stringArg(args["user"])
+	self := os.Getpid()
+	var matched []processInfo
+	for _, p := range processes {
+		if p.pid == self || (filter != "" && !strings.Contains(strings.ToLower(p.command), filter)) {
+			continue
+		}
+		if user != "" && p.user != user {
+			continue
+		}
+		// Leave out the ps run that produced this list
+		if p.name() == "ps" && p.ppid == self {
+			continue
+		}
+		matched = append(matched, p)
+	}
+
+	switch stringArg(args["sort"]) {
+	case "memory":
+		sort.SliceStable(matched, func(i, j int) bool { return matched[i].rss > matched[j].rss })
+	case "pid":
+		sort.SliceStable(matched, func(i, j int) bool { return matched[i].pid < matched[j].pid })
+	default:
+		sort.SliceStable(matched, func(i, j int) bool { return matched[i].cpu > matched[j].cpu })
+	}
+
+	if len(matched) == 0 {
+		return "No matching processes", nil
+	}
+	limit := defaultProcessLimit
+	if n, ok := intArg(args["limit"]); ok && n > 0 {
+		limit = n
+	}
+	result := ""
+	if len(matched) > limit {
+		result = fmt.Sprintf("Showing %d of %d processes\n", limit, len(matched))
+		matched = matched[:limit]
+	}
+	return result + formatProcesses(matched), nil
+}
+
+// ProcessInfoTool shows the details of one process
+type ProcessInfoTool struct{}
+
+func NewProcessInfoTool() *ProcessInfoTool {
+	return &ProcessInfoTool{}
+}
+
+func (t *ProcessInfoTool) Name() string {
+	return "process_info"
+}
+
+func (t *ProcessInfoTool) Description() string {
+	return "Show one process by PID: its full command line, resource use, parent, children and, on Linux, working directory"
+}
+
+func (t *ProcessInfoTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"pid": map[string]interface{}{
+				"type":        "integer",
+				"description": "Process ID",
+			},
+		},
+		"required": []string{"pid"},
+	}
+}
+
+func (t *ProcessInfoTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	pid, ok := intArg(args["pid"])
+	if !ok || pid <= 0 {
+		return "", fmt.Errorf("pid must be a positive number")
+	}
+	processes, err := listProcesses(ctx, pid)
+	if err != nil {
+		return "", err
+	}
+	if len(processes) == 0 {
+		return "", fmt.Errorf("no process with PID %d", pid)
+	}
+	p := processes[0]
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("PID: %d\n", p.pid))
+	sb.WriteString(fmt.Sprintf("Command: %s\n", shortCommand(p.command, 4000)))
+	sb.WriteString(fmt.Sprintf("User: %s\n", p.user))
+	sb.WriteString(fmt.Sprintf("CPU: %.1f%%, memory: %.1f%% (%s)\n", p.cpu, p.mem, formatBytes(p.rss)))
+	sb.WriteString(fmt.Sprintf("Running for: %s\n", p.elapsed))
+	if cwd, err := os.Readlink(fmt.Sprintf("/proc/%d/cwd", pid)); err == nil {
+		sb.WriteString(fmt.Sprintf("Working directory: %s\n", cwd))
+	}
+
+	// Parent and children need the full table
+	all, err := listProcesses(ctx, 0)
+	if err == nil {
+		var children []processInfo
+		for _, other := range all {
+			if other.pid == p.ppid {
+				sb.WriteString(fmt.Sprintf("Parent: %d %s\n", other.pid, shortCommand(other.command, 200)))
+			}
+			if other.ppid == p.pid && !(other.name() == "ps" && p.pid == os.Getpid()) {
+				children = append(children, other)
+			}
+		}
+		if len(children) > 0 {
+			sb.WriteString(fmt.Sprintf("Children:\n%s\n", formatProcesses(children)))
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
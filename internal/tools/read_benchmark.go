@@ -5,14 +5,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/LaPingvino/llemecode/internal/config"
 )
 
-type ReadBenchmarkTool struct{}
+type ReadBenchmarkTool struct {
+	cfg *config.Config
+}
 
-func NewReadBenchmarkTool() *ReadBenchmarkTool {
-	return &ReadBenchmarkTool{}
+func NewReadBenchmarkTool(cfg *config.Config) *ReadBenchmarkTool {
+	return &ReadBenchmarkTool{cfg: cfg}
 }
 
 func (t *ReadBenchmarkTool) Name() string {
@@ -44,6 +49,10 @@ func (t *ReadBenchmarkTool) Execute(ctx context.Context, args map[string]interfa
 		partialPath := configDir + "/benchmark_results_partial.json"
 		content, err = os.ReadFile(partialPath)
 		if err != nil {
+			// The scores saved with the model capabilities still compare models
+			if summary := t.configScores(); summary != "" {
+				return summary, nil
+			}
 			return "", fmt.Errorf("no benchmark results found. Run /benchmark to generate them")
 		}
 		resultsPath = partialPath
@@ -64,6 +73,7 @@ func (t *ReadBenchmarkTool) Execute(ctx context.Context, args map[string]interfa
 		rank := result["Rank"]
 		latency := result["AvgLatency"]
 		strengths, _ := result["Strengths"].([]interface{})
+		categories, _ := result["Categories"].(map[string]interface{})
 		description := result["Description"]
 
 		output += fmt.Sprintf("Model: %v\n", model)
@@ -82,6 +92,17 @@ func (t *ReadBenchmarkTool) Execute(ctx context.Context, args map[string]interfa
 			output += "\n"
 		}
 
+		if len(categories) > 0 {
+			output += "  Category Scores: "
+			for i, category := range sortedKeys(categories) {
+				if i > 0 {
+					output += ", "
+				}
+				output += fmt.Sprintf("%s %.2f", category, categories[category])
+			}
+			output += "\n"
+		}
+
 		if description != nil {
 			output += fmt.Sprintf("  Description: %v\n", description)
 		}
@@ -89,5 +110,74 @@ func (t *ReadBenchmarkTool) Execute(ctx context.Context, args map[string]interfa
 		output += "\n"
 	}
 
+	if t.cfg != nil {
+		// The same table /models shows, so the model can route sub-tasks
+		var best []string
+		var weights []string
+		bestModels := t.cfg.GetBestModels()
+		for _, category := range sortedKeys(bestModels) {
+			best = append(best, fmt.Sprintf("  %s: %s\n", category, bestModels[category]))
+		}
+		categoryWeights := t.cfg.GetCategoryWeights()
+		for _, category := range sortedKeys(categoryWeights) {
+			weights = append(weights, fmt.Sprintf("%s ×%g", category, categoryWeights[category]))
+		}
+		if len(best) > 0 {
+			output += "Best Model per Category:\n" + strings.Join(best, "")
+		}
+		if len(weights) > 0 {
+			output += fmt.Sprintf("Category Weights: %s\n", strings.Join(weights, ", "))
+		}
+	}
+
 	return output, nil
 }
+
+// configScores summarizes the benchmark scores recorded in the config, best
+// first; empty when there are none
+func (t *ReadBenchmarkTool) configScores() string {
+	if t.cfg == nil {
+		return ""
+	}
+	caps := t.cfg.GetCapabilities()
+	var models []string
+	for model, cap := range caps {
+		if cap.TotalScore > 0 {
+			models = append(models, model)
+		}
+	}
+	if len(models) == 0 {
+		return ""
+	}
+	sort.Slice(models, func(i, j int) bool { return caps[models[i]].TotalScore > caps[models[j]].TotalScore })
+
+	var sb strings.Builder
+	sb.WriteString("Benchmark Scores (from the config; the full results file is missing):\n\n")
+	for _, model := range models {
+		cap := caps[model]
+		sb.WriteString(fmt.Sprintf("Model: %s\n", model))
+		sb.WriteString(fmt.Sprintf("  Total Score: %.2f\n", cap.TotalScore))
+		sb.WriteString(fmt.Sprintf("  Avg Latency: %v\n", cap.AvgLatency.Round(time.Millisecond)))
+		if len(cap.RecommendedFor) > 0 {
+			sb.WriteString(fmt.Sprintf("  Strengths: %s\n", strings.Join(cap.RecommendedFor, ", ")))
+		}
+		if len(cap.CategoryScores) > 0 {
+			var categories []string
+			for _, category := range sortedKeys(cap.CategoryScores) {
+				categories = append(categories, fmt.Sprintf("%s %.2f", category, cap.CategoryScores[category]))
+			}
+			sb.WriteString(fmt.Sprintf("  Category Scores: %s\n", strings.Join(categories, ", ")))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
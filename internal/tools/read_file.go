@@ -6,12 +6,26 @@ import (
 	"os"
 )
 
-type ReadFileTool struct{}
+type ReadFileTool struct {
+	policy   *PathPolicy
+	versions *FileVersions
+}
 
 func NewReadFileTool() *ReadFileTool {
 	return &ReadFileTool{}
 }
 
+// SetPathPolicy restricts which paths the tool may use
+func (t *ReadFileTool) SetPathPolicy(policy *PathPolicy) {
+	t.policy = policy
+}
+
+// SetFileVersions records what the model read, so later writes can detect
+// changes made by someone else
+func (t *ReadFileTool) SetFileVersions(versions *FileVersions) {
+	t.versions = versions
+}
+
 func (t *ReadFileTool) Name() string {
 	return "read_file"
 }
@@ -38,11 +52,15 @@ func (t *ReadFileTool) Execute(ctx context.Context, args map[string]interface{})
 	if !ok {
 		return "", fmt.Errorf("path must be a string")
 	}
+	if err := t.policy.Check(path); err != nil {
+		return "", err
+	}
 
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return "", fmt.Errorf("read file: %w", err)
 	}
+	t.versions.Record(path, content)
 
 	return string(content), nil
 }
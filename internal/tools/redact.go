@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"regexp"
+	"strings"
+)
+
+// secretPatterns match well-known credential formats wherever they appear
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\b(ghp|gho|ghu|ghs|ghr|github_pat)_[A-Za-z0-9_]{20,}\b`),
+	regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`),
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}\b`),
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]{16,}`),
+	regexp.MustCompile(`://[^/?#\s@]+@`), // Any userinfo: user:pass@, or a key alone
+}
+
+// secretNameParts mark an environment variable or setting as a secret
+var secretNameParts = []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "CREDENTIAL", "API_KEY", "APIKEY", "PRIVATE_KEY", "ACCESS_KEY", "AUTH"}
+
+// secretNameWords mark a secret only as a whole word of the name, so
+// OPENAI_KEY is one but KEYBOARD_LAYOUT is not
+var secretNameWords = []string{"KEY", "KEYS", "PASS", "PWD", "DSN"}
+
+// plainNames are never secrets despite their words, like the shell's PWD
+var plainNames = map[string]bool{"PWD": true}
+
+// RedactSecrets replaces credentials in text with [REDACTED]
+func RedactSecrets(text string) string {
+	for _, pattern := range secretPatterns {
+		text = pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if strings.HasPrefix(match, "://") {
+				return "://[REDACTED]@"
+			}
+			return "[REDACTED]"
+		})
+	}
+	return text
+}
+
+// IsSecretName reports whether a variable called name likely holds a secret
+func IsSecretName(name string) bool {
+	upper := strings.ToUpper(name)
+	if plainNames[upper] {
+		return false
+	}
+	for _, part := range secretNameParts {
+		if strings.Contains(upper, part) {
+			return true
+		}
+	}
+	words := strings.FieldsFunc(upper, func(r rune) bool { return (r < 'A' || r > 'Z') && (r < '0' || r > '9') })
+	for _, word := range words {
+		for _, secret := range secretNameWords {
+			if word == secret {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RedactEnv returns value, or [REDACTED] if name or value looks secret
+func RedactEnv(name, value string) string {
+	if IsSecretName(name) && value != "" {
+		return "[REDACTED]"
+	}
+	return RedactSecrets(value)
+}
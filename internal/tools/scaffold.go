@@ -0,0 +1,240 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ScaffoldTool creates a directory tree and its files from one spec, so a
+// new project layout takes a single approval
+type ScaffoldTool struct {
+	policy *PathPolicy
+}
+
+func NewScaffoldTool() *ScaffoldTool {
+	return &ScaffoldTool{}
+}
+
+// SetPathPolicy restricts which paths the tool may use
+func (t *ScaffoldTool) SetPathPolicy(policy *PathPolicy) {
+	t.policy = policy
+}
+
+func (t *ScaffoldTool) Name() string {
+	return "scaffold"
+}
+
+func (t *ScaffoldTool) Description() string {
+	return "Create a directory tree with files in one step, e.g. a new Go module with cmd/, internal/ and a Makefile. Existing files are never overwritten"
+}
+
+func (t *ScaffoldTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Directory to create the tree in; default the working directory",
+			},
+			"directories": map[string]interface{}{
+				"type":        "array",
+				"description": "Directories to create, relative to path; parents of files are created anyway",
+				"items":       map[string]interface{}{"type": "string"},
+			},
+			"files": map[string]interface{}{
+				"type":        "array",
+				"description": "Files to create, relative to path",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"path":    map[string]interface{}{"type": "string"},
+						"content": map[string]interface{}{"type": "string"},
+					},
+					"required": []string{"path", "content"},
+				},
+			},
+		},
+	}
+}
+
+// scaffoldPlan is what a call would create
+type scaffoldPlan struct {
+	base  string
+	dirs  []string // Missing directories, parents first
+	files []fileChange
+}
+
+// TargetPaths returns every directory and file the call would create, so
+// each is checked against the workspace jail
+func (t *ScaffoldTool) TargetPaths(args map[string]interface{}) []string {
+	plan, err := t.plan(args)
+	if err != nil {
+		base, _ := args["path"].(string)
+		return []string{scaffoldBase(base)}
+	}
+	paths := append([]string{plan.base}, plan.dirs...)
+	for _, file := range plan.files {
+		paths = append(paths, file.path)
+	}
+	return paths
+}
+
+// ApprovalDetails shows the tree the call would create
+func (t *ScaffoldTool) ApprovalDetails(args map[string]interface{}) (string, error) {
+	plan, err := t.plan(args)
+	if err != nil {
+		return "", err
+	}
+	var entries []string
+	for _, dir := range plan.dirs {
+		entries = append(entries, relTo(plan.base, dir)+"/")
+	}
+	for _, file := range plan.files {
+		lines := strings.Count(strings.TrimSuffix(string(file.after), "\n"), "\n") + 1
+		unit := "lines"
+		if lines == 1 {
+			unit = "line"
+		}
+		entries = append(entries, fmt.Sprintf("%s (%d %s)", relTo(plan.base, file.path), lines, unit))
+	}
+	sort.Strings(entries)
+	return fmt.Sprintf("create in %s:\n  %s", plan.base, strings.Join(entries, "\n  ")), nil
+}
+
+func (t *ScaffoldTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	plan, err := t.plan(args)
+	if err != nil {
+		return "", err
+	}
+
+	var created []string
+	removeCreated := func() {
+		for i := len(created) - 1; i >= 0; i-- {
+			os.Remove(created[i])
+		}
+	}
+	for _, dir := range plan.dirs {
+		if err := os.Mkdir(dir, 0755); err != nil && !os.IsExist(err) {
+			removeCreated()
+			return "", fmt.Errorf("create directory %s: %w", dir, err)
+		}
+		created = append(created, dir)
+	}
+	if err := applyFileChanges(plan.files); err != nil {
+		removeCreated()
+		return "", err
+	}
+
+	return fmt.Sprintf("Created %d directories and %d files in %s", len(plan.dirs), len(plan.files), plan.base), nil
+}
+
+func (t *ScaffoldTool) plan(args map[string]interface{}) (scaffoldPlan, error) {
+	rawBase, _ := args["path"].(string)
+	base, err := filepath.Abs(scaffoldBase(rawBase))
+	if err != nil {
+		return scaffoldPlan{}, err
+	}
+	plan := scaffoldPlan{base: base}
+
+	dirs := make(map[string]bool)
+	addDir := func(dir string) {
+		for ; dir != base && strings.HasPrefix(dir, base); dir = filepath.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+
+	rawDirs, _ := args["directories"].([]interface{})
+	for _, raw := range rawDirs {
+		rel, _ := raw.(string)
+		dir, err := scaffoldPath(base, rel)
+		if err != nil {
+			return scaffoldPlan{}, err
+		}
+		addDir(dir)
+	}
+
+	rawFiles, _ := args["files"].([]interface{})
+	seen := make(map[string]bool)
+	for n, raw := range rawFiles {
+		spec, _ := raw.(map[string]interface{})
+		rel, _ := spec["path"].(string)
+		content, ok := spec["content"].(string)
+		if !ok {
+			return scaffoldPlan{}, fmt.Errorf("file %d needs path and content", n+1)
+		}
+		path, err := scaffoldPath(base, rel)
+		if err != nil {
+			return scaffoldPlan{}, err
+		}
+		if seen[path] {
+			return scaffoldPlan{}, fmt.Errorf("%s is listed twice", rel)
+		}
+		seen[path] = true
+		if _, err := os.Lstat(path); err == nil {
+			return scaffoldPlan{}, fmt.Errorf("%s already exists; scaffold never overwrites files", path)
+		}
+		if err := t.policy.Check(path); err != nil {
+			return scaffoldPlan{}, err
+		}
+		addDir(filepath.Dir(path))
+		plan.files = append(plan.files, fileChange{action: "create", path: path, after: []byte(content)})
+	}
+	if len(plan.files) == 0 && len(dirs) == 0 {
+		return scaffoldPlan{}, fmt.Errorf("nothing to create: give directories or files")
+	}
+
+	if _, err := os.Stat(base); err != nil {
+		dirs[base] = true
+		for parent := filepath.Dir(base); ; parent = filepath.Dir(parent) {
+			if _, err := os.Stat(parent); err == nil || parent == filepath.Dir(parent) {
+				break
+			}
+			dirs[parent] = true
+		}
+	}
+	for dir := range dirs {
+		info, err := os.Stat(dir)
+		if err == nil && !info.IsDir() {
+			return scaffoldPlan{}, fmt.Errorf("%s exists and is not a directory", dir)
+		}
+		if err != nil {
+			plan.dirs = append(plan.dirs, dir)
+		}
+	}
+	// Sorting puts parents before their children
+	sort.Strings(plan.dirs)
+	return plan, nil
+}
+
+func scaffoldBase(base string) string {
+	if base == "" {
+		return "."
+	}
+	return ExpandHome(base)
+}
+
+// scaffoldPath joins rel onto base, refusing paths that leave it
+func scaffoldPath(base, rel string) (string, error) {
+	if rel == "" {
+		return "", fmt.Errorf("empty path in the spec")
+	}
+	if filepath.IsAbs(rel) {
+		return "", fmt.Errorf("%s must be relative to the scaffold directory", rel)
+	}
+	path := filepath.Join(base, rel)
+	if !isWithin(base, path) || path == base {
+		return "", fmt.Errorf("%s is outside the scaffold directory", rel)
+	}
+	return path, nil
+}
+
+func relTo(base, path string) string {
+	if rel, err := filepath.Rel(base, path); err == nil {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// maxScreenshotBytes caps the image sent to the model
+const maxScreenshotBytes = 20 * 1024 * 1024
+
+// windowsScreenshot saves the primary screen with .NET, as Windows has no
+// screenshot command
+const windowsScreenshot = `Add-Type -AssemblyName System.Windows.Forms,System.Drawing
+$b = [System.Windows.Forms.Screen]::PrimaryScreen.Bounds
+$img = New-Object System.Drawing.Bitmap $b.Width, $b.Height
+$g = [System.Drawing.Graphics]::FromImage($img)
+$g.CopyFromScreen($b.Location, [System.Drawing.Point]::Empty, $b.Size)
+$img.Save($args[0], [System.Drawing.Imaging.ImageFormat]::Png)`
+
+// ScreenshotTool captures the screen and attaches the image for vision models
+type ScreenshotTool struct{}
+
+func NewScreenshotTool() *ScreenshotTool {
+	return &ScreenshotTool{}
+}
+
+func (t *ScreenshotTool) Name() string {
+	return "take_screenshot"
+}
+
+func (t *ScreenshotTool) Description() string {
+	return "Take a screenshot of the user's screen, e.g. to look at a failing UI. Vision-capable models receive the image."
+}
+
+func (t *ScreenshotTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"delay": map[string]interface{}{
+				"type":        "number",
+				"description": "Seconds to wait before capturing, e.g. to let the user bring a window to the front (default 0, max 30)",
+			},
+		},
+	}
+}
+
+// screenshotCommands lists the capture commands to try in order; each
+// writes a PNG to path
+func screenshotCommands(path string) [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"screencapture", "-x", path}}
+	case "windows":
+		return [][]string{{"powershell.exe", "-NoProfile", "-Command", windowsScreenshot, path}}
+	}
+	var cmds [][]string
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		cmds = append(cmds, []string{"grim", path})
+	}
+	return append(cmds,
+		[]string{"scrot", "--overwrite", path},
+		[]string{"gnome-screenshot", "-f", path},
+		[]string{"import", "-window", "root", path})
+}
+
+func (t *ScreenshotTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	if delay, ok := args["delay"].(float64); ok && delay > 0 {
+		select {
+		case <-time.After(time.Duration(min(delay, 30) * float64(time.Second))):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	path := filepath.Join(os.TempDir(), fmt.Sprintf("llemecode-screenshot-%s.png", time.Now().Format("20060102-150405")))
+	var lastErr error
+	found := false
+	for _, cmd := range screenshotCommands(path) {
+		if _, err := exec.LookPath(cmd[0]); err != nil {
+			continue
+		}
+		found = true
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		out, err := exec.CommandContext(ctx, cmd[0], cmd[1:]...).CombinedOutput()
+		cancel()
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w: %s", cmd[0], err, out)
+			continue
+		}
+		lastErr = nil
+		break
+	}
+	if !found {
+		return "", fmt.Errorf("no screenshot utility found (install grim on Wayland, or scrot or ImageMagick on X11)")
+	}
+	if lastErr != nil {
+		return "", lastErr
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read screenshot: %w", err)
+	}
+	if len(data) > maxScreenshotBytes {
+		return fmt.Sprintf("Screenshot saved to %s, but at %d MB it is too large to attach", path, len(data)/1024/1024), nil
+	}
+	if !AttachImage(ctx, data) {
+		return fmt.Sprintf("Screenshot saved to %s", path), nil
+	}
+	return fmt.Sprintf("Screenshot saved to %s and attached", path), nil
+}
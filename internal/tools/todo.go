@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/internal/todo"
+)
+
+// todoResult shows the whole list after a change, so the model always works
+// from the current state
+func todoResult(list *todo.List, change string) string {
+	return fmt.Sprintf("%s\n\nTask list (%d open):\n%s", change, list.Open(), list.Markdown())
+}
+
+// TodoAddTool adds tasks to the session's task list
+type TodoAddTool struct {
+	list *todo.List
+}
+
+func NewTodoAddTool(list *todo.List) *TodoAddTool {
+	return &TodoAddTool{list: list}
+}
+
+func (t *TodoAddTool) Name() string {
+	return "todo_add"
+}
+
+func (t *TodoAddTool) Description() string {
+	return "Add tasks to the task list the user sees. Before multi-step work, add one task per step, then mark each in_progress and done as you go"
+}
+
+func (t *TodoAddTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"tasks": map[string]interface{}{
+				"type":        "array",
+				"description": "Short task descriptions, in the order they should be done",
+				"items":       map[string]interface{}{"type": "string"},
+			},
+		},
+		"required": []string{"tasks"},
+	}
+}
+
+func (t *TodoAddTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var texts []string
+	switch tasks := args["tasks"].(type) {
+	case []interface{}:
+		for _, task := range tasks {
+			if s, ok := task.(string); ok {
+				texts = append(texts, s)
+			}
+		}
+	case string:
+		// Some models send one task as a plain string
+		texts = append(texts, tasks)
+	}
+	added := t.list.Add(texts...)
+	if len(added) == 0 {
+		return "", fmt.Errorf("tasks must be a non-empty list of strings")
+	}
+	ids := make([]string, len(added))
+	for i, item := range added {
+		ids[i] = fmt.Sprint(item.ID)
+	}
+	return todoResult(t.list, fmt.Sprintf("Added %s (%s)", plural(len(added), "task"), strings.Join(ids, ", "))), nil
+}
+
+// TodoUpdateTool changes the text, status or note of a task
+type TodoUpdateTool struct {
+	list *todo.List
+}
+
+func NewTodoUpdateTool(list *todo.List) *TodoUpdateTool {
+	return &TodoUpdateTool{list: list}
+}
+
+func (t *TodoUpdateTool) Name() string {
+	return "todo_update"
+}
+
+func (t *TodoUpdateTool) Description() string {
+	return "Change a task on the task list: mark it in_progress when you start it, reword it, or add a note such as a blocker"
+}
+
+func (t *TodoUpdateTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{
+				"type":        "integer",
+				"description": "Task number",
+			},
+			"status": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{todo.Pending, todo.InProgress, todo.Done},
+				"description": "New status",
+			},
+			"text": map[string]interface{}{
+				"type":        "string",
+				"description": "New description",
+			},
+			"note": map[string]interface{}{
+				"type":        "string",
+				"description": "Progress, outcome or blocker",
+			},
+		},
+		"required": []string{"id"},
+	}
+}
+
+func (t *TodoUpdateTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, ok := intArg(args["id"])
+	if !ok {
+		return "", fmt.Errorf("id must be a task number")
+	}
+	status, text, note := stringArg(args["status"]), stringArg(args["text"]), stringArg(args["note"])
+	if status == "" && text == "" && note == "" {
+		return "", fmt.Errorf("give a status, text or note to change")
+	}
+	item, err := t.list.Update(id, text, status, note)
+	if err != nil {
+		return "", err
+	}
+	return todoResult(t.list, fmt.Sprintf("Updated task %d", item.ID)), nil
+}
+
+// TodoCompleteTool marks a task done
+type TodoCompleteTool struct {
+	list *todo.List
+}
+
+func NewTodoCompleteTool(list *todo.List) *TodoCompleteTool {
+	return &TodoCompleteTool{list: list}
+}
+
+func (t *TodoCompleteTool) Name() string {
+	return "todo_complete"
+}
+
+func (t *TodoCompleteTool) Description() string {
+	return "Mark a task on the task list done, right after finishing it, with an optional note on the outcome"
+}
+
+func (t *TodoCompleteTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{
+				"type":        "integer",
+				"description": "Task number",
+			},
+			"note": map[string]interface{}{
+				"type":        "string",
+				"description": "What was done (optional)",
+			},
+		},
+		"required": []string{"id"},
+	}
+}
+
+func (t *TodoCompleteTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, ok := intArg(args["id"])
+	if !ok {
+		return "", fmt.Errorf("id must be a task number")
+	}
+	item, err := t.list.Update(id, "", todo.Done, stringArg(args["note"]))
+	if err != nil {
+		return "", err
+	}
+	return todoResult(t.list, fmt.Sprintf("Completed task %d", item.ID)), nil
+}
@@ -3,6 +3,8 @@ package tools
 import (
 	"context"
 	"encoding/json"
+
+	"github.com/LaPingvino/llemecode/internal/todo"
 )
 
 type Tool interface {
@@ -12,20 +14,120 @@ type Tool interface {
 	Execute(ctx context.Context, args map[string]interface{}) (string, error)
 }
 
+// MultiPathTool is implemented by tools that act on several paths in one
+// call, so each can be checked against the workspace jail
+type MultiPathTool interface {
+	TargetPaths(args map[string]interface{}) []string
+}
+
+// ApprovalDetailer is implemented by tools that can show what a call would
+// do, e.g. the diffs it would apply, better than its raw arguments
+type ApprovalDetailer interface {
+	ApprovalDetails(args map[string]interface{}) (string, error)
+}
+
 type Registry struct {
-	tools map[string]Tool
+	tools    map[string]Tool
+	outputs  *OutputStore
+	trash    *Trash
+	todos    *todo.List
+	progress ModelProgress
+	policy   *Policy
 }
 
 func NewRegistry() *Registry {
 	return &Registry{
-		tools: make(map[string]Tool),
+		tools:   make(map[string]Tool),
+		outputs: NewOutputStore(),
+		trash:   NewTrash(),
+		todos:   todo.NewList(),
 	}
 }
 
+// Todos returns the session's task list, which the todo_ tools and /todos
+// share
+func (r *Registry) Todos() *todo.List {
+	return r.todos
+}
+
+// Trash returns where delete_file puts what it deletes, which /trash
+// restores from
+func (r *Registry) Trash() *Trash {
+	return r.trash
+}
+
+// Outputs returns the store for full results that were truncated for the
+// model, which fetch_more reads from
+func (r *Registry) Outputs() *OutputStore {
+	return r.outputs
+}
+
 func (r *Registry) Register(tool Tool) {
+	if r.policy.disablesTool(tool) {
+		return
+	}
+	if ask := askModelTool(tool); ask != nil {
+		if r.progress != nil {
+			ask.SetProgress(r.progress)
+		}
+		// Files handed to a model go through read_file, with its approval
+		ask.readFile = func(ctx context.Context, path string) (string, error) {
+			return r.Execute(ctx, "read_file", map[string]interface{}{"path": path})
+		}
+	}
 	r.tools[tool.Name()] = tool
 }
 
+// SetPolicy removes the tools policy disables and keeps them from being
+// registered again, e.g. by MCP servers or /addtool
+func (r *Registry) SetPolicy(policy *Policy) {
+	r.policy = policy
+	for name, tool := range r.tools {
+		if policy.disablesTool(tool) {
+			delete(r.tools, name)
+		}
+	}
+}
+
+// SetModelProgress streams the answers of every ask_ tool, including ones
+// registered later, to progress
+func (r *Registry) SetModelProgress(progress ModelProgress) {
+	r.progress = progress
+	for _, tool := range r.tools {
+		if ask := askModelTool(tool); ask != nil {
+			ask.SetProgress(progress)
+		}
+	}
+}
+
+// askModelTool finds the AskModelTool inside tool, or returns nil
+func askModelTool(tool Tool) *AskModelTool {
+	if pt, ok := tool.(*ProtectedTool); ok {
+		tool = pt.UnwrapTool()
+	}
+	switch t := tool.(type) {
+	case *AskModelTool:
+		return t
+	case *AskModelToolWithComm:
+		return t.AskModelTool
+	}
+	return nil
+}
+
+// SetUserAsker sets who answers the questions ask_user puts
+func (r *Registry) SetUserAsker(asker UserAsker) {
+	tool, ok := r.tools["ask_user"]
+	if !ok {
+		return
+	}
+	if pt, ok := tool.(*ProtectedTool); ok {
+		tool = pt.UnwrapTool()
+	}
+	if ask, ok := tool.(*AskUserTool); ok {
+		ask.SetAsker(asker)
+	}
+}
+
 func (r *Registry) Unregister(name string) {
 	delete(r.tools, name)
 }
@@ -76,6 +178,17 @@ func (r *Registry) SetPermissionChecker(checker PermissionChecker) {
 	}
 }
 
+// PermissionConfig returns the permission config shared by the registry's
+// protected tools, or nil if there are none
+func (r *Registry) PermissionConfig() *PermissionConfig {
+	for _, tool := range r.tools {
+		if pt, ok := tool.(*ProtectedTool); ok {
+			return pt.permissionConfig
+		}
+	}
+	return nil
+}
+
 type ErrToolNotFound struct {
 	Name string
 }
@@ -1,10 +1,27 @@
 package tools
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+	"github.com/LaPingvino/llemecode/internal/notes"
+	"github.com/LaPingvino/llemecode/internal/ollama"
+	"github.com/LaPingvino/llemecode/internal/todo"
 )
 
 func TestReadFileTool(t *testing.T) {
@@ -174,3 +191,1100 @@ func TestToolRegistry(t *testing.T) {
 		t.Error("Expected error for nonexistent tool")
 	}
 }
+
+func TestWriteConflict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	os.WriteFile(path, []byte("one\ntwo\nthree\nfour\nfive\n"), 0644)
+
+	versions := NewFileVersions()
+	read := NewReadFileTool()
+	read.SetFileVersions(versions)
+	write := NewWriteFileTool()
+	write.SetFileVersions(versions)
+	ctx := context.Background()
+
+	if _, err := read.Execute(ctx, map[string]interface{}{"path": path}); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	// Someone edits the first line after the model read the file
+	os.WriteFile(path, []byte("ONE\ntwo\nthree\nfour\nfive\n"), 0644)
+
+	mine := "one\ntwo\nthree\nfour\nFIVE\n"
+	_, err := write.Execute(ctx, map[string]interface{}{"path": path, "content": mine})
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Expected a conflict error, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "ONE\ntwo\nthree\nfour\nfive\n" {
+		t.Errorf("Expected the other edit to be kept, got %q", data)
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is needed for merging")
+	}
+	if _, err := write.Execute(ctx, map[string]interface{}{"path": path, "content": mine, "merge": true}); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "ONE\ntwo\nthree\nfour\nFIVE\n" {
+		t.Errorf("Expected both edits after merging, got %q", data)
+	}
+
+	// The model's own write is the new version, so writing again is fine
+	if _, err := write.Execute(ctx, map[string]interface{}{"path": path, "content": "done\n"}); err != nil {
+		t.Errorf("Expected a write after the model's own write to succeed, got %v", err)
+	}
+}
+
+func TestApplyChanges(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.go")
+	old := filepath.Join(dir, "old.go")
+	os.WriteFile(main, []byte("package main\n\nfunc main() {\n\tgreet()\n}\n"), 0644)
+	os.WriteFile(old, []byte("package main\n"), 0644)
+	ctx := context.Background()
+
+	changes := []interface{}{
+		map[string]interface{}{"action": "modify", "path": main, "diff": "@@ -3,3 +3,3 @@\n func main() {\n-\tgreet()\n+\thello()\n }\n"},
+		map[string]interface{}{"action": "create", "path": filepath.Join(dir, "hello.go"), "content": "package main\n"},
+		map[string]interface{}{"action": "delete", "path": old},
+	}
+
+	// The approval shows every diff in one prompt
+	permConfig := DefaultPermissionConfig()
+	permConfig.SetRestrictToWorkingDir(false)
+	checker := &recordingChecker{}
+	tool := NewProtectedTool(NewApplyChangesTool(), PermissionWrite, checker, permConfig)
+	if _, err := tool.Execute(ctx, map[string]interface{}{"changes": changes}); err == nil {
+		t.Fatal("Expected denied changes to fail")
+	}
+	if len(checker.details) != 1 || !strings.Contains(checker.details[0], "+ \thello()") || !strings.Contains(checker.details[0], "=== delete "+old) {
+		t.Errorf("Expected one prompt with all changes, got %q", checker.details)
+	}
+	if _, err := os.Stat(old); err != nil {
+		t.Error("Expected nothing to change when denied")
+	}
+
+	// A hunk that doesn't match stops every change
+	bad := append([]interface{}{}, changes...)
+	bad[0] = map[string]interface{}{"action": "modify", "path": main, "diff": "-\tgoodbye()\n+\thello()\n"}
+	if _, err := NewApplyChangesTool().Execute(ctx, map[string]interface{}{"changes": bad}); err == nil {
+		t.Error("Expected a hunk that doesn't match to fail")
+	}
+	if _, err := os.Stat(old); err != nil {
+		t.Error("Expected no file to change when one change is invalid")
+	}
+
+	if _, err := NewApplyChangesTool().Execute(ctx, map[string]interface{}{"changes": changes}); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if data, _ := os.ReadFile(main); string(data) != "package main\n\nfunc main() {\n\thello()\n}\n" {
+		t.Errorf("Unexpected main.go: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "hello.go")); err != nil {
+		t.Error("Expected hello.go to be created")
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("Expected old.go to be deleted")
+	}
+}
+
+func TestLineEdits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.ini")
+	os.WriteFile(path, []byte("[server]\nport = 80\n\n[log]\nlevel = info"), 0644)
+	ctx := context.Background()
+
+	appendTool := NewAppendToFileTool()
+	if _, err := appendTool.Execute(ctx, map[string]interface{}{"path": path, "content": "file = app.log", "expect_last": "level = debug"}); err == nil {
+		t.Error("Expected a wrong expect_last to refuse the append")
+	}
+	if _, err := appendTool.Execute(ctx, map[string]interface{}{"path": path, "content": "file = app.log", "expect_last": "level = info"}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	// Line 4 is stale; the expected lines say where the setting belongs
+	insert := NewInsertAtLineTool()
+	args := map[string]interface{}{"path": path, "line": float64(4), "content": "host = ::", "expect_before": "[server]", "expect_after": "port = 80"}
+	if _, err := insert.Execute(ctx, args); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	want := "[server]\nhost = ::\nport = 80\n\n[log]\nlevel = info\nfile = app.log\n"
+	if data, _ := os.ReadFile(path); string(data) != want {
+		t.Errorf("Expected %q, got %q", want, data)
+	}
+
+	args["expect_after"] = "port = 8080"
+	if _, err := insert.Execute(ctx, args); err == nil || !strings.Contains(err.Error(), "port = 80") {
+		t.Errorf("Expected a mismatch showing the actual lines, got %v", err)
+	}
+}
+
+func TestDownloadFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "release contents")
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	tool := NewDownloadFileTool()
+	ctx := context.Background()
+	sum := sha256.Sum256([]byte("release contents"))
+
+	wrong := strings.Repeat("0", 64)
+	if _, err := tool.Execute(ctx, map[string]interface{}{"url": server.URL, "path": filepath.Join(dir, "bad"), "sha256": wrong}); err == nil {
+		t.Error("Expected a checksum mismatch to fail")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bad")); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be saved on a checksum mismatch")
+	}
+	if _, err := tool.Execute(ctx, map[string]interface{}{"url": server.URL, "path": filepath.Join(dir, "big"), "max_bytes": float64(4)}); err == nil {
+		t.Error("Expected a download over max_bytes to fail")
+	}
+
+	path := filepath.Join(dir, "release.tar")
+	if _, err := tool.Execute(ctx, map[string]interface{}{"url": server.URL, "path": path, "sha256": hex.EncodeToString(sum[:])}); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "release contents" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+	if _, err := tool.Execute(ctx, map[string]interface{}{"url": server.URL, "path": path}); err == nil {
+		t.Error("Expected downloading over an existing file to fail")
+	}
+}
+
+func TestArchives(t *testing.T) {
+	dir := t.TempDir()
+	writeTarGz := func(name string, entries map[string]string) string {
+		path := filepath.Join(dir, name)
+		f, _ := os.Create(path)
+		gz := gzip.NewWriter(f)
+		tw := tar.NewWriter(gz)
+		for entry, content := range entries {
+			tw.WriteHeader(&tar.Header{Name: entry, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+			tw.Write([]byte(content))
+		}
+		tw.Close()
+		gz.Close()
+		f.Close()
+		return path
+	}
+	ctx := context.Background()
+
+	good := writeTarGz("vendor.tar.gz", map[string]string{"vendor/lib/a.go": "package lib\n", "vendor/README": "hi\n"})
+	listing, err := NewListArchiveTool().Execute(ctx, map[string]interface{}{"path": good})
+	if err != nil || !strings.Contains(listing, "2 files") || !strings.Contains(listing, "vendor/lib/a.go") {
+		t.Errorf("Unexpected listing %q (%v)", listing, err)
+	}
+	extract := NewExtractArchiveTool()
+	if _, err := extract.Execute(ctx, map[string]interface{}{"path": good}); err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "vendor", "vendor", "lib", "a.go")); string(data) != "package lib\n" {
+		t.Errorf("Unexpected extracted contents %q", data)
+	}
+	if _, err := extract.Execute(ctx, map[string]interface{}{"path": good}); err == nil {
+		t.Error("Expected extracting over existing files to fail")
+	}
+	if _, err := extract.Execute(ctx, map[string]interface{}{"path": good, "destination": filepath.Join(dir, "small"), "max_bytes": float64(4)}); err == nil {
+		t.Error("Expected an archive over max_bytes to fail")
+	}
+
+	evil := writeTarGz("evil.tar.gz", map[string]string{"ok.txt": "fine", "../escape.txt": "gotcha"})
+	if listing, _ := NewListArchiveTool().Execute(ctx, map[string]interface{}{"path": evil}); !strings.Contains(listing, "⚠️") {
+		t.Errorf("Expected the escaping entry to be flagged, got %q", listing)
+	}
+	if _, err := extract.Execute(ctx, map[string]interface{}{"path": evil}); err == nil {
+		t.Error("Expected an archive with an escaping entry to be refused")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape.txt")); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be written outside the destination")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "evil")); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be extracted from a refused archive")
+	}
+}
+
+func TestArchiveChainedLinks(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret.txt")
+	os.WriteFile(secret, []byte("private"), 0600)
+	writeTar := func(name string, headers []tar.Header) string {
+		path := filepath.Join(dir, name)
+		f, _ := os.Create(path)
+		tw := tar.NewWriter(f)
+		for _, hdr := range headers {
+			tw.WriteHeader(&hdr)
+		}
+		tw.Close()
+		f.Close()
+		return path
+	}
+	ctx := context.Background()
+	extract := NewExtractArchiveTool()
+
+	// Each link looks harmless on its own, but l2 resolves to dir
+	chained := writeTar("chained.tar", []tar.Header{
+		{Name: "l1", Linkname: ".", Typeflag: tar.TypeSymlink},
+		{Name: "l2", Linkname: "l1/..", Typeflag: tar.TypeSymlink},
+		{Name: "h", Linkname: "l2/secret.txt", Typeflag: tar.TypeLink},
+	})
+	if _, err := extract.Execute(ctx, map[string]interface{}{"path": chained}); err == nil {
+		t.Error("Expected chained symlinks leading out to be refused")
+	}
+	if info, err := os.Lstat(filepath.Join(dir, "chained", "h")); err == nil {
+		if outside, _ := os.Stat(secret); os.SameFile(info, outside) {
+			t.Error("Expected the file outside not to be hard-linked in")
+		}
+	}
+
+	// l only leads out once a, extracted after it, exists
+	later := writeTar("later.tar", []tar.Header{
+		{Name: "l", Linkname: "a/..", Typeflag: tar.TypeSymlink},
+		{Name: "a", Linkname: ".", Typeflag: tar.TypeSymlink},
+	})
+	if _, err := extract.Execute(ctx, map[string]interface{}{"path": later}); err == nil {
+		t.Error("Expected a symlink leading out through a later one to be refused")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "later")); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be left of a refused archive")
+	}
+
+	inside := writeTar("inside.tar", []tar.Header{
+		{Name: "data.txt", Typeflag: tar.TypeReg, Mode: 0644},
+		{Name: "l1", Linkname: ".", Typeflag: tar.TypeSymlink},
+		{Name: "h", Linkname: "l1/data.txt", Typeflag: tar.TypeLink},
+	})
+	if _, err := extract.Execute(ctx, map[string]interface{}{"path": inside}); err != nil {
+		t.Errorf("Expected links inside the archive to extract, got %v", err)
+	}
+}
+
+func TestModelAsToolOverrides(t *testing.T) {
+	var got ollama.ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":"ok"},"done":true}`)
+	}))
+	defer server.Close()
+
+	temperature := 0.2
+	tool := NewModelAsTool(ollama.NewClient(server.URL), config.ModelAsTool{
+		ModelName: "coder", SystemPrompt: "Code only.", Temperature: &temperature, MaxTokens: 100,
+	})
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"question": "sort a slice"}); err != nil {
+		t.Fatalf("ask: %v", err)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Role != "system" || got.Messages[0].Content != "Code only." {
+		t.Errorf("Expected the system prompt first, got %+v", got.Messages)
+	}
+	if got.Options["temperature"] != 0.2 || got.Options["num_predict"] != float64(100) {
+		t.Errorf("Unexpected options %v", got.Options)
+	}
+}
+
+func TestModelAsToolContext(t *testing.T) {
+	var got ollama.ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":"ok"},"done":true}`)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "main.go")
+	os.WriteFile(path, []byte("package main\n"), 0644)
+	registry := NewRegistry()
+	registry.Register(NewReadFileTool())
+	tool := NewModelAsTool(ollama.NewClient(server.URL), config.ModelAsTool{ModelName: "coder"})
+	registry.Register(tool)
+
+	ctx := WithConversation(context.Background(), func() []ollama.Message {
+		return []ollama.Message{
+			{Role: "system", Content: "You are helpful."},
+			{Role: "user", Content: "We are porting the parser to Go."},
+		}
+	})
+	if _, err := tool.Execute(ctx, map[string]interface{}{"question": "review it", "context": "recent", "files": []interface{}{path}}); err != nil {
+		t.Fatalf("ask: %v", err)
+	}
+	handoff := got.Messages[len(got.Messages)-1].Content
+	for _, want := range []string{"User: We are porting the parser to Go.", "package main", "Question:\nreview it"} {
+		if !strings.Contains(handoff, want) {
+			t.Errorf("Expected %q in the handoff, got %q", want, handoff)
+		}
+	}
+	if strings.Contains(handoff, "You are helpful") {
+		t.Error("Expected the system prompt to be left out")
+	}
+}
+
+func TestModelAsToolLimits(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		content := "short summary"
+		if calls == 1 {
+			content = strings.Repeat("very long answer ", 100)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"message": map[string]string{"role": "assistant", "content": content}, "done": true})
+	}))
+	defer server.Close()
+
+	tool := NewModelAsTool(ollama.NewClient(server.URL), config.ModelAsTool{ModelName: "chatty", MaxResultTokens: 50})
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"question": "explain"})
+	if err != nil {
+		t.Fatalf("ask: %v", err)
+	}
+	if !strings.HasPrefix(result, "short summary") || !strings.Contains(result, "summarized from ~425 tokens") || !strings.Contains(result, "chatty answered in") {
+		t.Errorf("Expected the long answer to be summarized, got %q", result)
+	}
+}
+
+func TestEvaluateExpression(t *testing.T) {
+	tool := NewEvaluateTool()
+	ctx := context.Background()
+	tests := map[string]string{
+		"sum([x*x for x in range(1, 101)])":                                "338350",
+		"def f(n):\n    return 1 if n < 2 else n * f(n-1)\nresult = f(20)": "2432902008176640000",
+		"print(json.encode({'a': [1, 2]}))":                                `{"a":[1,2]}`,
+		"math.floor(math.sqrt(2) * 1000)":                                  "1414",
+		"x = [1]\nx += [2]\nx *= 2\nresult = x":                            "[1, 2, 1, 2]",
+		"len('ab' * 3 + 'c')":                                              "7",
+		"sorted(list((3, 1, 2)))":                                          "[1, 2, 3]",
+	}
+	for code, want := range tests {
+		if got, err := tool.Execute(ctx, map[string]interface{}{"code": code}); err != nil || got != want {
+			t.Errorf("%q: got %q (%v), want %q", code, got, err, want)
+		}
+	}
+	if _, err := tool.Execute(ctx, map[string]interface{}{"code": "while True:\n    pass"}); err == nil {
+		t.Error("Expected an endless loop to be stopped")
+	}
+	if _, err := tool.Execute(ctx, map[string]interface{}{"code": "load('os', 'system')"}); err == nil {
+		t.Error("Expected load to be refused")
+	}
+
+	// One step each, but gigabytes of memory
+	for _, code := range []string{
+		"len([0] * 200000000)",
+		"len(200000000 * 'x')",
+		"s = 'x' * 1000000\nresult = len(s + s)",
+		"l = [0] * 1000000\nl += l\nresult = len(l)",
+		"l = [0]\nl *= 200000000\nresult = len(l)",
+		"len(list(range(200000000)))",
+		"len(sum([[0] * 1000000, [0]], []))",
+	} {
+		start := time.Now()
+		if _, err := tool.Execute(ctx, map[string]interface{}{"code": code}); err == nil || !strings.Contains(err.Error(), "limit") {
+			t.Errorf("%q: expected the size limit to refuse it, got %v", code, err)
+		}
+		if took := time.Since(start); took > time.Second {
+			t.Errorf("%q: took %s to refuse", code, took)
+		}
+	}
+}
+
+func TestGetEnvironment(t *testing.T) {
+	t.Setenv("EDITOR", "vi")
+	t.Setenv("DEPLOY_TOKEN", "hunter2hunter2")
+	t.Setenv("DATABASE_URL", "postgres://app:s3cret@db/app")
+	t.Setenv("OPENAI_KEY", "opensesame")
+	t.Setenv("KEYBOARD_LAYOUT", "dvorak")
+	out, err := NewEnvironmentTool().Execute(context.Background(), map[string]interface{}{
+		"variables": []interface{}{"DEPLOY_TOKEN", "DATABASE_URL", "OPENAI_KEY", "KEYBOARD_LAYOUT"},
+	})
+	if err != nil {
+		t.Fatalf("get_environment: %v", err)
+	}
+	if !strings.Contains(out, "EDITOR=vi") || !strings.Contains(out, "Architecture: ") {
+		t.Errorf("Expected the platform and EDITOR, got %q", out)
+	}
+	if strings.Contains(out, "hunter2") || strings.Contains(out, "s3cret") || strings.Contains(out, "opensesame") {
+		t.Errorf("Expected secrets to be redacted, got %q", out)
+	}
+	if !strings.Contains(out, "KEYBOARD_LAYOUT=dvorak") {
+		t.Errorf("Expected KEYBOARD_LAYOUT to be shown, got %q", out)
+	}
+}
+
+func TestScaffold(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "app")
+	ctx := context.Background()
+	args := map[string]interface{}{
+		"path":        base,
+		"directories": []interface{}{"internal"},
+		"files": []interface{}{
+			map[string]interface{}{"path": "cmd/app/main.go", "content": "package main\n"},
+			map[string]interface{}{"path": "Makefile", "content": "build:\n\tgo build ./...\n"},
+		},
+	}
+
+	tool := NewScaffoldTool()
+	details, err := tool.ApprovalDetails(args)
+	if err != nil || !strings.Contains(details, "cmd/app/main.go (1 line)") || !strings.Contains(details, "internal/") {
+		t.Errorf("Expected the tree in the approval, got %q, %v", details, err)
+	}
+	if _, err := tool.Execute(ctx, args); err != nil {
+		t.Fatalf("scaffold: %v", err)
+	}
+	for _, path := range []string{"internal", "cmd/app/main.go", "Makefile"} {
+		if _, err := os.Stat(filepath.Join(base, path)); err != nil {
+			t.Errorf("Expected %s to be created", path)
+		}
+	}
+
+	if _, err := tool.Execute(ctx, args); err == nil {
+		t.Error("Expected scaffolding over existing files to fail")
+	}
+	escape := map[string]interface{}{"path": base, "files": []interface{}{map[string]interface{}{"path": "../x", "content": ""}}}
+	if _, err := tool.Execute(ctx, escape); err == nil {
+		t.Error("Expected a path outside the scaffold directory to fail")
+	}
+}
+
+func TestMoveAndDeleteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	os.WriteFile(path, []byte("hello"), 0644)
+	ctx := context.Background()
+
+	moved := filepath.Join(dir, "sub", "b.txt")
+	if _, err := NewMoveFileTool().Execute(ctx, map[string]interface{}{"path": path, "destination": moved}); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	if _, err := os.Stat(moved); err != nil {
+		t.Fatalf("Expected %s to exist after moving", moved)
+	}
+	os.WriteFile(path, []byte("other"), 0644)
+	if _, err := NewMoveFileTool().Execute(ctx, map[string]interface{}{"path": path, "destination": moved}); err == nil {
+		t.Error("Expected moving onto an existing file to fail")
+	}
+
+	trash := NewTrash()
+	t.Cleanup(func() { os.RemoveAll(trash.dir) })
+	del := NewDeleteFileTool(trash)
+	if _, err := del.Execute(ctx, map[string]interface{}{"path": filepath.Join(dir, "sub")}); err == nil {
+		t.Error("Expected deleting a directory without recursive to fail")
+	}
+	if _, err := del.Execute(ctx, map[string]interface{}{"path": moved}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := os.Stat(moved); !os.IsNotExist(err) {
+		t.Error("Expected the deleted file to be gone")
+	}
+	if _, err := trash.Restore(0); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if data, _ := os.ReadFile(moved); string(data) != "hello" {
+		t.Errorf("Expected the restored file back, got %q", data)
+	}
+	if len(trash.Items()) != 0 {
+		t.Error("Expected the trash to be empty after restoring")
+	}
+
+	// Names in the trash don't repeat once an item is restored
+	for _, content := range []string{"one", "two"} {
+		os.WriteFile(moved, []byte(content), 0644)
+		if _, err := trash.Put(moved); err != nil {
+			t.Fatalf("put %s: %v", content, err)
+		}
+	}
+	if _, err := trash.Restore(0); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if _, err := trash.Put(moved); err != nil {
+		t.Fatalf("put again: %v", err)
+	}
+	if items := trash.Items(); len(items) != 2 || items[0].Stored == items[1].Stored {
+		t.Fatalf("Expected two items with their own names, got %+v", items)
+	}
+	if data, _ := os.ReadFile(trash.Items()[0].Stored); string(data) != "two" {
+		t.Errorf("Expected the earlier copy to be kept, got %q", data)
+	}
+}
+
+func TestDeleteSymlinkToDirectory(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	os.Mkdir(target, 0755)
+	os.WriteFile(filepath.Join(target, "keep.txt"), []byte("keep"), 0644)
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	trash := NewTrash()
+	t.Cleanup(func() { os.RemoveAll(trash.dir) })
+	if _, err := NewDeleteFileTool(trash).Execute(context.Background(), map[string]interface{}{"path": link}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		t.Error("Expected the link to be gone")
+	}
+	if data, err := os.ReadFile(filepath.Join(target, "keep.txt")); err != nil || string(data) != "keep" {
+		t.Errorf("Expected the link's target to be untouched, got %q, %v", data, err)
+	}
+	items := trash.Items()
+	if len(items) != 1 || items[0].IsDir {
+		t.Fatalf("Expected the link in the trash, got %+v", items)
+	}
+	if info, err := os.Lstat(items[0].Stored); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Expected a symlink in the trash, got %v, %v", info, err)
+	}
+}
+
+type recordingChecker struct{ details []string }
+
+func (c *recordingChecker) RequestPermission(ctx context.Context, tool string, level PermissionLevel, details string) (bool, error) {
+	c.details = append(c.details, details)
+	return false, nil
+}
+
+func TestWorkspaceJail(t *testing.T) {
+	outside := t.TempDir()
+	testFile := filepath.Join(outside, "test.txt")
+	os.WriteFile(testFile, []byte("content"), 0644)
+
+	permConfig := DefaultPermissionConfig()
+	tool := NewProtectedTool(NewReadFileTool(), PermissionRead, nil, permConfig)
+
+	ctx := context.Background()
+	args := map[string]interface{}{
+		"path": testFile,
+	}
+
+	if _, err := tool.Execute(ctx, args); err == nil {
+		t.Error("Expected path outside the workspace to be denied")
+	}
+
+	permConfig.AddAllowedRoot(outside)
+	if _, err := tool.Execute(ctx, args); err != nil {
+		t.Errorf("Expected allowed root to be accessible, got %v", err)
+	}
+
+	permConfig.SetAllowedRoots(nil)
+	permConfig.SetRestrictToWorkingDir(false)
+	if _, err := tool.Execute(ctx, args); err != nil {
+		t.Errorf("Expected access with jail disabled, got %v", err)
+	}
+}
+
+func TestPathPolicy(t *testing.T) {
+	base := t.TempDir()
+	allowed := filepath.Join(base, "projects")
+	secret := filepath.Join(base, "secret")
+	os.MkdirAll(allowed, 0755)
+	os.MkdirAll(secret, 0755)
+	os.WriteFile(filepath.Join(secret, "key"), []byte("hunter2"), 0600)
+
+	// A symlink inside the allowed root pointing at the denied one
+	link := filepath.Join(allowed, "link")
+	if err := os.Symlink(secret, link); err != nil {
+		t.Skip("symlinks not supported")
+	}
+
+	tool := NewReadFileTool()
+	tool.SetPathPolicy(NewPathPolicy([]string{allowed}, []string{secret}))
+	ctx := context.Background()
+
+	if _, err := tool.Execute(ctx, map[string]interface{}{"path": filepath.Join(link, "key")}); err == nil {
+		t.Error("Expected symlink into denied root to be refused")
+	}
+
+	if _, err := tool.Execute(ctx, map[string]interface{}{"path": filepath.Join(base, "other.txt")}); err == nil {
+		t.Error("Expected path outside allowed roots to be refused")
+	}
+
+	writeTool := NewWriteFileTool()
+	writeTool.SetPathPolicy(NewPathPolicy([]string{allowed}, []string{secret}))
+	if _, err := writeTool.Execute(ctx, map[string]interface{}{"path": filepath.Join(link, "new", "file"), "content": "x"}); err == nil {
+		t.Error("Expected new file under symlinked denied root to be refused")
+	}
+	if _, err := writeTool.Execute(ctx, map[string]interface{}{"path": filepath.Join(allowed, "ok.txt"), "content": "x"}); err != nil {
+		t.Errorf("Expected write inside allowed root to succeed, got %v", err)
+	}
+}
+
+type denyChecker struct{ asked int }
+
+func (c *denyChecker) RequestPermission(ctx context.Context, tool string, level PermissionLevel, details string) (bool, error) {
+	c.asked++
+	return false, nil
+}
+
+func TestAlwaysAllowPathCanonicalized(t *testing.T) {
+	base := t.TempDir()
+	proj := filepath.Join(base, "proj")
+	sibling := filepath.Join(base, "project-secret")
+	os.MkdirAll(proj, 0755)
+	os.MkdirAll(sibling, 0755)
+	os.WriteFile(filepath.Join(proj, "ok.txt"), []byte("ok"), 0644)
+	os.WriteFile(filepath.Join(sibling, "key"), []byte("hunter2"), 0600)
+
+	permConfig := DefaultPermissionConfig()
+	permConfig.SetRestrictToWorkingDir(false)
+	permConfig.AutoApproveRead = false
+	permConfig.AlwaysAllowPatterns = []PermissionPattern{
+		{Tool: "read_file", PathPattern: proj, Enabled: true},
+	}
+	checker := &denyChecker{}
+	tool := NewProtectedTool(NewReadFileTool(), PermissionRead, checker, permConfig)
+	ctx := context.Background()
+
+	if _, err := tool.Execute(ctx, map[string]interface{}{"path": filepath.Join(proj, "ok.txt")}); err != nil {
+		t.Errorf("Expected file inside pattern directory to be allowed, got %v", err)
+	}
+
+	escapes := []string{
+		filepath.Join(sibling, "key"),
+		filepath.Join(proj, "..", "project-secret", "key"),
+	}
+	link := filepath.Join(proj, "link")
+	if err := os.Symlink(sibling, link); err == nil {
+		escapes = append(escapes, filepath.Join(link, "key"))
+	}
+
+	for _, path := range escapes {
+		before := checker.asked
+		if _, err := tool.Execute(ctx, map[string]interface{}{"path": path}); err == nil {
+			t.Errorf("Expected %s not to be auto-allowed", path)
+		}
+		if checker.asked == before {
+			t.Errorf("Expected approval to be requested for %s", path)
+		}
+	}
+}
+
+type echoExecutor struct{}
+
+func (echoExecutor) Execute(ctx context.Context, command string) (string, int, error) {
+	return command, 0, nil
+}
+
+func TestCommandAllowlist(t *testing.T) {
+	bash := NewBashTool()
+	bash.SetExecutor(echoExecutor{})
+
+	permConfig := DefaultPermissionConfig()
+	permConfig.RequireApprovalExecute = false
+	permConfig.SetCommandAllowlist(true)
+	permConfig.SetAllowedCommands([]string{"go", "git", "ls", "sudo"})
+	checker := &denyChecker{}
+	tool := NewProtectedTool(bash, PermissionExecute, checker, permConfig)
+	ctx := context.Background()
+
+	for _, command := range []string{"go test ./...", "git status && ls -la", "ls | git hash-object --stdin", "go vet ./... > /dev/null 2>&1"} {
+		if _, err := tool.Execute(ctx, map[string]interface{}{"command": command}); err != nil {
+			t.Errorf("Expected %q to run without approval, got %v", command, err)
+		}
+	}
+	if checker.asked != 0 {
+		t.Errorf("Expected no approval requests for allowed commands, got %d", checker.asked)
+	}
+
+	for _, command := range []string{"curl example.com", "go test; rm file", "ls $(whoami)", "bash -c 'python x.py'", "./go build", "env go test",
+		"echo x >> ~/.bashrc", "ls > /tmp/x", "git log &> out.txt"} {
+		before := checker.asked
+		if _, err := tool.Execute(ctx, map[string]interface{}{"command": command}); err == nil {
+			t.Errorf("Expected %q to need approval", command)
+		}
+		if checker.asked == before {
+			t.Errorf("Expected approval to be requested for %q", command)
+		}
+	}
+
+	// Without a way to ask, commands off the list are refused
+	unattended := NewProtectedTool(bash, PermissionExecute, nil, permConfig)
+	if _, err := unattended.Execute(ctx, map[string]interface{}{"command": "make"}); err == nil {
+		t.Error("Expected command off the allowlist to be refused without a checker")
+	}
+	if _, err := unattended.Execute(ctx, map[string]interface{}{"command": "sudo git pull"}); err != nil {
+		t.Errorf("Expected allowed wrapper and command to run, got %v", err)
+	}
+
+	// Interpreters run anything, so they aren't allowed out of the box
+	defaults := NewCommandAllowlist(DefaultAllowedCommands())
+	for _, command := range []string{"python3 -c 'import os; os.remove(\"x\")'", "node -e 'process.exit()'", "npx some-package", "env sh -c id"} {
+		if defaults.Allows(command) {
+			t.Errorf("Expected %q not to be allowed by default", command)
+		}
+	}
+	if !defaults.Allows("go test ./... | tail") {
+		t.Error("Expected go and tail to be allowed by default")
+	}
+}
+
+func TestSessionGrants(t *testing.T) {
+	bash := NewBashTool()
+	bash.SetExecutor(echoExecutor{})
+
+	permConfig := DefaultPermissionConfig()
+	checker := &denyChecker{}
+	tool := NewProtectedTool(bash, PermissionExecute, checker, permConfig)
+	ctx := context.Background()
+	args := map[string]interface{}{"command": "make test"}
+
+	if _, err := tool.Execute(ctx, args); err == nil {
+		t.Fatal("Expected command to need approval before any grant")
+	}
+
+	session := PermissionPattern{Tool: "run_command", CommandPattern: "make", Enabled: true, Session: true}
+	if !permConfig.AddAlwaysAllowPattern(session) {
+		t.Fatal("Expected session grant to be added")
+	}
+	if _, err := tool.Execute(ctx, args); err != nil {
+		t.Errorf("Expected session grant to apply right away, got %v", err)
+	}
+
+	saved := session
+	saved.Session = false
+	if !permConfig.AddAlwaysAllowPattern(saved) {
+		t.Error("Expected saving a session grant to replace it")
+	}
+	if permConfig.AddAlwaysAllowPattern(session) {
+		t.Error("Expected session grant not to downgrade a saved one")
+	}
+	if patterns := permConfig.Patterns(); len(patterns) != 1 || patterns[0].Session {
+		t.Errorf("Expected one saved grant, got %+v", patterns)
+	}
+
+	root := t.TempDir()
+	permConfig.AddSessionRoot(root)
+	if !permConfig.IsSessionRoot(root) {
+		t.Error("Expected root to be session-only")
+	}
+	permConfig.AddAllowedRoot(root)
+	if permConfig.IsSessionRoot(root) {
+		t.Error("Expected saving a session root to make it permanent")
+	}
+}
+
+func TestProjectRoots(t *testing.T) {
+	api, web := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(api, "main.go"), []byte("package main"), 0644)
+
+	permConfig := DefaultPermissionConfig()
+	if _, err := permConfig.AddProjectRoot("api", api); err != nil {
+		t.Fatalf("AddProjectRoot: %v", err)
+	}
+	permConfig.AddProjectRoot("web", web)
+	if _, err := permConfig.AddProjectRoot("no/slash", web); err == nil {
+		t.Error("Expected invalid root name to be refused")
+	}
+	ctx := context.Background()
+
+	read := NewProtectedTool(NewReadFileTool(), PermissionRead, nil, permConfig)
+	properties := read.Parameters()["properties"].(map[string]interface{})
+	if _, ok := properties["root"]; !ok {
+		t.Error("Expected read_file to take a root argument")
+	}
+	result, err := read.Execute(ctx, map[string]interface{}{"root": "api", "path": "main.go"})
+	if err != nil || !strings.Contains(result, "package main") {
+		t.Errorf("Expected path relative to root api, got %q, %v", result, err)
+	}
+	if _, err := read.Execute(ctx, map[string]interface{}{"root": "docs", "path": "main.go"}); err == nil {
+		t.Error("Expected unknown root to be an error")
+	}
+
+	bash := NewBashTool()
+	bash.SetExecutor(echoExecutor{})
+	checker := &denyChecker{}
+	run := NewProtectedTool(bash, PermissionExecute, checker, permConfig)
+	permConfig.AddAlwaysAllowPattern(PermissionPattern{Tool: "run_command", CommandPattern: "make", Root: "api", Enabled: true})
+
+	if _, err := run.Execute(ctx, map[string]interface{}{"root": "api", "command": "make test"}); err != nil {
+		t.Errorf("Expected grant for root api to apply there, got %v", err)
+	}
+	if _, err := run.Execute(ctx, map[string]interface{}{"root": "web", "command": "make test"}); err == nil || checker.asked != 1 {
+		t.Errorf("Expected grant for root api to need approval in root web, got %v", err)
+	}
+}
+
+func TestRememberAndRecall(t *testing.T) {
+	ctx := context.Background()
+	store := notes.Open(t.TempDir())
+	remember := NewRememberTool(store)
+	recall := NewRecallTool(store)
+
+	for _, fact := range []string{"The API key lives in .env.local", "Tests need\ndocker compose up", "the api key lives in .env.local"} {
+		if _, err := remember.Execute(ctx, map[string]interface{}{"fact": fact}); err != nil {
+			t.Fatalf("remember %q: %v", fact, err)
+		}
+	}
+	all, _ := store.List()
+	if len(all) != 2 || all[1].Text != "Tests need docker compose up" {
+		t.Fatalf("Expected two one-line notes without the duplicate, got %+v", all)
+	}
+
+	result, err := recall.Execute(ctx, map[string]interface{}{"query": "DOCKER up"})
+	if err != nil || !strings.Contains(result, "docker compose") || strings.Contains(result, "API") {
+		t.Errorf("Expected only the docker note, got %q, %v", result, err)
+	}
+
+	if err := store.Update(0, "The API key lives in .env"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+	if all, _ := store.List(); len(all) != 1 || all[0].Text != "The API key lives in .env" || all[0].Added == "" {
+		t.Errorf("Expected the edited note to keep its date, got %+v", all)
+	}
+}
+
+func TestParseContainers(t *testing.T) {
+	docker := `{"ID":"3f2a9c1b7d4e","Names":"web","Image":"nginx:1.27","State":"running","Status":"Up 2 hours","Ports":"0.0.0.0:8080->80/tcp"}
+{"ID":"a1b2c3d4e5f6","Names":"db","Image":"postgres:16","State":"exited","Status":"Exited (1) 5 minutes ago","Ports":""}`
+	podman := `[{"Id":"9e8d7c6b5a4f3e2d1c0b","Names":["api"],"Image":"localhost/api:dev","State":"running","Ports":[{"host_port":3000,"container_port":3000,"protocol":"tcp"}]}]`
+
+	containers, err := parseContainers(docker)
+	if err != nil || len(containers) != 2 {
+		t.Fatalf("Expected two docker containers, got %+v, %v", containers, err)
+	}
+	if c := containers[1]; c.Name != "db" || c.Status != "Exited (1) 5 minutes ago" {
+		t.Errorf("Unexpected docker container %+v", c)
+	}
+
+	containers, err = parseContainers(podman)
+	if err != nil || len(containers) != 1 {
+		t.Fatalf("Expected one podman container, got %+v, %v", containers, err)
+	}
+	if c := containers[0]; c.ID != "9e8d7c6b5a4f" || c.Name != "api" || c.Ports != "3000->3000/tcp" {
+		t.Errorf("Unexpected podman container %+v", c)
+	}
+	if out := formatContainers(containers); !strings.Contains(out, "api  localhost/api:dev  running  ports 3000->3000/tcp") {
+		t.Errorf("Unexpected listing %q", out)
+	}
+}
+
+func TestParseProcesses(t *testing.T) {
+	out := `    1     0 root       0.0  0.1  11840      10-02:03:04 /sbin/init splash
+ 4242     1 dev       97.5  3.2 524288         01:02 node server.js --port 3000
+`
+	processes := parseProcesses(out)
+	if len(processes) != 2 {
+		t.Fatalf("Expected 2 processes, got %d", len(processes))
+	}
+	p := processes[1]
+	if p.pid != 4242 || p.ppid != 1 || p.user != "dev" || p.cpu != 97.5 || p.rss != 524288*1024 {
+		t.Errorf("Unexpected process %+v", p)
+	}
+	if p.command != "node server.js --port 3000" || p.name() != "node" {
+		t.Errorf("Unexpected command %q (name %q)", p.command, p.name())
+	}
+}
+
+func TestFetchMorePages(t *testing.T) {
+	ctx := context.Background()
+	var sb strings.Builder
+	for i := 0; i < 100; i++ {
+		sb.WriteString(fmt.Sprintf("line %03d ünïcode\n", i))
+	}
+	text := sb.String()
+	store := NewOutputStore()
+	handle := store.Put(text)
+	fetch := NewFetchMoreTool(store, 200)
+
+	var got strings.Builder
+	offset := 0.0
+	for pages := 0; ; pages++ {
+		if pages > 20 {
+			t.Fatal("Paging did not reach the end")
+		}
+		result, err := fetch.Execute(ctx, map[string]interface{}{"handle": handle, "offset": offset})
+		if err != nil {
+			t.Fatal(err)
+		}
+		note := strings.LastIndex(result, "\n[")
+		page := result[:note]
+		if !strings.HasSuffix(page, "\n") {
+			t.Errorf("Expected pages to end at a line break, got %q", page)
+		}
+		got.WriteString(page)
+		if strings.Contains(result, "[End of "+handle) {
+			break
+		}
+		var next int
+		if _, err := fmt.Sscanf(result[strings.Index(result, "and offset ")+len("and offset "):], "%d", &next); err != nil {
+			t.Fatalf("No offset in %q", result[note:])
+		}
+		offset = float64(next)
+	}
+	if got.String() != text {
+		t.Error("Pages don't add up to the stored output")
+	}
+
+	if _, err := fetch.Execute(ctx, map[string]interface{}{"handle": "out-99"}); err == nil {
+		t.Error("Expected an error for an unknown handle")
+	}
+}
+
+func TestTodoTools(t *testing.T) {
+	ctx := context.Background()
+	list := todo.NewList()
+	result, err := NewTodoAddTool(list).Execute(ctx, map[string]interface{}{"tasks": []interface{}{"read the code", "fix the bug", " "}})
+	if err != nil || !strings.Contains(result, "Added 2 tasks (1, 2)") {
+		t.Fatalf("todo_add = %q, %v", result, err)
+	}
+	if _, err := NewTodoUpdateTool(list).Execute(ctx, map[string]interface{}{"id": float64(1), "status": "started"}); err == nil {
+		t.Error("expected an unknown status to be refused")
+	}
+	if _, err := NewTodoUpdateTool(list).Execute(ctx, map[string]interface{}{"id": float64(2), "status": todo.InProgress}); err != nil {
+		t.Fatal(err)
+	}
+	result, err = NewTodoCompleteTool(list).Execute(ctx, map[string]interface{}{"id": float64(1), "note": "it is in parse.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "- [x] 1. read the code — it is in parse.go\n- [~] 2. fix the bug (in progress)"
+	if !strings.Contains(result, "(1 open)") || !strings.HasSuffix(result, want) {
+		t.Errorf("todo_complete = %q", result)
+	}
+	if _, err := NewTodoCompleteTool(list).Execute(ctx, map[string]interface{}{"id": float64(9)}); err == nil {
+		t.Error("expected an error for a missing task")
+	}
+
+	// A restored list continues numbering after its highest ID
+	list.Restore([]todo.Item{{ID: 7, Text: "old", Status: todo.Pending}})
+	if added := list.Add("new"); added[0].ID != 8 {
+		t.Errorf("expected ID 8 after restore, got %d", added[0].ID)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	bash := NewBashTool()
+	bash.SetExecutor(echoExecutor{})
+
+	// The user's config would run anything without asking
+	permConfig := DefaultPermissionConfig()
+	permConfig.RequireApprovalExecute = false
+	permConfig.SetCommandAllowlist(true)
+	permConfig.SetAllowedCommands([]string{"go", "docker"})
+	permConfig.AddAlwaysAllowPattern(PermissionPattern{Tool: "run_command", AlwaysAllow: true, Enabled: true})
+	permConfig.SetPolicy(&Policy{
+		RequireApproval:      map[PermissionLevel]bool{PermissionExecute: true},
+		BlockedCommands:      []string{"docker"},
+		DisabledTools:        map[string]bool{"evaluate_expression": true},
+		RestrictToWorkingDir: true,
+	})
+	ctx := context.Background()
+
+	checker := &denyChecker{}
+	tool := NewProtectedTool(bash, PermissionExecute, checker, permConfig)
+	if _, err := tool.Execute(ctx, map[string]interface{}{"command": "go test ./..."}); err == nil || checker.asked != 1 {
+		t.Errorf("Expected the policy to make the command ask, got %v after %d requests", err, checker.asked)
+	}
+	if _, err := tool.Execute(ctx, map[string]interface{}{"command": "sudo docker ps"}); err == nil || checker.asked != 1 {
+		t.Errorf("Expected the policy to block docker without asking, got %v", err)
+	}
+
+	auto := NewProtectedTool(bash, PermissionExecute, NewAutoApproveChecker(), permConfig)
+	if _, err := auto.Execute(ctx, map[string]interface{}{"command": "go version"}); err == nil {
+		t.Error("Expected auto-approval to be refused under the policy")
+	}
+
+	permConfig.SetRestrictToWorkingDir(false)
+	if restrict, _ := permConfig.Workspace(); !restrict {
+		t.Error("Expected the policy to keep the workspace jail on")
+	}
+
+	registry := NewRegistry()
+	registry.Register(NewEvaluateTool())
+	registry.SetPolicy(permConfig.Policy())
+	registry.Register(NewEvaluateTool())
+	if _, ok := registry.Get("evaluate_expression"); ok {
+		t.Error("Expected the policy to remove the disabled tool")
+	}
+
+	// Custom tools would run shell commands with run_command disabled
+	registry.Register(NewCustomCommandTool("greet", "Say hi", "echo hi", nil))
+	registry.SetPolicy(&Policy{DisabledTools: map[string]bool{"run_command": true}})
+	registry.Register(NewAddCustomToolTool(registry, nil))
+	for _, name := range []string{"greet", "add_custom_tool"} {
+		if _, ok := registry.Get(name); ok {
+			t.Errorf("Expected %s to be removed along with run_command", name)
+		}
+	}
+}
+
+// outsideChecker approves everything and lets each outside path through once
+type outsideChecker struct{ asked int }
+
+func (c *outsideChecker) RequestPermission(ctx context.Context, tool string, level PermissionLevel, details string) (bool, error) {
+	return true, nil
+}
+
+func (c *outsideChecker) RequestOutsideWorkspace(ctx context.Context, tool, path string) (OutsideWorkspaceDecision, error) {
+	c.asked++
+	return OutsideWorkspaceAllowOnce, nil
+}
+
+func TestPolicyLocksWorkspace(t *testing.T) {
+	outside := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(outside, []byte("hi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	permConfig := DefaultPermissionConfig()
+	permConfig.AddAllowedRoot(filepath.Dir(outside))
+	ctx := context.Background()
+	args := map[string]interface{}{"path": outside}
+
+	checker := &outsideChecker{}
+	tool := NewProtectedTool(NewReadFileTool(), PermissionRead, checker, permConfig)
+	if _, err := tool.Execute(ctx, args); err != nil {
+		t.Fatalf("Expected the allowed root to work without a policy: %v", err)
+	}
+
+	permConfig.SetPolicy(&Policy{RestrictToWorkingDir: true})
+	if _, roots := permConfig.Workspace(); len(roots) != 0 {
+		t.Errorf("Expected the policy to ignore allowed roots, got %v", roots)
+	}
+	if _, err := tool.Execute(ctx, args); err == nil || checker.asked != 0 {
+		t.Errorf("Expected the outside path to be refused without asking, got %v after %d requests", err, checker.asked)
+	}
+}
+
+type fakeAsker struct {
+	answer   string
+	question string
+	options  []string
+}
+
+func (a *fakeAsker) AskUser(ctx context.Context, question string, options []string) (string, error) {
+	a.question, a.options = question, options
+	return a.answer, nil
+}
+
+func TestAskUserTool(t *testing.T) {
+	tool := NewAskUserTool()
+	ctx := context.Background()
+	args := map[string]interface{}{
+		"question":   "Which database?",
+		"options":    []interface{}{"postgres", " ", "sqlite"},
+		"assumption": "sqlite",
+	}
+
+	if result, err := tool.Execute(ctx, args); err != nil || !strings.Contains(result, "assumption") {
+		t.Errorf("Expected to be told to assume without an asker, got %q, %v", result, err)
+	}
+
+	asker := &fakeAsker{answer: "postgres"}
+	tool.SetAsker(asker)
+	result, err := tool.Execute(ctx, args)
+	if err != nil || result != "The user chose: postgres" {
+		t.Errorf("Unexpected result %q, %v", result, err)
+	}
+	if !strings.Contains(asker.question, "sqlite") || len(asker.options) != 2 {
+		t.Errorf("Expected the assumption and two options, got %q %v", asker.question, asker.options)
+	}
+
+	asker.answer = "mysql"
+	if result, _ := tool.Execute(ctx, args); result != "The user answered: mysql" {
+		t.Errorf("Expected a free answer, got %q", result)
+	}
+	asker.answer = "  "
+	if result, _ := tool.Execute(ctx, args); !strings.Contains(result, "skipped") {
+		t.Errorf("Expected an empty answer to skip, got %q", result)
+	}
+	if _, err := tool.Execute(ctx, map[string]interface{}{}); err == nil {
+		t.Error("Expected an error without a question")
+	}
+}
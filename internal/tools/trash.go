@@ -0,0 +1,172 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// TrashItem is a file or directory delete_file moved to the trash
+type TrashItem struct {
+	Original string // Where it was, resolved
+	Stored   string // Where it is now
+	Deleted  time.Time
+	IsDir    bool
+}
+
+// Trash keeps what delete_file removed during the session, so it can be
+// put back. Its folder is made in the temporary directory on first use.
+type Trash struct {
+	mu    sync.Mutex
+	dir   string
+	items []TrashItem
+	next  int // Numbers stored names, so they never repeat
+}
+
+func NewTrash() *Trash {
+	return &Trash{}
+}
+
+// Put moves path into the trash. A symlink is moved itself, not its target.
+func (t *Trash) Put(path string) (TrashItem, error) {
+	abs, err := filepath.Abs(ExpandHome(path))
+	if err != nil {
+		return TrashItem{}, fmt.Errorf("resolve path '%s': %w", path, err)
+	}
+	// Only the parent is resolved, so a link in the path's last element stays
+	parent, err := ResolvePath(filepath.Dir(abs))
+	if err != nil {
+		return TrashItem{}, fmt.Errorf("resolve path '%s': %w", path, err)
+	}
+	original := filepath.Join(parent, filepath.Base(abs))
+	info, err := os.Lstat(original)
+	if err != nil {
+		return TrashItem{}, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.dir == "" {
+		dir, err := os.MkdirTemp("", "llemecode-trash-")
+		if err != nil {
+			return TrashItem{}, fmt.Errorf("create trash folder: %w", err)
+		}
+		t.dir = dir
+	}
+	t.next++
+	stored := filepath.Join(t.dir, fmt.Sprintf("%d-%s", t.next, filepath.Base(original)))
+	if _, err := os.Lstat(stored); err == nil {
+		return TrashItem{}, fmt.Errorf("%s is already in the trash", stored)
+	}
+	if err := movePath(original, stored); err != nil {
+		return TrashItem{}, err
+	}
+	item := TrashItem{Original: original, Stored: stored, Deleted: time.Now(), IsDir: info.IsDir()}
+	t.items = append(t.items, item)
+	return item, nil
+}
+
+// Items returns what is in the trash, oldest first
+func (t *Trash) Items() []TrashItem {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TrashItem(nil), t.items...)
+}
+
+// Restore puts item i (0-based) back where it was, unless something else
+// is there now
+func (t *Trash) Restore(i int) (TrashItem, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if i < 0 || i >= len(t.items) {
+		return TrashItem{}, fmt.Errorf("no trash item %d", i+1)
+	}
+	item := t.items[i]
+	if _, err := os.Lstat(item.Original); err == nil {
+		return TrashItem{}, fmt.Errorf("%s exists again; move it away first", item.Original)
+	}
+	if err := os.MkdirAll(filepath.Dir(item.Original), 0755); err != nil {
+		return TrashItem{}, fmt.Errorf("create directory: %w", err)
+	}
+	if err := movePath(item.Stored, item.Original); err != nil {
+		return TrashItem{}, err
+	}
+	t.items = append(t.items[:i], t.items[i+1:]...)
+	return item, nil
+}
+
+// Empty deletes everything in the trash for good
+func (t *Trash) Empty() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, item := range t.items {
+		if err := os.RemoveAll(item.Stored); err != nil {
+			return err
+		}
+	}
+	t.items = nil
+	return nil
+}
+
+// movePath renames from to to, copying when they are on different file
+// systems. to must not exist.
+func movePath(from, to string) error {
+	err := os.Rename(from, to)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := copyPath(from, to); err != nil {
+		os.RemoveAll(to)
+		return fmt.Errorf("copy %s: %w", from, err)
+	}
+	return os.RemoveAll(from)
+}
+
+func copyPath(from, to string) error {
+	info, err := os.Lstat(from)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(from)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, to)
+	case info.IsDir():
+		if err := os.Mkdir(to, info.Mode().Perm()); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(from)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := copyPath(filepath.Join(from, entry.Name()), filepath.Join(to, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
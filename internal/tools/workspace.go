@@ -0,0 +1,165 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ProjectRoot is one named directory of a multi-root workspace, such as a
+// backend and a frontend repository worked on together
+type ProjectRoot struct {
+	Name string
+	Path string // Resolved
+}
+
+var rootNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// AddProjectRoot registers a named root, replacing one with the same name
+func (c *PermissionConfig) AddProjectRoot(name, path string) (ProjectRoot, error) {
+	if !rootNamePattern.MatchString(name) {
+		return ProjectRoot{}, fmt.Errorf("invalid root name %q: use letters, digits, '.', '_' or '-'", name)
+	}
+	resolved, err := ResolvePath(path)
+	if err != nil {
+		return ProjectRoot{}, fmt.Errorf("resolve %s: %w", path, err)
+	}
+	root := ProjectRoot{Name: name, Path: resolved}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, existing := range c.projectRoots {
+		if existing.Name == name {
+			c.projectRoots[i] = root
+			return root, nil
+		}
+	}
+	c.projectRoots = append(c.projectRoots, root)
+	return root, nil
+}
+
+// RemoveProjectRoot unregisters a root, returning false if there was none
+func (c *PermissionConfig) RemoveProjectRoot(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, existing := range c.projectRoots {
+		if existing.Name == name {
+			c.projectRoots = append(c.projectRoots[:i], c.projectRoots[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectRoots returns the registered roots in the order they were added
+func (c *PermissionConfig) ProjectRoots() []ProjectRoot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]ProjectRoot(nil), c.projectRoots...)
+}
+
+// ProjectRoot looks up a registered root by name
+func (c *PermissionConfig) ProjectRoot(name string) (ProjectRoot, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, root := range c.projectRoots {
+		if root.Name == name {
+			return root, true
+		}
+	}
+	return ProjectRoot{}, false
+}
+
+// applyProjectRoot handles the optional root argument: relative paths are
+// made relative to that root, and a missing path means the root itself.
+// The returned args no longer contain root.
+func (c *PermissionConfig) applyProjectRoot(tool Tool, args map[string]interface{}) (map[string]interface{}, ProjectRoot, error) {
+	name, _ := args["root"].(string)
+	if name == "" {
+		return args, ProjectRoot{}, nil
+	}
+	root, ok := c.ProjectRoot(name)
+	if !ok {
+		var names []string
+		for _, r := range c.ProjectRoots() {
+			names = append(names, r.Name)
+		}
+		if len(names) == 0 {
+			return nil, ProjectRoot{}, fmt.Errorf("unknown root %q: no project roots are registered", name)
+		}
+		return nil, ProjectRoot{}, fmt.Errorf("unknown root %q (roots: %s)", name, strings.Join(names, ", "))
+	}
+
+	rooted := make(map[string]interface{}, len(args))
+	for key, value := range args {
+		if key != "root" {
+			rooted[key] = value
+		}
+	}
+	found := false
+	for _, key := range []string{"path", "file_path", "destination"} {
+		if path, ok := rooted[key].(string); ok {
+			found = true
+			if !filepath.IsAbs(ExpandHome(path)) {
+				rooted[key] = filepath.Join(root.Path, path)
+			}
+		}
+	}
+	if !found && hasParameter(tool, "path") {
+		rooted["path"] = root.Path
+	}
+	return rooted, root, nil
+}
+
+// takesRoot reports whether a tool works on paths or runs commands, so a
+// root argument means something to it
+func takesRoot(tool Tool) bool {
+	return tool.Name() == "run_command" || hasParameter(tool, "path") || hasParameter(tool, "file_path")
+}
+
+func hasParameter(tool Tool, name string) bool {
+	properties, _ := tool.Parameters()["properties"].(map[string]interface{})
+	_, ok := properties[name]
+	return ok
+}
+
+// withRootParameter adds the optional root argument to a tool's schema,
+// listing the registered roots
+func withRootParameter(params map[string]interface{}, roots []ProjectRoot) map[string]interface{} {
+	names := make([]string, len(roots))
+	for i, root := range roots {
+		names[i] = root.Name
+	}
+	properties, _ := params["properties"].(map[string]interface{})
+	extended := make(map[string]interface{}, len(properties)+1)
+	for key, value := range properties {
+		extended[key] = value
+	}
+	extended["root"] = map[string]interface{}{
+		"type":        "string",
+		"enum":        names,
+		"description": "Project root that relative paths and commands are relative to; omit for the working directory",
+	}
+	out := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		out[key] = value
+	}
+	out["properties"] = extended
+	return out
+}
+
+type workDirKey struct{}
+
+// WithWorkDir makes commands run through ctx start in dir
+func WithWorkDir(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, workDirKey{}, dir)
+}
+
+// WorkDir returns the directory commands should start in; empty means the
+// working directory
+func WorkDir(ctx context.Context) string {
+	dir, _ := ctx.Value(workDirKey{}).(string)
+	return dir
+}
@@ -7,12 +7,26 @@ import (
 	"path/filepath"
 )
 
-type WriteFileTool struct{}
+type WriteFileTool struct {
+	policy   *PathPolicy
+	versions *FileVersions
+}
 
 func NewWriteFileTool() *WriteFileTool {
 	return &WriteFileTool{}
 }
 
+// SetPathPolicy restricts which paths the tool may use
+func (t *WriteFileTool) SetPathPolicy(policy *PathPolicy) {
+	t.policy = policy
+}
+
+// SetFileVersions makes the tool refuse to overwrite files that changed
+// since the model last read or wrote them
+func (t *WriteFileTool) SetFileVersions(versions *FileVersions) {
+	t.versions = versions
+}
+
 func (t *WriteFileTool) Name() string {
 	return "write_file"
 }
@@ -33,6 +47,10 @@ func (t *WriteFileTool) Parameters() map[string]interface{} {
 				"type":        "string",
 				"description": "Content to write to the file",
 			},
+			"merge": map[string]interface{}{
+				"type":        "boolean",
+				"description": "If the file was changed by someone else since you read it, merge their changes with yours instead of failing",
+			},
 		},
 		"required": []string{"path", "content"},
 	}
@@ -43,12 +61,33 @@ func (t *WriteFileTool) Execute(ctx context.Context, args map[string]interface{}
 	if !ok {
 		return "", fmt.Errorf("path must be a string")
 	}
+	if err := t.policy.Check(path); err != nil {
+		return "", err
+	}
 
 	content, ok := args["content"].(string)
 	if !ok {
 		return "", fmt.Errorf("content must be a string")
 	}
 
+	merge, _ := args["merge"].(bool)
+	merged := false
+	if base, current, conflict := t.versions.Check(path); conflict {
+		if !merge || base == nil {
+			return "", &ConflictError{Path: path, Mergeable: base != nil}
+		}
+		result, conflicts, err := mergeFiles(ctx, base, current, []byte(content))
+		if err != nil {
+			return "", fmt.Errorf("merge %s: %w", path, err)
+		}
+		if conflicts > 0 {
+			// The next write resolves the conflict, so it is made against the current file
+			t.versions.Record(path, current)
+			return "", fmt.Errorf("merging %s left %d conflicts, so nothing was written. Resolve the conflict markers below and write the whole file again:\n\n%s", path, conflicts, result)
+		}
+		content, merged = string(result), true
+	}
+
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return "", fmt.Errorf("create directory: %w", err)
@@ -57,6 +96,10 @@ func (t *WriteFileTool) Execute(ctx context.Context, args map[string]interface{}
 	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
 		return "", fmt.Errorf("write file: %w", err)
 	}
+	t.versions.Record(path, []byte(content))
 
+	if merged {
+		return fmt.Sprintf("Merged your changes with the other changes to %s and wrote %d bytes", path, len(content)), nil
+	}
 	return fmt.Sprintf("Successfully wrote %d bytes to %s", len(content), path), nil
 }
@@ -0,0 +1,131 @@
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/llemecode/internal/config"
+)
+
+const (
+	releasesURL = "https://api.github.com/repos/LaPingvino/llemecode/releases/latest"
+
+	// InstallCommand updates an installation made with go install
+	InstallCommand = "go install github.com/LaPingvino/llemecode/cmd/llemecode@latest"
+
+	checkInterval = 24 * time.Hour
+)
+
+// Release is the latest published release
+type Release struct {
+	Tag       string    `json:"tag_name"`
+	URL       string    `json:"html_url"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// LatestRelease asks GitHub for the latest release
+func LatestRelease(ctx context.Context) (*Release, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", releasesURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("check for updates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("check for updates: unexpected status %d", resp.StatusCode)
+	}
+
+	var release Release
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("decode release: %w", err)
+	}
+	release.CheckedAt = time.Now()
+	return &release, nil
+}
+
+// CachedLatestRelease is LatestRelease, asking GitHub at most once a day.
+// The answer is kept in update_check.json in the config directory.
+func CachedLatestRelease(ctx context.Context) (*Release, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("get config dir: %w", err)
+	}
+	path := filepath.Join(configDir, "update_check.json")
+
+	if data, err := os.ReadFile(path); err == nil {
+		var cached Release
+		if json.Unmarshal(data, &cached) == nil && time.Since(cached.CheckedAt) < checkInterval {
+			return &cached, nil
+		}
+	}
+
+	release, err := LatestRelease(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(release); err == nil {
+		os.WriteFile(path, data, 0644)
+	}
+	return release, nil
+}
+
+// pseudoVersion matches the versions Go stamps on untagged commits, such as
+// v0.0.0-20261014131654-34937dc236b3
+var pseudoVersion = regexp.MustCompile(`\d{14}-[0-9a-f]{12}`)
+
+// Newer reports whether release tag latest is a later version than current.
+// Development builds, including untagged commits, are never out of date.
+func Newer(latest, current string) bool {
+	if pseudoVersion.MatchString(current) {
+		return false
+	}
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	c, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
+
+// parseVersion reads vMAJOR.MINOR.PATCH, ignoring pre-release and build suffixes
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	fields := strings.Split(v, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
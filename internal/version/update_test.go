@@ -0,0 +1,25 @@
+package version
+
+import "testing"
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		latest, current string
+		want            bool
+	}{
+		{"v0.3.0", "v0.2.9", true},
+		{"v0.10.0", "v0.9.0", true},
+		{"v1.0.0", "v1.0.0", false},
+		{"v1.0.0", "v1.0.1", false},
+		{"v1.1", "v1.0.5", true},
+		{"v1.0.1", "v1.0.1-rc1", false},
+		{"v1.0.0", "dev", false},
+		{"v1.0.0", "v0.0.0-20261014131654-34937dc236b3+dirty", false},
+		{"nightly", "v1.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.latest, tt.current); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.latest, tt.current, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Package version describes the running build and checks GitHub for newer
+// releases.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Version is set when building a release:
+//
+//	go build -ldflags "-X github.com/LaPingvino/llemecode/internal/version.Version=v0.3.0" ./cmd/llemecode
+var Version = "dev"
+
+// Info is what is known about the running binary
+type Info struct {
+	Version   string
+	Commit    string // Short VCS revision, empty if unknown
+	Date      string // Commit time
+	Modified  bool   // Built from a tree with uncommitted changes
+	GoVersion string
+}
+
+// Get collects the version from the build flags and the build info Go
+// embeds in the binary. go install ...@v0.3.0 sets the module version even
+// without -ldflags.
+func Get() Info {
+	info := Info{Version: Version, GoVersion: runtime.Version()}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if info.Version == "dev" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		info.Version = bi.Main.Version
+	}
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Commit = setting.Value
+			if len(info.Commit) > 12 {
+				info.Commit = info.Commit[:12]
+			}
+		case "vcs.time":
+			info.Date = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+func (i Info) String() string {
+	s := "llemecode " + i.Version
+	if i.Commit != "" {
+		s += " (commit " + i.Commit
+		if i.Modified {
+			s += ", modified"
+		}
+		if i.Date != "" {
+			s += ", " + i.Date
+		}
+		s += ")"
+	}
+	return s + fmt.Sprintf("\n%s %s/%s", i.GoVersion, runtime.GOOS, runtime.GOARCH)
+}
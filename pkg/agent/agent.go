@@ -0,0 +1,282 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultMaxIterations is how many model replies one Run may take before it
+// gives up, the limit the llemecode CLI uses too
+const DefaultMaxIterations = 10
+
+// ErrMaxIterations is returned when the model keeps calling tools past the
+// iteration limit
+var ErrMaxIterations = errors.New("max iterations reached without completion")
+
+// Message is one entry in a conversation
+type Message struct {
+	Role      string // "system", "user", "assistant" or "tool"
+	Content   string
+	ToolName  string     // Set on tool results
+	ToolCalls []ToolCall // Set on assistant messages that call tools
+}
+
+// ToolCall is a request from the model to run a tool
+type ToolCall struct {
+	Name string
+	Args map[string]interface{}
+}
+
+// ToolSpec describes a tool to the model
+type ToolSpec struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{} // JSON schema
+}
+
+// ChatRequest is what a Provider is asked to answer
+type ChatRequest struct {
+	Model    string
+	Messages []Message
+	Tools    []ToolSpec
+}
+
+// Provider talks to a language model. onToken, when not nil, is called with
+// each piece of the reply as it streams; the returned message is the whole
+// reply.
+type Provider interface {
+	Chat(ctx context.Context, req ChatRequest, onToken func(token string)) (Message, error)
+}
+
+// Tool is something the model can call. It has the same method set as the
+// llemecode built-in tools, see StandardTools.
+type Tool interface {
+	Name() string
+	Description() string
+	Parameters() map[string]interface{}
+	Execute(ctx context.Context, args map[string]interface{}) (string, error)
+}
+
+// PermissionChecker decides whether a tool call may run. A refusal is
+// returned to the model as the tool's error, so it can try something else.
+type PermissionChecker interface {
+	Allow(ctx context.Context, call ToolCall) error
+}
+
+// PermissionFunc adapts a function to a PermissionChecker
+type PermissionFunc func(ctx context.Context, call ToolCall) error
+
+func (f PermissionFunc) Allow(ctx context.Context, call ToolCall) error {
+	return f(ctx, call)
+}
+
+// EventType tells what an Event reports
+type EventType int
+
+const (
+	EventToken     EventType = iota // Text holds the next piece of the reply
+	EventToolStart                  // Call is about to run
+	EventToolEnd                    // Call finished with Result or Err, taking Duration
+	EventDone                       // Text holds the final reply
+)
+
+// Event is something that happened during a Run
+type Event struct {
+	Type      EventType
+	Iteration int
+	Text      string
+	Call      ToolCall
+	Result    string
+	Err       error
+	Duration  time.Duration
+}
+
+// EventSink receives events as a Run progresses. It is called from the
+// goroutine that called Run.
+type EventSink interface {
+	Event(e Event)
+}
+
+// EventFunc adapts a function to an EventSink
+type EventFunc func(e Event)
+
+func (f EventFunc) Event(e Event) {
+	f(e)
+}
+
+// Config sets up an Agent. Provider and Model are required.
+type Config struct {
+	Provider      Provider
+	Model         string
+	SystemPrompt  string
+	Tools         []Tool
+	Permissions   PermissionChecker // Nil allows every call
+	Events        EventSink         // Nil drops events
+	MaxIterations int               // 0 means DefaultMaxIterations
+
+	// ToolCallFormat is how the model calls tools, see FormatNative; empty
+	// means native. Other formats don't send the tools with the request,
+	// so SystemPrompt has to describe them and the format.
+	ToolCallFormat string
+}
+
+// Agent runs the loop of asking the model, running the tools it calls and
+// handing back their results until it answers. Runs on one Agent share a
+// conversation and must not overlap.
+type Agent struct {
+	cfg      Config
+	tools    map[string]Tool
+	specs    []ToolSpec
+	mu       sync.Mutex
+	messages []Message
+}
+
+func New(cfg Config) (*Agent, error) {
+	if cfg.Provider == nil {
+		return nil, fmt.Errorf("agent: no provider")
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("agent: no model")
+	}
+	if cfg.MaxIterations <= 0 {
+		cfg.MaxIterations = DefaultMaxIterations
+	}
+	switch cfg.ToolCallFormat {
+	case "":
+		cfg.ToolCallFormat = FormatNative
+	case FormatNative, FormatXML, FormatJSON, FormatText:
+	default:
+		return nil, fmt.Errorf("agent: unknown tool call format %q", cfg.ToolCallFormat)
+	}
+
+	a := &Agent{cfg: cfg, tools: make(map[string]Tool)}
+	for _, tool := range cfg.Tools {
+		if _, dup := a.tools[tool.Name()]; dup {
+			return nil, fmt.Errorf("agent: tool %s given twice", tool.Name())
+		}
+		a.tools[tool.Name()] = tool
+		a.specs = append(a.specs, ToolSpec{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			Parameters:  tool.Parameters(),
+		})
+	}
+	a.Reset()
+	return a, nil
+}
+
+// Run sends a user message and returns the model's final reply. A Run that
+// ends early, e.g. because ctx is cancelled, answers the calls it didn't get
+// to, so the conversation can go on.
+func (a *Agent) Run(ctx context.Context, userMessage string) (string, error) {
+	a.append(Message{Role: "user", Content: userMessage})
+
+	var specs []ToolSpec
+	if a.cfg.ToolCallFormat == FormatNative {
+		specs = a.specs
+	}
+	iterations := 0
+	loop := Loop{
+		Format:        a.cfg.ToolCallFormat,
+		MaxIterations: a.cfg.MaxIterations,
+		Reply: func(ctx context.Context, iteration int) (Message, error) {
+			iterations = iteration
+			reply, err := a.cfg.Provider.Chat(ctx, ChatRequest{
+				Model:    a.cfg.Model,
+				Messages: a.Messages(),
+				Tools:    specs,
+			}, func(token string) {
+				a.emit(Event{Type: EventToken, Iteration: iteration, Text: token})
+			})
+			if err != nil {
+				return Message{}, fmt.Errorf("chat request: %w", err)
+			}
+			reply.Role = "assistant"
+			a.append(reply)
+			return reply, nil
+		},
+		RunTool: func(ctx context.Context, iteration int, call ToolCall) error {
+			a.runTool(ctx, iteration, call)
+			return nil
+		},
+		Skip: func(call ToolCall, err error) {
+			a.append(Message{Role: "tool", ToolName: call.Name, Content: fmt.Sprintf("Not run: %v", err)})
+		},
+	}
+
+	reply, err := loop.Run(ctx)
+	if err != nil {
+		return "", err
+	}
+	a.emit(Event{Type: EventDone, Iteration: iterations, Text: reply.Content})
+	return reply.Content, nil
+}
+
+func (a *Agent) runTool(ctx context.Context, iteration int, call ToolCall) {
+	a.emit(Event{Type: EventToolStart, Iteration: iteration, Call: call})
+
+	start := time.Now()
+	result, err := a.execute(ctx, call)
+	duration := time.Since(start)
+
+	a.emit(Event{Type: EventToolEnd, Iteration: iteration, Call: call, Result: result, Err: err, Duration: duration})
+
+	msg := Message{Role: "tool", ToolName: call.Name, Content: result}
+	if err != nil {
+		msg.Content = fmt.Sprintf("Error executing tool %s: %v", call.Name, err)
+	}
+	a.append(msg)
+}
+
+func (a *Agent) execute(ctx context.Context, call ToolCall) (string, error) {
+	tool, ok := a.tools[call.Name]
+	if !ok {
+		return "", fmt.Errorf("tool not found: %s", call.Name)
+	}
+	if a.cfg.Permissions != nil {
+		if err := a.cfg.Permissions.Allow(ctx, call); err != nil {
+			return "", fmt.Errorf("permission denied: %w", err)
+		}
+	}
+	return tool.Execute(ctx, call.Args)
+}
+
+func (a *Agent) emit(e Event) {
+	if a.cfg.Events != nil {
+		a.cfg.Events.Event(e)
+	}
+}
+
+func (a *Agent) append(msg Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.messages = append(a.messages, msg)
+}
+
+// Messages returns a copy of the conversation so far, starting with the
+// system prompt if there is one
+func (a *Agent) Messages() []Message {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Message(nil), a.messages...)
+}
+
+// Restore replaces the conversation, e.g. with one saved from Messages
+func (a *Agent) Restore(messages []Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.messages = append([]Message(nil), messages...)
+}
+
+// Reset starts a new conversation, keeping the system prompt
+func (a *Agent) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.messages = nil
+	if a.cfg.SystemPrompt != "" {
+		a.messages = append(a.messages, Message{Role: "system", Content: a.cfg.SystemPrompt})
+	}
+}
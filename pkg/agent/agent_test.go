@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// echo is a tool that returns its text argument
+type echo struct{}
+
+func (echo) Name() string                       { return "echo" }
+func (echo) Description() string                { return "Return the text" }
+func (echo) Parameters() map[string]interface{} { return map[string]interface{}{"type": "object"} }
+func (echo) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	text, _ := args["text"].(string)
+	return text, nil
+}
+
+// replies is a provider that gives its replies in turn, recording what it
+// was asked
+type replies struct {
+	replies  []Message
+	requests []ChatRequest
+}
+
+func (p *replies) Chat(ctx context.Context, req ChatRequest, onToken func(string)) (Message, error) {
+	p.requests = append(p.requests, req)
+	reply := p.replies[0]
+	p.replies = p.replies[1:]
+	return reply, nil
+}
+
+func TestRunToolCallFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		reply  string
+	}{
+		{FormatXML, "Let me check.\n<tool_call>\n<name>echo</name>\n<arguments>{\"text\": \"hi\"}</arguments>\n</tool_call>"},
+		{FormatJSON, "Let me check.\n```json\n{\"tool_call\": {\"name\": \"echo\", \"arguments\": {\"text\": \"hi\"}}}\n```"},
+		{FormatText, "Let me check.\nUSE_TOOL: echo\nARGS: {\"text\": \"hi\"}"},
+	}
+
+	for _, tt := range tests {
+		provider := &replies{replies: []Message{{Content: tt.reply}, {Content: "done"}}}
+		a, err := New(Config{Provider: provider, Model: "m", Tools: []Tool{echo{}}, ToolCallFormat: tt.format})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		reply, err := a.Run(context.Background(), "go")
+		if err != nil || reply != "done" {
+			t.Fatalf("%s: Run = %q, %v", tt.format, reply, err)
+		}
+		msgs := a.Messages()
+		if last := msgs[len(msgs)-2]; last.Role != "tool" || last.Content != "hi" {
+			t.Errorf("%s: expected the echo result before the final reply, got %+v", tt.format, last)
+		}
+		if provider.requests[0].Tools != nil {
+			t.Errorf("%s: expected no native tools in the request", tt.format)
+		}
+	}
+
+	if _, err := New(Config{Provider: &replies{}, Model: "m", ToolCallFormat: "yaml"}); err == nil {
+		t.Error("Expected an unknown format to be refused")
+	}
+}
+
+// cancelling is a permission checker that cancels the run on the first call
+type cancelling struct {
+	cancel context.CancelFunc
+}
+
+func (c cancelling) Allow(ctx context.Context, call ToolCall) error {
+	c.cancel()
+	return nil
+}
+
+func TestRunCancelledAnswersEveryCall(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := []ToolCall{
+		{Name: "echo", Args: map[string]interface{}{"text": "one"}},
+		{Name: "echo", Args: map[string]interface{}{"text": "two"}},
+		{Name: "echo", Args: map[string]interface{}{"text": "three"}},
+	}
+	provider := &replies{replies: []Message{{ToolCalls: calls}}}
+	a, err := New(Config{Provider: provider, Model: "m", Tools: []Tool{echo{}}, Permissions: cancelling{cancel}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := a.Run(ctx, "go"); err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	var results []Message
+	for _, msg := range a.Messages() {
+		if msg.Role == "tool" {
+			results = append(results, msg)
+		}
+	}
+	if len(results) != len(calls) {
+		t.Fatalf("Expected a result for each of %d calls, got %+v", len(calls), results)
+	}
+	if results[0].Content != "one" || !strings.HasPrefix(results[1].Content, "Not run") || !strings.HasPrefix(results[2].Content, "Not run") {
+		t.Errorf("Expected the first call to run and the others to be skipped, got %+v", results)
+	}
+}
@@ -0,0 +1,38 @@
+// Package agent embeds the llemecode agent loop in other Go programs,
+// without the CLI or TUI.
+//
+// An Agent sends the conversation to a Provider, runs the tools the model
+// calls and feeds their results back until the model answers. Providers,
+// tools, permission checks and event sinks are small interfaces, so any of
+// them can be replaced:
+//
+//	a, err := agent.New(agent.Config{
+//		Provider:     agent.NewOllamaProvider(""),
+//		Model:        "qwen2.5-coder:7b",
+//		SystemPrompt: "You are a careful coding assistant.",
+//		Tools:        agent.StandardTools(),
+//		Permissions: agent.PermissionFunc(func(ctx context.Context, call agent.ToolCall) error {
+//			if call.Name == "run_command" {
+//				return errors.New("commands are not allowed here")
+//			}
+//			return nil
+//		}),
+//		Events: agent.EventFunc(func(e agent.Event) {
+//			if e.Type == agent.EventToolEnd {
+//				log.Printf("%s took %s", e.Call.Name, e.Duration)
+//			}
+//		}),
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	reply, err := a.Run(ctx, "What does main.go do?")
+//
+// Runs on the same Agent continue one conversation; use Reset to start over.
+//
+// Models without native tool calling write their calls in the reply text;
+// set Config.ToolCallFormat to FormatXML, FormatJSON or FormatText and
+// describe the tools and the format in the system prompt. Callers that keep
+// the conversation in types of their own, like the llemecode CLI, run a Loop
+// instead of an Agent.
+package agent
@@ -0,0 +1,64 @@
+package agent_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/LaPingvino/llemecode/pkg/agent"
+)
+
+// shout is a tool that upper-cases its text argument
+type shout struct{}
+
+func (shout) Name() string        { return "shout" }
+func (shout) Description() string { return "Upper-case a text" }
+func (shout) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"text": map[string]interface{}{"type": "string"},
+		},
+		"required": []string{"text"},
+	}
+}
+func (shout) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	text, _ := args["text"].(string)
+	return strings.ToUpper(text), nil
+}
+
+// scripted is a provider that calls shout once and then repeats its result
+type scripted struct{}
+
+func (scripted) Chat(ctx context.Context, req agent.ChatRequest, onToken func(string)) (agent.Message, error) {
+	last := req.Messages[len(req.Messages)-1]
+	if last.Role == "tool" {
+		return agent.Message{Content: "The tool said " + last.Content}, nil
+	}
+	return agent.Message{ToolCalls: []agent.ToolCall{{Name: "shout", Args: map[string]interface{}{"text": last.Content}}}}, nil
+}
+
+func Example() {
+	a, err := agent.New(agent.Config{
+		Provider: scripted{}, // agent.NewOllamaProvider("") for a real model
+		Model:    "any",
+		Tools:    []agent.Tool{shout{}},
+		Events: agent.EventFunc(func(e agent.Event) {
+			if e.Type == agent.EventToolStart {
+				fmt.Println("calling", e.Call.Name)
+			}
+		}),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	reply, err := a.Run(context.Background(), "hello")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(reply)
+	// Output:
+	// calling shout
+	// The tool said HELLO
+}
@@ -0,0 +1,133 @@
+package agent
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Tool call formats: how a model asks for tools. Models without native tool
+// support are told in the system prompt to write calls in their reply text
+// in one of the other formats.
+const (
+	FormatNative = "native" // The provider returns calls in Message.ToolCalls
+	FormatXML    = "xml"    // <tool_call><name>...</name><arguments>{...}</arguments></tool_call>
+	FormatJSON   = "json"   // A ```json block holding {"tool_call": {"name": ..., "arguments": {...}}}
+	FormatText   = "text"   // A USE_TOOL: <name> line followed by an ARGS: <json> line
+)
+
+// Patterns calls in the xml and json formats are found with
+var (
+	XMLToolCallPattern = regexp.MustCompile(`(?s)<tool_call>(.*?)</tool_call>`)
+	XMLNamePattern     = regexp.MustCompile(`<name>(.*?)</name>`)
+	XMLArgsPattern     = regexp.MustCompile(`(?s)<arguments>(.*?)</arguments>`)
+	JSONBlockPattern   = regexp.MustCompile("(?s)```json\\s*\\n(.*?)\\n```")
+)
+
+// FindToolCalls returns the tool calls in reply: the native ones if there
+// are any, otherwise those written in its text in format
+func FindToolCalls(format string, reply Message) []ToolCall {
+	if len(reply.ToolCalls) > 0 {
+		return reply.ToolCalls
+	}
+	switch format {
+	case FormatXML:
+		return parseXMLToolCalls(reply.Content)
+	case FormatJSON:
+		return parseJSONToolCalls(reply.Content)
+	case FormatText:
+		return parseTextToolCalls(reply.Content)
+	}
+	return nil
+}
+
+func parseXMLToolCalls(content string) []ToolCall {
+	var toolCalls []ToolCall
+
+	for _, match := range XMLToolCallPattern.FindAllStringSubmatch(content, -1) {
+		if len(match) < 2 {
+			continue
+		}
+		toolCallContent := match[1]
+
+		nameMatch := XMLNamePattern.FindStringSubmatch(toolCallContent)
+		if len(nameMatch) < 2 {
+			continue
+		}
+		name := strings.TrimSpace(nameMatch[1])
+
+		var args map[string]interface{}
+		if argsMatch := XMLArgsPattern.FindStringSubmatch(toolCallContent); len(argsMatch) >= 2 {
+			// Arguments that aren't valid JSON become no arguments
+			json.Unmarshal([]byte(strings.TrimSpace(argsMatch[1])), &args)
+		}
+		if args == nil {
+			args = make(map[string]interface{})
+		}
+
+		toolCalls = append(toolCalls, ToolCall{Name: name, Args: args})
+	}
+
+	return toolCalls
+}
+
+func parseJSONToolCalls(content string) []ToolCall {
+	var toolCalls []ToolCall
+
+	for _, match := range JSONBlockPattern.FindAllStringSubmatch(content, -1) {
+		if len(match) < 2 {
+			continue
+		}
+
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(match[1]), &data); err != nil {
+			continue
+		}
+
+		toolCallData, ok := data["tool_call"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, ok := toolCallData["name"].(string)
+		if !ok {
+			continue
+		}
+		args, ok := toolCallData["arguments"].(map[string]interface{})
+		if !ok || args == nil {
+			args = make(map[string]interface{})
+		}
+
+		toolCalls = append(toolCalls, ToolCall{Name: name, Args: args})
+	}
+
+	return toolCalls
+}
+
+func parseTextToolCalls(content string) []ToolCall {
+	var toolCalls []ToolCall
+
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "USE_TOOL:") || i+1 >= len(lines) {
+			continue
+		}
+		nextLine := strings.TrimSpace(lines[i+1])
+		if !strings.HasPrefix(nextLine, "ARGS:") {
+			continue
+		}
+		name := strings.TrimSpace(strings.TrimPrefix(line, "USE_TOOL:"))
+
+		var args map[string]interface{}
+		// Arguments that aren't valid JSON become no arguments
+		json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(nextLine, "ARGS:"))), &args)
+		if args == nil {
+			args = make(map[string]interface{})
+		}
+
+		toolCalls = append(toolCalls, ToolCall{Name: name, Args: args})
+		i++ // Skip the ARGS line
+	}
+
+	return toolCalls
+}
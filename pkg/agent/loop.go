@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"context"
+)
+
+// Loop is the agent loop for callers that keep the conversation in types of
+// their own: Agent.Run is a Loop over a []Message, and the llemecode CLI
+// runs one over its Ollama history. Reply and RunTool are required.
+type Loop struct {
+	Format        string // How the model writes tool calls, see FormatNative; empty means native
+	MaxIterations int    // 0 means DefaultMaxIterations
+
+	// Reply asks the model to answer the conversation so far and adds the
+	// reply to it
+	Reply func(ctx context.Context, iteration int) (Message, error)
+
+	// Found, when not nil, is told which calls were found in each reply
+	Found func(iteration int, reply Message, calls []ToolCall)
+
+	// RunTool runs a call and adds its result to the conversation. An error
+	// means the call didn't run and ends the turn.
+	RunTool func(ctx context.Context, iteration int, call ToolCall) error
+
+	// Skip, when not nil, adds a result for a call that won't run because
+	// the turn ended with err, so no call in the conversation goes without
+	// an answer
+	Skip func(call ToolCall, err error)
+}
+
+// Run asks for replies and runs the tools they call until the model answers
+// without calling any, and returns that answer
+func (l Loop) Run(ctx context.Context) (Message, error) {
+	maxIterations := l.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = DefaultMaxIterations
+	}
+
+	for i := 0; i < maxIterations; i++ {
+		reply, err := l.Reply(ctx, i)
+		if err != nil {
+			return Message{}, err
+		}
+
+		calls := FindToolCalls(l.Format, reply)
+		if l.Found != nil {
+			l.Found(i, reply, calls)
+		}
+		if len(calls) == 0 {
+			return reply, nil
+		}
+
+		for j, call := range calls {
+			err := ctx.Err()
+			if err == nil {
+				err = l.RunTool(ctx, i, call)
+			}
+			if err != nil {
+				if l.Skip != nil {
+					for _, skipped := range calls[j:] {
+						l.Skip(skipped, err)
+					}
+				}
+				return Message{}, err
+			}
+		}
+	}
+	return Message{}, ErrMaxIterations
+}
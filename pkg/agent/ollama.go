@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/LaPingvino/llemecode/internal/ollama"
+)
+
+// OllamaProvider is a Provider backed by an Ollama server, using the model's
+// native tool calling
+type OllamaProvider struct {
+	client *ollama.Client
+}
+
+// NewOllamaProvider connects to the Ollama server at baseURL; an empty URL
+// means http://localhost:11434
+func NewOllamaProvider(baseURL string) *OllamaProvider {
+	return &OllamaProvider{client: ollama.NewClient(baseURL)}
+}
+
+func (p *OllamaProvider) Chat(ctx context.Context, req ChatRequest, onToken func(token string)) (Message, error) {
+	ollamaReq := ollama.ChatRequest{Model: req.Model}
+	for _, msg := range req.Messages {
+		ollamaReq.Messages = append(ollamaReq.Messages, toOllama(msg))
+	}
+	for _, spec := range req.Tools {
+		ollamaReq.Tools = append(ollamaReq.Tools, ollama.Tool{
+			Type: "function",
+			Function: ollama.ToolFunction{
+				Name:        spec.Name,
+				Description: spec.Description,
+				Parameters:  spec.Parameters,
+			},
+		})
+	}
+
+	var resp *ollama.ChatResponse
+	var err error
+	if onToken != nil {
+		resp, err = p.client.ChatStream(ctx, ollamaReq, onToken)
+	} else {
+		resp, err = p.client.Chat(ctx, ollamaReq)
+	}
+	if err != nil {
+		return Message{}, err
+	}
+	return fromOllama(resp.Message), nil
+}
+
+func toOllama(msg Message) ollama.Message {
+	out := ollama.Message{Role: msg.Role, Content: msg.Content, ToolName: msg.ToolName}
+	for _, call := range msg.ToolCalls {
+		out.ToolCalls = append(out.ToolCalls, ollama.ToolCall{
+			Function: ollama.ToolCallFunction{Name: call.Name, Arguments: call.Args},
+		})
+	}
+	return out
+}
+
+func fromOllama(msg ollama.Message) Message {
+	out := Message{Role: msg.Role, Content: msg.Content, ToolName: msg.ToolName}
+	for _, call := range msg.ToolCalls {
+		out.ToolCalls = append(out.ToolCalls, ToolCall{Name: call.Function.Name, Args: call.Function.Arguments})
+	}
+	return out
+}
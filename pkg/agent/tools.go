@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+
+	"github.com/LaPingvino/llemecode/internal/tools"
+)
+
+// StandardTools returns the llemecode file, command and web tools:
+// read_file, write_file, list_files, run_command and web_fetch. They act on
+// the process's working directory without asking, so pair them with a
+// PermissionChecker when the model is not trusted.
+func StandardTools() []Tool {
+	bash := tools.NewBashTool()
+	bash.SetExecutor(shellExecutor{})
+	versions := tools.NewFileVersions()
+	read := tools.NewReadFileTool()
+	read.SetFileVersions(versions)
+	write := tools.NewWriteFileTool()
+	write.SetFileVersions(versions)
+	return []Tool{
+		read,
+		write,
+		tools.NewListFilesTool(),
+		bash,
+		tools.NewWebFetchTool(),
+	}
+}
+
+// shellExecutor runs run_command without a terminal
+type shellExecutor struct{}
+
+func (shellExecutor) Execute(ctx context.Context, command string) (string, int, error) {
+	out, err := exec.CommandContext(ctx, "bash", "-c", command).CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(out), exitErr.ExitCode(), err
+	}
+	return string(out), 0, err
+}